@@ -53,6 +53,7 @@ type format_1_18Serialization struct {
 	StatePort       int    `yaml:",omitempty"`
 	SharedSecret    string `yaml:",omitempty"`
 	SystemIdentity  string `yaml:",omitempty"`
+	CAPrivateKey    string `yaml:",omitempty"`
 }
 
 func init() {
@@ -117,6 +118,7 @@ func (formatter_1_18) unmarshal(data []byte) (*configInternal, error) {
 			StatePort:      format.StatePort,
 			SharedSecret:   format.SharedSecret,
 			SystemIdentity: format.SystemIdentity,
+			CAPrivateKey:   format.CAPrivateKey,
 		}
 		// There's a private key, then we need the state port,
 		// which wasn't always in the  1.18 format. If it's not present
@@ -161,6 +163,7 @@ func (formatter_1_18) marshal(config *configInternal) ([]byte, error) {
 		format.StatePort = config.servingInfo.StatePort
 		format.SharedSecret = config.servingInfo.SharedSecret
 		format.SystemIdentity = config.servingInfo.SystemIdentity
+		format.CAPrivateKey = config.servingInfo.CAPrivateKey
 	}
 	if config.stateDetails != nil {
 		format.StateAddresses = config.stateDetails.addresses
@@ -85,10 +85,11 @@ func (*formatSuite) TestRead(c *gc.C) {
 
 func (*formatSuite) TestReadWriteStateConfig(c *gc.C) {
 	servingInfo := state.StateServingInfo{
-		Cert:       "some special cert",
-		PrivateKey: "a special key",
-		StatePort:  12345,
-		APIPort:    23456,
+		Cert:         "some special cert",
+		PrivateKey:   "a special key",
+		StatePort:    12345,
+		APIPort:      23456,
+		CAPrivateKey: "a special CA key",
 	}
 	params := agentParams
 	params.DataDir = c.MkDir()
@@ -60,6 +60,7 @@ func paramsStateServingInfoToStateStateServingInfo(si *params.StateServingInfo)
 		PrivateKey:     si.PrivateKey,
 		SharedSecret:   si.SharedSecret,
 		SystemIdentity: si.SystemIdentity,
+		CAPrivateKey:   si.CAPrivateKey,
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"time"
 
@@ -129,12 +130,10 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	if len(info.Addrs) == 0 {
 		return nil, fmt.Errorf("no API addresses to connect to")
 	}
-	pool := x509.NewCertPool()
-	xcert, err := cert.ParseCert(info.CACert)
+	pool, err := cert.ParseCertPool(info.CACert)
 	if err != nil {
 		return nil, err
 	}
-	pool.AddCert(xcert)
 
 	var environUUID string
 	if info.EnvironTag != nil {
@@ -229,12 +228,39 @@ func setUpWebsocket(addr, environUUID string, rootCAs *x509.CertPool) (*websocke
 		return nil, err
 	}
 	cfg.TlsConfig = &tls.Config{
-		RootCAs:    rootCAs,
-		ServerName: "anything",
+		RootCAs: rootCAs,
 	}
 	return cfg, nil
 }
 
+// dialWebsocketConfig opens the websocket described by cfg. Unlike
+// websocket.DialConfig, it verifies the server certificate against
+// the host name dialled with cert.VerifyHost, using the CA
+// certificates in cfg.TlsConfig.RootCAs.
+func dialWebsocketConfig(cfg *websocket.Config) (*websocket.Conn, error) {
+	addr := cfg.Location.Host
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	// The server certificate is verified after the handshake.
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	peerCerts := conn.ConnectionState().PeerCertificates
+	if err := cert.VerifyHost(peerCerts, host, cfg.TlsConfig.RootCAs, time.Now()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ws, err := websocket.NewClient(cfg, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
 // newWebsocketDialer returns a function that
 // can be passed to utils/parallel.Try.Start.
 func newWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan struct{}) (io.Closer, error) {
@@ -250,7 +276,7 @@ func newWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan struct
 			default:
 			}
 			logger.Infof("dialing %q", cfg.Location)
-			conn, err := websocket.DialConfig(cfg)
+			conn, err := dialWebsocketConfig(cfg)
 			if err == nil {
 				return conn, nil
 			}
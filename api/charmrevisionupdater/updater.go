@@ -31,3 +31,27 @@ func (st *State) UpdateLatestRevisions() error {
 	}
 	return nil
 }
+
+// errUpgradeCharmsNotImplemented is returned when the state server is
+// too old to upgrade charms automatically.
+var errUpgradeCharmsNotImplemented = &params.Error{
+	Code:    params.CodeNotImplemented,
+	Message: "automatic charm upgrades not implemented",
+}
+
+// UpgradeCharms upgrades the charms of services whose upgrade policy
+// allows it to the latest revisions recorded by UpdateLatestRevisions.
+func (st *State) UpgradeCharms() error {
+	if st.facade.BestAPIVersion() < 1 {
+		return errUpgradeCharmsNotImplemented
+	}
+	result := new(params.ErrorResult)
+	err := st.facade.FacadeCall("UpgradeCharms", nil, result)
+	if err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
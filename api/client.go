@@ -91,6 +91,8 @@ type ServiceStatus struct {
 	Relations     map[string][]string
 	Networks      NetworksSpecification
 	CanUpgradeTo  string
+	UpgradePolicy string
+	UpgradeWindow string
 	SubordinateTo []string
 	Units         map[string]UnitStatus
 }
@@ -357,6 +359,24 @@ func (c *Client) ServiceDeployWithNetworks(charmURL string, serviceName string,
 	return c.facade.FacadeCall("ServiceDeployWithNetworks", params, nil)
 }
 
+// ServiceDeployWithBindings works like ServiceDeployWithNetworks,
+// but also binds the charm's endpoints to spaces. The bindings map
+// endpoint names to space names; the empty endpoint name binds all
+// endpoints not otherwise listed.
+func (c *Client) ServiceDeployWithBindings(charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string, networks []string, bindings map[string]string) error {
+	params := params.ServiceDeploy{
+		ServiceName:      serviceName,
+		CharmUrl:         charmURL,
+		NumUnits:         numUnits,
+		ConfigYAML:       configYAML,
+		Constraints:      cons,
+		ToMachineSpec:    toMachineSpec,
+		Networks:         networks,
+		EndpointBindings: bindings,
+	}
+	return c.facade.FacadeCall("ServiceDeployWithBindings", params, nil)
+}
+
 // ServiceDeploy obtains the charm, either locally or from the charm store,
 // and deploys it.
 func (c *Client) ServiceDeploy(charmURL string, serviceName string, numUnits int, configYAML string, cons constraints.Value, toMachineSpec string) error {
@@ -388,6 +408,85 @@ func (c *Client) ServiceSetCharm(serviceName string, charmUrl string, force bool
 	return c.facade.FacadeCall("ServiceSetCharm", args, nil)
 }
 
+// ServiceSetCharmRolling sets the charm for a given service, and
+// upgrades its units batchSize units at a time. Each batch must
+// upgrade successfully before the next one is started.
+func (c *Client) ServiceSetCharmRolling(serviceName string, charmUrl string, force bool, batchSize int) error {
+	args := params.ServiceSetCharmRolling{
+		ServiceName: serviceName,
+		CharmUrl:    charmUrl,
+		Force:       force,
+		BatchSize:   batchSize,
+	}
+	return c.facade.FacadeCall("ServiceSetCharmRolling", args, nil)
+}
+
+// ServicePauseRollingUpgrade stops further units of the given service
+// from being upgraded by its rolling upgrade.
+func (c *Client) ServicePauseRollingUpgrade(serviceName string) error {
+	args := params.ServiceGet{ServiceName: serviceName}
+	return c.facade.FacadeCall("ServicePauseRollingUpgrade", args, nil)
+}
+
+// ServiceResumeRollingUpgrade resumes the paused rolling upgrade of
+// the given service.
+func (c *Client) ServiceResumeRollingUpgrade(serviceName string) error {
+	args := params.ServiceGet{ServiceName: serviceName}
+	return c.facade.FacadeCall("ServiceResumeRollingUpgrade", args, nil)
+}
+
+// ServiceRollingUpgradeStatus returns the progress of the rolling
+// upgrade of the given service.
+func (c *Client) ServiceRollingUpgradeStatus(serviceName string) (params.RollingUpgradeStatus, error) {
+	args := params.ServiceGet{ServiceName: serviceName}
+	var result params.RollingUpgradeStatus
+	err := c.facade.FacadeCall("ServiceRollingUpgradeStatus", args, &result)
+	return result, err
+}
+
+// ServiceSetUpgradePolicy sets the charm upgrade policy of the given
+// service. If window is not empty, automatic upgrades only start
+// within that daily maintenance window ("HH:MM-HH:MM", in UTC).
+func (c *Client) ServiceSetUpgradePolicy(serviceName, policy, window string) error {
+	args := params.ServiceSetUpgradePolicy{
+		ServiceName: serviceName,
+		Policy:      policy,
+		Window:      window,
+	}
+	return c.facade.FacadeCall("ServiceSetUpgradePolicy", args, nil)
+}
+
+// AutoCharmUpgrades returns the charm upgrades made automatically
+// because of the upgrade policy of the given service, or of any
+// service if serviceName is empty.
+func (c *Client) AutoCharmUpgrades(serviceName string) ([]params.AutoCharmUpgrade, error) {
+	args := params.ServiceGet{ServiceName: serviceName}
+	var result params.AutoCharmUpgradesResults
+	if err := c.facade.FacadeCall("AutoCharmUpgrades", args, &result); err != nil {
+		return nil, err
+	}
+	return result.Upgrades, nil
+}
+
+// ServiceSetScalingPolicy sets the policy by which the given service
+// is scaled automatically, or removes it if policy is nil.
+func (c *Client) ServiceSetScalingPolicy(serviceName string, policy *params.ScalingPolicy) error {
+	args := params.ServiceSetScalingPolicy{
+		ServiceName: serviceName,
+		Policy:      policy,
+	}
+	return c.facade.FacadeCall("ServiceSetScalingPolicy", args, nil)
+}
+
+// ServiceScaling returns the scaling policy of the given service, and
+// how it currently applies.
+func (c *Client) ServiceScaling(serviceName string) (params.ServiceScalingResult, error) {
+	args := params.ServiceGet{ServiceName: serviceName}
+	var result params.ServiceScalingResult
+	err := c.facade.FacadeCall("ServiceScaling", args, &result)
+	return result, err
+}
+
 // ServiceGetCharmURL returns the charm URL the given service is
 // running at present.
 func (c *Client) ServiceGetCharmURL(serviceName string) (*charm.URL, error) {
@@ -601,6 +700,31 @@ func (c *Client) Run(run params.RunParams) ([]params.RunResult, error) {
 	return results.Results, err
 }
 
+// RunAsync queues a job running the Commands specified on the targets
+// identified in run, and returns the job id. The progress of the job
+// can be retrieved with RunStatus.
+func (c *Client) RunAsync(run params.RunAsyncParams) (string, error) {
+	var result params.RunJobId
+	err := c.facade.FacadeCall("RunAsync", run, &result)
+	return result.JobId, err
+}
+
+// RunStatus returns the progress of the run job with the given id.
+func (c *Client) RunStatus(jobId string) (params.RunJobStatus, error) {
+	var result params.RunJobStatus
+	err := c.facade.FacadeCall("RunStatus", params.RunJobId{JobId: jobId}, &result)
+	return result, err
+}
+
+// Introspect asks the agents of the given machines and units the
+// given introspection query, returning the output of juju-introspect
+// on each agent's machine.
+func (c *Client) Introspect(args params.IntrospectParams) ([]params.RunResult, error) {
+	var results params.RunResults
+	err := c.facade.FacadeCall("Introspect", args, &results)
+	return results.Results, err
+}
+
 // DestroyEnvironment puts the environment into a "dying" state,
 // and removes all non-manager machine instances. DestroyEnvironment
 // will fail if there are any manually-provisioned non-manager machines
@@ -765,6 +889,45 @@ func (c *Client) UploadTools(r io.Reader, vers version.Binary) (*tools.Tools, er
 	return jsonResponse.Tools, nil
 }
 
+// AttachResource uploads the content read from r via the API server
+// as a new revision of the named resource of the given service.
+func (c *Client) AttachResource(service, name string, r io.Reader) (*params.ResourceInfo, error) {
+	// Prepare the upload request.
+	query := url.Values{"service": {service}, "name": {name}}
+	url := fmt.Sprintf("%s/resources?%s", c.st.serverRoot, query.Encode())
+	req, err := http.NewRequest("POST", url, r)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create upload request")
+	}
+	req.SetBasicAuth(c.st.tag, c.st.password)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	// Send the request. See AddLocalCharm for why we don't
+	// validate the server's certificate.
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot upload resource")
+	}
+	defer resp.Body.Close()
+
+	// Now parse the response & return.
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read resource upload response")
+	}
+	var jsonResponse params.ResourcesResponse
+	if err := json.Unmarshal(body, &jsonResponse); err != nil {
+		return nil, errors.Annotatef(err, "resource upload failed: %v (%s)", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if jsonResponse.Error != "" {
+		return nil, errors.Errorf("error uploading resource: %v", jsonResponse.Error)
+	}
+	if resp.StatusCode != http.StatusOK || jsonResponse.Resource == nil {
+		return nil, errors.Errorf("resource upload failed: %v (%s)", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return jsonResponse.Resource, nil
+}
+
 // APIHostPorts returns a slice of network.HostPort for each API server.
 func (c *Client) APIHostPorts() ([][]network.HostPort, error) {
 	var result params.APIHostPortsResult
@@ -798,6 +961,131 @@ func (c *Client) EnsureAvailability(numStateServers int, cons constraints.Value,
 	return result.Result, nil
 }
 
+// HighAvailabilityStatus reports on the state server machines and
+// their membership of the mongo replica set.
+func (c *Client) HighAvailabilityStatus() (params.HighAvailabilityStatus, error) {
+	var result params.HighAvailabilityStatus
+	err := c.facade.FacadeCall("HighAvailabilityStatus", nil, &result)
+	return result, err
+}
+
+// RemoveStateServer removes the state servers running on the machines
+// with the given tags, without destroying the machines.
+func (c *Client) RemoveStateServer(machines ...string) ([]params.ErrorResult, error) {
+	p := params.Entities{}
+	p.Entities = make([]params.Entity, len(machines))
+	for i, machine := range machines {
+		p.Entities[i] = params.Entity{Tag: machine}
+	}
+	var results params.ErrorResults
+	err := c.facade.FacadeCall("RemoveStateServer", p, &results)
+	return results.Results, err
+}
+
+// SwitchBlockOn switches on the environment block of the given type,
+// which is one of "destroy-environment", "remove-object" and
+// "all-changes". The message explains why the block is in place.
+func (c *Client) SwitchBlockOn(blockType, msg string) error {
+	args := params.BlockSwitchParams{Type: blockType, Message: msg}
+	return c.facade.FacadeCall("SwitchBlockOn", args, nil)
+}
+
+// SwitchBlockOff switches off the environment block of the given type.
+func (c *Client) SwitchBlockOff(blockType string) error {
+	args := params.BlockSwitchParams{Type: blockType}
+	return c.facade.FacadeCall("SwitchBlockOff", args, nil)
+}
+
+// ListBlocks returns the environment blocks that are switched on.
+func (c *Client) ListBlocks() ([]params.Block, error) {
+	var result params.BlockResults
+	err := c.facade.FacadeCall("ListBlocks", nil, &result)
+	return result.Blocks, err
+}
+
+// StorageGC removes the charm archives and tools tarballs that are
+// no longer used in the environment, returning the items removed. If
+// dryRun is true, the items are only reported.
+func (c *Client) StorageGC(dryRun bool) ([]params.StorageGCItem, error) {
+	args := params.StorageGCArgs{DryRun: dryRun}
+	var result params.StorageGCResult
+	err := c.facade.FacadeCall("StorageGC", args, &result)
+	return result.Items, err
+}
+
+// RotateCA starts replacing the environment's CA with a newly
+// generated one or, if finish is true, makes the new CA sign the
+// state server certificates.
+// It returns the CA certificates trusted by the environment
+// afterwards, in PEM format.
+func (c *Client) RotateCA(finish bool) (string, error) {
+	args := params.RotateCA{Finish: finish}
+	var result params.RotateCAResult
+	err := c.facade.FacadeCall("RotateCA", args, &result)
+	return result.CACert, err
+}
+
+// ImportCA starts replacing the environment's CA with the one with
+// the given certificate and private key, in PEM format. It returns
+// the CA certificates trusted by the environment afterwards.
+func (c *Client) ImportCA(caCert, caKey string) (string, error) {
+	args := params.RotateCA{
+		CACert:       caCert,
+		CAPrivateKey: caKey,
+	}
+	var result params.RotateCAResult
+	err := c.facade.FacadeCall("RotateCA", args, &result)
+	return result.CACert, err
+}
+
+// SetServerCertificate makes the state servers use the given
+// externally signed certificate and private key. The certificate,
+// which may be followed by intermediate certificates, must be valid
+// with respect to the given CA certificate, which becomes the only
+// CA certificate trusted by the environment. All values are in PEM
+// format.
+func (c *Client) SetServerCertificate(caCert, srvCert, srvKey string) error {
+	args := params.ServerCertificate{
+		CACert:     caCert,
+		Cert:       srvCert,
+		PrivateKey: srvKey,
+	}
+	return c.facade.FacadeCall("SetServerCertificate", args, nil)
+}
+
+// AddSubnet adds a subnet with the given CIDR to the environment.
+// The provider id, VLAN tag and availability zone are optional.
+func (c *Client) AddSubnet(cidr, providerId string, vlanTag int, zone string) error {
+	args := params.AddSubnet{
+		CIDR:             cidr,
+		ProviderId:       providerId,
+		VLANTag:          vlanTag,
+		AvailabilityZone: zone,
+	}
+	return c.facade.FacadeCall("AddSubnet", args, nil)
+}
+
+// ListSubnets returns all the subnets in the environment.
+func (c *Client) ListSubnets() ([]params.SubnetInfo, error) {
+	var result params.SubnetsResult
+	err := c.facade.FacadeCall("ListSubnets", nil, &result)
+	return result.Subnets, err
+}
+
+// AddSpace creates a space with the given name, containing the
+// subnets with the given CIDRs.
+func (c *Client) AddSpace(name string, subnets []string) error {
+	args := params.AddSpace{Name: name, Subnets: subnets}
+	return c.facade.FacadeCall("AddSpace", args, nil)
+}
+
+// ListSpaces returns all the spaces in the environment.
+func (c *Client) ListSpaces() ([]params.SpaceInfo, error) {
+	var result params.SpacesResult
+	err := c.facade.FacadeCall("ListSpaces", nil, &result)
+	return result.Spaces, err
+}
+
 // AgentVersion reports the version number of the api server.
 func (c *Client) AgentVersion() (version.Number, error) {
 	var result params.AgentVersionResult
@@ -807,10 +1095,10 @@ func (c *Client) AgentVersion() (version.Number, error) {
 	return result.Version, nil
 }
 
-// websocketDialConfig is called instead of websocket.DialConfig so we can
+// websocketDialConfig is called instead of dialWebsocketConfig so we can
 // override it in tests.
 var websocketDialConfig = func(config *websocket.Config) (io.ReadCloser, error) {
-	return websocket.DialConfig(config)
+	return dialWebsocketConfig(config)
 }
 
 // DebugLogParams holds parameters for WatchDebugLog that control the
@@ -882,15 +1170,29 @@ func (c *Client) WatchDebugLog(args DebugLogParams) (io.ReadCloser, error) {
 	attrs["excludeEntity"] = args.ExcludeEntity
 	attrs["excludeModule"] = args.ExcludeModule
 
+	return c.openWebsocket("/log", attrs)
+}
+
+// WatchRunOutput returns a ReadCloser that the caller can read the
+// output of the run job with the given id from, as it runs. Each line
+// is prefixed with the id of the machine or unit that produced it, and
+// the stream ends once the job is done.
+func (c *Client) WatchRunOutput(jobId string) (io.ReadCloser, error) {
+	return c.openWebsocket("/runoutput", url.Values{"jobid": {jobId}})
+}
+
+// openWebsocket opens a websocket to the API server at the given path,
+// and returns it once the initial error response has been read from it.
+func (c *Client) openWebsocket(path string, attrs url.Values) (io.ReadCloser, error) {
 	target := url.URL{
 		Scheme:   "wss",
 		Host:     c.st.addr,
-		Path:     "/log",
+		Path:     path,
 		RawQuery: attrs.Encode(),
 	}
 	cfg, err := websocket.NewConfig(target.String(), "http://localhost/")
 	cfg.Header = utils.BasicAuthHeader(c.st.tag, c.st.password)
-	cfg.TlsConfig = &tls.Config{RootCAs: c.st.certPool, ServerName: "anything"}
+	cfg.TlsConfig = &tls.Config{RootCAs: c.st.certPool}
 	connection, err := websocketDialConfig(cfg)
 	if err != nil {
 		return nil, err
@@ -100,6 +100,44 @@ func (s *clientSuite) TestAddLocalCharmError(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "charm upload failed: 405 \\(Method Not Allowed\\)")
 }
 
+var resourcesMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+
+func (s *clientSuite) TestAttachAndOpenResource(c *gc.C) {
+	s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", resourcesMeta, 1))
+	client := s.APIState.Client()
+
+	info, err := client.AttachResource("app", "app", strings.NewReader("content"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Service, gc.Equals, "app")
+	c.Assert(info.Name, gc.Equals, "app")
+	c.Assert(info.Revision, gc.Equals, 1)
+	c.Assert(info.Size, gc.Equals, int64(7))
+
+	r, err := s.APIState.OpenResource("app", "app")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "content")
+}
+
+func (s *clientSuite) TestAttachResourceErrors(c *gc.C) {
+	s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", resourcesMeta, 1))
+	client := s.APIState.Client()
+
+	_, err := client.AttachResource("app", "other", strings.NewReader("content"))
+	c.Assert(err, gc.ErrorMatches, `error uploading resource: cannot set resource "other" for service "app": .* not found`)
+	_, err = s.APIState.OpenResource("app", "app")
+	c.Assert(err, gc.ErrorMatches, `cannot download resource "app": resource "app" for service "app" not found`)
+}
+
 func (s *clientSuite) TestClientEnvironmentUUID(c *gc.C) {
 	environ, err := s.State.Environment()
 	c.Assert(err, gc.IsNil)
@@ -193,6 +231,25 @@ func (s *clientSuite) TestParamsEncoded(c *gc.C) {
 	})
 }
 
+func (s *clientSuite) TestWatchRunOutputConnected(c *gc.C) {
+	client := s.APIState.Client()
+	reader, err := client.WatchRunOutput("42")
+	c.Assert(err, gc.ErrorMatches, `run job "42" not found`)
+	c.Assert(reader, gc.IsNil)
+}
+
+func (s *clientSuite) TestWatchRunOutputParamsEncoded(c *gc.C) {
+	s.PatchValue(api.WebsocketDialConfig, echoURL(c))
+
+	client := s.APIState.Client()
+	reader, err := client.WatchRunOutput("42")
+	c.Assert(err, gc.IsNil)
+
+	connectURL := connectURLFromReader(c, reader)
+	c.Assert(connectURL.Path, gc.Matches, "/runoutput")
+	c.Assert(connectURL.Query(), jc.DeepEquals, url.Values{"jobid": {"42"}})
+}
+
 func (s *clientSuite) TestDebugLogRootPath(c *gc.C) {
 	s.PatchValue(api.WebsocketDialConfig, echoURL(c))
 
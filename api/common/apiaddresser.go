@@ -38,7 +38,8 @@ func (a *APIAddresser) APIAddresses() ([]string, error) {
 	return result.Result, nil
 }
 
-// CACert returns the certificate used to validate the API and state connections.
+// CACert returns the certificates of the CAs trusted to sign the
+// certificates used to validate the API and state connections.
 func (a *APIAddresser) CACert() (string, error) {
 	var result params.BytesResult
 	err := a.facade.FacadeCall("CACert", nil, &result)
@@ -67,3 +68,13 @@ func (a *APIAddresser) WatchAPIHostPorts() (watcher.NotifyWatcher, error) {
 	}
 	return watcher.NewNotifyWatcher(a.facade.RawAPICaller(), result), nil
 }
+
+// WatchCACert watches the certificates of the trusted CAs.
+func (a *APIAddresser) WatchCACert() (watcher.NotifyWatcher, error) {
+	var result params.NotifyWatchResult
+	err := a.facade.FacadeCall("WatchCACert", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return watcher.NewNotifyWatcher(a.facade.RawAPICaller(), result), nil
+}
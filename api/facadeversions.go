@@ -21,16 +21,17 @@ var facadeVersions = map[string]int{
 	"Logger":               0,
 	"MetricsManager":       0,
 	"Pinger":               0,
-	"Provisioner":          0,
+	"Provisioner":          1,
 	"RelationUnitsWatcher": 0,
 	"UserManager":          0,
-	"CharmRevisionUpdater": 0,
+	"CharmRevisionUpdater": 1,
 	"Client":               0,
 	"NotifyWatcher":        0,
 	"Upgrader":             0,
 	"Firewaller":           0,
 	"Rsyslog":              0,
-	"Uniter":               0,
+	"RunTasks":             0,
+	"Uniter":               1,
 }
 
 // bestVersion tries to find the newest version in the version list that we can
@@ -67,7 +67,8 @@ func (u *Unit) Service() (*Service, error) {
 // OpenedPorts returns the list of opened ports for this unit.
 //
 // NOTE: This differs from state.Unit.OpenedPorts() by returning
-// an error as well, because it needs to make an API call.
+// individual ports rather than port ranges, because the firewaller
+// cannot handle port ranges yet.
 func (u *Unit) OpenedPorts() ([]network.Port, error) {
 	var results params.PortsResults
 	args := params.Entities{
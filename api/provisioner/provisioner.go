@@ -4,6 +4,8 @@
 package provisioner
 
 import (
+	"fmt"
+
 	"github.com/juju/names"
 
 	"github.com/juju/juju/api/base"
@@ -147,3 +149,58 @@ func (st *State) FindTools(v version.Number, series string, arch *string) (tools
 	}
 	return result.List, nil
 }
+
+// errAddressesNotImplemented is returned when the state server is too
+// old to allocate container addresses.
+var errAddressesNotImplemented = &params.Error{
+	Code:    params.CodeNotImplemented,
+	Message: "container address allocation not implemented",
+}
+
+// AllocateContainerAddress asks for an address on the host's network
+// to be allocated for the given container, and returns it with the
+// details needed to configure the container's network.
+func (st *State) AllocateContainerAddress(tag names.MachineTag) (params.ContainerAddressResult, error) {
+	if st.facade.BestAPIVersion() < 1 {
+		return params.ContainerAddressResult{}, errAddressesNotImplemented
+	}
+	var results params.ContainerAddressResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: tag.String()}},
+	}
+	if err := st.facade.FacadeCall("AllocateContainerAddresses", args, &results); err != nil {
+		return params.ContainerAddressResult{}, err
+	}
+	if len(results.Results) != 1 {
+		return params.ContainerAddressResult{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return params.ContainerAddressResult{}, result.Error
+	}
+	return result, nil
+}
+
+// ReleaseContainerAddresses releases all the addresses allocated to
+// the given container, and returns them. The addresses are returned
+// even if releasing them fails.
+func (st *State) ReleaseContainerAddresses(tag names.MachineTag) ([]string, error) {
+	if st.facade.BestAPIVersion() < 1 {
+		return nil, errAddressesNotImplemented
+	}
+	var results params.StringsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: tag.String()}},
+	}
+	if err := st.facade.FacadeCall("ReleaseContainerAddresses", args, &results); err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return result.Result, result.Error
+	}
+	return result.Result, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// OpenResource downloads the named resource attached to the given
+// service from the API server, using the credentials the connection
+// logged in with. The returned reader must be closed after use.
+func (s *State) OpenResource(service, name string) (io.ReadCloser, error) {
+	query := url.Values{"service": {service}, "name": {name}}
+	uri := fmt.Sprintf("%s/resources?%s", s.serverRoot, query.Encode())
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create download request")
+	}
+	req.SetBasicAuth(s.tag, s.password)
+
+	// See Client.AddLocalCharm for why we don't validate
+	// the server's certificate.
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot download resource %q", name)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot read resource download response")
+	}
+	var jsonResponse params.ResourcesResponse
+	if err := json.Unmarshal(body, &jsonResponse); err == nil && jsonResponse.Error != "" {
+		return nil, errors.Errorf("cannot download resource %q: %v", name, jsonResponse.Error)
+	}
+	return nil, errors.Errorf("resource download failed: %v (%s)", resp.StatusCode, bytes.TrimSpace(body))
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runtasks
+
+import (
+	"fmt"
+
+	"github.com/juju/names"
+
+	"github.com/juju/juju/api/base"
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/apiserver/params"
+)
+
+const runTasksFacade = "RunTasks"
+
+// State provides access to the run task worker's view of the state.
+type State struct {
+	facade base.FacadeCaller
+}
+
+// NewState returns a version of the state that provides functionality
+// required by the run task worker.
+func NewState(caller base.APICaller) *State {
+	return &State{base.NewFacadeCaller(caller, runTasksFacade)}
+}
+
+// WatchRunTasks returns a StringsWatcher notifying of the ids of the
+// run tasks waiting to be run by the agent of the given machine.
+func (st *State) WatchRunTasks(machineTag names.MachineTag) (watcher.StringsWatcher, error) {
+	var results params.StringsWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: machineTag.String()}},
+	}
+	err := st.facade.FacadeCall("WatchRunTasks", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewStringsWatcher(st.facade.RawAPICaller(), result)
+	return w, nil
+}
+
+// RunTasks returns the commands to run for each of the given tasks.
+func (st *State) RunTasks(ids []string) ([]params.RunTaskResult, error) {
+	var results params.RunTaskResults
+	args := params.RunTaskIds{Ids: ids}
+	err := st.facade.FacadeCall("RunTasks", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != len(ids) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(ids), len(results.Results))
+	}
+	return results.Results, nil
+}
+
+// StartRunTask records that the agent has started running the task
+// with the given id.
+func (st *State) StartRunTask(id string) error {
+	var results params.ErrorResults
+	args := params.RunTaskIds{Ids: []string{id}}
+	err := st.facade.FacadeCall("StartRunTasks", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// AppendRunTaskOutput records output written so far by the commands
+// of a running task.
+func (st *State) AppendRunTaskOutput(output params.RunTaskOutput) error {
+	var results params.ErrorResults
+	args := params.RunTaskOutputs{Outputs: []params.RunTaskOutput{output}}
+	err := st.facade.FacadeCall("AppendRunTaskOutput", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// FinishRunTask records the outcome of a running task.
+func (st *State) FinishRunTask(outcome params.RunTaskOutcome) error {
+	var results params.ErrorResults
+	args := params.RunTaskOutcomes{Outcomes: []params.RunTaskOutcome{outcome}}
+	err := st.facade.FacadeCall("FinishRunTasks", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
+
+// FailRunningTasks records that the tasks the agent of the given
+// machine was running have failed, because the agent restarted while
+// running them.
+func (st *State) FailRunningTasks(machineTag names.MachineTag) error {
+	var results params.ErrorResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: machineTag.String()}},
+	}
+	err := st.facade.FacadeCall("FailRunningTasks", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
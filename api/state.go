@@ -21,6 +21,7 @@ import (
 	"github.com/juju/juju/api/networker"
 	"github.com/juju/juju/api/provisioner"
 	"github.com/juju/juju/api/rsyslog"
+	"github.com/juju/juju/api/runtasks"
 	"github.com/juju/juju/api/uniter"
 	"github.com/juju/juju/api/upgrader"
 	"github.com/juju/juju/apiserver/params"
@@ -183,3 +184,8 @@ func (st *State) CharmRevisionUpdater() *charmrevisionupdater.State {
 func (st *State) Rsyslog() *rsyslog.State {
 	return rsyslog.NewState(st)
 }
+
+// RunTasks returns access to the RunTasks API
+func (st *State) RunTasks() *runtasks.State {
+	return runtasks.NewState(st)
+}
@@ -4,12 +4,16 @@
 package testing
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/cert"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	statetesting "github.com/juju/juju/state/testing"
+	coretesting "github.com/juju/juju/testing"
 )
 
 type APIAddresserTests struct {
@@ -29,6 +33,7 @@ type APIAddresserFacade interface {
 	CACert() (string, error)
 	APIHostPorts() ([][]network.HostPort, error)
 	WatchAPIHostPorts() (watcher.NotifyWatcher, error)
+	WatchCACert() (watcher.NotifyWatcher, error)
 }
 
 func (s *APIAddresserTests) TestAPIAddresses(c *gc.C) {
@@ -73,7 +78,36 @@ func (s *APIAddresserTests) TestAPIHostPorts(c *gc.C) {
 func (s *APIAddresserTests) TestCACert(c *gc.C) {
 	caCert, err := s.facade.CACert()
 	c.Assert(err, gc.IsNil)
-	c.Assert(caCert, gc.DeepEquals, s.state.CACert())
+	expectCACert, err := s.state.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.DeepEquals, expectCACert)
+}
+
+func (s *APIAddresserTests) TestWatchCACert(c *gc.C) {
+	w, err := s.facade.WatchCACert()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+
+	wc := statetesting.NewNotifyWatcherC(c, s.state, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Trust another CA and check that we get a notification.
+	newCACert, _, err := cert.NewCA("new", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	err = s.state.UpdateEnvironConfig(map[string]interface{}{
+		"ca-cert": coretesting.CACert + newCACert,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	caCert, err := s.facade.CACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, coretesting.CACert+newCACert)
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
 }
 
 func (s *APIAddresserTests) TestWatchAPIHostPorts(c *gc.C) {
@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/api/uniter"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type resourcesSuite struct {
+	uniterSuite
+
+	appService *state.Service
+	apiService *uniter.Service
+}
+
+var _ = gc.Suite(&resourcesSuite{})
+
+var resourcesMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+
+func (s *resourcesSuite) SetUpTest(c *gc.C) {
+	s.uniterSuite.SetUpTest(c)
+
+	// Log in as a unit of a service whose charm declares resources.
+	s.appService = s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", resourcesMeta, 1))
+	unit, err := s.appService.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.wordpressMachine)
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+	st := s.OpenAPIAs(c, unit.Tag(), password)
+	s.apiService, err = st.Uniter().Service(s.appService.Tag().(names.ServiceTag))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *resourcesSuite) TestResources(c *gc.C) {
+	resources, err := s.apiService.Resources()
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 0)
+
+	_, err = s.appService.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+	resources, err = s.apiService.Resources()
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.DeepEquals, []params.ResourceInfo{{
+		Service:  "app",
+		Name:     "app",
+		Revision: 1,
+		Size:     7,
+		SHA256:   "sha256-content",
+	}})
+}
+
+func (s *resourcesSuite) TestOpenResource(c *gc.C) {
+	_, err := s.appService.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+	r, err := s.apiService.OpenResource("app")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "content")
+}
+
+func (s *resourcesSuite) TestWatchResources(c *gc.C) {
+	w, err := s.apiService.WatchResources()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Attach a resource and check it's detected.
+	_, err = s.appService.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/juju/names"
 	"gopkg.in/juju/charm.v3"
@@ -61,6 +62,64 @@ func (s *Service) WatchRelations() (watcher.StringsWatcher, error) {
 	return w, nil
 }
 
+// WatchResources returns a NotifyWatcher that notifies of changes to
+// the resources attached to s.
+func (s *Service) WatchResources() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("WatchServiceResources", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewNotifyWatcher(s.st.facade.RawAPICaller(), result)
+	return w, nil
+}
+
+// Resources returns the resources attached to s.
+func (s *Service) Resources() ([]params.ResourceInfo, error) {
+	var results params.ResourcesResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.tag.String()}},
+	}
+	err := s.st.facade.FacadeCall("ServiceResources", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Resources, nil
+}
+
+// resourceOpener is implemented by API connections that can
+// download resources from the API server.
+type resourceOpener interface {
+	OpenResource(service, name string) (io.ReadCloser, error)
+}
+
+// OpenResource downloads the content of the named resource attached
+// to s. The returned reader must be closed after use.
+func (s *Service) OpenResource(name string) (io.ReadCloser, error) {
+	opener, ok := s.st.facade.RawAPICaller().(resourceOpener)
+	if !ok {
+		return nil, fmt.Errorf("API connection cannot download resources")
+	}
+	return opener.OpenResource(s.Name(), name)
+}
+
 // Life returns the service's current life state.
 func (s *Service) Life() params.Life {
 	return s.life
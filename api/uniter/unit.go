@@ -4,15 +4,17 @@
 package uniter
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/juju/errors"
 	"github.com/juju/names"
 	"gopkg.in/juju/charm.v3"
 
 	"github.com/juju/juju/api/common"
 	"github.com/juju/juju/api/watcher"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
 )
 
 // Unit represents a juju unit as seen by a uniter worker.
@@ -325,6 +327,70 @@ func (u *Unit) ClosePort(protocol string, number int) error {
 	return result.OneError()
 }
 
+// OpenPorts sets the policy of the ports with the given protocol
+// and port range to be opened. For ICMP, fromPort and toPort should
+// both be -1.
+func (u *Unit) OpenPorts(protocol string, fromPort, toPort int) error {
+	return u.changePorts("OpenPorts", protocol, fromPort, toPort)
+}
+
+// ClosePorts sets the policy of the ports with the given protocol
+// and port range to be closed. The range must match one previously
+// opened by the unit.
+func (u *Unit) ClosePorts(protocol string, fromPort, toPort int) error {
+	return u.changePorts("ClosePorts", protocol, fromPort, toPort)
+}
+
+func (u *Unit) changePorts(method, protocol string, fromPort, toPort int) error {
+	if u.st.facade.BestAPIVersion() < 1 {
+		// Older state servers can only open and close single ports.
+		if fromPort != toPort || strings.ToLower(protocol) == "icmp" {
+			return errors.NotSupportedf("port ranges and ICMP")
+		}
+		if method == "OpenPorts" {
+			return u.OpenPort(protocol, fromPort)
+		}
+		return u.ClosePort(protocol, fromPort)
+	}
+	var result params.ErrorResults
+	args := params.EntitiesPortRanges{
+		Entities: []params.EntityPortRange{{
+			Tag:      u.tag.String(),
+			Protocol: protocol,
+			FromPort: fromPort,
+			ToPort:   toPort,
+		}},
+	}
+	err := u.st.facade.FacadeCall(method, args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// OpenedPorts returns the port ranges opened by the unit.
+func (u *Unit) OpenedPorts() ([]network.PortRange, error) {
+	if u.st.facade.BestAPIVersion() < 1 {
+		return nil, errors.NotSupportedf("listing opened ports")
+	}
+	var results params.PortRangesResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag.String()}},
+	}
+	err := u.st.facade.FacadeCall("OpenedPorts", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Ranges, nil
+}
+
 var ErrNoCharmURLSet = errors.New("unit has no charm url set")
 
 // CharmURL returns the charm URL this unit is currently using.
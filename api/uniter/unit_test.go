@@ -13,6 +13,7 @@ import (
 	"gopkg.in/juju/charm.v3"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/api/base"
 	"github.com/juju/juju/api/uniter"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/network"
@@ -226,21 +227,23 @@ func (s *unitSuite) TestPrivateAddress(c *gc.C) {
 }
 
 func (s *unitSuite) TestOpenClosePort(c *gc.C) {
-	ports := s.wordpressUnit.OpenedPorts()
+	ports, err := s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	c.Assert(ports, gc.HasLen, 0)
 
-	err := s.apiUnit.OpenPort("tcp", 1234)
+	err = s.apiUnit.OpenPort("tcp", 1234)
 	c.Assert(err, gc.IsNil)
 	err = s.apiUnit.OpenPort("tcp", 4321)
 	c.Assert(err, gc.IsNil)
 
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	ports = s.wordpressUnit.OpenedPorts()
+	ports, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	// OpenedPorts returns a sorted slice.
-	c.Assert(ports, gc.DeepEquals, []network.Port{
-		{Protocol: "tcp", Number: 1234},
-		{Protocol: "tcp", Number: 4321},
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{
+		{FromPort: 1234, ToPort: 1234, Protocol: "tcp"},
+		{FromPort: 4321, ToPort: 4321, Protocol: "tcp"},
 	})
 
 	err = s.apiUnit.ClosePort("tcp", 4321)
@@ -248,10 +251,11 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	ports = s.wordpressUnit.OpenedPorts()
+	ports, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	// OpenedPorts returns a sorted slice.
-	c.Assert(ports, gc.DeepEquals, []network.Port{
-		{Protocol: "tcp", Number: 1234},
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{
+		{FromPort: 1234, ToPort: 1234, Protocol: "tcp"},
 	})
 
 	err = s.apiUnit.ClosePort("tcp", 1234)
@@ -259,7 +263,68 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	ports = s.wordpressUnit.OpenedPorts()
+	ports, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.HasLen, 0)
+}
+
+func (s *unitSuite) TestOpenClosePorts(c *gc.C) {
+	ranges, err := s.apiUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.HasLen, 0)
+
+	err = s.apiUnit.OpenPorts("tcp", 8000, 8100)
+	c.Assert(err, gc.IsNil)
+	err = s.apiUnit.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+
+	ranges, err = s.apiUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{
+		{FromPort: -1, ToPort: -1, Protocol: "icmp"},
+		{FromPort: 8000, ToPort: 8100, Protocol: "tcp"},
+	})
+
+	err = s.apiUnit.ClosePorts("tcp", 8000, 8100)
+	c.Assert(err, gc.IsNil)
+	ranges, err = s.apiUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{
+		{FromPort: -1, ToPort: -1, Protocol: "icmp"},
+	})
+}
+
+// v0Caller makes API calls using version 0 of every facade.
+type v0Caller struct {
+	base.APICaller
+}
+
+func (v0Caller) BestFacadeVersion(string) int {
+	return 0
+}
+
+func (s *unitSuite) TestOpenClosePortsV0(c *gc.C) {
+	st := uniter.NewState(v0Caller{s.st}, s.wordpressUnit.Tag().(names.UnitTag))
+	apiUnit, err := st.Unit(s.wordpressUnit.Tag().(names.UnitTag))
+	c.Assert(err, gc.IsNil)
+
+	err = apiUnit.OpenPorts("tcp", 80, 80)
+	c.Assert(err, gc.IsNil)
+	err = apiUnit.OpenPorts("tcp", 8000, 8100)
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	err = apiUnit.OpenPorts("icmp", -1, -1)
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	_, err = apiUnit.OpenedPorts()
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+
+	ports, err := s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+
+	err = apiUnit.ClosePorts("tcp", 80, 80)
+	c.Assert(err, gc.IsNil)
+	ports, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	c.Assert(ports, gc.HasLen, 0)
 }
 
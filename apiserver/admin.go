@@ -102,6 +102,7 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	}
 	entity, err := doCheckCreds(a.root.srv.state, c)
 	if err != nil {
+		loginFailures.Inc()
 		return params.LoginResult{}, err
 	}
 	if a.reqNotifier != nil {
@@ -23,6 +23,7 @@ import (
 	"github.com/juju/juju/rpc"
 	"github.com/juju/juju/rpc/jsoncodec"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/stats"
 )
 
 var logger = loggo.GetLogger("juju.apiserver")
@@ -42,6 +43,10 @@ type Server struct {
 	limiter   utils.Limiter
 	validator LoginValidator
 
+	// requestLimiter limits the requests made by logged in
+	// entities.
+	requestLimiter *requestLimiter
+
 	mu          sync.Mutex // protects the fields that follow
 	environUUID string
 }
@@ -58,6 +63,16 @@ type ServerConfig struct {
 	DataDir   string
 	LogDir    string
 	Validator LoginValidator
+
+	// RateLimit holds the limits applied to requests made by
+	// logged in users and agents. The zero value applies no
+	// limits.
+	RateLimit RateLimitConfig
+
+	// CertChanged, if not nil, receives the state server's
+	// certificate and key whenever they change, so that new
+	// connections are served with them.
+	CertChanged <-chan params.StateServingInfo
 }
 
 // NewServer serves the given state by accepting requests on the given
@@ -80,16 +95,86 @@ func NewServer(s *state.State, lis net.Listener, cfg ServerConfig) (*Server, err
 		logDir:    cfg.LogDir,
 		limiter:   utils.NewLimiter(loginRateLimit),
 		validator: cfg.Validator,
+
+		requestLimiter: newRequestLimiter(cfg.RateLimit),
 	}
 	// TODO(rog) check that *srvRoot is a valid type for using
 	// as an RPC server.
-	lis = tls.NewListener(lis, &tls.Config{
-		Certificates: []tls.Certificate{tlsCert},
-	})
-	go srv.run(lis)
+	certLis := newChangeCertListener(lis, tlsCert)
+	if cfg.CertChanged != nil {
+		srv.wg.Add(1)
+		go func() {
+			defer srv.wg.Done()
+			srv.updateCertificate(certLis, cfg.CertChanged)
+		}()
+	}
+	go srv.run(certLis)
 	return srv, nil
 }
 
+// updateCertificate changes the certificate served by the listener
+// whenever a new one is received on the given channel, until the
+// server is stopped.
+func (srv *Server) updateCertificate(lis *changeCertListener, certChanged <-chan params.StateServingInfo) {
+	for {
+		select {
+		case <-srv.tomb.Dying():
+			return
+		case info := <-certChanged:
+			if err := lis.setCertificate([]byte(info.Cert), []byte(info.PrivateKey)); err != nil {
+				logger.Errorf("cannot use new state server certificate: %v", err)
+				continue
+			}
+			logger.Infof("state server certificate changed")
+		}
+	}
+}
+
+// changeCertListener is a TLS net.Listener whose certificate can be
+// changed while it is running. Connections accepted after the change
+// are served with the new certificate.
+type changeCertListener struct {
+	net.Listener
+
+	mu     sync.Mutex
+	config *tls.Config
+}
+
+func newChangeCertListener(lis net.Listener, cert tls.Certificate) *changeCertListener {
+	return &changeCertListener{
+		Listener: lis,
+		config: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		},
+	}
+}
+
+// Accept implements net.Listener.Accept.
+func (lis *changeCertListener) Accept() (net.Conn, error) {
+	conn, err := lis.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	lis.mu.Lock()
+	defer lis.mu.Unlock()
+	return tls.Server(conn, lis.config), nil
+}
+
+// setCertificate changes the certificate served by the listener to
+// the one with the given certificate and key (in PEM format).
+func (lis *changeCertListener) setCertificate(certPEM, keyPEM []byte) error {
+	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	lis.mu.Lock()
+	defer lis.mu.Unlock()
+	lis.config = &tls.Config{
+		Certificates: []tls.Certificate{tlsCert},
+	}
+	return nil
+}
+
 // Dead returns a channel that signals when the server has exited.
 func (srv *Server) Dead() <-chan struct{} {
 	return srv.tomb.Dead()
@@ -116,6 +201,10 @@ type requestNotifier struct {
 	id    int64
 	start time.Time
 
+	// logging holds whether requests and replies
+	// should be logged.
+	logging bool
+
 	mu   sync.Mutex
 	tag_ string
 }
@@ -144,6 +233,9 @@ func (n *requestNotifier) tag() (tag string) {
 }
 
 func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
+	if !n.logging {
+		return
+	}
 	if hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
 		return
 	}
@@ -152,6 +244,12 @@ func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 }
 
 func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body interface{}, timeSpent time.Duration) {
+	facade, method := requestLabels(req, hdr)
+	requestCounter.Inc(facade, method, hdr.ErrorCode)
+	requestLatency.Observe(timeSpent.Seconds(), facade, method)
+	if !n.logging {
+		return
+	}
 	if req.Type == "Pinger" && req.Action == "Ping" {
 		return
 	}
@@ -159,10 +257,12 @@ func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body int
 }
 
 func (n *requestNotifier) join(req *http.Request) {
+	activeConnections.Inc()
 	logger.Infof("[%X] API connection from %s", n.id, req.RemoteAddr)
 }
 
 func (n *requestNotifier) leave() {
+	activeConnections.Dec()
 	logger.Infof("[%X] %s API connection terminated after %v", n.id, n.tag(), time.Since(n.start))
 }
 
@@ -206,6 +306,10 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler: httpHandler{state: srv.state},
 			logDir:      srv.logDir},
 	)
+	handleAll(mux, "/environment/:envuuid/runoutput",
+		&runOutputHandler{
+			httpHandler: httpHandler{state: srv.state}},
+	)
 	handleAll(mux, "/environment/:envuuid/charms",
 		&charmsHandler{
 			httpHandler: httpHandler{state: srv.state},
@@ -225,6 +329,16 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler{state: srv.state},
 		}},
 	)
+	handleAll(mux, "/environment/:envuuid/resources",
+		&resourcesHandler{
+			httpHandler: httpHandler{state: srv.state},
+		},
+	)
+	handleAll(mux, "/environment/:envuuid/metrics",
+		&metricsHandler{
+			httpHandler: httpHandler{state: srv.state},
+			registry:    stats.Default},
+	)
 	handleAll(mux, "/environment/:envuuid/api", http.HandlerFunc(srv.apiHandler))
 	// For backwards compatibility we register all the old paths
 	handleAll(mux, "/log",
@@ -232,6 +346,10 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler: httpHandler{state: srv.state},
 			logDir:      srv.logDir},
 	)
+	handleAll(mux, "/runoutput",
+		&runOutputHandler{
+			httpHandler: httpHandler{state: srv.state}},
+	)
 	handleAll(mux, "/charms",
 		&charmsHandler{
 			httpHandler: httpHandler{state: srv.state},
@@ -247,6 +365,16 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler{state: srv.state},
 		}},
 	)
+	handleAll(mux, "/resources",
+		&resourcesHandler{
+			httpHandler: httpHandler{state: srv.state},
+		},
+	)
+	handleAll(mux, "/metrics",
+		&metricsHandler{
+			httpHandler: httpHandler{state: srv.state},
+			registry:    stats.Default},
+	)
 	handleAll(mux, "/", http.HandlerFunc(srv.apiHandler))
 	// The error from http.Serve is not interesting.
 	http.Serve(lis, mux)
@@ -331,13 +459,10 @@ func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifie
 	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
 		codec.SetLogging(true)
 	}
-	var notifier rpc.RequestNotifier
-	if logger.EffectiveLogLevel() <= loggo.DEBUG {
-		// Incur request monitoring overhead only if we
-		// know we'll need it.
-		notifier = reqNotifier
-	}
-	conn := rpc.NewConn(codec, notifier)
+	// Incur request logging overhead only if we know we'll
+	// need it. The notifier always records request metrics.
+	reqNotifier.logging = logger.EffectiveLogLevel() <= loggo.DEBUG
+	conn := rpc.NewConn(codec, reqNotifier)
 	err := srv.validateEnvironUUID(envUUID)
 	if err != nil {
 		conn.Serve(&errRoot{err}, serverError)
@@ -361,7 +486,10 @@ func (srv *Server) mongoPinger() error {
 		case <-srv.tomb.Dying():
 			return tomb.ErrDying
 		}
-		if err := session.Ping(); err != nil {
+		start := time.Now()
+		err := session.Ping()
+		mongoPingLatency.Observe(time.Since(start).Seconds())
+		if err != nil {
 			logger.Infof("got error pinging mongo: %v", err)
 			return fmt.Errorf("error pinging mongo: %v", err)
 		}
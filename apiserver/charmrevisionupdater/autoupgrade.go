@@ -0,0 +1,221 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmrevisionupdater
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v3"
+
+	"github.com/juju/juju/apiserver/client"
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state"
+)
+
+// now returns the current time; it is a variable so that tests can
+// check maintenance windows.
+var now = time.Now
+
+// CharmRevisionUpdaterV1 defines the methods on version 1 of the
+// charmrevisionupdater API end point.
+type CharmRevisionUpdaterV1 interface {
+	CharmRevisionUpdater
+	UpgradeCharms() (params.ErrorResult, error)
+}
+
+// CharmRevisionUpdaterAPIV1 implements version 1 of the
+// charmrevisionupdater API end point, which adds automatic charm
+// upgrades.
+type CharmRevisionUpdaterAPIV1 struct {
+	*CharmRevisionUpdaterAPI
+
+	// mu serializes UpgradeCharms calls and guards patchChecks.
+	mu sync.Mutex
+
+	// patchChecks caches whether upgrading from one charm to another
+	// is a patch revision upgrade, and why not if it is not, so that
+	// the latest revision of a charm is only downloaded once to check
+	// it. It only holds the checks needed by the last UpgradeCharms
+	// call.
+	patchChecks map[patchCheck]string
+}
+
+var _ CharmRevisionUpdaterV1 = (*CharmRevisionUpdaterAPIV1)(nil)
+
+// patchCheck identifies a check of whether upgrading from one charm
+// to another would be a patch revision upgrade.
+type patchCheck struct {
+	from, to string
+}
+
+// NewCharmRevisionUpdaterAPIV1 creates a new server-side version 1
+// charmrevisionupdater API end point.
+func NewCharmRevisionUpdaterAPIV1(
+	st *state.State,
+	resources *common.Resources,
+	authorizer common.Authorizer,
+) (*CharmRevisionUpdaterAPIV1, error) {
+	api, err := NewCharmRevisionUpdaterAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &CharmRevisionUpdaterAPIV1{CharmRevisionUpdaterAPI: api}, nil
+}
+
+// UpgradeCharms upgrades the charms of services whose upgrade policy
+// allows it to the latest revisions recorded by UpdateLatestRevisions,
+// as "juju upgrade-charm" would.
+func (api *CharmRevisionUpdaterAPIV1) UpgradeCharms() (params.ErrorResult, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	// Automatic upgrades change the environment like any other.
+	if err := common.NewBlockChecker(api.state).ChangeAllowed(); err != nil {
+		logger.Infof("not upgrading charms automatically: %v", err)
+		return params.ErrorResult{}, nil
+	}
+	services, err := api.state.AllServices()
+	if err != nil {
+		return params.ErrorResult{Error: common.ServerError(err)}, nil
+	}
+	t := now()
+	checks := make(map[patchCheck]string)
+	for _, service := range services {
+		if err := api.upgradeCharm(service, t, checks); err != nil {
+			// Carry on with the other services; the upgrade will
+			// be retried next time.
+			logger.Errorf("cannot upgrade charm of service %q automatically: %v", service.Name(), err)
+		}
+	}
+	api.patchChecks = checks
+	return params.ErrorResult{}, nil
+}
+
+// upgradeCharm upgrades the charm of the service to the latest
+// revision in the charm store, if the service's upgrade policy allows
+// it at time t. Any patch revision check made is recorded in checks.
+func (api *CharmRevisionUpdaterAPIV1) upgradeCharm(service *state.Service, t time.Time, checks map[patchCheck]string) error {
+	policy, window := service.UpgradePolicy()
+	if policy == state.UpgradeNever || service.Life() != state.Alive {
+		return nil
+	}
+	curl, _ := service.CharmURL()
+	if curl.Schema != "cs" {
+		return nil
+	}
+	latest, err := api.state.LatestPlaceholderCharm(curl)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	latestURL := latest.URL()
+	if latestURL.Revision <= curl.Revision {
+		return nil
+	}
+	if !policy.AutoUpgrades() {
+		logger.Infof("upgrade available for service %q: %s", service.Name(), latestURL)
+		return nil
+	}
+	if window != nil && !window.Contains(t) {
+		logger.Debugf("upgrade of service %q to %s waits for maintenance window %s", service.Name(), latestURL, window)
+		return nil
+	}
+	if upgrade, err := service.RollingUpgrade(); err == nil && upgrade.Status() != state.RollingUpgradeComplete {
+		logger.Infof("upgrade of service %q to %s waits for rolling upgrade to %s", service.Name(), latestURL, upgrade.CharmURL())
+		return nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if policy == state.UpgradePatchRevisions {
+		reason, err := api.checkPatchRevision(service, latestURL, checks)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			logger.Infof("not upgrading service %q to %s automatically: %s", service.Name(), latestURL, reason)
+			return nil
+		}
+	}
+	if err := client.AddStoreCharm(api.state, latestURL); err != nil {
+		return err
+	}
+	ch, err := api.state.Charm(latestURL)
+	if err != nil {
+		return err
+	}
+	if err := service.SetCharmAutomatically(ch); err != nil {
+		return err
+	}
+	logger.Infof("upgraded service %q from %s to %s (upgrade policy %q)", service.Name(), curl, latestURL, policy)
+	return nil
+}
+
+// checkPatchRevision returns why upgrading the service to the charm
+// with the given URL would not be a patch revision upgrade, or an
+// empty string if it would be one. The new revision is checked before
+// it is added to state, so that it is still reported as available if
+// it is not a patch revision. The outcome is recorded in checks, and
+// taken from those of the previous UpgradeCharms call if possible, so
+// the new revision is only downloaded once.
+func (api *CharmRevisionUpdaterAPIV1) checkPatchRevision(service *state.Service, latestURL *charm.URL, checks map[patchCheck]string) (string, error) {
+	current, _, err := service.Charm()
+	if err != nil {
+		return "", err
+	}
+	check := patchCheck{from: current.URL().String(), to: latestURL.String()}
+	if mismatch, ok := checks[check]; ok {
+		return mismatch, nil
+	}
+	if mismatch, ok := api.patchChecks[check]; ok {
+		checks[check] = mismatch
+		return mismatch, nil
+	}
+	envConfig, err := api.state.EnvironConfig()
+	if err != nil {
+		return "", err
+	}
+	store := config.SpecializeCharmRepo(client.CharmStore, envConfig)
+	downloaded, err := store.Get(latestURL)
+	if err != nil {
+		return "", errors.Annotatef(err, "cannot download charm %q", latestURL)
+	}
+	mismatch := patchRevisionMismatch(current, downloaded)
+	checks[check] = mismatch
+	return mismatch, nil
+}
+
+// patchRevisionMismatch returns why upgrading from the charm current
+// to the charm latest would not be a patch revision upgrade, or an
+// empty string if it would be one. A patch revision changes neither the
+// charm's relations nor its configuration options.
+var patchRevisionMismatch = func(current, latest charm.Charm) string {
+	currentMeta, latestMeta := current.Meta(), latest.Meta()
+	if !reflect.DeepEqual(currentMeta.Provides, latestMeta.Provides) ||
+		!reflect.DeepEqual(currentMeta.Requires, latestMeta.Requires) ||
+		!reflect.DeepEqual(currentMeta.Peers, latestMeta.Peers) {
+		return "relations changed"
+	}
+	currentOptions, latestOptions := current.Config().Options, latest.Config().Options
+	for name, option := range currentOptions {
+		latestOption, ok := latestOptions[name]
+		if !ok {
+			return fmt.Sprintf("configuration option %q removed", name)
+		}
+		if latestOption.Type != option.Type {
+			return fmt.Sprintf("configuration option %q changed type", name)
+		}
+	}
+	for name := range latestOptions {
+		if _, ok := currentOptions[name]; !ok {
+			return fmt.Sprintf("configuration option %q added", name)
+		}
+	}
+	return ""
+}
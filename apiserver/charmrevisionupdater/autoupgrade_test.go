@@ -0,0 +1,128 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charmrevisionupdater_test
+
+import (
+	"time"
+
+	"gopkg.in/juju/charm.v3"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/charmrevisionupdater"
+	"github.com/juju/juju/state"
+)
+
+func (s *charmVersionSuite) setUpgradePolicy(c *gc.C, policy state.CharmUpgradePolicy, window string) *state.Service {
+	s.AddMachine(c, "0", state.JobManageEnviron)
+	s.SetupScenario(c)
+	svc, err := s.State.Service("mysql")
+	c.Assert(err, gc.IsNil)
+	var w *state.MaintenanceWindow
+	if window != "" {
+		w, err = state.ParseMaintenanceWindow(window)
+		c.Assert(err, gc.IsNil)
+	}
+	err = svc.SetUpgradePolicy(policy, w)
+	c.Assert(err, gc.IsNil)
+	result, err := s.charmrevisionupdater.UpdateLatestRevisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Error, gc.IsNil)
+	return svc
+}
+
+func (s *charmVersionSuite) upgradeCharms(c *gc.C, svc *state.Service) string {
+	result, err := s.charmrevisionupdater.UpgradeCharms()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Error, gc.IsNil)
+	err = svc.Refresh()
+	c.Assert(err, gc.IsNil)
+	curl, _ := svc.CharmURL()
+	return curl.String()
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsAlways(c *gc.C) {
+	svc := s.setUpgradePolicy(c, state.UpgradeAlways, "")
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-23")
+
+	upgrades, err := s.State.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 1)
+	c.Assert(upgrades[0].ServiceName, gc.Equals, "mysql")
+	c.Assert(upgrades[0].From.String(), gc.Equals, "cs:quantal/mysql-22")
+	c.Assert(upgrades[0].To.String(), gc.Equals, "cs:quantal/mysql-23")
+	c.Assert(upgrades[0].Policy, gc.Equals, state.UpgradeAlways)
+
+	// Nothing more to upgrade.
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-23")
+	upgrades, err = s.State.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 1)
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsPatchRevisions(c *gc.C) {
+	svc := s.setUpgradePolicy(c, state.UpgradePatchRevisions, "")
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-23")
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsNotPatchRevision(c *gc.C) {
+	checks := 0
+	s.PatchValue(charmrevisionupdater.PatchRevisionMismatch, func(current, latest charm.Charm) string {
+		checks++
+		return "relations changed"
+	})
+	svc := s.setUpgradePolicy(c, state.UpgradePatchRevisions, "")
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+
+	// The new revision is only downloaded and checked once.
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+	c.Assert(checks, gc.Equals, 1)
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsForgetsUnusedPatchChecks(c *gc.C) {
+	checks := 0
+	s.PatchValue(charmrevisionupdater.PatchRevisionMismatch, func(current, latest charm.Charm) string {
+		checks++
+		return "relations changed"
+	})
+	svc := s.setUpgradePolicy(c, state.UpgradePatchRevisions, "")
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+	c.Assert(checks, gc.Equals, 1)
+
+	// Once no service needs the check, it is forgotten.
+	err := svc.SetUpgradePolicy(state.UpgradeNotify, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+	err = svc.SetUpgradePolicy(state.UpgradePatchRevisions, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+	c.Assert(checks, gc.Equals, 2)
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsNotify(c *gc.C) {
+	svc := s.setUpgradePolicy(c, state.UpgradeNotify, "")
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+	upgrades, err := s.State.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 0)
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsMaintenanceWindow(c *gc.C) {
+	svc := s.setUpgradePolicy(c, state.UpgradeAlways, "02:00-04:00")
+	s.PatchValue(charmrevisionupdater.Now, func() time.Time {
+		return time.Date(2014, 10, 1, 12, 0, 0, 0, time.UTC)
+	})
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+
+	s.PatchValue(charmrevisionupdater.Now, func() time.Time {
+		return time.Date(2014, 10, 1, 3, 0, 0, 0, time.UTC)
+	})
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-23")
+}
+
+func (s *charmVersionSuite) TestUpgradeCharmsBlocked(c *gc.C) {
+	svc := s.setUpgradePolicy(c, state.UpgradeAlways, "")
+	err := s.State.SwitchBlockOn("all-changes", "frozen")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.upgradeCharms(c, svc), gc.Equals, "cs:quantal/mysql-22")
+}
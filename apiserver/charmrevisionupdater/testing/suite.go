@@ -11,6 +11,7 @@ import (
 	charmtesting "gopkg.in/juju/charm.v3/testing"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/apiserver/client"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 )
@@ -44,6 +45,7 @@ func (s *CharmSuite) TearDownSuite(c *gc.C) {
 func (s *CharmSuite) SetUpTest(c *gc.C) {
 	s.jcSuite.PatchValue(&charm.CacheDir, c.MkDir())
 	s.jcSuite.PatchValue(&charm.Store, &charm.CharmStore{BaseURL: s.Server.Address()})
+	s.jcSuite.PatchValue(&client.CharmStore, charm.Store)
 	s.Server.Downloads = nil
 	s.Server.Authorizations = nil
 	s.Server.Metadata = nil
@@ -81,7 +83,7 @@ func (s *CharmSuite) AddCharmWithRevision(c *gc.C, charmName string, rev int) *s
 	curl := charm.MustParseURL(fmt.Sprintf("cs:quantal/%s-%d", name, rev))
 	bundleURL, err := url.Parse(fmt.Sprintf("http://bundles.testing.invalid/%s-%d", name, rev))
 	c.Assert(err, gc.IsNil)
-	dummy, err := s.jcSuite.State.AddCharm(ch, curl, bundleURL, fmt.Sprintf("%s-%d-sha256", name, rev))
+	dummy, err := s.jcSuite.State.AddCharm(ch, curl, bundleURL, fmt.Sprintf("%s-%d-sha256", name, rev), 0)
 	c.Assert(err, gc.IsNil)
 	s.charms[name] = dummy
 	return dummy
@@ -17,6 +17,7 @@ var logger = loggo.GetLogger("juju.apiserver.charmrevisionupdater")
 
 func init() {
 	common.RegisterStandardFacade("CharmRevisionUpdater", 0, NewCharmRevisionUpdaterAPI)
+	common.RegisterStandardFacade("CharmRevisionUpdater", 1, NewCharmRevisionUpdaterAPIV1)
 }
 
 // CharmRevisionUpdater defines the methods on the charmrevisionupdater API end point.
@@ -21,7 +21,7 @@ type charmVersionSuite struct {
 	testing.CharmSuite
 	jujutesting.JujuConnSuite
 
-	charmrevisionupdater *charmrevisionupdater.CharmRevisionUpdaterAPI
+	charmrevisionupdater *charmrevisionupdater.CharmRevisionUpdaterAPIV1
 	resources            *common.Resources
 	authoriser           apiservertesting.FakeAuthorizer
 }
@@ -47,7 +47,7 @@ func (s *charmVersionSuite) SetUpTest(c *gc.C) {
 		EnvironManager: true,
 	}
 	var err error
-	s.charmrevisionupdater, err = charmrevisionupdater.NewCharmRevisionUpdaterAPI(s.State, s.resources, s.authoriser)
+	s.charmrevisionupdater, err = charmrevisionupdater.NewCharmRevisionUpdaterAPIV1(s.State, s.resources, s.authoriser)
 	c.Assert(err, gc.IsNil)
 }
 
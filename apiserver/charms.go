@@ -25,6 +25,7 @@ import (
 	ziputil "github.com/juju/utils/zip"
 	"gopkg.in/juju/charm.v3"
 
+	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/environs"
 )
@@ -53,6 +54,10 @@ func (h *charmsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "POST":
 		// Add a local charm to the store provider.
 		// Requires a "series" query specifying the series to use for the charm.
+		if err := common.NewBlockChecker(h.state).ChangeAllowed(); err != nil {
+			h.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		charmURL, err := h.processPost(r)
 		if err != nil {
 			h.sendError(w, http.StatusBadRequest, err.Error())
@@ -373,7 +378,7 @@ func (h *charmsHandler) repackageAndUploadCharm(archive *charm.CharmArchive, cur
 	}
 
 	// And finally, update state.
-	_, err = h.state.UpdateUploadedCharm(archive, curl, bundleURL, bundleSHA256)
+	_, err = h.state.UpdateUploadedCharm(archive, curl, bundleURL, bundleSHA256, size)
 	if err != nil {
 		return errors.Annotate(err, "cannot update uploaded charm in state")
 	}
@@ -145,6 +145,14 @@ func (s *charmsSuite) TestUploadRequiresSeries(c *gc.C) {
 	s.assertErrorResponse(c, resp, http.StatusBadRequest, "expected series=URL argument")
 }
 
+func (s *charmsSuite) TestUploadBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	resp, err := s.authRequest(c, "POST", s.charmsURI(c, "?series=quantal"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "the operation has been blocked.*")
+}
+
 func (s *charmsSuite) TestUploadFailsWithInvalidZip(c *gc.C) {
 	// Create an empty file.
 	tempFile, err := ioutil.TempFile(c.MkDir(), "charm")
@@ -170,7 +178,7 @@ func (s *charmsSuite) TestUploadBumpsRevision(c *gc.C) {
 	)
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
 	c.Assert(err, gc.IsNil)
-	_, err = s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256")
+	_, err = s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256", 0)
 	c.Assert(err, gc.IsNil)
 
 	// Now try uploading the same revision and verify it gets bumped,
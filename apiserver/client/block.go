@@ -0,0 +1,45 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// SwitchBlockOn switches on the environment block of the given type,
+// so that the operations it guards against fail with an error that
+// includes args.Message.
+func (c *Client) SwitchBlockOn(args params.BlockSwitchParams) error {
+	t, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOn(t, args.Message)
+}
+
+// SwitchBlockOff switches off the environment block of the given type.
+func (c *Client) SwitchBlockOff(args params.BlockSwitchParams) error {
+	t, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOff(t)
+}
+
+// ListBlocks returns the environment blocks that are switched on.
+func (c *Client) ListBlocks() (params.BlockResults, error) {
+	blocks, err := c.api.state.AllBlocks()
+	if err != nil {
+		return params.BlockResults{}, err
+	}
+	var result params.BlockResults
+	for _, block := range blocks {
+		result.Blocks = append(result.Blocks, params.Block{
+			Type:    string(block.Type()),
+			Message: block.Message(),
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+type blockSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&blockSuite{})
+
+func (s *blockSuite) TestSwitchBlocks(c *gc.C) {
+	client := s.APIState.Client()
+	err := client.SwitchBlockOn("destroy-environment", "production")
+	c.Assert(err, gc.IsNil)
+	err = client.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	blocks, err := client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, jc.DeepEquals, []params.Block{
+		{Type: "destroy-environment", Message: "production"},
+		{Type: "all-changes"},
+	})
+
+	err = client.SwitchBlockOff("all-changes")
+	c.Assert(err, gc.IsNil)
+	blocks, err = client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, jc.DeepEquals, []params.Block{
+		{Type: "destroy-environment", Message: "production"},
+	})
+
+	err = client.SwitchBlockOn("everything", "")
+	c.Assert(err, gc.ErrorMatches, `block type "everything" not valid`)
+	err = client.SwitchBlockOff("remove-object")
+	c.Assert(err, gc.ErrorMatches, `cannot switch off "remove-object" block: "remove-object" block not found`)
+}
+
+func (s *blockSuite) assertBlocked(c *gc.C, err error, msg string) {
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: "+msg)
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+}
+
+func (s *blockSuite) TestDestroyBlock(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err := s.State.SwitchBlockOn("destroy-environment", "production")
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	err = client.DestroyEnvironment()
+	s.assertBlocked(c, err, "production")
+	err = client.ServiceDestroy("wordpress")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *blockSuite) TestRemoveBlock(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err := s.State.SwitchBlockOn("remove-object", "keep everything")
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	err = client.DestroyEnvironment()
+	s.assertBlocked(c, err, "keep everything")
+	err = client.ServiceDestroy("wordpress")
+	s.assertBlocked(c, err, "keep everything")
+	err = client.DestroyMachines("0")
+	s.assertBlocked(c, err, "keep everything")
+	err = client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *blockSuite) TestChangeBlock(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err := s.State.SwitchBlockOn("all-changes", "frozen")
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	err = client.ServiceExpose("wordpress")
+	s.assertBlocked(c, err, "frozen")
+	_, err = client.AddServiceUnits("wordpress", 1, "")
+	s.assertBlocked(c, err, "frozen")
+	err = client.ServiceDestroy("wordpress")
+	s.assertBlocked(c, err, "frozen")
+
+	// Reporting still works.
+	_, err = client.Status(nil)
+	c.Assert(err, gc.IsNil)
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// ServiceSetUpgradePolicy sets the charm upgrade policy of a service.
+func (c *Client) ServiceSetUpgradePolicy(args params.ServiceSetUpgradePolicy) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	policy, err := state.ParseCharmUpgradePolicy(args.Policy)
+	if err != nil {
+		return err
+	}
+	var window *state.MaintenanceWindow
+	if args.Window != "" {
+		if window, err = state.ParseMaintenanceWindow(args.Window); err != nil {
+			return err
+		}
+	}
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	return service.SetUpgradePolicy(policy, window)
+}
+
+// AutoCharmUpgrades returns the charm upgrades made automatically
+// because of the upgrade policy of the given service, or of any
+// service if no service name is given.
+func (c *Client) AutoCharmUpgrades(args params.ServiceGet) (params.AutoCharmUpgradesResults, error) {
+	if args.ServiceName != "" {
+		if _, err := c.api.state.Service(args.ServiceName); err != nil {
+			return params.AutoCharmUpgradesResults{}, err
+		}
+	}
+	upgrades, err := c.api.state.AutoCharmUpgrades(args.ServiceName)
+	if err != nil {
+		return params.AutoCharmUpgradesResults{}, err
+	}
+	result := params.AutoCharmUpgradesResults{
+		Upgrades: make([]params.AutoCharmUpgrade, len(upgrades)),
+	}
+	for i, upgrade := range upgrades {
+		result.Upgrades[i] = params.AutoCharmUpgrade{
+			ServiceName: upgrade.ServiceName,
+			From:        upgrade.From.String(),
+			To:          upgrade.To.String(),
+			Policy:      string(upgrade.Policy),
+			Time:        upgrade.Time,
+		}
+	}
+	return result, nil
+}
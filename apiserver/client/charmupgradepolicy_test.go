@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+type charmUpgradePolicySuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&charmUpgradePolicySuite{})
+
+func (s *charmUpgradePolicySuite) TestServiceSetUpgradePolicy(c *gc.C) {
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	client := s.APIState.Client()
+
+	err := client.ServiceSetUpgradePolicy("wordpress", "patch-revisions", "22:00-02:00")
+	c.Assert(err, gc.IsNil)
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	policy, window := service.UpgradePolicy()
+	c.Assert(policy, gc.Equals, state.UpgradePatchRevisions)
+	c.Assert(window.String(), gc.Equals, "22:00-02:00")
+
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Services["wordpress"].UpgradePolicy, gc.Equals, "patch-revisions")
+	c.Assert(status.Services["wordpress"].UpgradeWindow, gc.Equals, "22:00-02:00")
+
+	err = client.ServiceSetUpgradePolicy("wordpress", "sometimes", "")
+	c.Assert(err, gc.ErrorMatches, `charm upgrade policy "sometimes" not valid`)
+	err = client.ServiceSetUpgradePolicy("wordpress", "always", "02:00")
+	c.Assert(err, gc.ErrorMatches, `maintenance window "02:00" \(expected HH:MM-HH:MM\) not valid`)
+	err = client.ServiceSetUpgradePolicy("nosuch", "always", "")
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+}
+
+func (s *charmUpgradePolicySuite) TestServiceSetUpgradePolicyBlocked(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	err := s.State.SwitchBlockOn("all-changes", "frozen")
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().ServiceSetUpgradePolicy("wordpress", "always", "")
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: frozen")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+}
+
+func (s *charmUpgradePolicySuite) TestAutoCharmUpgrades(c *gc.C) {
+	oldCharm := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", oldCharm)
+	newCharm := s.AddMetaCharm(c, "wordpress", "name: wordpress\nsummary: blog\ndescription: blog\n", 42)
+	err := service.SetUpgradePolicy(state.UpgradeAlways, nil)
+	c.Assert(err, gc.IsNil)
+	err = service.SetCharmAutomatically(newCharm)
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	upgrades, err := client.AutoCharmUpgrades("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 1)
+	c.Assert(upgrades[0].Time.IsZero(), jc.IsFalse)
+	upgrades[0].Time = time.Time{}
+	c.Assert(upgrades[0], jc.DeepEquals, params.AutoCharmUpgrade{
+		ServiceName: "wordpress",
+		From:        oldCharm.String(),
+		To:          newCharm.String(),
+		Policy:      "always",
+	})
+
+	all, err := client.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 1)
+	_, err = client.AutoCharmUpgrades("nosuch")
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+}
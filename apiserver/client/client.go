@@ -46,7 +46,8 @@ type API struct {
 
 // Client serves client-specific API methods.
 type Client struct {
-	api *API
+	api   *API
+	check *common.BlockChecker
 }
 
 // NewClient creates a new instance of the Client Facade.
@@ -59,13 +60,16 @@ func NewClient(st *state.State, resources *common.Resources, authorizer common.A
 		return nil, err
 	}
 	urlGetter := common.NewToolsURLGetter(env.UUID(), st)
-	return &Client{api: &API{
-		state:        st,
-		auth:         authorizer,
-		resources:    resources,
-		statusSetter: common.NewStatusSetter(st, common.AuthAlways()),
-		toolsFinder:  common.NewToolsFinder(st, urlGetter),
-	}}, nil
+	return &Client{
+		api: &API{
+			state:        st,
+			auth:         authorizer,
+			resources:    resources,
+			statusSetter: common.NewStatusSetter(st, common.AuthAlways()),
+			toolsFinder:  common.NewToolsFinder(st, urlGetter),
+		},
+		check: common.NewBlockChecker(st),
+	}, nil
 }
 
 func (c *Client) WatchAll() (params.AllWatcherId, error) {
@@ -81,6 +85,9 @@ func (c *Client) WatchAll() (params.AllWatcherId, error) {
 // (Deprecated) Use NewServiceSetForClientAPI instead, to preserve values set to
 // an empty string, and use ServiceUnset to unset values.
 func (c *Client) ServiceSet(p params.ServiceSet) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -96,6 +103,9 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 // TODO(Nate): rename this to ServiceSet (and remove the deprecated ServiceSet)
 // when the GUI handles the new behavior.
 func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -105,6 +115,9 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
 func (c *Client) ServiceUnset(p params.ServiceUnset) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -118,6 +131,9 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
 func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -145,6 +161,9 @@ func (c *Client) ServiceCharmRelations(p params.ServiceCharmRelations) (params.S
 
 // Resolved implements the server side of Client.Resolved.
 func (c *Client) Resolved(p params.Resolved) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	unit, err := c.api.state.Unit(p.UnitName)
 	if err != nil {
 		return err
@@ -211,6 +230,9 @@ func (c *Client) PrivateAddress(p params.PrivateAddress) (results params.Private
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceExpose(args params.ServiceExpose) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -221,6 +243,9 @@ func (c *Client) ServiceExpose(args params.ServiceExpose) error {
 // ServiceUnexpose changes the juju-managed firewall to unexpose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceUnexpose(args params.ServiceUnexpose) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -247,6 +272,9 @@ func networkTagsToNames(tags []string) ([]string, error) {
 // before calling ServiceDeploy, although for backward compatibility
 // this is not necessary until 1.16 support is removed.
 func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	curl, err := charm.ParseURL(args.CharmUrl)
 	if err != nil {
 		return err
@@ -301,13 +329,14 @@ func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
 		juju.DeployServiceParams{
 			ServiceName: args.ServiceName,
 			// TODO(dfc) ServiceOwner should be a tag
-			ServiceOwner:   c.api.auth.GetAuthTag().String(),
-			Charm:          ch,
-			NumUnits:       args.NumUnits,
-			ConfigSettings: settings,
-			Constraints:    args.Constraints,
-			ToMachineSpec:  args.ToMachineSpec,
-			Networks:       requestedNetworks,
+			ServiceOwner:     c.api.auth.GetAuthTag().String(),
+			Charm:            ch,
+			NumUnits:         args.NumUnits,
+			ConfigSettings:   settings,
+			Constraints:      args.Constraints,
+			ToMachineSpec:    args.ToMachineSpec,
+			Networks:         requestedNetworks,
+			EndpointBindings: args.EndpointBindings,
 		})
 	return err
 }
@@ -320,10 +349,21 @@ func (c *Client) ServiceDeployWithNetworks(args params.ServiceDeploy) error {
 	return c.ServiceDeploy(args)
 }
 
+// ServiceDeployWithBindings works exactly like ServiceDeployWithNetworks,
+// but also binds the charm's endpoints to spaces as given by
+// args.EndpointBindings. It exists so that clients that need bindings
+// get an error from older API servers, which ignore the field.
+func (c *Client) ServiceDeployWithBindings(args params.ServiceDeploy) error {
+	return c.ServiceDeploy(args)
+}
+
 // ServiceUpdate updates the service attributes, including charm URL,
 // minimum number of units, settings and constraints.
 // All parameters in params.ServiceUpdate except the service name are optional.
 func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -447,6 +487,9 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 
 // ServiceSetCharm sets the charm for a given service.
 func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -471,6 +514,9 @@ func addServiceUnits(state *state.State, args params.AddServiceUnits) ([]*state.
 
 // AddServiceUnits adds a given number of units to a service.
 func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddServiceUnitsResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.AddServiceUnitsResults{}, err
+	}
 	units, err := addServiceUnits(c.api.state, args)
 	if err != nil {
 		return params.AddServiceUnitsResults{}, err
@@ -484,6 +530,9 @@ func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddService
 
 // DestroyServiceUnits removes a given set of service units.
 func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
+	if err := c.check.RemoveAllowed(); err != nil {
+		return err
+	}
 	var errs []string
 	for _, name := range args.UnitNames {
 		unit, err := c.api.state.Unit(name)
@@ -507,6 +556,9 @@ func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
 
 // ServiceDestroy destroys a given service.
 func (c *Client) ServiceDestroy(args params.ServiceDestroy) error {
+	if err := c.check.RemoveAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -535,6 +587,9 @@ func (c *Client) GetEnvironmentConstraints() (params.GetConstraintsResults, erro
 
 // SetServiceConstraints sets the constraints for a given service.
 func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -544,11 +599,17 @@ func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
 
 // SetEnvironmentConstraints sets the constraints for the environment.
 func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironConstraints(args.Constraints)
 }
 
 // AddRelation adds a relation between the specified endpoints and returns the relation info.
 func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.AddRelationResults{}, err
+	}
 	inEps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return params.AddRelationResults{}, err
@@ -570,6 +631,9 @@ func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults
 
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(args params.DestroyRelation) error {
+	if err := c.check.RemoveAllowed(); err != nil {
+		return err
+	}
 	eps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return err
@@ -588,6 +652,9 @@ func (c *Client) AddMachines(args params.AddMachines) (params.AddMachinesResults
 
 // AddMachinesV2 adds new machines with the supplied parameters.
 func (c *Client) AddMachinesV2(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	results := params.AddMachinesResults{
 		Machines: make([]params.AddMachinesResult, len(args.MachineParams)),
 	}
@@ -603,6 +670,9 @@ func (c *Client) AddMachinesV2(args params.AddMachines) (params.AddMachinesResul
 
 // InjectMachines injects a machine into state with provisioned status.
 func (c *Client) InjectMachines(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	return c.AddMachines(args)
 }
 
@@ -660,11 +730,11 @@ func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error
 		return nil, err
 	}
 	template := state.MachineTemplate{
-		Series:      p.Series,
-		Constraints: p.Constraints,
-		InstanceId:  p.InstanceId,
-		Jobs:        jobs,
-		Nonce:       p.Nonce,
+		Series:                  p.Series,
+		Constraints:             p.Constraints,
+		InstanceId:              p.InstanceId,
+		Jobs:                    jobs,
+		Nonce:                   p.Nonce,
 		HardwareCharacteristics: p.HardwareCharacteristics,
 		Addresses:               p.Addrs,
 		Placement:               placementDirective,
@@ -721,6 +791,9 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (param
 
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(args params.DestroyMachines) error {
+	if err := c.check.RemoveAllowed(); err != nil {
+		return err
+	}
 	var errs []string
 	for _, id := range args.MachineNames {
 		machine, err := c.api.state.Machine(id)
@@ -815,6 +888,9 @@ func (c *Client) findEntity(tag names.Tag) (state.Annotator, error) {
 
 // SetAnnotations stores annotations about a given entity.
 func (c *Client) SetAnnotations(args params.SetAnnotations) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	tag, err := names.ParseTag(args.Tag)
 	if err != nil {
 		return errors.Trace(err)
@@ -880,6 +956,9 @@ func (c *Client) EnvironmentGet() (params.EnvironmentGetResults, error) {
 // EnvironmentSet implements the server-side part of the
 // set-environment CLI command.
 func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	// Make sure we don't allow changing agent-version.
 	checkAgentVersion := func(updateAttrs map[string]interface{}, removeAttrs []string, oldConfig *config.Config) error {
 		if v, found := updateAttrs["agent-version"]; found {
@@ -899,6 +978,9 @@ func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
 // EnvironmentUnset implements the server-side part of the
 // set-environment CLI command.
 func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	// TODO(waigani) 2014-3-11 #1167616
 	// Add a txn retry loop to ensure that the settings on disk have not
 	// changed underneath us.
@@ -907,6 +989,9 @@ func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
 
 // SetEnvironAgentVersion sets the environment agent version.
 func (c *Client) SetEnvironAgentVersion(args params.SetEnvironAgentVersion) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironAgentVersion(args.Version)
 }
 
@@ -931,6 +1016,9 @@ func destroyErr(desc string, ids, errs []string) error {
 // the environment, if it does not exist yet. Local charms are not
 // supported, only charm store URLs. See also AddLocalCharm().
 func (c *Client) AddCharm(args params.CharmURL) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
 	charmURL, err := charm.ParseURL(args.URL)
 	if err != nil {
 		return err
@@ -941,9 +1029,15 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 	if charmURL.Revision < 0 {
 		return fmt.Errorf("charm URL must include revision")
 	}
+	return AddStoreCharm(c.api.state, charmURL)
+}
 
+// AddStoreCharm downloads the charm with the given URL from the charm
+// store, uploads it to the environment storage, and adds it to state,
+// unless it has been added already.
+func AddStoreCharm(st *state.State, charmURL *charm.URL) error {
 	// First, check if a pending or a real charm exists in state.
-	stateCharm, err := c.api.state.PrepareStoreCharmUpload(charmURL)
+	stateCharm, err := st.PrepareStoreCharmUpload(charmURL)
 	if err == nil && stateCharm.IsUploaded() {
 		// Charm already in state (it was uploaded already).
 		return nil
@@ -952,7 +1046,7 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 	}
 
 	// Get the charm and its information from the store.
-	envConfig, err := c.api.state.EnvironConfig()
+	envConfig, err := st.EnvironConfig()
 	if err != nil {
 		return err
 	}
@@ -1003,7 +1097,7 @@ func (c *Client) AddCharm(args params.CharmURL) error {
 	}
 
 	// Finally, update the charm data in state and mark it as no longer pending.
-	_, err = c.api.state.UpdateUploadedCharm(downloadedCharm, charmURL, bundleURL, bundleSHA256)
+	_, err = st.UpdateUploadedCharm(downloadedCharm, charmURL, bundleURL, bundleSHA256, size)
 	cause := errors.Cause(err)
 	if err == state.ErrCharmRevisionAlreadyModified ||
 		cause == state.ErrCharmRevisionAlreadyModified ||
@@ -1064,6 +1158,9 @@ func CharmArchiveName(name string, revision int) (string, error) {
 
 // RetryProvisioning marks a provisioning error as transient on the machines.
 func (c *Client) RetryProvisioning(p params.Entities) (params.ErrorResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.ErrorResults{}, err
+	}
 	entityStatus := make([]params.EntityStatus, len(p.Entities))
 	for i, entity := range p.Entities {
 		entityStatus[i] = params.EntityStatus{Tag: entity.Tag, Data: map[string]interface{}{"transient": true}}
@@ -1103,6 +1200,9 @@ func stateServersChanges(change state.StateServersChanges) params.StateServersCh
 
 // EnsureAvailability ensures the availability of Juju state servers.
 func (c *Client) EnsureAvailability(args params.StateServersSpecs) (params.StateServersChangeResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.StateServersChangeResults{}, err
+	}
 	results := params.StateServersChangeResults{Results: make([]params.StateServersChangeResult, len(args.Specs))}
 	for i, stateServersSpec := range args.Specs {
 		result, err := c.ensureAvailabilitySingle(stateServersSpec)
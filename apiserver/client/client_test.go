@@ -2058,7 +2058,7 @@ func (s *clientSuite) TestAddCharm(c *gc.C) {
 	curl := charm.MustParseURL("cs:quantal/" + ident)
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/" + ident)
 	c.Assert(err, gc.IsNil)
-	sch, err := s.State.AddCharm(charmDir, curl, bundleURL, ident+"-sha256")
+	sch, err := s.State.AddCharm(charmDir, curl, bundleURL, ident+"-sha256", 0)
 	c.Assert(err, gc.IsNil)
 
 	name := charm.Quote(sch.URL().String())
@@ -6,3 +6,5 @@ package client
 var ParseSettingsCompatible = parseSettingsCompatible
 var RemoteParamsForMachine = remoteParamsForMachine
 var GetAllUnitNames = getAllUnitNames
+var ReplicaSetStatus = &replicaSetStatus
+var StorageGCGracePeriod = &storageGCGracePeriod
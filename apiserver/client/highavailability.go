@@ -0,0 +1,110 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"time"
+
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/replicaset"
+	"github.com/juju/juju/state"
+)
+
+// replicaSetStatus returns the status and members of the mongo
+// replica set holding the given state. It is a variable so that it
+// can be patched by tests, which do not run mongo as a replica set.
+var replicaSetStatus = func(st *state.State) (*replicaset.Status, []replicaset.Member, error) {
+	session := st.MongoSession()
+	status, err := replicaset.CurrentStatus(session)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := replicaset.CurrentMembers(session)
+	if err != nil {
+		return nil, nil, err
+	}
+	return status, members, nil
+}
+
+// HighAvailabilityStatus reports on each state server machine and
+// its membership of the mongo replica set.
+func (c *Client) HighAvailabilityStatus() (params.HighAvailabilityStatus, error) {
+	var result params.HighAvailabilityStatus
+	info, err := c.api.state.StateServerInfo()
+	if err != nil {
+		return result, err
+	}
+	status, members, err := replicaSetStatus(c.api.state)
+	if err != nil {
+		return result, err
+	}
+	// Members are associated with machines through their tags,
+	// and member statuses with members through their ids.
+	memberIds := make(map[string]int)
+	for _, member := range members {
+		if machineId, ok := member.Tags[replicaset.JujuMachineKey]; ok {
+			memberIds[machineId] = member.Id
+		}
+	}
+	statuses := make(map[int]replicaset.MemberStatus)
+	var primaryOpTime time.Time
+	for _, memberStatus := range status.Members {
+		statuses[memberStatus.Id] = memberStatus
+		if memberStatus.State == replicaset.PrimaryState {
+			primaryOpTime = memberStatus.OpTime
+		}
+	}
+	for _, id := range info.MachineIds {
+		machine, err := c.api.state.Machine(id)
+		if err != nil {
+			return params.HighAvailabilityStatus{}, err
+		}
+		member := params.StateServerMemberStatus{
+			MachineId: id,
+			WantsVote: machine.WantsVote(),
+			HasVote:   machine.HasVote(),
+			Removing:  machine.IsRemovingStateServer(),
+		}
+		if memberId, ok := memberIds[id]; ok {
+			if memberStatus, ok := statuses[memberId]; ok {
+				member.Address = memberStatus.Address
+				member.State = memberStatus.State.String()
+				member.Healthy = memberStatus.Healthy
+				member.Message = memberStatus.ErrMsg
+				member.LastHeartbeat = memberStatus.LastHeartbeat
+				if !primaryOpTime.IsZero() && memberStatus.OpTime.Before(primaryOpTime) {
+					member.OpTimeLag = primaryOpTime.Sub(memberStatus.OpTime)
+				}
+			}
+		}
+		result.Members = append(result.Members, member)
+	}
+	return result, nil
+}
+
+// RemoveStateServer removes the state servers running on the given
+// machines, leaving the machines themselves in place. Each machine
+// is demoted and then dropped from the replica set by the worker
+// that maintains it.
+func (c *Client) RemoveStateServer(args params.Entities) (params.ErrorResults, error) {
+	if err := c.check.RemoveAllowed(); err != nil {
+		return params.ErrorResults{}, err
+	}
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseMachineTag(entity.Tag)
+		if err != nil {
+			results.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = c.api.state.RemoveStateServer(tag.Id())
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/client"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/replicaset"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type highAvailabilitySuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&highAvailabilitySuite{})
+
+func (s *highAvailabilitySuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	m0, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	// Machine 0's agent must be alive, or EnsureAvailability
+	// will demote it.
+	pinger, err := m0.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer assertKill(c, pinger)
+	s.State.StartSync()
+	err = m0.WaitAgentPresence(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *highAvailabilitySuite) TestHighAvailabilityStatus(c *gc.C) {
+	opTime := time.Date(2014, 10, 1, 12, 30, 0, 0, time.UTC)
+	heartbeat := time.Date(2014, 10, 1, 12, 30, 5, 0, time.UTC)
+	status := &replicaset.Status{
+		Members: []replicaset.MemberStatus{{
+			Id:      1,
+			Address: "10.0.0.1:37017",
+			Self:    true,
+			Healthy: true,
+			State:   replicaset.PrimaryState,
+			OpTime:  opTime,
+		}, {
+			Id:            2,
+			Address:       "10.0.0.2:37017",
+			Healthy:       true,
+			State:         replicaset.RecoveringState,
+			ErrMsg:        "still syncing",
+			OpTime:        opTime.Add(-5 * time.Second),
+			LastHeartbeat: heartbeat,
+		}},
+	}
+	members := []replicaset.Member{{
+		Id:   1,
+		Tags: map[string]string{replicaset.JujuMachineKey: "0"},
+	}, {
+		Id:   2,
+		Tags: map[string]string{replicaset.JujuMachineKey: "1"},
+	}}
+	s.PatchValue(client.ReplicaSetStatus, func(*state.State) (*replicaset.Status, []replicaset.Member, error) {
+		return status, members, nil
+	})
+	m0, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	err = m0.SetHasVote(true)
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.APIState.Client().HighAvailabilityStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.HighAvailabilityStatus{
+		Members: []params.StateServerMemberStatus{{
+			MachineId: "0",
+			WantsVote: true,
+			HasVote:   true,
+			Address:   "10.0.0.1:37017",
+			State:     "PRIMARY",
+			Healthy:   true,
+		}, {
+			MachineId:     "1",
+			WantsVote:     true,
+			Address:       "10.0.0.2:37017",
+			State:         "RECOVERING",
+			Healthy:       true,
+			Message:       "still syncing",
+			OpTimeLag:     5 * time.Second,
+			LastHeartbeat: heartbeat,
+		}, {
+			// Machine 2 is not yet a replica set member.
+			MachineId: "2",
+			WantsVote: true,
+		}},
+	})
+}
+
+func (s *highAvailabilitySuite) TestRemoveStateServer(c *gc.C) {
+	results, err := s.APIState.Client().RemoveStateServer("machine-1", "machine-42", "unit-foo-0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, []params.ErrorResult{
+		{nil},
+		{&params.Error{Message: "cannot remove state server 42: machine 42 not found", Code: params.CodeNotFound}},
+		{&params.Error{Message: "permission denied", Code: params.CodeUnauthorized}},
+	})
+
+	m1, err := s.State.Machine("1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m1.WantsVote(), jc.IsFalse)
+	c.Assert(m1.IsRemovingStateServer(), jc.IsTrue)
+	c.Assert(m1.Life(), gc.Equals, state.Alive)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.VotingMachineIds, jc.SameContents, []string{"0", "2"})
+}
@@ -0,0 +1,47 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Introspect asks the agents of the specified machines and units the
+// given introspection query, by running juju-introspect on the
+// machines the agents run on.
+func (c *Client) Introspect(args params.IntrospectParams) (results params.RunResults, err error) {
+	var execParams []*RemoteExec
+	quotedQuery := utils.ShQuote(args.Query)
+	for _, machineId := range args.Machines {
+		machine, err := c.api.state.Machine(machineId)
+		if err != nil {
+			return results, err
+		}
+		command := fmt.Sprintf("juju-introspect %s %s", machine.Tag(), quotedQuery)
+		execParams = append(execParams, remoteParamsForMachine(machine, command, args.Timeout))
+	}
+	for _, unitName := range args.Units {
+		unit, err := c.api.state.Unit(unitName)
+		if err != nil {
+			return results, err
+		}
+		machineId, err := unit.AssignedMachineId()
+		if err != nil {
+			return results, err
+		}
+		machine, err := c.api.state.Machine(machineId)
+		if err != nil {
+			return results, err
+		}
+		command := fmt.Sprintf("juju-introspect %s %s", unit.Tag(), quotedQuery)
+		execParam := remoteParamsForMachine(machine, command, args.Timeout)
+		execParam.UnitId = unit.Name()
+		execParams = append(execParams, execParam)
+	}
+	return ParallelExecute(c.getDataDir(), execParams), nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"gopkg.in/juju/charm.v3"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// ServiceSetCharmRolling sets the charm for a given service, and
+// upgrades its units args.BatchSize units at a time. The charm must
+// have been added to the environment already.
+func (c *Client) ServiceSetCharmRolling(args params.ServiceSetCharmRolling) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	curl, err := charm.ParseURL(args.CharmUrl)
+	if err != nil {
+		return err
+	}
+	ch, err := c.api.state.Charm(curl)
+	if err != nil {
+		return err
+	}
+	return service.SetCharmRolling(ch, args.Force, args.BatchSize)
+}
+
+// ServicePauseRollingUpgrade pauses the rolling upgrade of a service.
+func (c *Client) ServicePauseRollingUpgrade(args params.ServiceGet) error {
+	// Pausing is allowed even when changes are blocked, as it only
+	// stops an upgrade from doing further damage.
+	upgrade, err := c.rollingUpgrade(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	return upgrade.Pause()
+}
+
+// ServiceResumeRollingUpgrade resumes the paused rolling upgrade of a
+// service.
+func (c *Client) ServiceResumeRollingUpgrade(args params.ServiceGet) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	upgrade, err := c.rollingUpgrade(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	return upgrade.Resume()
+}
+
+// ServiceRollingUpgradeStatus returns the progress of the rolling
+// upgrade of a service.
+func (c *Client) ServiceRollingUpgradeStatus(args params.ServiceGet) (params.RollingUpgradeStatus, error) {
+	upgrade, err := c.rollingUpgrade(args.ServiceName)
+	if err != nil {
+		return params.RollingUpgradeStatus{}, err
+	}
+	pending, err := upgrade.Pending()
+	if err != nil {
+		return params.RollingUpgradeStatus{}, err
+	}
+	batch := upgrade.Batch()
+	inBatch := make(map[string]bool)
+	for _, name := range batch {
+		inBatch[name] = true
+	}
+	var upgraded []string
+	for _, name := range upgrade.Admitted() {
+		if !inBatch[name] {
+			upgraded = append(upgraded, name)
+		}
+	}
+	return params.RollingUpgradeStatus{
+		ServiceName: upgrade.ServiceName(),
+		CharmURL:    upgrade.CharmURL().String(),
+		BatchSize:   upgrade.BatchSize(),
+		Status:      string(upgrade.Status()),
+		Message:     upgrade.Message(),
+		Batch:       batch,
+		Upgraded:    upgraded,
+		Pending:     pending,
+	}, nil
+}
+
+func (c *Client) rollingUpgrade(serviceName string) (*state.RollingUpgrade, error) {
+	service, err := c.api.state.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return service.RollingUpgrade()
+}
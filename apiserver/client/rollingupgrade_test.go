@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+type rollingUpgradeSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&rollingUpgradeSuite{})
+
+func (s *rollingUpgradeSuite) TestRollingUpgrade(c *gc.C) {
+	oldCharm := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", oldCharm)
+	for i := 0; i < 3; i++ {
+		unit, err := service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(oldCharm.URL())
+		c.Assert(err, gc.IsNil)
+	}
+	newCharm := s.AddMetaCharm(c, "wordpress", "name: wordpress\nsummary: blog\ndescription: blog\n", 42)
+
+	client := s.APIState.Client()
+	err := client.ServiceSetCharmRolling("wordpress", newCharm.String(), false, 2)
+	c.Assert(err, gc.IsNil)
+	upgrade, err := service.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+
+	err = client.ServicePauseRollingUpgrade("wordpress")
+	c.Assert(err, gc.IsNil)
+	status, err := client.ServiceRollingUpgradeStatus("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, jc.DeepEquals, params.RollingUpgradeStatus{
+		ServiceName: "wordpress",
+		CharmURL:    newCharm.String(),
+		BatchSize:   2,
+		Status:      "paused",
+		Batch:       []string{"wordpress/0", "wordpress/1"},
+		Pending:     []string{"wordpress/2"},
+	})
+
+	err = client.ServiceResumeRollingUpgrade("wordpress")
+	c.Assert(err, gc.IsNil)
+	status, err = client.ServiceRollingUpgradeStatus("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Status, gc.Equals, "running")
+}
+
+func (s *rollingUpgradeSuite) TestRollingUpgradeErrors(c *gc.C) {
+	charm := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", charm)
+	client := s.APIState.Client()
+
+	err := client.ServiceSetCharmRolling("wordpress", charm.String(), false, 0)
+	c.Assert(err, gc.ErrorMatches, `cannot upgrade service "wordpress" in batches of 0 units`)
+	err = client.ServiceSetCharmRolling("wordpress", "cs:precise/nosuch-1", false, 1)
+	c.Assert(err, gc.ErrorMatches, `charm "cs:precise/nosuch-1" not found`)
+	_, err = client.ServiceRollingUpgradeStatus("wordpress")
+	c.Assert(err, gc.ErrorMatches, `rolling upgrade of service "wordpress" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+	err = client.ServicePauseRollingUpgrade("nosuch")
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
+)
+
+// RotateCA starts replacing the environment's CA with the given one,
+// or a newly generated one if none is given, or, if args.Finish is
+// set, makes the new CA sign the state server certificates; the
+// replacement is complete once all state servers use them. It returns
+// the CA certificates trusted by the environment afterwards.
+func (c *Client) RotateCA(args params.RotateCA) (params.RotateCAResult, error) {
+	var result params.RotateCAResult
+	if err := c.check.ChangeAllowed(); err != nil {
+		return result, err
+	}
+	st := c.api.state
+	hasCA := args.CACert != "" || args.CAPrivateKey != ""
+	switch {
+	case args.Finish && hasCA:
+		return result, errors.New("cannot specify a CA when finishing a CA rotation")
+	case args.Finish:
+		if err := st.FinishCARotation(); err != nil {
+			return result, err
+		}
+	default:
+		caCert, caKey := args.CACert, args.CAPrivateKey
+		if !hasCA {
+			cfg, err := st.EnvironConfig()
+			if err != nil {
+				return result, err
+			}
+			caCert, caKey, err = cert.NewCA(cfg.Name(), time.Now().UTC().AddDate(10, 0, 0))
+			if err != nil {
+				return result, err
+			}
+		}
+		if err := st.StartCARotation(caCert, caKey); err != nil {
+			return result, err
+		}
+	}
+	caCert, err := st.TrustedCACert()
+	if err != nil {
+		return result, err
+	}
+	result.CACert = caCert
+	return result, nil
+}
+
+// SetServerCertificate makes the state servers use the given
+// externally signed certificate instead of one issued by the
+// environment's CA.
+func (c *Client) SetServerCertificate(args params.ServerCertificate) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	return c.api.state.SetServerCertificate(args.CACert, args.Cert, args.PrivateKey)
+}
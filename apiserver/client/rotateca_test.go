@@ -0,0 +1,119 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type rotateCASuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&rotateCASuite{})
+
+func (s *rotateCASuite) SetUpTest(c *gc.C) {
+	s.baseSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(state.StateServingInfo{
+		APIPort:      1234,
+		StatePort:    2345,
+		Cert:         coretesting.ServerCert,
+		PrivateKey:   coretesting.ServerKey,
+		CAPrivateKey: coretesting.CAKey,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *rotateCASuite) TestRotateCA(c *gc.C) {
+	client := s.APIState.Client()
+	trusted, err := client.RotateCA(false)
+	c.Assert(err, gc.IsNil)
+	caCerts, err := cert.SplitCerts(trusted)
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCerts, gc.HasLen, 2)
+	c.Assert(caCerts[0], gc.Equals, coretesting.CACert)
+	inProgress, err := s.State.CARotationInProgress()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inProgress, jc.IsTrue)
+
+	_, err = client.RotateCA(false)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: CA rotation already in progress")
+
+	// The old CA stays trusted until the state servers use
+	// certificates signed by the new one.
+	trusted, err = client.RotateCA(true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.Equals, caCerts[1]+caCerts[0])
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	_, _, err = cert.ParseCertAndKey(caCerts[1], info.CAPrivateKey)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *rotateCASuite) TestFinishNotStarted(c *gc.C) {
+	_, err := s.APIState.Client().RotateCA(true)
+	c.Assert(err, gc.ErrorMatches, "cannot finish CA rotation: no CA rotation in progress")
+}
+
+func (s *rotateCASuite) TestRotateCABlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().RotateCA(false)
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked.*")
+}
+
+func (s *rotateCASuite) TestImportCA(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("imported", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	client := s.APIState.Client()
+	trusted, err := client.ImportCA(caCert, caKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.Equals, coretesting.CACert+caCert)
+
+	trusted, err = client.RotateCA(true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.Equals, caCert+coretesting.CACert)
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.CAPrivateKey, gc.Equals, caKey)
+}
+
+func (s *rotateCASuite) TestImportCAInvalid(c *gc.C) {
+	_, err := s.APIState.Client().ImportCA(coretesting.CACert, coretesting.ServerKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: invalid CA certificate or key: .*")
+}
+
+func (s *rotateCASuite) TestSetServerCertificate(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetServerCertificate(caCert, srvCert, srvKey)
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, srvCert)
+	c.Assert(info.CAPrivateKey, gc.Equals, "")
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.Equals, caCert)
+}
+
+func (s *rotateCASuite) TestSetServerCertificateNotSignedByCA(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetServerCertificate(coretesting.CACert, srvCert, srvKey)
+	c.Assert(err, gc.ErrorMatches, "cannot set server certificate: invalid certificate: x509: certificate signed by unknown authority.*")
+}
@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/juju/errors"
+	"github.com/juju/names"
 	"github.com/juju/utils"
 	"github.com/juju/utils/set"
 
@@ -83,6 +85,9 @@ func (c *Client) getDataDir() string {
 // Run the commands specified on the machines identified through the
 // list of machines, units and services.
 func (c *Client) Run(run params.RunParams) (results params.RunResults, err error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return results, err
+	}
 	units, err := getAllUnitNames(c.api.state, run.Units, run.Services)
 	if err != nil {
 		return results, err
@@ -120,6 +125,9 @@ func (c *Client) Run(run params.RunParams) (results params.RunResults, err error
 
 // RunOnAllMachines attempts to run the specified command on all the machines.
 func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunResults{}, err
+	}
 	machines, err := c.api.state.AllMachines()
 	if err != nil {
 		return params.RunResults{}, err
@@ -133,6 +141,89 @@ func (c *Client) RunOnAllMachines(run params.RunParams) (params.RunResults, erro
 	return ParallelExecute(c.getDataDir(), params), nil
 }
 
+// RunAsync queues a job running the commands on the specified
+// targets, and returns its id without waiting for the commands to
+// run. The agent of each target's machine runs the commands and
+// records the result, which can be retrieved with RunStatus.
+func (c *Client) RunAsync(run params.RunAsyncParams) (params.RunJobId, error) {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return params.RunJobId{}, err
+	}
+	var targets []names.Tag
+	if run.AllMachines {
+		if len(run.Machines) != 0 || len(run.Services) != 0 || len(run.Units) != 0 {
+			return params.RunJobId{}, errors.New("cannot specify targets when running on all machines")
+		}
+		machines, err := c.api.state.AllMachines()
+		if err != nil {
+			return params.RunJobId{}, err
+		}
+		for _, machine := range machines {
+			if machine.Life() == state.Alive {
+				targets = append(targets, machine.Tag())
+			}
+		}
+	} else {
+		units, err := getAllUnitNames(c.api.state, run.Units, run.Services)
+		if err != nil {
+			return params.RunJobId{}, err
+		}
+		for _, unit := range units {
+			targets = append(targets, unit.Tag())
+		}
+		for _, machineId := range run.Machines {
+			if !names.IsValidMachine(machineId) {
+				return params.RunJobId{}, errors.NotValidf("machine id %q", machineId)
+			}
+			targets = append(targets, names.NewMachineTag(machineId))
+		}
+	}
+	job, err := c.api.state.AddRunJob(run.Commands, run.Timeout, run.Parallelism, targets)
+	if err != nil {
+		return params.RunJobId{}, err
+	}
+	return params.RunJobId{JobId: job.Id()}, nil
+}
+
+// RunStatus returns the progress of an asynchronous run job, with
+// the results of the targets that have finished.
+func (c *Client) RunStatus(args params.RunJobId) (params.RunJobStatus, error) {
+	job, err := c.api.state.RunJob(args.JobId)
+	if err != nil {
+		return params.RunJobStatus{}, err
+	}
+	tasks, err := job.Tasks()
+	if err != nil {
+		return params.RunJobStatus{}, err
+	}
+	status := params.RunJobStatus{
+		JobId:    job.Id(),
+		Commands: job.Commands(),
+		Done:     true,
+	}
+	for _, task := range tasks {
+		runResult := params.RunResult{
+			MachineId: task.MachineId(),
+			Status:    string(task.Status()),
+		}
+		if tag, err := names.ParseUnitTag(task.Target()); err == nil {
+			runResult.UnitId = tag.Id()
+		}
+		if task.Status().Done() {
+			result := task.Result()
+			runResult.Code = result.Code
+			runResult.Stdout = result.Stdout
+			runResult.Stderr = result.Stderr
+			runResult.Error = result.Error
+		} else {
+			status.Done = false
+		}
+		status.Results = append(status.Results, runResult)
+	}
+	sort.Stable(MachineOrder(status.Results))
+	return status, nil
+}
+
 // RemoteExec extends the standard ssh.ExecParams by providing the machine and
 // perhaps the unit ids.  These are then returned in the params.RunResult return
 // values.
@@ -303,6 +303,156 @@ func (s *runSuite) TestRunMachineAndService(c *gc.C) {
 	c.Assert(results, jc.DeepEquals, expectedResults)
 }
 
+func (s *runSuite) TestIntrospectMachineAndUnit(c *gc.C) {
+	s.addMachineWithAddress(c, "10.3.2.1")
+	charm := s.AddTestingCharm(c, "dummy")
+	owner := s.Factory.MakeUser(c, nil).Tag()
+	magic, err := s.State.AddService("magic", owner.String(), charm, nil)
+	c.Assert(err, gc.IsNil)
+	s.addUnit(c, magic)
+
+	s.mockSSH(c, echoInput)
+
+	results, err := s.APIState.Client().Introspect(params.IntrospectParams{
+		Query:    "workers",
+		Timeout:  testing.LongWait,
+		Machines: []string{"0"},
+		Units:    []string{"magic/0"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, []params.RunResult{{
+		ExecResponse: exec.ExecResponse{Stdout: []byte("juju-introspect machine-0 'workers'\n")},
+		MachineId:    "0",
+	}, {
+		ExecResponse: exec.ExecResponse{Stdout: []byte("juju-introspect unit-magic-0 'workers'\n")},
+		MachineId:    "1",
+		UnitId:       "magic/0",
+	}})
+}
+
+func (s *runSuite) TestIntrospectUnknownUnit(c *gc.C) {
+	_, err := s.APIState.Client().Introspect(params.IntrospectParams{
+		Query: "workers",
+		Units: []string{"magic/0"},
+	})
+	c.Assert(err, gc.ErrorMatches, `unit "magic/0" not found`)
+}
+
+func (s *runSuite) TestRunAsyncAndStatus(c *gc.C) {
+	s.addMachine(c)
+	charm := s.AddTestingCharm(c, "dummy")
+	owner := s.Factory.MakeUser(c, nil).Tag()
+	magic, err := s.State.AddService("magic", owner.String(), charm, nil)
+	c.Assert(err, gc.IsNil)
+	s.addUnit(c, magic)
+
+	client := s.APIState.Client()
+	jobId, err := client.RunAsync(params.RunAsyncParams{
+		RunParams: params.RunParams{
+			Commands: "hostname",
+			Timeout:  testing.LongWait,
+			Machines: []string{"0"},
+			Services: []string{"magic"},
+		},
+		Parallelism: 1,
+	})
+	c.Assert(err, gc.IsNil)
+
+	status, err := client.RunStatus(jobId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, jc.DeepEquals, params.RunJobStatus{
+		JobId:    jobId,
+		Commands: "hostname",
+		Results: []params.RunResult{{
+			MachineId: "0",
+			Status:    "queued",
+		}, {
+			MachineId: "1",
+			UnitId:    "magic/0",
+			Status:    "pending",
+		}},
+	})
+
+	// Simulate the agents running the commands.
+	job, err := s.State.RunJob(jobId)
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 2; i++ {
+		tasks, err := job.Tasks()
+		c.Assert(err, gc.IsNil)
+		err = tasks[i].Start()
+		c.Assert(err, gc.IsNil)
+		err = tasks[i].Finish(state.RunTaskResult{Stdout: []byte(tasks[i].Target())})
+		c.Assert(err, gc.IsNil)
+	}
+
+	status, err = client.RunStatus(jobId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, jc.DeepEquals, params.RunJobStatus{
+		JobId:    jobId,
+		Commands: "hostname",
+		Done:     true,
+		Results: []params.RunResult{{
+			ExecResponse: exec.ExecResponse{Stdout: []byte("machine-0")},
+			MachineId:    "0",
+			Status:       "completed",
+		}, {
+			ExecResponse: exec.ExecResponse{Stdout: []byte("unit-magic-0")},
+			MachineId:    "1",
+			UnitId:       "magic/0",
+			Status:       "completed",
+		}},
+	})
+}
+
+func (s *runSuite) TestRunBlocked(c *gc.C) {
+	s.addMachine(c)
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().Run(params.RunParams{Commands: "hostname", Machines: []string{"0"}})
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked.*")
+}
+
+func (s *runSuite) TestRunOnAllMachinesBlocked(c *gc.C) {
+	s.addMachine(c)
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().RunOnAllMachines("hostname", testing.LongWait)
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked.*")
+}
+
+func (s *runSuite) TestRunAsyncBlocked(c *gc.C) {
+	s.addMachine(c)
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().RunAsync(params.RunAsyncParams{
+		RunParams: params.RunParams{Commands: "hostname", Machines: []string{"0"}},
+	})
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked.*")
+}
+
+func (s *runSuite) TestRunAsyncAllMachines(c *gc.C) {
+	s.addMachine(c)
+	s.addMachine(c)
+	client := s.APIState.Client()
+	_, err := client.RunAsync(params.RunAsyncParams{
+		RunParams:   params.RunParams{Commands: "hostname", Machines: []string{"0"}},
+		AllMachines: true,
+	})
+	c.Assert(err, gc.ErrorMatches, "cannot specify targets when running on all machines")
+
+	jobId, err := client.RunAsync(params.RunAsyncParams{
+		RunParams:   params.RunParams{Commands: "hostname"},
+		AllMachines: true,
+	})
+	c.Assert(err, gc.IsNil)
+	status, err := client.RunStatus(jobId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Results, gc.HasLen, 2)
+
+	_, err = client.RunStatus("42")
+	c.Assert(err, gc.ErrorMatches, `run job "42" not found`)
+}
+
 var echoInputShowArgs = `#!/bin/bash
 # Write the args to stderr
 echo "$*" >&2
@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"time"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// ServiceSetScalingPolicy sets or removes the policy by which a
+// service is scaled automatically.
+func (c *Client) ServiceSetScalingPolicy(args params.ServiceSetScalingPolicy) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	if args.Policy == nil {
+		return service.RemoveScalingPolicy()
+	}
+	policy := state.ScalingPolicy{
+		Min:       args.Policy.Min,
+		Max:       args.Policy.Max,
+		Cooldown:  args.Policy.Cooldown,
+		Placement: args.Policy.Placement,
+	}
+	for _, rule := range args.Policy.Rules {
+		policy.Rules = append(policy.Rules, state.ScalingRule{
+			Metric: rule.Metric,
+			Above:  rule.Above,
+			Below:  rule.Below,
+			Step:   rule.Step,
+		})
+	}
+	return service.SetScalingPolicy(policy)
+}
+
+// ServiceScaling returns the scaling policy of a service, and how it
+// currently applies.
+func (c *Client) ServiceScaling(args params.ServiceGet) (params.ServiceScalingResult, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.ServiceScalingResult{}, err
+	}
+	scaling, err := service.Scaling()
+	if err != nil {
+		return params.ServiceScalingResult{}, err
+	}
+	eval, err := scaling.Evaluate(time.Now())
+	if err != nil {
+		return params.ServiceScalingResult{}, err
+	}
+	policy := scaling.Policy()
+	result := params.ServiceScalingResult{
+		ServiceName: service.Name(),
+		Policy: params.ScalingPolicy{
+			Min:       policy.Min,
+			Max:       policy.Max,
+			Cooldown:  policy.Cooldown,
+			Placement: policy.Placement,
+			Rules:     make([]params.ScalingRule, len(policy.Rules)),
+		},
+		Units:      eval.Units,
+		Desired:    eval.Desired,
+		Reason:     eval.Reason,
+		Cooldown:   eval.Cooldown,
+		Values:     make([]params.ScalingRuleValue, len(eval.Values)),
+		LastScaled: scaling.LastScaled(),
+		LastAction: scaling.LastAction(),
+	}
+	for i, rule := range policy.Rules {
+		result.Policy.Rules[i] = scalingRuleToParams(rule)
+	}
+	for i, value := range eval.Values {
+		result.Values[i] = params.ScalingRuleValue{
+			Rule:  scalingRuleToParams(value.Rule),
+			Known: value.Known,
+			Value: value.Value,
+		}
+	}
+	return result, nil
+}
+
+func scalingRuleToParams(rule state.ScalingRule) params.ScalingRule {
+	return params.ScalingRule{
+		Metric: rule.Metric,
+		Above:  rule.Above,
+		Below:  rule.Below,
+		Step:   rule.Step,
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+type scalingSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&scalingSuite{})
+
+func (s *scalingSuite) TestServiceScaling(c *gc.C) {
+	ch := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", ch)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(ch.URL())
+	c.Assert(err, gc.IsNil)
+	now := time.Now()
+	_, err = unit.AddMetrics(now, []state.Metric{{Key: "load", Value: "0.9", Time: now}})
+	c.Assert(err, gc.IsNil)
+
+	above := 0.8
+	policy := params.ScalingPolicy{
+		Min:      1,
+		Max:      3,
+		Cooldown: time.Minute,
+		Rules:    []params.ScalingRule{{Metric: "load", Above: &above}},
+	}
+	client := s.APIState.Client()
+	err = client.ServiceSetScalingPolicy("wordpress", &policy)
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.Refresh(), gc.IsNil)
+	c.Assert(service.MinUnits(), gc.Equals, 1)
+
+	result, err := client.ServiceScaling("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.ServiceScalingResult{
+		ServiceName: "wordpress",
+		Policy:      policy,
+		Units:       1,
+		Desired:     2,
+		Reason:      "load is 0.9, above 0.8",
+		Values: []params.ScalingRuleValue{{
+			Rule:  policy.Rules[0],
+			Known: true,
+			Value: 0.9,
+		}},
+	})
+
+	err = client.ServiceSetScalingPolicy("wordpress", nil)
+	c.Assert(err, gc.IsNil)
+	_, err = client.ServiceScaling("wordpress")
+	c.Assert(err, gc.ErrorMatches, `scaling policy of service "wordpress" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *scalingSuite) TestServiceSetScalingPolicyErrors(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	client := s.APIState.Client()
+	err := client.ServiceSetScalingPolicy("wordpress", &params.ScalingPolicy{Min: 2, Max: 1})
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy of service "wordpress": maximum of 1 units with minimum of 2 units not valid`)
+	err = client.ServiceSetScalingPolicy("nosuch", &params.ScalingPolicy{Max: 1})
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+
+	err = s.State.SwitchBlockOn("all-changes", "frozen")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceSetScalingPolicy("wordpress", &params.ScalingPolicy{Max: 1})
+	c.Assert(err, gc.ErrorMatches, "the operation has been blocked: frozen")
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+// AddSubnet adds a subnet to the environment.
+func (c *Client) AddSubnet(args params.AddSubnet) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	_, err := c.api.state.AddSubnet(state.SubnetInfo{
+		CIDR:             args.CIDR,
+		ProviderId:       network.Id(args.ProviderId),
+		VLANTag:          args.VLANTag,
+		AvailabilityZone: args.AvailabilityZone,
+	})
+	return err
+}
+
+// ListSubnets returns all the subnets in the environment.
+func (c *Client) ListSubnets() (params.SubnetsResult, error) {
+	var result params.SubnetsResult
+	subnets, err := c.api.state.AllSubnets()
+	if err != nil {
+		return result, err
+	}
+	for _, subnet := range subnets {
+		result.Subnets = append(result.Subnets, params.SubnetInfo{
+			CIDR:             subnet.CIDR(),
+			ProviderId:       string(subnet.ProviderId()),
+			VLANTag:          subnet.VLANTag(),
+			AvailabilityZone: subnet.AvailabilityZone(),
+			Space:            subnet.SpaceName(),
+		})
+	}
+	return result, nil
+}
+
+// AddSpace creates a space containing the given subnets.
+func (c *Client) AddSpace(args params.AddSpace) error {
+	if err := c.check.ChangeAllowed(); err != nil {
+		return err
+	}
+	_, err := c.api.state.AddSpace(args.Name, args.Subnets)
+	return err
+}
+
+// ListSpaces returns all the spaces in the environment, with the
+// CIDRs of their subnets.
+func (c *Client) ListSpaces() (params.SpacesResult, error) {
+	var result params.SpacesResult
+	spaces, err := c.api.state.AllSpaces()
+	if err != nil {
+		return result, err
+	}
+	for _, space := range spaces {
+		subnets, err := space.Subnets()
+		if err != nil {
+			return result, err
+		}
+		info := params.SpaceInfo{Name: space.Name()}
+		for _, subnet := range subnets {
+			info.Subnets = append(info.Subnets, subnet.CIDR())
+		}
+		result.Spaces = append(result.Spaces, info)
+	}
+	return result, nil
+}
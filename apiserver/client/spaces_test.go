@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/constraints"
+)
+
+type spacesSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&spacesSuite{})
+
+func (s *spacesSuite) TestSubnetsAndSpaces(c *gc.C) {
+	client := s.APIState.Client()
+	err := client.AddSubnet("10.0.1.0/24", "subnet-1", 42, "zone1")
+	c.Assert(err, gc.IsNil)
+	err = client.AddSubnet("10.0.2.0/24", "", 0, "")
+	c.Assert(err, gc.IsNil)
+	err = client.AddSubnet("10.0.2.0/24", "", 0, "")
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.2.0/24": subnet "10.0.2.0/24" already exists`)
+
+	err = client.AddSpace("dmz", []string{"10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	err = client.AddSpace("empty", nil)
+	c.Assert(err, gc.IsNil)
+
+	subnets, err := client.ListSubnets()
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnets, jc.DeepEquals, []params.SubnetInfo{{
+		CIDR:             "10.0.1.0/24",
+		ProviderId:       "subnet-1",
+		VLANTag:          42,
+		AvailabilityZone: "zone1",
+		Space:            "dmz",
+	}, {
+		CIDR: "10.0.2.0/24",
+	}})
+
+	spaces, err := client.ListSpaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(spaces, jc.DeepEquals, []params.SpaceInfo{
+		{Name: "dmz", Subnets: []string{"10.0.1.0/24"}},
+		{Name: "empty"},
+	})
+}
+
+func (s *spacesSuite) TestServiceDeployWithBindings(c *gc.C) {
+	_, err := s.State.AddSpace("dmz", nil)
+	c.Assert(err, gc.IsNil)
+	store, restore := makeMockCharmStore()
+	defer restore()
+	curl, _ := addCharm(c, store, "wordpress")
+	err = s.APIState.Client().ServiceDeployWithBindings(
+		curl.String(), "wordpress", 1, "", constraints.Value{}, "", nil,
+		map[string]string{"db": "dmz"},
+	)
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, map[string]string{"db": "dmz"})
+}
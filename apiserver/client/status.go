@@ -491,6 +491,12 @@ func (context *statusContext) processService(service *state.Service) (status api
 	if ok && latestCharm != serviceCharmURL.String() {
 		status.CanUpgradeTo = latestCharm
 	}
+	if policy, window := service.UpgradePolicy(); policy != state.UpgradeNever {
+		status.UpgradePolicy = string(policy)
+		if window != nil {
+			status.UpgradeWindow = window.String()
+		}
+	}
 	var err error
 	status.Relations, status.SubordinateTo, err = context.processServiceRelations(service)
 	if err != nil {
@@ -537,8 +543,12 @@ func (context *statusContext) processUnits(units map[string]*state.Unit, service
 
 func (context *statusContext) processUnit(unit *state.Unit, serviceCharm string) (status api.UnitStatus) {
 	status.PublicAddress, _ = unit.PublicAddress()
-	for _, port := range unit.OpenedPorts() {
-		status.OpenedPorts = append(status.OpenedPorts, port.String())
+	ranges, err := unit.OpenedPorts()
+	if err != nil {
+		logger.Debugf("cannot get opened ports of unit %q: %v", unit.Name(), err)
+	}
+	for _, portRange := range ranges {
+		status.OpenedPorts = append(status.OpenedPorts, portRange.DisplayString())
 	}
 	if unit.IsPrincipal() {
 		status.Machine, _ = unit.AssignedMachineId()
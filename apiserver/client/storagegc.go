@@ -0,0 +1,47 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/state/storagegc"
+)
+
+// storageGCGracePeriod is how long a charm must have been in state
+// before StorageGC will consider it. It is a variable so that it
+// can be patched by tests.
+var storageGCGracePeriod = storagegc.DefaultGracePeriod
+
+// StorageGC finds the charm archives and tools tarballs that are no
+// longer used in the environment and, unless args.DryRun is set,
+// removes them.
+func (c *Client) StorageGC(args params.StorageGCArgs) (params.StorageGCResult, error) {
+	var result params.StorageGCResult
+	if !args.DryRun {
+		if err := c.check.RemoveAllowed(); err != nil {
+			return result, err
+		}
+	}
+	stor, err := environs.GetStorage(c.api.state)
+	if err != nil {
+		return result, err
+	}
+	report, err := storagegc.Collect(c.api.state, storagegc.Params{
+		Storage:     stor,
+		GracePeriod: storageGCGracePeriod,
+		DryRun:      args.DryRun,
+	})
+	if err != nil {
+		return result, err
+	}
+	for _, item := range report.Items {
+		result.Items = append(result.Items, params.StorageGCItem{
+			Kind: item.Kind,
+			Name: item.Name,
+			Size: item.Size,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/client"
+)
+
+type storageGCSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&storageGCSuite{})
+
+func (s *storageGCSuite) TestStorageGC(c *gc.C) {
+	s.PatchValue(client.StorageGCGracePeriod, time.Duration(0))
+	used := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", used)
+	unused := s.AddTestingCharm(c, "mysql")
+
+	items, err := s.APIState.Client().StorageGC(true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(items, gc.HasLen, 1)
+	c.Assert(items[0].Kind, gc.Equals, "charm")
+	c.Assert(items[0].Name, gc.Equals, unused.URL().String())
+	c.Assert(items[0].Size > 0, jc.IsTrue)
+	_, err = s.State.Charm(unused.URL())
+	c.Assert(err, gc.IsNil)
+
+	removed, err := s.APIState.Client().StorageGC(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(removed, gc.DeepEquals, items)
+	_, err = s.State.Charm(unused.URL())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *storageGCSuite) TestStorageGCGracePeriod(c *gc.C) {
+	s.AddTestingCharm(c, "mysql")
+	items, err := s.APIState.Client().StorageGC(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(items, gc.HasLen, 0)
+}
@@ -16,8 +16,10 @@ type AddressAndCertGetter interface {
 	Addresses() ([]string, error)
 	APIAddressesFromMachines() ([]string, error)
 	CACert() string
+	TrustedCACert() (string, error)
 	APIHostPorts() ([][]network.HostPort, error)
 	WatchAPIHostPorts() state.NotifyWatcher
+	WatchTrustedCACert() state.NotifyWatcher
 }
 
 // APIAddresser implements the APIAddresses method
@@ -75,11 +77,27 @@ func (api *APIAddresser) APIAddresses() (params.StringsResult, error) {
 	}, nil
 }
 
-// CACert returns the certificate used to validate the state connection.
-func (a *APIAddresser) CACert() params.BytesResult {
+// CACert returns the certificates of the CAs trusted to sign the
+// certificates used to validate the state and API connections.
+func (a *APIAddresser) CACert() (params.BytesResult, error) {
+	caCert, err := a.getter.TrustedCACert()
+	if err != nil {
+		return params.BytesResult{}, err
+	}
 	return params.BytesResult{
-		Result: []byte(a.getter.CACert()),
+		Result: []byte(caCert),
+	}, nil
+}
+
+// WatchCACert watches the certificates of the trusted CAs.
+func (api *APIAddresser) WatchCACert() (params.NotifyWatchResult, error) {
+	watch := api.getter.WatchTrustedCACert()
+	if _, ok := <-watch.Changes(); ok {
+		return params.NotifyWatchResult{
+			NotifyWatcherId: api.resources.Register(watch),
+		}, nil
 	}
+	return params.NotifyWatchResult{}, watcher.MustErr(watch)
 }
 
 // StateAddresser implements a common set of methods for getting state
@@ -46,8 +46,9 @@ func (s *apiAddresserSuite) TestAPIAddresses(c *gc.C) {
 }
 
 func (s *apiAddresserSuite) TestCACert(c *gc.C) {
-	result := s.addresser.CACert()
-	c.Assert(string(result.Result), gc.Equals, "a cert")
+	result, err := s.addresser.CACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(result.Result), gc.Equals, "trusted certs")
 }
 
 var _ common.AddressAndCertGetter = fakeAddresses{}
@@ -66,6 +67,10 @@ func (fakeAddresses) CACert() string {
 	return "a cert"
 }
 
+func (fakeAddresses) TrustedCACert() (string, error) {
+	return "trusted certs", nil
+}
+
 func (fakeAddresses) APIHostPorts() ([][]network.HostPort, error) {
 	return [][]network.HostPort{{{
 		Address: network.NewAddress("apiaddresses", network.ScopeUnknown),
@@ -79,3 +84,7 @@ func (fakeAddresses) APIHostPorts() ([][]network.HostPort, error) {
 func (fakeAddresses) WatchAPIHostPorts() state.NotifyWatcher {
 	panic("should never be called")
 }
+
+func (fakeAddresses) WatchTrustedCACert() state.NotifyWatcher {
+	panic("should never be called")
+}
@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"github.com/juju/juju/state"
+)
+
+// BlockGetter is implemented by state.State; it is an interface so
+// that it can be mocked in tests.
+type BlockGetter interface {
+	GetBlockForType(t state.BlockType) (*state.Block, bool, error)
+}
+
+// BlockChecker checks the environment blocks switched on by
+// administrators before operations that they guard against.
+type BlockChecker struct {
+	getter BlockGetter
+}
+
+// NewBlockChecker returns a new BlockChecker.
+func NewBlockChecker(getter BlockGetter) *BlockChecker {
+	return &BlockChecker{getter}
+}
+
+// ChangeAllowed returns an error satisfying IsOperationBlockedError
+// if operations that change the environment are blocked.
+func (c *BlockChecker) ChangeAllowed() error {
+	return c.checkBlock(state.ChangeBlock)
+}
+
+// RemoveAllowed returns an error satisfying IsOperationBlockedError
+// if operations that remove entities from the environment are
+// blocked.
+func (c *BlockChecker) RemoveAllowed() error {
+	return c.checkBlock(state.ChangeBlock, state.RemoveBlock)
+}
+
+// DestroyAllowed returns an error satisfying IsOperationBlockedError
+// if the environment may not be destroyed.
+func (c *BlockChecker) DestroyAllowed() error {
+	return c.checkBlock(state.ChangeBlock, state.RemoveBlock, state.DestroyBlock)
+}
+
+func (c *BlockChecker) checkBlock(types ...state.BlockType) error {
+	for _, t := range types {
+		block, found, err := c.getter.GetBlockForType(t)
+		if err != nil {
+			return err
+		}
+		if found {
+			return OperationBlockedError(block.Message())
+		}
+	}
+	return nil
+}
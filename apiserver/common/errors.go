@@ -63,6 +63,30 @@ func IsUnknownEnviromentError(err error) bool {
 	return ok
 }
 
+type operationBlockedError struct {
+	msg string
+}
+
+func (e *operationBlockedError) Error() string {
+	return e.msg
+}
+
+// OperationBlockedError returns an error which signifies that the
+// operation has been blocked by an environment block; the message
+// includes the reason given when the block was switched on.
+func OperationBlockedError(reason string) error {
+	msg := "the operation has been blocked"
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return &operationBlockedError{msg: msg}
+}
+
+func IsOperationBlockedError(err error) bool {
+	_, ok := err.(*operationBlockedError)
+	return ok
+}
+
 var (
 	ErrBadId          = stderrors.New("id not found")
 	ErrBadCreds       = stderrors.New("invalid entity name or password")
@@ -73,6 +97,9 @@ var (
 	ErrStoppedWatcher = stderrors.New("watcher has been stopped")
 	ErrBadRequest     = stderrors.New("invalid request")
 	ErrTryAgain       = stderrors.New("try again")
+
+	ErrRateLimitExceeded = stderrors.New("rate limit exceeded, try again later")
+	ErrTooManyRequests   = stderrors.New("too many concurrent requests, try again later")
 )
 
 var singletonErrorCodes = map[error]string{
@@ -88,6 +115,8 @@ var singletonErrorCodes = map[error]string{
 	ErrUnknownWatcher:            params.CodeNotFound,
 	ErrStoppedWatcher:            params.CodeStopped,
 	ErrTryAgain:                  params.CodeTryAgain,
+	ErrRateLimitExceeded:         params.CodeRateLimitExceeded,
+	ErrTooManyRequests:           params.CodeTooManyRequests,
 }
 
 func singletonCode(err error) (string, bool) {
@@ -117,6 +146,8 @@ func ServerError(err error) *params.Error {
 		code = params.CodeNotFound
 	case errors.IsAlreadyExists(err):
 		code = params.CodeAlreadyExists
+	case errors.IsNotSupported(err):
+		code = params.CodeNotSupported
 	case state.IsNotAssigned(err):
 		code = params.CodeNotAssigned
 	case state.IsHasAssignedUnitsError(err):
@@ -127,6 +158,8 @@ func ServerError(err error) *params.Error {
 		code = params.CodeNotProvisioned
 	case IsUnknownEnviromentError(err):
 		code = params.CodeNotFound
+	case IsOperationBlockedError(err):
+		code = params.CodeOperationBlocked
 	default:
 		code = params.ErrCode(err)
 	}
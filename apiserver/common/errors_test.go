@@ -84,6 +84,14 @@ var errorTransformTests = []struct {
 	err:        errors.AlreadyExistsf("blah"),
 	code:       params.CodeAlreadyExists,
 	helperFunc: params.IsCodeAlreadyExists,
+}, {
+	err:        errors.NotSupportedf("blah"),
+	code:       params.CodeNotSupported,
+	helperFunc: params.IsCodeNotSupported,
+}, {
+	err:        common.OperationBlockedError("production"),
+	code:       params.CodeOperationBlocked,
+	helperFunc: params.IsCodeOperationBlocked,
 }, {
 	err:        common.ErrUnknownWatcher,
 	code:       params.CodeNotFound,
@@ -104,6 +112,14 @@ var errorTransformTests = []struct {
 	err:        common.ErrTryAgain,
 	code:       params.CodeTryAgain,
 	helperFunc: params.IsCodeTryAgain,
+}, {
+	err:        common.ErrRateLimitExceeded,
+	code:       params.CodeRateLimitExceeded,
+	helperFunc: params.IsCodeRateLimitExceeded,
+}, {
+	err:        common.ErrTooManyRequests,
+	code:       params.CodeTooManyRequests,
+	helperFunc: params.IsCodeTooManyRequests,
 }, {
 	err:  stderrors.New("an error"),
 	code: "",
@@ -51,18 +51,18 @@ func (h *debugLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		Handler: func(socket *websocket.Conn) {
 			logger.Infof("debug log handler starting")
 			if err := h.authenticate(req); err != nil {
-				h.sendError(socket, fmt.Errorf("auth failed: %v", err))
+				sendWebsocketError(socket, fmt.Errorf("auth failed: %v", err))
 				socket.Close()
 				return
 			}
 			if err := h.validateEnvironUUID(req); err != nil {
-				h.sendError(socket, err)
+				sendWebsocketError(socket, err)
 				socket.Close()
 				return
 			}
 			stream, err := newLogStream(req.URL.Query())
 			if err != nil {
-				h.sendError(socket, err)
+				sendWebsocketError(socket, err)
 				socket.Close()
 				return
 			}
@@ -70,13 +70,13 @@ func (h *debugLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			logLocation := filepath.Join(h.logDir, "all-machines.log")
 			logFile, err := os.Open(logLocation)
 			if err != nil {
-				h.sendError(socket, fmt.Errorf("cannot open log file: %v", err))
+				sendWebsocketError(socket, fmt.Errorf("cannot open log file: %v", err))
 				socket.Close()
 				return
 			}
 			defer logFile.Close()
 			if err := stream.positionLogFile(logFile); err != nil {
-				h.sendError(socket, fmt.Errorf("cannot position log file: %v", err))
+				sendWebsocketError(socket, fmt.Errorf("cannot position log file: %v", err))
 				socket.Close()
 				return
 			}
@@ -84,7 +84,7 @@ func (h *debugLogHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			// If we get to here, no more errors to report, so we report a nil
 			// error.  This way the first line of the socket is always a json
 			// formatted simple error.
-			if err := h.sendError(socket, nil); err != nil {
+			if err := sendWebsocketError(socket, nil); err != nil {
 				logger.Errorf("could not send good log stream start")
 				socket.Close()
 				return
@@ -155,8 +155,9 @@ func newLogStream(queryMap url.Values) (*logStream, error) {
 	}, nil
 }
 
-// sendError sends a JSON-encoded error response.
-func (h *debugLogHandler) sendError(w io.Writer, err error) error {
+// sendWebsocketError sends a JSON-encoded error response as the first
+// line of a websocket stream.
+func sendWebsocketError(w io.Writer, err error) error {
 	response := &params.ErrorResult{}
 	if err != nil {
 		response.Error = &params.Error{Message: fmt.Sprint(err)}
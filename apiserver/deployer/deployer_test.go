@@ -330,7 +330,8 @@ func (s *deployerSuite) TestAPIAddresses(c *gc.C) {
 }
 
 func (s *deployerSuite) TestCACert(c *gc.C) {
-	result := s.deployer.CACert()
+	result, err := s.deployer.CACert()
+	c.Assert(err, gc.IsNil)
 	c.Assert(result, gc.DeepEquals, params.BytesResult{
 		Result: []byte(s.State.CACert()),
 	})
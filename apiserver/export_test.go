@@ -16,6 +16,8 @@ var (
 	NewPingTimeout        = newPingTimeout
 	MaxClientPingInterval = &maxClientPingInterval
 	MongoPingInterval     = &mongoPingInterval
+	RunOutputPollInterval = &runOutputPollInterval
+	RunOutputMaxDuration  = &runOutputMaxDuration
 )
 
 const LoginRateLimit = loginRateLimit
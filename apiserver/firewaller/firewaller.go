@@ -7,6 +7,7 @@ import (
 
 	"github.com/juju/juju/apiserver/common"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 )
 
@@ -113,7 +114,14 @@ func (f *FirewallerAPI) OpenedPorts(args params.Entities) (params.PortsResults,
 		}
 		unit, err := f.getUnit(canAccess, tag)
 		if err == nil {
-			result.Results[i].Ports = unit.OpenedPorts()
+			var portRanges []network.PortRange
+			portRanges, err = unit.OpenedPorts()
+			if err == nil {
+				// The firewaller still works with single ports;
+				// ICMP is sent as port -1.
+				ports := network.PortRangesToPorts(portRanges)
+				result.Results[i].Ports = append([]network.Port{}, ports...)
+			}
 		}
 		result.Results[i].Error = common.ServerError(err)
 	}
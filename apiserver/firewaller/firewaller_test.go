@@ -323,6 +323,24 @@ func (s *firewallerSuite) TestGetExposed(c *gc.C) {
 	})
 }
 
+func (s *firewallerSuite) TestOpenedPortRanges(c *gc.C) {
+	err := s.units[0].OpenPorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+	err = s.units[0].OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.units[0].Tag().String()},
+	}}
+	result, err := s.firewaller.OpenedPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.PortsResults{
+		Results: []params.PortsResult{
+			{Ports: []network.Port{{"icmp", -1}, {"tcp", 8000}, {"tcp", 8001}, {"tcp", 8002}}},
+		},
+	})
+}
+
 func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	// Open some ports on two of the units.
 	err := s.units[0].OpenPort("tcp", 1234)
@@ -28,32 +28,42 @@ type httpHandler struct {
 
 // authenticate parses HTTP basic authentication and authorizes the
 // request by looking up the provided tag and password against state.
+// Only users are allowed.
 func (h *httpHandler) authenticate(r *http.Request) error {
+	_, err := h.authenticateAs(r, func(tag names.Tag) bool {
+		_, ok := tag.(names.UserTag)
+		return ok
+	})
+	return err
+}
+
+// authenticateAs is like authenticate, but allows any entity whose
+// tag satisfies allow, and returns the authenticated entity.
+func (h *httpHandler) authenticateAs(r *http.Request, allow func(names.Tag) bool) (state.Entity, error) {
 	parts := strings.Fields(r.Header.Get("Authorization"))
 	if len(parts) != 2 || parts[0] != "Basic" {
 		// Invalid header format or no header provided.
-		return fmt.Errorf("invalid request format")
+		return nil, fmt.Errorf("invalid request format")
 	}
 	// Challenge is a base64-encoded "tag:pass" string.
 	// See RFC 2617, Section 2.
 	challenge, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return fmt.Errorf("invalid request format")
+		return nil, fmt.Errorf("invalid request format")
 	}
 	tagPass := strings.SplitN(string(challenge), ":", 2)
 	if len(tagPass) != 2 {
-		return fmt.Errorf("invalid request format")
+		return nil, fmt.Errorf("invalid request format")
 	}
-	// Only allow users, not agents.
-	if _, err := names.ParseUserTag(tagPass[0]); err != nil {
-		return common.ErrBadCreds
+	tag, err := names.ParseTag(tagPass[0])
+	if err != nil || !allow(tag) {
+		return nil, common.ErrBadCreds
 	}
 	// Ensure the credentials are correct.
-	_, err = checkCreds(h.state, params.Creds{
+	return checkCreds(h.state, params.Creds{
 		AuthTag:  tagPass[0],
 		Password: tagPass[1],
 	})
-	return err
 }
 
 func (h *httpHandler) getEnvironUUID(r *http.Request) string {
@@ -0,0 +1,126 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/stats"
+)
+
+var (
+	requestCounter = stats.NewCounter(
+		"juju_apiserver_requests_total",
+		"Number of API requests served, by facade, method and error code.",
+		"facade", "method", "code",
+	)
+	requestLatency = stats.NewHistogram(
+		"juju_apiserver_request_duration_seconds",
+		"Time taken to serve API requests, by facade and method.",
+		nil,
+		"facade", "method",
+	)
+	activeConnections = stats.NewGauge(
+		"juju_apiserver_connections",
+		"Number of open API connections.",
+	)
+	loginFailures = stats.NewCounter(
+		"juju_apiserver_login_failures_total",
+		"Number of failed API logins.",
+	)
+	mongoPingLatency = stats.NewHistogram(
+		"juju_apiserver_mongo_ping_duration_seconds",
+		"Time taken by the API server to ping mongo.",
+		nil,
+	)
+	rateLimitedRequests = stats.NewCounter(
+		"juju_apiserver_rate_limited_requests_total",
+		"Number of API requests rejected by the request limiter, by reason.",
+		"reason",
+	)
+)
+
+// unknownLabel is used in place of the facade and method names of
+// requests that do not name an existing API method, so that clients
+// cannot create arbitrarily many metrics.
+const unknownLabel = "unknown"
+
+// initialRootType describes the methods served before login.
+var initialRootType = rpcreflect.TypeOf(reflect.TypeOf((*initialRoot)(nil)))
+
+// requestLabels returns the facade and method labels to use in the
+// metrics for the given request.
+func requestLabels(req rpc.Request, hdr *rpc.Header) (facade, method string) {
+	if hdr.ErrorCode == rpc.CodeNotImplemented {
+		return unknownLabel, unknownLabel
+	}
+	var objType *rpcreflect.ObjType
+	if rootMethod, err := initialRootType.Method(req.Type); err == nil {
+		objType = rootMethod.ObjType
+	} else if goType, err := common.Facades.GetType(req.Type, req.Version); err == nil {
+		objType = rpcreflect.ObjTypeOf(goType)
+	} else {
+		return unknownLabel, unknownLabel
+	}
+	if _, err := objType.Method(req.Action); err != nil {
+		return req.Type, unknownLabel
+	}
+	return req.Type, req.Action
+}
+
+// metricsHandler serves the metrics kept by the API server and the
+// other parts of the state server in the Prometheus text exposition
+// format.
+type metricsHandler struct {
+	httpHandler
+	registry *stats.Registry
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authenticate(r); err != nil {
+		h.authError(w, h)
+		return
+	}
+	if err := h.validateEnvironUUID(r); err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	switch r.Method {
+	case "GET":
+		var buf bytes.Buffer
+		if err := h.registry.WriteText(&buf); err != nil {
+			h.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	default:
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+	}
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *metricsHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	body, err := json.Marshal(&params.ErrorResult{
+		Error: common.ServerError(errors.New(message)),
+	})
+	if err != nil {
+		logger.Errorf("failed to send error: %v", err)
+		return
+	}
+	w.Write(body)
+}
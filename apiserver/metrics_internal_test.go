@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// This is an internal package test.
+
+package apiserver
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/testing"
+)
+
+type requestLabelsSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&requestLabelsSuite{})
+
+func (s *requestLabelsSuite) TestRequestLabels(c *gc.C) {
+	for i, test := range []struct {
+		req    rpc.Request
+		code   string
+		facade string
+		method string
+	}{{
+		req:    rpc.Request{Type: "Client", Action: "EnvironmentGet"},
+		facade: "Client",
+		method: "EnvironmentGet",
+	}, {
+		req:    rpc.Request{Type: "Admin", Action: "Login"},
+		code:   "unauthorized access",
+		facade: "Admin",
+		method: "Login",
+	}, {
+		req:    rpc.Request{Type: "Client", Action: "NoSuchMethod"},
+		facade: "Client",
+		method: "unknown",
+	}, {
+		req:    rpc.Request{Type: "NoSuchFacade", Action: "EnvironmentGet"},
+		facade: "unknown",
+		method: "unknown",
+	}, {
+		req:    rpc.Request{Type: "Client", Version: 99, Action: "EnvironmentGet"},
+		facade: "unknown",
+		method: "unknown",
+	}, {
+		req:    rpc.Request{Type: "Client", Action: "EnvironmentGet"},
+		code:   rpc.CodeNotImplemented,
+		facade: "unknown",
+		method: "unknown",
+	}} {
+		c.Logf("test %d: %+v", i, test.req)
+		facade, method := requestLabels(test.req, &rpc.Header{ErrorCode: test.code})
+		c.Check(facade, gc.Equals, test.facade)
+		c.Check(method, gc.Equals, test.method)
+	}
+}
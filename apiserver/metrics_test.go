@@ -0,0 +1,105 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+type metricsSuite struct {
+	authHttpSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) metricsURI(c *gc.C) string {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	uri := s.baseURL(c)
+	uri.Path = "/environment/" + env.UUID() + "/metrics"
+	return uri.String()
+}
+
+func (s *metricsSuite) assertError(c *gc.C, resp *http.Response, expCode int, expError string) {
+	body := assertResponse(c, resp, expCode, "application/json")
+	var result params.ErrorResult
+	err := json.Unmarshal(body, &result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Error, gc.NotNil)
+	c.Check(result.Error.Message, gc.Matches, expError)
+}
+
+func (s *metricsSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertError(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *metricsSuite) TestAuthRequiresUser(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned("foo", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = machine.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+
+	resp, err := s.sendRequest(c, machine.Tag().String(), password, "GET", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertError(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *metricsSuite) TestRequiresGET(c *gc.C) {
+	resp, err := s.authRequest(c, "POST", s.metricsURI(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertError(c, resp, http.StatusMethodNotAllowed, `unsupported method: "POST"`)
+}
+
+func (s *metricsSuite) TestRejectsWrongEnvironment(c *gc.C) {
+	uri := s.baseURL(c)
+	uri.Path = "/environment/dead-beef-123456/metrics"
+	resp, err := s.authRequest(c, "GET", uri.String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertError(c, resp, http.StatusNotFound, `unknown environment: "dead-beef-123456"`)
+}
+
+func (s *metricsSuite) TestMetrics(c *gc.C) {
+	// Make an API request so that there is something to report.
+	_, err := s.APIState.Client().EnvironmentGet()
+	c.Assert(err, gc.IsNil)
+
+	for _, path := range []string{"", "/metrics"} {
+		uri := s.metricsURI(c)
+		if path != "" {
+			u := s.baseURL(c)
+			u.Path = path
+			uri = u.String()
+		}
+		resp, err := s.authRequest(c, "GET", uri, "", nil)
+		c.Assert(err, gc.IsNil)
+		defer resp.Body.Close()
+		c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+		c.Assert(resp.Header.Get("Content-Type"), gc.Equals, "text/plain; version=0.0.4")
+		body, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, gc.IsNil)
+		text := string(body)
+		c.Check(text, jc.Contains, "# TYPE juju_apiserver_requests_total counter\n")
+		c.Check(text, jc.Contains, `juju_apiserver_requests_total{facade="Client",method="EnvironmentGet",code=""}`)
+		c.Check(text, jc.Contains, `juju_apiserver_request_duration_seconds_count{facade="Client",method="EnvironmentGet"}`)
+		c.Check(text, jc.Contains, "# TYPE juju_apiserver_connections gauge\n")
+		c.Check(text, jc.Contains, "# TYPE juju_apiserver_login_failures_total counter\n")
+		c.Check(text, jc.Contains, "# TYPE juju_apiserver_mongo_ping_duration_seconds histogram\n")
+		c.Check(text, jc.Contains, "# TYPE juju_state_txn_retries_total counter\n")
+	}
+}
@@ -47,7 +47,11 @@ const (
 	CodeNoAddressSet        = "no address set"
 	CodeTryAgain            = "try again"
 	CodeNotImplemented      = rpc.CodeNotImplemented
+	CodeNotSupported        = "not supported"
 	CodeAlreadyExists       = "already exists"
+	CodeRateLimitExceeded   = "rate limit exceeded"
+	CodeTooManyRequests     = "too many concurrent requests"
+	CodeOperationBlocked    = "operation is blocked"
 )
 
 // ErrCode returns the error code associated with
@@ -142,6 +146,22 @@ func IsCodeNotImplemented(err error) bool {
 	return ErrCode(err) == CodeNotImplemented
 }
 
+func IsCodeNotSupported(err error) bool {
+	return ErrCode(err) == CodeNotSupported
+}
+
 func IsCodeAlreadyExists(err error) bool {
 	return ErrCode(err) == CodeAlreadyExists
 }
+
+func IsCodeRateLimitExceeded(err error) bool {
+	return ErrCode(err) == CodeRateLimitExceeded
+}
+
+func IsCodeTooManyRequests(err error) bool {
+	return ErrCode(err) == CodeTooManyRequests
+}
+
+func IsCodeOperationBlocked(err error) bool {
+	return ErrCode(err) == CodeOperationBlocked
+}
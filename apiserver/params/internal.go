@@ -70,6 +70,19 @@ type PortsResult struct {
 	Ports []network.Port
 }
 
+// PortRangesResults holds the bulk operation result of an API call
+// that returns a slice of network.PortRange.
+type PortRangesResults struct {
+	Results []PortRangesResult
+}
+
+// PortRangesResult holds the result of an API call that returns a
+// slice of network.PortRange or an error.
+type PortRangesResult struct {
+	Error  *Error
+	Ranges []network.PortRange
+}
+
 // StringsResults holds the bulk operation result of an API call
 // that returns a slice of strings or an error.
 type StringsResults struct {
@@ -287,6 +300,21 @@ type EntitiesPorts struct {
 	Entities []EntityPort
 }
 
+// EntityPortRange holds an entity's tag, a protocol and a range
+// of ports.
+type EntityPortRange struct {
+	Tag      string
+	Protocol string
+	FromPort int
+	ToPort   int
+}
+
+// EntitiesPortRanges holds the parameters for making an OpenPorts
+// or ClosePorts on some entities.
+type EntitiesPortRanges struct {
+	Entities []EntityPortRange
+}
+
 // EntityCharmURL holds an entity's tag and a charm URL.
 type EntityCharmURL struct {
 	Tag      string
@@ -417,6 +445,22 @@ type MachineNetworkInfoResults struct {
 	Results []MachineNetworkInfoResult
 }
 
+// ContainerAddressResult holds the address allocated to a container
+// on its host's network, with the details needed to configure the
+// container's network, or an error.
+type ContainerAddressResult struct {
+	Error          *Error
+	Address        network.Address
+	CIDR           string
+	NetworkId      network.Id
+	GatewayAddress string
+}
+
+// ContainerAddressResults holds multiple container address results.
+type ContainerAddressResults struct {
+	Results []ContainerAddressResult
+}
+
 // EntityStatus holds an entity tag, status and extra info.
 type EntityStatus struct {
 	Tag    string
@@ -595,6 +639,34 @@ type CharmsResponse struct {
 	Files    []string `json:",omitempty"`
 }
 
+// ResourceInfo describes the current revision of a resource
+// attached to a service.
+type ResourceInfo struct {
+	Service  string
+	Name     string
+	Revision int
+	Size     int64
+	SHA256   string
+}
+
+// ResourcesResponse is the server response to resource upload requests.
+type ResourcesResponse struct {
+	Error    string        `json:",omitempty"`
+	Resource *ResourceInfo `json:",omitempty"`
+}
+
+// ResourcesResults holds the bulk operation result of an API call
+// that returns the resources attached to services.
+type ResourcesResults struct {
+	Results []ResourcesResult
+}
+
+// ResourcesResult holds the resources attached to a service, or an error.
+type ResourcesResult struct {
+	Error     *Error
+	Resources []ResourceInfo
+}
+
 // RunParams is used to provide the parameters to the Run method.
 // Commands and Timeout are expected to have values, and one or more
 // values should be in the Machines, Services, or Units slices.
@@ -608,11 +680,13 @@ type RunParams struct {
 
 // RunResult contains the result from an individual run call on a machine.
 // UnitId is populated if the command was run inside the unit context.
+// Status is only populated for the results of asynchronous run jobs.
 type RunResult struct {
 	exec.ExecResponse
 	MachineId string
 	UnitId    string
 	Error     string
+	Status    string
 }
 
 // RunResults is used to return the slice of results.  API server side calls
@@ -621,6 +695,95 @@ type RunResults struct {
 	Results []RunResult
 }
 
+// RunAsyncParams is used to provide the parameters to the RunAsync
+// method. If AllMachines is true, the commands run on all the
+// machines, and Machines, Services and Units must be empty. If
+// Parallelism is positive, the commands run on at most that many
+// targets at a time.
+type RunAsyncParams struct {
+	RunParams
+	AllMachines bool
+	Parallelism int
+}
+
+// RunJobId identifies an asynchronous run job.
+type RunJobId struct {
+	JobId string
+}
+
+// RunJobStatus holds the progress of an asynchronous run job, with
+// a result for each of its targets.
+type RunJobStatus struct {
+	JobId    string
+	Commands string
+	Done     bool
+	Results  []RunResult
+}
+
+// RunTask holds what an agent needs to run the commands of a run job
+// on one of its targets.
+type RunTask struct {
+	Id       string
+	Target   string
+	Commands string
+	Timeout  time.Duration
+}
+
+// RunTaskResult holds a run task or an error.
+type RunTaskResult struct {
+	Error  *Error
+	Result RunTask
+}
+
+// RunTaskResults holds the results of the RunTasks.RunTasks call.
+type RunTaskResults struct {
+	Results []RunTaskResult
+}
+
+// RunTaskIds holds the ids of run tasks.
+type RunTaskIds struct {
+	Ids []string
+}
+
+// RunTaskOutput holds output written so far by the commands of a
+// running task.
+type RunTaskOutput struct {
+	Id     string
+	Stdout []byte
+	Stderr []byte
+}
+
+// RunTaskOutputs holds the parameters for the
+// RunTasks.AppendRunTaskOutput call.
+type RunTaskOutputs struct {
+	Outputs []RunTaskOutput
+}
+
+// RunTaskOutcome holds the outcome of a run task. Error is set if
+// the commands could not be run.
+type RunTaskOutcome struct {
+	Id string
+	exec.ExecResponse
+	Error string
+}
+
+// RunTaskOutcomes holds the parameters for the RunTasks.FinishRunTasks
+// call.
+type RunTaskOutcomes struct {
+	Outcomes []RunTaskOutcome
+}
+
+// IntrospectParams is used to provide the parameters to the Introspect
+// method. Query should be one of the queries understood by
+// juju-introspect, and one or more values should be in the Machines
+// or Units slices.
+type IntrospectParams struct {
+	Query    string
+	Timeout  time.Duration
+	Machines []string
+	Units    []string
+}
+
 // AgentVersionResult is used to return the current version number of the
 // agent running the API server.
 type AgentVersionResult struct {
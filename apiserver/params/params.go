@@ -156,6 +156,9 @@ type ServiceDeploy struct {
 	Constraints   constraints.Value
 	ToMachineSpec string
 	Networks      []string
+	// EndpointBindings maps charm endpoint names to spaces; the
+	// empty name binds all endpoints not otherwise listed.
+	EndpointBindings map[string]string
 }
 
 // ServiceUpdate holds the parameters for making the ServiceUpdate call.
@@ -176,6 +179,103 @@ type ServiceSetCharm struct {
 	Force       bool
 }
 
+// ServiceSetCharmRolling holds the parameters for the
+// ServiceSetCharmRolling call, which sets the charm for a service
+// and upgrades its units BatchSize units at a time.
+type ServiceSetCharmRolling struct {
+	ServiceName string
+	CharmUrl    string
+	Force       bool
+	BatchSize   int
+}
+
+// RollingUpgradeStatus holds the results of the
+// ServiceRollingUpgradeStatus call.
+type RollingUpgradeStatus struct {
+	ServiceName string
+	CharmURL    string
+	BatchSize   int
+	Status      string
+	Message     string
+	Batch       []string
+	Upgraded    []string
+	Pending     []string
+}
+
+// ServiceSetUpgradePolicy holds the parameters for the
+// ServiceSetUpgradePolicy call. Window, if not empty, restricts
+// automatic upgrades to a daily maintenance window written as
+// "HH:MM-HH:MM" in UTC.
+type ServiceSetUpgradePolicy struct {
+	ServiceName string
+	Policy      string
+	Window      string
+}
+
+// AutoCharmUpgrade describes a charm upgrade made automatically
+// because of a service's upgrade policy.
+type AutoCharmUpgrade struct {
+	ServiceName string
+	From        string
+	To          string
+	Policy      string
+	Time        time.Time
+}
+
+// AutoCharmUpgradesResults holds the results of the
+// AutoCharmUpgrades call.
+type AutoCharmUpgradesResults struct {
+	Upgrades []AutoCharmUpgrade
+}
+
+// ScalingRule describes when a service is scaled according to the
+// value of a metric; see state.ScalingRule.
+type ScalingRule struct {
+	Metric string
+	Above  *float64
+	Below  *float64
+	Step   int
+}
+
+// ScalingPolicy describes how a service is scaled automatically; see
+// state.ScalingPolicy.
+type ScalingPolicy struct {
+	Min       int
+	Max       int
+	Cooldown  time.Duration
+	Placement string
+	Rules     []ScalingRule
+}
+
+// ServiceSetScalingPolicy holds the parameters for the
+// ServiceSetScalingPolicy call. A nil Policy removes the service's
+// scaling policy.
+type ServiceSetScalingPolicy struct {
+	ServiceName string
+	Policy      *ScalingPolicy
+}
+
+// ScalingRuleValue holds the current value of a scaling rule's
+// metric. Known is false if no recent value is available.
+type ScalingRuleValue struct {
+	Rule  ScalingRule
+	Known bool
+	Value float64
+}
+
+// ServiceScalingResult holds the results of the ServiceScaling call.
+type ServiceScalingResult struct {
+	ServiceName string
+	Policy      ScalingPolicy
+	Units       int
+	Desired     int
+	Reason      string
+	Cooldown    bool
+	Values      []ScalingRuleValue
+	LastScaled  time.Time
+	LastAction  string
+}
+
 // ServiceExpose holds the parameters for making the ServiceExpose call.
 type ServiceExpose struct {
 	ServiceName string
@@ -486,6 +586,9 @@ type StateServingInfo struct {
 	// this will be passed as the KeyFile argument to MongoDB
 	SharedSecret   string
 	SystemIdentity string
+	// CAPrivateKey holds the private key of the CA that signs
+	// state server certificates.
+	CAPrivateKey string
 }
 
 // IsMasterResult holds the result of an IsMaster API call.
@@ -776,6 +879,151 @@ type StateServersChanges struct {
 	Demoted    []string `json:demoted,omitempty`
 }
 
+// StateServerMemberStatus describes a state server machine and its
+// membership of the mongo replica set.
+type StateServerMemberStatus struct {
+	MachineId string
+
+	// WantsVote reports whether the machine wants to take part in
+	// peer voting, and HasVote whether it currently does.
+	WantsVote bool
+	HasVote   bool
+
+	// Removing reports whether the machine is being removed as a
+	// state server.
+	Removing bool
+
+	// The following fields describe the machine's replica set
+	// member, and are empty if the machine is not yet a member.
+	Address string
+	State   string
+	Healthy bool
+	Message string
+
+	// OpTimeLag holds how far the member's last applied operation
+	// lags behind the primary's.
+	OpTimeLag time.Duration
+
+	// LastHeartbeat holds the time the member was last heard from
+	// by the state server answering the request.
+	LastHeartbeat time.Time
+}
+
+// HighAvailabilityStatus holds the result of the
+// HighAvailabilityStatus API call.
+type HighAvailabilityStatus struct {
+	Members []StateServerMemberStatus
+}
+
+// BlockSwitchParams holds the parameters for the SwitchBlockOn and
+// SwitchBlockOff calls.
+type BlockSwitchParams struct {
+	Type    string
+	Message string `json:",omitempty"`
+}
+
+// Block describes an environment block that is switched on.
+type Block struct {
+	Type    string
+	Message string
+}
+
+// BlockResults holds the results of the ListBlocks call.
+type BlockResults struct {
+	Blocks []Block
+}
+
+// StorageGCArgs holds the arguments for the StorageGC API call.
+type StorageGCArgs struct {
+	// DryRun causes unused items to be reported but not removed.
+	DryRun bool
+}
+
+// StorageGCItem describes an unused charm archive or tools tarball.
+type StorageGCItem struct {
+	Kind string
+	Name string
+	Size int64
+}
+
+// StorageGCResult holds the result of the StorageGC API call.
+type StorageGCResult struct {
+	Items []StorageGCItem
+}
+
+// RotateCA holds the arguments for the RotateCA API call.
+type RotateCA struct {
+	// Finish causes an ongoing CA rotation to be completed,
+	// rather than a new one started.
+	Finish bool
+
+	// CACert and CAPrivateKey, if set, hold the CA to rotate
+	// to, in PEM format. Otherwise a new CA is generated.
+	CACert       string
+	CAPrivateKey string
+}
+
+// RotateCAResult holds the result of the RotateCA API call.
+type RotateCAResult struct {
+	// CACert holds the CA certificates trusted by the
+	// environment, in PEM format.
+	CACert string
+}
+
+// ServerCertificate holds the arguments for the SetServerCertificate
+// API call. All values are in PEM format.
+type ServerCertificate struct {
+	// CACert holds the certificate of the CA that signed Cert.
+	CACert string
+
+	// Cert holds the externally signed state server certificate,
+	// followed by any intermediate certificates that chain it
+	// to CACert.
+	Cert string
+
+	// PrivateKey holds the private key of Cert.
+	PrivateKey string
+}
+
+// AddSubnet holds the arguments for the AddSubnet API call.
+type AddSubnet struct {
+	CIDR             string
+	ProviderId       string
+	VLANTag          int
+	AvailabilityZone string
+}
+
+// SubnetInfo describes a subnet known to juju.
+type SubnetInfo struct {
+	CIDR             string
+	ProviderId       string
+	VLANTag          int
+	AvailabilityZone string
+	Space            string
+}
+
+// SubnetsResult holds the result of the ListSubnets API call.
+type SubnetsResult struct {
+	Subnets []SubnetInfo
+}
+
+// AddSpace holds the arguments for the AddSpace API call.
+type AddSpace struct {
+	Name    string
+	Subnets []string
+}
+
+// SpaceInfo describes a network space and the CIDRs of its subnets.
+type SpaceInfo struct {
+	Name    string
+	Subnets []string
+}
+
+// SpacesResult holds the result of the ListSpaces API call.
+type SpacesResult struct {
+	Spaces []SpaceInfo
+}
+
 // FindToolsParams defines parameters for the FindTools method.
 type FindToolsParams struct {
 	// Number will be used to match tools versions exactly if non-zero.
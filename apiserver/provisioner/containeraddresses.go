@@ -0,0 +1,237 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner
+
+import (
+	"net"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+var logger = loggo.GetLogger("juju.apiserver.provisioner")
+
+// AllocateContainerAddresses allocates an address for each given
+// container from the provider, on the network of the container's
+// host, and records the allocation in state. The result holds what
+// the container's network needs to be configured with. If an address
+// is already allocated to a container, that address is returned.
+//
+// When the provider cannot allocate addresses, or the host's network
+// is unknown, the error has the code params.CodeNotSupported and the
+// container should use the host's bridge instead.
+func (p *ProvisionerAPIV1) AllocateContainerAddresses(args params.Entities) (params.ContainerAddressResults, error) {
+	result := params.ContainerAddressResults{
+		Results: make([]params.ContainerAddressResult, len(args.Entities)),
+	}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	env, err := p.environ()
+	if err != nil {
+		return result, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseMachineTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		res, err := p.allocateContainerAddress(env, canAccess, tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		result.Results[i] = res
+	}
+	return result, nil
+}
+
+func (p *ProvisionerAPI) allocateContainerAddress(
+	env environs.Environ, canAccess common.AuthFunc, tag names.MachineTag,
+) (result params.ContainerAddressResult, err error) {
+	container, err := p.getMachine(canAccess, tag)
+	if err != nil {
+		return result, err
+	}
+	defer errors.DeferredAnnotatef(&err, "cannot allocate address for container %q", container.Id())
+	hostId := state.ParentId(container.Id())
+	if hostId == "" {
+		return result, errors.Errorf("machine is not a container")
+	}
+	host, err := p.st.Machine(hostId)
+	if err != nil {
+		return result, err
+	}
+	hostInstId, err := host.InstanceId()
+	if err != nil {
+		return result, err
+	}
+	p.releaseOrphanedAddresses(env, hostInstId)
+	hostAddress := network.SelectInternalAddress(host.Addresses(), false)
+	if hostAddress == "" {
+		return result, errors.NotSupportedf("host machine %q without an internal address", hostId)
+	}
+	hostNetwork, err := hostNetwork(env, hostAddress)
+	if err != nil {
+		return result, err
+	}
+	result.CIDR = hostNetwork.CIDR
+	result.NetworkId = hostNetwork.ProviderId
+	result.GatewayAddress = hostAddress
+
+	allocated, err := p.st.AllocatedIPAddresses(container.Id())
+	if err != nil {
+		return result, err
+	}
+	for _, ipaddr := range allocated {
+		if ipaddr.NetworkId() == hostNetwork.ProviderId {
+			result.Address = ipaddr.Address()
+			return result, nil
+		}
+	}
+
+	addr, err := env.AllocateAddress(hostInstId, hostNetwork.ProviderId)
+	if errors.IsNotImplemented(err) {
+		return result, errors.NotSupportedf("address allocation")
+	} else if err != nil {
+		return result, err
+	}
+	if _, err := p.st.AddIPAddress(addr, container.Id(), hostInstId, hostNetwork.ProviderId); err != nil {
+		// Don't leak the address if it can't be recorded.
+		if releaseErr := env.ReleaseAddress(hostInstId, hostNetwork.ProviderId, addr); releaseErr != nil {
+			logger.Errorf("cannot release unrecorded address %q: %v", addr.Value, releaseErr)
+		}
+		return result, err
+	}
+	result.Address = addr
+	return result, nil
+}
+
+// hostNetwork returns the provider network the given host address
+// is on.
+func hostNetwork(env environs.Environ, hostAddress string) (network.BasicInfo, error) {
+	ip := net.ParseIP(hostAddress)
+	if ip == nil {
+		return network.BasicInfo{}, errors.NotSupportedf("host address %q that is not an IP address", hostAddress)
+	}
+	networks, err := env.ListNetworks()
+	if errors.IsNotImplemented(err) {
+		return network.BasicInfo{}, errors.NotSupportedf("listing networks")
+	} else if err != nil {
+		return network.BasicInfo{}, err
+	}
+	for _, info := range networks {
+		_, ipNet, err := net.ParseCIDR(info.CIDR)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return info, nil
+		}
+	}
+	return network.BasicInfo{}, errors.NotSupportedf("host address %q on an unknown network", hostAddress)
+}
+
+// ReleaseContainerAddresses releases all the addresses allocated to
+// each given container back to the provider, and removes them from
+// state. The containers need not exist any more. The addresses that
+// were allocated are returned even if releasing them fails, so that
+// the host can stop routing them.
+func (p *ProvisionerAPIV1) ReleaseContainerAddresses(args params.Entities) (params.StringsResults, error) {
+	result := params.StringsResults{
+		Results: make([]params.StringsResult, len(args.Entities)),
+	}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	env, err := p.environ()
+	if err != nil {
+		return result, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseMachineTag(entity.Tag)
+		if err != nil || !canAccess(tag) {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		addresses, err := p.releaseContainerAddresses(env, tag.Id())
+		result.Results[i].Result = addresses
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// releaseContainerAddresses releases the addresses allocated to the
+// given container, and returns their values.
+func (p *ProvisionerAPI) releaseContainerAddresses(env environs.Environ, machineId string) ([]string, error) {
+	allocated, err := p.st.AllocatedIPAddresses(machineId)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(allocated))
+	for i, ipaddr := range allocated {
+		addresses[i] = ipaddr.Value()
+	}
+	for _, ipaddr := range allocated {
+		if err := releaseAddress(env, ipaddr); err != nil {
+			return addresses, err
+		}
+	}
+	return addresses, nil
+}
+
+// releaseOrphanedAddresses releases the addresses on the given host
+// that are still recorded for containers which are dead or gone,
+// because releasing them when the container was destroyed failed.
+// Failures are only logged; the next allocation on the host tries
+// again.
+func (p *ProvisionerAPI) releaseOrphanedAddresses(env environs.Environ, hostInstId instance.Id) {
+	allocated, err := p.st.HostIPAddresses(hostInstId)
+	if err != nil {
+		logger.Errorf("cannot release orphaned addresses: %v", err)
+		return
+	}
+	for _, ipaddr := range allocated {
+		container, err := p.st.Machine(ipaddr.MachineId())
+		if err == nil && container.Life() != state.Dead {
+			continue
+		} else if err != nil && !errors.IsNotFound(err) {
+			logger.Errorf("cannot release orphaned addresses: %v", err)
+			return
+		}
+		if err := releaseAddress(env, ipaddr); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}
+}
+
+// releaseAddress releases the given address back to the provider and
+// removes it from state.
+func releaseAddress(env environs.Environ, ipaddr *state.IPAddress) error {
+	err := env.ReleaseAddress(ipaddr.HostInstanceId(), ipaddr.NetworkId(), ipaddr.Address())
+	if err != nil {
+		return errors.Annotatef(err, "cannot release address %q of container %q", ipaddr.Value(), ipaddr.MachineId())
+	}
+	return ipaddr.Remove()
+}
+
+// environ returns the environment the provisioner is running in.
+func (p *ProvisionerAPI) environ() (environs.Environ, error) {
+	cfg, err := p.st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	return environs.New(cfg)
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/provisioner"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type containerAddressesSuite struct {
+	provisionerSuite
+	container   *state.Machine
+	provisioner *provisioner.ProvisionerAPIV1
+}
+
+var _ = gc.Suite(&containerAddressesSuite{})
+
+func (s *containerAddressesSuite) SetUpTest(c *gc.C) {
+	s.provisionerSuite.SetUpTest(c)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	var err error
+	s.container, err = s.State.AddMachineInsideMachine(template, s.machines[0].Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+
+	// Login as the machine agent of the container's host.
+	anAuthorizer := s.authorizer
+	anAuthorizer.EnvironManager = false
+	anAuthorizer.Tag = s.machines[0].Tag()
+	s.provisioner, err = provisioner.NewProvisionerAPIV1(s.State, s.resources, anAuthorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *containerAddressesSuite) setUpHost(c *gc.C) {
+	err := s.machines[0].SetProvisioned("i-host", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machines[0].SetAddresses(network.NewAddress("0.10.0.100", network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *containerAddressesSuite) TestAllocateAndRelease(c *gc.C) {
+	s.setUpHost(c)
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.container.Tag().String()},
+		{Tag: s.machines[0].Tag().String()},
+		{Tag: s.machines[1].Tag().String()},
+		{Tag: "unit-foo-0"},
+	}}
+	results, err := s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 4)
+	expected := params.ContainerAddressResult{
+		Address:        network.NewAddress("0.10.0.1", network.ScopeCloudLocal),
+		CIDR:           "0.10.0.0/8",
+		NetworkId:      "dummy-private",
+		GatewayAddress: "0.10.0.100",
+	}
+	c.Assert(results.Results[0], jc.DeepEquals, expected)
+	c.Assert(results.Results[1].Error, gc.ErrorMatches,
+		`cannot allocate address for container "0": machine is not a container`)
+	c.Assert(results.Results[2].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+	c.Assert(results.Results[3].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	ipaddrs, err := s.State.AllocatedIPAddresses(s.container.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 1)
+	c.Assert(ipaddrs[0].Value(), gc.Equals, "0.10.0.1")
+	c.Assert(ipaddrs[0].HostInstanceId(), gc.Equals, instance.Id("i-host"))
+
+	// Allocating again returns the same address.
+	args = params.Entities{Entities: []params.Entity{{Tag: s.container.Tag().String()}}}
+	results, err = s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, jc.DeepEquals, []params.ContainerAddressResult{expected})
+
+	// Addresses can be released even after the container is removed.
+	err = s.container.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.container.Remove()
+	c.Assert(err, gc.IsNil)
+	release, err := s.provisioner.ReleaseContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(release, gc.DeepEquals, params.StringsResults{
+		Results: []params.StringsResult{{Result: []string{"0.10.0.1"}}},
+	})
+	ipaddrs, err = s.State.AllocatedIPAddresses(s.container.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 0)
+}
+
+func (s *containerAddressesSuite) TestAllocateNotSupported(c *gc.C) {
+	// The host has no address on a known network.
+	err := s.machines[0].SetProvisioned("i-host", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	args := params.Entities{Entities: []params.Entity{{Tag: s.container.Tag().String()}}}
+	results, err := s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 1)
+	c.Assert(results.Results[0].Error, jc.Satisfies, params.IsCodeNotSupported)
+
+	err = s.machines[0].SetAddresses(network.NewAddress("192.168.1.1", network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+	results, err = s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results[0].Error, jc.Satisfies, params.IsCodeNotSupported)
+}
+
+func (s *containerAddressesSuite) TestAllocateReleasesOrphanedAddresses(c *gc.C) {
+	s.setUpHost(c)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	orphan, err := s.State.AddMachineInsideMachine(template, s.machines[0].Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	args := params.Entities{Entities: []params.Entity{{Tag: orphan.Tag().String()}}}
+	results, err := s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+
+	// Remove the container without releasing its address, as when
+	// releasing fails while the container is destroyed.
+	err = orphan.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = orphan.Remove()
+	c.Assert(err, gc.IsNil)
+
+	args = params.Entities{Entities: []params.Entity{{Tag: s.container.Tag().String()}}}
+	results, err = s.provisioner.AllocateContainerAddresses(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+
+	ipaddrs, err := s.State.AllocatedIPAddresses(orphan.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 0)
+	ipaddrs, err = s.State.HostIPAddresses("i-host")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 1)
+	c.Assert(ipaddrs[0].MachineId(), gc.Equals, s.container.Id())
+}
@@ -20,6 +20,7 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Provisioner", 0, NewProvisionerAPI)
+	common.RegisterStandardFacade("Provisioner", 1, NewProvisionerAPIV1)
 }
 
 // ProvisionerAPI provides access to the Provisioner API facade.
@@ -100,6 +101,22 @@ func NewProvisionerAPI(st *state.State, resources *common.Resources, authorizer
 	}, nil
 }
 
+// ProvisionerAPIV1 provides access to version 1 of the Provisioner
+// API facade, which adds container address allocation.
+type ProvisionerAPIV1 struct {
+	*ProvisionerAPI
+}
+
+// NewProvisionerAPIV1 creates a new server-side version 1
+// ProvisionerAPI facade.
+func NewProvisionerAPIV1(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*ProvisionerAPIV1, error) {
+	api, err := NewProvisionerAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &ProvisionerAPIV1{api}, nil
+}
+
 func (p *ProvisionerAPI) getMachine(canAccess common.AuthFunc, tag names.MachineTag) (*state.Machine, error) {
 	if !canAccess(tag) {
 		return nil, common.ErrPerm
@@ -351,18 +368,24 @@ func (p *ProvisionerAPI) ProvisioningInfo(args params.Entities) (params.Provisio
 		}
 		machine, err := p.getMachine(canAccess, tag)
 		if err == nil {
-			result.Results[i].Result, err = getProvisioningInfo(machine)
+			result.Results[i].Result, err = getProvisioningInfo(p.st, machine)
 		}
 		result.Results[i].Error = common.ServerError(err)
 	}
 	return result, nil
 }
 
-func getProvisioningInfo(m *state.Machine) (*params.ProvisioningInfo, error) {
+func getProvisioningInfo(st *state.State, m *state.Machine) (*params.ProvisioningInfo, error) {
 	cons, err := m.Constraints()
 	if err != nil {
 		return nil, err
 	}
+	// Providers know nothing of spaces, so start the instance
+	// in the zones of the requested spaces' subnets.
+	cons, err = st.ResolveSpaceConstraints(cons)
+	if err != nil {
+		return nil, err
+	}
 	// TODO(dimitern) For now, since network names and
 	// provider ids are the same, we return what we got
 	// from state. In the future, when networks can be
@@ -1281,7 +1281,8 @@ func (s *withStateServerSuite) TestStateAddresses(c *gc.C) {
 }
 
 func (s *withStateServerSuite) TestCACert(c *gc.C) {
-	result := s.provisioner.CACert()
+	result, err := s.provisioner.CACert()
+	c.Assert(err, gc.IsNil)
 	c.Assert(result, gc.DeepEquals, params.BytesResult{
 		Result: []byte(s.State.CACert()),
 	})
@@ -0,0 +1,219 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc"
+)
+
+// RateLimitConfig holds the limits applied to API requests made by
+// logged in entities. Limits are tracked per entity, so all the
+// connections made by a single user or agent share the same
+// allowance. A zero rate or concurrency disables the corresponding
+// limit.
+type RateLimitConfig struct {
+	// UserRate holds the sustained number of requests per second
+	// allowed for each user.
+	UserRate float64
+
+	// UserBurst holds the number of requests a user may make in
+	// quick succession before UserRate applies.
+	UserBurst int
+
+	// UserConcurrency holds the maximum number of requests a user
+	// may have running at once.
+	UserConcurrency int
+
+	// AgentRate, AgentBurst and AgentConcurrency are the
+	// equivalent limits applied to each machine and unit agent.
+	AgentRate        float64
+	AgentBurst       int
+	AgentConcurrency int
+}
+
+// DefaultRateLimitConfig returns the limits used by jujud when
+// running an API server.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		UserRate:         20,
+		UserBurst:        100,
+		UserConcurrency:  20,
+		AgentRate:        50,
+		AgentBurst:       200,
+		AgentConcurrency: 50,
+	}
+}
+
+// RateLimitStats holds counters describing the decisions made by
+// the API server's request limiter.
+type RateLimitStats struct {
+	// Admitted holds the number of requests allowed to run.
+	Admitted uint64
+
+	// RateLimited holds the number of requests rejected because
+	// the caller exceeded its request rate.
+	RateLimited uint64
+
+	// ConcurrencyLimited holds the number of requests rejected
+	// because the caller had too many requests running.
+	ConcurrencyLimited uint64
+
+	// Running holds the number of limited requests currently
+	// running.
+	Running int
+}
+
+// limiterNow is used by the request limiter to find out the current
+// time. It is a variable so that it can be patched by tests.
+var limiterNow = time.Now
+
+// limiterPruneInterval holds how often the request limiter forgets
+// the allowances of idle entities.
+const limiterPruneInterval = time.Minute
+
+// requestLimiter enforces a RateLimitConfig across all the
+// connections served by an API server.
+type requestLimiter struct {
+	config RateLimitConfig
+
+	mu         sync.Mutex
+	entities   map[string]*entityAllowance
+	stats      RateLimitStats
+	lastPruned time.Time
+}
+
+// entityAllowance holds the rate limiting state for a single entity.
+// The request rate is limited with a token bucket holding up to
+// burst tokens that refills at rate tokens per second.
+type entityAllowance struct {
+	rate        float64
+	burst       int
+	concurrency int
+
+	tokens  float64
+	updated time.Time
+	running int
+}
+
+func newRequestLimiter(config RateLimitConfig) *requestLimiter {
+	return &requestLimiter{
+		config:   config,
+		entities: make(map[string]*entityAllowance),
+	}
+}
+
+// isExemptRequest reports whether the request should never be
+// limited. Agents must always be able to ping, or the API server
+// will drop their connections.
+func isExemptRequest(req rpc.Request) bool {
+	return req.Type == "Pinger" && req.Action == "Ping"
+}
+
+// acquire checks that the entity with the given tag may make
+// another request. If it may, the request is counted as running
+// until the returned release function is called.
+func (l *requestLimiter) acquire(tag names.Tag) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := limiterNow()
+	l.prune(now)
+	a := l.allowance(tag, now)
+	if a.concurrency > 0 && a.running >= a.concurrency {
+		l.stats.ConcurrencyLimited++
+		rateLimitedRequests.Inc("concurrency")
+		logger.Debugf("%s has %d requests running, rejecting request", tag, a.running)
+		return nil, common.ErrTooManyRequests
+	}
+	if a.rate > 0 {
+		a.tokens += now.Sub(a.updated).Seconds() * a.rate
+		if a.tokens > float64(a.burst) {
+			a.tokens = float64(a.burst)
+		}
+		a.updated = now
+		if a.tokens < 1 {
+			l.stats.RateLimited++
+			rateLimitedRequests.Inc("rate")
+			logger.Debugf("%s exceeded its request rate, rejecting request", tag)
+			return nil, common.ErrRateLimitExceeded
+		}
+		a.tokens--
+	}
+	a.running++
+	l.stats.Admitted++
+	l.stats.Running++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			a.running--
+			l.stats.Running--
+		})
+	}, nil
+}
+
+// allowance returns the allowance for the given entity, creating it
+// if necessary. It must be called with l.mu held.
+func (l *requestLimiter) allowance(tag names.Tag, now time.Time) *entityAllowance {
+	if a, ok := l.entities[tag.String()]; ok {
+		return a
+	}
+	a := &entityAllowance{updated: now}
+	if _, isUser := tag.(names.UserTag); isUser {
+		a.rate = l.config.UserRate
+		a.burst = l.config.UserBurst
+		a.concurrency = l.config.UserConcurrency
+	} else {
+		a.rate = l.config.AgentRate
+		a.burst = l.config.AgentBurst
+		a.concurrency = l.config.AgentConcurrency
+	}
+	if a.burst < 1 {
+		a.burst = 1
+	}
+	a.tokens = float64(a.burst)
+	l.entities[tag.String()] = a
+	return a
+}
+
+// prune forgets the allowances of the entities with no requests
+// running and a full token bucket, which are the same as new ones.
+// It must be called with l.mu held.
+func (l *requestLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPruned) < limiterPruneInterval {
+		return
+	}
+	l.lastPruned = now
+	for tag, a := range l.entities {
+		if a.running > 0 {
+			continue
+		}
+		if a.rate > 0 && a.tokens+now.Sub(a.updated).Seconds()*a.rate < float64(a.burst) {
+			continue
+		}
+		delete(l.entities, tag)
+	}
+}
+
+// Stats returns a snapshot of the limiter's counters.
+func (l *requestLimiter) Stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Admit implements rpc.Admitter. State server machine agents are
+// never limited, as they run the environment's own workers.
+func (r *srvRoot) Admit(req rpc.Request) (func(), error) {
+	if r.limiter == nil || isExemptRequest(req) || r.AuthEnvironManager() {
+		return func() {}, nil
+	}
+	return r.limiter.acquire(r.GetAuthTag())
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// This is an internal package test.
+
+package apiserver
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/testing"
+)
+
+type rateLimitSuite struct {
+	testing.BaseSuite
+	now time.Time
+}
+
+var _ = gc.Suite(&rateLimitSuite{})
+
+func (s *rateLimitSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.now = time.Date(2014, 10, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(&limiterNow, func() time.Time { return s.now })
+}
+
+func (s *rateLimitSuite) TestRateLimit(c *gc.C) {
+	l := newRequestLimiter(RateLimitConfig{UserRate: 2, UserBurst: 3})
+	tag := names.NewUserTag("bob")
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(tag)
+		c.Assert(err, gc.IsNil)
+		release()
+	}
+	_, err := l.acquire(tag)
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+
+	// Half a second later, one more request is allowed.
+	s.now = s.now.Add(500 * time.Millisecond)
+	release, err := l.acquire(tag)
+	c.Assert(err, gc.IsNil)
+	release()
+	_, err = l.acquire(tag)
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+
+	// The allowance never exceeds the burst size.
+	s.now = s.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(tag)
+		c.Assert(err, gc.IsNil)
+		release()
+	}
+	_, err = l.acquire(tag)
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+
+	c.Assert(l.Stats(), gc.Equals, RateLimitStats{
+		Admitted:    7,
+		RateLimited: 3,
+	})
+}
+
+func (s *rateLimitSuite) TestConcurrencyLimit(c *gc.C) {
+	l := newRequestLimiter(RateLimitConfig{AgentConcurrency: 2})
+	tag := names.NewUnitTag("wordpress/0")
+	release1, err := l.acquire(tag)
+	c.Assert(err, gc.IsNil)
+	release2, err := l.acquire(tag)
+	c.Assert(err, gc.IsNil)
+	_, err = l.acquire(tag)
+	c.Assert(err, gc.Equals, common.ErrTooManyRequests)
+	c.Assert(l.Stats().Running, gc.Equals, 2)
+
+	// Releasing more than once has no further effect.
+	release1()
+	release1()
+	c.Assert(l.Stats().Running, gc.Equals, 1)
+	release3, err := l.acquire(tag)
+	c.Assert(err, gc.IsNil)
+	release2()
+	release3()
+	c.Assert(l.Stats(), gc.Equals, RateLimitStats{
+		Admitted:           3,
+		ConcurrencyLimited: 1,
+	})
+}
+
+func (s *rateLimitSuite) TestLimitsArePerEntity(c *gc.C) {
+	l := newRequestLimiter(RateLimitConfig{
+		UserRate:         1,
+		UserBurst:        1,
+		AgentConcurrency: 1,
+	})
+	_, err := l.acquire(names.NewUserTag("bob"))
+	c.Assert(err, gc.IsNil)
+	_, err = l.acquire(names.NewUserTag("bob"))
+	c.Assert(err, gc.Equals, common.ErrRateLimitExceeded)
+	_, err = l.acquire(names.NewUserTag("mary"))
+	c.Assert(err, gc.IsNil)
+
+	// Agents are not subject to the user rate.
+	for i := 0; i < 5; i++ {
+		release, err := l.acquire(names.NewMachineTag("1"))
+		c.Assert(err, gc.IsNil)
+		release()
+	}
+	_, err = l.acquire(names.NewMachineTag("2"))
+	c.Assert(err, gc.IsNil)
+	_, err = l.acquire(names.NewMachineTag("2"))
+	c.Assert(err, gc.Equals, common.ErrTooManyRequests)
+}
+
+func (s *rateLimitSuite) TestZeroConfigDoesNotLimit(c *gc.C) {
+	l := newRequestLimiter(RateLimitConfig{})
+	for i := 0; i < 1000; i++ {
+		_, err := l.acquire(names.NewUserTag("bob"))
+		c.Assert(err, gc.IsNil)
+	}
+	c.Assert(l.Stats().Running, gc.Equals, 1000)
+}
+
+func (s *rateLimitSuite) TestPingsAreExempt(c *gc.C) {
+	c.Assert(isExemptRequest(rpc.Request{Type: "Pinger", Action: "Ping"}), gc.Equals, true)
+	c.Assert(isExemptRequest(rpc.Request{Type: "Client", Action: "FullStatus"}), gc.Equals, false)
+	c.Assert(isExemptRequest(rpc.Request{Type: "Pinger", Action: "Stop"}), gc.Equals, false)
+}
+
+func (s *rateLimitSuite) TestIdleAllowancesPruned(c *gc.C) {
+	l := newRequestLimiter(RateLimitConfig{UserRate: 0.1, UserBurst: 10, AgentConcurrency: 1})
+	busy := names.NewUserTag("bob")
+	for i := 0; i < 10; i++ {
+		release, err := l.acquire(busy)
+		c.Assert(err, gc.IsNil)
+		release()
+	}
+	running := names.NewUnitTag("wordpress/0")
+	_, err := l.acquire(running)
+	c.Assert(err, gc.IsNil)
+	idle := names.NewUserTag("alice")
+	release, err := l.acquire(idle)
+	c.Assert(err, gc.IsNil)
+	release()
+	c.Assert(l.entities, gc.HasLen, 3)
+
+	// After a minute, the idle allowance has refilled and is
+	// forgotten, but the others are kept.
+	s.now = s.now.Add(time.Minute)
+	release, err = l.acquire(names.NewUserTag("carol"))
+	c.Assert(err, gc.IsNil)
+	release()
+	c.Assert(l.entities, gc.HasLen, 3)
+	c.Assert(l.entities[idle.String()], gc.IsNil)
+	c.Assert(l.entities[busy.String()], gc.NotNil)
+	c.Assert(l.entities[running.String()], gc.NotNil)
+}
@@ -0,0 +1,176 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+// resourcesHandler handles the upload and download of service
+// resources through HTTPS in the API server. Users may upload and
+// download resources; unit agents may only download the resources
+// attached to their own service.
+type resourcesHandler struct {
+	httpHandler
+}
+
+func (h *resourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.validateEnvironUUID(r); err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	serviceName, name := query.Get("service"), query.Get("name")
+
+	switch r.Method {
+	case "POST":
+		// Attach a new revision of a resource to a service.
+		// Requires "service" and "name" queries identifying the
+		// resource, and the resource content in the request body.
+		if err := h.authenticate(r); err != nil {
+			h.authError(w, h)
+			return
+		}
+		if err := common.NewBlockChecker(h.state).ChangeAllowed(); err != nil {
+			h.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		res, err := h.processPost(r, serviceName, name)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.sendJSON(w, http.StatusOK, &params.ResourcesResponse{Resource: res})
+	case "GET":
+		// Retrieve the content of a resource.
+		// Requires "service" and "name" queries identifying the resource.
+		if _, err := h.authenticateAs(r, h.canRead(serviceName)); err != nil {
+			h.authError(w, h)
+			return
+		}
+		h.processGet(w, serviceName, name)
+	default:
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+	}
+}
+
+// canRead returns a function that reports whether the entity with
+// the given tag may download the resources of the named service.
+func (h *resourcesHandler) canRead(serviceName string) func(names.Tag) bool {
+	return func(tag names.Tag) bool {
+		switch tag := tag.(type) {
+		case names.UserTag:
+			return true
+		case names.UnitTag:
+			return names.UnitService(tag.Id()) == serviceName
+		}
+		return false
+	}
+}
+
+// sendJSON sends a JSON-encoded response to the client.
+func (h *resourcesHandler) sendJSON(w http.ResponseWriter, statusCode int, response *params.ResourcesResponse) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	w.Write(body)
+	return nil
+}
+
+// sendError sends a JSON-encoded error response.
+func (h *resourcesHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	if err := h.sendJSON(w, statusCode, &params.ResourcesResponse{Error: message}); err != nil {
+		logger.Errorf("failed to send error: %v", err)
+	}
+}
+
+// processPost handles a resource upload POST request after authentication.
+func (h *resourcesHandler) processPost(r *http.Request, serviceName, name string) (*params.ResourceInfo, error) {
+	if serviceName == "" || name == "" {
+		return nil, fmt.Errorf("expected service and name arguments")
+	}
+	service, err := h.state.Service(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	// Spool the upload to a temp file, so the blob store can be
+	// told its size, calculating the sha256 along the way.
+	tempFile, err := ioutil.TempFile("", "resource")
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot create temp file")
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hash), r.Body)
+	if err != nil {
+		return nil, errors.Annotate(err, "error processing file upload")
+	}
+	if _, err := tempFile.Seek(0, 0); err != nil {
+		return nil, errors.Annotate(err, "cannot rewind uploaded resource")
+	}
+	res, err := service.SetResource(name, tempFile, size, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return nil, err
+	}
+	return resourceInfo(res), nil
+}
+
+// processGet sends the content of the named resource to the client.
+func (h *resourcesHandler) processGet(w http.ResponseWriter, serviceName, name string) {
+	if serviceName == "" || name == "" {
+		h.sendError(w, http.StatusBadRequest, "expected service and name arguments")
+		return
+	}
+	service, err := h.state.Service(serviceName)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	res, r, err := service.OpenResource(name)
+	if errors.IsNotFound(err) {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer r.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(res.Size(), 10))
+	w.Header().Set("Juju-Resource-Revision", strconv.Itoa(res.Revision()))
+	w.Header().Set("Juju-Resource-Sha256", res.SHA256())
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, r); err != nil {
+		logger.Errorf("failed to send resource %q for service %q: %v", name, serviceName, err)
+	}
+}
+
+func resourceInfo(res *state.Resource) *params.ResourceInfo {
+	return &params.ResourceInfo{
+		Service:  res.Service(),
+		Name:     res.Name(),
+		Revision: res.Revision(),
+		Size:     res.Size(),
+		SHA256:   res.SHA256(),
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+type resourcesSuite struct {
+	authHttpSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&resourcesSuite{})
+
+var resourcesMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+
+func (s *resourcesSuite) SetUpTest(c *gc.C) {
+	s.authHttpSuite.SetUpTest(c)
+	s.service = s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", resourcesMeta, 1))
+}
+
+func (s *resourcesSuite) resourcesURI(c *gc.C, service, name string) string {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	uri := s.baseURL(c)
+	uri.Path = "/environment/" + env.UUID() + "/resources"
+	uri.RawQuery = url.Values{"service": {service}, "name": {name}}.Encode()
+	return uri.String()
+}
+
+func (s *resourcesSuite) upload(c *gc.C, content string) params.ResourcesResponse {
+	resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "app", "app"), "application/octet-stream", strings.NewReader(content))
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusOK, "application/json")
+	var result params.ResourcesResponse
+	err = json.Unmarshal(body, &result)
+	c.Assert(err, gc.IsNil)
+	return result
+}
+
+func (s *resourcesSuite) addUnit(c *gc.C, service *state.Service) (*state.Unit, string) {
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+	return unit, password
+}
+
+func (s *resourcesSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *resourcesSuite) TestRequiresPOSTorGET(c *gc.C) {
+	resp, err := s.authRequest(c, "PUT", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "PUT"`)
+}
+
+func (s *resourcesSuite) TestUpload(c *gc.C) {
+	hash := sha256.Sum256([]byte("first"))
+	result := s.upload(c, "first")
+	c.Assert(result, gc.DeepEquals, params.ResourcesResponse{
+		Resource: &params.ResourceInfo{
+			Service:  "app",
+			Name:     "app",
+			Revision: 1,
+			Size:     5,
+			SHA256:   hex.EncodeToString(hash[:]),
+		},
+	})
+	result = s.upload(c, "second")
+	c.Assert(result.Resource.Revision, gc.Equals, 2)
+
+	res, err := s.service.Resource("app")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision(), gc.Equals, 2)
+	c.Assert(res.Size(), gc.Equals, int64(6))
+}
+
+func (s *resourcesSuite) TestUploadErrors(c *gc.C) {
+	resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "app", ""), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "expected service and name arguments")
+
+	resp, err = s.authRequest(c, "POST", s.resourcesURI(c, "foo", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, `service "foo" not found`)
+
+	resp, err = s.authRequest(c, "POST", s.resourcesURI(c, "app", "other"), "", strings.NewReader("x"))
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, `cannot set resource "other" for service "app": .* not found`)
+}
+
+func (s *resourcesSuite) TestUploadBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	resp, err := s.authRequest(c, "POST", s.resourcesURI(c, "app", "app"), "application/octet-stream", strings.NewReader("x"))
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "the operation has been blocked.*")
+
+	_, err = s.service.Resource("app")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *resourcesSuite) TestUploadRequiresUser(c *gc.C) {
+	unit, password := s.addUnit(c, s.service)
+	resp, err := s.sendRequest(c, unit.Tag().String(), password, "POST", s.resourcesURI(c, "app", "app"), "", strings.NewReader("x"))
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *resourcesSuite) TestDownload(c *gc.C) {
+	s.upload(c, "content")
+	resp, err := s.authRequest(c, "GET", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusOK, "application/octet-stream")
+	c.Assert(string(body), gc.Equals, "content")
+	c.Assert(resp.Header.Get("Juju-Resource-Revision"), gc.Equals, "1")
+}
+
+func (s *resourcesSuite) TestDownloadAsUnit(c *gc.C) {
+	s.upload(c, "content")
+	unit, password := s.addUnit(c, s.service)
+	resp, err := s.sendRequest(c, unit.Tag().String(), password, "GET", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusOK, "application/octet-stream")
+	c.Assert(string(body), gc.Equals, "content")
+
+	// Units of other services may not download the resource.
+	other := s.AddTestingService(c, "other", s.AddTestingCharm(c, "dummy"))
+	unit, password = s.addUnit(c, other)
+	resp, err = s.sendRequest(c, unit.Tag().String(), password, "GET", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *resourcesSuite) TestDownloadNotFound(c *gc.C) {
+	resp, err := s.authRequest(c, "GET", s.resourcesURI(c, "app", "app"), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusNotFound, `resource "app" for service "app" not found`)
+}
@@ -45,6 +45,7 @@ type srvRoot struct {
 	rpcConn     *rpc.Conn
 	resources   *common.Resources
 	entity      state.Entity
+	limiter     *requestLimiter
 	objectMutex sync.RWMutex
 	objectCache map[objectKey]reflect.Value
 }
@@ -60,6 +61,7 @@ func newSrvRoot(root *initialRoot, entity state.Entity) *srvRoot {
 		rpcConn:     root.rpcConn,
 		resources:   common.NewResources(),
 		entity:      entity,
+		limiter:     root.srv.requestLimiter,
 		objectCache: make(map[objectKey]reflect.Value),
 	}
 	r.resources.RegisterNamed("dataDir", common.StringResource(root.srv.dataDir))
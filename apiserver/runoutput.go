@@ -0,0 +1,212 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"code.google.com/p/go.net/websocket"
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/state"
+)
+
+// runOutputPollInterval holds how often the run output handler checks
+// for new output.
+var runOutputPollInterval = 500 * time.Millisecond
+
+// runOutputMaxDuration holds how long the output of a run job is
+// streamed before the run output handler gives up waiting for the
+// job's tasks to finish.
+var runOutputMaxDuration = time.Hour
+
+// runOutputHandler takes requests to stream the output of a run job.
+type runOutputHandler struct {
+	httpHandler
+}
+
+// ServeHTTP will serve up connections as a websocket, streaming the
+// output of the run job identified by the "jobid" argument. Each line
+// of output is prefixed with the id of the machine or unit that
+// produced it, and the socket is closed once all the job's tasks are
+// done, the client goes away, or runOutputMaxDuration has passed.
+func (h *runOutputHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	server := websocket.Server{
+		Handler: func(socket *websocket.Conn) {
+			defer socket.Close()
+			logger.Infof("run output handler starting")
+			if err := h.authenticate(req); err != nil {
+				sendWebsocketError(socket, fmt.Errorf("auth failed: %v", err))
+				return
+			}
+			if err := h.validateEnvironUUID(req); err != nil {
+				sendWebsocketError(socket, err)
+				return
+			}
+			jobId := req.URL.Query().Get("jobid")
+			if jobId == "" {
+				sendWebsocketError(socket, fmt.Errorf("no job id specified"))
+				return
+			}
+			job, err := h.state.RunJob(jobId)
+			if err != nil {
+				sendWebsocketError(socket, err)
+				return
+			}
+			if err := sendWebsocketError(socket, nil); err != nil {
+				logger.Errorf("could not send good run output stream start")
+				return
+			}
+			// The client sends nothing, so reading fails only once
+			// it has gone away.
+			clientGone := make(chan struct{})
+			go func() {
+				defer close(clientGone)
+				io.Copy(ioutil.Discard, socket)
+			}()
+			if err := streamRunOutput(job, socket, clientGone); err != nil {
+				logger.Errorf("run output handler error: %v", err)
+			}
+		}}
+	server.ServeHTTP(w, req)
+}
+
+// streamRunOutput writes the output of the job's tasks to w as it
+// arrives, until all the tasks are done or stop is closed. It gives
+// up after runOutputMaxDuration. The tasks are loaded once; after
+// that only the unfinished tasks are refreshed, fetching just their
+// new output.
+func streamRunOutput(job *state.RunJob, w io.Writer, stop <-chan struct{}) error {
+	tasks, err := job.Tasks()
+	if err != nil {
+		return err
+	}
+	streams := make([]*taskStream, len(tasks))
+	for i, task := range tasks {
+		streams[i] = newTaskStream(task)
+	}
+	timeout := time.After(runOutputMaxDuration)
+	for {
+		done := true
+		for i, task := range tasks {
+			if streams[i].done {
+				continue
+			}
+			if err := streams[i].write(w, task); err != nil {
+				return err
+			}
+			done = done && streams[i].done
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-timeout:
+			return errors.Errorf("run job %q still running after %v", job.Id(), runOutputMaxDuration)
+		case <-time.After(runOutputPollInterval):
+		}
+		for i, task := range tasks {
+			if streams[i].done {
+				continue
+			}
+			if err := task.RefreshOutput(); errors.IsNotFound(err) {
+				// The job has been removed.
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// taskStream turns the output of a single task into lines prefixed
+// with the task's label.
+type taskStream struct {
+	label string
+	// next holds the index of the first output chunk not yet seen.
+	next int
+	// seen holds how much of stdout and stderr has been seen.
+	seen [2]int
+	// partial holds incomplete lines of stdout and stderr.
+	partial [2][]byte
+	// done records whether the task was seen to be done.
+	done bool
+}
+
+func newTaskStream(task *state.RunTask) *taskStream {
+	label := task.Target()
+	if tag, err := names.ParseTag(label); err == nil {
+		label = tag.Id()
+	}
+	return &taskStream{label: label}
+}
+
+// write writes any complete lines of new output from the task to w.
+// Once the task is done, its output is only held in its result, so
+// the rest of the result is written, including incomplete lines.
+func (s *taskStream) write(w io.Writer, task *state.RunTask) error {
+	if s.done {
+		return nil
+	}
+	if !task.Status().Done() {
+		output := task.Output()
+		for ; s.next < len(output); s.next++ {
+			chunk := output[s.next]
+			if err := s.add(w, chunk.Stderr, chunk.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s.done = true
+	result := task.Result()
+	for i, data := range [][]byte{result.Stdout, result.Stderr} {
+		if s.seen[i] < len(data) {
+			if err := s.add(w, i == 1, data[s.seen[i]:]); err != nil {
+				return err
+			}
+		}
+		if len(s.partial[i]) > 0 {
+			if err := s.writeLine(w, s.partial[i]); err != nil {
+				return err
+			}
+			s.partial[i] = nil
+		}
+	}
+	return nil
+}
+
+// add writes any complete lines of the given output to w, keeping
+// the incomplete line for later.
+func (s *taskStream) add(w io.Writer, stderr bool, data []byte) error {
+	i := 0
+	if stderr {
+		i = 1
+	}
+	s.seen[i] += len(data)
+	s.partial[i] = append(s.partial[i], data...)
+	for {
+		n := bytes.IndexByte(s.partial[i], '\n')
+		if n < 0 {
+			return nil
+		}
+		if err := s.writeLine(w, s.partial[i][:n]); err != nil {
+			return err
+		}
+		s.partial[i] = s.partial[i][n+1:]
+	}
+}
+
+func (s *taskStream) writeLine(w io.Writer, line []byte) error {
+	_, err := fmt.Fprintf(w, "%s: %s\n", s.label, line)
+	return err
+}
@@ -0,0 +1,174 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+
+	"code.google.com/p/go.net/websocket"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type runOutputSuite struct {
+	authHttpSuite
+	job *state.RunJob
+}
+
+var _ = gc.Suite(&runOutputSuite{})
+
+func (s *runOutputSuite) SetUpTest(c *gc.C) {
+	s.authHttpSuite.SetUpTest(c)
+	s.PatchValue(apiserver.RunOutputPollInterval, 10*time.Millisecond)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	s.job, err = s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{machine.Tag(), unit.Tag()})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *runOutputSuite) openWebsocket(c *gc.C, path, userTag string, values url.Values) *bufio.Reader {
+	server := s.baseURL(c)
+	server.Scheme = "wss"
+	server.Path = path
+	server.RawQuery = values.Encode()
+	config, err := websocket.NewConfig(server.String(), "http://localhost/")
+	c.Assert(err, gc.IsNil)
+	if userTag != "" {
+		config.Header = utils.BasicAuthHeader(userTag, s.password)
+	}
+	caCerts := x509.NewCertPool()
+	c.Assert(caCerts.AppendCertsFromPEM([]byte(testing.CACert)), jc.IsTrue)
+	config.TlsConfig = &tls.Config{RootCAs: caCerts, ServerName: "anything"}
+	conn, err := websocket.DialConfig(config)
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(_ *gc.C) { conn.Close() })
+	return bufio.NewReader(conn)
+}
+
+func (s *runOutputSuite) assertErrorResponse(c *gc.C, reader *bufio.Reader, expected string) {
+	line, err := reader.ReadSlice('\n')
+	c.Assert(err, gc.IsNil)
+	var errResult params.ErrorResult
+	err = json.Unmarshal(line, &errResult)
+	c.Assert(err, gc.IsNil)
+	if expected == "" {
+		c.Assert(errResult.Error, gc.IsNil)
+		return
+	}
+	c.Assert(errResult.Error, gc.NotNil)
+	c.Assert(errResult.Error.Message, gc.Matches, expected)
+	s.assertClosed(c, reader)
+}
+
+func (s *runOutputSuite) assertClosed(c *gc.C, reader *bufio.Reader) {
+	_, err := reader.ReadByte()
+	c.Assert(err, gc.Equals, io.EOF)
+}
+
+func (s *runOutputSuite) readLines(c *gc.C, reader *bufio.Reader, count int) []string {
+	var lines []string
+	for len(lines) < count {
+		line, err := reader.ReadString('\n')
+		c.Assert(err, gc.IsNil)
+		lines = append(lines, line[:len(line)-1])
+	}
+	return lines
+}
+
+func (s *runOutputSuite) TestNoAuth(c *gc.C) {
+	reader := s.openWebsocket(c, "/runoutput", "", url.Values{"jobid": {s.job.Id()}})
+	s.assertErrorResponse(c, reader, "auth failed: invalid request format")
+}
+
+func (s *runOutputSuite) TestNoJobId(c *gc.C) {
+	reader := s.openWebsocket(c, "/runoutput", s.userTag, url.Values{})
+	s.assertErrorResponse(c, reader, "no job id specified")
+}
+
+func (s *runOutputSuite) TestUnknownJob(c *gc.C) {
+	reader := s.openWebsocket(c, "/runoutput", s.userTag, url.Values{"jobid": {"42"}})
+	s.assertErrorResponse(c, reader, `run job "42" not found`)
+}
+
+func (s *runOutputSuite) TestWrongEnvUUID(c *gc.C) {
+	path := "/environment/dead-beef-123456/runoutput"
+	reader := s.openWebsocket(c, path, s.userTag, url.Values{"jobid": {s.job.Id()}})
+	s.assertErrorResponse(c, reader, `unknown environment: "dead-beef-123456"`)
+}
+
+func (s *runOutputSuite) TestStreamsOutput(c *gc.C) {
+	tasks, err := s.job.Tasks()
+	c.Assert(err, gc.IsNil)
+	for _, task := range tasks {
+		err := task.Start()
+		c.Assert(err, gc.IsNil)
+	}
+	err = tasks[0].AppendOutput([]byte("one\ntw"), []byte("oops\n"))
+	c.Assert(err, gc.IsNil)
+
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	path := "/environment/" + env.UUID() + "/runoutput"
+	reader := s.openWebsocket(c, path, s.userTag, url.Values{"jobid": {s.job.Id()}})
+	s.assertErrorResponse(c, reader, "")
+	c.Assert(s.readLines(c, reader, 2), jc.DeepEquals, []string{"0: one", "0: oops"})
+
+	// Partial lines are sent once the task is done.
+	err = tasks[1].AppendOutput([]byte("wordpress\n"), nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.readLines(c, reader, 1), jc.DeepEquals, []string{"wordpress/0: wordpress"})
+	err = tasks[0].AppendOutput([]byte("o"), nil)
+	c.Assert(err, gc.IsNil)
+	err = tasks[0].Finish(state.RunTaskResult{
+		Stdout: []byte("one\ntwo"),
+		Stderr: []byte("oops\n"),
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.readLines(c, reader, 1), jc.DeepEquals, []string{"0: two"})
+
+	// The socket is closed once all tasks are done.
+	err = tasks[1].Finish(state.RunTaskResult{Code: 1, Stdout: []byte("wordpress\n")})
+	c.Assert(err, gc.IsNil)
+	s.assertClosed(c, reader)
+}
+
+func (s *runOutputSuite) TestStopsWhenJobRemoved(c *gc.C) {
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	path := "/environment/" + env.UUID() + "/runoutput"
+	reader := s.openWebsocket(c, path, s.userTag, url.Values{"jobid": {s.job.Id()}})
+	s.assertErrorResponse(c, reader, "")
+
+	err = s.job.Remove()
+	c.Assert(err, gc.IsNil)
+	s.assertClosed(c, reader)
+}
+
+func (s *runOutputSuite) TestGivesUpAfterMaxDuration(c *gc.C) {
+	s.PatchValue(apiserver.RunOutputMaxDuration, 50*time.Millisecond)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	path := "/environment/" + env.UUID() + "/runoutput"
+	reader := s.openWebsocket(c, path, s.userTag, url.Values{"jobid": {s.job.Id()}})
+	s.assertErrorResponse(c, reader, "")
+	s.assertClosed(c, reader)
+}
@@ -0,0 +1,171 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runtasks
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
+)
+
+func init() {
+	common.RegisterStandardFacade("RunTasks", 0, NewRunTasksAPI)
+}
+
+// RunTasksAPI implements the API used by machine agents to run the
+// tasks of asynchronous run jobs on their machines and units.
+type RunTasksAPI struct {
+	st         *state.State
+	resources  *common.Resources
+	authorizer common.Authorizer
+}
+
+// NewRunTasksAPI creates a new server-side RunTasks API end point.
+func NewRunTasksAPI(
+	st *state.State,
+	resources *common.Resources,
+	authorizer common.Authorizer,
+) (*RunTasksAPI, error) {
+	if !authorizer.AuthMachineAgent() {
+		return nil, common.ErrPerm
+	}
+	return &RunTasksAPI{st: st, resources: resources, authorizer: authorizer}, nil
+}
+
+// WatchRunTasks returns a StringsWatcher for each given machine,
+// notifying of the ids of the tasks waiting to be run by its agent.
+func (api *RunTasksAPI) WatchRunTasks(args params.Entities) (params.StringsWatchResults, error) {
+	results := make([]params.StringsWatchResult, len(args.Entities))
+	for i, entity := range args.Entities {
+		tag, err := names.ParseMachineTag(entity.Tag)
+		if err != nil || !api.authorizer.AuthOwner(tag) {
+			results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		machine, err := api.st.Machine(tag.Id())
+		if err != nil {
+			results[i].Error = common.ServerError(err)
+			continue
+		}
+		watch := machine.WatchRunTasks()
+		// Consume the initial event and forward it to the result.
+		if changes, ok := <-watch.Changes(); ok {
+			results[i].StringsWatcherId = api.resources.Register(watch)
+			results[i].Changes = changes
+		} else {
+			results[i].Error = common.ServerError(watcher.MustErr(watch))
+		}
+	}
+	return params.StringsWatchResults{Results: results}, nil
+}
+
+// RunTasks returns the commands to run for each of the given tasks.
+func (api *RunTasksAPI) RunTasks(args params.RunTaskIds) (params.RunTaskResults, error) {
+	results := make([]params.RunTaskResult, len(args.Ids))
+	for i, id := range args.Ids {
+		task, err := api.getTask(id)
+		if err != nil {
+			results[i].Error = common.ServerError(err)
+			continue
+		}
+		job, err := task.Job()
+		if err != nil {
+			results[i].Error = common.ServerError(err)
+			continue
+		}
+		results[i].Result = params.RunTask{
+			Id:       task.Id(),
+			Target:   task.Target(),
+			Commands: job.Commands(),
+			Timeout:  job.Timeout(),
+		}
+	}
+	return params.RunTaskResults{Results: results}, nil
+}
+
+// StartRunTasks records that the agent has started running each of
+// the given tasks.
+func (api *RunTasksAPI) StartRunTasks(args params.RunTaskIds) (params.ErrorResults, error) {
+	results := make([]params.ErrorResult, len(args.Ids))
+	for i, id := range args.Ids {
+		task, err := api.getTask(id)
+		if err == nil {
+			err = task.Start()
+		}
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ErrorResults{Results: results}, nil
+}
+
+// AppendRunTaskOutput records output written so far by the commands
+// of each of the given running tasks.
+func (api *RunTasksAPI) AppendRunTaskOutput(args params.RunTaskOutputs) (params.ErrorResults, error) {
+	results := make([]params.ErrorResult, len(args.Outputs))
+	for i, output := range args.Outputs {
+		task, err := api.getTask(output.Id)
+		if err == nil {
+			err = task.AppendOutput(output.Stdout, output.Stderr)
+		}
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ErrorResults{Results: results}, nil
+}
+
+// FinishRunTasks records the outcome of each of the given running
+// tasks.
+func (api *RunTasksAPI) FinishRunTasks(args params.RunTaskOutcomes) (params.ErrorResults, error) {
+	results := make([]params.ErrorResult, len(args.Outcomes))
+	for i, outcome := range args.Outcomes {
+		task, err := api.getTask(outcome.Id)
+		if err == nil {
+			err = task.Finish(state.RunTaskResult{
+				Code:   outcome.Code,
+				Stdout: outcome.Stdout,
+				Stderr: outcome.Stderr,
+				Error:  outcome.Error,
+			})
+		}
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ErrorResults{Results: results}, nil
+}
+
+// FailRunningTasks records that the tasks each given machine's agent
+// was running have failed because the agent restarted while running
+// them.
+func (api *RunTasksAPI) FailRunningTasks(args params.Entities) (params.ErrorResults, error) {
+	results := make([]params.ErrorResult, len(args.Entities))
+	for i, entity := range args.Entities {
+		tag, err := names.ParseMachineTag(entity.Tag)
+		if err != nil || !api.authorizer.AuthOwner(tag) {
+			results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		machine, err := api.st.Machine(tag.Id())
+		if err == nil {
+			err = machine.FailRunningTasks("agent restarted while running the commands")
+		}
+		results[i].Error = common.ServerError(err)
+	}
+	return params.ErrorResults{Results: results}, nil
+}
+
+// getTask returns the task with the given id, if it is run by the
+// authenticated machine agent.
+func (api *RunTasksAPI) getTask(id string) (*state.RunTask, error) {
+	task, err := api.st.RunTask(id)
+	if errors.IsNotFound(err) {
+		return nil, common.ErrPerm
+	} else if err != nil {
+		return nil, err
+	}
+	if !api.authorizer.AuthOwner(names.NewMachineTag(task.MachineId())) {
+		return nil, common.ErrPerm
+	}
+	return task, nil
+}
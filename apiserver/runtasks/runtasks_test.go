@@ -0,0 +1,196 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runtasks_test
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/exec"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/apiserver/runtasks"
+	apiservertesting "github.com/juju/juju/apiserver/testing"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+)
+
+type runTasksSuite struct {
+	jujutesting.JujuConnSuite
+
+	machine    *state.Machine
+	other      *state.Machine
+	job        *state.RunJob
+	tasks      []*state.RunTask
+	resources  *common.Resources
+	authorizer apiservertesting.FakeAuthorizer
+	api        *runtasks.RunTasksAPI
+}
+
+var _ = gc.Suite(&runTasksSuite{})
+
+func (s *runTasksSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.other, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.job, err = s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{s.machine.Tag(), s.other.Tag()})
+	c.Assert(err, gc.IsNil)
+	s.tasks, err = s.job.Tasks()
+	c.Assert(err, gc.IsNil)
+
+	s.authorizer = apiservertesting.FakeAuthorizer{Tag: s.machine.Tag()}
+	s.api, err = runtasks.NewRunTasksAPI(s.State, s.resources, s.authorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *runTasksSuite) TestNewRunTasksAPIRefusesNonMachineAgent(c *gc.C) {
+	authorizer := apiservertesting.FakeAuthorizer{Tag: names.NewUnitTag("wordpress/0")}
+	_, err := runtasks.NewRunTasksAPI(s.State, s.resources, authorizer)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *runTasksSuite) TestWatchRunTasks(c *gc.C) {
+	results, err := s.api.WatchRunTasks(params.Entities{Entities: []params.Entity{
+		{Tag: s.machine.Tag().String()},
+		{Tag: s.other.Tag().String()},
+		{Tag: "unit-wordpress-0"},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.StringsWatchResults{
+		Results: []params.StringsWatchResult{
+			{StringsWatcherId: "1", Changes: []string{s.tasks[0].Id()}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+	wc := statetesting.NewStringsWatcherC(c, s.State, resource.(state.StringsWatcher))
+	wc.AssertNoChange()
+}
+
+func (s *runTasksSuite) TestRunTasks(c *gc.C) {
+	results, err := s.api.RunTasks(params.RunTaskIds{Ids: []string{
+		s.tasks[0].Id(),
+		s.tasks[1].Id(),
+		"machine-0#42#machine-0",
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.RunTaskResults{
+		Results: []params.RunTaskResult{
+			{Result: params.RunTask{
+				Id:       s.tasks[0].Id(),
+				Target:   s.machine.Tag().String(),
+				Commands: "hostname",
+				Timeout:  time.Minute,
+			}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *runTasksSuite) TestStartAndFinishRunTasks(c *gc.C) {
+	ids := params.RunTaskIds{Ids: []string{s.tasks[0].Id(), s.tasks[1].Id()}}
+	results, err := s.api.StartRunTasks(ids)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	results, err = s.api.FinishRunTasks(params.RunTaskOutcomes{
+		Outcomes: []params.RunTaskOutcome{{
+			Id:           s.tasks[0].Id(),
+			ExecResponse: exec.ExecResponse{Code: 1, Stdout: []byte("out")},
+		}, {
+			Id:    s.tasks[1].Id(),
+			Error: "boom",
+		}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	err = s.tasks[0].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.tasks[0].Status(), gc.Equals, state.RunTaskCompleted)
+	c.Assert(s.tasks[0].Result(), jc.DeepEquals, state.RunTaskResult{Code: 1, Stdout: []byte("out")})
+	err = s.tasks[1].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.tasks[1].Status(), gc.Equals, state.RunTaskPending)
+}
+
+func (s *runTasksSuite) TestAppendRunTaskOutput(c *gc.C) {
+	err := s.tasks[0].Start()
+	c.Assert(err, gc.IsNil)
+	results, err := s.api.AppendRunTaskOutput(params.RunTaskOutputs{
+		Outputs: []params.RunTaskOutput{{
+			Id:     s.tasks[0].Id(),
+			Stdout: []byte("out\n"),
+			Stderr: []byte("err\n"),
+		}, {
+			Id:     s.tasks[1].Id(),
+			Stdout: []byte("out\n"),
+		}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+	err = s.tasks[0].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.tasks[0].Output(), jc.DeepEquals, []state.RunOutput{
+		{Data: []byte("out\n")},
+		{Stderr: true, Data: []byte("err\n")},
+	})
+}
+
+func (s *runTasksSuite) TestFailRunningTasks(c *gc.C) {
+	for _, task := range s.tasks {
+		err := task.Start()
+		c.Assert(err, gc.IsNil)
+	}
+	results, err := s.api.FailRunningTasks(params.Entities{Entities: []params.Entity{
+		{Tag: s.machine.Tag().String()},
+		{Tag: s.other.Tag().String()},
+		{Tag: "unit-wordpress-0"},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, jc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	err = s.tasks[0].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.tasks[0].Status(), gc.Equals, state.RunTaskFailed)
+	c.Assert(s.tasks[0].Result().Error, gc.Equals, "agent restarted while running the commands")
+	err = s.tasks[1].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.tasks[1].Status(), gc.Equals, state.RunTaskRunning)
+}
@@ -294,6 +294,45 @@ func dialWebsocket(c *gc.C, addr, path string) (*websocket.Conn, error) {
 	return websocket.DialConfig(config)
 }
 
+func (s *serverSuite) TestCertChanged(c *gc.C) {
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, gc.IsNil)
+	certChanged := make(chan params.StateServingInfo)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert:        []byte(coretesting.ServerCert),
+		Key:         []byte(coretesting.ServerKey),
+		CertChanged: certChanged,
+	})
+	c.Assert(err, gc.IsNil)
+	defer srv.Stop()
+	_, portString, err := net.SplitHostPort(srv.Addr())
+	c.Assert(err, gc.IsNil)
+	addr := "localhost:" + portString
+
+	expiry := time.Now().AddDate(1, 0, 0)
+	newCACert, newCAKey, err := cert.NewCA("new", expiry)
+	c.Assert(err, gc.IsNil)
+	newCert, newKey, err := cert.NewServer(newCACert, newCAKey, expiry, []string{"localhost"})
+	c.Assert(err, gc.IsNil)
+	pool, err := cert.ParseCertPool(newCACert)
+	c.Assert(err, gc.IsNil)
+	tlsConfig := &tls.Config{RootCAs: pool}
+
+	// The old certificate is served until the new one is received.
+	_, err = tls.Dial("tcp", addr, tlsConfig)
+	c.Assert(err, gc.ErrorMatches, "x509: certificate signed by unknown authority")
+
+	certChanged <- params.StateServingInfo{Cert: newCert, PrivateKey: newKey}
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err == nil {
+			conn.Close()
+			return
+		}
+	}
+	c.Fatalf("new certificate not served")
+}
+
 func (s *serverSuite) TestNonCompatiblePathsAre404(c *gc.C) {
 	// we expose the API at '/' for compatibility, and at '/ENVUUID/api'
 	// for the correct location, but other Paths should fail.
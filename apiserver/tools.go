@@ -75,6 +75,10 @@ func (h *toolsUploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "POST":
 		// Add tools to storage.
+		if err := common.NewBlockChecker(h.state).ChangeAllowed(); err != nil {
+			h.sendJSON(w, http.StatusBadRequest, &params.ToolsResult{Error: common.ServerError(err)})
+			return
+		}
 		agentTools, disableSSLHostnameVerification, err := h.processPost(r)
 		if err != nil {
 			h.sendError(w, http.StatusBadRequest, err.Error())
@@ -81,6 +81,15 @@ func (s *toolsSuite) TestUploadRequiresVersion(c *gc.C) {
 	s.assertErrorResponse(c, resp, http.StatusBadRequest, "expected binaryVersion argument")
 }
 
+func (s *toolsSuite) TestUploadBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn("all-changes", "")
+	c.Assert(err, gc.IsNil)
+	_, vers, toolPath := s.setupToolsForUpload(c)
+	resp, err := s.uploadRequest(c, s.toolsURI(c, "?binaryVersion="+vers.String()), true, toolPath)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusBadRequest, "the operation has been blocked.*")
+}
+
 func (s *toolsSuite) TestUploadFailsWithNoTools(c *gc.C) {
 	// Create an empty file.
 	tempFile, err := ioutil.TempFile(c.MkDir(), "tools")
@@ -20,6 +20,7 @@ import (
 
 func init() {
 	common.RegisterStandardFacade("Uniter", 0, NewUniterAPI)
+	common.RegisterStandardFacade("Uniter", 1, NewUniterAPIV1)
 }
 
 // UniterAPI implements the API used by the uniter worker.
@@ -79,6 +80,21 @@ func NewUniterAPI(st *state.State, resources *common.Resources, authorizer commo
 	}, nil
 }
 
+// UniterAPIV1 implements version 1 of the API used by the uniter
+// worker, which adds support for port ranges.
+type UniterAPIV1 struct {
+	*UniterAPI
+}
+
+// NewUniterAPIV1 creates a new instance of version 1 of the Uniter API.
+func NewUniterAPIV1(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*UniterAPIV1, error) {
+	api, err := NewUniterAPI(st, resources, authorizer)
+	if err != nil {
+		return nil, err
+	}
+	return &UniterAPIV1{api}, nil
+}
+
 func (u *UniterAPI) getUnit(tag names.UnitTag) (*state.Unit, error) {
 	return u.st.Unit(tag.Id())
 }
@@ -360,17 +376,12 @@ func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, er
 		}
 		err = common.ErrPerm
 		if canAccess(tag) {
-			var unitOrService state.Entity
-			unitOrService, err = u.st.FindEntity(tag)
-			if err == nil {
-				charmURLer := unitOrService.(interface {
-					CharmURL() (*charm.URL, bool)
-				})
-				curl, ok := charmURLer.CharmURL()
-				if curl != nil {
-					result.Results[i].Result = curl.String()
-					result.Results[i].Ok = ok
-				}
+			var curl *charm.URL
+			var ok bool
+			curl, ok, err = u.charmURL(tag)
+			if err == nil && curl != nil {
+				result.Results[i].Result = curl.String()
+				result.Results[i].Ok = ok
 			}
 		}
 		result.Results[i].Error = common.ServerError(err)
@@ -378,6 +389,35 @@ func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, er
 	return result, nil
 }
 
+// charmURL returns the charm URL of the given unit or service. For a
+// service, the charm URL is the one the authenticated unit should be
+// running, which lags behind the service's own during a rolling
+// upgrade that has yet to admit the unit.
+func (u *UniterAPI) charmURL(tag names.Tag) (*charm.URL, bool, error) {
+	entity, err := u.st.FindEntity(tag)
+	if err != nil {
+		return nil, false, err
+	}
+	service, ok := entity.(*state.Service)
+	if !ok {
+		charmURLer := entity.(interface {
+			CharmURL() (*charm.URL, bool)
+		})
+		curl, ok := charmURLer.CharmURL()
+		return curl, ok, nil
+	}
+	unitTag, ok := u.auth.GetAuthTag().(names.UnitTag)
+	if !ok {
+		curl, force := service.CharmURL()
+		return curl, force, nil
+	}
+	unit, err := u.getUnit(unitTag)
+	if err != nil {
+		return nil, false, err
+	}
+	return service.CharmURLForUnit(unit)
+}
+
 // SetCharmURL sets the charm URL for each given unit. An error will
 // be returned if a unit is dead, or the charm URL is not know.
 func (u *UniterAPI) SetCharmURL(args params.EntitiesCharmURL) (params.ErrorResults, error) {
@@ -469,6 +509,76 @@ func (u *UniterAPI) ClosePort(args params.EntitiesPorts) (params.ErrorResults, e
 	return result, nil
 }
 
+// OpenPorts sets the policy of the ports in each given range to be
+// opened, for all given units.
+func (u *UniterAPIV1) OpenPorts(args params.EntitiesPortRanges) (params.ErrorResults, error) {
+	return u.changePorts(args, (*state.Unit).OpenPorts)
+}
+
+// ClosePorts sets the policy of the ports in each given range to be
+// closed, for all given units.
+func (u *UniterAPIV1) ClosePorts(args params.EntitiesPortRanges) (params.ErrorResults, error) {
+	return u.changePorts(args, (*state.Unit).ClosePorts)
+}
+
+func (u *UniterAPI) changePorts(
+	args params.EntitiesPortRanges,
+	change func(unit *state.Unit, protocol string, fromPort, toPort int) error,
+) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseUnitTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		if canAccess(tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(tag)
+			if err == nil {
+				err = change(unit, entity.Protocol, entity.FromPort, entity.ToPort)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// OpenedPorts returns the port ranges opened by each given unit.
+func (u *UniterAPIV1) OpenedPorts(args params.Entities) (params.PortRangesResults, error) {
+	result := params.PortRangesResults{
+		Results: make([]params.PortRangesResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.PortRangesResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseUnitTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		if canAccess(tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(tag)
+			if err == nil {
+				result.Results[i].Ranges, err = unit.OpenedPorts()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (u *UniterAPI) watchOneUnitConfigSettings(tag names.UnitTag) (string, error) {
 	unit, err := u.getUnit(tag)
 	if err != nil {
@@ -1319,3 +1429,93 @@ func (u *UniterAPI) WatchUnitAddresses(args params.Entities) (params.NotifyWatch
 	}
 	return result, nil
 }
+
+// ServiceResources returns the resources attached to each given service.
+func (u *UniterAPI) ServiceResources(args params.Entities) (params.ResourcesResults, error) {
+	result := params.ResourcesResults{
+		Results: make([]params.ResourcesResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessService()
+	if err != nil {
+		return params.ResourcesResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		if canAccess(tag) {
+			var service *state.Service
+			service, err = u.getService(tag)
+			if err == nil {
+				result.Results[i].Resources, err = serviceResources(service)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func serviceResources(service *state.Service) ([]params.ResourceInfo, error) {
+	resources, err := service.Resources()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]params.ResourceInfo, len(resources))
+	for i, res := range resources {
+		infos[i] = params.ResourceInfo{
+			Service:  res.Service(),
+			Name:     res.Name(),
+			Revision: res.Revision(),
+			Size:     res.Size(),
+			SHA256:   res.SHA256(),
+		}
+	}
+	return infos, nil
+}
+
+func (u *UniterAPI) watchOneServiceResources(tag names.ServiceTag) (string, error) {
+	service, err := u.getService(tag)
+	if err != nil {
+		return "", err
+	}
+	watch := service.WatchResources()
+	// Consume the initial event. Technically, API
+	// calls to Watch 'transmit' the initial event
+	// in the Watch response. But NotifyWatchers
+	// have no state to transmit.
+	if _, ok := <-watch.Changes(); ok {
+		return u.resources.Register(watch), nil
+	}
+	return "", watcher.MustErr(watch)
+}
+
+// WatchServiceResources returns a NotifyWatcher, for each given
+// service, that notifies of changes to the resources attached to
+// that service.
+func (u *UniterAPI) WatchServiceResources(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessService()
+	if err != nil {
+		return params.NotifyWatchResults{}, err
+	}
+	for i, entity := range args.Entities {
+		tag, err := names.ParseServiceTag(entity.Tag)
+		if err != nil {
+			result.Results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		err = common.ErrPerm
+		watcherId := ""
+		if canAccess(tag) {
+			watcherId, err = u.watchOneServiceResources(tag)
+		}
+		result.Results[i].NotifyWatcherId = watcherId
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
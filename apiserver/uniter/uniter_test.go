@@ -4,6 +4,7 @@
 package uniter_test
 
 import (
+	"strings"
 	stdtesting "testing"
 
 	"github.com/juju/errors"
@@ -45,7 +46,8 @@ type uniterSuite struct {
 	wordpressUnit *state.Unit
 	mysqlUnit     *state.Unit
 
-	uniter *uniter.UniterAPI
+	uniter   *uniter.UniterAPI
+	uniterV1 *uniter.UniterAPIV1
 }
 
 var _ = gc.Suite(&uniterSuite{})
@@ -90,6 +92,12 @@ func (s *uniterSuite) SetUpTest(c *gc.C) {
 		s.authorizer,
 	)
 	c.Assert(err, gc.IsNil)
+	s.uniterV1, err = uniter.NewUniterAPIV1(
+		s.State,
+		s.resources,
+		s.authorizer,
+	)
+	c.Assert(err, gc.IsNil)
 	s.EnvironWatcherTest = commontesting.NewEnvironWatcherTest(s.uniter, s.State, s.resources, commontesting.NoSecrets)
 }
 
@@ -624,6 +632,33 @@ func (s *uniterSuite) TestCharmURL(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestCharmURLDuringRollingUpgrade(c *gc.C) {
+	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
+	c.Assert(err, gc.IsNil)
+	newCharm := s.AddMetaCharm(c, "wordpress", "name: wordpress\nsummary: blog\ndescription: blog\n", 42)
+	err = s.wordpress.SetCharmRolling(newCharm, false, 1)
+	c.Assert(err, gc.IsNil)
+
+	// Until the rolling upgrade admits the unit, the service's
+	// charm is reported as the one the unit is already running.
+	args := params.Entities{Entities: []params.Entity{{Tag: "service-wordpress"}}}
+	result, err := s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{{Result: s.wpCharm.String()}},
+	})
+
+	upgrade, err := s.wordpress.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	result, err = s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{{Result: newCharm.String()}},
+	})
+}
+
 func (s *uniterSuite) TestSetCharmURL(c *gc.C) {
 	charmUrl, ok := s.wordpressUnit.CharmURL()
 	c.Assert(charmUrl, gc.IsNil)
@@ -654,7 +689,8 @@ func (s *uniterSuite) TestSetCharmURL(c *gc.C) {
 }
 
 func (s *uniterSuite) TestOpenPort(c *gc.C) {
-	openedPorts := s.wordpressUnit.OpenedPorts()
+	openedPorts, err := s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	c.Assert(openedPorts, gc.HasLen, 0)
 
 	args := params.EntitiesPorts{Entities: []params.EntityPort{
@@ -675,9 +711,10 @@ func (s *uniterSuite) TestOpenPort(c *gc.C) {
 	// Verify the wordpressUnit's port is opened.
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	openedPorts = s.wordpressUnit.OpenedPorts()
-	c.Assert(openedPorts, gc.DeepEquals, []network.Port{
-		{Protocol: "udp", Number: 4321},
+	openedPorts, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(openedPorts, gc.DeepEquals, []network.PortRange{
+		{FromPort: 4321, ToPort: 4321, Protocol: "udp"},
 	})
 }
 
@@ -687,9 +724,10 @@ func (s *uniterSuite) TestClosePort(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	openedPorts := s.wordpressUnit.OpenedPorts()
-	c.Assert(openedPorts, gc.DeepEquals, []network.Port{
-		{Protocol: "udp", Number: 4321},
+	openedPorts, err := s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(openedPorts, gc.DeepEquals, []network.PortRange{
+		{FromPort: 4321, ToPort: 4321, Protocol: "udp"},
 	})
 
 	args := params.EntitiesPorts{Entities: []params.EntityPort{
@@ -710,10 +748,78 @@ func (s *uniterSuite) TestClosePort(c *gc.C) {
 	// Verify the wordpressUnit's port is closed.
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
-	openedPorts = s.wordpressUnit.OpenedPorts()
+	openedPorts, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
 	c.Assert(openedPorts, gc.HasLen, 0)
 }
 
+func (s *uniterSuite) TestOpenClosePorts(c *gc.C) {
+	args := params.EntitiesPortRanges{Entities: []params.EntityPortRange{
+		{Tag: "unit-mysql-0", Protocol: "tcp", FromPort: 1234, ToPort: 1240},
+		{Tag: "unit-wordpress-0", Protocol: "tcp", FromPort: 8000, ToPort: 8002},
+		{Tag: "unit-wordpress-0", Protocol: "icmp", FromPort: -1, ToPort: -1},
+		{Tag: "unit-foo-42", Protocol: "tcp", FromPort: 42, ToPort: 43},
+	}}
+	result, err := s.uniterV1.OpenPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the wordpressUnit's ports are opened.
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	ranges, err := s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{
+		{FromPort: -1, ToPort: -1, Protocol: "icmp"},
+		{FromPort: 8000, ToPort: 8002, Protocol: "tcp"},
+	})
+
+	result, err = s.uniterV1.ClosePorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the wordpressUnit's ports are closed.
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	ranges, err = s.wordpressUnit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.HasLen, 0)
+}
+
+func (s *uniterSuite) TestOpenedPorts(c *gc.C) {
+	err := s.wordpressUnit.OpenPorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniterV1.OpenedPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.PortRangesResults{
+		Results: []params.PortRangesResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Ranges: []network.PortRange{{FromPort: 8000, ToPort: 8002, Protocol: "tcp"}}},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *uniterSuite) TestWatchConfigSettings(c *gc.C) {
 	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
 	c.Assert(err, gc.IsNil)
@@ -1770,3 +1876,86 @@ func (s *uniterSuite) TestWatchUnitAddresses(c *gc.C) {
 	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
 	wc.AssertNoChange()
 }
+
+var resourcesMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+
+// addResourcesService adds a service whose charm declares resources,
+// and returns it with a uniter API for its only unit.
+func (s *uniterSuite) addResourcesService(c *gc.C) (*state.Service, *uniter.UniterAPI) {
+	service := s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", resourcesMeta, 1))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	authorizer := apiservertesting.FakeAuthorizer{Tag: unit.Tag()}
+	api, err := uniter.NewUniterAPI(s.State, s.resources, authorizer)
+	c.Assert(err, gc.IsNil)
+	return service, api
+}
+
+func (s *uniterSuite) TestServiceResources(c *gc.C) {
+	service, api := s.addResourcesService(c)
+	_, err := service.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "service-app"},
+		{Tag: "service-wordpress"},
+		{Tag: "unit-app-0"},
+		{Tag: "service-foo"},
+	}}
+	result, err := api.ServiceResources(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ResourcesResults{
+		Results: []params.ResourcesResult{
+			{Resources: []params.ResourceInfo{{
+				Service:  "app",
+				Name:     "app",
+				Revision: 1,
+				Size:     7,
+				SHA256:   "sha256-content",
+			}}},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestWatchServiceResources(c *gc.C) {
+	service, api := s.addResourcesService(c)
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "service-wordpress"},
+		{Tag: "service-app"},
+		{Tag: "unit-app-0"},
+	}}
+	result, err := api.WatchServiceResources(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// Check that the Watch has consumed the initial event ("returned" in
+	// the Watch call), and reports resource changes.
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+	_, err = service.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
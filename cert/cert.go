@@ -38,6 +38,45 @@ func ParseCert(certPEM string) (*x509.Certificate, error) {
 	return nil, errors.New("no certificates found")
 }
 
+// SplitCerts returns each of the PEM-formatted X509 certificates
+// in the given PEM data, in order.
+func SplitCerts(certsPEM string) ([]string, error) {
+	var certs []string
+	certsPEMData := []byte(certsPEM)
+	for len(certsPEMData) > 0 {
+		var certBlock *pem.Block
+		certBlock, certsPEMData = pem.Decode(certsPEMData)
+		if certBlock == nil {
+			break
+		}
+		if certBlock.Type == "CERTIFICATE" {
+			certs = append(certs, string(pem.EncodeToMemory(certBlock)))
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+	return certs, nil
+}
+
+// ParseCertPool returns a certificate pool holding all the PEM-formatted
+// X509 certificates in the given PEM data.
+func ParseCertPool(certsPEM string) (*x509.CertPool, error) {
+	certs, err := SplitCerts(certsPEM)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	for _, certPEM := range certs {
+		xcert, err := ParseCert(certPEM)
+		if err != nil {
+			return nil, err
+		}
+		pool.AddCert(xcert)
+	}
+	return pool, nil
+}
+
 // ParseCertAndKey parses the given PEM-formatted X509 certificate
 // and RSA private key.
 func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey, error) {
@@ -59,27 +98,86 @@ func ParseCertAndKey(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey
 }
 
 // Verify verifies that the given server certificate is valid with
-// respect to the given CA certificate at the given time.
+// respect to the given CA certificate at the given time. The CA
+// certificate may hold several certificates, in which case the server
+// certificate must be valid with respect to any one of them. The server
+// certificate may be followed by the intermediate certificates that
+// chain it to the CA. The host names the certificate is valid for are
+// not checked.
 func Verify(srvCertPEM, caCertPEM string, when time.Time) error {
-	caCert, err := ParseCert(caCertPEM)
+	pool, err := ParseCertPool(caCertPEM)
 	if err != nil {
 		return errors.Annotate(err, "cannot parse CA certificate")
 	}
-	srvCert, err := ParseCert(srvCertPEM)
+	srvCerts, err := SplitCerts(srvCertPEM)
 	if err != nil {
 		return errors.Annotate(err, "cannot parse server certificate")
 	}
-	pool := x509.NewCertPool()
-	pool.AddCert(caCert)
+	var chain []*x509.Certificate
+	for i, certPEM := range srvCerts {
+		xcert, err := ParseCert(certPEM)
+		if err != nil {
+			if i == 0 {
+				return errors.Annotate(err, "cannot parse server certificate")
+			}
+			return errors.Annotate(err, "cannot parse intermediate certificate")
+		}
+		chain = append(chain, xcert)
+	}
+	return verifyChain(chain, pool, when)
+}
+
+// VerifyHost verifies that the certificates presented by a server,
+// the server certificate followed by any intermediate certificates,
+// are valid with respect to the given CA certificates at the given
+// time, and that the server certificate is valid for the given host.
+// Certificates valid for the wildcard host name "*", as those issued
+// by juju are, are accepted for any host.
+func VerifyHost(chain []*x509.Certificate, host string, roots *x509.CertPool, when time.Time) error {
+	if len(chain) == 0 {
+		return errors.New("no server certificate")
+	}
+	if err := verifyChain(chain, roots, when); err != nil {
+		return err
+	}
+	srvCert := chain[0]
+	if err := srvCert.VerifyHostname(host); err != nil && !isWildcard(srvCert) {
+		return err
+	}
+	return nil
+}
+
+// verifyChain verifies that the first of the given certificates is
+// valid with respect to the given CA certificates at the given time,
+// using the others as intermediate certificates.
+func verifyChain(chain []*x509.Certificate, roots *x509.CertPool, when time.Time) error {
+	intermediates := x509.NewCertPool()
+	for _, xcert := range chain[1:] {
+		intermediates.AddCert(xcert)
+	}
 	opts := x509.VerifyOptions{
-		DNSName:     "anyServer",
-		Roots:       pool,
-		CurrentTime: when,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   when,
 	}
-	_, err = srvCert.Verify(opts)
+	_, err := chain[0].Verify(opts)
 	return err
 }
 
+// isWildcard reports whether the certificate is valid for the
+// wildcard host name "*".
+func isWildcard(xcert *x509.Certificate) bool {
+	if xcert.Subject.CommonName == "*" {
+		return true
+	}
+	for _, name := range xcert.DNSNames {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // NewCA generates a CA certificate/key pair suitable for signing server
 // keys for an environment with the given name.
 func NewCA(envName string, expiry time.Time) (certPEM, keyPEM string, err error) {
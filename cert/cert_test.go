@@ -5,12 +5,16 @@ package cert_test
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net"
 	"strings"
 	"testing"
@@ -196,6 +200,159 @@ func (certSuite) TestVerify(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, "x509: certificate signed by unknown authority")
 }
 
+func (certSuite) TestVerifyWithSeveralCAs(c *gc.C) {
+	now := time.Now()
+	caCert, caKey, err := cert.NewCA("foo", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	caCert2, caKey2, err := cert.NewCA("bar", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	caCert3, _, err := cert.NewCA("baz", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+
+	var noHostnames []string
+	srvCert, _, err := cert.NewServer(caCert, caKey, now.Add(1*time.Minute), noHostnames)
+	c.Assert(err, gc.IsNil)
+	srvCert2, _, err := cert.NewServer(caCert2, caKey2, now.Add(1*time.Minute), noHostnames)
+	c.Assert(err, gc.IsNil)
+
+	bundle := caCert + caCert2
+	err = cert.Verify(srvCert, bundle, now)
+	c.Assert(err, gc.IsNil)
+	err = cert.Verify(srvCert2, bundle, now)
+	c.Assert(err, gc.IsNil)
+
+	err = cert.Verify(srvCert, caCert2+caCert3, now)
+	c.Check(err, gc.ErrorMatches, "x509: certificate signed by unknown authority")
+}
+
+func (certSuite) TestVerifyWithIntermediate(c *gc.C) {
+	now := time.Now()
+	rootCert, rootKey, err := cert.NewCA("root", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	interCert, interKey := newIntermediateCA(c, rootCert, rootKey, now.Add(1*time.Minute))
+	srvCert, _, err := cert.NewServer(interCert, interKey, now.Add(1*time.Minute), []string{"*"})
+	c.Assert(err, gc.IsNil)
+
+	err = cert.Verify(srvCert+interCert, rootCert, now)
+	c.Assert(err, gc.IsNil)
+
+	err = cert.Verify(srvCert, rootCert, now)
+	c.Check(err, gc.ErrorMatches, "x509: certificate signed by unknown authority.*")
+}
+
+func (certSuite) TestVerifyIgnoresHostnames(c *gc.C) {
+	now := time.Now()
+	caCert, caKey, err := cert.NewCA("foo", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	srvCert := newExternalServer(c, caCert, caKey, now.Add(1*time.Minute), "example.com")
+	err = cert.Verify(srvCert, caCert, now)
+	c.Assert(err, gc.IsNil)
+}
+
+func (certSuite) TestVerifyHost(c *gc.C) {
+	now := time.Now()
+	caCert, caKey, err := cert.NewCA("foo", now.Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	pool, err := cert.ParseCertPool(caCert)
+	c.Assert(err, gc.IsNil)
+	srvCert := newExternalServer(c, caCert, caKey, now.Add(1*time.Minute), "example.com")
+	xcert, err := cert.ParseCert(srvCert)
+	c.Assert(err, gc.IsNil)
+
+	err = cert.VerifyHost([]*x509.Certificate{xcert}, "example.com", pool, now)
+	c.Assert(err, gc.IsNil)
+	err = cert.VerifyHost([]*x509.Certificate{xcert}, "other.example.com", pool, now)
+	c.Assert(err, gc.ErrorMatches, "x509: certificate is valid for example.com, not other.example.com")
+	err = cert.VerifyHost(nil, "example.com", pool, now)
+	c.Assert(err, gc.ErrorMatches, "no server certificate")
+
+	// Certificates issued by juju are accepted for any host.
+	jujuCert, _, err := cert.NewServer(caCert, caKey, now.Add(1*time.Minute), nil)
+	c.Assert(err, gc.IsNil)
+	xcert, err = cert.ParseCert(jujuCert)
+	c.Assert(err, gc.IsNil)
+	err = cert.VerifyHost([]*x509.Certificate{xcert}, "0.1.2.3", pool, now)
+	c.Assert(err, gc.IsNil)
+}
+
+func (certSuite) TestSplitCerts(c *gc.C) {
+	caCert2, _, err := cert.NewCA("bar", time.Now().Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+
+	certs, err := cert.SplitCerts(caCertPEM + caKeyPEM + caCert2)
+	c.Assert(err, gc.IsNil)
+	c.Assert(certs, gc.HasLen, 2)
+	xcert, err := cert.ParseCert(certs[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(xcert.Subject.CommonName, gc.Equals, "juju testing")
+	c.Assert(certs[1], gc.Equals, caCert2)
+
+	certs, err = cert.SplitCerts(caKeyPEM)
+	c.Check(certs, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
+func (certSuite) TestParseCertPool(c *gc.C) {
+	caCert2, _, err := cert.NewCA("bar", time.Now().Add(1*time.Minute))
+	c.Assert(err, gc.IsNil)
+	xcert2, err := cert.ParseCert(caCert2)
+	c.Assert(err, gc.IsNil)
+
+	pool, err := cert.ParseCertPool(caCertPEM + caCert2)
+	c.Assert(err, gc.IsNil)
+	subjects := pool.Subjects()
+	c.Assert(subjects, gc.HasLen, 2)
+	c.Assert(subjects[1], gc.DeepEquals, xcert2.RawSubject)
+
+	_, err = cert.ParseCertPool(caKeyPEM)
+	c.Assert(err, gc.ErrorMatches, "no certificates found")
+}
+
+// newIntermediateCA returns a CA certificate and key signed by the
+// given CA.
+func newIntermediateCA(c *gc.C, caCertPEM, caKeyPEM string, expiry time.Time) (string, string) {
+	caCert, caKey, err := cert.ParseCertAndKey(caCertPEM, caKeyPEM)
+	c.Assert(err, gc.IsNil)
+	key, err := rsa.GenerateKey(rand.Reader, cert.KeyBits)
+	c.Assert(err, gc.IsNil)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		NotBefore:             time.Now().AddDate(0, 0, -7),
+		NotAfter:              expiry,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	c.Assert(err, gc.IsNil)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(certPEM), string(keyPEM)
+}
+
+// newExternalServer returns a server certificate signed by the given
+// CA that, unlike those issued by juju, is only valid for the given
+// host name.
+func newExternalServer(c *gc.C, caCertPEM, caKeyPEM string, expiry time.Time, hostname string) string {
+	caCert, caKey, err := cert.ParseCertAndKey(caCertPEM, caKeyPEM)
+	c.Assert(err, gc.IsNil)
+	key, err := rsa.GenerateKey(rand.Reader, cert.KeyBits)
+	c.Assert(err, gc.IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		NotBefore:    time.Now().AddDate(0, 0, -7),
+		NotAfter:     expiry,
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{hostname},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	c.Assert(err, gc.IsNil)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}))
+}
+
 // checkTLSConnection checks that we can correctly perform a TLS
 // handshake using the given credentials.
 func checkTLSConnection(c *gc.C, caCert, srvCert *x509.Certificate, srvKey *rsa.PrivateKey) (caName string) {
@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/resource"
+)
+
+// AttachCommand uploads files as new revisions of the resources
+// attached to a service.
+type AttachCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	Resources   []resourceFile
+}
+
+// resourceFile identifies a file to upload as a named resource.
+type resourceFile struct {
+	Name string
+	Path string
+}
+
+const attachDoc = `
+Uploads files as new revisions of resources declared by a service's
+charm. Each resource is given as name=path, where path is the local
+file to upload. Units of the service fetch resources from the state
+server with the resource-get hook tool, and run their upgrade-charm
+hook whenever a resource is attached.
+
+Examples:
+   juju attach myapp app=./app-1.2.tar.gz
+   juju attach myapp app=./app.tar.gz data=/srv/data.db
+`
+
+func (c *AttachCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "attach",
+		Args:    "<service> <name>=<path> [...]",
+		Purpose: "attach resources to a service",
+		Doc:     attachDoc,
+	}
+}
+
+func (c *AttachCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	c.ServiceName = args[0]
+	if len(args) == 1 {
+		return errors.New("no resources specified")
+	}
+	for _, arg := range args[1:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("expected name=path, got %q", arg)
+		}
+		if !resource.IsValidName(parts[0]) {
+			return fmt.Errorf("invalid resource name %q", parts[0])
+		}
+		c.Resources = append(c.Resources, resourceFile{parts[0], parts[1]})
+	}
+	return nil
+}
+
+// Run uploads each resource in turn, reporting its new revision.
+func (c *AttachCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	for _, res := range c.Resources {
+		f, err := os.Open(ctx.AbsPath(res.Path))
+		if err != nil {
+			return err
+		}
+		info, err := client.AttachResource(c.ServiceName, res.Name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		ctx.Infof("attached %s revision %d to service %q", info.Name, info.Revision, info.Service)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/testing"
+)
+
+type AttachSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&AttachSuite{})
+
+var attachMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+
+func runAttach(c *gc.C, args ...string) (string, error) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&AttachCommand{}), args...)
+	if err != nil {
+		return "", err
+	}
+	return testing.Stderr(ctx), nil
+}
+
+func (s *AttachSuite) TestInitErrors(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no service name specified",
+	}, {
+		args: []string{"app"},
+		err:  "no resources specified",
+	}, {
+		args: []string{"app", "app"},
+		err:  `expected name=path, got "app"`,
+	}, {
+		args: []string{"app", "app="},
+		err:  `expected name=path, got "app="`,
+	}, {
+		args: []string{"app", "Bad=foo"},
+		err:  `invalid resource name "Bad"`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(&AttachCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *AttachSuite) TestAttach(c *gc.C) {
+	service := s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", attachMeta, 1))
+	path := filepath.Join(c.MkDir(), "app.tar.gz")
+	err := ioutil.WriteFile(path, []byte("content"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	out, err := runAttach(c, "app", "app="+path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "attached app revision 1 to service \"app\"\n")
+
+	res, err := service.Resource("app")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision(), gc.Equals, 1)
+	c.Assert(res.Size(), gc.Equals, int64(7))
+}
+
+func (s *AttachSuite) TestAttachUndeclared(c *gc.C) {
+	s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", attachMeta, 1))
+	path := filepath.Join(c.MkDir(), "data")
+	err := ioutil.WriteFile(path, []byte("content"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	_, err = runAttach(c, "app", "data="+path)
+	c.Assert(err, gc.ErrorMatches, `error uploading resource: cannot set resource "data" for service "app": .* not found`)
+}
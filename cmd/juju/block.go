@@ -0,0 +1,195 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// blockTypes holds the kinds of environment block, from the least to
+// the most restrictive.
+var blockTypes = []string{"destroy-environment", "remove-object", "all-changes"}
+
+// BlockAPI holds the API calls made by the block commands.
+type BlockAPI interface {
+	SwitchBlockOn(blockType, msg string) error
+	SwitchBlockOff(blockType string) error
+	ListBlocks() ([]params.Block, error)
+	Close() error
+}
+
+var getBlockAPI = func(c *envcmd.EnvCommandBase) (BlockAPI, error) {
+	return c.NewAPIClient()
+}
+
+func checkBlockType(blockType string) error {
+	for _, t := range blockTypes {
+		if t == blockType {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid block type %q; valid types are %s", blockType, strings.Join(blockTypes, ", "))
+}
+
+// blockedOperationError adds advice on removing blocks to err if it
+// was returned because an operation was blocked.
+func blockedOperationError(err error) error {
+	if !params.IsCodeOperationBlocked(err) {
+		return err
+	}
+	return fmt.Errorf(`%v
+Run "juju list-blocks" to see the blocks in place, and "juju unblock"
+to switch them off.`, err)
+}
+
+const blockDoc = `
+Blocks guard the environment against operations that could cause
+damage, such as destroying it by mistake. While a block is switched on,
+the operations it covers fail with an error that includes the message
+given when the block was switched on.
+
+The block types are:
+
+    destroy-environment  prevents the environment from being destroyed
+    remove-object        also prevents machines, services, units and
+                         relations from being removed
+    all-changes          prevents all changes to the environment
+
+Blocks are switched off with "juju unblock" and listed with
+"juju list-blocks".
+
+Example:
+  juju block destroy-environment "this is the production environment"
+`
+
+// BlockCommand switches on an environment block.
+type BlockCommand struct {
+	envcmd.EnvCommandBase
+	Type    string
+	Message string
+}
+
+func (c *BlockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "block",
+		Args:    "destroy-environment|remove-object|all-changes [<message>]",
+		Purpose: "block operations that could damage the environment",
+		Doc:     blockDoc,
+	}
+}
+
+func (c *BlockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no block type specified")
+	}
+	if err := checkBlockType(args[0]); err != nil {
+		return err
+	}
+	c.Type = args[0]
+	c.Message = strings.Join(args[1:], " ")
+	return nil
+}
+
+func (c *BlockCommand) Run(_ *cmd.Context) error {
+	client, err := getBlockAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SwitchBlockOn(c.Type, c.Message)
+}
+
+const unblockDoc = `
+Switch off an environment block switched on with "juju block", so that
+the operations it covers are allowed again. See "juju help block".
+
+Example:
+  juju unblock destroy-environment
+`
+
+// UnblockCommand switches off an environment block.
+type UnblockCommand struct {
+	envcmd.EnvCommandBase
+	Type string
+}
+
+func (c *UnblockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "unblock",
+		Args:    "destroy-environment|remove-object|all-changes",
+		Purpose: "switch off an environment block",
+		Doc:     unblockDoc,
+	}
+}
+
+func (c *UnblockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no block type specified")
+	}
+	if err := checkBlockType(args[0]); err != nil {
+		return err
+	}
+	c.Type = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *UnblockCommand) Run(_ *cmd.Context) error {
+	client, err := getBlockAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SwitchBlockOff(c.Type)
+}
+
+const listBlocksDoc = `
+List the environment blocks that are switched on, with the messages
+given when they were switched on. See "juju help block".
+`
+
+// ListBlocksCommand lists the environment blocks that are switched on.
+type ListBlocksCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+func (c *ListBlocksCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list-blocks",
+		Purpose: "list environment blocks",
+		Doc:     listBlocksDoc,
+	}
+}
+
+func (c *ListBlocksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *ListBlocksCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ListBlocksCommand) Run(ctx *cmd.Context) error {
+	client, err := getBlockAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	blocks, err := client.ListBlocks()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]string)
+	for _, block := range blocks {
+		result[block.Type] = block.Message
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type BlockCommandSuite struct {
+	testing.FakeJujuHomeSuite
+	mockAPI *mockBlockAPI
+}
+
+var _ = gc.Suite(&BlockCommandSuite{})
+
+func (s *BlockCommandSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mockAPI = &mockBlockAPI{blocks: make(map[string]string)}
+	s.PatchValue(&getBlockAPI, func(c *envcmd.EnvCommandBase) (BlockAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *BlockCommandSuite) TestInitErrors(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&BlockCommand{}), nil)
+	c.Check(err, gc.ErrorMatches, "no block type specified")
+	err = testing.InitCommand(envcmd.Wrap(&BlockCommand{}), []string{"everything"})
+	c.Check(err, gc.ErrorMatches, `invalid block type "everything"; valid types are destroy-environment, remove-object, all-changes`)
+	err = testing.InitCommand(envcmd.Wrap(&UnblockCommand{}), nil)
+	c.Check(err, gc.ErrorMatches, "no block type specified")
+	err = testing.InitCommand(envcmd.Wrap(&UnblockCommand{}), []string{"all-changes", "now"})
+	c.Check(err, gc.ErrorMatches, `unrecognized args: \["now"\]`)
+	err = testing.InitCommand(envcmd.Wrap(&ListBlocksCommand{}), []string{"all"})
+	c.Check(err, gc.ErrorMatches, `unrecognized args: \["all"\]`)
+}
+
+func (s *BlockCommandSuite) TestBlockAndUnblock(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&BlockCommand{}), "destroy-environment", "this", "is", "production")
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, envcmd.Wrap(&BlockCommand{}), "all-changes")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mockAPI.blocks, jc.DeepEquals, map[string]string{
+		"destroy-environment": "this is production",
+		"all-changes":         "",
+	})
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&UnblockCommand{}), "all-changes")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mockAPI.blocks, jc.DeepEquals, map[string]string{
+		"destroy-environment": "this is production",
+	})
+}
+
+func (s *BlockCommandSuite) TestListBlocks(c *gc.C) {
+	s.mockAPI.blocks["destroy-environment"] = "this is production"
+	s.mockAPI.blocks["remove-object"] = ""
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ListBlocksCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"destroy-environment: this is production\n"+
+		"remove-object: \"\"\n")
+}
+
+func (s *BlockCommandSuite) TestBlockedOperationError(c *gc.C) {
+	err := &params.Error{
+		Code:    params.CodeOperationBlocked,
+		Message: "the operation has been blocked: production",
+	}
+	c.Assert(blockedOperationError(err), gc.ErrorMatches, `the operation has been blocked: production
+Run "juju list-blocks" to see the blocks in place, and "juju unblock"
+to switch them off.`)
+	other := &params.Error{Message: "boom"}
+	c.Assert(blockedOperationError(other), gc.Equals, other)
+	c.Assert(blockedOperationError(nil), gc.IsNil)
+}
+
+type mockBlockAPI struct {
+	blocks map[string]string
+}
+
+func (m *mockBlockAPI) SwitchBlockOn(blockType, msg string) error {
+	m.blocks[blockType] = msg
+	return nil
+}
+
+func (m *mockBlockAPI) SwitchBlockOff(blockType string) error {
+	delete(m.blocks, blockType)
+	return nil
+}
+
+func (m *mockBlockAPI) ListBlocks() ([]params.Block, error) {
+	var blocks []params.Block
+	for _, t := range blockTypes {
+		if msg, ok := m.blocks[t]; ok {
+			blocks = append(blocks, params.Block{Type: t, Message: msg})
+		}
+	}
+	return blocks, nil
+}
+
+func (m *mockBlockAPI) Close() error {
+	return nil
+}
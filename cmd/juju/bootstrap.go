@@ -293,6 +293,12 @@ func (c *BootstrapCommand) SetBootstrapEndpointAddress(environ environs.Environ)
 		apiAddrs[i] = hp.NetAddr()
 	}
 	endpoint.Addresses = apiAddrs
+	// Remember the CA certificates clients verify the state
+	// server certificate with, which may have been supplied
+	// rather than generated.
+	if caCert, ok := cfg.CACert(); ok {
+		endpoint.CACert = caCert
+	}
 	writer, err := c.ConnectionWriter()
 	if err != nil {
 		return errors.Annotate(err, "failed to get connection writer")
@@ -158,7 +158,7 @@ func (test bootstrapTest) run(c *gc.C) {
 	// Check a CA cert/key was generated by reloading the environment.
 	env, err = environs.NewFromName("peckham", store)
 	c.Assert(err, gc.IsNil)
-	_, hasCert := env.Config().CACert()
+	caCert, hasCert := env.Config().CACert()
 	c.Check(hasCert, gc.Equals, true)
 	_, hasKey := env.Config().CAPrivateKey()
 	c.Check(hasKey, gc.Equals, true)
@@ -166,6 +166,7 @@ func (test bootstrapTest) run(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(info, gc.NotNil)
 	c.Assert(info.APIEndpoint().Addresses, gc.DeepEquals, []string{"localhost:17070"})
+	c.Assert(info.APIEndpoint().CACert, gc.Equals, caCert)
 }
 
 var bootstrapTests = []bootstrapTest{{
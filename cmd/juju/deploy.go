@@ -30,6 +30,8 @@ type DeployCommand struct {
 	Config       cmd.FileVar
 	Constraints  constraints.Value
 	Networks     string
+	Bindings     map[string]string
+	BindSpec     string
 	BumpRevision bool   // Remove this once the 1.16 support is dropped.
 	RepoPath     string // defaults to JUJU_REPOSITORY
 }
@@ -102,6 +104,16 @@ to configure on it. The --networks argument instructs juju to add all the
 networks specified with it to all new machines deployed to host units of
 the service. Not supported on all providers.
 
+The charm's endpoints can be bound to network spaces (see "juju help
+space") with the --bind argument, which takes a space-separated list of
+endpoint=space pairs. A space name on its own binds all endpoints not
+otherwise listed. The private address a unit reports for a relation is
+then an address in the space its endpoint is bound to:
+
+   juju deploy mysql --bind "db=dmz internal"
+   (bind mysql's "db" endpoint to the "dmz" space and all its other
+    endpoints to the "internal" space)
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -125,6 +137,7 @@ func (c *DeployCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(&c.Config, "config", "path to yaml-formatted service config")
 	f.Var(constraints.ConstraintsValue{Target: &c.Constraints}, "constraints", "set service constraints")
 	f.StringVar(&c.Networks, "networks", "", "bind the service to specific networks")
+	f.StringVar(&c.BindSpec, "bind", "", "bind charm endpoints to network spaces")
 	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepositoryEnvKey), "local charm repository")
 }
 
@@ -146,6 +159,11 @@ func (c *DeployCommand) Init(args []string) error {
 	default:
 		return cmd.CheckEmpty(args[2:])
 	}
+	bindings, err := parseBindings(c.BindSpec)
+	if err != nil {
+		return err
+	}
+	c.Bindings = bindings
 	return c.UnitCommandBase.Init(args)
 }
 
@@ -229,6 +247,22 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 			return err
 		}
 	}
+	if len(c.Bindings) > 0 {
+		err = client.ServiceDeployWithBindings(
+			curl.String(),
+			serviceName,
+			numUnits,
+			string(configYAML),
+			c.Constraints,
+			c.ToMachineSpec,
+			requestedNetworks,
+			c.Bindings,
+		)
+		if params.IsCodeNotImplemented(err) {
+			return errors.New("cannot use --bind: not supported by the API server")
+		}
+		return err
+	}
 	err = client.ServiceDeployWithNetworks(
 		curl.String(),
 		serviceName,
@@ -313,3 +347,31 @@ func networkNamesToTags(networks []string) ([]string, error) {
 	}
 	return tags, nil
 }
+
+// parseBindings returns the endpoint bindings given by the value of
+// the --bind argument: a space-separated list of endpoint=space pairs,
+// with a lone space name binding all endpoints not otherwise listed.
+// The default binding is keyed by the empty endpoint name.
+func parseBindings(bindValue string) (map[string]string, error) {
+	bindings := make(map[string]string)
+	for _, part := range strings.Fields(bindValue) {
+		endpoint, space := "", part
+		if i := strings.Index(part, "="); i >= 0 {
+			endpoint, space = part[:i], part[i+1:]
+			if endpoint == "" {
+				return nil, fmt.Errorf("invalid --bind value %q: missing endpoint name", part)
+			}
+		}
+		if space == "" {
+			return nil, fmt.Errorf("invalid --bind value %q: missing space name", part)
+		}
+		if _, ok := bindings[endpoint]; ok {
+			if endpoint == "" {
+				return nil, fmt.Errorf("invalid --bind value: more than one default space")
+			}
+			return nil, fmt.Errorf("invalid --bind value: endpoint %q bound more than once", endpoint)
+		}
+		bindings[endpoint] = space
+	}
+	return bindings, nil
+}
@@ -60,6 +60,18 @@ var initErrorTests = []struct {
 	}, {
 		args: []string{"craziness", "burble1", "--constraints", "gibber=plop"},
 		err:  `invalid value "gibber=plop" for flag --constraints: unknown constraint "gibber"`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "=dmz"},
+		err:  `invalid --bind value "=dmz": missing endpoint name`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "db="},
+		err:  `invalid --bind value "db=": missing space name`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "dmz internal"},
+		err:  `invalid --bind value: more than one default space`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "db=dmz db=internal"},
+		err:  `invalid --bind value: endpoint "db" bound more than once`,
 	},
 }
 
@@ -185,6 +197,28 @@ func (s *DeploySuite) TestNetworks(c *gc.C) {
 	c.Assert(cons, jc.DeepEquals, constraints.MustParse("mem=2G cpu-cores=2 networks=net1,net0,^net3,^net4"))
 }
 
+func (s *DeploySuite) TestBindings(c *gc.C) {
+	_, err := s.State.AddSpace("dmz", nil)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddSpace("internal", nil)
+	c.Assert(err, gc.IsNil)
+	charmtesting.Charms.CharmArchivePath(s.SeriesPath, "wordpress")
+	err = runDeploy(c, "local:wordpress", "--bind", "db=dmz internal")
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:precise/wordpress-3")
+	service, _ := s.AssertService(c, "wordpress", curl, 1, 0)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, map[string]string{
+		"db": "dmz",
+		"":   "internal",
+	})
+}
+
+func (s *DeploySuite) TestBindingsUnknownSpace(c *gc.C) {
+	charmtesting.Charms.CharmArchivePath(s.SeriesPath, "wordpress")
+	err := runDeploy(c, "local:wordpress", "--bind", "db=dmz")
+	c.Assert(err, gc.ErrorMatches, `.*space "dmz" not found`)
+}
+
 func (s *DeploySuite) TestSubordinateConstraints(c *gc.C) {
 	charmtesting.Charms.CharmArchivePath(s.SeriesPath, "logging")
 	err := runDeploy(c, "local:logging", "--constraints", "mem=1G")
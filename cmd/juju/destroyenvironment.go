@@ -102,8 +102,10 @@ func (c *DestroyEnvironmentCommand) Run(ctx *cmd.Context) (result error) {
 	// This is necessary to destroy broken environments, where the
 	// API server is inaccessible or faulty.
 	if !c.force {
+		blocked := false
 		defer func() {
-			if result == nil {
+			if result == nil || blocked {
+				// Don't suggest --force to get around a block.
 				return
 			}
 			logger.Errorf(`failed to destroy environment %q
@@ -124,6 +126,10 @@ to be cleaned up.
 		}
 		defer apiclient.Close()
 		err = apiclient.DestroyEnvironment()
+		if params.IsCodeOperationBlocked(err) {
+			blocked = true
+			return blockedOperationError(err)
+		}
 		if err != nil && !params.IsCodeNotImplemented(err) {
 			return fmt.Errorf("destroying environment: %v", err)
 		}
@@ -16,6 +16,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
 )
 
@@ -44,6 +45,23 @@ func (s *destroyEnvSuite) TestDestroyEnvironmentCommand(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
+func (s *destroyEnvSuite) TestDestroyEnvironmentCommandBlocked(c *gc.C) {
+	// Prepare the environment so we can destroy it.
+	_, err := environs.PrepareFromName("dummyenv", nullContext(c), s.ConfigStore)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.DestroyBlock, "this is production")
+	c.Assert(err, gc.IsNil)
+
+	_, errc := runCommand(nullContext(c), new(DestroyEnvironmentCommand), "dummyenv", "--yes")
+	c.Check(<-errc, gc.ErrorMatches, `the operation has been blocked: this is production
+Run "juju list-blocks" to see the blocks in place, and "juju unblock"
+to switch them off.`)
+
+	// The environment information is kept.
+	_, err = s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *destroyEnvSuite) TestDestroyEnvironmentCommandEFlag(c *gc.C) {
 	// Prepare the environment so we can destroy it.
 	_, err := environs.PrepareFromName("dummyenv", nullContext(c), s.ConfigStore)
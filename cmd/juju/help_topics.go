@@ -333,6 +333,21 @@ networks
    network. Positive network constraints do not imply the networks will be enabled,
    use the --networks argument for that, just that they could be enabled.
 
+zones
+   Zones defines the list of availability zones that the machine may be started
+   in. Multiple zones must be delimited by a comma; machines are spread across
+   the listed zones, and an error is reported for any zone that the environment
+   does not know or that is unavailable. Zones are currently only supported by
+   the Amazon EC2, OpenStack, Azure (where the location is the only zone) and
+   dummy environments. A machine may also be placed in a single zone with
+   "juju add-machine zone=<name>", which must satisfy any zones constraint.
+
+spaces
+   Spaces defines the list of network spaces (see "juju help space") the machine
+   must be connected to. Multiple spaces must be delimited by a comma. Where the
+   subnets of the listed spaces have known availability zones, and no zones
+   constraint is given, the machine is started in one of those zones.
+
 Example:
 
    juju add-machine --constraints "arch=amd64 mem=8G tags=foo,bar"
@@ -10,6 +10,7 @@ import (
 	"gopkg.in/juju/charm.v3"
 	"launchpad.net/gnuflag"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/worker/uniter/jujuc"
 )
 
@@ -26,12 +27,15 @@ func (dummyHookContext) PublicAddress() (string, bool) {
 func (dummyHookContext) PrivateAddress() (string, bool) {
 	return "", false
 }
-func (dummyHookContext) OpenPort(protocol string, port int) error {
+func (dummyHookContext) OpenPorts(protocol string, fromPort, toPort int) error {
 	return nil
 }
-func (dummyHookContext) ClosePort(protocol string, port int) error {
+func (dummyHookContext) ClosePorts(protocol string, fromPort, toPort int) error {
 	return nil
 }
+func (dummyHookContext) OpenedPorts() ([]network.PortRange, error) {
+	return nil, nil
+}
 func (dummyHookContext) ConfigSettings() (charm.Settings, error) {
 	return charm.NewConfig().DefaultSettings(), nil
 }
@@ -56,6 +60,10 @@ func (dummyHookContext) OwnerTag() string {
 	return ""
 }
 
+func (dummyHookContext) ResourcePath(name string) (string, error) {
+	return "", fmt.Errorf("resource %q not found", name)
+}
+
 type HelpToolCommand struct {
 	cmd.CommandBase
 	tool string
@@ -0,0 +1,234 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// HighAvailabilityAPI holds the API calls made by the show-ha and
+// remove-state-server commands.
+type HighAvailabilityAPI interface {
+	HighAvailabilityStatus() (params.HighAvailabilityStatus, error)
+	RemoveStateServer(machines ...string) ([]params.ErrorResult, error)
+	Close() error
+}
+
+var getHighAvailabilityAPI = func(c *envcmd.EnvCommandBase) (HighAvailabilityAPI, error) {
+	return c.NewAPIClient()
+}
+
+// ShowHACommand reports on the health of the state servers'
+// replica set.
+type ShowHACommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+const showHADoc = `
+Show the state server machines and the health of their members of
+the mongo replica set: the state of each member, whether it takes part
+in voting, how far it lags behind the primary and when it was last
+heard from.
+
+The vote column is one of:
+ yes        the member votes
+ no         the member does not vote
+ pending    the member will vote once it is ready
+ demoting   the member's vote is being removed
+ removing   the state server is being removed by remove-state-server
+`
+
+func (c *ShowHACommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "show-ha",
+		Purpose: "show the health of Juju state servers",
+		Doc:     showHADoc,
+	}
+}
+
+func (c *ShowHACommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatHATabular,
+	})
+}
+
+func (c *ShowHACommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+type haMemberInfo struct {
+	Machine       string `json:"machine" yaml:"machine"`
+	Address       string `json:"address,omitempty" yaml:"address,omitempty"`
+	State         string `json:"state,omitempty" yaml:"state,omitempty"`
+	Healthy       bool   `json:"healthy" yaml:"healthy"`
+	Vote          string `json:"vote" yaml:"vote"`
+	Lag           string `json:"lag,omitempty" yaml:"lag,omitempty"`
+	LastHeartbeat string `json:"last-heartbeat,omitempty" yaml:"last-heartbeat,omitempty"`
+	Message       string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func (c *ShowHACommand) Run(ctx *cmd.Context) error {
+	client, err := getHighAvailabilityAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	status, err := client.HighAvailabilityStatus()
+	if err != nil {
+		return err
+	}
+	result := make([]haMemberInfo, len(status.Members))
+	for i, m := range status.Members {
+		info := haMemberInfo{
+			Machine: m.MachineId,
+			Address: m.Address,
+			State:   m.State,
+			Healthy: m.Healthy,
+			Vote:    describeVote(m),
+			Message: m.Message,
+		}
+		if m.State != "" {
+			info.Lag = fmt.Sprintf("%gs", m.OpTimeLag.Seconds())
+		}
+		if !m.LastHeartbeat.IsZero() {
+			info.LastHeartbeat = m.LastHeartbeat.UTC().Format(time.RFC3339)
+		}
+		result[i] = info
+	}
+	return c.out.Write(ctx, result)
+}
+
+// describeVote describes the voting status of a state server.
+func describeVote(m params.StateServerMemberStatus) string {
+	switch {
+	case m.Removing:
+		return "removing"
+	case m.WantsVote && m.HasVote:
+		return "yes"
+	case m.WantsVote:
+		return "pending"
+	case m.HasVote:
+		return "demoting"
+	}
+	return "no"
+}
+
+// formatHATabular returns a table describing the state servers.
+func formatHATabular(value interface{}) ([]byte, error) {
+	members, ok := value.([]haMemberInfo)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type for show-ha call")
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "MACHINE\tADDRESS\tSTATE\tHEALTHY\tVOTE\tLAG\tLAST HEARTBEAT\tMESSAGE")
+	for _, m := range members {
+		state := m.State
+		if state == "" {
+			state = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\t%s\t%s\t%s\t%s\n",
+			m.Machine, m.Address, state, m.Healthy, m.Vote, m.Lag, m.LastHeartbeat, m.Message)
+	}
+	tw.Flush()
+	return buf.Bytes(), nil
+}
+
+// RemoveStateServerCommand removes the state server running on a
+// machine without destroying the machine.
+type RemoveStateServerCommand struct {
+	envcmd.EnvCommandBase
+	MachineId string
+	assumeYes bool
+}
+
+const removeStateServerDoc = `
+Remove the state server running on the given machine, leaving the
+machine itself, and any units it hosts, in place. The state server is
+first demoted, so that it no longer votes in the mongo replica set, and
+is then removed from the replica set; use show-ha to follow its
+progress. The last voting state server cannot be removed.
+
+Removing a state server may leave an even number of voting state
+servers; use ensure-availability to restore the desired number.
+`
+
+const removeStateServerMsg = `
+WARNING! this command will remove the state server running on machine %s.
+The machine will be left running, but will no longer manage the environment.
+
+Continue [y/N]? `
+
+func (c *RemoveStateServerCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "remove-state-server",
+		Args:    "<machine>",
+		Purpose: "remove a state server without destroying its machine",
+		Doc:     removeStateServerDoc,
+	}
+}
+
+func (c *RemoveStateServerCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.assumeYes, "y", false, "Do not ask for confirmation")
+	f.BoolVar(&c.assumeYes, "yes", false, "")
+}
+
+func (c *RemoveStateServerCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no machine specified")
+	}
+	c.MachineId, args = args[0], args[1:]
+	if !names.IsValidMachine(c.MachineId) {
+		return fmt.Errorf("invalid machine id %q", c.MachineId)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *RemoveStateServerCommand) Run(ctx *cmd.Context) error {
+	if !c.assumeYes {
+		fmt.Fprintf(ctx.Stdout, removeStateServerMsg, c.MachineId)
+		scanner := bufio.NewScanner(ctx.Stdin)
+		scanner.Scan()
+		if err := scanner.Err(); err != nil && err != io.EOF {
+			return fmt.Errorf("state server removal aborted: %v", err)
+		}
+		answer := strings.ToLower(scanner.Text())
+		if answer != "y" && answer != "yes" {
+			return errors.New("state server removal aborted")
+		}
+	}
+	client, err := getHighAvailabilityAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	results, err := client.RemoveStateServer(names.NewMachineTag(c.MachineId).String())
+	if err != nil {
+		return err
+	}
+	if len(results) != 1 {
+		return fmt.Errorf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Error != nil {
+		return results[0].Error
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type HighAvailabilitySuite struct {
+	testing.FakeJujuHomeSuite
+	api *fakeHighAvailabilityAPI
+}
+
+var _ = gc.Suite(&HighAvailabilitySuite{})
+
+type fakeHighAvailabilityAPI struct {
+	status  params.HighAvailabilityStatus
+	removed []string
+	err     *params.Error
+}
+
+func (f *fakeHighAvailabilityAPI) HighAvailabilityStatus() (params.HighAvailabilityStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeHighAvailabilityAPI) RemoveStateServer(machines ...string) ([]params.ErrorResult, error) {
+	f.removed = append(f.removed, machines...)
+	return []params.ErrorResult{{f.err}}, nil
+}
+
+func (*fakeHighAvailabilityAPI) Close() error {
+	return nil
+}
+
+func (s *HighAvailabilitySuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &fakeHighAvailabilityAPI{}
+	s.PatchValue(&getHighAvailabilityAPI, func(*envcmd.EnvCommandBase) (HighAvailabilityAPI, error) {
+		return s.api, nil
+	})
+}
+
+func (s *HighAvailabilitySuite) TestShowHA(c *gc.C) {
+	s.api.status = params.HighAvailabilityStatus{
+		Members: []params.StateServerMemberStatus{{
+			MachineId: "0",
+			WantsVote: true,
+			HasVote:   true,
+			Address:   "10.0.0.1:37017",
+			State:     "PRIMARY",
+			Healthy:   true,
+		}, {
+			MachineId:     "1",
+			WantsVote:     true,
+			HasVote:       true,
+			Address:       "10.0.0.2:37017",
+			State:         "RECOVERING",
+			Healthy:       true,
+			Message:       "still syncing",
+			OpTimeLag:     5 * time.Second,
+			LastHeartbeat: time.Date(2014, 10, 1, 12, 30, 5, 0, time.UTC),
+		}, {
+			MachineId: "2",
+			Removing:  true,
+		}, {
+			MachineId: "3",
+			WantsVote: true,
+		}},
+	}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ShowHACommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"MACHINE  ADDRESS         STATE       HEALTHY  VOTE      LAG  LAST HEARTBEAT        MESSAGE\n"+
+		"0        10.0.0.1:37017  PRIMARY     true     yes       0s                         \n"+
+		"1        10.0.0.2:37017  RECOVERING  true     yes       5s   2014-10-01T12:30:05Z  still syncing\n"+
+		"2                        -           false    removing                             \n"+
+		"3                        -           false    pending                              \n",
+	)
+}
+
+func (s *HighAvailabilitySuite) TestShowHAYaml(c *gc.C) {
+	s.api.status = params.HighAvailabilityStatus{
+		Members: []params.StateServerMemberStatus{{
+			MachineId: "0",
+			WantsVote: true,
+			HasVote:   true,
+			Address:   "10.0.0.1:37017",
+			State:     "PRIMARY",
+			Healthy:   true,
+		}},
+	}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ShowHACommand{}), "--format", "yaml")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"- machine: \"0\"\n"+
+		"  address: 10.0.0.1:37017\n"+
+		"  state: PRIMARY\n"+
+		"  healthy: true\n"+
+		"  vote: \"yes\"\n"+
+		"  lag: 0s\n",
+	)
+}
+
+func (s *HighAvailabilitySuite) TestRemoveStateServerInit(c *gc.C) {
+	for i, test := range []struct {
+		args     []string
+		errMatch string
+	}{{
+		errMatch: "no machine specified",
+	}, {
+		args:     []string{"foo"},
+		errMatch: `invalid machine id "foo"`,
+	}, {
+		args:     []string{"1", "2"},
+		errMatch: `unrecognized args: \["2"\]`,
+	}, {
+		args: []string{"1"},
+	}} {
+		c.Logf("test %d: %v", i, test.args)
+		err := testing.InitCommand(&RemoveStateServerCommand{}, test.args)
+		if test.errMatch == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.errMatch)
+		}
+	}
+}
+
+func (s *HighAvailabilitySuite) TestRemoveStateServer(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RemoveStateServerCommand{}), "1", "-y")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.removed, gc.DeepEquals, []string{"machine-1"})
+}
+
+func (s *HighAvailabilitySuite) TestRemoveStateServerError(c *gc.C) {
+	s.api.err = &params.Error{Message: "cannot remove state server 0: cannot remove the last voting state server"}
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RemoveStateServerCommand{}), "0", "-y")
+	c.Assert(err, gc.ErrorMatches, "cannot remove state server 0: cannot remove the last voting state server")
+}
+
+func (s *HighAvailabilitySuite) TestRemoveStateServerConfirmation(c *gc.C) {
+	for _, answer := range []string{"", "n", "y", "YES"} {
+		c.Logf("answer %q", answer)
+		s.api.removed = nil
+		command := envcmd.Wrap(&RemoveStateServerCommand{})
+		err := testing.InitCommand(command, []string{"1"})
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		ctx.Stdin = strings.NewReader(answer)
+		err = command.Run(ctx)
+		c.Check(testing.Stdout(ctx), gc.Matches, "\nWARNING! this command will remove the state server running on machine 1\\.(.|\n)*")
+		if answer == "y" || answer == "YES" {
+			c.Check(err, gc.IsNil)
+			c.Check(s.api.removed, gc.DeepEquals, []string{"machine-1"})
+		} else {
+			c.Check(err, gc.ErrorMatches, "state server removal aborted")
+			c.Check(s.api.removed, gc.HasLen, 0)
+		}
+	}
+}
@@ -34,6 +34,11 @@ var x = []byte("\x96\x8c\x99\x8a\x9c\x94\x96\x91\x98\xdf\x9e\x92\x9e\x85\x96\x91
 // to the cmd package. This function is not redundant with main, because it
 // provides an entry point for testing with arbitrary command line arguments.
 func Main(args []string) {
+	// Hook tools run by "juju replay-hook" are links to this
+	// executable.
+	if code, ok := hookToolMain(args); ok {
+		os.Exit(code)
+	}
 	ctx, err := cmd.DefaultContext()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -93,6 +98,8 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&DeployCommand{}))
 	r.Register(wrapEnvCommand(&AddRelationCommand{}))
 	r.Register(wrapEnvCommand(&AddUnitCommand{}))
+	r.Register(wrapEnvCommand(&SetScalingPolicyCommand{}))
+	r.Register(wrapEnvCommand(&ShowScalingCommand{}))
 
 	// Destruction commands.
 	r.Register(wrapEnvCommand(&RemoveMachineCommand{}))
@@ -108,11 +115,14 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 
 	// Error resolution and debugging commands.
 	r.Register(wrapEnvCommand(&RunCommand{}))
+	r.Register(wrapEnvCommand(&RunStatusCommand{}))
 	r.Register(wrapEnvCommand(&SCPCommand{}))
 	r.Register(wrapEnvCommand(&SSHCommand{}))
 	r.Register(wrapEnvCommand(&ResolvedCommand{}))
 	r.Register(wrapEnvCommand(&DebugLogCommand{}))
 	r.Register(wrapEnvCommand(&DebugHooksCommand{}))
+	r.Register(&ReplayHookCommand{})
+	r.Register(&SimulateHooksCommand{})
 	r.Register(wrapEnvCommand(&RetryProvisioningCommand{}))
 
 	// Configuration commands.
@@ -130,6 +140,9 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&UnexposeCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeJujuCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeCharmCommand{}))
+	r.Register(wrapEnvCommand(&SetUpgradePolicyCommand{}))
+	r.Register(wrapEnvCommand(&AutoUpgradesCommand{}))
+	r.Register(wrapEnvCommand(&AttachCommand{}))
 
 	// Charm publishing commands.
 	r.Register(wrapEnvCommand(&PublishCommand{}))
@@ -143,8 +156,24 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	// Manage users and access
 	r.Register(NewUserCommand())
 
+	// Manage subnets and network spaces.
+	r.Register(NewSubnetCommand())
+	r.Register(NewSpaceCommand())
+
+	// Manage environment blocks.
+	r.Register(wrapEnvCommand(&BlockCommand{}))
+	r.Register(wrapEnvCommand(&UnblockCommand{}))
+	r.Register(wrapEnvCommand(&ListBlocksCommand{}))
+
 	// Manage state server availability.
 	r.Register(wrapEnvCommand(&EnsureAvailabilityCommand{}))
+	r.Register(wrapEnvCommand(&ShowHACommand{}))
+	r.Register(wrapEnvCommand(&RemoveStateServerCommand{}))
+	r.Register(wrapEnvCommand(&RotateCACommand{}))
+	r.Register(wrapEnvCommand(&SetServerCertCommand{}))
+
+	// Manage environment storage.
+	r.Register(wrapEnvCommand(&StorageGCCommand{}))
 }
 
 // envCmdWrapper is a struct that wraps an environment command and lets us handle
@@ -210,8 +210,11 @@ var commandNames = []string{
 	"add-relation",
 	"add-unit",
 	"api-endpoints",
+	"attach",
 	"authorised-keys", // alias for authorized-keys
 	"authorized-keys",
+	"auto-upgrades",
+	"block",
 	"bootstrap",
 	"debug-hooks",
 	"debug-log",
@@ -232,25 +235,40 @@ var commandNames = []string{
 	"help",
 	"help-tool",
 	"init",
+	"list-blocks",
 	"publish",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
 	"remove-service",  // alias for destroy-service
-	"remove-unit",     // alias for destroy-unit
+	"remove-state-server",
+	"remove-unit", // alias for destroy-unit
+	"replay-hook",
 	"resolved",
 	"retry-provisioning",
+	"rotate-ca",
 	"run",
+	"run-status",
 	"scp",
 	"set",
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"set-scaling-policy",
+	"set-server-cert",
+	"set-upgrade-policy",
+	"show-ha",
+	"show-scaling",
+	"simulate-hooks",
+	"space",
 	"ssh",
 	"stat", // alias for status
 	"status",
+	"storage-gc",
+	"subnet",
 	"switch",
 	"sync-tools",
 	"terminate-machine", // alias for destroy-machine
+	"unblock",
 	"unexpose",
 	"unset",
 	"unset-env", // alias for unset-environment
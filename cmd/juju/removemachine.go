@@ -68,7 +68,7 @@ func (c *RemoveMachineCommand) Run(_ *cmd.Context) error {
 	}
 	defer apiclient.Close()
 	if c.Force {
-		return apiclient.ForceDestroyMachines(c.MachineIds...)
+		return blockedOperationError(apiclient.ForceDestroyMachines(c.MachineIds...))
 	}
-	return apiclient.DestroyMachines(c.MachineIds...)
+	return blockedOperationError(apiclient.DestroyMachines(c.MachineIds...))
 }
@@ -40,5 +40,5 @@ func (c *RemoveRelationCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
-	return client.DestroyRelation(c.Endpoints...)
+	return blockedOperationError(client.DestroyRelation(c.Endpoints...))
 }
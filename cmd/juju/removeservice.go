@@ -45,5 +45,5 @@ func (c *RemoveServiceCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
-	return client.ServiceDestroy(c.ServiceName)
+	return blockedOperationError(client.ServiceDestroy(c.ServiceName))
 }
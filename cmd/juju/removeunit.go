@@ -48,5 +48,5 @@ func (c *RemoveUnitCommand) Run(_ *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
-	return client.DestroyServiceUnits(c.UnitNames...)
+	return blockedOperationError(client.DestroyServiceUnits(c.UnitNames...))
 }
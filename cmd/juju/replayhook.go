@@ -0,0 +1,168 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/worker/uniter/jujuc"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+// ReplayHookCommand replays a hook recorded by a unit agent.
+type ReplayHookCommand struct {
+	cmd.CommandBase
+	out      cmd.Output
+	archive  string
+	charmDir string
+	show     bool
+}
+
+const replayHookDoc = `
+Replay a hook recorded by a unit, locally and outside the environment.
+
+When the "record-hooks" environment setting is true, units record the
+execution context of every hook they run: the environment variables,
+service configuration, relation settings, action parameters, and the
+hook tool calls the hook made. Each recording is saved, together with
+the charm, in an archive in the unit's "recordings" directory, e.g.
+
+  /var/lib/juju/agents/unit-wordpress-0/recordings
+
+and can be copied from there with "juju scp".
+
+replay-hook runs the recorded hook again with the recorded environment,
+against a fake hook tool server that serves the recorded context, so
+that hook failures can be reproduced without access to the environment.
+Changes made by hook tools, such as relation-set, only affect the
+replay. By default the hook is run from the recorded copy of the charm;
+use --charm-dir to run it from a local copy under development instead.
+
+With --show, the recording is shown instead of being replayed.
+
+Example:
+
+  juju set-env record-hooks=true
+  juju scp wordpress/0:/var/lib/juju/agents/unit-wordpress-0/recordings/20141001-120000-install.tar.gz .
+  juju replay-hook 20141001-120000-install.tar.gz
+`
+
+func (c *ReplayHookCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "replay-hook",
+		Args:    "<recording>",
+		Purpose: "replay a recorded hook locally",
+		Doc:     replayHookDoc,
+	}
+}
+
+func (c *ReplayHookCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+	f.StringVar(&c.charmDir, "charm-dir", "", "run the hook from this charm directory instead of the recorded charm")
+	f.BoolVar(&c.show, "show", false, "show the recording instead of replaying it")
+}
+
+func (c *ReplayHookCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no recording specified")
+	}
+	c.archive, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// jujuExecutable returns the path of the running juju executable, to
+// which the hook tools are linked when replaying a hook.
+var jujuExecutable = func() (string, error) {
+	path, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+func (c *ReplayHookCommand) Run(ctx *cmd.Context) error {
+	f, err := os.Open(ctx.AbsPath(c.archive))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tempDir, err := ioutil.TempDir("", "juju-replay-hook")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	charmDir := filepath.Join(tempDir, "charm")
+	rec, err := replay.ReadArchive(f, charmDir)
+	if err != nil {
+		return err
+	}
+	if c.show {
+		return c.out.Write(ctx, rec)
+	}
+	if c.charmDir != "" {
+		charmDir = ctx.AbsPath(c.charmDir)
+	}
+
+	// The hook tools are links to this executable, which forwards
+	// calls made through them to the replay's hook tool server.
+	toolsDir := filepath.Join(tempDir, "tools")
+	if err := os.Mkdir(toolsDir, 0755); err != nil {
+		return err
+	}
+	juju, err := jujuExecutable()
+	if err != nil {
+		return fmt.Errorf("cannot find juju executable: %v", err)
+	}
+	for _, name := range jujuc.CommandNames() {
+		if err := os.Symlink(juju, filepath.Join(toolsDir, name)); err != nil {
+			return err
+		}
+	}
+
+	ctx.Infof("replaying %q hook of %s recorded at %s", rec.HookName, rec.UnitName, rec.Time)
+	if rec.Error != "" {
+		ctx.Infof("recorded hook failed: %s", rec.Error)
+	}
+	_, err = replay.Replay(replay.Params{
+		Recording:  rec,
+		CharmDir:   charmDir,
+		ToolsDir:   toolsDir,
+		SocketPath: filepath.Join(tempDir, "agent.socket"),
+		Stdout:     ctx.Stdout,
+		Stderr:     ctx.Stderr,
+	})
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(interface {
+			ExitStatus() int
+		}); ok {
+			return cmd.NewRcPassthroughError(status.ExitStatus())
+		}
+	}
+	return err
+}
+
+// hookToolMain runs the hook tool named by args[0], if there is one,
+// by forwarding the call to the hook tool server of the replay that
+// ran the hook. It reports whether args[0] named a hook tool.
+func hookToolMain(args []string) (code int, isHookTool bool) {
+	commandName := filepath.Base(args[0])
+	for _, name := range jujuc.CommandNames() {
+		if name != commandName {
+			continue
+		}
+		code, err := jujuc.ClientMain(commandName, args[1:], os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return code, true
+	}
+	return 0, false
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+type ReplayHookSuite struct {
+	testing.FakeJujuHomeSuite
+	archive string
+}
+
+var _ = gc.Suite(&ReplayHookSuite{})
+
+func (s *ReplayHookSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	charmDir := c.MkDir()
+	err := os.Mkdir(filepath.Join(charmDir, "hooks"), 0755)
+	c.Assert(err, gc.IsNil)
+	hook := "#!/bin/bash\necho $JUJU_UNIT_NAME $JUJU_REMOTE_UNIT\nexit 3\n"
+	err = ioutil.WriteFile(filepath.Join(charmDir, "hooks", "db-relation-changed"), []byte(hook), 0755)
+	c.Assert(err, gc.IsNil)
+
+	rec := &replay.Recording{
+		HookName:       "db-relation-changed",
+		Location:       "hooks",
+		UnitName:       "wordpress/0",
+		Time:           time.Date(2014, 10, 1, 12, 0, 0, 0, time.UTC),
+		Env:            []string{"JUJU_UNIT_NAME=wordpress/0", "JUJU_REMOTE_UNIT=mysql/0"},
+		RelationId:     -1,
+		RemoteUnitName: "mysql/0",
+		Error:          "exit status 3",
+	}
+	s.archive = filepath.Join(c.MkDir(), "recording.tar.gz")
+	f, err := os.Create(s.archive)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	err = replay.WriteArchive(f, rec, charmDir)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ReplayHookSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(&ReplayHookCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no recording specified")
+	err = testing.InitCommand(&ReplayHookCommand{}, []string{"foo", "bar"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["bar"\]`)
+}
+
+func (s *ReplayHookSuite) TestShow(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &ReplayHookCommand{}, "--show", s.archive)
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Matches, `(?s).*hookname: db-relation-changed\n.*remoteunitname: mysql/0\n.*`)
+}
+
+func (s *ReplayHookSuite) TestReplay(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &ReplayHookCommand{}, s.archive)
+	c.Assert(err, jc.Satisfies, cmd.IsRcPassthroughError)
+	c.Assert(err, gc.ErrorMatches, "subprocess encountered error code 3")
+	c.Assert(testing.Stdout(ctx), gc.Equals, "wordpress/0 mysql/0\n")
+}
+
+func (s *ReplayHookSuite) TestReplayCharmDir(c *gc.C) {
+	charmDir := c.MkDir()
+	err := os.Mkdir(filepath.Join(charmDir, "hooks"), 0755)
+	c.Assert(err, gc.IsNil)
+	hook := "#!/bin/bash\necho fixed\n"
+	err = ioutil.WriteFile(filepath.Join(charmDir, "hooks", "db-relation-changed"), []byte(hook), 0755)
+	c.Assert(err, gc.IsNil)
+	ctx, err := testing.RunCommand(c, &ReplayHookCommand{}, "--charm-dir", charmDir, s.archive)
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "fixed\n")
+}
+
+func (s *ReplayHookSuite) TestReplayInvalidArchive(c *gc.C) {
+	archive := filepath.Join(c.MkDir(), "invalid.tar.gz")
+	err := ioutil.WriteFile(archive, []byte("foo"), 0644)
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &ReplayHookCommand{}, archive)
+	c.Assert(err, gc.ErrorMatches, "cannot read hook recording: .*")
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+)
+
+// RotateCAAPI holds the API calls made by the rotate-ca command.
+type RotateCAAPI interface {
+	RotateCA(finish bool) (string, error)
+	ImportCA(caCert, caKey string) (string, error)
+	Close() error
+}
+
+var getRotateCAAPI = func(c *envcmd.EnvCommandBase) (RotateCAAPI, error) {
+	return c.NewAPIClient()
+}
+
+var getCACertEndpoint = func(c *envcmd.EnvCommandBase) (configstore.APIEndpoint, error) {
+	return c.ConnectionEndpoint(false)
+}
+
+var getCACertWriter = func(c *envcmd.EnvCommandBase) (envcmd.ConnectionWriter, error) {
+	return c.ConnectionWriter()
+}
+
+// caCertRecorder records the CA certificates trusted by an
+// environment in its local environment information, so that clients
+// can verify the state servers' certificates.
+type caCertRecorder struct {
+	endpoint configstore.APIEndpoint
+	writer   envcmd.ConnectionWriter
+}
+
+// newCACertRecorder returns a caCertRecorder for the command's
+// environment. It must be called before the CA certificates change,
+// as finding the endpoint may require connecting to the environment.
+func newCACertRecorder(c *envcmd.EnvCommandBase) (*caCertRecorder, error) {
+	endpoint, err := getCACertEndpoint(c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	writer, err := getCACertWriter(c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &caCertRecorder{endpoint: endpoint, writer: writer}, nil
+}
+
+// record records the given CA certificates.
+func (r *caCertRecorder) record(caCert string) error {
+	r.endpoint.CACert = caCert
+	r.writer.SetAPIEndpoint(r.endpoint)
+	if err := r.writer.Write(); err != nil {
+		return errors.Annotatef(err, "cannot record CA certificate in %s", r.writer.Location())
+	}
+	return nil
+}
+
+// readPEMFile reads the PEM data in the named file.
+func readPEMFile(ctx *cmd.Context, path string) (string, error) {
+	data, err := ioutil.ReadFile(ctx.AbsPath(path))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RotateCACommand replaces the environment's CA.
+type RotateCACommand struct {
+	envcmd.EnvCommandBase
+	Finish     bool
+	CACertPath string
+	CAKeyPath  string
+}
+
+const rotateCADoc = `
+Replace the CA that signs the state server certificates with a newly
+generated one or, with --ca-cert and --ca-private-key, with the CA
+held in the given PEM files. Rotation happens in two steps, so that no
+agent or client loses the ability to connect.
+
+Without --finish, the new CA is trusted alongside the current one.
+Agents learn about it automatically; other clients of the environment
+should refresh their copy of the CA certificate before the rotation is
+finished.
+
+With --finish, the state servers start using certificates signed by the
+new CA. The old CA is no longer trusted once they all do.
+
+In both cases, the local environment information is updated with the
+CA certificates the environment trusts.
+`
+
+func (c *RotateCACommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "rotate-ca",
+		Purpose: "replace the environment's certificate authority",
+		Doc:     rotateCADoc,
+	}
+}
+
+func (c *RotateCACommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.Finish, "finish", false, "finish an ongoing CA rotation")
+	f.StringVar(&c.CACertPath, "ca-cert", "", "path to the certificate of the CA to rotate to")
+	f.StringVar(&c.CAKeyPath, "ca-private-key", "", "path to the private key of the CA to rotate to")
+}
+
+func (c *RotateCACommand) Init(args []string) error {
+	hasCA := c.CACertPath != "" || c.CAKeyPath != ""
+	if hasCA && c.Finish {
+		return fmt.Errorf("--ca-cert and --ca-private-key cannot be used with --finish")
+	}
+	if hasCA && (c.CACertPath == "" || c.CAKeyPath == "") {
+		return fmt.Errorf("--ca-cert and --ca-private-key must be specified together")
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *RotateCACommand) Run(ctx *cmd.Context) error {
+	var caCert, caKey string
+	if c.CACertPath != "" {
+		var err error
+		if caCert, err = readPEMFile(ctx, c.CACertPath); err != nil {
+			return err
+		}
+		if caKey, err = readPEMFile(ctx, c.CAKeyPath); err != nil {
+			return err
+		}
+	}
+	recorder, err := newCACertRecorder(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	client, err := getRotateCAAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	var trusted string
+	if caCert != "" {
+		trusted, err = client.ImportCA(caCert, caKey)
+	} else {
+		trusted, err = client.RotateCA(c.Finish)
+	}
+	if err != nil {
+		return err
+	}
+	if err := recorder.record(trusted); err != nil {
+		return err
+	}
+	if c.Finish {
+		fmt.Fprintln(ctx.Stdout, "CA rotation finishing; the old CA is trusted until all state servers use the new one")
+	} else {
+		fmt.Fprintln(ctx.Stdout, "CA rotation started; run juju rotate-ca --finish to complete it")
+	}
+	return nil
+}
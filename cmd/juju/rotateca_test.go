@@ -0,0 +1,142 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/testing"
+)
+
+type RotateCASuite struct {
+	testing.FakeJujuHomeSuite
+	api    *fakeRotateCAAPI
+	writer *fakeEndpointWriter
+}
+
+var _ = gc.Suite(&RotateCASuite{})
+
+type fakeRotateCAAPI struct {
+	finish   []bool
+	imported []string
+	caCert   string
+	err      error
+}
+
+func (f *fakeRotateCAAPI) RotateCA(finish bool) (string, error) {
+	f.finish = append(f.finish, finish)
+	return f.caCert, f.err
+}
+
+func (f *fakeRotateCAAPI) ImportCA(caCert, caKey string) (string, error) {
+	f.imported = append(f.imported, caCert, caKey)
+	return f.caCert, f.err
+}
+
+func (*fakeRotateCAAPI) Close() error {
+	return nil
+}
+
+type fakeEndpointWriter struct {
+	envcmd.ConnectionWriter
+	endpoint configstore.APIEndpoint
+	written  bool
+}
+
+func (w *fakeEndpointWriter) SetAPIEndpoint(endpoint configstore.APIEndpoint) {
+	w.endpoint = endpoint
+}
+
+func (w *fakeEndpointWriter) Write() error {
+	w.written = true
+	return nil
+}
+
+func (s *RotateCASuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &fakeRotateCAAPI{caCert: "old-ca\nnew-ca\n"}
+	s.writer = &fakeEndpointWriter{}
+	s.PatchValue(&getRotateCAAPI, func(*envcmd.EnvCommandBase) (RotateCAAPI, error) {
+		return s.api, nil
+	})
+	s.PatchValue(&getCACertEndpoint, func(*envcmd.EnvCommandBase) (configstore.APIEndpoint, error) {
+		return configstore.APIEndpoint{
+			Addresses: []string{"10.0.0.1:17070"},
+			CACert:    "old-ca\n",
+		}, nil
+	})
+	s.PatchValue(&getCACertWriter, func(*envcmd.EnvCommandBase) (envcmd.ConnectionWriter, error) {
+		return s.writer, nil
+	})
+}
+
+func (s *RotateCASuite) TestStart(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&RotateCACommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.finish, gc.DeepEquals, []bool{false})
+	c.Assert(s.writer.written, gc.Equals, true)
+	c.Assert(s.writer.endpoint, gc.DeepEquals, configstore.APIEndpoint{
+		Addresses: []string{"10.0.0.1:17070"},
+		CACert:    "old-ca\nnew-ca\n",
+	})
+	c.Assert(testing.Stdout(ctx), gc.Equals, "CA rotation started; run juju rotate-ca --finish to complete it\n")
+}
+
+func (s *RotateCASuite) TestFinish(c *gc.C) {
+	s.api.caCert = "new-ca\n"
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&RotateCACommand{}), "--finish")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.finish, gc.DeepEquals, []bool{true})
+	c.Assert(s.writer.endpoint.CACert, gc.Equals, "new-ca\n")
+	c.Assert(testing.Stdout(ctx), gc.Equals, "CA rotation finishing; the old CA is trusted until all state servers use the new one\n")
+}
+
+func (s *RotateCASuite) TestError(c *gc.C) {
+	s.api.err = errors.New("cannot finish CA rotation: no CA rotation in progress")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCACommand{}), "--finish")
+	c.Assert(err, gc.ErrorMatches, "cannot finish CA rotation: no CA rotation in progress")
+	c.Assert(s.writer.written, gc.Equals, false)
+}
+
+func (s *RotateCASuite) TestImport(c *gc.C) {
+	dir := c.MkDir()
+	caCertPath := filepath.Join(dir, "ca-cert.pem")
+	err := ioutil.WriteFile(caCertPath, []byte("new-ca\n"), 0600)
+	c.Assert(err, gc.IsNil)
+	caKeyPath := filepath.Join(dir, "ca-key.pem")
+	err = ioutil.WriteFile(caKeyPath, []byte("new-ca-key\n"), 0600)
+	c.Assert(err, gc.IsNil)
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&RotateCACommand{}), "--ca-cert", caCertPath, "--ca-private-key", caKeyPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.finish, gc.HasLen, 0)
+	c.Assert(s.api.imported, gc.DeepEquals, []string{"new-ca\n", "new-ca-key\n"})
+	c.Assert(s.writer.endpoint.CACert, gc.Equals, "old-ca\nnew-ca\n")
+}
+
+func (s *RotateCASuite) TestInitErrors(c *gc.C) {
+	for i, test := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"foo"},
+		err:  `unrecognized args: \["foo"\]`,
+	}, {
+		args: []string{"--ca-cert", "ca-cert.pem"},
+		err:  "--ca-cert and --ca-private-key must be specified together",
+	}, {
+		args: []string{"--finish", "--ca-cert", "ca-cert.pem", "--ca-private-key", "ca-key.pem"},
+		err:  "--ca-cert and --ca-private-key cannot be used with --finish",
+	}} {
+		c.Logf("test %d: %q", i, test.args)
+		_, err := testing.RunCommand(c, envcmd.Wrap(&RotateCACommand{}), test.args...)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"encoding/base64"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -28,6 +29,9 @@ type RunCommand struct {
 	services []string
 	units    []string
 	commands string
+	async    bool
+	stream   bool
+	parallel int
 }
 
 const runDoc = `
@@ -56,6 +60,19 @@ the unit.
 in the environment.  If you specify --all you cannot provide additional
 targets.
 
+If --async is specified, the command returns the id of a run job
+straight away instead of waiting for the results. The agents of the
+targets' machines run the commands and record the results, which can be
+retrieved with "juju run-status <job-id>".
+
+If --stream is specified, the output of the commands is shown as they
+run, with each line prefixed by the id of the machine or unit it came
+from. Once the commands have finished on all the targets, their exit
+codes are summarised.
+
+With --async or --stream, --parallel limits the number of targets the
+commands run on at the same time.
+
 `
 
 func (c *RunCommand) Info() *cmd.Info {
@@ -74,6 +91,9 @@ func (c *RunCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(cmd.NewStringsValue(nil, &c.machines), "machine", "one or more machine ids")
 	f.Var(cmd.NewStringsValue(nil, &c.services), "service", "one or more service names")
 	f.Var(cmd.NewStringsValue(nil, &c.units), "unit", "one or more unit ids")
+	f.BoolVar(&c.async, "async", false, "queue the commands and return the run job id without waiting for the results")
+	f.BoolVar(&c.stream, "stream", false, "show the output of the commands as they run")
+	f.IntVar(&c.parallel, "parallel", 0, "with --async or --stream, the maximum number of targets to run the commands on at the same time (0 means no limit)")
 }
 
 func (c *RunCommand) Init(args []string) error {
@@ -98,6 +118,16 @@ func (c *RunCommand) Init(args []string) error {
 		}
 	}
 
+	if c.async && c.stream {
+		return fmt.Errorf("You cannot specify both --async and --stream")
+	}
+	if c.parallel < 0 {
+		return fmt.Errorf("--parallel must not be negative")
+	}
+	if c.parallel != 0 && !c.async && !c.stream {
+		return fmt.Errorf("--parallel can only be used with --async or --stream")
+	}
+
 	var nameErrors []string
 	for _, machineId := range c.machines {
 		if !names.IsValidMachine(machineId) {
@@ -165,6 +195,9 @@ func ConvertRunResults(runResults []params.RunResult) interface{} {
 		if result.Error != "" {
 			values["Error"] = result.Error
 		}
+		if result.Status != "" {
+			values["Status"] = result.Status
+		}
 		results[i] = values
 	}
 
@@ -178,6 +211,13 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 	}
 	defer client.Close()
 
+	if c.async {
+		return c.runAsync(ctx, client)
+	}
+	if c.stream {
+		return c.runStream(ctx, client)
+	}
+
 	var runResults []params.RunResult
 	if c.all {
 		runResults, err = client.RunOnAllMachines(c.commands, c.timeout)
@@ -216,6 +256,80 @@ func (c *RunCommand) Run(ctx *cmd.Context) error {
 	return nil
 }
 
+// startJob queues the commands as a run job, and returns its id.
+func (c *RunCommand) startJob(client RunClient) (string, error) {
+	jobId, err := client.RunAsync(params.RunAsyncParams{
+		RunParams: params.RunParams{
+			Commands: c.commands,
+			Timeout:  c.timeout,
+			Machines: c.machines,
+			Services: c.services,
+			Units:    c.units,
+		},
+		AllMachines: c.all,
+		Parallelism: c.parallel,
+	})
+	if params.IsCodeNotImplemented(err) {
+		return "", fmt.Errorf("cannot run asynchronously: not supported by the API server")
+	}
+	return jobId, err
+}
+
+func (c *RunCommand) runAsync(ctx *cmd.Context, client RunClient) error {
+	jobId, err := c.startJob(client)
+	if err != nil {
+		return err
+	}
+	if err := c.out.Write(ctx, jobId); err != nil {
+		return err
+	}
+	ctx.Infof("Run \"juju run-status %s\" to get the results.", jobId)
+	return nil
+}
+
+func (c *RunCommand) runStream(ctx *cmd.Context, client RunClient) error {
+	jobId, err := c.startJob(client)
+	if err != nil {
+		return err
+	}
+	output, err := client.WatchRunOutput(jobId)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(ctx.Stdout, output)
+	output.Close()
+	if err != nil {
+		return fmt.Errorf("cannot read output of run job %s: %v", jobId, err)
+	}
+	status, err := client.RunStatus(jobId)
+	if err != nil {
+		return err
+	}
+	if !status.Done {
+		return fmt.Errorf("run job %s has not finished; run \"juju run-status %s\" to get the results", jobId, jobId)
+	}
+	failed := 0
+	for _, result := range status.Results {
+		target := result.MachineId
+		if result.UnitId != "" {
+			target = result.UnitId
+		}
+		if result.Error != "" {
+			fmt.Fprintf(ctx.Stdout, "%s: failed: %s\n", target, result.Error)
+			failed++
+			continue
+		}
+		fmt.Fprintf(ctx.Stdout, "%s: exit code %d\n", target, result.Code)
+		if result.Code != 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("commands failed on %d of %d targets", failed, len(status.Results))
+	}
+	return nil
+}
+
 // In order to be able to easily mock out the API side for testing,
 // the API client is got using a function.
 
@@ -223,6 +337,9 @@ type RunClient interface {
 	Close() error
 	RunOnAllMachines(commands string, timeout time.Duration) ([]params.RunResult, error)
 	Run(run params.RunParams) ([]params.RunResult, error)
+	RunAsync(run params.RunAsyncParams) (string, error)
+	WatchRunOutput(jobId string) (io.ReadCloser, error)
+	RunStatus(jobId string) (params.RunJobStatus, error)
 }
 
 // Here we need the signature to be correct for the interface.
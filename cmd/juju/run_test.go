@@ -5,7 +5,10 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/juju/cmd"
@@ -294,6 +297,100 @@ func (s *RunSuite) TestAllMachines(c *gc.C) {
 	c.Check(testing.Stdout(context), gc.Equals, string(jsonFormatted)+"\n")
 }
 
+func (*RunSuite) TestAsyncArgParsing(c *gc.C) {
+	runCmd := &RunCommand{}
+	testing.TestInit(c, envcmd.Wrap(runCmd), []string{"--all", "--parallel=2", "hostname"}, "--parallel can only be used with --async or --stream")
+	runCmd = &RunCommand{}
+	testing.TestInit(c, envcmd.Wrap(runCmd), []string{"--all", "--async", "--stream", "hostname"}, "You cannot specify both --async and --stream")
+	runCmd = &RunCommand{}
+	testing.TestInit(c, envcmd.Wrap(runCmd), []string{"--all", "--async", "--parallel=-1", "hostname"}, "--parallel must not be negative")
+	runCmd = &RunCommand{}
+	testing.TestInit(c, envcmd.Wrap(runCmd), []string{"--all", "--async", "--parallel=2", "hostname"}, "")
+	c.Check(runCmd.async, jc.IsTrue)
+	c.Check(runCmd.parallel, gc.Equals, 2)
+	runCmd = &RunCommand{}
+	testing.TestInit(c, envcmd.Wrap(runCmd), []string{"--all", "--stream", "--parallel=2", "hostname"}, "")
+	c.Check(runCmd.stream, jc.IsTrue)
+	c.Check(runCmd.parallel, gc.Equals, 2)
+}
+
+func (s *RunSuite) TestAsync(c *gc.C) {
+	mock := s.setupMockAPI()
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}),
+		"--async", "--parallel=10", "--all", "--timeout=1m", "hostname",
+	)
+	c.Assert(err, gc.IsNil)
+	c.Check(testing.Stdout(context), gc.Equals, "42\n")
+	c.Check(testing.Stderr(context), gc.Equals, "Run \"juju run-status 42\" to get the results.\n")
+	c.Check(mock.async, jc.DeepEquals, []params.RunAsyncParams{{
+		RunParams: params.RunParams{
+			Commands: "hostname",
+			Timeout:  time.Minute,
+		},
+		AllMachines: true,
+		Parallelism: 10,
+	}})
+}
+
+func (s *RunSuite) TestStream(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.output = "0: one\nwordpress/0: two\n"
+	mock.status = params.RunJobStatus{
+		JobId: "42",
+		Done:  true,
+		Results: []params.RunResult{
+			{MachineId: "0", ExecResponse: exec.ExecResponse{Stdout: []byte("one\n")}},
+			{MachineId: "1", UnitId: "wordpress/0", ExecResponse: exec.ExecResponse{Stdout: []byte("two\n")}},
+		},
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}),
+		"--stream", "--parallel=1", "--machine=0", "--unit=wordpress/0", "hostname",
+	)
+	c.Assert(err, gc.IsNil)
+	c.Check(testing.Stdout(context), gc.Equals, `
+0: one
+wordpress/0: two
+0: exit code 0
+wordpress/0: exit code 0
+`[1:])
+	c.Check(mock.async, jc.DeepEquals, []params.RunAsyncParams{{
+		RunParams: params.RunParams{
+			Commands: "hostname",
+			Timeout:  5 * time.Minute,
+			Machines: []string{"0"},
+			Units:    []string{"wordpress/0"},
+		},
+		Parallelism: 1,
+	}})
+}
+
+func (s *RunSuite) TestStreamFailures(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.status = params.RunJobStatus{
+		JobId: "42",
+		Done:  true,
+		Results: []params.RunResult{
+			{MachineId: "0", ExecResponse: exec.ExecResponse{Code: 2}},
+			{MachineId: "1", Error: "no juju-run"},
+			{MachineId: "2"},
+		},
+	}
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}), "--stream", "--all", "hostname")
+	c.Assert(err, gc.ErrorMatches, "commands failed on 2 of 3 targets")
+	c.Check(testing.Stdout(context), gc.Equals, `
+0: exit code 2
+1: failed: no juju-run
+2: exit code 0
+`[1:])
+}
+
+func (s *RunSuite) TestStreamNotFinished(c *gc.C) {
+	mock := s.setupMockAPI()
+	mock.status = params.RunJobStatus{JobId: "42"}
+	_, err := testing.RunCommand(c, envcmd.Wrap(&RunCommand{}), "--stream", "--all", "hostname")
+	c.Assert(err, gc.ErrorMatches, `run job 42 has not finished; run "juju run-status 42" to get the results`)
+}
+
 func (s *RunSuite) TestSingleResponse(c *gc.C) {
 	mock := s.setupMockAPI()
 	mock.setMachinesAlive("0")
@@ -363,6 +460,9 @@ type mockRunAPI struct {
 	// machines, services, units
 	machines  map[string]bool
 	responses map[string]params.RunResult
+	async     []params.RunAsyncParams
+	output    string
+	status    params.RunJobStatus
 }
 
 type mockResponse struct {
@@ -449,3 +549,16 @@ func (m *mockRunAPI) Run(runParams params.RunParams) ([]params.RunResult, error)
 
 	return result, nil
 }
+
+func (m *mockRunAPI) RunAsync(runParams params.RunAsyncParams) (string, error) {
+	m.async = append(m.async, runParams)
+	return "42", nil
+}
+
+func (m *mockRunAPI) WatchRunOutput(jobId string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(m.output)), nil
+}
+
+func (m *mockRunAPI) RunStatus(jobId string) (params.RunJobStatus, error) {
+	return m.status, nil
+}
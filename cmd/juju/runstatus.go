@@ -0,0 +1,87 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// RunStatusCommand reports the progress and results of a run job
+// queued with "juju run --async".
+type RunStatusCommand struct {
+	envcmd.EnvCommandBase
+	out   cmd.Output
+	jobId string
+}
+
+const runStatusDoc = `
+Show the progress of a run job queued with "juju run --async", and the
+results of the targets that have finished running the commands.
+
+Each target has one of the following statuses:
+  queued     waiting for other targets to finish, because of --parallel
+  pending    waiting for the agent to run the commands
+  running    the agent is running the commands
+  completed  the commands ran; the output and return code are shown
+  failed     the agent could not run the commands; the error is shown
+`
+
+func (c *RunStatusCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "run-status",
+		Args:    "<job-id>",
+		Purpose: "show the results of an asynchronous run",
+		Doc:     runStatusDoc,
+	}
+}
+
+func (c *RunStatusCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *RunStatusCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no job id specified")
+	}
+	c.jobId, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+func (c *RunStatusCommand) Run(ctx *cmd.Context) error {
+	client, err := getRunStatusAPI(c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.RunStatus(c.jobId)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot get run status: not supported by the API server")
+	} else if err != nil {
+		return err
+	}
+	return c.out.Write(ctx, map[string]interface{}{
+		"JobId":    status.JobId,
+		"Commands": status.Commands,
+		"Done":     status.Done,
+		"Results":  ConvertRunResults(status.Results),
+	})
+}
+
+// RunStatusAPI defines the API methods that the run-status command
+// uses.
+type RunStatusAPI interface {
+	Close() error
+	RunStatus(jobId string) (params.RunJobStatus, error)
+}
+
+var getRunStatusAPI = func(c *RunStatusCommand) (RunStatusAPI, error) {
+	return c.NewAPIClient()
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"github.com/juju/utils/exec"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type RunStatusSuite struct {
+	testing.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&RunStatusSuite{})
+
+type mockRunStatusAPI struct {
+	jobId string
+}
+
+func (*mockRunStatusAPI) Close() error {
+	return nil
+}
+
+func (m *mockRunStatusAPI) RunStatus(jobId string) (params.RunJobStatus, error) {
+	m.jobId = jobId
+	return params.RunJobStatus{
+		JobId:    jobId,
+		Commands: "hostname",
+		Results: []params.RunResult{{
+			ExecResponse: exec.ExecResponse{Stdout: []byte("megatron")},
+			MachineId:    "0",
+			Status:       "completed",
+		}, {
+			MachineId: "1",
+			UnitId:    "wordpress/0",
+			Status:    "pending",
+		}},
+	}, nil
+}
+
+func (s *RunStatusSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&RunStatusCommand{}), nil)
+	c.Check(err, gc.ErrorMatches, "no job id specified")
+	err = testing.InitCommand(envcmd.Wrap(&RunStatusCommand{}), []string{"1", "2"})
+	c.Check(err, gc.ErrorMatches, `unrecognized args: \["2"\]`)
+}
+
+func (s *RunStatusSuite) TestRunStatus(c *gc.C) {
+	mock := &mockRunStatusAPI{}
+	s.PatchValue(&getRunStatusAPI, func(_ *RunStatusCommand) (RunStatusAPI, error) {
+		return mock, nil
+	})
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&RunStatusCommand{}), "42")
+	c.Assert(err, gc.IsNil)
+	c.Assert(mock.jobId, gc.Equals, "42")
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"Commands: hostname\n"+
+		"Done: false\n"+
+		"JobId: \"42\"\n"+
+		"Results:\n"+
+		"- MachineId: \"0\"\n"+
+		"  Status: completed\n"+
+		"  Stdout: megatron\n"+
+		"- MachineId: \"1\"\n"+
+		"  Status: pending\n"+
+		"  Stdout: \"\"\n"+
+		"  UnitId: wordpress/0\n")
+}
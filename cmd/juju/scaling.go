@@ -0,0 +1,256 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// SetScalingPolicyCommand sets the policy by which a service is scaled
+// automatically.
+type SetScalingPolicyCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	Policy      params.ScalingPolicy
+	Remove      bool
+}
+
+const setScalingPolicyDoc = `
+Set the policy by which units of a service are added or removed
+automatically, according to metrics reported by its units.
+
+Each rule is written as <metric>:<below>:<above>[:<step>]. Units are
+added when the metric, averaged over the service's units, is above the
+upper threshold, and removed when it is below the lower one; either
+threshold may be left empty. Step units (1 by default) are added or
+removed at a time. Units are added if any rule asks for more units, and
+removed only if every rule with a lower threshold asks for fewer. Only
+metrics reported in the last 10 minutes are taken into account.
+
+The metric may be a metric defined by the charm and reported with
+add-metric, or juju-units-per-machine, the number of units of the
+service per machine hosting them.
+
+The number of units is kept between --min and --max. The minimum is set
+as the service's minimum number of units, so missing units are added
+even when no rule applies. After the service has been scaled, it is not
+scaled again until --cooldown has passed. New units are placed with
+--placement (as "juju add-unit --to") if it is given, or on new
+machines chosen according to the service's constraints; the most
+recently added units are removed first.
+
+Examples:
+
+  juju set-scaling-policy --max 10 --cooldown 5m wordpress requests:50:200
+  juju set-scaling-policy --min 2 --max 6 mysql load::0.8:2
+  juju set-scaling-policy --remove wordpress
+
+Use "juju show-scaling" to see how the policy currently applies.
+`
+
+func (c *SetScalingPolicyCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-scaling-policy",
+		Args:    "<service> [<rule> ...]",
+		Purpose: "set how a service is scaled automatically",
+		Doc:     setScalingPolicyDoc,
+	}
+}
+
+func (c *SetScalingPolicyCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.IntVar(&c.Policy.Min, "min", 0, "minimum number of units")
+	f.IntVar(&c.Policy.Max, "max", 0, "maximum number of units")
+	f.DurationVar(&c.Policy.Cooldown, "cooldown", 5*time.Minute, "time after scaling before the service is scaled again")
+	f.StringVar(&c.Policy.Placement, "placement", "", "placement directive for new units")
+	f.BoolVar(&c.Remove, "remove", false, "stop scaling the service automatically")
+}
+
+func (c *SetScalingPolicyCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	c.ServiceName, args = args[0], args[1:]
+	if c.Remove {
+		if c.Policy.Max != 0 || c.Policy.Min != 0 || c.Policy.Placement != "" {
+			return errors.New("--remove cannot be used with other flags")
+		}
+		return cmd.CheckEmpty(args)
+	}
+	if c.Policy.Max < 1 {
+		return errors.New("--max must be specified")
+	}
+	for _, arg := range args {
+		rule, err := parseScalingRule(arg)
+		if err != nil {
+			return err
+		}
+		c.Policy.Rules = append(c.Policy.Rules, rule)
+	}
+	return nil
+}
+
+// parseScalingRule parses a scaling rule written as
+// <metric>:<below>:<above>[:<step>].
+func parseScalingRule(s string) (params.ScalingRule, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] == "" {
+		return params.ScalingRule{}, fmt.Errorf("invalid scaling rule %q (expected <metric>:<below>:<above>[:<step>])", s)
+	}
+	rule := params.ScalingRule{Metric: parts[0]}
+	for i, threshold := range []**float64{&rule.Below, &rule.Above} {
+		part := parts[i+1]
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return params.ScalingRule{}, fmt.Errorf("invalid threshold %q in scaling rule %q", part, s)
+		}
+		*threshold = &value
+	}
+	if rule.Below == nil && rule.Above == nil {
+		return params.ScalingRule{}, fmt.Errorf("scaling rule %q has no thresholds", s)
+	}
+	if len(parts) == 4 {
+		step, err := strconv.Atoi(parts[3])
+		if err != nil || step < 1 {
+			return params.ScalingRule{}, fmt.Errorf("invalid step %q in scaling rule %q", parts[3], s)
+		}
+		rule.Step = step
+	}
+	return rule, nil
+}
+
+func (c *SetScalingPolicyCommand) Run(_ *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	policy := &c.Policy
+	if c.Remove {
+		policy = nil
+	}
+	err = client.ServiceSetScalingPolicy(c.ServiceName, policy)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot set scaling policy: not supported by the API server")
+	}
+	return err
+}
+
+// ShowScalingCommand shows the scaling policy of a service and how it
+// currently applies.
+type ShowScalingCommand struct {
+	envcmd.EnvCommandBase
+	out         cmd.Output
+	ServiceName string
+}
+
+const showScalingDoc = `
+Show the scaling policy set with "juju set-scaling-policy" for a
+service, the current value of each rule's metric, the number of units
+the policy currently asks for, and when and why the service was last
+scaled.
+`
+
+func (c *ShowScalingCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "show-scaling",
+		Args:    "<service>",
+		Purpose: "show how a service is scaled automatically",
+		Doc:     showScalingDoc,
+	}
+}
+
+func (c *ShowScalingCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *ShowScalingCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	c.ServiceName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// scalingStatus holds the scaling of a service as reported by
+// show-scaling.
+type scalingStatus struct {
+	Service    string        `yaml:"service" json:"service"`
+	Min        int           `yaml:"min" json:"min"`
+	Max        int           `yaml:"max" json:"max"`
+	Cooldown   string        `yaml:"cooldown" json:"cooldown"`
+	Placement  string        `yaml:"placement,omitempty" json:"placement,omitempty"`
+	Rules      []scalingRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+	Units      int           `yaml:"units" json:"units"`
+	Desired    int           `yaml:"desired" json:"desired"`
+	Reason     string        `yaml:"reason,omitempty" json:"reason,omitempty"`
+	CoolingOff bool          `yaml:"cooling-off,omitempty" json:"cooling-off,omitempty"`
+	LastScaled string        `yaml:"last-scaled,omitempty" json:"last-scaled,omitempty"`
+	LastAction string        `yaml:"last-action,omitempty" json:"last-action,omitempty"`
+}
+
+// scalingRule holds a scaling rule, and the current value of its
+// metric if known, as reported by show-scaling.
+type scalingRule struct {
+	Metric string   `yaml:"metric" json:"metric"`
+	Below  *float64 `yaml:"below,omitempty" json:"below,omitempty"`
+	Above  *float64 `yaml:"above,omitempty" json:"above,omitempty"`
+	Step   int      `yaml:"step,omitempty" json:"step,omitempty"`
+	Value  *float64 `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+func (c *ShowScalingCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	result, err := client.ServiceScaling(c.ServiceName)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot show scaling: not supported by the API server")
+	} else if err != nil {
+		return err
+	}
+	status := scalingStatus{
+		Service:    result.ServiceName,
+		Min:        result.Policy.Min,
+		Max:        result.Policy.Max,
+		Cooldown:   result.Policy.Cooldown.String(),
+		Placement:  result.Policy.Placement,
+		Units:      result.Units,
+		Desired:    result.Desired,
+		Reason:     result.Reason,
+		CoolingOff: result.Cooldown,
+		LastAction: result.LastAction,
+	}
+	if !result.LastScaled.IsZero() {
+		status.LastScaled = result.LastScaled.UTC().Format(time.RFC3339)
+	}
+	for _, v := range result.Values {
+		rule := scalingRule{
+			Metric: v.Rule.Metric,
+			Below:  v.Rule.Below,
+			Above:  v.Rule.Above,
+			Step:   v.Rule.Step,
+		}
+		if v.Known {
+			value := v.Value
+			rule.Value = &value
+		}
+		status.Rules = append(status.Rules, rule)
+	}
+	return c.out.Write(ctx, status)
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	goyaml "gopkg.in/yaml.v1"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type ScalingSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&ScalingSuite{})
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func (s *ScalingSuite) TestParseScalingRule(c *gc.C) {
+	for i, t := range []struct {
+		rule     string
+		expected params.ScalingRule
+		err      string
+	}{{
+		rule:     "load:0.2:0.8",
+		expected: params.ScalingRule{Metric: "load", Below: float(0.2), Above: float(0.8)},
+	}, {
+		rule:     "requests::200:3",
+		expected: params.ScalingRule{Metric: "requests", Above: float(200), Step: 3},
+	}, {
+		rule:     "juju-units-per-machine:1:",
+		expected: params.ScalingRule{Metric: "juju-units-per-machine", Below: float(1)},
+	}, {
+		rule: "load",
+		err:  `invalid scaling rule "load" \(expected <metric>:<below>:<above>\[:<step>\]\)`,
+	}, {
+		rule: ":1:2",
+		err:  `invalid scaling rule ":1:2" .*`,
+	}, {
+		rule: "load:low:0.8",
+		err:  `invalid threshold "low" in scaling rule "load:low:0.8"`,
+	}, {
+		rule: "load::",
+		err:  `scaling rule "load::" has no thresholds`,
+	}, {
+		rule: "load:0.2:0.8:0",
+		err:  `invalid step "0" in scaling rule "load:0.2:0.8:0"`,
+	}} {
+		c.Logf("test %d: %s", i, t.rule)
+		rule, err := parseScalingRule(t.rule)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Check(err, gc.IsNil)
+		c.Check(rule, jc.DeepEquals, t.expected)
+	}
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		err: "no service name specified",
+	}, {
+		args: []string{"wordpress"},
+		err:  "--max must be specified",
+	}, {
+		args: []string{"--remove", "--max", "2", "wordpress"},
+		err:  "--remove cannot be used with other flags",
+	}, {
+		args: []string{"--remove", "wordpress", "load:1:2"},
+		err:  `unrecognized args: \["load:1:2"\]`,
+	}, {
+		args: []string{"--max", "2", "wordpress", "load"},
+		err:  `invalid scaling rule "load" .*`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(&SetScalingPolicyCommand{}, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *ScalingSuite) TestSetScalingPolicy(c *gc.C) {
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SetScalingPolicyCommand{}),
+		"--min", "1", "--max", "5", "--cooldown", "10m", "wordpress", "load:0.2:0.8:2", "requests::100")
+	c.Assert(err, gc.IsNil)
+	scaling, err := service.Scaling()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.Policy(), jc.DeepEquals, state.ScalingPolicy{
+		Min:      1,
+		Max:      5,
+		Cooldown: 10 * time.Minute,
+		Rules: []state.ScalingRule{
+			{Metric: "load", Below: float(0.2), Above: float(0.8), Step: 2},
+			{Metric: "requests", Above: float(100)},
+		},
+	})
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&SetScalingPolicyCommand{}), "--remove", "wordpress")
+	c.Assert(err, gc.IsNil)
+	_, err = service.Scaling()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ScalingSuite) TestShowScaling(c *gc.C) {
+	ch := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", ch)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(ch.URL())
+	c.Assert(err, gc.IsNil)
+	now := time.Now()
+	_, err = unit.AddMetrics(now, []state.Metric{{Key: "load", Value: "0.9", Time: now}})
+	c.Assert(err, gc.IsNil)
+	err = service.SetScalingPolicy(state.ScalingPolicy{
+		Max:      3,
+		Cooldown: time.Minute,
+		Rules: []state.ScalingRule{
+			{Metric: "load", Above: float(0.8)},
+			{Metric: "requests", Above: float(100)},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ShowScalingCommand{}), "wordpress")
+	c.Assert(err, gc.IsNil)
+	var status scalingStatus
+	err = goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &status)
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, jc.DeepEquals, scalingStatus{
+		Service:  "wordpress",
+		Max:      3,
+		Cooldown: "1m0s",
+		Rules: []scalingRule{
+			{Metric: "load", Above: float(0.8), Value: float(0.9)},
+			{Metric: "requests", Above: float(100)},
+		},
+		Units:   1,
+		Desired: 2,
+		Reason:  "load is 0.9, above 0.8",
+	})
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&ShowScalingCommand{}), "mysql")
+	c.Assert(err, gc.ErrorMatches, `service "mysql" not found`)
+}
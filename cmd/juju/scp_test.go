@@ -110,7 +110,7 @@ func (s *SCPSuite) TestSCPCommand(c *gc.C) {
 	)
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
 	c.Assert(err, gc.IsNil)
-	dummyCharm, err := s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256")
+	dummyCharm, err := s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256", 0)
 	c.Assert(err, gc.IsNil)
 	srv := s.AddTestingService(c, "mysql", dummyCharm)
 	s.addUnit(srv, m[0], c)
@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// SetServerCertAPI holds the API calls made by the set-server-cert
+// command.
+type SetServerCertAPI interface {
+	SetServerCertificate(caCert, srvCert, srvKey string) error
+	Close() error
+}
+
+var getSetServerCertAPI = func(c *envcmd.EnvCommandBase) (SetServerCertAPI, error) {
+	return c.NewAPIClient()
+}
+
+// SetServerCertCommand makes the state servers use an externally
+// signed certificate.
+type SetServerCertCommand struct {
+	envcmd.EnvCommandBase
+	CACertPath string
+	CertPath   string
+	KeyPath    string
+}
+
+const setServerCertDoc = `
+Make the state servers use the externally signed certificate and
+private key held in the given PEM files, instead of certificates issued
+by the environment's CA. The certificate file may also hold, after the
+certificate, the intermediate certificates that chain it to the CA.
+
+The certificate must be valid with respect to the given CA certificate,
+which becomes the only CA certificate trusted by the environment and is
+recorded in the local environment information. It must also be valid
+for the host names or addresses that agents and clients use to reach
+the state servers, which they verify.
+
+The same settings are available at bootstrap time as the ca-cert,
+server-cert and server-private-key environment attributes, or their
+-path variants. Run juju rotate-ca to go back to certificates issued by
+an environment CA.
+`
+
+func (c *SetServerCertCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-server-cert",
+		Args:    "<ca-cert-file> <cert-file> <private-key-file>",
+		Purpose: "use an externally signed state server certificate",
+		Doc:     setServerCertDoc,
+	}
+}
+
+func (c *SetServerCertCommand) Init(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("CA certificate, certificate and private key files must be specified")
+	}
+	c.CACertPath, c.CertPath, c.KeyPath = args[0], args[1], args[2]
+	return cmd.CheckEmpty(args[3:])
+}
+
+func (c *SetServerCertCommand) Run(ctx *cmd.Context) error {
+	caCert, err := readPEMFile(ctx, c.CACertPath)
+	if err != nil {
+		return err
+	}
+	srvCert, err := readPEMFile(ctx, c.CertPath)
+	if err != nil {
+		return err
+	}
+	srvKey, err := readPEMFile(ctx, c.KeyPath)
+	if err != nil {
+		return err
+	}
+	// Check the certificate before changing anything;
+	// the state servers check it too.
+	if _, _, err := cert.ParseCertAndKey(srvCert, srvKey); err != nil {
+		return errors.Annotate(err, "invalid certificate or key")
+	}
+	if err := cert.Verify(srvCert, caCert, time.Now()); err != nil {
+		return errors.Annotate(err, "invalid certificate")
+	}
+	recorder, err := newCACertRecorder(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	client, err := getSetServerCertAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.SetServerCertificate(caCert, srvCert, srvKey); err != nil {
+		return err
+	}
+	return recorder.record(caCert)
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/testing"
+)
+
+type SetServerCertSuite struct {
+	testing.FakeJujuHomeSuite
+	api    *fakeSetServerCertAPI
+	writer *fakeEndpointWriter
+	dir    string
+	caCert string
+}
+
+var _ = gc.Suite(&SetServerCertSuite{})
+
+type fakeSetServerCertAPI struct {
+	args []string
+}
+
+func (f *fakeSetServerCertAPI) SetServerCertificate(caCert, srvCert, srvKey string) error {
+	f.args = []string{caCert, srvCert, srvKey}
+	return nil
+}
+
+func (*fakeSetServerCertAPI) Close() error {
+	return nil
+}
+
+func (s *SetServerCertSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &fakeSetServerCertAPI{}
+	s.writer = &fakeEndpointWriter{}
+	s.PatchValue(&getSetServerCertAPI, func(*envcmd.EnvCommandBase) (SetServerCertAPI, error) {
+		return s.api, nil
+	})
+	s.PatchValue(&getCACertEndpoint, func(*envcmd.EnvCommandBase) (configstore.APIEndpoint, error) {
+		return configstore.APIEndpoint{
+			Addresses: []string{"10.0.0.1:17070"},
+			CACert:    testing.CACert,
+		}, nil
+	})
+	s.PatchValue(&getCACertWriter, func(*envcmd.EnvCommandBase) (envcmd.ConnectionWriter, error) {
+		return s.writer, nil
+	})
+
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	s.caCert = caCert
+	s.dir = c.MkDir()
+	s.writeFile(c, "ca-cert.pem", caCert)
+	s.writeFile(c, "cert.pem", srvCert)
+	s.writeFile(c, "key.pem", srvKey)
+}
+
+func (s *SetServerCertSuite) writeFile(c *gc.C, name, data string) {
+	err := ioutil.WriteFile(filepath.Join(s.dir, name), []byte(data), 0600)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *SetServerCertSuite) run(c *gc.C, args ...string) error {
+	for i, arg := range args {
+		args[i] = filepath.Join(s.dir, arg)
+	}
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SetServerCertCommand{}), args...)
+	return err
+}
+
+func (s *SetServerCertSuite) TestSetServerCert(c *gc.C) {
+	err := s.run(c, "ca-cert.pem", "cert.pem", "key.pem")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.args, gc.HasLen, 3)
+	c.Assert(s.api.args[0], gc.Equals, s.caCert)
+	c.Assert(s.writer.written, gc.Equals, true)
+	c.Assert(s.writer.endpoint, gc.DeepEquals, configstore.APIEndpoint{
+		Addresses: []string{"10.0.0.1:17070"},
+		CACert:    s.caCert,
+	})
+}
+
+func (s *SetServerCertSuite) TestNotSignedByCA(c *gc.C) {
+	s.writeFile(c, "ca-cert.pem", testing.CACert)
+	err := s.run(c, "ca-cert.pem", "cert.pem", "key.pem")
+	c.Assert(err, gc.ErrorMatches, "invalid certificate: x509: certificate signed by unknown authority.*")
+	c.Assert(s.api.args, gc.IsNil)
+	c.Assert(s.writer.written, gc.Equals, false)
+}
+
+func (s *SetServerCertSuite) TestMismatchedKey(c *gc.C) {
+	s.writeFile(c, "key.pem", testing.ServerKey)
+	err := s.run(c, "ca-cert.pem", "cert.pem", "key.pem")
+	c.Assert(err, gc.ErrorMatches, "invalid certificate or key: .*")
+	c.Assert(s.api.args, gc.IsNil)
+}
+
+func (s *SetServerCertSuite) TestInitErrors(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SetServerCertCommand{}), "ca-cert.pem", "cert.pem")
+	c.Assert(err, gc.ErrorMatches, "CA certificate, certificate and private key files must be specified")
+	_, err = testing.RunCommand(c, envcmd.Wrap(&SetServerCertCommand{}), "a", "b", "c", "d")
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["d"\]`)
+}
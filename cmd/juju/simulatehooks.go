@@ -0,0 +1,282 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/juju/cmd"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/juju/charm.v3/hooks"
+	goyaml "gopkg.in/yaml.v1"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/worker/uniter/harness"
+)
+
+// SimulateHooksCommand runs the hooks of a local charm against a fake
+// hook context.
+type SimulateHooksCommand struct {
+	cmd.CommandBase
+	out      cmd.Output
+	charmDir string
+	scenario string
+	unitName string
+}
+
+const simulateHooksDoc = `
+Run the hooks of a local charm outside of any environment, in a scratch
+directory and against a fake hook context, and report the output of
+each hook and the hook tool calls it made.
+
+The hooks to run are described by a scenario file. Each step of the
+scenario runs a hook, after optionally changing the fake context:
+
+  unit: wordpress/0
+  config:
+    blog-title: My Title
+  steps:
+  - hook: install
+  - hook: config-changed
+  - hook: start
+  - hook: relation-joined
+    relation: db
+    unit: mysql/0
+    settings:
+      host: 10.0.0.2
+  - hook: relation-changed
+    relation: db
+    unit: mysql/0
+  - hook: config-changed
+    config:
+      blog-title: Other Title
+  - action: backup
+    params:
+      target: /srv
+  - hook: relation-departed
+    relation: db
+    unit: mysql/0
+  - hook: relation-broken
+    relation: db
+
+Relations are created when first named. The settings of a remote unit
+are set before its relation-joined and relation-changed hooks are run,
+and the unit leaves the relation before its relation-departed hook is
+run. Relation settings written by a hook are discarded if the hook
+fails, as they are by the unit agent.
+
+Without a scenario, the install, config-changed and start hooks are run.
+The simulation stops at the first hook that fails.
+`
+
+func (c *SimulateHooksCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "simulate-hooks",
+		Args:    "<charm directory> [<scenario>]",
+		Purpose: "run the hooks of a local charm against a fake hook context",
+		Doc:     simulateHooksDoc,
+	}
+}
+
+func (c *SimulateHooksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+	f.StringVar(&c.unitName, "unit", "", "the name of the unit running the hooks")
+}
+
+func (c *SimulateHooksCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return fmt.Errorf("no charm directory specified")
+	case 1:
+		c.charmDir = args[0]
+	default:
+		c.charmDir, c.scenario = args[0], args[1]
+		return cmd.CheckEmpty(args[2:])
+	}
+	return nil
+}
+
+// hookScenario describes the hooks run by simulate-hooks.
+type hookScenario struct {
+	Unit   string         `yaml:"unit"`
+	Config charm.Settings `yaml:"config"`
+	Steps  []scenarioStep `yaml:"steps"`
+}
+
+// scenarioStep describes a single hook run by simulate-hooks.
+type scenarioStep struct {
+	Hook     string                  `yaml:"hook"`
+	Relation string                  `yaml:"relation"`
+	Unit     string                  `yaml:"unit"`
+	Settings params.RelationSettings `yaml:"settings"`
+	Config   charm.Settings          `yaml:"config"`
+	Action   string                  `yaml:"action"`
+	Params   map[string]interface{}  `yaml:"params"`
+}
+
+var defaultScenario = hookScenario{
+	Steps: []scenarioStep{
+		{Hook: string(hooks.Install)},
+		{Hook: string(hooks.ConfigChanged)},
+		{Hook: string(hooks.Start)},
+	},
+}
+
+// simulatedHook holds the outcome of a hook run by simulate-hooks.
+type simulatedHook struct {
+	Hook    string          `yaml:"hook" json:"hook"`
+	Missing bool            `yaml:"missing,omitempty" json:"missing,omitempty"`
+	Calls   []simulatedCall `yaml:"calls,omitempty" json:"calls,omitempty"`
+	Output  string          `yaml:"output,omitempty" json:"output,omitempty"`
+	Error   string          `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// simulatedCall holds a hook tool call made by a hook run by
+// simulate-hooks.
+type simulatedCall struct {
+	Command string   `yaml:"command" json:"command"`
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+	Code    int      `yaml:"code,omitempty" json:"code,omitempty"`
+	Stdout  string   `yaml:"stdout,omitempty" json:"stdout,omitempty"`
+	Stderr  string   `yaml:"stderr,omitempty" json:"stderr,omitempty"`
+}
+
+func (c *SimulateHooksCommand) readScenario(ctx *cmd.Context) (*hookScenario, error) {
+	if c.scenario == "" {
+		scenario := defaultScenario
+		return &scenario, nil
+	}
+	data, err := ioutil.ReadFile(ctx.AbsPath(c.scenario))
+	if err != nil {
+		return nil, err
+	}
+	var scenario hookScenario
+	if err := goyaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("cannot parse scenario: %v", err)
+	}
+	return &scenario, nil
+}
+
+func (c *SimulateHooksCommand) Run(ctx *cmd.Context) error {
+	scenario, err := c.readScenario(ctx)
+	if err != nil {
+		return err
+	}
+	hookTool, err := jujuExecutable()
+	if err != nil {
+		return fmt.Errorf("cannot find juju executable: %v", err)
+	}
+	unitName := c.unitName
+	if unitName == "" {
+		unitName = scenario.Unit
+	}
+	h, err := harness.New(harness.Params{
+		CharmDir: ctx.AbsPath(c.charmDir),
+		UnitName: unitName,
+		HookTool: hookTool,
+	})
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	h.Context().UpdateConfig(scenario.Config)
+
+	var simulated []simulatedHook
+	var stepErr error
+	for i, step := range scenario.Steps {
+		var result *harness.Result
+		if result, stepErr = runScenarioStep(h, step); stepErr != nil && result == nil {
+			stepErr = fmt.Errorf("step %d: %v", i+1, stepErr)
+		}
+		if result != nil {
+			simulated = append(simulated, formatSimulatedHook(result))
+		}
+		if stepErr != nil {
+			break
+		}
+	}
+	if err := c.out.Write(ctx, simulated); err != nil {
+		return err
+	}
+	return stepErr
+}
+
+// runScenarioStep changes the context as described by step, and runs
+// its hook.
+func runScenarioStep(h *harness.Harness, step scenarioStep) (*harness.Result, error) {
+	ctx := h.Context()
+	ctx.UpdateConfig(step.Config)
+	if step.Action != "" {
+		if step.Hook != "" {
+			return nil, fmt.Errorf("cannot specify both hook and action")
+		}
+		return h.RunAction(step.Action, step.Params)
+	}
+	if step.Hook == "" {
+		return nil, fmt.Errorf("no hook or action specified")
+	}
+	hi := harness.Hook{Kind: hooks.Kind(step.Hook)}
+	if !hi.Kind.IsRelation() {
+		return h.Run(hi)
+	}
+	if step.Relation == "" {
+		return nil, fmt.Errorf("%q hook requires a relation", hi.Kind)
+	}
+	relationId, err := ctx.RelationId(step.Relation)
+	if err != nil {
+		relationId = ctx.AddRelation(step.Relation)
+	}
+	hi.RelationId, hi.RemoteUnit = relationId, step.Unit
+	switch hi.Kind {
+	case hooks.RelationJoined, hooks.RelationChanged:
+		if step.Unit == "" {
+			break
+		}
+		r, _ := ctx.Relation(relationId)
+		if _, err := r.ReadSettings(step.Unit); err != nil || step.Settings != nil {
+			if err := ctx.SetRemoteUnit(relationId, step.Unit, step.Settings); err != nil {
+				return nil, err
+			}
+		}
+	case hooks.RelationDeparted:
+		if step.Unit == "" {
+			break
+		}
+		if err := ctx.RemoveRemoteUnit(relationId, step.Unit); err != nil {
+			return nil, err
+		}
+	case hooks.RelationBroken:
+		result, err := h.Run(hi)
+		if err != nil {
+			return result, err
+		}
+		return result, ctx.RemoveRelation(relationId)
+	}
+	return h.Run(hi)
+}
+
+// formatSimulatedHook returns the outcome of a hook as reported by
+// simulate-hooks.
+func formatSimulatedHook(result *harness.Result) simulatedHook {
+	formatted := simulatedHook{
+		Hook:    result.HookName,
+		Missing: result.Missing,
+		Output:  result.Output,
+	}
+	for _, call := range result.Calls {
+		formatted.Calls = append(formatted.Calls, simulatedCall{
+			Command: call.Command,
+			Args:    call.Args,
+			Code:    call.Code,
+			Stdout:  call.Stdout,
+			Stderr:  call.Stderr,
+		})
+	}
+	if result.Err != nil {
+		formatted.Error = result.Err.Error()
+	}
+	return formatted
+}
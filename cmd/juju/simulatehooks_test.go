@@ -0,0 +1,115 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/cmd"
+	goyaml "gopkg.in/yaml.v1"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+)
+
+type SimulateHooksSuite struct {
+	testing.FakeJujuHomeSuite
+	charmDir string
+}
+
+var _ = gc.Suite(&SimulateHooksSuite{})
+
+func (s *SimulateHooksSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.charmDir = filepath.Join(c.MkDir(), "wordpress")
+	for name, content := range map[string]string{
+		"install":             "echo installing $JUJU_UNIT_NAME",
+		"start":               "echo starting",
+		"db-relation-joined":  "echo joined $JUJU_RELATION_ID $JUJU_REMOTE_UNIT",
+		"db-relation-changed": "echo oops >&2; exit 1",
+	} {
+		path := filepath.Join(s.charmDir, "hooks", name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		c.Assert(err, gc.IsNil)
+		err = ioutil.WriteFile(path, []byte("#!/bin/bash\n"+content+"\n"), 0755)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func simulatedHooks(c *gc.C, ctx *cmd.Context) []simulatedHook {
+	var result []simulatedHook
+	err := goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &result)
+	c.Assert(err, gc.IsNil)
+	return result
+}
+
+func (s *SimulateHooksSuite) TestInit(c *gc.C) {
+	err := testing.InitCommand(&SimulateHooksCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no charm directory specified")
+	err = testing.InitCommand(&SimulateHooksCommand{}, []string{"charm", "scenario", "foo"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
+
+func (s *SimulateHooksSuite) TestDefaultScenario(c *gc.C) {
+	ctx, err := testing.RunCommand(c, &SimulateHooksCommand{}, s.charmDir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(simulatedHooks(c, ctx), gc.DeepEquals, []simulatedHook{
+		{Hook: "install", Output: "installing wordpress/0\n"},
+		{Hook: "config-changed", Missing: true},
+		{Hook: "start", Output: "starting\n"},
+	})
+}
+
+func (s *SimulateHooksSuite) TestScenario(c *gc.C) {
+	scenario := filepath.Join(c.MkDir(), "scenario.yaml")
+	err := ioutil.WriteFile(scenario, []byte(`
+steps:
+- hook: relation-joined
+  relation: db
+  unit: mysql/0
+- hook: relation-changed
+  relation: db
+  unit: mysql/0
+- hook: start
+`), 0644)
+	c.Assert(err, gc.IsNil)
+	ctx, err := testing.RunCommand(c, &SimulateHooksCommand{}, "--unit", "blog/1", s.charmDir, scenario)
+	c.Assert(err, gc.ErrorMatches, `hook "db-relation-changed" failed: exit status 1`)
+	c.Assert(simulatedHooks(c, ctx), gc.DeepEquals, []simulatedHook{
+		{Hook: "db-relation-joined", Output: "joined db:0 mysql/0\n"},
+		{Hook: "db-relation-changed", Output: "oops\n", Error: "exit status 1"},
+	})
+}
+
+func (s *SimulateHooksSuite) TestScenarioErrors(c *gc.C) {
+	for i, t := range []struct {
+		scenario string
+		err      string
+	}{{
+		scenario: "steps: [{}]",
+		err:      "step 1: no hook or action specified",
+	}, {
+		scenario: "steps: [{hook: install, action: backup}]",
+		err:      "step 1: cannot specify both hook and action",
+	}, {
+		scenario: "steps: [{hook: relation-joined, unit: mysql/0}]",
+		err:      `step 1: "relation-joined" hook requires a relation`,
+	}, {
+		scenario: "steps: [{hook: relation-departed, relation: db, unit: mysql/0}]",
+		err:      `step 1: unit "mysql/0" is not in relation 0`,
+	}, {
+		scenario: "steps: foo",
+		err:      "cannot parse scenario: .*",
+	}} {
+		c.Logf("test %d: %s", i, t.scenario)
+		scenario := filepath.Join(c.MkDir(), "scenario.yaml")
+		err := ioutil.WriteFile(scenario, []byte(t.scenario), 0644)
+		c.Assert(err, gc.IsNil)
+		_, err = testing.RunCommand(c, &SimulateHooksCommand{}, s.charmDir, scenario)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const spaceCommandDoc = `
+"juju space" is used to manage network spaces. A space is a named set
+of subnets; charm endpoints can be bound to a space when a service is
+deployed (see "juju help deploy"), and machines can be constrained to
+spaces with the "spaces" constraint.
+`
+
+// NewSpaceCommand returns the "juju space" super-command.
+func NewSpaceCommand() cmd.Command {
+	spacecmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "space",
+		Doc:         spaceCommandDoc,
+		UsagePrefix: "juju",
+		Purpose:     "manage network spaces",
+	})
+	spacecmd.Register(envcmd.Wrap(&SpaceCreateCommand{}))
+	spacecmd.Register(envcmd.Wrap(&SpaceListCommand{}))
+	return spacecmd
+}
+
+// SpaceAPI holds the API calls made by the space commands.
+type SpaceAPI interface {
+	AddSpace(name string, subnets []string) error
+	ListSpaces() ([]params.SpaceInfo, error)
+	Close() error
+}
+
+var getSpaceAPI = func(c *envcmd.EnvCommandBase) (SpaceAPI, error) {
+	return c.NewAPIClient()
+}
+
+const spaceCreateCommandDoc = `
+Create a space containing the given subnets. The subnets must already
+be known to juju (see "juju subnet add") and must not belong to any
+other space.
+
+Example:
+  juju space create dmz 10.0.1.0/24 10.0.2.0/24
+`
+
+// SpaceCreateCommand creates a new space.
+type SpaceCreateCommand struct {
+	envcmd.EnvCommandBase
+	Name    string
+	Subnets []string
+}
+
+func (c *SpaceCreateCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "create",
+		Args:    "<name> [<CIDR> ...]",
+		Purpose: "create a network space",
+		Doc:     spaceCreateCommandDoc,
+	}
+}
+
+func (c *SpaceCreateCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no space name specified")
+	}
+	c.Name = args[0]
+	for _, cidr := range args[1:] {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR %q", cidr)
+		}
+	}
+	c.Subnets = args[1:]
+	return nil
+}
+
+func (c *SpaceCreateCommand) Run(ctx *cmd.Context) error {
+	client, err := getSpaceAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.AddSpace(c.Name, c.Subnets)
+}
+
+const spaceListCommandDoc = `
+List the network spaces in the environment, with the subnets in each.
+`
+
+// SpaceListCommand lists the spaces in the environment.
+type SpaceListCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+func (c *SpaceListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list network spaces",
+		Doc:     spaceListCommandDoc,
+	}
+}
+
+func (c *SpaceListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *SpaceListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *SpaceListCommand) Run(ctx *cmd.Context) error {
+	client, err := getSpaceAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	spaces, err := client.ListSpaces()
+	if err != nil {
+		return err
+	}
+	result := make(map[string][]string)
+	for _, space := range spaces {
+		subnets := space.Subnets
+		if subnets == nil {
+			subnets = []string{}
+		}
+		result[space.Name] = subnets
+	}
+	return c.out.Write(ctx, result)
+}
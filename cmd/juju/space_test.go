@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type SpaceCommandSuite struct {
+	testing.FakeJujuHomeSuite
+	mockAPI *mockSpaceAPI
+}
+
+var _ = gc.Suite(&SpaceCommandSuite{})
+
+func (s *SpaceCommandSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mockAPI = &mockSpaceAPI{}
+	s.PatchValue(&getSpaceAPI, func(c *envcmd.EnvCommandBase) (SpaceAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *SpaceCommandSuite) TestCreateInitErrors(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&SpaceCreateCommand{}), nil)
+	c.Check(err, gc.ErrorMatches, "no space name specified")
+	err = testing.InitCommand(envcmd.Wrap(&SpaceCreateCommand{}), []string{"dmz", "10.0.0.0"})
+	c.Check(err, gc.ErrorMatches, `invalid CIDR "10.0.0.0"`)
+}
+
+func (s *SpaceCommandSuite) TestCreate(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SpaceCreateCommand{}), "dmz", "10.0.1.0/24", "10.0.2.0/24")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mockAPI.added, jc.DeepEquals, []params.AddSpace{{
+		Name:    "dmz",
+		Subnets: []string{"10.0.1.0/24", "10.0.2.0/24"},
+	}})
+}
+
+func (s *SpaceCommandSuite) TestList(c *gc.C) {
+	s.mockAPI.spaces = []params.SpaceInfo{{
+		Name:    "dmz",
+		Subnets: []string{"10.0.1.0/24", "10.0.2.0/24"},
+	}, {
+		Name: "empty",
+	}}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&SpaceListCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"dmz:\n"+
+		"- 10.0.1.0/24\n"+
+		"- 10.0.2.0/24\n"+
+		"empty: []\n")
+}
+
+type mockSpaceAPI struct {
+	added  []params.AddSpace
+	spaces []params.SpaceInfo
+	err    error
+}
+
+func (m *mockSpaceAPI) AddSpace(name string, subnets []string) error {
+	m.added = append(m.added, params.AddSpace{Name: name, Subnets: subnets})
+	return m.err
+}
+
+func (m *mockSpaceAPI) ListSpaces() ([]params.SpaceInfo, error) {
+	return m.spaces, m.err
+}
+
+func (m *mockSpaceAPI) Close() error {
+	return nil
+}
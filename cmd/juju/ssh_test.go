@@ -104,7 +104,7 @@ func (s *SSHSuite) TestSSHCommand(c *gc.C) {
 	)
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
 	c.Assert(err, gc.IsNil)
-	dummy, err := s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256")
+	dummy, err := s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256", 0)
 	c.Assert(err, gc.IsNil)
 	srv := s.AddTestingService(c, "mysql", dummy)
 	s.addUnit(srv, m[0], c)
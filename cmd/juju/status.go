@@ -36,6 +36,14 @@ will be displayed.
 Wildcards ('*') may be specified in service/unit names to match any sequence
 of characters. For example, 'nova-*' will match any service whose name begins
 with 'nova-': 'nova-compute', 'nova-volume', etc.
+
+As well as yaml (the default) and json, the following formats
+are supported:
+
+    tabular  tables of services, units and machines
+    summary  counts of machines and units by state, along with
+             open ports and networks
+    oneline  a line for each unit giving its address and state
 `
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -50,8 +58,11 @@ func (c *StatusCommand) Info() *cmd.Info {
 
 func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
-		"yaml": cmd.FormatYaml,
-		"json": cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": formatStatusTabular,
+		"summary": formatStatusSummary,
+		"oneline": formatStatusOneline,
 	})
 }
 
@@ -154,6 +165,8 @@ type serviceStatus struct {
 	Err           error                 `json:"-" yaml:",omitempty"`
 	Charm         string                `json:"charm" yaml:"charm"`
 	CanUpgradeTo  string                `json:"can-upgrade-to,omitempty" yaml:"can-upgrade-to,omitempty"`
+	UpgradePolicy string                `json:"upgrade-policy,omitempty" yaml:"upgrade-policy,omitempty"`
+	UpgradeWindow string                `json:"upgrade-window,omitempty" yaml:"upgrade-window,omitempty"`
 	Exposed       bool                  `json:"exposed" yaml:"exposed"`
 	Life          string                `json:"life,omitempty" yaml:"life,omitempty"`
 	Relations     map[string][]string   `json:"relations,omitempty" yaml:"relations,omitempty"`
@@ -336,6 +349,8 @@ func (sf *statusFormatter) formatService(name string, service api.ServiceStatus)
 		Relations:     service.Relations,
 		Networks:      make(map[string][]string),
 		CanUpgradeTo:  service.CanUpgradeTo,
+		UpgradePolicy: service.UpgradePolicy,
+		UpgradeWindow: service.UpgradeWindow,
 		SubordinateTo: service.SubordinateTo,
 		Units:         make(map[string]unitStatus),
 	}
@@ -0,0 +1,264 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The status formatters below present the same formattedStatus as the
+// yaml and json formats, so any patterns given to the status command
+// have already been applied to it.
+
+// formatStatusTabular returns tables of the services, units and
+// machines in the environment. Subordinate units are indented
+// under their principals, and containers are listed after the
+// machines that host them.
+func formatStatusTabular(value interface{}) ([]byte, error) {
+	fs, ok := value.(formattedStatus)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", fs, value)
+	}
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "[Services]")
+	fmt.Fprintln(tw, "NAME\tEXPOSED\tCHARM")
+	for _, name := range sortedServiceNames(fs.Services) {
+		svc := fs.Services[name]
+		if svc.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\n", name, svc.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%s\n", name, svc.Exposed, svc.Charm)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "[Units]")
+	fmt.Fprintln(tw, "ID\tSTATE\tVERSION\tMACHINE\tPORTS\tPUBLIC-ADDRESS\tINFO")
+	var printUnits func(units map[string]unitStatus, indent string)
+	printUnits = func(units map[string]unitStatus, indent string) {
+		for _, name := range sortedUnitNames(units) {
+			u := units[name]
+			if u.Err != nil {
+				fmt.Fprintf(tw, "%s%s\terror: %v\n", indent, name, u.Err)
+				continue
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				indent, name, u.AgentState, u.AgentVersion, u.Machine,
+				strings.Join(u.OpenedPorts, ","), u.PublicAddress, u.AgentStateInfo)
+			printUnits(u.Subordinates, indent+"  ")
+		}
+	}
+	for _, name := range sortedServiceNames(fs.Services) {
+		printUnits(fs.Services[name].Units, "")
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "[Machines]")
+	fmt.Fprintln(tw, "ID\tSTATE\tVERSION\tDNS\tINSTANCE-ID\tSERIES\tHARDWARE")
+	var printMachines func(machines map[string]machineStatus)
+	printMachines = func(machines map[string]machineStatus) {
+		for _, id := range sortedMachineIds(machines) {
+			m := machines[id]
+			if m.Err != nil {
+				fmt.Fprintf(tw, "%s\terror: %v\n", id, m.Err)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				id, m.AgentState, m.AgentVersion, m.DNSName, m.InstanceId, m.Series, m.Hardware)
+			printMachines(m.Containers)
+		}
+	}
+	printMachines(fs.Machines)
+	tw.Flush()
+	return buf.Bytes(), nil
+}
+
+// formatStatusSummary returns an overview of the environment: the
+// number of machines and units in each state, the number of
+// services and how many are exposed, the ports opened by units
+// and the networks in use.
+func formatStatusSummary(value interface{}) ([]byte, error) {
+	fs, ok := value.(formattedStatus)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", fs, value)
+	}
+	machineStates := make(map[string]int)
+	var countMachines func(machines map[string]machineStatus)
+	countMachines = func(machines map[string]machineStatus) {
+		for _, m := range machines {
+			machineStates[summaryState(string(m.AgentState), m.Err)]++
+			countMachines(m.Containers)
+		}
+	}
+	countMachines(fs.Machines)
+
+	unitStates := make(map[string]int)
+	ports := make(map[string]bool)
+	var countUnits func(units map[string]unitStatus)
+	countUnits = func(units map[string]unitStatus) {
+		for _, u := range units {
+			unitStates[summaryState(string(u.AgentState), u.Err)]++
+			for _, port := range u.OpenedPorts {
+				ports[port] = true
+			}
+			countUnits(u.Subordinates)
+		}
+	}
+	exposed := 0
+	for _, svc := range fs.Services {
+		if svc.Exposed {
+			exposed++
+		}
+		countUnits(svc.Units)
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	printStates := func(title string, states map[string]int) {
+		total := 0
+		for _, n := range states {
+			total += n
+		}
+		fmt.Fprintf(tw, "%s:\t%d\n", title, total)
+		var names []string
+		for state := range states {
+			names = append(names, state)
+		}
+		sort.Strings(names)
+		for _, state := range names {
+			fmt.Fprintf(tw, "  %s:\t%d\n", state, states[state])
+		}
+	}
+	printStates("MACHINES", machineStates)
+	printStates("UNITS", unitStates)
+	fmt.Fprintf(tw, "SERVICES:\t%d\n", len(fs.Services))
+	fmt.Fprintf(tw, "  exposed:\t%d\n", exposed)
+
+	var openPorts []string
+	for port := range ports {
+		openPorts = append(openPorts, port)
+	}
+	sort.Sort(naturally(openPorts))
+	fmt.Fprintf(tw, "OPEN PORTS:\t%s\n", strings.Join(openPorts, ", "))
+
+	var networks []string
+	for name := range fs.Networks {
+		networks = append(networks, name)
+	}
+	sort.Strings(networks)
+	for i, name := range networks {
+		if cidr := fs.Networks[name].CIDR; cidr != "" {
+			networks[i] = fmt.Sprintf("%s (%s)", name, cidr)
+		}
+	}
+	fmt.Fprintf(tw, "NETWORKS:\t%s\n", strings.Join(networks, ", "))
+	tw.Flush()
+	return buf.Bytes(), nil
+}
+
+// summaryState returns the state under which an entity
+// is counted by formatStatusSummary.
+func summaryState(agentState string, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case agentState == "":
+		return "unknown"
+	}
+	return agentState
+}
+
+// formatStatusOneline returns a line for each unit, giving its
+// public address, agent state and opened ports. Subordinate units
+// are indented under their principals.
+func formatStatusOneline(value interface{}) ([]byte, error) {
+	fs, ok := value.(formattedStatus)
+	if !ok {
+		return nil, fmt.Errorf("expected value of type %T, got %T", fs, value)
+	}
+	var buf bytes.Buffer
+	var printUnits func(units map[string]unitStatus, indent string)
+	printUnits = func(units map[string]unitStatus, indent string) {
+		for _, name := range sortedUnitNames(units) {
+			u := units[name]
+			if u.Err != nil {
+				fmt.Fprintf(&buf, "%s- %s: error: %v\n", indent, name, u.Err)
+				continue
+			}
+			fmt.Fprintf(&buf, "%s- %s:", indent, name)
+			if u.PublicAddress != "" {
+				fmt.Fprintf(&buf, " %s", u.PublicAddress)
+			}
+			fmt.Fprintf(&buf, " (%s)", u.AgentState)
+			if len(u.OpenedPorts) > 0 {
+				fmt.Fprintf(&buf, " %s", strings.Join(u.OpenedPorts, ","))
+			}
+			fmt.Fprintln(&buf)
+			printUnits(u.Subordinates, indent+"  ")
+		}
+	}
+	for _, name := range sortedServiceNames(fs.Services) {
+		printUnits(fs.Services[name].Units, "")
+	}
+	return buf.Bytes(), nil
+}
+
+func sortedServiceNames(services map[string]serviceStatus) []string {
+	var names []string
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedUnitNames(units map[string]unitStatus) []string {
+	var names []string
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Sort(naturally(names))
+	return names
+}
+
+func sortedMachineIds(machines map[string]machineStatus) []string {
+	var ids []string
+	for id := range machines {
+		ids = append(ids, id)
+	}
+	sort.Sort(naturally(ids))
+	return ids
+}
+
+// naturally sorts strings made of slash-separated parts, such as
+// machine ids, unit names and ports, comparing numeric parts by
+// value so that "2" sorts before "10".
+type naturally []string
+
+func (n naturally) Len() int      { return len(n) }
+func (n naturally) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+
+func (n naturally) Less(i, j int) bool {
+	a := strings.Split(n[i], "/")
+	b := strings.Split(n[j], "/")
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] == b[k] {
+			continue
+		}
+		an, aErr := strconv.Atoi(a[k])
+		bn, bErr := strconv.Atoi(b[k])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return a[k] < b[k]
+	}
+	return len(a) < len(b)
+}
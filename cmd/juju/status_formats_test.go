@@ -0,0 +1,201 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/instance"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type StatusFormatsSuite struct {
+	coretesting.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&StatusFormatsSuite{})
+
+var testFormattedStatus = formattedStatus{
+	Environment: "dummyenv",
+	Machines: map[string]machineStatus{
+		"0": {
+			AgentState:   "started",
+			AgentVersion: "1.21.0",
+			DNSName:      "dummyenv-0.dns",
+			InstanceId:   instance.Id("dummyenv-0"),
+			Series:       "trusty",
+			Hardware:     "arch=amd64",
+		},
+		"1": {
+			AgentState:   "started",
+			AgentVersion: "1.21.0",
+			DNSName:      "dummyenv-1.dns",
+			InstanceId:   instance.Id("dummyenv-1"),
+			Series:       "trusty",
+			Containers: map[string]machineStatus{
+				"1/lxc/0": {
+					AgentState: "pending",
+					Series:     "trusty",
+				},
+			},
+		},
+		"10": {
+			AgentState: "pending",
+			Series:     "trusty",
+		},
+	},
+	Services: map[string]serviceStatus{
+		"wordpress": {
+			Charm:   "cs:trusty/wordpress-3",
+			Exposed: true,
+			Units: map[string]unitStatus{
+				"wordpress/0": {
+					AgentState:    "started",
+					AgentVersion:  "1.21.0",
+					Machine:       "1",
+					OpenedPorts:   []string{"80/tcp", "443/tcp"},
+					PublicAddress: "dummyenv-1.dns",
+					Subordinates: map[string]unitStatus{
+						"logging/0": {
+							AgentState:    "started",
+							AgentVersion:  "1.21.0",
+							PublicAddress: "dummyenv-1.dns",
+						},
+					},
+				},
+			},
+		},
+		"mysql": {
+			Charm: "cs:trusty/mysql-1",
+			Units: map[string]unitStatus{
+				"mysql/0": {
+					AgentState:     "error",
+					AgentStateInfo: `hook failed: "install"`,
+					Machine:        "1/lxc/0",
+				},
+			},
+		},
+		"logging": {
+			Charm: "cs:trusty/logging-1",
+		},
+	},
+	Networks: map[string]networkStatus{
+		"net1": {CIDR: "10.0.0.0/24"},
+	},
+}
+
+func (s *StatusFormatsSuite) TestTabular(c *gc.C) {
+	out, err := formatStatusTabular(testFormattedStatus)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"[Services]\n"+
+		"NAME       EXPOSED  CHARM\n"+
+		"logging    false    cs:trusty/logging-1\n"+
+		"mysql      false    cs:trusty/mysql-1\n"+
+		"wordpress  true     cs:trusty/wordpress-3\n"+
+		"\n"+
+		"[Units]\n"+
+		"ID           STATE    VERSION  MACHINE  PORTS           PUBLIC-ADDRESS  INFO\n"+
+		"mysql/0      error             1/lxc/0                                  hook failed: \"install\"\n"+
+		"wordpress/0  started  1.21.0   1        80/tcp,443/tcp  dummyenv-1.dns  \n"+
+		"  logging/0  started  1.21.0                            dummyenv-1.dns  \n"+
+		"\n"+
+		"[Machines]\n"+
+		"ID       STATE    VERSION  DNS             INSTANCE-ID  SERIES  HARDWARE\n"+
+		"0        started  1.21.0   dummyenv-0.dns  dummyenv-0   trusty  arch=amd64\n"+
+		"1        started  1.21.0   dummyenv-1.dns  dummyenv-1   trusty  \n"+
+		"1/lxc/0  pending                                        trusty  \n"+
+		"10       pending                                        trusty  \n",
+	)
+}
+
+func (s *StatusFormatsSuite) TestSummary(c *gc.C) {
+	out, err := formatStatusSummary(testFormattedStatus)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"MACHINES:    4\n"+
+		"  pending:   2\n"+
+		"  started:   2\n"+
+		"UNITS:       3\n"+
+		"  error:     1\n"+
+		"  started:   2\n"+
+		"SERVICES:    3\n"+
+		"  exposed:   1\n"+
+		"OPEN PORTS:  80/tcp, 443/tcp\n"+
+		"NETWORKS:    net1 (10.0.0.0/24)\n",
+	)
+}
+
+func (s *StatusFormatsSuite) TestOneline(c *gc.C) {
+	out, err := formatStatusOneline(testFormattedStatus)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"- mysql/0: (error)\n"+
+		"- wordpress/0: dummyenv-1.dns (started) 80/tcp,443/tcp\n"+
+		"  - logging/0: dummyenv-1.dns (started)\n",
+	)
+}
+
+func (s *StatusFormatsSuite) TestErrors(c *gc.C) {
+	fs := formattedStatus{
+		Machines: map[string]machineStatus{
+			"0": {Err: errors.New("boom")},
+		},
+		Services: map[string]serviceStatus{
+			"mysql": {
+				Units: map[string]unitStatus{
+					"mysql/0": {Err: errors.New("bang")},
+				},
+			},
+		},
+	}
+	out, err := formatStatusOneline(fs)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, "- mysql/0: error: bang\n")
+	out, err = formatStatusSummary(fs)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Matches, "MACHINES:    1\n  error:     1\nUNITS:       1\n  error:     1\n(.|\n)*")
+}
+
+func (s *StatusFormatsSuite) TestUnexpectedValue(c *gc.C) {
+	for _, format := range []func(interface{}) ([]byte, error){
+		formatStatusTabular, formatStatusSummary, formatStatusOneline,
+	} {
+		_, err := format("foo")
+		c.Check(err, gc.ErrorMatches, "expected value of type main.formattedStatus, got string")
+	}
+}
+
+func (s *StatusFormatsSuite) TestStatusCommandFormats(c *gc.C) {
+	client := newFakeApiClient(&api.Status{
+		EnvironmentName: "dummyenv",
+		Services: map[string]api.ServiceStatus{
+			"mysql": {
+				Charm: "cs:trusty/mysql-1",
+				Units: map[string]api.UnitStatus{
+					"mysql/0": {
+						AgentState:    "started",
+						PublicAddress: "dummyenv-1.dns",
+					},
+				},
+			},
+		},
+	})
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&StatusCommand{}), "--format", "oneline", "mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Equals, "- mysql/0: dummyenv-1.dns (started)\n")
+	c.Assert(client.patternsUsed, gc.DeepEquals, []string{"mysql"})
+
+	for _, format := range []string{"tabular", "summary"} {
+		_, err := coretesting.RunCommand(c, envcmd.Wrap(&StatusCommand{}), "--format", format)
+		c.Assert(err, gc.IsNil)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// StorageGCAPI holds the API calls made by the storage-gc command.
+type StorageGCAPI interface {
+	StorageGC(dryRun bool) ([]params.StorageGCItem, error)
+	Close() error
+}
+
+var getStorageGCAPI = func(c *envcmd.EnvCommandBase) (StorageGCAPI, error) {
+	return c.NewAPIClient()
+}
+
+// StorageGCCommand removes charm archives and tools tarballs that
+// are no longer used in the environment.
+type StorageGCCommand struct {
+	envcmd.EnvCommandBase
+	DryRun bool
+}
+
+const storageGCDoc = `
+Remove the charm archives and tools tarballs that are no longer used
+in the environment, reporting each one removed and the space reclaimed.
+
+A charm is unused when no service or unit refers to it, and it has been
+in the environment for at least a day. Tools are unused when they are
+older than the environment's agent version and no agent runs them.
+The state server also removes unused items periodically.
+
+With --dry-run, the unused items are only reported.
+`
+
+func (c *StorageGCCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "storage-gc",
+		Purpose: "remove unused charms and tools from environment storage",
+		Doc:     storageGCDoc,
+	}
+}
+
+func (c *StorageGCCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.DryRun, "dry-run", false, "report unused items without removing them")
+}
+
+func (c *StorageGCCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *StorageGCCommand) Run(ctx *cmd.Context) error {
+	client, err := getStorageGCAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	items, err := client.StorageGC(c.DryRun)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(ctx.Stdout, "no unused items found")
+		return nil
+	}
+	tw := tabwriter.NewWriter(ctx.Stdout, 0, 1, 1, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME\tSIZE")
+	var total int64
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", item.Kind, item.Name, formatSize(item.Size))
+		total += item.Size
+	}
+	tw.Flush()
+	if c.DryRun {
+		fmt.Fprintf(ctx.Stdout, "%s reclaimable\n", formatSize(total))
+	} else {
+		fmt.Fprintf(ctx.Stdout, "%s reclaimed\n", formatSize(total))
+	}
+	return nil
+}
+
+// formatSize formats a size in bytes using binary prefixes.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, prefix := float64(size)/unit, "KMGTPE"
+	for value >= unit && len(prefix) > 1 {
+		value, prefix = value/unit, prefix[1:]
+	}
+	return fmt.Sprintf("%.1f%ciB", value, prefix[0])
+}
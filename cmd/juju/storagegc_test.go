@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type StorageGCSuite struct {
+	testing.FakeJujuHomeSuite
+	api *fakeStorageGCAPI
+}
+
+var _ = gc.Suite(&StorageGCSuite{})
+
+type fakeStorageGCAPI struct {
+	dryRun []bool
+	items  []params.StorageGCItem
+}
+
+func (f *fakeStorageGCAPI) StorageGC(dryRun bool) ([]params.StorageGCItem, error) {
+	f.dryRun = append(f.dryRun, dryRun)
+	return f.items, nil
+}
+
+func (*fakeStorageGCAPI) Close() error {
+	return nil
+}
+
+func (s *StorageGCSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.api = &fakeStorageGCAPI{
+		items: []params.StorageGCItem{
+			{Kind: "charm", Name: "cs:precise/mysql-1", Size: 3 * 1024 * 1024},
+			{Kind: "tools", Name: "1.18.0-precise-amd64", Size: 512},
+		},
+	}
+	s.PatchValue(&getStorageGCAPI, func(*envcmd.EnvCommandBase) (StorageGCAPI, error) {
+		return s.api, nil
+	})
+}
+
+func (s *StorageGCSuite) TestDryRun(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&StorageGCCommand{}), "--dry-run")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.dryRun, gc.DeepEquals, []bool{true})
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"KIND  NAME                 SIZE\n"+
+		"charm cs:precise/mysql-1   3.0MiB\n"+
+		"tools 1.18.0-precise-amd64 512B\n"+
+		"3.0MiB reclaimable\n")
+}
+
+func (s *StorageGCSuite) TestRemove(c *gc.C) {
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&StorageGCCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.dryRun, gc.DeepEquals, []bool{false})
+	c.Assert(testing.Stdout(ctx), gc.Matches, "(.|\n)*3.0MiB reclaimed\n")
+}
+
+func (s *StorageGCSuite) TestNothingUnused(c *gc.C) {
+	s.api.items = nil
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&StorageGCCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "no unused items found\n")
+}
+
+func (s *StorageGCSuite) TestInitErrors(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&StorageGCCommand{}), []string{"foo"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
+
+func (s *StorageGCSuite) TestFormatSize(c *gc.C) {
+	for _, t := range []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{5 * 1024 * 1024 * 1024, "5.0GiB"},
+	} {
+		c.Check(formatSize(t.size), gc.Equals, t.want)
+	}
+}
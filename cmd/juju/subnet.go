@@ -0,0 +1,157 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const subnetCommandDoc = `
+"juju subnet" is used to manage the subnets known to juju. Subnets can
+be grouped into spaces with "juju space create".
+`
+
+// NewSubnetCommand returns the "juju subnet" super-command.
+func NewSubnetCommand() cmd.Command {
+	subnetcmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "subnet",
+		Doc:         subnetCommandDoc,
+		UsagePrefix: "juju",
+		Purpose:     "manage subnets",
+	})
+	subnetcmd.Register(envcmd.Wrap(&SubnetAddCommand{}))
+	subnetcmd.Register(envcmd.Wrap(&SubnetListCommand{}))
+	return subnetcmd
+}
+
+// SubnetAPI holds the API calls made by the subnet commands.
+type SubnetAPI interface {
+	AddSubnet(cidr, providerId string, vlanTag int, zone string) error
+	ListSubnets() ([]params.SubnetInfo, error)
+	Close() error
+}
+
+var getSubnetAPI = func(c *envcmd.EnvCommandBase) (SubnetAPI, error) {
+	return c.NewAPIClient()
+}
+
+const subnetAddCommandDoc = `
+Add a subnet to the environment, so that it can be added to a space.
+The subnet is identified by its CIDR; its provider id, VLAN tag and
+availability zone may be given if known.
+
+Examples:
+  juju subnet add 10.0.1.0/24
+  juju subnet add 10.0.2.0/24 --provider-id subnet-1234 --zone us-east-1b
+`
+
+// SubnetAddCommand adds a subnet to the environment.
+type SubnetAddCommand struct {
+	envcmd.EnvCommandBase
+	CIDR       string
+	ProviderId string
+	VLANTag    int
+	Zone       string
+}
+
+func (c *SubnetAddCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<CIDR>",
+		Purpose: "add a subnet",
+		Doc:     subnetAddCommandDoc,
+	}
+}
+
+func (c *SubnetAddCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.ProviderId, "provider-id", "", "the provider's id for the subnet")
+	f.IntVar(&c.VLANTag, "vlan-tag", 0, "the subnet's VLAN tag, or 0 if it is not a VLAN")
+	f.StringVar(&c.Zone, "zone", "", "the availability zone the subnet is in")
+}
+
+func (c *SubnetAddCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no CIDR specified")
+	}
+	c.CIDR = args[0]
+	if _, _, err := net.ParseCIDR(c.CIDR); err != nil {
+		return fmt.Errorf("invalid CIDR %q", c.CIDR)
+	}
+	if c.VLANTag < 0 || c.VLANTag > 4094 {
+		return fmt.Errorf("invalid VLAN tag %d: must be between 0 and 4094", c.VLANTag)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *SubnetAddCommand) Run(ctx *cmd.Context) error {
+	client, err := getSubnetAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.AddSubnet(c.CIDR, c.ProviderId, c.VLANTag, c.Zone)
+}
+
+const subnetListCommandDoc = `
+List the subnets known to juju, with the space each belongs to.
+`
+
+// SubnetListCommand lists the subnets in the environment.
+type SubnetListCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+// subnetInfo is the output form of a subnet.
+type subnetInfo struct {
+	ProviderId string `yaml:"provider-id,omitempty" json:"provider-id,omitempty"`
+	VLANTag    int    `yaml:"vlan-tag,omitempty" json:"vlan-tag,omitempty"`
+	Zone       string `yaml:"zone,omitempty" json:"zone,omitempty"`
+	Space      string `yaml:"space,omitempty" json:"space,omitempty"`
+}
+
+func (c *SubnetListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "list subnets",
+		Doc:     subnetListCommandDoc,
+	}
+}
+
+func (c *SubnetListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *SubnetListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *SubnetListCommand) Run(ctx *cmd.Context) error {
+	client, err := getSubnetAPI(&c.EnvCommandBase)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	subnets, err := client.ListSubnets()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]subnetInfo)
+	for _, subnet := range subnets {
+		result[subnet.CIDR] = subnetInfo{
+			ProviderId: subnet.ProviderId,
+			VLANTag:    subnet.VLANTag,
+			Zone:       subnet.AvailabilityZone,
+			Space:      subnet.Space,
+		}
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/testing"
+)
+
+type SubnetCommandSuite struct {
+	testing.FakeJujuHomeSuite
+	mockAPI *mockSubnetAPI
+}
+
+var _ = gc.Suite(&SubnetCommandSuite{})
+
+func (s *SubnetCommandSuite) SetUpTest(c *gc.C) {
+	s.FakeJujuHomeSuite.SetUpTest(c)
+	s.mockAPI = &mockSubnetAPI{}
+	s.PatchValue(&getSubnetAPI, func(c *envcmd.EnvCommandBase) (SubnetAPI, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *SubnetCommandSuite) TestAddInitErrors(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no CIDR specified",
+	}, {
+		args: []string{"10.0.0.0"},
+		err:  `invalid CIDR "10.0.0.0"`,
+	}, {
+		args: []string{"10.0.0.0/24", "--vlan-tag", "4095"},
+		err:  "invalid VLAN tag 4095: must be between 0 and 4094",
+	}, {
+		args: []string{"10.0.0.0/24", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}} {
+		c.Logf("test %d", i)
+		err := testing.InitCommand(envcmd.Wrap(&SubnetAddCommand{}), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *SubnetCommandSuite) TestAdd(c *gc.C) {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SubnetAddCommand{}),
+		"10.0.1.0/24", "--provider-id", "subnet-1", "--vlan-tag", "42", "--zone", "zone1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.mockAPI.added, jc.DeepEquals, []params.AddSubnet{{
+		CIDR:             "10.0.1.0/24",
+		ProviderId:       "subnet-1",
+		VLANTag:          42,
+		AvailabilityZone: "zone1",
+	}})
+}
+
+func (s *SubnetCommandSuite) TestAddError(c *gc.C) {
+	s.mockAPI.err = errors.New("boom")
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SubnetAddCommand{}), "10.0.1.0/24")
+	c.Assert(err, gc.ErrorMatches, "boom")
+}
+
+func (s *SubnetCommandSuite) TestList(c *gc.C) {
+	s.mockAPI.subnets = []params.SubnetInfo{{
+		CIDR:             "10.0.1.0/24",
+		ProviderId:       "subnet-1",
+		AvailabilityZone: "zone1",
+		Space:            "dmz",
+	}, {
+		CIDR:    "10.0.2.0/24",
+		VLANTag: 42,
+	}}
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&SubnetListCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"10.0.1.0/24:\n"+
+		"  provider-id: subnet-1\n"+
+		"  zone: zone1\n"+
+		"  space: dmz\n"+
+		"10.0.2.0/24:\n"+
+		"  vlan-tag: 42\n")
+}
+
+type mockSubnetAPI struct {
+	added   []params.AddSubnet
+	subnets []params.SubnetInfo
+	err     error
+}
+
+func (m *mockSubnetAPI) AddSubnet(cidr, providerId string, vlanTag int, zone string) error {
+	m.added = append(m.added, params.AddSubnet{
+		CIDR:             cidr,
+		ProviderId:       providerId,
+		VLANTag:          vlanTag,
+		AvailabilityZone: zone,
+	})
+	return m.err
+}
+
+func (m *mockSubnetAPI) ListSubnets() ([]params.SubnetInfo, error) {
+	return m.subnets, m.err
+}
+
+func (m *mockSubnetAPI) Close() error {
+	return nil
+}
@@ -6,12 +6,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
 	"gopkg.in/juju/charm.v3"
 	"launchpad.net/gnuflag"
 
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/environs/config"
 )
@@ -24,6 +26,10 @@ type UpgradeCharmCommand struct {
 	RepoPath    string // defaults to JUJU_REPOSITORY
 	SwitchURL   string
 	Revision    int // defaults to -1 (latest)
+	BatchSize   int // defaults to 0 (all units at once)
+	Pause       bool
+	Resume      bool
+	Status      bool
 }
 
 const upgradeCharmDoc = `
@@ -62,6 +68,16 @@ would specify revision number 5 of the wordpress charm.
 Use of the --force flag is not generally recommended; units upgraded while in an
 error state will not have upgrade-charm hooks executed, and may cause unexpected
 behavior.
+
+By default all units are upgraded at once. The --batch-size flag starts a
+rolling upgrade instead, which upgrades that many units at a time. Each batch
+must upgrade successfully before the next one is started; if any unit fails,
+the upgrade is paused and the remaining units keep the old charm. New units
+added during the upgrade are deployed with the new charm.
+
+A rolling upgrade is controlled with the --pause, --resume and --status flags,
+given on their own with the service name. Before resuming an upgrade that was
+paused because a unit failed, resolve the unit's error with "juju resolved".
 `
 
 func (c *UpgradeCharmCommand) Info() *cmd.Info {
@@ -78,6 +94,10 @@ func (c *UpgradeCharmCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.RepoPath, "repository", os.Getenv("JUJU_REPOSITORY"), "local charm repository path")
 	f.StringVar(&c.SwitchURL, "switch", "", "crossgrade to a different charm")
 	f.IntVar(&c.Revision, "revision", -1, "explicit revision of current charm")
+	f.IntVar(&c.BatchSize, "batch-size", 0, "upgrade this many units at a time")
+	f.BoolVar(&c.Pause, "pause", false, "pause the service's rolling upgrade")
+	f.BoolVar(&c.Resume, "resume", false, "resume the service's paused rolling upgrade")
+	f.BoolVar(&c.Status, "status", false, "show the progress of the service's rolling upgrade")
 }
 
 func (c *UpgradeCharmCommand) Init(args []string) error {
@@ -95,6 +115,23 @@ func (c *UpgradeCharmCommand) Init(args []string) error {
 	if c.SwitchURL != "" && c.Revision != -1 {
 		return fmt.Errorf("--switch and --revision are mutually exclusive")
 	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+	var control []string
+	for flag, set := range map[string]bool{"pause": c.Pause, "resume": c.Resume, "status": c.Status} {
+		if set {
+			control = append(control, flag)
+		}
+	}
+	switch {
+	case len(control) > 1:
+		return fmt.Errorf("--pause, --resume and --status are mutually exclusive")
+	case len(control) == 1:
+		if c.Force || c.SwitchURL != "" || c.Revision != -1 || c.BatchSize != 0 {
+			return fmt.Errorf("--%s cannot be used with other flags", control[0])
+		}
+	}
 	return nil
 }
 
@@ -106,6 +143,19 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
+	switch {
+	case c.Pause:
+		return rollingUpgradeError(client.ServicePauseRollingUpgrade(c.ServiceName))
+	case c.Resume:
+		return rollingUpgradeError(client.ServiceResumeRollingUpgrade(c.ServiceName))
+	case c.Status:
+		status, err := client.ServiceRollingUpgradeStatus(c.ServiceName)
+		if err != nil {
+			return rollingUpgradeError(err)
+		}
+		printRollingUpgradeStatus(ctx, status)
+		return nil
+	}
 	oldURL, err := client.ServiceGetCharmURL(c.ServiceName)
 	if err != nil {
 		return err
@@ -164,5 +214,40 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 		return err
 	}
 
+	if c.BatchSize > 0 {
+		err := client.ServiceSetCharmRolling(c.ServiceName, addedURL.String(), c.Force, c.BatchSize)
+		return rollingUpgradeError(err)
+	}
 	return client.ServiceSetCharm(c.ServiceName, addedURL.String(), c.Force)
 }
+
+// rollingUpgradeError returns err, replacing the error returned by
+// API servers that do not support rolling upgrades with a clearer one.
+func rollingUpgradeError(err error) error {
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot upgrade in batches: not supported by the API server")
+	}
+	return err
+}
+
+func printRollingUpgradeStatus(ctx *cmd.Context, status params.RollingUpgradeStatus) {
+	fmt.Fprintf(ctx.Stdout, "service: %s\n", status.ServiceName)
+	fmt.Fprintf(ctx.Stdout, "charm: %s\n", status.CharmURL)
+	fmt.Fprintf(ctx.Stdout, "batch-size: %d\n", status.BatchSize)
+	fmt.Fprintf(ctx.Stdout, "status: %s\n", status.Status)
+	if status.Message != "" {
+		fmt.Fprintf(ctx.Stdout, "message: %s\n", status.Message)
+	}
+	for _, units := range []struct {
+		label string
+		names []string
+	}{
+		{"upgraded", status.Upgraded},
+		{"current batch", status.Batch},
+		{"pending", status.Pending},
+	} {
+		if len(units.names) > 0 {
+			fmt.Fprintf(ctx.Stdout, "%s: %s\n", units.label, strings.Join(units.names, ", "))
+		}
+	}
+}
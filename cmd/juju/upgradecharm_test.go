@@ -48,6 +48,17 @@ func (s *UpgradeCharmErrorsSuite) TestInvalidArgs(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["bar"\]`)
 }
 
+func (s *UpgradeCharmErrorsSuite) TestInvalidRollingArgs(c *gc.C) {
+	err := runUpgradeCharm(c, "foo", "--batch-size=-1")
+	c.Assert(err, gc.ErrorMatches, "--batch-size must be positive")
+	err = runUpgradeCharm(c, "foo", "--pause", "--resume")
+	c.Assert(err, gc.ErrorMatches, "--pause, --resume and --status are mutually exclusive")
+	err = runUpgradeCharm(c, "foo", "--status", "--force")
+	c.Assert(err, gc.ErrorMatches, "--status cannot be used with other flags")
+	err = runUpgradeCharm(c, "foo", "--resume", "--batch-size=2")
+	c.Assert(err, gc.ErrorMatches, "--resume cannot be used with other flags")
+}
+
 func (s *UpgradeCharmErrorsSuite) TestWithInvalidRepository(c *gc.C) {
 	charmtesting.Charms.ClonedDirPath(s.SeriesPath, "riak")
 	err := runDeploy(c, "local:riak", "riak")
@@ -217,3 +228,44 @@ func (s *UpgradeCharmSuccessSuite) TestSwitch(c *gc.C) {
 	c.Assert(curl.String(), gc.Equals, "local:precise/myriak-42")
 	s.assertLocalRevision(c, 42, myriakPath)
 }
+
+func (s *UpgradeCharmSuccessSuite) TestRollingUpgrade(c *gc.C) {
+	err := runUpgradeCharm(c, "riak", "--batch-size=2")
+	c.Assert(err, gc.IsNil)
+	curl := s.assertUpgraded(c, 8, false)
+	upgrade, err := s.riak.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.CharmURL(), gc.DeepEquals, curl)
+	c.Assert(upgrade.BatchSize(), gc.Equals, 2)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradeRunning)
+
+	err = runUpgradeCharm(c, "riak", "--pause")
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradePaused)
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&UpgradeCharmCommand{}), "riak", "--status")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"service: riak\n"+
+		"charm: local:precise/riak-8\n"+
+		"batch-size: 2\n"+
+		"status: paused\n",
+	)
+
+	err = runUpgradeCharm(c, "riak", "--resume")
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradeRunning)
+
+	// A plain upgrade abandons the rolling upgrade.
+	err = runUpgradeCharm(c, "riak")
+	c.Assert(err, gc.IsNil)
+	s.assertUpgraded(c, 9, false)
+	_, err = s.riak.RollingUpgrade()
+	c.Assert(err, gc.ErrorMatches, `rolling upgrade of service "riak" not found`)
+	err = runUpgradeCharm(c, "riak", "--status")
+	c.Assert(err, gc.ErrorMatches, `rolling upgrade of service "riak" not found`)
+}
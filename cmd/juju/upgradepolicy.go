@@ -0,0 +1,152 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+// SetUpgradePolicyCommand sets the charm upgrade policy of a service.
+type SetUpgradePolicyCommand struct {
+	envcmd.EnvCommandBase
+	ServiceName string
+	Policy      string
+	Window      string
+}
+
+const setUpgradePolicyDoc = `
+Set whether the charm of a service is upgraded automatically when a new
+revision is published in the charm store. The policy is one of:
+
+  never            the charm is only upgraded with "juju upgrade-charm"
+                   (the default)
+  notify           new revisions are shown by "juju status" as
+                   can-upgrade-to, but the charm is not upgraded
+  patch-revisions  the charm is upgraded to new revisions that change
+                   neither its relations nor its configuration options;
+                   other revisions are only shown by "juju status"
+  always           the charm is upgraded to every new revision
+
+Automatic upgrades follow the same path as "juju upgrade-charm", and
+wait for any rolling upgrade of the service to complete. The --window
+flag restricts them to start within a daily maintenance window, given
+in UTC as HH:MM-HH:MM; a window may span midnight. Automatic upgrades
+are listed by "juju auto-upgrades".
+
+Only services deployed from the charm store are upgraded automatically.
+`
+
+func (c *SetUpgradePolicyCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-upgrade-policy",
+		Args:    "<service> <policy>",
+		Purpose: "set whether a service's charm is upgraded automatically",
+		Doc:     setUpgradePolicyDoc,
+	}
+}
+
+func (c *SetUpgradePolicyCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.Window, "window", "", "daily maintenance window for automatic upgrades (HH:MM-HH:MM, UTC)")
+}
+
+func (c *SetUpgradePolicyCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no service name specified")
+	case 1:
+		return errors.New("no upgrade policy specified")
+	}
+	c.ServiceName, c.Policy = args[0], args[1]
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *SetUpgradePolicyCommand) Run(_ *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	err = client.ServiceSetUpgradePolicy(c.ServiceName, c.Policy, c.Window)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot set upgrade policy: not supported by the API server")
+	}
+	return err
+}
+
+// AutoUpgradesCommand lists the charm upgrades made automatically
+// because of services' upgrade policies.
+type AutoUpgradesCommand struct {
+	envcmd.EnvCommandBase
+	out         cmd.Output
+	ServiceName string
+}
+
+const autoUpgradesDoc = `
+List the charm upgrades made automatically because of the upgrade
+policies set with "juju set-upgrade-policy", oldest first. If a service
+is named, only its upgrades are listed.
+`
+
+func (c *AutoUpgradesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "auto-upgrades",
+		Args:    "[<service>]",
+		Purpose: "list automatic charm upgrades",
+		Doc:     autoUpgradesDoc,
+	}
+}
+
+func (c *AutoUpgradesCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *AutoUpgradesCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.ServiceName, args = args[0], args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// autoUpgrade holds an automatic charm upgrade as reported by
+// auto-upgrades.
+type autoUpgrade struct {
+	Service string `yaml:"service" json:"service"`
+	From    string `yaml:"from" json:"from"`
+	To      string `yaml:"to" json:"to"`
+	Policy  string `yaml:"policy" json:"policy"`
+	Time    string `yaml:"time" json:"time"`
+}
+
+func (c *AutoUpgradesCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	upgrades, err := client.AutoCharmUpgrades(c.ServiceName)
+	if params.IsCodeNotImplemented(err) {
+		return fmt.Errorf("cannot list automatic upgrades: not supported by the API server")
+	} else if err != nil {
+		return err
+	}
+	result := make([]autoUpgrade, len(upgrades))
+	for i, upgrade := range upgrades {
+		result[i] = autoUpgrade{
+			Service: upgrade.ServiceName,
+			From:    upgrade.From,
+			To:      upgrade.To,
+			Policy:  upgrade.Policy,
+			Time:    upgrade.Time.UTC().Format(time.RFC3339),
+		}
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+
+	goyaml "gopkg.in/yaml.v1"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type UpgradePolicySuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&UpgradePolicySuite{})
+
+func (s *UpgradePolicySuite) TestSetUpgradePolicyInit(c *gc.C) {
+	err := testing.InitCommand(&SetUpgradePolicyCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no service name specified")
+	err = testing.InitCommand(&SetUpgradePolicyCommand{}, []string{"wordpress"})
+	c.Assert(err, gc.ErrorMatches, "no upgrade policy specified")
+	err = testing.InitCommand(&SetUpgradePolicyCommand{}, []string{"wordpress", "always", "foo"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
+
+func (s *UpgradePolicySuite) TestSetUpgradePolicy(c *gc.C) {
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	_, err := testing.RunCommand(c, envcmd.Wrap(&SetUpgradePolicyCommand{}), "--window", "02:00-04:00", "wordpress", "always")
+	c.Assert(err, gc.IsNil)
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	policy, window := service.UpgradePolicy()
+	c.Assert(policy, gc.Equals, state.UpgradeAlways)
+	c.Assert(window.String(), gc.Equals, "02:00-04:00")
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&SetUpgradePolicyCommand{}), "wordpress", "sometimes")
+	c.Assert(err, gc.ErrorMatches, `charm upgrade policy "sometimes" not valid`)
+}
+
+func (s *UpgradePolicySuite) TestAutoUpgrades(c *gc.C) {
+	oldCharm := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", oldCharm)
+	newCharm := s.AddMetaCharm(c, "wordpress", "name: wordpress\nsummary: blog\ndescription: blog\n", 42)
+	err := service.SetUpgradePolicy(state.UpgradePatchRevisions, nil)
+	c.Assert(err, gc.IsNil)
+	err = service.SetCharmAutomatically(newCharm)
+	c.Assert(err, gc.IsNil)
+
+	for _, args := range [][]string{nil, {"wordpress"}} {
+		ctx, err := testing.RunCommand(c, envcmd.Wrap(&AutoUpgradesCommand{}), args...)
+		c.Assert(err, gc.IsNil)
+		var upgrades []autoUpgrade
+		err = goyaml.Unmarshal(ctx.Stdout.(*bytes.Buffer).Bytes(), &upgrades)
+		c.Assert(err, gc.IsNil)
+		c.Assert(upgrades, gc.HasLen, 1)
+		c.Assert(upgrades[0].Time, gc.Not(gc.Equals), "")
+		upgrades[0].Time = ""
+		c.Assert(upgrades[0], gc.Equals, autoUpgrade{
+			Service: "wordpress",
+			From:    oldCharm.String(),
+			To:      newCharm.String(),
+			Policy:  "patch-revisions",
+		})
+	}
+}
@@ -115,6 +115,14 @@ func (a *AgentConf) SetAPIHostPorts(servers [][]network.HostPort) error {
 	})
 }
 
+// SetCACert satisfies worker/caupdater/CACertSetter.
+func (a *AgentConf) SetCACert(caCert string) error {
+	return a.ChangeConfig(func(c agent.ConfigSetter) error {
+		c.SetCACert(caCert)
+		return nil
+	})
+}
+
 func importance(err error) int {
 	switch {
 	case err == nil:
@@ -343,6 +351,15 @@ func (c *closeWorker) Kill() {
 	c.worker.Kill()
 }
 
+// Report implements worker.Reporter by reporting on the
+// wrapped worker, if it can.
+func (c *closeWorker) Report() []worker.WorkerReport {
+	if reporter, ok := c.worker.(worker.Reporter); ok {
+		return reporter.Report()
+	}
+	return nil
+}
+
 func (c *closeWorker) Wait() error {
 	err := c.worker.Wait()
 	if err := c.closer.Close(); err != nil {
@@ -279,6 +279,9 @@ func (s *agentSuite) SetUpSuite(c *gc.C) {
 	s.PatchValue(&ensureMongoServer, func(mongo.EnsureServerParams) error {
 		return nil
 	})
+	s.PatchValue(&updateMongoSSLKey, func(dataDir, namespace, cert, privateKey string) error {
+		return nil
+	})
 }
 
 func (s *agentSuite) TearDownSuite(c *gc.C) {
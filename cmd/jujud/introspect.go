@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/introspection"
+)
+
+// IntrospectCommand queries the introspection socket of an agent
+// running on the local machine.
+type IntrospectCommand struct {
+	cmd.CommandBase
+	agent string
+	query string
+}
+
+const introspectCommandDoc = `
+Ask an agent running on this machine about its current state.
+
+agent-name can be either the agent's tag:
+ i.e.  machine-0, unit-ubuntu-0
+or a machine or unit id:
+ i.e.  0, ubuntu/0
+
+The query may be one of:
+ workers     the agent's workers, their restart counts and last errors
+ goroutines  a dump of the agent's goroutines
+ logging     the agent's logging configuration
+
+If no query is specified, workers is assumed.
+`
+
+// Info returns usage information for the command.
+func (c *IntrospectCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "juju-introspect",
+		Args:    "<agent-name> [" + strings.Join(introspection.Queries, "|") + "]",
+		Purpose: "query a running agent",
+		Doc:     introspectCommandDoc,
+	}
+}
+
+func (c *IntrospectCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("missing agent-name")
+	}
+	c.agent, args = args[0], args[1:]
+	// Agent directories on disk are named by tag, so convert
+	// machine and unit ids.
+	switch {
+	case names.IsValidMachine(c.agent):
+		c.agent = names.NewMachineTag(c.agent).String()
+	case names.IsValidUnit(c.agent):
+		c.agent = names.NewUnitTag(c.agent).String()
+	}
+	tag, err := names.ParseTag(c.agent)
+	if err != nil {
+		return err
+	}
+	if kind := tag.Kind(); kind != names.MachineTagKind && kind != names.UnitTagKind {
+		return fmt.Errorf("%q is not a machine or unit agent", c.agent)
+	}
+	c.query = introspection.QueryWorkers
+	if len(args) > 0 {
+		c.query, args = args[0], args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *IntrospectCommand) Run(ctx *cmd.Context) error {
+	tag, err := names.ParseTag(c.agent)
+	if err != nil {
+		return err
+	}
+	socketPath := introspection.SocketPath(filepath.Join(AgentDir, c.agent), tag)
+	result, err := introspection.Query(socketPath, c.query)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(ctx.Stdout, result)
+	return nil
+}
+
+// startIntrospection starts a worker on the given runner that
+// answers introspection queries about the runner's workers.
+func startIntrospection(runner worker.Runner, dataDir string, tag names.Tag) {
+	reporter, ok := runner.(worker.Reporter)
+	if !ok {
+		logger.Debugf("runner does not report on its workers, not starting introspection")
+		return
+	}
+	socketPath := introspection.SocketPath(agent.Dir(dataDir, tag), tag)
+	runner.StartWorker("introspection", func() (worker.Worker, error) {
+		return introspection.NewWorker(reporter, socketPath)
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/introspection"
+)
+
+type IntrospectSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&IntrospectSuite{})
+
+func (*IntrospectSuite) TestArgs(c *gc.C) {
+	for i, test := range []struct {
+		title    string
+		args     []string
+		errMatch string
+		agent    string
+		query    string
+	}{{
+		title:    "no args",
+		errMatch: "missing agent-name",
+	}, {
+		title: "agent tag",
+		args:  []string{"unit-foo-1"},
+		agent: "unit-foo-1",
+		query: "workers",
+	}, {
+		title: "unit id converted to tag",
+		args:  []string{"foo/1", "goroutines"},
+		agent: "unit-foo-1",
+		query: "goroutines",
+	}, {
+		title: "machine id converted to tag",
+		args:  []string{"0", "logging"},
+		agent: "machine-0",
+		query: "logging",
+	}, {
+		title:    "not an agent",
+		args:     []string{"service-foo"},
+		errMatch: `"service-foo" is not a machine or unit agent`,
+	}, {
+		title:    "more than two args",
+		args:     []string{"0", "workers", "baz"},
+		errMatch: `unrecognized args: \["baz"\]`,
+	}} {
+		c.Logf("\n%d: %s", i, test.title)
+		command := &IntrospectCommand{}
+		err := testing.InitCommand(command, test.args)
+		if test.errMatch == "" {
+			c.Assert(err, gc.IsNil)
+			c.Assert(command.agent, gc.Equals, test.agent)
+			c.Assert(command.query, gc.Equals, test.query)
+		} else {
+			c.Assert(err, gc.ErrorMatches, test.errMatch)
+		}
+	}
+}
+
+func (s *IntrospectSuite) TestNoAgent(c *gc.C) {
+	s.PatchValue(&AgentDir, c.MkDir())
+	_, err := testing.RunCommand(c, &IntrospectCommand{}, "foo/1")
+	c.Assert(err, gc.ErrorMatches, "cannot connect to agent: .*")
+}
+
+func (s *IntrospectSuite) TestQueryWorkers(c *gc.C) {
+	s.PatchValue(&AgentDir, c.MkDir())
+	agentDir := filepath.Join(AgentDir, "unit-foo-1")
+	err := os.Mkdir(agentDir, 0755)
+	c.Assert(err, gc.IsNil)
+
+	runner := worker.NewRunner(isFatal, moreImportant)
+	defer worker.Stop(runner)
+	w, err := introspection.NewWorker(runner.(worker.Reporter), filepath.Join(agentDir, introspection.SocketFile))
+	c.Assert(err, gc.IsNil)
+	defer worker.Stop(w)
+
+	ctx, err := testing.RunCommand(c, &IntrospectCommand{}, "foo/1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "WORKER  STATE  RESTARTS  LAST ERROR\n")
+}
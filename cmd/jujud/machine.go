@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -42,11 +43,15 @@ import (
 	"github.com/juju/juju/service"
 	"github.com/juju/juju/service/common"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/storagegc"
 	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
 	"github.com/juju/juju/worker/authenticationworker"
+	"github.com/juju/juju/worker/autoscaler"
+	"github.com/juju/juju/worker/caupdater"
+	"github.com/juju/juju/worker/certupdater"
 	"github.com/juju/juju/worker/charmrevisionworker"
 	"github.com/juju/juju/worker/cleaner"
 	"github.com/juju/juju/worker/deployer"
@@ -61,8 +66,13 @@ import (
 	"github.com/juju/juju/worker/peergrouper"
 	"github.com/juju/juju/worker/provisioner"
 	"github.com/juju/juju/worker/resumer"
+	"github.com/juju/juju/worker/rollingupgradeworker"
 	"github.com/juju/juju/worker/rsyslog"
+	"github.com/juju/juju/worker/runjobcleaner"
+	"github.com/juju/juju/worker/runtaskworker"
+	"github.com/juju/juju/worker/selfhealer"
 	"github.com/juju/juju/worker/singular"
+	"github.com/juju/juju/worker/storagegcworker"
 	"github.com/juju/juju/worker/terminationworker"
 	"github.com/juju/juju/worker/upgrader"
 )
@@ -79,11 +89,13 @@ type eitherState interface{}
 var (
 	retryDelay      = 3 * time.Second
 	jujuRun         = paths.MustSucceed(paths.JujuRun(version.Current.Series))
+	jujuIntrospect  = paths.MustSucceed(paths.JujuIntrospect(version.Current.Series))
 	useMultipleCPUs = utils.UseMultipleCPUs
 
 	// The following are defined as variables to
 	// allow the tests to intercept calls to the functions.
 	ensureMongoServer        = mongo.EnsureServer
+	updateMongoSSLKey        = mongo.UpdateSSLKey
 	maybeInitiateMongoServer = peergrouper.MaybeInitiateMongoServer
 	ensureMongoAdminUser     = mongo.EnsureAdminUser
 	newSingularRunner        = singular.New
@@ -179,6 +191,7 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 	if err := a.createJujuRun(agentConfig.DataDir()); err != nil {
 		return fmt.Errorf("cannot create juju run symlink: %v", err)
 	}
+	startIntrospection(a.runner, agentConfig.DataDir(), a.Tag())
 	a.runner.StartWorker("api", a.APIWorker)
 	a.runner.StartWorker("statestarter", a.newStateStarterWorker)
 	a.runner.StartWorker("termination", func() (worker.Worker, error) {
@@ -332,6 +345,9 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	a.startWorkerAfterUpgrade(runner, "apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a), nil
 	})
+	a.startWorkerAfterUpgrade(runner, "caupdater", func() (worker.Worker, error) {
+		return caupdater.NewCACertUpdater(st.Machiner(), a), nil
+	})
 	a.startWorkerAfterUpgrade(runner, "logger", func() (worker.Worker, error) {
 		return workerlogger.NewLogger(st.Logger(), agentConfig), nil
 	})
@@ -341,6 +357,9 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	a.startWorkerAfterUpgrade(runner, "rsyslog", func() (worker.Worker, error) {
 		return newRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslogMode)
 	})
+	a.startWorkerAfterUpgrade(runner, "runtaskworker", func() (worker.Worker, error) {
+		return runtaskworker.NewRunTaskWorker(st.RunTasks(), names.NewMachineTag(a.MachineId)), nil
+	})
 	// TODO (mfoord 8/8/2014) improve the way we detect networking capabilities. Bug lp:1354365
 	writeNetworkConfig := providerType == "maas"
 	if disableNetworkManagement || !writeNetworkConfig {
@@ -522,6 +541,28 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(runner, "peergrouper", func() (worker.Worker, error) {
 				return peergrouperNew(st)
 			})
+			// The certificate updater sends the state server's new
+			// certificates to the API server, and notifies the mongo
+			// SSL key updater.
+			certChanged := make(chan params.StateServingInfo, 1)
+			sslKeyChanged := make(chan struct{}, 1)
+			a.startWorkerAfterUpgrade(runner, "certupdater", func() (worker.Worker, error) {
+				getter := func() (state.StateServingInfo, bool) {
+					return a.CurrentConfig().StateServingInfo()
+				}
+				return certupdater.NewCertificateUpdater(st, getter, a.stateServingInfoSetter(certChanged, sslKeyChanged)), nil
+			})
+			a.startWorkerAfterUpgrade(runner, "mongosslkey", func() (worker.Worker, error) {
+				// Check the certificate mongo uses whenever the
+				// worker starts.
+				select {
+				case sslKeyChanged <- struct{}{}:
+				default:
+				}
+				return worker.NewSimpleWorker(func(stop <-chan struct{}) error {
+					return a.updateMongoSSLKeys(st, sslKeyChanged, stop)
+				}), nil
+			})
 			runner.StartWorker("apiserver", func() (worker.Worker, error) {
 				// If the configuration does not have the required information,
 				// it is currently not a recoverable error, so we kill the whole
@@ -529,6 +570,10 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				// the agent's configuration file. In the future, we may retrieve
 				// the state server certificate and key from the state, and
 				// this should then change.
+				//
+				// The certificate may have been replaced since the
+				// state worker started, so use the current configuration.
+				agentConfig := a.CurrentConfig()
 				info, ok := agentConfig.StateServingInfo()
 				if !ok {
 					return nil, &fatalError{"StateServingInfo not available and we need it"}
@@ -548,11 +593,13 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 					return nil, err
 				}
 				return apiserver.NewServer(st, listener, apiserver.ServerConfig{
-					Cert:      cert,
-					Key:       key,
-					DataDir:   dataDir,
-					LogDir:    logDir,
-					Validator: a.limitLoginsDuringUpgrade,
+					Cert:        cert,
+					Key:         key,
+					DataDir:     dataDir,
+					LogDir:      logDir,
+					Validator:   a.limitLoginsDuringUpgrade,
+					RateLimit:   apiserver.DefaultRateLimitConfig(),
+					CertChanged: certChanged,
 				})
 			})
 			a.startWorkerAfterUpgrade(singularRunner, "cleaner", func() (worker.Worker, error) {
@@ -567,6 +614,21 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(singularRunner, "minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "storagegc", func() (worker.Worker, error) {
+				return storagegcworker.NewStorageGC(st, storagegc.DefaultGracePeriod), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "rollingupgrader", func() (worker.Worker, error) {
+				return rollingupgradeworker.NewRollingUpgradeWorker(st), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "runjobcleaner", func() (worker.Worker, error) {
+				return runjobcleaner.NewRunJobCleaner(st, runjobcleaner.DefaultMaxAge), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "autoscaler", func() (worker.Worker, error) {
+				return autoscaler.NewAutoscaler(st), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "selfhealer", func() (worker.Worker, error) {
+				return selfhealer.NewSelfHealer(st), nil
+			})
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
 		default:
@@ -576,6 +638,120 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	return newCloseWorker(runner, st), nil
 }
 
+// mongoRestartInterval holds the time between the restarts of the
+// mongo servers of the state servers when their certificates change,
+// so that the replica set keeps a majority of its members up.
+var mongoRestartInterval = time.Minute
+
+// mongoRestartDelay returns how long the mongo server of the given
+// state server waits before restarting to use a new certificate. The
+// state servers restart one after the other, in the same order on all
+// of them, except that the one hosting the primary restarts last.
+func mongoRestartDelay(st *state.State, machineId string) (time.Duration, error) {
+	info, err := st.StateServerInfo()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if len(info.MachineIds) < 2 {
+		return 0, nil
+	}
+	machine, err := st.Machine(machineId)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	isMaster, err := mongo.IsMaster(st.MongoSession(), machine)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if isMaster {
+		return time.Duration(len(info.MachineIds)) * mongoRestartInterval, nil
+	}
+	ids := append([]string(nil), info.MachineIds...)
+	sort.Strings(ids)
+	for i, id := range ids {
+		if id == machineId {
+			return time.Duration(i) * mongoRestartInterval, nil
+		}
+	}
+	return 0, nil
+}
+
+// stateServingInfoSetter returns a function that makes the state
+// server use the given state serving information: it is written to
+// the agent's configuration, the new certificate is sent on
+// certChanged for the API server, and sslKeyChanged is notified so
+// that mongo is given the certificate too.
+func (a *MachineAgent) stateServingInfoSetter(certChanged chan params.StateServingInfo, sslKeyChanged chan struct{}) certupdater.StateServingInfoSetter {
+	return func(info state.StateServingInfo) error {
+		if err := a.ChangeConfig(func(config agent.ConfigSetter) error {
+			config.SetStateServingInfo(info)
+			return nil
+		}); err != nil {
+			return err
+		}
+		// Replace any certificate the API server has not used yet.
+		select {
+		case <-certChanged:
+		default:
+		}
+		certChanged <- params.StateServingInfo{
+			Cert:       info.Cert,
+			PrivateKey: info.PrivateKey,
+		}
+		select {
+		case sslKeyChanged <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+}
+
+// updateMongoSSLKeys gives mongo the state server certificate from
+// the agent's configuration each time changed is notified, until stop
+// is closed. As mongo has to restart to use a new certificate, it
+// first waits for mongoRestartDelay, so that the state servers do not
+// all restart at once. The certificate is then recorded in state as
+// the one the state server uses, so that a CA rotation can complete.
+func (a *MachineAgent) updateMongoSSLKeys(st *state.State, changed <-chan struct{}, stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-changed:
+		}
+		agentConfig := a.CurrentConfig()
+		delay, err := mongoRestartDelay(st, agentConfig.Tag().Id())
+		if err != nil {
+			return err
+		}
+		if delay > 0 {
+			logger.Infof("waiting %v before giving mongo the new certificate", delay)
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(delay):
+			}
+		}
+		// The certificate may have changed again while waiting.
+		select {
+		case <-changed:
+		default:
+		}
+		agentConfig = a.CurrentConfig()
+		info, ok := agentConfig.StateServingInfo()
+		if !ok {
+			return errors.New("no state serving information available")
+		}
+		err = updateMongoSSLKey(agentConfig.DataDir(), agentConfig.Value(agent.Namespace), info.Cert, info.PrivateKey)
+		if err != nil {
+			return err
+		}
+		if err := st.RecordStateServerCertificate(agentConfig.Tag().Id(), info.Cert); err != nil {
+			return err
+		}
+	}
+}
+
 // stateWorkerDialOpts is a mongo.DialOpts suitable
 // for use by StateWorker to dial mongo.
 //
@@ -852,13 +1028,18 @@ func (a *MachineAgent) Tag() names.Tag {
 }
 
 func (a *MachineAgent) createJujuRun(dataDir string) error {
-	// TODO do not remove the symlink if it already points
+	// TODO do not remove the symlinks if they already point
 	// to the right place.
-	if err := os.Remove(jujuRun); err != nil && !os.IsNotExist(err) {
-		return err
-	}
 	jujud := filepath.Join(dataDir, "tools", a.Tag().String(), jujunames.Jujud)
-	return symlink.New(jujud, jujuRun)
+	for _, link := range []string{jujuRun, jujuIntrospect} {
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := symlink.New(jujud, link); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (a *MachineAgent) uninstallAgent(agentConfig agent.Config) error {
@@ -873,9 +1054,11 @@ func (a *MachineAgent) uninstallAgent(agentConfig agent.Config) error {
 			errors = append(errors, fmt.Errorf("cannot remove service %q: %v", agentServiceName, err))
 		}
 	}
-	// Remove the juju-run symlink.
-	if err := os.Remove(jujuRun); err != nil && !os.IsNotExist(err) {
-		errors = append(errors, err)
+	// Remove the juju-run and juju-introspect symlinks.
+	for _, link := range []string{jujuRun, jujuIntrospect} {
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			errors = append(errors, err)
+		}
 	}
 
 	namespace := agentConfig.Value(agent.Namespace)
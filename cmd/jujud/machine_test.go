@@ -31,6 +31,7 @@ import (
 	apirsyslog "github.com/juju/juju/api/rsyslog"
 	charmtesting "github.com/juju/juju/apiserver/charmrevisionupdater/testing"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/cert"
 	lxctesting "github.com/juju/juju/container/lxc/testing"
 	"github.com/juju/juju/environs/config"
 	envtesting "github.com/juju/juju/environs/testing"
@@ -84,7 +85,8 @@ func (s *commonMachineSuite) SetUpTest(c *gc.C) {
 	s.agentSuite.PatchValue(&charm.CacheDir, c.MkDir())
 	s.agentSuite.PatchValue(&stateWorkerDialOpts, mongo.DialOpts{})
 
-	os.Remove(jujuRun) // ignore error; may not exist
+	os.Remove(jujuRun)        // ignore error; may not exist
+	os.Remove(jujuIntrospect) // ignore error; may not exist
 	// Patch ssh user to avoid touching ~ubuntu/.ssh/authorized_keys.
 	s.agentSuite.PatchValue(&authenticationworker.SSHUser, "")
 
@@ -404,12 +406,17 @@ func (s *MachineSuite) TestManageEnviron(c *gc.C) {
 	}
 
 	c.Assert(s.singularRecord.started(), jc.DeepEquals, []string{
+		"autoscaler",
 		"charm-revision-updater",
 		"cleaner",
 		"environ-provisioner",
 		"firewaller",
 		"minunitsworker",
 		"resumer",
+		"rollingupgrader",
+		"runjobcleaner",
+		"selfhealer",
+		"storagegc",
 	})
 }
 
@@ -892,6 +899,16 @@ func (s *MachineSuite) TestMachineAgentSymlinkJujuRunExists(c *gc.C) {
 	})
 }
 
+func (s *MachineSuite) TestMachineAgentSymlinkJujuIntrospect(c *gc.C) {
+	_, err := os.Stat(jujuIntrospect)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+	s.assertJobWithAPI(c, state.JobManageEnviron, func(conf agent.Config, st *api.State) {
+		// juju-introspect should have been created
+		_, err := os.Stat(jujuIntrospect)
+		c.Assert(err, gc.IsNil)
+	})
+}
+
 func (s *MachineSuite) TestMachineEnvironWorker(c *gc.C) {
 	proxyDir := c.MkDir()
 	s.agentSuite.PatchValue(&machineenvironmentworker.ProxyDirectory, proxyDir)
@@ -992,6 +1009,73 @@ func (s *MachineSuite) TestMachineAgentRunsAPIAddressUpdaterWorker(c *gc.C) {
 	c.Fatalf("timeout while waiting for agent config to change")
 }
 
+func (s *MachineSuite) TestMachineAgentRunsCACertUpdaterWorker(c *gc.C) {
+	// Start the machine agent.
+	m, _, _ := s.primeAgent(c, version.Current, state.JobHostUnits)
+	a := s.newAgent(c, m)
+	go func() { c.Check(a.Run(nil), gc.IsNil) }()
+	defer func() { c.Check(a.Stop(), gc.IsNil) }()
+
+	// Trust another CA.
+	newCACert, _, err := cert.NewCA("new", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	caCert := coretesting.CACert + newCACert
+	err = s.BackingState.UpdateEnvironConfig(map[string]interface{}{
+		"ca-cert": caCert,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+
+	// Wait for config to be updated.
+	s.BackingState.StartSync()
+	for attempt := coretesting.LongAttempt.Start(); attempt.Next(); {
+		if a.CurrentConfig().CACert() == caCert {
+			return
+		}
+	}
+	c.Fatalf("timeout while waiting for agent config to change")
+}
+
+func (s *MachineSuite) TestUpdateMongoSSLKeys(c *gc.C) {
+	m, _, _ := s.primeAgent(c, version.Current, state.JobManageEnviron)
+	a := s.newAgent(c, m)
+	updated := make(chan string, 1)
+	s.agentSuite.PatchValue(&updateMongoSSLKey, func(dataDir, namespace, cert, privateKey string) error {
+		updated <- cert
+		return nil
+	})
+	info, ok := a.CurrentConfig().StateServingInfo()
+	c.Assert(ok, jc.IsTrue)
+
+	changed := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- a.updateMongoSSLKeys(s.BackingState, changed, stop)
+	}()
+	changed <- struct{}{}
+	select {
+	case cert := <-updated:
+		c.Assert(cert, gc.Equals, info.Cert)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("mongo SSL key not updated")
+	}
+
+	close(stop)
+	select {
+	case err := <-done:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("mongo SSL key updater did not stop")
+	}
+}
+
+func (s *MachineSuite) TestMongoRestartDelaySingleStateServer(c *gc.C) {
+	m, _, _ := s.primeAgent(c, version.Current, state.JobManageEnviron)
+	delay, err := mongoRestartDelay(s.BackingState, m.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(delay, gc.Equals, time.Duration(0))
+}
+
 func (s *MachineSuite) TestMachineAgentRunsSafeNetworkerWhenNetworkManagementIsDisabled(c *gc.C) {
 	attrs := coretesting.Attrs{"disable-network-management": true}
 	err := s.BackingState.UpdateEnvironConfig(attrs, nil, nil)
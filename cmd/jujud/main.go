@@ -13,11 +13,9 @@ import (
 
 	"github.com/juju/cmd"
 	"github.com/juju/loggo"
-	"github.com/juju/utils/exec"
 
 	jujucmd "github.com/juju/juju/cmd"
 	"github.com/juju/juju/juju/names"
-	"github.com/juju/juju/juju/sockets"
 	// Import the providers.
 	_ "github.com/juju/juju/provider/all"
 	"github.com/juju/juju/worker/uniter/jujuc"
@@ -43,54 +41,11 @@ func getenv(name string) (string, error) {
 	return value, nil
 }
 
-func getwd() (string, error) {
-	dir, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	abs, err := filepath.Abs(dir)
-	if err != nil {
-		return "", err
-	}
-	return abs, nil
-}
-
 // jujuCMain uses JUJU_CONTEXT_ID and JUJU_AGENT_SOCKET to ask a running unit agent
 // to execute a Command on our behalf. Individual commands should be exposed
 // by symlinking the command name to this executable.
 func jujuCMain(commandName string, args []string) (code int, err error) {
-	code = 1
-	contextId, err := getenv("JUJU_CONTEXT_ID")
-	if err != nil {
-		return
-	}
-	dir, err := getwd()
-	if err != nil {
-		return
-	}
-	req := jujuc.Request{
-		ContextId:   contextId,
-		Dir:         dir,
-		CommandName: commandName,
-		Args:        args[1:],
-	}
-	socketPath, err := getenv("JUJU_AGENT_SOCKET")
-	if err != nil {
-		return
-	}
-	client, err := sockets.Dial(socketPath)
-	if err != nil {
-		return
-	}
-	defer client.Close()
-	var resp exec.ExecResponse
-	err = client.Call("Jujuc.Main", req, &resp)
-	if err != nil {
-		return
-	}
-	os.Stdout.Write(resp.Stdout)
-	os.Stderr.Write(resp.Stderr)
-	return resp.Code, nil
+	return jujuc.ClientMain(commandName, args[1:], os.Stdout, os.Stderr)
 }
 
 // Main registers subcommands for the jujud executable, and hands over control
@@ -126,6 +81,8 @@ func Main(args []string) {
 		err = fmt.Errorf("jujuc should not be called directly")
 	} else if commandName == names.JujuRun {
 		code = cmd.Main(&RunCommand{}, ctx, args[1:])
+	} else if commandName == names.JujuIntrospect {
+		code = cmd.Main(&IntrospectCommand{}, ctx, args[1:])
 	} else {
 		code, err = jujuCMain(commandName, args)
 	}
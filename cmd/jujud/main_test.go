@@ -58,10 +58,12 @@ func TestPackage(t *stdtesting.T) {
 	// TODO(waigani) 2014-03-19 bug 1294458
 	// Refactor to use base suites
 
-	// Change the path to "juju-run", so that the
-	// tests don't try to write to /usr/local/bin.
+	// Change the paths to "juju-run" and "juju-introspect", so
+	// that the tests don't try to write to /usr/local/bin.
 	jujuRun = mktemp("juju-run", "")
 	defer os.Remove(jujuRun)
+	jujuIntrospect = mktemp("juju-introspect", "")
+	defer os.Remove(jujuIntrospect)
 
 	// Create a CA certificate available for all tests.
 	caCertFile = mktemp("juju-test-cert", coretesting.CACert)
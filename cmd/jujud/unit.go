@@ -19,6 +19,7 @@ import (
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
+	"github.com/juju/juju/worker/caupdater"
 	workerlogger "github.com/juju/juju/worker/logger"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/uniter"
@@ -78,6 +79,7 @@ func (a *UnitAgent) Run(ctx *cmd.Context) error {
 	}
 	agentLogger.Infof("unit agent %v start (%s [%s])", a.Tag().String(), version.Current, runtime.Compiler)
 	network.InitializeFromConfig(a.CurrentConfig())
+	startIntrospection(a.runner, a.CurrentConfig().DataDir(), a.Tag())
 	a.runner.StartWorker("api", a.APIWorkers)
 	err := agentDone(a.runner.Wait())
 	a.tomb.Kill(err)
@@ -121,6 +123,9 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Uniter(), a), nil
 	})
+	runner.StartWorker("caupdater", func() (worker.Worker, error) {
+		return caupdater.NewCACertUpdater(st.Uniter(), a), nil
+	})
 	runner.StartWorker("rsyslog", func() (worker.Worker, error) {
 		return newRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
 	})
@@ -0,0 +1,20 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The juju-provider-dummy command is a provider plugin serving the
+// dummy provider, and is a reference for plugin authors. It is used
+// by environments of type "plugin" with "plugin: dummy".
+//
+// The dummy provider keeps its environments in memory, so they last
+// only as long as the plugin process. It is intended for testing
+// the plugin machinery, not for deploying services.
+package main
+
+import (
+	_ "github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/provider/plugin"
+)
+
+func main() {
+	plugin.Main("dummy")
+}
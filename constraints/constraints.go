@@ -29,6 +29,8 @@ const (
 	Tags         = "tags"
 	InstanceType = "instance-type"
 	Networks     = "networks"
+	Zones        = "zones"
+	Spaces       = "spaces"
 )
 
 // Value describes a user's requirements of the hardware on which units
@@ -78,6 +80,17 @@ type Value struct {
 	// negative values are accepted, and the difference is the latter
 	// have a "^" prefix to the name.
 	Networks *[]string `json:"networks,omitempty" yaml:"networks,omitempty"`
+
+	// Zones, if not nil, holds a list of availability zones in which
+	// a machine may be started. Only valid for clouds which support
+	// availability zones.
+	Zones *[]string `json:"zones,omitempty" yaml:"zones,omitempty"`
+
+	// Spaces, if not nil, holds a list of network spaces in which a
+	// machine must have an address. Spaces are defined in state and
+	// group subnets, which a provider may use to choose where to
+	// start the machine.
+	Spaces *[]string `json:"spaces,omitempty" yaml:"spaces,omitempty"`
 }
 
 // fieldNames records a mapping from the constraint tag to struct field name.
@@ -151,6 +164,16 @@ func (v *Value) HaveNetworks() bool {
 	return v.Networks != nil && len(*v.Networks) > 0
 }
 
+// HaveZones returns whether any availability zones were specified.
+func (v *Value) HaveZones() bool {
+	return v.Zones != nil && len(*v.Zones) > 0
+}
+
+// HaveSpaces returns whether any network spaces were specified.
+func (v *Value) HaveSpaces() bool {
+	return v.Spaces != nil && len(*v.Spaces) > 0
+}
+
 // String expresses a constraints.Value in the language in which it was specified.
 func (v Value) String() string {
 	var strs []string
@@ -191,6 +214,14 @@ func (v Value) String() string {
 		s := strings.Join(*v.Networks, ",")
 		strs = append(strs, "networks="+s)
 	}
+	if v.Zones != nil {
+		s := strings.Join(*v.Zones, ",")
+		strs = append(strs, "zones="+s)
+	}
+	if v.Spaces != nil {
+		s := strings.Join(*v.Spaces, ",")
+		strs = append(strs, "spaces="+s)
+	}
 	return strings.Join(strs, " ")
 }
 
@@ -330,6 +361,10 @@ func (v *Value) setRaw(raw string) error {
 		err = v.setInstanceType(str)
 	case Networks:
 		err = v.setNetworks(str)
+	case Zones:
+		err = v.setZones(str)
+	case Spaces:
+		err = v.setSpaces(str)
 	default:
 		return fmt.Errorf("unknown constraint %q", name)
 	}
@@ -376,6 +411,10 @@ func (v *Value) SetYAML(tag string, value interface{}) bool {
 			if err == nil {
 				err = v.validateNetworks(networks)
 			}
+		case Zones:
+			v.Zones, err = parseYamlStrings("zones", val)
+		case Spaces:
+			v.Spaces, err = parseYamlStrings("spaces", val)
 		default:
 			return false
 		}
@@ -478,6 +517,22 @@ func (v *Value) setNetworks(str string) error {
 	return nil
 }
 
+func (v *Value) setZones(str string) error {
+	if v.Zones != nil {
+		return fmt.Errorf("already set")
+	}
+	v.Zones = parseCommaDelimited(str)
+	return nil
+}
+
+func (v *Value) setSpaces(str string) error {
+	if v.Spaces != nil {
+		return fmt.Errorf("already set")
+	}
+	v.Spaces = parseCommaDelimited(str)
+	return nil
+}
+
 func (v *Value) validateNetworks(networks *[]string) error {
 	if networks == nil {
 		return nil
@@ -525,7 +580,8 @@ func parseSize(str string) (*uint64, error) {
 }
 
 // parseCommaDelimited returns the items in the value s. We expect the
-// tags to be comma delimited strings. It is used for tags and networks.
+// tags to be comma delimited strings. It is used for tags, networks,
+// zones and spaces.
 func parseCommaDelimited(s string) *[]string {
 	if s == "" {
 		return &[]string{}
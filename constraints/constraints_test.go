@@ -280,6 +280,38 @@ var parseConstraintsTests = []struct {
 		args:    []string{"networks="},
 	},
 
+	// zones
+	{
+		summary: "single zone",
+		args:    []string{"zones=us-east-1a"},
+	}, {
+		summary: "multiple zones",
+		args:    []string{"zones=us-east-1a,us-east-1b"},
+	}, {
+		summary: "no zones",
+		args:    []string{"zones="},
+	}, {
+		summary: "double set zones",
+		args:    []string{"zones=us-east-1a", "zones=us-east-1b"},
+		err:     `bad "zones" constraint: already set`,
+	},
+
+	// spaces
+	{
+		summary: "single space",
+		args:    []string{"spaces=dmz"},
+	}, {
+		summary: "multiple spaces",
+		args:    []string{"spaces=dmz,db"},
+	}, {
+		summary: "no spaces",
+		args:    []string{"spaces="},
+	}, {
+		summary: "double set spaces",
+		args:    []string{"spaces=dmz", "spaces=db"},
+		err:     `bad "spaces" constraint: already set`,
+	},
+
 	// instance type
 	{
 		summary: "set instance type",
@@ -373,6 +405,24 @@ func (s *ConstraintsSuite) TestIncludeExcludeAndHaveNetworks(c *gc.C) {
 	c.Check(con.HaveNetworks(), jc.IsTrue)
 }
 
+func (s *ConstraintsSuite) TestHaveZones(c *gc.C) {
+	con := constraints.MustParse("zones=zone1,zone2")
+	c.Check(con.HaveZones(), jc.IsTrue)
+	con = constraints.MustParse("zones=")
+	c.Check(con.HaveZones(), jc.IsFalse)
+	con = constraints.MustParse("mem=4G")
+	c.Check(con.HaveZones(), jc.IsFalse)
+}
+
+func (s *ConstraintsSuite) TestHaveSpaces(c *gc.C) {
+	con := constraints.MustParse("spaces=dmz,db")
+	c.Check(con.HaveSpaces(), jc.IsTrue)
+	con = constraints.MustParse("spaces=")
+	c.Check(con.HaveSpaces(), jc.IsFalse)
+	con = constraints.MustParse("mem=4G")
+	c.Check(con.HaveSpaces(), jc.IsFalse)
+}
+
 func (s *ConstraintsSuite) TestInvalidNetworks(c *gc.C) {
 	invalidNames := []string{
 		"%ne$t", "^net#2", "_", "tcp:ip",
@@ -459,6 +509,12 @@ var constraintsRoundtripTests = []roundTrip{
 	{"Networks1", constraints.Value{Networks: nil}},
 	{"Networks2", constraints.Value{Networks: &[]string{}}},
 	{"Networks3", constraints.Value{Networks: &[]string{"net1", "^net2"}}},
+	{"Zones1", constraints.Value{Zones: nil}},
+	{"Zones2", constraints.Value{Zones: &[]string{}}},
+	{"Zones3", constraints.Value{Zones: &[]string{"zone1", "zone2"}}},
+	{"Spaces1", constraints.Value{Spaces: nil}},
+	{"Spaces2", constraints.Value{Spaces: &[]string{}}},
+	{"Spaces3", constraints.Value{Spaces: &[]string{"dmz", "db"}}},
 	{"InstanceType1", constraints.Value{InstanceType: strp("")}},
 	{"InstanceType2", constraints.Value{InstanceType: strp("foo")}},
 	{"All", constraints.Value{
@@ -471,6 +527,8 @@ var constraintsRoundtripTests = []roundTrip{
 		Tags:         &[]string{"foo", "bar"},
 		Networks:     &[]string{"net1", "^net2"},
 		InstanceType: strp("foo"),
+		Zones:        &[]string{"zone1", "zone2"},
+		Spaces:       &[]string{"dmz", "db"},
 	}},
 }
 
@@ -121,7 +121,7 @@ func (manager *containerManager) CreateContainer(
 		return nil, nil, fmt.Errorf("failed to create container directory: %v", err)
 	}
 	logger.Tracef("write cloud-init")
-	userDataFilename, err := container.WriteUserData(machineConfig, directory)
+	userDataFilename, err := container.WriteUserData(machineConfig, network, directory)
 	if err != nil {
 		return nil, nil, errors.LoggedErrorf(logger, "failed to write user data: %v", err)
 	}
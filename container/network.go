@@ -3,6 +3,11 @@
 
 package container
 
+import (
+	"fmt"
+	"net"
+)
+
 const (
 	// BridgeNetwork will have the container use the network bridge.
 	BridgeNetwork = "bridge"
@@ -14,16 +19,64 @@ const (
 type NetworkConfig struct {
 	NetworkType string
 	Device      string
+
+	// StaticAddress, if not nil, holds an address allocated for the
+	// container on its host's network. The container's primary
+	// interface is then statically configured with it, instead of
+	// getting a private address from the bridge over DHCP.
+	StaticAddress *StaticAddress
+}
+
+// StaticAddress describes an address a container's primary interface
+// is statically configured with.
+type StaticAddress struct {
+	// Address is the container's IP address.
+	Address string
+
+	// CIDR is the CIDR of the network the address is on.
+	CIDR string
+
+	// Gateway is the address the container routes its traffic
+	// through; this is the host's address on the same network.
+	Gateway string
 }
 
 // BridgeNetworkConfig returns a valid NetworkConfig to use the specified
 // device as a network bridge for the container.
 func BridgeNetworkConfig(device string) *NetworkConfig {
-	return &NetworkConfig{BridgeNetwork, device}
+	return &NetworkConfig{NetworkType: BridgeNetwork, Device: device}
 }
 
 // PhysicalNetworkConfig returns a valid NetworkConfig to use the specified
 // device as the network device for the container.
 func PhysicalNetworkConfig(device string) *NetworkConfig {
-	return &NetworkConfig{PhysicalNetwork, device}
+	return &NetworkConfig{NetworkType: PhysicalNetwork, Device: device}
+}
+
+const staticInterfacesTemplate = `auto lo
+iface lo inet loopback
+
+auto eth0
+iface eth0 inet static
+    address %s
+    netmask %s
+    gateway %s
+`
+
+// staticInterfaces returns the contents of /etc/network/interfaces
+// configuring the container's primary interface with the given
+// static address.
+func staticInterfaces(addr *StaticAddress) (string, error) {
+	if net.ParseIP(addr.Address) == nil {
+		return "", fmt.Errorf("invalid container address %q", addr.Address)
+	}
+	if net.ParseIP(addr.Gateway) == nil {
+		return "", fmt.Errorf("invalid gateway address %q", addr.Gateway)
+	}
+	_, ipNet, err := net.ParseCIDR(addr.CIDR)
+	if err != nil {
+		return "", fmt.Errorf("invalid network CIDR %q", addr.CIDR)
+	}
+	netmask := net.IP(ipNet.Mask).String()
+	return fmt.Sprintf(staticInterfacesTemplate, addr.Address, netmask, addr.Gateway), nil
 }
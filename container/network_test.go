@@ -0,0 +1,55 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package container_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/testing"
+)
+
+type NetworkSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&NetworkSuite{})
+
+func (*NetworkSuite) TestStaticInterfaces(c *gc.C) {
+	interfaces, err := container.StaticInterfaces(&container.StaticAddress{
+		Address: "10.0.1.5",
+		CIDR:    "10.0.1.0/24",
+		Gateway: "10.0.1.2",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(interfaces, gc.Equals, `auto lo
+iface lo inet loopback
+
+auto eth0
+iface eth0 inet static
+    address 10.0.1.5
+    netmask 255.255.255.0
+    gateway 10.0.1.2
+`)
+}
+
+func (*NetworkSuite) TestStaticInterfacesErrors(c *gc.C) {
+	for i, t := range []struct {
+		addr container.StaticAddress
+		err  string
+	}{{
+		addr: container.StaticAddress{Address: "foo", CIDR: "10.0.1.0/24", Gateway: "10.0.1.2"},
+		err:  `invalid container address "foo"`,
+	}, {
+		addr: container.StaticAddress{Address: "10.0.1.5", CIDR: "10.0.1.0/24"},
+		err:  `invalid gateway address ""`,
+	}, {
+		addr: container.StaticAddress{Address: "10.0.1.5", CIDR: "10.0.1.0", Gateway: "10.0.1.2"},
+		err:  `invalid network CIDR "10.0.1.0"`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := container.StaticInterfaces(&t.addr)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
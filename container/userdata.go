@@ -4,10 +4,12 @@
 package container
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 
 	"github.com/juju/loggo"
+	"github.com/juju/utils"
 
 	coreCloudinit "github.com/juju/juju/cloudinit"
 	"github.com/juju/juju/environs/cloudinit"
@@ -17,11 +19,11 @@ var (
 	logger = loggo.GetLogger("juju.container")
 )
 
-// WriteUserData generates the cloud init for the specified machine config,
-// and writes the serialized form out to a cloud-init file in the directory
-// specified.
-func WriteUserData(machineConfig *cloudinit.MachineConfig, directory string) (string, error) {
-	userData, err := cloudInitUserData(machineConfig)
+// WriteUserData generates the cloud init for the specified machine config
+// and network config, and writes the serialized form out to a cloud-init
+// file in the directory specified.
+func WriteUserData(machineConfig *cloudinit.MachineConfig, networkConfig *NetworkConfig, directory string) (string, error) {
+	userData, err := cloudInitUserData(machineConfig, networkConfig)
 	if err != nil {
 		logger.Errorf("failed to create user data: %v", err)
 		return "", err
@@ -40,8 +42,13 @@ func WriteCloudInitFile(directory string, userData []byte) (string, error) {
 	return userDataFilename, nil
 }
 
-func cloudInitUserData(machineConfig *cloudinit.MachineConfig) ([]byte, error) {
+func cloudInitUserData(machineConfig *cloudinit.MachineConfig, networkConfig *NetworkConfig) ([]byte, error) {
 	cloudConfig := coreCloudinit.New()
+	if networkConfig != nil && networkConfig.StaticAddress != nil {
+		if err := addStaticAddressBootCmds(cloudConfig, networkConfig.StaticAddress); err != nil {
+			return nil, err
+		}
+	}
 	udata, err := cloudinit.NewUserdataConfig(machineConfig, cloudConfig)
 	if err != nil {
 		return nil, err
@@ -65,3 +72,18 @@ func cloudInitUserData(machineConfig *cloudinit.MachineConfig) ([]byte, error) {
 	}
 	return data, nil
 }
+
+// addStaticAddressBootCmds adds commands to the cloud-init config
+// that reconfigure the container's primary interface with the given
+// static address early on each boot.
+func addStaticAddressBootCmds(cloudConfig *coreCloudinit.Config, addr *StaticAddress) error {
+	interfaces, err := staticInterfaces(addr)
+	if err != nil {
+		return err
+	}
+	cloudConfig.AddBootCmd("ifdown --force eth0")
+	cloudConfig.AddBootCmd(fmt.Sprintf("printf '%%s' %s > /etc/network/interfaces", utils.ShQuote(interfaces)))
+	cloudConfig.AddBootCmd("rm -f /etc/network/interfaces.d/eth0.cfg")
+	cloudConfig.AddBootCmd("ifup eth0")
+	return nil
+}
@@ -172,16 +172,28 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config) (err
 	mcfg.MongoInfo = &mongo.MongoInfo{Password: passwordHash, Info: mongo.Info{CACert: caCert}}
 
 	// These really are directly relevant to running a state server.
-	cert, key, err := cfg.GenerateStateServerCertAndKey()
-	if err != nil {
-		return errors.Annotate(err, "cannot generate state server certificate")
+	// An externally signed certificate is used as is; state servers
+	// must not replace it, so they are not given the CA private key.
+	var cert, key, caKey string
+	if srvCert, ok := cfg.ServerCert(); ok {
+		cert = srvCert
+		key, _ = cfg.ServerPrivateKey()
+	} else {
+		cert, key, err = cfg.GenerateStateServerCertAndKey()
+		if err != nil {
+			return errors.Annotate(err, "cannot generate state server certificate")
+		}
+		// The CA private key is not stored in the environment
+		// configuration, but state servers need it to issue new
+		// certificates.
+		caKey, _ = cfg.CAPrivateKey()
 	}
-
 	srvInfo := state.StateServingInfo{
-		StatePort:  cfg.StatePort(),
-		APIPort:    cfg.APIPort(),
-		Cert:       string(cert),
-		PrivateKey: string(key),
+		StatePort:    cfg.StatePort(),
+		APIPort:      cfg.APIPort(),
+		Cert:         string(cert),
+		PrivateKey:   string(key),
+		CAPrivateKey: caKey,
 	}
 	mcfg.StateServingInfo = &srvInfo
 	if mcfg.Config, err = BootstrapConfig(cfg); err != nil {
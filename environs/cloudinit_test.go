@@ -157,6 +157,7 @@ func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	})
 	c.Check(mcfg.StateServingInfo.StatePort, gc.Equals, cfg.StatePort())
 	c.Check(mcfg.StateServingInfo.APIPort, gc.Equals, cfg.APIPort())
+	c.Check(mcfg.StateServingInfo.CAPrivateKey, gc.Equals, testing.CAKey)
 
 	oldAttrs["ca-private-key"] = ""
 	oldAttrs["admin-secret"] = ""
@@ -174,6 +175,37 @@ func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	c.Assert(err, gc.NotNil)
 }
 
+func (s *CloudInitSuite) TestFinishBootstrapConfigWithServerCert(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	attrs := dummySampleConfig().Merge(testing.Attrs{
+		"admin-secret":       "lisboan-pork",
+		"agent-version":      "1.2.3",
+		"state-server":       false,
+		"ca-cert":            caCert,
+		"ca-private-key":     "",
+		"server-cert":        srvCert,
+		"server-private-key": srvKey,
+	})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	mcfg := &cloudinit.MachineConfig{
+		Bootstrap: true,
+	}
+	err = environs.FinishMachineConfig(mcfg, cfg)
+	c.Assert(err, gc.IsNil)
+	c.Check(mcfg.APIInfo.CACert, gc.Equals, caCert)
+	c.Check(mcfg.StateServingInfo.Cert, gc.Equals, srvCert)
+	c.Check(mcfg.StateServingInfo.PrivateKey, gc.Equals, srvKey)
+	c.Check(mcfg.StateServingInfo.CAPrivateKey, gc.Equals, "")
+
+	bootstrapAttrs := mcfg.Config.AllAttrs()
+	c.Check(bootstrapAttrs["server-cert"], gc.IsNil)
+	c.Check(bootstrapAttrs["server-private-key"], gc.IsNil)
+}
+
 func (s *CloudInitSuite) TestUserData(c *gc.C) {
 	s.testUserData(c, false)
 }
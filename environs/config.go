@@ -262,14 +262,18 @@ func WriteEnvirons(path string, fileContents string) (string, error) {
 }
 
 // BootstrapConfig returns a copy of the supplied configuration with the
-// admin-secret and ca-private-key attributes removed. If the resulting
-// config is not suitable for bootstrapping an environment, an error is
-// returned.
+// admin-secret, ca-private-key, server-cert and server-private-key
+// attributes removed. If the resulting config is not suitable for
+// bootstrapping an environment, an error is returned.
 func BootstrapConfig(cfg *config.Config) (*config.Config, error) {
 	m := cfg.AllAttrs()
 	// We never want to push admin-secret or the root CA private key to the cloud.
 	delete(m, "admin-secret")
 	delete(m, "ca-private-key")
+	// The state server certificate is part of the state serving
+	// information, so it doesn't belong in the environment config.
+	delete(m, "server-cert")
+	delete(m, "server-private-key")
 	cfg, err := config.New(config.NoDefaults, m)
 	if err != nil {
 		return nil, err
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/juju/utils"
 
@@ -86,6 +87,22 @@ func verifyKeyPair(certb, key string) error {
 	return err
 }
 
+// verifyServerCert verifies that the server certificate and key parse
+// correctly, and that the certificate is valid with respect to the CA
+// certificate.
+func verifyServerCert(caCert, srvCert, srvKey string) error {
+	if srvCert == "" || srvKey == "" {
+		return fmt.Errorf("server certificate and key must be specified together")
+	}
+	if caCert == "" {
+		return fmt.Errorf("no CA certificate")
+	}
+	if _, err := tls.X509KeyPair([]byte(srvCert), []byte(srvKey)); err != nil {
+		return err
+	}
+	return cert.Verify(srvCert, caCert, time.Now())
+}
+
 // ConcatAuthKeys concatenates the two sets of authorised keys, interposing
 // a newline if necessary, because authorised keys are newline-separated.
 func ConcatAuthKeys(a, b string) string {
@@ -59,6 +59,11 @@ const (
 	// refresh addresses from the provider each time.
 	DefaultBootstrapSSHAddressesDelay int = 10
 
+	// DefaultSelfHealingAgentTimeout is the amount of time a machine
+	// agent may be down before its machine is reprovisioned, when
+	// self-healing is enabled, in seconds.
+	DefaultSelfHealingAgentTimeout int = 1800
+
 	// fallbackLtsSeries is the latest LTS series we'll use, if we fail to
 	// obtain this information from the system.
 	fallbackLtsSeries string = "precise"
@@ -211,8 +216,10 @@ const (
 // Specifically, the "authorized-keys-path" key
 // is translated into "authorized-keys" by loading the content from
 // respective file.  Similarly, "ca-cert-path" and "ca-private-key-path"
-// are translated into the "ca-cert" and "ca-private-key" values.  If
-// not specified, authorized SSH keys and CA details will be read from:
+// are translated into the "ca-cert" and "ca-private-key" values, and
+// "server-cert-path" and "server-private-key-path" into the
+// "server-cert" and "server-private-key" values.  If not specified,
+// authorized SSH keys and CA details will be read from:
 //
 //     ~/.ssh/id_dsa.pub
 //     ~/.ssh/id_rsa.pub
@@ -313,6 +320,14 @@ func (c *Config) fillInDefaults() error {
 	if err != nil {
 		return err
 	}
+	err = maybeReadAttrFromFile(c.defined, "server-cert", "")
+	if err != nil {
+		return err
+	}
+	err = maybeReadAttrFromFile(c.defined, "server-private-key", "")
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -422,6 +437,10 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	if v, ok := cfg.defined["self-healing-agent-timeout"].(int); ok && v < 0 {
+		return fmt.Errorf("invalid self-healing-agent-timeout in environment configuration: %d", v)
+	}
+
 	// Check firewall mode.
 	if mode := cfg.FirewallMode(); mode != FwInstance && mode != FwGlobal {
 		return fmt.Errorf("invalid firewall mode in environment configuration: %q", mode)
@@ -434,6 +453,13 @@ func Validate(cfg, old *Config) error {
 			return errors.Annotate(err, "bad CA certificate/key in configuration")
 		}
 	}
+	srvCert, srvCertOK := cfg.ServerCert()
+	srvKey, srvKeyOK := cfg.ServerPrivateKey()
+	if srvCertOK || srvKeyOK {
+		if err := verifyServerCert(caCert, srvCert, srvKey); err != nil {
+			return errors.Annotate(err, "bad server certificate/key in configuration")
+		}
+	}
 
 	// Ensure that the auth token is a set of key=value pairs.
 	authToken, _ := cfg.CharmStoreAuth()
@@ -514,8 +540,9 @@ func maybeReadAttrFromFile(defined map[string]interface{}, attr, defaultPath str
 	delete(defined, pathAttr)
 	hasPath := path != ""
 	if !hasPath {
-		// No path and attribute is already set; leave it be.
-		if s, _ := defined[attr].(string); s != "" {
+		// No path and attribute is already set, or
+		// there is nowhere to read it from; leave it be.
+		if s, _ := defined[attr].(string); s != "" || defaultPath == "" {
 			return nil
 		}
 		path = defaultPath
@@ -746,6 +773,26 @@ func (c *Config) CAPrivateKey() (key string, ok bool) {
 	return "", false
 }
 
+// ServerCert returns the externally signed state server certificate,
+// in PEM format, followed by any intermediate certificates that chain
+// it to the CA certificate, and whether the setting is available.
+func (c *Config) ServerCert() (string, bool) {
+	if s, ok := c.defined["server-cert"]; ok && s != "" {
+		return s.(string), true
+	}
+	return "", false
+}
+
+// ServerPrivateKey returns the private key of the externally signed
+// state server certificate, in PEM format, and whether the setting is
+// available.
+func (c *Config) ServerPrivateKey() (string, bool) {
+	if s, ok := c.defined["server-private-key"]; ok && s != "" {
+		return s.(string), true
+	}
+	return "", false
+}
+
 // AdminSecret returns the administrator password.
 // It's empty if the password has not been set.
 func (c *Config) AdminSecret() string {
@@ -898,6 +945,32 @@ func (c *Config) DisableNetworkManagement() (bool, bool) {
 	return v, ok
 }
 
+// RecordHooks reports whether units should record the execution
+// context of the hooks they run, so that they can be replayed
+// outside the environment with "juju replay-hook".
+func (c *Config) RecordHooks() bool {
+	v, _ := c.defined["record-hooks"].(bool)
+	return v
+}
+
+// SelfHealing reports whether machines whose instance has vanished,
+// or whose agent has been down for longer than SelfHealingAgentTimeout,
+// should be reprovisioned automatically.
+func (c *Config) SelfHealing() bool {
+	v, _ := c.defined["self-healing"].(bool)
+	return v
+}
+
+// SelfHealingAgentTimeout returns how long a machine agent may be
+// down before its machine is reprovisioned, when self-healing is
+// enabled.
+func (c *Config) SelfHealingAgentTimeout() time.Duration {
+	if v, ok := c.defined["self-healing-agent-timeout"].(int); ok && v != 0 {
+		return time.Duration(v) * time.Second
+	}
+	return time.Duration(DefaultSelfHealingAgentTimeout) * time.Second
+}
+
 // UnknownAttrs returns a copy of the raw configuration attributes
 // that are supposedly specific to the environment type. They could
 // also be wrong attributes, though. Only the specific environment
@@ -955,6 +1028,10 @@ var fields = schema.Fields{
 	"ca-cert-path":               schema.String(),
 	"ca-private-key":             schema.String(),
 	"ca-private-key-path":        schema.String(),
+	"server-cert":                schema.String(),
+	"server-cert-path":           schema.String(),
+	"server-private-key":         schema.String(),
+	"server-private-key-path":    schema.String(),
 	"ssl-hostname-verification":  schema.Bool(),
 	"state-port":                 schema.ForceInt(),
 	"api-port":                   schema.ForceInt(),
@@ -981,6 +1058,9 @@ var fields = schema.Fields{
 	"enable-os-refresh-update":   schema.Bool(),
 	"enable-os-upgrade":          schema.Bool(),
 	"disable-network-management": schema.Bool(),
+	"record-hooks":               schema.Bool(),
+	"self-healing":               schema.Bool(),
+	"self-healing-agent-timeout": schema.ForceInt(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":            schema.String(),
@@ -1003,6 +1083,10 @@ var alwaysOptional = schema.Defaults{
 	"authorized-keys-path":       schema.Omit,
 	"ca-cert-path":               schema.Omit,
 	"ca-private-key-path":        schema.Omit,
+	"server-cert":                schema.Omit,
+	"server-cert-path":           schema.Omit,
+	"server-private-key":         schema.Omit,
+	"server-private-key-path":    schema.Omit,
 	"logging-config":             schema.Omit,
 	ProvisionerHarvestModeKey:    schema.Omit,
 	"bootstrap-timeout":          schema.Omit,
@@ -1018,6 +1102,9 @@ var alwaysOptional = schema.Defaults{
 	"apt-ftp-proxy":              schema.Omit,
 	"lxc-clone":                  schema.Omit,
 	"disable-network-management": schema.Omit,
+	"record-hooks":               schema.Omit,
+	"self-healing":               schema.Omit,
+	"self-healing-agent-timeout": schema.Omit,
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":            "",
@@ -1094,6 +1181,8 @@ func allDefaults() schema.Defaults {
 var allowedWithDefaultsOnly = []string{
 	"ca-cert-path",
 	"ca-private-key-path",
+	"server-cert-path",
+	"server-private-key-path",
 	"authorized-keys-path",
 }
 
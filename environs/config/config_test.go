@@ -316,6 +316,45 @@ var configTests = []configTest{
 			"name": "my-name",
 			"disable-network-management": true,
 		},
+	}, {
+		about:       "Invalid record-hooks flag",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"authorized-keys": testing.FakeAuthKeys,
+			"record-hooks":    "invalid",
+		},
+		err: `record-hooks: expected bool, got string\("invalid"\)`,
+	}, {
+		about:       "record-hooks on",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":            "my-type",
+			"name":            "my-name",
+			"authorized-keys": testing.FakeAuthKeys,
+			"record-hooks":    true,
+		},
+	}, {
+		about:       "self-healing on",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                       "my-type",
+			"name":                       "my-name",
+			"authorized-keys":            testing.FakeAuthKeys,
+			"self-healing":               true,
+			"self-healing-agent-timeout": 600,
+		},
+	}, {
+		about:       "Invalid self-healing-agent-timeout",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":                       "my-type",
+			"name":                       "my-name",
+			"authorized-keys":            testing.FakeAuthKeys,
+			"self-healing-agent-timeout": -1,
+		},
+		err: `invalid self-healing-agent-timeout in environment configuration: -1`,
 	}, {
 		about:       "Invalid prefer-ipv6 flag",
 		useDefaults: config.UseDefaults,
@@ -1004,6 +1043,15 @@ func (test configTest) check(c *gc.C, home *gitjujutesting.FakeHome) {
 
 	testmode, _ := test.attrs["test-mode"].(bool)
 	c.Assert(cfg.TestMode(), gc.Equals, testmode)
+	recordHooks, _ := test.attrs["record-hooks"].(bool)
+	c.Assert(cfg.RecordHooks(), gc.Equals, recordHooks)
+	selfHealing, _ := test.attrs["self-healing"].(bool)
+	c.Assert(cfg.SelfHealing(), gc.Equals, selfHealing)
+	if v, ok := test.attrs["self-healing-agent-timeout"].(int); ok {
+		c.Assert(cfg.SelfHealingAgentTimeout(), gc.Equals, time.Duration(v)*time.Second)
+	} else {
+		c.Assert(cfg.SelfHealingAgentTimeout(), gc.Equals, time.Duration(config.DefaultSelfHealingAgentTimeout)*time.Second)
+	}
 
 	series, _ := test.attrs["default-series"].(string)
 	if defaultSeries, ok := cfg.DefaultSeries(); ok {
@@ -1548,6 +1596,90 @@ func (s *ConfigSuite) TestGenerateStateServerCertAndKey(c *gc.C) {
 	}
 }
 
+func (s *ConfigSuite) TestServerCert(c *gc.C) {
+	s.FakeHomeSuite.Home.AddFiles(c, gitjujutesting.TestFile{".ssh/id_rsa.pub", "rsa\n"})
+	extCACert, extCAKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(extCACert, extCAKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	s.FakeHomeSuite.Home.AddFiles(c,
+		gitjujutesting.TestFile{".juju/server-cert.pem", srvCert},
+		gitjujutesting.TestFile{".juju/server-key.pem", srvKey},
+	)
+
+	for i, test := range []struct {
+		about    string
+		attrs    map[string]interface{}
+		errMatch string
+	}{{
+		about: "certificate and key as attributes",
+		attrs: map[string]interface{}{
+			"ca-cert":            extCACert,
+			"server-cert":        srvCert,
+			"server-private-key": srvKey,
+		},
+	}, {
+		about: "certificate and key from path",
+		attrs: map[string]interface{}{
+			"ca-cert":                 extCACert,
+			"server-cert-path":        "server-cert.pem",
+			"server-private-key-path": "server-key.pem",
+		},
+	}, {
+		about: "certificate without key",
+		attrs: map[string]interface{}{
+			"ca-cert":     extCACert,
+			"server-cert": srvCert,
+		},
+		errMatch: "bad server certificate/key in configuration: server certificate and key must be specified together",
+	}, {
+		about: "certificate without CA certificate",
+		attrs: map[string]interface{}{
+			"server-cert":        srvCert,
+			"server-private-key": srvKey,
+		},
+		errMatch: "bad server certificate/key in configuration: no CA certificate",
+	}, {
+		about: "certificate not signed by CA",
+		attrs: map[string]interface{}{
+			"ca-cert":            testing.CACert,
+			"server-cert":        srvCert,
+			"server-private-key": srvKey,
+		},
+		errMatch: "bad server certificate/key in configuration: x509: certificate signed by unknown authority.*",
+	}, {
+		about: "mismatched certificate and key",
+		attrs: map[string]interface{}{
+			"ca-cert":            extCACert,
+			"server-cert":        srvCert,
+			"server-private-key": extCAKey,
+		},
+		errMatch: "bad server certificate/key in configuration: crypto/tls: private key does not match public key",
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		attrs := map[string]interface{}{
+			"name": "test-server-cert",
+			"type": "dummy",
+		}
+		for k, v := range test.attrs {
+			attrs[k] = v
+		}
+		cfg, err := config.New(config.UseDefaults, attrs)
+		if test.errMatch != "" {
+			c.Check(err, gc.ErrorMatches, test.errMatch)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		gotCert, ok := cfg.ServerCert()
+		c.Check(ok, jc.IsTrue)
+		c.Check(gotCert, gc.Equals, srvCert)
+		gotKey, ok := cfg.ServerPrivateKey()
+		c.Check(ok, jc.IsTrue)
+		c.Check(gotKey, gc.Equals, srvKey)
+		c.Check(cfg.AllAttrs()["server-cert-path"], gc.IsNil)
+	}
+}
+
 var caCert = `
 -----BEGIN CERTIFICATE-----
 MIIBjDCCATigAwIBAgIBADALBgkqhkiG9w0BAQUwHjENMAsGA1UEChMEanVqdTEN
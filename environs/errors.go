@@ -12,4 +12,9 @@ var (
 	ErrAlreadyBootstrapped = errors.New("environment is already bootstrapped")
 	ErrNoInstances         = errors.New("no instances found")
 	ErrPartialInstances    = errors.New("only some instances were found")
+
+	// ErrRateLimited is the cause of errors returned by environ
+	// methods when the provider refuses a request because too many
+	// requests have been made recently.
+	ErrRateLimited = errors.New("provider request rate limit exceeded")
 )
@@ -122,6 +122,10 @@ type Environ interface {
 	// given instance on the given network.
 	AllocateAddress(instId instance.Id, netId network.Id) (network.Address, error)
 
+	// ReleaseAddress releases an address previously allocated with
+	// AllocateAddress for the given instance on the given network.
+	ReleaseAddress(instId instance.Id, netId network.Id, addr network.Address) error
+
 	// ListNetworks returns basic information about all networks known
 	// by the provider for the environment. They may be unknown to juju
 	// yet (i.e. when called initially or when a new network was created).
@@ -188,6 +192,14 @@ type Environ interface {
 	state.Prechecker
 }
 
+// InstancesLimiter may be implemented by an Environ whose provider
+// limits the number of instances that can be asked for at once.
+type InstancesLimiter interface {
+	// MaxInstancesPerCall returns the maximum number of instance ids
+	// that may be passed to a single call to Instances.
+	MaxInstancesPerCall() int
+}
+
 // BootstrapContext is an interface that is passed to
 // Environ.Bootstrap, providing a means of obtaining
 // information about and manipulating the context in which
@@ -258,6 +258,11 @@ func ensureCertificate(cfg *config.Config) (*config.Config, error) {
 		return cfg, nil
 	}
 	if hasCACert && !hasCAKey {
+		// An externally signed state server certificate
+		// does not need the CA private key.
+		if _, hasServerCert := cfg.ServerCert(); hasServerCert {
+			return cfg, nil
+		}
 		return nil, fmt.Errorf("environment configuration with a certificate but no CA private key")
 	}
 
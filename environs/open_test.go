@@ -5,6 +5,7 @@ package environs_test
 
 import (
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
@@ -295,6 +296,31 @@ func (*OpenSuite) TestPrepareWithExistingKeyPair(c *gc.C) {
 	c.Assert(string(cfgKeyPEM), gc.DeepEquals, testing.CAKey)
 }
 
+func (*OpenSuite) TestPrepareWithServerCert(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	cfg, err := config.New(config.NoDefaults, dummy.SampleConfig().Delete("ca-cert", "ca-private-key").Merge(
+		testing.Attrs{
+			"state-server":       false,
+			"name":               "erewhemos",
+			"ca-cert":            caCert,
+			"server-cert":        srvCert,
+			"server-private-key": srvKey,
+		},
+	))
+	c.Assert(err, gc.IsNil)
+	env, err := environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.IsNil)
+	cfgCertPEM, _ := env.Config().CACert()
+	c.Assert(cfgCertPEM, gc.Equals, caCert)
+	_, cfgKeyOK := env.Config().CAPrivateKey()
+	c.Assert(cfgKeyOK, jc.IsFalse)
+	cfgSrvCertPEM, _ := env.Config().ServerCert()
+	c.Assert(cfgSrvCertPEM, gc.Equals, srvCert)
+}
+
 func (*OpenSuite) TestDestroy(c *gc.C) {
 	cfg, err := config.New(config.NoDefaults, dummy.SampleConfig().Merge(
 		testing.Attrs{
@@ -49,12 +49,13 @@ type Instance interface {
 // HardwareCharacteristics represents the characteristics of the instance (if known).
 // Attributes that are nil are unknown or not supported.
 type HardwareCharacteristics struct {
-	Arch     *string   `json:",omitempty" yaml:"arch,omitempty"`
-	Mem      *uint64   `json:",omitempty" yaml:"mem,omitempty"`
-	RootDisk *uint64   `json:",omitempty" yaml:"rootdisk,omitempty"`
-	CpuCores *uint64   `json:",omitempty" yaml:"cpucores,omitempty"`
-	CpuPower *uint64   `json:",omitempty" yaml:"cpupower,omitempty"`
-	Tags     *[]string `json:",omitempty" yaml:"tags,omitempty"`
+	Arch             *string   `json:",omitempty" yaml:"arch,omitempty"`
+	Mem              *uint64   `json:",omitempty" yaml:"mem,omitempty"`
+	RootDisk         *uint64   `json:",omitempty" yaml:"rootdisk,omitempty"`
+	CpuCores         *uint64   `json:",omitempty" yaml:"cpucores,omitempty"`
+	CpuPower         *uint64   `json:",omitempty" yaml:"cpupower,omitempty"`
+	Tags             *[]string `json:",omitempty" yaml:"tags,omitempty"`
+	AvailabilityZone *string   `json:",omitempty" yaml:"availabilityzone,omitempty"`
 }
 
 func uintStr(i uint64) string {
@@ -84,6 +85,9 @@ func (hc HardwareCharacteristics) String() string {
 	if hc.Tags != nil && len(*hc.Tags) > 0 {
 		strs = append(strs, fmt.Sprintf("tags=%s", strings.Join(*hc.Tags, ",")))
 	}
+	if hc.AvailabilityZone != nil && *hc.AvailabilityZone != "" {
+		strs = append(strs, fmt.Sprintf("availability-zone=%s", *hc.AvailabilityZone))
+	}
 	return strings.Join(strs, " ")
 }
 
@@ -147,6 +151,8 @@ func (hc *HardwareCharacteristics) setRaw(raw string) error {
 		err = hc.setRootDisk(str)
 	case "tags":
 		err = hc.setTags(str)
+	case "availability-zone":
+		err = hc.setAvailabilityZone(str)
 	default:
 		return fmt.Errorf("unknown characteristic %q", name)
 	}
@@ -191,6 +197,16 @@ func (hc *HardwareCharacteristics) setMem(str string) (err error) {
 	return
 }
 
+func (hc *HardwareCharacteristics) setAvailabilityZone(str string) error {
+	if hc.AvailabilityZone != nil {
+		return fmt.Errorf("already set")
+	}
+	if str != "" {
+		hc.AvailabilityZone = &str
+	}
+	return nil
+}
+
 func (hc *HardwareCharacteristics) setRootDisk(str string) (err error) {
 	if hc.RootDisk != nil {
 		return fmt.Errorf("already set")
@@ -217,10 +217,20 @@ var parseHardwareTests = []struct {
 		err:     `bad "root-disk" characteristic: already set`,
 	},
 
+	// availability zone
+	{
+		summary: "set availability-zone",
+		args:    []string{"availability-zone=us-east-1a"},
+	}, {
+		summary: "double set availability-zone",
+		args:    []string{"availability-zone=us-east-1a availability-zone=us-east-1b"},
+		err:     `bad "availability-zone" characteristic: already set`,
+	},
+
 	// Everything at once.
 	{
 		summary: "kitchen sink together",
-		args:    []string{" root-disk=4G mem=2T  arch=i386  cpu-cores=4096 cpu-power=9001"},
+		args:    []string{" root-disk=4G mem=2T  arch=i386  cpu-cores=4096 cpu-power=9001 availability-zone=zone1"},
 	}, {
 		summary: "kitchen sink separately",
 		args:    []string{"root-disk=4G", "mem=2T", "cpu-cores=4096", "cpu-power=9001", "arch=armhf"},
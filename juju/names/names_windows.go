@@ -5,8 +5,9 @@
 package names
 
 const (
-	Juju    = "juju.exe"
-	Jujud   = "jujud.exe"
-	Jujuc   = "jujuc.exe"
-	JujuRun = "juju-run.exe"
+	Juju           = "juju.exe"
+	Jujud          = "jujud.exe"
+	Jujuc          = "jujuc.exe"
+	JujuRun        = "juju-run.exe"
+	JujuIntrospect = "juju-introspect.exe"
 )
@@ -13,20 +13,23 @@ const (
 	logDir
 	dataDir
 	jujuRun
+	jujuIntrospect
 )
 
 var linuxVals = map[osVarType]string{
-	tmpDir:  "/tmp",
-	logDir:  "/var/log",
-	dataDir: "/var/lib/juju",
-	jujuRun: "/usr/local/bin/juju-run",
+	tmpDir:         "/tmp",
+	logDir:         "/var/log",
+	dataDir:        "/var/lib/juju",
+	jujuRun:        "/usr/local/bin/juju-run",
+	jujuIntrospect: "/usr/local/bin/juju-introspect",
 }
 
 var winVals = map[osVarType]string{
-	tmpDir:  "C:/Juju/tmp",
-	logDir:  "C:/Juju/log",
-	dataDir: "C:/Juju/lib/juju",
-	jujuRun: "C:/Juju/bin/juju-run.exe",
+	tmpDir:         "C:/Juju/tmp",
+	logDir:         "C:/Juju/log",
+	dataDir:        "C:/Juju/lib/juju",
+	jujuRun:        "C:/Juju/bin/juju-run.exe",
+	jujuIntrospect: "C:/Juju/bin/juju-introspect.exe",
 }
 
 // osVal will lookup the value of the key valname
@@ -71,6 +74,12 @@ func JujuRun(series string) (string, error) {
 	return osVal(series, jujuRun)
 }
 
+// JujuIntrospect returns the absolute path to the juju-introspect
+// binary for a particular series.
+func JujuIntrospect(series string) (string, error) {
+	return osVal(series, jujuIntrospect)
+}
+
 func MustSucceed(s string, e error) string {
 	if e != nil {
 		panic(e)
@@ -396,7 +396,7 @@ func addCharm(st *state.State, curl *charm.URL, ch charm.Charm) (*state.Charm, e
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse storage URL: %v", err)
 	}
-	sch, err := st.AddCharm(ch, curl, u, digest)
+	sch, err := st.AddCharm(ch, curl, u, digest, size)
 	if err != nil {
 		return nil, fmt.Errorf("cannot add charm: %v", err)
 	}
@@ -505,6 +505,21 @@ func (s *JujuConnSuite) AddTestingCharm(c *gc.C, name string) *state.Charm {
 	return sch
 }
 
+// AddMetaCharm adds a charm to state, cloned from the named testing
+// charm with its metadata.yaml replaced by metaYaml.
+func (s *JujuConnSuite) AddMetaCharm(c *gc.C, name, metaYaml string, revision int) *state.Charm {
+	path := charmtesting.Charms.ClonedDirPath(c.MkDir(), name)
+	err := ioutil.WriteFile(filepath.Join(path, "metadata.yaml"), []byte(metaYaml), 0644)
+	c.Assert(err, gc.IsNil)
+	ch, err := charm.ReadCharmDir(path)
+	c.Assert(err, gc.IsNil)
+	ch.SetRevision(revision)
+	ident := fmt.Sprintf("%s-%d", ch.Meta().Name, ch.Revision())
+	sch, err := addCharm(s.State, charm.MustParseURL("local:quantal/"+ident), ch)
+	c.Assert(err, gc.IsNil)
+	return sch
+}
+
 func (s *JujuConnSuite) AddTestingService(c *gc.C, name string, ch *state.Charm) *state.Service {
 	return s.AddTestingServiceWithNetworks(c, name, ch, nil)
 }
@@ -8,6 +8,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
@@ -137,6 +138,31 @@ func RemoveService(namespace string) error {
 	return upstartServiceStopAndRemove(svc)
 }
 
+// UpdateSSLKey writes the given certificate and private key to the
+// file mongod reads its SSL key from and, as mongod only reads it when
+// it starts, restarts the mongo server with the given namespace if it
+// is running. Nothing is done if the file already holds the
+// certificate and key.
+func UpdateSSLKey(dataDir, namespace, cert, privateKey string) error {
+	certKey := cert + "\n" + privateKey
+	if current, err := ioutil.ReadFile(sslKeyPath(dataDir)); err == nil && string(current) == certKey {
+		return nil
+	}
+	err := utils.AtomicWriteFile(sslKeyPath(dataDir), []byte(certKey), 0600)
+	if err != nil {
+		return fmt.Errorf("cannot write SSL key: %v", err)
+	}
+	svc := upstart.NewService(ServiceName(namespace), common.Conf{})
+	if !upstartServiceRunning(svc) {
+		return nil
+	}
+	logger.Infof("restarting mongo to use the new SSL key")
+	if err := upstartServiceStop(svc); err != nil {
+		return fmt.Errorf("failed to stop mongo: %v", err)
+	}
+	return upstartServiceStart(svc)
+}
+
 // EnsureServerParams is a parameter struct for EnsureServer.
 type EnsureServerParams struct {
 	// APIPort is the port to connect to the api server.
@@ -375,6 +375,53 @@ func (s *MongoSuite) TestRemoveService(c *gc.C) {
 	}})
 }
 
+func (s *MongoSuite) TestUpdateSSLKey(c *gc.C) {
+	dataDir := c.MkDir()
+	var calls []string
+	s.PatchValue(mongo.UpstartServiceRunning, func(svc *upstart.Service) bool {
+		c.Assert(svc.Name, gc.Equals, "juju-db-namespace")
+		return true
+	})
+	s.PatchValue(mongo.UpstartServiceStop, func(svc *upstart.Service) error {
+		calls = append(calls, "stop")
+		return nil
+	})
+	s.PatchValue(mongo.UpstartServiceStart, func(svc *upstart.Service) error {
+		calls = append(calls, "start")
+		return nil
+	})
+
+	err := mongo.UpdateSSLKey(dataDir, "namespace", "new cert", "new key")
+	c.Assert(err, gc.IsNil)
+	contents, err := ioutil.ReadFile(mongo.SSLKeyPath(dataDir))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(contents), gc.Equals, "new cert\nnew key")
+	c.Assert(calls, jc.DeepEquals, []string{"stop", "start"})
+
+	// Mongo is not restarted when the key has not changed.
+	calls = nil
+	err = mongo.UpdateSSLKey(dataDir, "namespace", "new cert", "new key")
+	c.Assert(err, gc.IsNil)
+	c.Assert(calls, gc.HasLen, 0)
+}
+
+func (s *MongoSuite) TestUpdateSSLKeyServerNotRunning(c *gc.C) {
+	dataDir := c.MkDir()
+	s.PatchValue(mongo.UpstartServiceRunning, func(svc *upstart.Service) bool {
+		return false
+	})
+	s.PatchValue(mongo.UpstartServiceStart, func(svc *upstart.Service) error {
+		c.Fatalf("mongo should not be started")
+		return nil
+	})
+
+	err := mongo.UpdateSSLKey(dataDir, "", "new cert", "new key")
+	c.Assert(err, gc.IsNil)
+	contents, err := ioutil.ReadFile(mongo.SSLKeyPath(dataDir))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(contents), gc.Equals, "new cert\nnew key")
+}
+
 func (s *MongoSuite) TestQuantalAptAddRepo(c *gc.C) {
 	dir := c.MkDir()
 	s.PatchEnvPathPrepend(dir)
@@ -5,7 +5,6 @@ package mongo
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	stderrors "errors"
 	"fmt"
 	"net"
@@ -101,17 +100,20 @@ func DialInfo(info Info, opts DialOpts) (*mgo.DialInfo, error) {
 	if len(info.CACert) == 0 {
 		return nil, stderrors.New("missing CA certificate")
 	}
-	xcert, err := cert.ParseCert(info.CACert)
+	pool, err := cert.ParseCertPool(info.CACert)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse CA certificate: %v", err)
 	}
-	pool := x509.NewCertPool()
-	pool.AddCert(xcert)
 	tlsConfig := &tls.Config{
-		RootCAs:    pool,
-		ServerName: "anything",
+		// The server certificate is verified after the
+		// handshake, against the host name dialled.
+		InsecureSkipVerify: true,
 	}
 	dial := func(addr net.Addr) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return nil, err
+		}
 		c, err := net.Dial("tcp", addr.String())
 		if err != nil {
 			logger.Debugf("connection failed, will retry: %v", err)
@@ -122,6 +124,12 @@ func DialInfo(info Info, opts DialOpts) (*mgo.DialInfo, error) {
 			logger.Debugf("TLS handshake failed: %v", err)
 			return nil, err
 		}
+		peerCerts := cc.ConnectionState().PeerCertificates
+		if err := cert.VerifyHost(peerCerts, host, pool, time.Now()); err != nil {
+			logger.Debugf("invalid server certificate: %v", err)
+			cc.Close()
+			return nil, err
+		}
 		logger.Infof("dialled mongo successfully on address %q", addr)
 		return cc, nil
 	}
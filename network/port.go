@@ -124,30 +124,51 @@ func SortHostPorts(hps []HostPort, preferIPv6 bool) {
 	sort.Sort(h)
 }
 
-// PortRange represents a single range of ports.
+// PortRange represents a single range of ports. An ICMP port
+// range has the protocol "icmp" and -1 as both its FromPort and
+// ToPort.
 type PortRange struct {
 	FromPort int
 	ToPort   int
 	Protocol string
 }
 
+// ICMPPortRange returns the port range used to open or close ICMP.
+func ICMPPortRange() PortRange {
+	return PortRange{FromPort: -1, ToPort: -1, Protocol: "icmp"}
+}
+
 // IsValid determines if the port range is valid.
 func (p PortRange) Validate() error {
 	proto := strings.ToLower(p.Protocol)
-	if proto != "tcp" && proto != "udp" {
+	switch proto {
+	case "tcp", "udp":
+	case "icmp":
+		if p.FromPort != -1 || p.ToPort != -1 {
+			return errors.Errorf("invalid ICMP port range %d-%d", p.FromPort, p.ToPort)
+		}
+		return nil
+	default:
 		return errors.Errorf("invalid protocol %q", proto)
 	}
 	if p.FromPort > p.ToPort {
 		return errors.Errorf("invalid port range %d-%d", p.FromPort, p.ToPort)
 	}
+	if p.FromPort < 1 || p.ToPort > 65535 {
+		return errors.Errorf("invalid port range %d-%d: ports must be in the range [1, 65535]", p.FromPort, p.ToPort)
+	}
 	return nil
 }
 
 // ConflictsWith determines if the two port ranges conflict.
+// ICMP port ranges never conflict, as ICMP has no ports.
 func (a PortRange) ConflictsWith(b PortRange) bool {
 	if a.Protocol != b.Protocol {
 		return false
 	}
+	if strings.ToLower(a.Protocol) == "icmp" {
+		return false
+	}
 	return a.ToPort >= b.FromPort && b.ToPort >= a.FromPort
 }
 
@@ -155,6 +176,20 @@ func (p PortRange) String() string {
 	return fmt.Sprintf("%d-%d/%s", p.FromPort, p.ToPort, strings.ToLower(p.Protocol))
 }
 
+// DisplayString returns the port range in the form accepted by the
+// open-port and close-port hook tools: "icmp" for ICMP, "80/tcp"
+// for a single port and "8000-8100/tcp" for a range.
+func (p PortRange) DisplayString() string {
+	proto := strings.ToLower(p.Protocol)
+	switch {
+	case proto == "icmp":
+		return proto
+	case p.FromPort == p.ToPort:
+		return fmt.Sprintf("%d/%s", p.FromPort, proto)
+	}
+	return fmt.Sprintf("%d-%d/%s", p.FromPort, p.ToPort, proto)
+}
+
 type portRangeSlice []PortRange
 
 func (p portRangeSlice) Len() int      { return len(p) }
@@ -182,8 +217,16 @@ func SortPortRanges(portRanges []PortRange) {
 // dealing with individual ports
 // TODO (domas) 2014-07-31: remove this once firewaller is capable of
 // handling port ranges
+//
+// An ICMP port range, which has no ports, becomes a single port with
+// the protocol "icmp" and the number -1, which PortsToPortRanges
+// turns back into the ICMP port range.
 func PortRangesToPorts(portRanges []PortRange) (result []Port) {
 	for _, portRange := range portRanges {
+		if strings.ToLower(portRange.Protocol) == "icmp" {
+			result = append(result, Port{"icmp", -1})
+			continue
+		}
 		for p := portRange.FromPort; p <= portRange.ToPort; p++ {
 			result = append(result, Port{portRange.Protocol, p})
 		}
@@ -190,6 +190,11 @@ func (p *PortSuite) TestPortRangeConflicts(c *gc.C) {
 		network.PortRange{100, 200, "TCP"},
 		network.PortRange{120, 140, "TCP"},
 		true,
+	}, {
+		"ICMP",
+		network.ICMPPortRange(),
+		network.ICMPPortRange(),
+		false,
 	}}
 
 	for i, t := range testCases {
@@ -208,6 +213,18 @@ func (p *PortSuite) TestPortRangeString(c *gc.C) {
 		"80-100/tcp")
 }
 
+func (p *PortSuite) TestPortRangeDisplayString(c *gc.C) {
+	c.Assert(network.PortRange{80, 80, "TCP"}.DisplayString(),
+		gc.Equals,
+		"80/tcp")
+	c.Assert(network.PortRange{80, 100, "TCP"}.DisplayString(),
+		gc.Equals,
+		"80-100/tcp")
+	c.Assert(network.ICMPPortRange().DisplayString(),
+		gc.Equals,
+		"icmp")
+}
+
 func (p *PortSuite) TestPortRangeValidity(c *gc.C) {
 	testCases := []struct {
 		about    string
@@ -233,6 +250,18 @@ func (p *PortSuite) TestPortRangeValidity(c *gc.C) {
 		"invalid protocol",
 		network.PortRange{80, 80, "some protocol"},
 		"invalid protocol.*",
+	}, {
+		"port out of range",
+		network.PortRange{0, 80, "tcp"},
+		`invalid port range 0-80: ports must be in the range \[1, 65535\]`,
+	}, {
+		"valid ICMP port range",
+		network.ICMPPortRange(),
+		"",
+	}, {
+		"ICMP port range with ports",
+		network.PortRange{80, 80, "icmp"},
+		"invalid ICMP port range 80-80",
 	}}
 
 	for i, t := range testCases {
@@ -291,3 +320,10 @@ func (*PortSuite) TestCollapsePorts(c *gc.C) {
 		c.Assert(network.CollapsePorts(t.ports), gc.DeepEquals, t.expected)
 	}
 }
+
+func (*PortSuite) TestPortRangesToPortsICMP(c *gc.C) {
+	ranges := []network.PortRange{network.ICMPPortRange(), {80, 81, "tcp"}}
+	ports := network.PortRangesToPorts(ranges)
+	c.Assert(ports, gc.DeepEquals, []network.Port{{"icmp", -1}, {"tcp", 80}, {"tcp", 81}})
+	c.Assert(network.PortsToPortRanges(ports[:1]), gc.DeepEquals, []network.PortRange{network.ICMPPortRange()})
+}
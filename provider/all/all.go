@@ -12,4 +12,5 @@ import (
 	_ "github.com/juju/juju/provider/maas"
 	_ "github.com/juju/juju/provider/manual"
 	_ "github.com/juju/juju/provider/openstack"
+	_ "github.com/juju/juju/provider/plugin"
 )
@@ -514,6 +514,7 @@ func (env *azureEnviron) ConstraintsValidator() (constraints.Validator, error) {
 		instTypeNames[i] = instanceType.Name
 	}
 	validator.RegisterVocabulary(constraints.InstanceType, instTypeNames)
+	validator.RegisterVocabulary(constraints.Zones, []string{env.getSnapshot().ecfg.location()})
 	validator.RegisterConflicts(
 		[]string{constraints.InstanceType},
 		[]string{constraints.Mem, constraints.CpuCores, constraints.Arch, constraints.RootDisk})
@@ -526,6 +527,9 @@ func (env *azureEnviron) PrecheckInstance(series string, cons constraints.Value,
 	if placement != "" {
 		return fmt.Errorf("unknown placement directive: %s", placement)
 	}
+	if err := checkZoneConstraint(cons, env.getSnapshot().ecfg.location()); err != nil {
+		return err
+	}
 	if !cons.HasInstanceType() {
 		return nil
 	}
@@ -542,6 +546,22 @@ func (env *azureEnviron) PrecheckInstance(series string, cons constraints.Value,
 	return fmt.Errorf("invalid instance type %q", *cons.InstanceType)
 }
 
+// checkZoneConstraint returns an error if the zones constraint in
+// cons names anything other than the environment's location. Azure
+// has no availability zones within a location, so each environment
+// is treated as having a single zone named after its location.
+func checkZoneConstraint(cons constraints.Value, location string) error {
+	if !cons.HaveZones() {
+		return nil
+	}
+	for _, zone := range *cons.Zones {
+		if zone != location {
+			return fmt.Errorf("invalid availability zone %q", zone)
+		}
+	}
+	return nil
+}
+
 // createInstance creates all of the Azure entities necessary for a
 // new instance. This includes Cloud Service, Deployment and Role.
 //
@@ -658,6 +678,9 @@ func (env *azureEnviron) StartInstance(args environs.StartInstanceParams) (_ ins
 
 	snapshot := env.getSnapshot()
 	location := snapshot.ecfg.location()
+	if err := checkZoneConstraint(args.Constraints, location); err != nil {
+		return nil, nil, nil, err
+	}
 	instanceType, sourceImageName, err := env.selectInstanceTypeAndImage(&instances.InstanceConstraint{
 		Region:      location,
 		Series:      args.Tools.OneSeries(),
@@ -704,6 +727,8 @@ func (env *azureEnviron) StartInstance(args environs.StartInstanceParams) (_ ins
 		Mem:      &instanceType.Mem,
 		RootDisk: &instanceType.RootDisk,
 		CpuCores: &instanceType.CpuCores,
+		// Azure has no availability zones within a location.
+		AvailabilityZone: &location,
 	}
 	if len(instanceType.Arches) == 1 {
 		hc.Arch = &instanceType.Arches[0]
@@ -1022,6 +1047,12 @@ func (*azureEnviron) AllocateAddress(_ instance.Id, _ network.Id) (network.Addre
 	return network.Address{}, errors.NotImplementedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not implemented on the Azure provider yet.
+func (*azureEnviron) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return errors.NotImplementedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
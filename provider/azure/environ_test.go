@@ -1582,15 +1582,23 @@ func (s *startInstanceSuite) startInstance(c *gc.C) (serviceName string, stateSe
 	c.Assert(called, jc.IsTrue)
 	c.Assert(hardware, gc.NotNil)
 	arch := "amd64"
+	location := s.env.getSnapshot().ecfg.location()
 	c.Assert(hardware, gc.DeepEquals, &instance.HardwareCharacteristics{
-		Arch:     &arch,
-		Mem:      &roleSize.Mem,
-		RootDisk: &roleSize.OSDiskSpace,
-		CpuCores: &roleSize.CpuCores,
+		Arch:             &arch,
+		Mem:              &roleSize.Mem,
+		RootDisk:         &roleSize.OSDiskSpace,
+		CpuCores:         &roleSize.CpuCores,
+		AvailabilityZone: &location,
 	})
 	return serviceName, stateServer
 }
 
+func (s *startInstanceSuite) TestStartInstanceInvalidZone(c *gc.C) {
+	s.params.Constraints = constraints.MustParse("zones=nowhere")
+	_, _, _, err := s.env.StartInstance(s.params)
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "nowhere"`)
+}
+
 func (s *startInstanceSuite) TestStartInstanceDistributionGroupError(c *gc.C) {
 	s.params.DistributionGroup = func() ([]instance.Id, error) {
 		return nil, fmt.Errorf("DistributionGroupError")
@@ -4,8 +4,11 @@
 package common
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/instance"
 )
@@ -129,6 +132,78 @@ func AvailabilityZoneAllocations(env ZonedEnviron, group []instance.Id) ([]Avail
 
 var internalAvailabilityZoneAllocations = AvailabilityZoneAllocations
 
+// AvailabilityZoneNames returns the names of all availability zones
+// in the environment, whether or not they are currently available.
+// It is used to register the zones constraint vocabulary.
+func AvailabilityZoneNames(env ZonedEnviron) ([]string, error) {
+	zones, err := env.AvailabilityZones()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(zones))
+	for i, zone := range zones {
+		names[i] = zone.Name()
+	}
+	return names, nil
+}
+
+// ValidateZoneConstraint returns an error if the zones constraint
+// in cons names an availability zone that is unknown to the
+// environment or is currently unavailable.
+func ValidateZoneConstraint(env ZonedEnviron, cons constraints.Value) error {
+	if !cons.HaveZones() {
+		return nil
+	}
+	zones, err := env.AvailabilityZones()
+	if err != nil {
+		return err
+	}
+	available := make(map[string]bool)
+	for _, zone := range zones {
+		available[zone.Name()] = zone.Available()
+	}
+	for _, name := range *cons.Zones {
+		isAvailable, ok := available[name]
+		if !ok {
+			return fmt.Errorf("invalid availability zone %q", name)
+		}
+		if !isAvailable {
+			return fmt.Errorf("availability zone %q is unavailable", name)
+		}
+	}
+	return nil
+}
+
+// CheckZonePlacement returns an error if the availability zone
+// chosen by a placement directive is excluded by the zones
+// constraint in cons.
+func CheckZonePlacement(zone string, cons constraints.Value) error {
+	if !cons.HaveZones() {
+		return nil
+	}
+	for _, name := range *cons.Zones {
+		if name == zone {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"availability zone %q does not satisfy zones constraint %q",
+		zone, strings.Join(*cons.Zones, ","),
+	)
+}
+
+// ZoneNames returns the names of the zones in zoneInstances, in
+// order, omitting any excluded by the zones constraint in cons.
+func ZoneNames(zoneInstances []AvailabilityZoneInstances, cons constraints.Value) []string {
+	var names []string
+	for _, z := range zoneInstances {
+		if CheckZonePlacement(z.ZoneName, cons) == nil {
+			names = append(names, z.ZoneName)
+		}
+	}
+	return names
+}
+
 // DistributeInstances is a common function for implement the
 // state.InstanceDistributor policy based on availability zone
 // spread.
@@ -9,6 +9,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/provider/common"
@@ -246,3 +247,48 @@ func (s *AvailabilityZoneSuite) TestDistributeInstances(c *gc.C) {
 		c.Assert(eligible, jc.SameContents, test.eligible)
 	}
 }
+
+func (s *AvailabilityZoneSuite) TestAvailabilityZoneNames(c *gc.C) {
+	names, err := common.AvailabilityZoneNames(&s.env)
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.DeepEquals, []string{"az0", "az1", "az2"})
+}
+
+func (s *AvailabilityZoneSuite) TestValidateZoneConstraint(c *gc.C) {
+	for i, test := range []struct {
+		cons string
+		err  string
+	}{
+		{"", ""},
+		{"zones=az1,az2", ""},
+		{"zones=az0", `availability zone "az0" is unavailable`},
+		{"zones=az1,az9", `invalid availability zone "az9"`},
+	} {
+		c.Logf("test %d: %q", i, test.cons)
+		err := common.ValidateZoneConstraint(&s.env, constraints.MustParse(test.cons))
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *AvailabilityZoneSuite) TestCheckZonePlacement(c *gc.C) {
+	err := common.CheckZonePlacement("az1", constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	err = common.CheckZonePlacement("az1", constraints.MustParse("zones=az1,az2"))
+	c.Assert(err, gc.IsNil)
+	err = common.CheckZonePlacement("az0", constraints.MustParse("zones=az1,az2"))
+	c.Assert(err, gc.ErrorMatches, `availability zone "az0" does not satisfy zones constraint "az1,az2"`)
+}
+
+func (s *AvailabilityZoneSuite) TestZoneNames(c *gc.C) {
+	zoneInstances := []common.AvailabilityZoneInstances{
+		{ZoneName: "az2"}, {ZoneName: "az1"},
+	}
+	names := common.ZoneNames(zoneInstances, constraints.Value{})
+	c.Assert(names, gc.DeepEquals, []string{"az2", "az1"})
+	names = common.ZoneNames(zoneInstances, constraints.MustParse("zones=az1"))
+	c.Assert(names, gc.DeepEquals, []string{"az1"})
+}
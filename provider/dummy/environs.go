@@ -144,6 +144,13 @@ type OpAllocateAddress struct {
 	Address    network.Address
 }
 
+type OpReleaseAddress struct {
+	Env        string
+	InstanceId instance.Id
+	NetworkId  network.Id
+	Address    network.Address
+}
+
 type OpListNetworks struct {
 	Env  string
 	Info []network.BasicInfo
@@ -214,6 +221,7 @@ type environState struct {
 	mu           sync.Mutex
 	maxId        int // maximum instance id allocated so far.
 	maxAddr      int // maximum allocated address last byte
+	addrs        map[string]allocatedAddress
 	insts        map[instance.Id]*dummyInstance
 	globalPorts  map[network.PortRange]bool
 	bootstrapped bool
@@ -331,6 +339,7 @@ func newState(name string, ops chan<- Operation, policy state.Policy) *environSt
 		name:        name,
 		ops:         ops,
 		statePolicy: policy,
+		addrs:       make(map[string]allocatedAddress),
 		insts:       make(map[instance.Id]*dummyInstance),
 		globalPorts: make(map[network.PortRange]bool),
 	}
@@ -599,11 +608,80 @@ func (*environ) SupportNetworks() bool {
 }
 
 // PrecheckInstance is specified in the state.Prechecker interface.
-func (*environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
+func (e *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
+	if strings.HasPrefix(placement, "zone=") {
+		_, err := e.placementZone(placement, cons)
+		return err
+	}
 	if placement != "" && placement != "valid" {
 		return fmt.Errorf("%s placement is invalid", placement)
 	}
-	return nil
+	return common.ValidateZoneConstraint(e, cons)
+}
+
+// dummyZones holds the availability zones of every dummy environment.
+var dummyZones = []dummyZone{
+	{"zone1", true},
+	{"zone2", true},
+	{"zone3", false},
+}
+
+type dummyZone struct {
+	name      string
+	available bool
+}
+
+func (z dummyZone) Name() string {
+	return z.name
+}
+
+func (z dummyZone) Available() bool {
+	return z.available
+}
+
+// AvailabilityZones is specified in the common.ZonedEnviron interface.
+func (e *environ) AvailabilityZones() ([]common.AvailabilityZone, error) {
+	if err := e.checkBroken("AvailabilityZones"); err != nil {
+		return nil, err
+	}
+	zones := make([]common.AvailabilityZone, len(dummyZones))
+	for i, zone := range dummyZones {
+		zones[i] = zone
+	}
+	return zones, nil
+}
+
+// InstanceAvailabilityZoneNames is specified in the common.ZonedEnviron
+// interface.
+func (e *environ) InstanceAvailabilityZoneNames(ids []instance.Id) ([]string, error) {
+	instances, err := e.Instances(ids)
+	if err != nil && err != environs.ErrPartialInstances {
+		return nil, err
+	}
+	zones := make([]string, len(instances))
+	for i, inst := range instances {
+		if inst != nil {
+			zones[i] = inst.(*dummyInstance).zone
+		}
+	}
+	return zones, err
+}
+
+// placementZone returns the availability zone named by the given
+// "zone=<name>" placement directive, checking that it is available
+// and satisfies the zones constraint in cons.
+func (e *environ) placementZone(placement string, cons constraints.Value) (string, error) {
+	zone := strings.TrimPrefix(placement, "zone=")
+	for _, z := range dummyZones {
+		if z.name != zone {
+			continue
+		}
+		if !z.available {
+			return "", fmt.Errorf("availability zone %q is unavailable", zone)
+		}
+		return zone, common.CheckZonePlacement(zone, cons)
+	}
+	return "", fmt.Errorf("invalid availability zone %q", zone)
 }
 
 // GetImageSources returns a list of sources which are used to search for simplestreams image metadata.
@@ -781,6 +859,11 @@ func (e *environ) ConstraintsValidator() (constraints.Validator, error) {
 	validator := constraints.NewValidator()
 	validator.RegisterUnsupported([]string{constraints.CpuPower})
 	validator.RegisterConflicts([]string{constraints.InstanceType}, []string{constraints.Mem})
+	zoneNames := make([]string, len(dummyZones))
+	for i, zone := range dummyZones {
+		zoneNames[i] = zone.name
+	}
+	validator.RegisterVocabulary(constraints.Zones, zoneNames)
 	return validator, nil
 }
 
@@ -793,6 +876,20 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	if err := e.checkBroken("StartInstance"); err != nil {
 		return nil, nil, nil, err
 	}
+	// Use the zone chosen by placement, or else the first zone
+	// allowed by the zones constraint.
+	var zone string
+	if strings.HasPrefix(args.Placement, "zone=") {
+		var err error
+		if zone, err = e.placementZone(args.Placement, args.Constraints); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if args.Constraints.HaveZones() {
+		if err := common.ValidateZoneConstraint(e, args.Constraints); err != nil {
+			return nil, nil, nil, err
+		}
+		zone = (*args.Constraints.Zones)[0]
+	}
 	estate, err := e.state()
 	if err != nil {
 		return nil, nil, nil, err
@@ -827,6 +924,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		machineId:    machineId,
 		series:       series,
 		firewallMode: e.Config().FirewallMode(),
+		zone:         zone,
 		state:        estate,
 	}
 
@@ -861,6 +959,9 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 			cores := uint64(1)
 			hc.CpuCores = &cores
 		}
+		if zone != "" {
+			hc.AvailabilityZone = &zone
+		}
 	}
 	// Simulate networks added when requested.
 	networks := append(args.Constraints.IncludeNetworks(), args.MachineConfig.Networks...)
@@ -969,14 +1070,25 @@ func (env *environ) AllocateAddress(instId instance.Id, netId network.Id) (netwo
 	estate.mu.Lock()
 	defer estate.mu.Unlock()
 	estate.maxAddr++
-	// TODO(dimitern) Once we have integrated networks
-	// and addresses, make sure we return a valid address
-	// for the given network, and we also have the network
-	// already registered.
+	// Addresses on the networks returned by ListNetworks are
+	// allocated from the network's range; any other network
+	// gets addresses from 0.1.2.0/24.
+	ip := net.IPv4(0, 1, 2, 0).To4()
+	for _, info := range dummyNetworks {
+		if info.ProviderId == netId {
+			_, ipNet, err := net.ParseCIDR(info.CIDR)
+			if err != nil {
+				return network.Address{}, err
+			}
+			ip = ipNet.IP.To4()
+			break
+		}
+	}
 	newAddress := network.NewAddress(
-		fmt.Sprintf("0.1.2.%d", estate.maxAddr),
+		fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], estate.maxAddr),
 		network.ScopeCloudLocal,
 	)
+	estate.addrs[newAddress.Value] = allocatedAddress{instId, netId}
 	estate.ops <- OpAllocateAddress{
 		Env:        env.name,
 		InstanceId: instId,
@@ -986,6 +1098,46 @@ func (env *environ) AllocateAddress(instId instance.Id, netId network.Id) (netwo
 	return newAddress, nil
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress.
+func (env *environ) ReleaseAddress(instId instance.Id, netId network.Id, addr network.Address) error {
+	if err := env.checkBroken("ReleaseAddress"); err != nil {
+		return err
+	}
+
+	estate, err := env.state()
+	if err != nil {
+		return err
+	}
+	estate.mu.Lock()
+	defer estate.mu.Unlock()
+	allocated, ok := estate.addrs[addr.Value]
+	if !ok || allocated.instId != instId || allocated.netId != netId {
+		return fmt.Errorf("address %q not allocated to instance %q on network %q", addr.Value, instId, netId)
+	}
+	delete(estate.addrs, addr.Value)
+	estate.ops <- OpReleaseAddress{
+		Env:        env.name,
+		InstanceId: instId,
+		NetworkId:  netId,
+		Address:    addr,
+	}
+	return nil
+}
+
+// allocatedAddress records the instance and network an
+// address was allocated for.
+type allocatedAddress struct {
+	instId instance.Id
+	netId  network.Id
+}
+
+// dummyNetworks holds the networks returned by ListNetworks.
+var dummyNetworks = []network.BasicInfo{
+	{CIDR: "0.10.0.0/8", ProviderId: "dummy-private"},
+	{CIDR: "0.20.0.0/24", ProviderId: "dummy-public"},
+}
+
 // ListNetworks implements environs.Environ.ListNetworks.
 func (env *environ) ListNetworks() ([]network.BasicInfo, error) {
 	if err := env.checkBroken("ListNetworks"); err != nil {
@@ -999,10 +1151,8 @@ func (env *environ) ListNetworks() ([]network.BasicInfo, error) {
 	estate.mu.Lock()
 	defer estate.mu.Unlock()
 
-	netInfo := []network.BasicInfo{
-		{CIDR: "0.10.0.0/8", ProviderId: "dummy-private"},
-		{CIDR: "0.20.0.0/24", ProviderId: "dummy-public"},
-	}
+	netInfo := make([]network.BasicInfo, len(dummyNetworks))
+	copy(netInfo, dummyNetworks)
 	estate.ops <- OpListNetworks{
 		Env:  env.name,
 		Info: netInfo,
@@ -1090,6 +1240,7 @@ type dummyInstance struct {
 	series       string
 	firewallMode string
 	stateServer  bool
+	zone         string
 
 	mu        sync.Mutex
 	addresses []network.Address
@@ -12,6 +12,7 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/config"
@@ -21,6 +22,7 @@ import (
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/state"
 	"github.com/juju/juju/testing"
 	"github.com/juju/juju/version"
 )
@@ -148,6 +150,41 @@ func (s *suite) TestAllocateAddress(c *gc.C) {
 	assertAllocateAddress(c, e, opc, inst.Id(), netId, expectAddress)
 }
 
+func (s *suite) TestReleaseAddress(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	defer func() {
+		err := e.Destroy()
+		c.Assert(err, gc.IsNil)
+	}()
+
+	inst, _ := jujutesting.AssertStartInstance(c, e, "0")
+	c.Assert(inst, gc.NotNil)
+	netId := network.Id("dummy-private")
+
+	address, err := e.AllocateAddress(inst.Id(), netId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(address, gc.DeepEquals, network.NewAddress("0.10.0.1", network.ScopeCloudLocal))
+
+	opc := make(chan dummy.Operation, 200)
+	dummy.Listen(opc)
+
+	err = e.ReleaseAddress(inst.Id(), netId, address)
+	c.Assert(err, gc.IsNil)
+	select {
+	case op := <-opc:
+		addrOp, ok := op.(dummy.OpReleaseAddress)
+		c.Assert(ok, jc.IsTrue)
+		c.Check(addrOp.NetworkId, gc.Equals, netId)
+		c.Check(addrOp.InstanceId, gc.Equals, inst.Id())
+		c.Check(addrOp.Address, gc.Equals, address)
+	case <-time.After(testing.ShortWait):
+		c.Fatalf("time out wating for operation")
+	}
+
+	err = e.ReleaseAddress(inst.Id(), netId, address)
+	c.Assert(err, gc.ErrorMatches, `address "0.10.0.1" not allocated to instance ".*" on network "dummy-private"`)
+}
+
 func (s *suite) TestListNetworks(c *gc.C) {
 	e := s.bootstrapTestEnviron(c, false)
 	defer func() {
@@ -206,6 +243,47 @@ func (s *suite) TestPreferIPv6Off(c *gc.C) {
 	c.Assert(toolsURL.Host, gc.Matches, `127\.0\.0\.1:\d+`)
 }
 
+func (s *suite) TestStartInstanceZones(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	defer func() {
+		err := e.Destroy()
+		c.Assert(err, gc.IsNil)
+	}()
+
+	_, hc := jujutesting.AssertStartInstanceWithConstraints(c, e, "1", constraints.MustParse("zones=zone2,zone1"))
+	c.Assert(hc.AvailabilityZone, gc.NotNil)
+	c.Assert(*hc.AvailabilityZone, gc.Equals, "zone2")
+
+	params := environs.StartInstanceParams{Placement: "zone=zone1"}
+	_, hc, _, err := jujutesting.StartInstanceWithParams(e, "2", params, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(*hc.AvailabilityZone, gc.Equals, "zone1")
+
+	params.Constraints = constraints.MustParse("zones=zone2")
+	_, _, _, err = jujutesting.StartInstanceWithParams(e, "3", params, nil)
+	c.Assert(err, gc.ErrorMatches, `availability zone "zone1" does not satisfy zones constraint "zone2"`)
+
+	_, _, _, err = jujutesting.StartInstanceWithConstraints(e, "4", constraints.MustParse("zones=zone3"))
+	c.Assert(err, gc.ErrorMatches, `availability zone "zone3" is unavailable`)
+	_, _, _, err = jujutesting.StartInstanceWithConstraints(e, "5", constraints.MustParse("zones=nowhere"))
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "nowhere"`)
+}
+
+func (s *suite) TestPrecheckInstanceZones(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	defer func() {
+		err := e.Destroy()
+		c.Assert(err, gc.IsNil)
+	}()
+	prechecker := e.(state.Prechecker)
+	err := prechecker.PrecheckInstance("quantal", constraints.MustParse("zones=zone1"), "")
+	c.Assert(err, gc.IsNil)
+	err = prechecker.PrecheckInstance("quantal", constraints.Value{}, "zone=zone2")
+	c.Assert(err, gc.IsNil)
+	err = prechecker.PrecheckInstance("quantal", constraints.Value{}, "zone=nowhere")
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "nowhere"`)
+}
+
 func assertAllocateAddress(c *gc.C, e environs.Environ, opc chan dummy.Operation, expectInstId instance.Id, expectNetId network.Id, expectAddress network.Address) {
 	select {
 	case op := <-opc:
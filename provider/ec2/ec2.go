@@ -76,6 +76,7 @@ var _ imagemetadata.SupportsCustomSources = (*environ)(nil)
 var _ envtools.SupportsCustomSources = (*environ)(nil)
 var _ state.Prechecker = (*environ)(nil)
 var _ state.InstanceDistributor = (*environ)(nil)
+var _ environs.InstancesLimiter = (*environ)(nil)
 
 type ec2Instance struct {
 	e *environ
@@ -381,6 +382,9 @@ func (e *environ) ConstraintsValidator() (constraints.Validator, error) {
 		instTypeNames[i] = itype.Name
 	}
 	validator.RegisterVocabulary(constraints.InstanceType, instTypeNames)
+	// Zones are not registered as a vocabulary, as that would need
+	// an API call every time constraints are validated; the zones
+	// constraint is checked by PrecheckInstance and StartInstance.
 	return validator, nil
 }
 
@@ -479,10 +483,17 @@ func (e *environ) parsePlacement(placement string) (*ec2Placement, error) {
 // PrecheckInstance is defined on the state.Prechecker interface.
 func (e *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
 	if placement != "" {
-		if _, err := e.parsePlacement(placement); err != nil {
+		p, err := e.parsePlacement(placement)
+		if err != nil {
+			return err
+		}
+		if err := common.CheckZonePlacement(p.availabilityZone.Name, cons); err != nil {
 			return err
 		}
 	}
+	if err := common.ValidateZoneConstraint(e, cons); err != nil {
+		return err
+	}
 	if !cons.HasInstanceType() {
 		return nil
 	}
@@ -554,13 +565,19 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		if placement.availabilityZone.State != "available" {
 			return nil, nil, nil, fmt.Errorf("availability zone %q is %s", placement.availabilityZone.Name, placement.availabilityZone.State)
 		}
+		if err := common.CheckZonePlacement(placement.availabilityZone.Name, args.Constraints); err != nil {
+			return nil, nil, nil, err
+		}
 		availabilityZones = append(availabilityZones, placement.availabilityZone.Name)
 	}
 
 	// If no availability zone is specified, then automatically spread across
-	// the known zones for optimal spread across the instance distribution
-	// group.
+	// the known zones (or those allowed by the zones constraint) for optimal
+	// spread across the instance distribution group.
 	if len(availabilityZones) == 0 {
+		if err := common.ValidateZoneConstraint(e, args.Constraints); err != nil {
+			return nil, nil, nil, err
+		}
 		var group []instance.Id
 		var err error
 		if args.DistributionGroup != nil {
@@ -573,9 +590,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		for _, z := range zoneInstances {
-			availabilityZones = append(availabilityZones, z.ZoneName)
-		}
+		availabilityZones = common.ZoneNames(zoneInstances, args.Constraints)
 		if len(availabilityZones) == 0 {
 			return nil, nil, nil, fmt.Errorf("failed to determine availability zones")
 		}
@@ -656,11 +671,12 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	logger.Infof("started instance %q in %q", inst.Id(), inst.Instance.AvailZone)
 
 	hc := instance.HardwareCharacteristics{
-		Arch:     &spec.Image.Arch,
-		Mem:      &spec.InstanceType.Mem,
-		CpuCores: &spec.InstanceType.CpuCores,
-		CpuPower: spec.InstanceType.CpuPower,
-		RootDisk: &diskSize,
+		Arch:             &spec.Image.Arch,
+		Mem:              &spec.InstanceType.Mem,
+		CpuCores:         &spec.InstanceType.CpuCores,
+		CpuPower:         spec.InstanceType.CpuPower,
+		RootDisk:         &diskSize,
+		AvailabilityZone: &inst.Instance.AvailZone,
 		// Tags currently not supported by EC2
 	}
 	return inst, &hc, nil, nil
@@ -781,6 +797,9 @@ func (e *environ) gatherInstances(ids []instance.Id, insts []instance.Instance)
 	}
 	filter.Add("instance-id", need...)
 	resp, err := e.ec2().Instances(nil, filter)
+	if ec2ErrCode(err) == "RequestLimitExceeded" {
+		return errors.Annotate(environs.ErrRateLimited, err.Error())
+	}
 	if err != nil {
 		return err
 	}
@@ -838,6 +857,16 @@ func (e *environ) Instances(ids []instance.Id) ([]instance.Instance, error) {
 	return insts, nil
 }
 
+// maxInstancesPerCall holds the maximum number of values EC2 accepts
+// in a single filter.
+const maxInstancesPerCall = 200
+
+// MaxInstancesPerCall is specified in the environs.InstancesLimiter
+// interface.
+func (*environ) MaxInstancesPerCall() int {
+	return maxInstancesPerCall
+}
+
 // AllocateAddress requests a new address to be allocated for the
 // given instance on the given network. This is not implemented by the
 // EC2 provider yet.
@@ -846,6 +875,12 @@ func (*environ) AllocateAddress(_ instance.Id, _ network.Id) (network.Address, e
 	return network.Address{}, errors.NotImplementedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not implemented by the EC2 provider yet.
+func (*environ) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return errors.NotImplementedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
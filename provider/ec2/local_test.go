@@ -555,6 +555,42 @@ func (t *localServerSuite) TestStartInstanceAvailZoneOneConstrained(c *gc.C) {
 	c.Assert(ec2.InstanceEC2(inst).AvailZone, gc.Equals, "az2")
 }
 
+func (t *localServerSuite) TestStartInstanceZonesConstraint(c *gc.C) {
+	env := t.Prepare(c)
+	envtesting.UploadFakeTools(c, env.Storage())
+	err := bootstrap.Bootstrap(coretesting.Context(c), env, bootstrap.BootstrapParams{})
+	c.Assert(err, gc.IsNil)
+
+	mock := mockAvailabilityZoneAllocations{
+		result: []common.AvailabilityZoneInstances{
+			{ZoneName: "test-impaired"}, {ZoneName: "test-available"},
+		},
+	}
+	t.PatchValue(ec2.AvailabilityZoneAllocations, mock.AvailabilityZoneAllocations)
+
+	var azArgs []string
+	realRunInstances := *ec2.RunInstances
+	t.PatchValue(ec2.RunInstances, func(e *amzec2.EC2, ri *amzec2.RunInstances) (*amzec2.RunInstancesResp, error) {
+		azArgs = append(azArgs, ri.AvailZone)
+		return realRunInstances(e, ri)
+	})
+	cons := constraints.MustParse("zones=test-available")
+	_, hc := testing.AssertStartInstanceWithConstraints(c, env, "1", cons)
+	c.Assert(azArgs, gc.DeepEquals, []string{"test-available"})
+	c.Assert(*hc.AvailabilityZone, gc.Equals, "test-available")
+
+	cons = constraints.MustParse("zones=test-unknown")
+	_, _, _, err = testing.StartInstanceWithConstraints(env, "2", cons)
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "test-unknown"`)
+
+	params := environs.StartInstanceParams{
+		Placement:   "zone=test-available",
+		Constraints: constraints.MustParse("zones=test-impaired"),
+	}
+	_, _, _, err = testing.StartInstanceWithParams(env, "3", params, nil)
+	c.Assert(err, gc.ErrorMatches, `availability zone "test-available" does not satisfy zones constraint "test-impaired"`)
+}
+
 func (t *localServerSuite) TestAddresses(c *gc.C) {
 	env := t.Prepare(c)
 	envtesting.UploadFakeTools(c, env.Storage())
@@ -669,6 +705,16 @@ func (t *localServerSuite) TestPrecheckInstanceAvailZoneUnknown(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `invalid availability zone "test-unknown"`)
 }
 
+func (t *localServerSuite) TestPrecheckInstanceZonesConstraint(c *gc.C) {
+	env := t.Prepare(c)
+	cons := constraints.MustParse("zones=test-available")
+	err := env.PrecheckInstance("precise", cons, "")
+	c.Assert(err, gc.IsNil)
+	cons = constraints.MustParse("zones=test-unknown")
+	err = env.PrecheckInstance("precise", cons, "")
+	c.Assert(err, gc.ErrorMatches, `invalid availability zone "test-unknown"`)
+}
+
 func (t *localServerSuite) TestValidateImageMetadata(c *gc.C) {
 	env := t.Prepare(c)
 	params, err := env.(simplestreams.MetadataValidator).MetadataLookupParams("test")
@@ -57,6 +57,8 @@ func (env *joyentEnviron) machineFullName(machineId string) string {
 var unsupportedConstraints = []string{
 	constraints.CpuPower,
 	constraints.Tags,
+	constraints.Zones,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
@@ -232,6 +234,12 @@ func (*joyentEnviron) AllocateAddress(_ instance.Id, _ network.Id) (network.Addr
 	return network.Address{}, errors.NotImplementedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not implemented on the Joyent provider yet.
+func (*joyentEnviron) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return errors.NotImplementedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known by
 // the provider for the environment. They may be unknown to juju yet
 // (i.e. when called initially or when a new network was created).
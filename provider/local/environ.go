@@ -343,6 +343,8 @@ var unsupportedConstraints = []string{
 	constraints.CpuPower,
 	constraints.InstanceType,
 	constraints.Tags,
+	constraints.Zones,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
@@ -445,6 +447,12 @@ func (*localEnviron) AllocateAddress(_ instance.Id, _ network.Id) (network.Addre
 	return network.Address{}, errors.NotSupportedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not supported on the local provider.
+func (*localEnviron) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return errors.NotSupportedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
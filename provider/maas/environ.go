@@ -367,6 +367,8 @@ sed -i 's/iface %s inet dhcp/iface %s inet manual/' /etc/network/interfaces.d/%s
 var unsupportedConstraints = []string{
 	constraints.CpuPower,
 	constraints.InstanceType,
+	constraints.Zones,
+	constraints.Spaces,
 }
 
 // ConstraintsValidator is defined on the Environs interface.
@@ -652,6 +654,12 @@ func (*maasEnviron) AllocateAddress(_ instance.Id, _ network.Id) (network.Addres
 	return network.Address{}, errors.NotImplementedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not implemented on the MAAS provider yet.
+func (*maasEnviron) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return errors.NotImplementedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
@@ -561,7 +561,18 @@ func (e *environ) ConstraintsValidator() (constraints.Validator, error) {
 	validator.RegisterConflicts(
 		[]string{constraints.InstanceType},
 		[]string{constraints.Mem, constraints.Arch, constraints.RootDisk, constraints.CpuCores})
-	validator.RegisterUnsupported(unsupportedConstraints)
+	unsupported := unsupportedConstraints
+	zoneNames, err := common.AvailabilityZoneNames(e)
+	if jujuerrors.IsNotImplemented(err) {
+		// Availability zones are an extension, so the zones
+		// constraint can only be supported if it is available.
+		unsupported = append(unsupported[:len(unsupported):len(unsupported)], constraints.Zones)
+	} else if err != nil {
+		return nil, err
+	} else {
+		validator.RegisterVocabulary(constraints.Zones, zoneNames)
+	}
+	validator.RegisterUnsupported(unsupported)
 	supportedArches, err := e.SupportedArchitectures()
 	if err != nil {
 		return nil, err
@@ -662,9 +673,16 @@ func (e *environ) parsePlacement(placement string) (*openstackPlacement, error)
 // PrecheckInstance is defined on the state.Prechecker interface.
 func (e *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
 	if placement != "" {
-		if _, err := e.parsePlacement(placement); err != nil {
+		p, err := e.parsePlacement(placement)
+		if err != nil {
 			return err
 		}
+		if err := common.CheckZonePlacement(p.availabilityZone.Name, cons); err != nil {
+			return err
+		}
+	}
+	if err := common.ValidateZoneConstraint(e, cons); err != nil && !jujuerrors.IsNotImplemented(err) {
+		return err
 	}
 	if !cons.HasInstanceType() {
 		return nil
@@ -926,13 +944,19 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		if !placement.availabilityZone.State.Available {
 			return nil, nil, nil, fmt.Errorf("availability zone %q is unavailable", placement.availabilityZone.Name)
 		}
+		if err := common.CheckZonePlacement(placement.availabilityZone.Name, args.Constraints); err != nil {
+			return nil, nil, nil, err
+		}
 		availabilityZone = placement.availabilityZone.Name
 	}
 
 	// If no availability zone is specified, then automatically spread across
-	// the known zones for optimal spread across the instance distribution
-	// group.
+	// the known zones (or those allowed by the zones constraint) for optimal
+	// spread across the instance distribution group.
 	if availabilityZone == "" {
+		if err := common.ValidateZoneConstraint(e, args.Constraints); err != nil && !jujuerrors.IsNotImplemented(err) {
+			return nil, nil, nil, err
+		}
 		var group []instance.Id
 		var err error
 		if args.DistributionGroup != nil {
@@ -947,8 +971,8 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 			// not implemented error; ignore these.
 		} else if err != nil {
 			return nil, nil, nil, err
-		} else if len(zoneInstances) > 0 {
-			availabilityZone = zoneInstances[0].ZoneName
+		} else if zoneNames := common.ZoneNames(zoneInstances, args.Constraints); len(zoneNames) > 0 {
+			availabilityZone = zoneNames[0]
 		}
 	}
 
@@ -1053,7 +1077,14 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		inst.floatingIP = publicIP
 		logger.Infof("assigned public IP %s to %q", publicIP.IP, inst.Id())
 	}
-	return inst, inst.hardwareCharacteristics(), nil, nil
+	hc := inst.hardwareCharacteristics()
+	if detail.AvailabilityZone != "" {
+		availabilityZone = detail.AvailabilityZone
+	}
+	if availabilityZone != "" {
+		hc.AvailabilityZone = &availabilityZone
+	}
+	return inst, hc, nil, nil
 }
 
 func (e *environ) StopInstances(ids ...instance.Id) error {
@@ -1190,6 +1221,12 @@ func (*environ) AllocateAddress(_ instance.Id, _ network.Id) (network.Address, e
 	return network.Address{}, jujuerrors.NotImplementedf("AllocateAddress")
 }
 
+// ReleaseAddress releases a specific address previously allocated with
+// AllocateAddress. This is not implemented on the OpenStack provider yet.
+func (*environ) ReleaseAddress(_ instance.Id, _ network.Id, _ network.Address) error {
+	return jujuerrors.NotImplementedf("ReleaseAddress")
+}
+
 // ListNetworks returns basic information about all networks known
 // by the provider for the environment. They may be unknown to juju
 // yet (i.e. when called initially or when a new network was created).
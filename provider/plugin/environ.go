@@ -0,0 +1,313 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+// environ is an environment opened by a plugin. Each method
+// is forwarded to the plugin's environment with the same id.
+type environ struct {
+	client *client
+	id     string
+
+	mu  sync.Mutex
+	cfg *config.Config
+}
+
+var _ environs.Environ = (*environ)(nil)
+
+func newEnviron(c *client, id string, cfg *config.Config) *environ {
+	return &environ{
+		client: c,
+		id:     id,
+		cfg:    cfg,
+	}
+}
+
+func (e *environ) call(method string, args, result interface{}) error {
+	return e.client.call("Environ", e.id, method, args, result)
+}
+
+// Config is specified in the Environ interface.
+func (e *environ) Config() *config.Config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cfg
+}
+
+// SetConfig is specified in the Environ interface.
+func (e *environ) SetConfig(cfg *config.Config) error {
+	if err := e.call("SetConfig", ConfigParams{cfg.AllAttrs()}, nil); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.cfg = cfg
+	e.mu.Unlock()
+	return nil
+}
+
+// Provider is specified in the Environ interface.
+func (e *environ) Provider() environs.EnvironProvider {
+	return environProvider{}
+}
+
+// Bootstrap is specified in the Environ interface. The plugin
+// keeps the finalizer of its environment, and the returned
+// finalizer asks the plugin to run it.
+func (e *environ) Bootstrap(ctx environs.BootstrapContext, args environs.BootstrapParams) (arch, series string, _ environs.BootstrapFinalizer, _ error) {
+	var result BootstrapResult
+	err := e.call("Bootstrap", BootstrapParams{
+		Constraints:    args.Constraints,
+		Placement:      args.Placement,
+		AvailableTools: args.AvailableTools,
+	}, &result)
+	if err != nil {
+		return "", "", nil, err
+	}
+	finalize := func(ctx environs.BootstrapContext, mcfg *cloudinit.MachineConfig) error {
+		return e.call("FinalizeBootstrap", FinalizeBootstrapParams{
+			MachineConfig: toWireMachineConfig(mcfg),
+		}, nil)
+	}
+	return result.Arch, result.Series, finalize, nil
+}
+
+// StartInstance is specified in the InstanceBroker interface.
+func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Instance, *instance.HardwareCharacteristics, []network.Info, error) {
+	startArgs := StartInstanceParams{
+		Constraints:   args.Constraints,
+		Tools:         args.Tools,
+		MachineConfig: toWireMachineConfig(args.MachineConfig),
+		Placement:     args.Placement,
+	}
+	if args.DistributionGroup != nil {
+		group, err := args.DistributionGroup()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		startArgs.DistributionGroup = group
+	}
+	var result StartInstanceResult
+	if err := e.call("StartInstance", startArgs, &result); err != nil {
+		return nil, nil, nil, err
+	}
+	return newInstance(e, result.Instance), result.Hardware, result.Networks, nil
+}
+
+// StopInstances is specified in the InstanceBroker interface.
+func (e *environ) StopInstances(ids ...instance.Id) error {
+	return e.call("StopInstances", InstanceIds{ids}, nil)
+}
+
+// AllInstances is specified in the InstanceBroker interface.
+func (e *environ) AllInstances() ([]instance.Instance, error) {
+	var result InstancesResult
+	if err := e.call("AllInstances", nil, &result); err != nil {
+		return nil, err
+	}
+	insts := make([]instance.Instance, len(result.Instances))
+	for i, info := range result.Instances {
+		insts[i] = newInstance(e, *info)
+	}
+	return insts, nil
+}
+
+// Instances is specified in the Environ interface.
+func (e *environ) Instances(ids []instance.Id) ([]instance.Instance, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var result InstancesResult
+	if err := e.call("Instances", InstanceIds{ids}, &result); err != nil {
+		return nil, err
+	}
+	insts := make([]instance.Instance, len(result.Instances))
+	found := 0
+	for i, info := range result.Instances {
+		if info != nil {
+			insts[i] = newInstance(e, *info)
+			found++
+		}
+	}
+	switch found {
+	case 0:
+		return nil, environs.ErrNoInstances
+	case len(ids):
+		return insts, nil
+	}
+	return insts, environs.ErrPartialInstances
+}
+
+// StateServerInstances is specified in the Environ interface.
+func (e *environ) StateServerInstances() ([]instance.Id, error) {
+	var result InstanceIds
+	if err := e.call("StateServerInstances", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Ids, nil
+}
+
+// AllocateAddress is specified in the Environ interface.
+func (e *environ) AllocateAddress(instId instance.Id, netId network.Id) (network.Address, error) {
+	var result AddressResult
+	err := e.call("AllocateAddress", AllocateAddressParams{
+		InstanceId: instId,
+		NetworkId:  netId,
+	}, &result)
+	return result.Address, err
+}
+
+// ReleaseAddress is specified in the Environ interface.
+func (e *environ) ReleaseAddress(instId instance.Id, netId network.Id, addr network.Address) error {
+	return e.call("ReleaseAddress", ReleaseAddressParams{
+		InstanceId: instId,
+		NetworkId:  netId,
+		Address:    addr,
+	}, nil)
+}
+
+// ListNetworks is specified in the Environ interface.
+func (e *environ) ListNetworks() ([]network.BasicInfo, error) {
+	var result NetworksResult
+	if err := e.call("ListNetworks", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Networks, nil
+}
+
+// SupportedArchitectures is specified in the EnvironCapability interface.
+func (e *environ) SupportedArchitectures() ([]string, error) {
+	var result StringsResult
+	if err := e.call("SupportedArchitectures", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// SupportNetworks is specified in the EnvironCapability interface.
+func (e *environ) SupportNetworks() bool {
+	var result BoolResult
+	if err := e.call("SupportNetworks", nil, &result); err != nil {
+		logger.Warningf("cannot determine network support: %v", err)
+		return false
+	}
+	return result.Result
+}
+
+// SupportsUnitPlacement is specified in the EnvironCapability interface.
+func (e *environ) SupportsUnitPlacement() error {
+	return e.call("SupportsUnitPlacement", nil, nil)
+}
+
+// PrecheckInstance is specified in the state.Prechecker interface.
+func (e *environ) PrecheckInstance(series string, cons constraints.Value, placement string) error {
+	return e.call("PrecheckInstance", PrecheckParams{
+		Series:      series,
+		Constraints: cons,
+		Placement:   placement,
+	}, nil)
+}
+
+// ConstraintsValidator is specified in the Environ interface.
+func (e *environ) ConstraintsValidator() (constraints.Validator, error) {
+	return &validator{env: e}, nil
+}
+
+// Storage is specified in the Environ interface.
+func (e *environ) Storage() storage.Storage {
+	return &environStorage{env: e}
+}
+
+// Destroy is specified in the Environ interface.
+func (e *environ) Destroy() error {
+	return e.call("Destroy", nil, nil)
+}
+
+// OpenPorts is specified in the Environ interface.
+func (e *environ) OpenPorts(ports []network.PortRange) error {
+	return e.call("OpenPorts", PortsParams{Ports: ports}, nil)
+}
+
+// ClosePorts is specified in the Environ interface.
+func (e *environ) ClosePorts(ports []network.PortRange) error {
+	return e.call("ClosePorts", PortsParams{Ports: ports}, nil)
+}
+
+// Ports is specified in the Environ interface.
+func (e *environ) Ports() ([]network.PortRange, error) {
+	var result PortsResult
+	if err := e.call("Ports", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Ports, nil
+}
+
+// validator is the constraints validator of a plugin environment.
+// Rules registered with it are sent with each call to the plugin,
+// which applies them to its own environment's validator.
+type validator struct {
+	env   *environ
+	rules ValidatorRules
+}
+
+// RegisterConflicts is defined on constraints.Validator.
+func (v *validator) RegisterConflicts(reds, blues []string) {
+	v.rules.Conflicts = append(v.rules.Conflicts, ConflictRule{reds, blues})
+}
+
+// RegisterUnsupported is defined on constraints.Validator.
+func (v *validator) RegisterUnsupported(unsupported []string) {
+	v.rules.Unsupported = append([]string{}, unsupported...)
+}
+
+// RegisterVocabulary is defined on constraints.Validator.
+func (v *validator) RegisterVocabulary(attributeName string, allowedValues interface{}) {
+	val := reflect.ValueOf(allowedValues)
+	values := make([]interface{}, val.Len())
+	for i := range values {
+		values[i] = val.Index(i).Interface()
+	}
+	if v.rules.Vocabularies == nil {
+		v.rules.Vocabularies = make(map[string][]interface{})
+	}
+	v.rules.Vocabularies[attributeName] = values
+}
+
+// Validate is defined on constraints.Validator.
+func (v *validator) Validate(cons constraints.Value) ([]string, error) {
+	var result StringsResult
+	err := v.env.call("ValidateConstraints", ConstraintsParams{
+		Rules:       v.rules,
+		Constraints: cons,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// Merge is defined on constraints.Validator.
+func (v *validator) Merge(consFallback, cons constraints.Value) (constraints.Value, error) {
+	var result ConstraintsResult
+	err := v.env.call("MergeConstraints", ConstraintsParams{
+		Rules:       v.rules,
+		Constraints: cons,
+		Fallback:    consFallback,
+	}, &result)
+	if err != nil {
+		return constraints.Value{}, err
+	}
+	return result.Constraints, nil
+}
@@ -0,0 +1,463 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/instance"
+)
+
+// environState holds an environment opened by a plugin.
+type environState struct {
+	srv  *server
+	name string
+	env  environs.Environ
+
+	mu        sync.Mutex
+	finalize  environs.BootstrapFinalizer
+	instances map[instance.Id]instance.Instance
+}
+
+func newEnvironState(srv *server, name string, env environs.Environ) *environState {
+	return &environState{
+		srv:       srv,
+		name:      name,
+		env:       env,
+		instances: make(map[instance.Id]instance.Instance),
+	}
+}
+
+// instanceInfo records inst so that later calls can refer
+// to it by id, and returns its description.
+func (st *environState) instanceInfo(inst instance.Instance) *InstanceInfo {
+	if inst == nil {
+		return nil
+	}
+	st.mu.Lock()
+	st.instances[inst.Id()] = inst
+	st.mu.Unlock()
+	return &InstanceInfo{
+		Id:     inst.Id(),
+		Status: inst.Status(),
+	}
+}
+
+// instance returns the instance with the given id, asking
+// the environment for it if it has not been seen before.
+func (st *environState) instance(id instance.Id) (instance.Instance, error) {
+	st.mu.Lock()
+	inst := st.instances[id]
+	st.mu.Unlock()
+	if inst != nil {
+		return inst, nil
+	}
+	insts, err := st.env.Instances([]instance.Id{id})
+	if err == environs.ErrNoInstances {
+		return nil, errors.NotFoundf("instance %q", id)
+	} else if err != nil {
+		return nil, err
+	}
+	st.instanceInfo(insts[0])
+	return insts[0], nil
+}
+
+// environAPI is the facade for an environment opened by a plugin.
+type environAPI struct {
+	st *environState
+}
+
+// Config implements environs.Environ.Config.
+func (api *environAPI) Config() ConfigParams {
+	return ConfigParams{pluginAttrs(api.st.env.Config(), api.st.name)}
+}
+
+// SetConfig implements environs.Environ.SetConfig.
+func (api *environAPI) SetConfig(args ConfigParams) error {
+	cfg, _, err := api.st.srv.providerConfig(args.Attrs)
+	if err != nil {
+		return err
+	}
+	return api.st.env.SetConfig(cfg)
+}
+
+// Bootstrap implements environs.Environ.Bootstrap. The returned
+// finalizer is kept to be run by FinalizeBootstrap.
+func (api *environAPI) Bootstrap(args BootstrapParams) (BootstrapResult, error) {
+	arch, series, finalize, err := api.st.env.Bootstrap(api.st.srv.ctx, environs.BootstrapParams{
+		Constraints:    args.Constraints,
+		Placement:      args.Placement,
+		AvailableTools: args.AvailableTools,
+	})
+	if err != nil {
+		return BootstrapResult{}, err
+	}
+	api.st.mu.Lock()
+	api.st.finalize = finalize
+	api.st.mu.Unlock()
+	return BootstrapResult{Arch: arch, Series: series}, nil
+}
+
+// FinalizeBootstrap runs the finalizer returned by the
+// last call to Bootstrap.
+func (api *environAPI) FinalizeBootstrap(args FinalizeBootstrapParams) error {
+	api.st.mu.Lock()
+	finalize := api.st.finalize
+	api.st.finalize = nil
+	api.st.mu.Unlock()
+	if finalize == nil {
+		return errors.New("environment is not being bootstrapped")
+	}
+	mcfg, err := fromWireMachineConfig(args.MachineConfig)
+	if err != nil {
+		return err
+	}
+	return finalize(api.st.srv.ctx, mcfg)
+}
+
+// StartInstance implements environs.InstanceBroker.StartInstance.
+func (api *environAPI) StartInstance(args StartInstanceParams) (StartInstanceResult, error) {
+	mcfg, err := fromWireMachineConfig(args.MachineConfig)
+	if err != nil {
+		return StartInstanceResult{}, err
+	}
+	startArgs := environs.StartInstanceParams{
+		Constraints:   args.Constraints,
+		Tools:         args.Tools,
+		MachineConfig: mcfg,
+		Placement:     args.Placement,
+	}
+	if args.DistributionGroup != nil {
+		group := args.DistributionGroup
+		startArgs.DistributionGroup = func() ([]instance.Id, error) {
+			return group, nil
+		}
+	}
+	inst, hc, networks, err := api.st.env.StartInstance(startArgs)
+	if err != nil {
+		return StartInstanceResult{}, err
+	}
+	return StartInstanceResult{
+		Instance: *api.st.instanceInfo(inst),
+		Hardware: hc,
+		Networks: networks,
+	}, nil
+}
+
+// StopInstances implements environs.InstanceBroker.StopInstances.
+func (api *environAPI) StopInstances(args InstanceIds) error {
+	if err := api.st.env.StopInstances(args.Ids...); err != nil {
+		return err
+	}
+	api.st.mu.Lock()
+	defer api.st.mu.Unlock()
+	for _, id := range args.Ids {
+		delete(api.st.instances, id)
+	}
+	return nil
+}
+
+// AllInstances implements environs.InstanceBroker.AllInstances.
+func (api *environAPI) AllInstances() (InstancesResult, error) {
+	insts, err := api.st.env.AllInstances()
+	if err != nil {
+		return InstancesResult{}, err
+	}
+	return api.instancesResult(insts), nil
+}
+
+// Instances implements environs.Environ.Instances. Instances
+// that are not found are returned as nil entries rather than
+// with an error.
+func (api *environAPI) Instances(args InstanceIds) (InstancesResult, error) {
+	insts, err := api.st.env.Instances(args.Ids)
+	switch err {
+	case nil, environs.ErrPartialInstances:
+	case environs.ErrNoInstances:
+		insts = make([]instance.Instance, len(args.Ids))
+	default:
+		return InstancesResult{}, err
+	}
+	return api.instancesResult(insts), nil
+}
+
+func (api *environAPI) instancesResult(insts []instance.Instance) InstancesResult {
+	result := InstancesResult{
+		Instances: make([]*InstanceInfo, len(insts)),
+	}
+	for i, inst := range insts {
+		result.Instances[i] = api.st.instanceInfo(inst)
+	}
+	return result
+}
+
+// StateServerInstances implements environs.Environ.StateServerInstances.
+func (api *environAPI) StateServerInstances() (InstanceIds, error) {
+	ids, err := api.st.env.StateServerInstances()
+	if err != nil {
+		return InstanceIds{}, err
+	}
+	return InstanceIds{ids}, nil
+}
+
+// AllocateAddress implements environs.Environ.AllocateAddress.
+func (api *environAPI) AllocateAddress(args AllocateAddressParams) (AddressResult, error) {
+	addr, err := api.st.env.AllocateAddress(args.InstanceId, args.NetworkId)
+	if err != nil {
+		return AddressResult{}, err
+	}
+	return AddressResult{addr}, nil
+}
+
+// ReleaseAddress implements environs.Environ.ReleaseAddress.
+func (api *environAPI) ReleaseAddress(args ReleaseAddressParams) error {
+	return api.st.env.ReleaseAddress(args.InstanceId, args.NetworkId, args.Address)
+}
+
+// ListNetworks implements environs.Environ.ListNetworks.
+func (api *environAPI) ListNetworks() (NetworksResult, error) {
+	networks, err := api.st.env.ListNetworks()
+	if err != nil {
+		return NetworksResult{}, err
+	}
+	return NetworksResult{networks}, nil
+}
+
+// SupportedArchitectures implements
+// state.EnvironCapability.SupportedArchitectures.
+func (api *environAPI) SupportedArchitectures() (StringsResult, error) {
+	arches, err := api.st.env.SupportedArchitectures()
+	if err != nil {
+		return StringsResult{}, err
+	}
+	return StringsResult{arches}, nil
+}
+
+// SupportNetworks implements state.EnvironCapability.SupportNetworks.
+func (api *environAPI) SupportNetworks() BoolResult {
+	return BoolResult{api.st.env.SupportNetworks()}
+}
+
+// SupportsUnitPlacement implements
+// state.EnvironCapability.SupportsUnitPlacement.
+func (api *environAPI) SupportsUnitPlacement() error {
+	return api.st.env.SupportsUnitPlacement()
+}
+
+// PrecheckInstance implements state.Prechecker.PrecheckInstance.
+func (api *environAPI) PrecheckInstance(args PrecheckParams) error {
+	return api.st.env.PrecheckInstance(args.Series, args.Constraints, args.Placement)
+}
+
+// validator returns the environment's constraints validator
+// with the given rules applied.
+func (api *environAPI) validator(rules ValidatorRules) (constraints.Validator, error) {
+	validator, err := api.st.env.ConstraintsValidator()
+	if err != nil {
+		return nil, err
+	}
+	for _, conflict := range rules.Conflicts {
+		validator.RegisterConflicts(conflict.Reds, conflict.Blues)
+	}
+	if rules.Unsupported != nil {
+		validator.RegisterUnsupported(rules.Unsupported)
+	}
+	for attr, values := range rules.Vocabularies {
+		validator.RegisterVocabulary(attr, values)
+	}
+	return validator, nil
+}
+
+// ValidateConstraints implements constraints.Validator.Validate
+// for the environment's constraints validator.
+func (api *environAPI) ValidateConstraints(args ConstraintsParams) (StringsResult, error) {
+	validator, err := api.validator(args.Rules)
+	if err != nil {
+		return StringsResult{}, err
+	}
+	unsupported, err := validator.Validate(args.Constraints)
+	if err != nil {
+		return StringsResult{}, err
+	}
+	return StringsResult{unsupported}, nil
+}
+
+// MergeConstraints implements constraints.Validator.Merge
+// for the environment's constraints validator.
+func (api *environAPI) MergeConstraints(args ConstraintsParams) (ConstraintsResult, error) {
+	validator, err := api.validator(args.Rules)
+	if err != nil {
+		return ConstraintsResult{}, err
+	}
+	cons, err := validator.Merge(args.Fallback, args.Constraints)
+	if err != nil {
+		return ConstraintsResult{}, err
+	}
+	return ConstraintsResult{cons}, nil
+}
+
+// Destroy implements environs.Environ.Destroy.
+func (api *environAPI) Destroy() error {
+	return api.st.env.Destroy()
+}
+
+// OpenPorts implements environs.Environ.OpenPorts.
+func (api *environAPI) OpenPorts(args PortsParams) error {
+	return api.st.env.OpenPorts(args.Ports)
+}
+
+// ClosePorts implements environs.Environ.ClosePorts.
+func (api *environAPI) ClosePorts(args PortsParams) error {
+	return api.st.env.ClosePorts(args.Ports)
+}
+
+// Ports implements environs.Environ.Ports.
+func (api *environAPI) Ports() (PortsResult, error) {
+	ports, err := api.st.env.Ports()
+	if err != nil {
+		return PortsResult{}, err
+	}
+	return PortsResult{ports}, nil
+}
+
+// InstanceRefresh implements instance.Instance.Refresh,
+// returning the refreshed instance.
+func (api *environAPI) InstanceRefresh(args InstanceId) (InstanceInfo, error) {
+	inst, err := api.st.instance(args.Id)
+	if err != nil {
+		return InstanceInfo{}, err
+	}
+	if err := inst.Refresh(); err != nil {
+		return InstanceInfo{}, err
+	}
+	return *api.st.instanceInfo(inst), nil
+}
+
+// InstanceAddresses implements instance.Instance.Addresses.
+func (api *environAPI) InstanceAddresses(args InstanceId) (AddressesResult, error) {
+	inst, err := api.st.instance(args.Id)
+	if err != nil {
+		return AddressesResult{}, err
+	}
+	addrs, err := inst.Addresses()
+	if err != nil {
+		return AddressesResult{}, err
+	}
+	return AddressesResult{addrs}, nil
+}
+
+// InstanceOpenPorts implements instance.Instance.OpenPorts.
+func (api *environAPI) InstanceOpenPorts(args PortsParams) error {
+	inst, err := api.st.instance(args.InstanceId)
+	if err != nil {
+		return err
+	}
+	return inst.OpenPorts(args.MachineId, args.Ports)
+}
+
+// InstanceClosePorts implements instance.Instance.ClosePorts.
+func (api *environAPI) InstanceClosePorts(args PortsParams) error {
+	inst, err := api.st.instance(args.InstanceId)
+	if err != nil {
+		return err
+	}
+	return inst.ClosePorts(args.MachineId, args.Ports)
+}
+
+// InstancePorts implements instance.Instance.Ports.
+func (api *environAPI) InstancePorts(args PortsParams) (PortsResult, error) {
+	inst, err := api.st.instance(args.InstanceId)
+	if err != nil {
+		return PortsResult{}, err
+	}
+	ports, err := inst.Ports(args.MachineId)
+	if err != nil {
+		return PortsResult{}, err
+	}
+	return PortsResult{ports}, nil
+}
+
+// storageError returns the error to send for an error
+// returned by the environment's storage.
+func storageError(stor storage.Storage, err error) error {
+	if err == nil || errors.IsNotFound(err) {
+		return err
+	}
+	if stor.ShouldRetry(err) {
+		return &retryableError{err}
+	}
+	return err
+}
+
+// StorageGet implements storage.StorageReader.Get.
+func (api *environAPI) StorageGet(args StorageName) (StorageData, error) {
+	stor := api.st.env.Storage()
+	r, err := stor.Get(args.Name)
+	if err != nil {
+		return StorageData{}, storageError(stor, err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return StorageData{}, storageError(stor, err)
+	}
+	return StorageData{data}, nil
+}
+
+// StorageList implements storage.StorageReader.List.
+func (api *environAPI) StorageList(args StorageName) (StringsResult, error) {
+	stor := api.st.env.Storage()
+	names, err := stor.List(args.Name)
+	if err != nil {
+		return StringsResult{}, storageError(stor, err)
+	}
+	return StringsResult{names}, nil
+}
+
+// StorageURL implements storage.StorageReader.URL.
+func (api *environAPI) StorageURL(args StorageName) (StringResult, error) {
+	stor := api.st.env.Storage()
+	url, err := stor.URL(args.Name)
+	if err != nil {
+		return StringResult{}, storageError(stor, err)
+	}
+	return StringResult{url}, nil
+}
+
+// StorageConsistency implements
+// storage.StorageReader.DefaultConsistencyStrategy.
+func (api *environAPI) StorageConsistency() ConsistencyResult {
+	attempt := api.st.env.Storage().DefaultConsistencyStrategy()
+	return ConsistencyResult{
+		Total: attempt.Total,
+		Delay: attempt.Delay,
+		Min:   attempt.Min,
+	}
+}
+
+// StoragePut implements storage.StorageWriter.Put.
+func (api *environAPI) StoragePut(args StoragePutParams) error {
+	stor := api.st.env.Storage()
+	err := stor.Put(args.Name, bytes.NewReader(args.Data), int64(len(args.Data)))
+	return storageError(stor, err)
+}
+
+// StorageRemove implements storage.StorageWriter.Remove.
+func (api *environAPI) StorageRemove(args StorageName) error {
+	stor := api.st.env.Storage()
+	return storageError(stor, stor.Remove(args.Name))
+}
+
+// StorageRemoveAll implements storage.StorageWriter.RemoveAll.
+func (api *environAPI) StorageRemoveAll() error {
+	stor := api.st.env.Storage()
+	return storageError(stor, stor.RemoveAll())
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/rpc"
+)
+
+// Error codes sent by plugins for errors that juju
+// treats specially.
+const (
+	CodeNotFound        = "not found"
+	CodeNoInstances     = "no instances"
+	CodeNotBootstrapped = "not bootstrapped"
+	CodeRetry           = "retry"
+)
+
+// Error is the error sent by a plugin in response to a call.
+type Error struct {
+	Message string
+	Code    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCode implements rpc.ErrorCoder.
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+// retryableError wraps a storage error for
+// which the storage's ShouldRetry returns true.
+type retryableError struct {
+	error
+}
+
+// serverError returns the error to send to the
+// client for an error returned by a provider.
+func serverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := ""
+	switch cause := errors.Cause(err); {
+	case errors.IsNotFound(err):
+		code = CodeNotFound
+	case cause == environs.ErrNoInstances:
+		code = CodeNoInstances
+	case cause == environs.ErrNotBootstrapped:
+		code = CodeNotBootstrapped
+	default:
+		if _, ok := err.(*retryableError); ok {
+			code = CodeRetry
+		}
+	}
+	return &Error{
+		Message: err.Error(),
+		Code:    code,
+	}
+}
+
+// clientError returns the error to return to the caller for
+// an error returned by a call to a plugin.
+func clientError(err error) error {
+	rpcErr, ok := err.(*rpc.RequestError)
+	if !ok {
+		return err
+	}
+	switch rpcErr.Code {
+	case CodeNotFound:
+		return errors.NewNotFound(nil, rpcErr.Message)
+	case CodeNoInstances:
+		return environs.ErrNoInstances
+	case CodeNotBootstrapped:
+		return environs.ErrNotBootstrapped
+	case CodeRetry:
+		return &retryableError{errors.New(rpcErr.Message)}
+	case rpc.CodeNotImplemented:
+		return rpcErr
+	}
+	return errors.New(rpcErr.Message)
+}
+
+// isNotImplemented reports whether err was returned
+// because the plugin does not implement a call.
+func isNotImplemented(err error) bool {
+	rpcErr, ok := err.(*rpc.RequestError)
+	return ok && rpcErr.Code == rpc.CodeNotImplemented
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"sync"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+// pluginInstance is an instance in a plugin environment.
+type pluginInstance struct {
+	env *environ
+	id  instance.Id
+
+	mu     sync.Mutex
+	status string
+}
+
+var _ instance.Instance = (*pluginInstance)(nil)
+
+func newInstance(env *environ, info InstanceInfo) *pluginInstance {
+	return &pluginInstance{
+		env:    env,
+		id:     info.Id,
+		status: info.Status,
+	}
+}
+
+// Id is specified in the Instance interface.
+func (inst *pluginInstance) Id() instance.Id {
+	return inst.id
+}
+
+// Status is specified in the Instance interface. It returns
+// the status as of the last call to Refresh.
+func (inst *pluginInstance) Status() string {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	return inst.status
+}
+
+// Refresh is specified in the Instance interface.
+func (inst *pluginInstance) Refresh() error {
+	var info InstanceInfo
+	if err := inst.env.call("InstanceRefresh", InstanceId{inst.id}, &info); err != nil {
+		return err
+	}
+	inst.mu.Lock()
+	inst.status = info.Status
+	inst.mu.Unlock()
+	return nil
+}
+
+// Addresses is specified in the Instance interface.
+func (inst *pluginInstance) Addresses() ([]network.Address, error) {
+	var result AddressesResult
+	if err := inst.env.call("InstanceAddresses", InstanceId{inst.id}, &result); err != nil {
+		return nil, err
+	}
+	return result.Addresses, nil
+}
+
+// OpenPorts is specified in the Instance interface.
+func (inst *pluginInstance) OpenPorts(machineId string, ports []network.PortRange) error {
+	return inst.env.call("InstanceOpenPorts", PortsParams{
+		InstanceId: inst.id,
+		MachineId:  machineId,
+		Ports:      ports,
+	}, nil)
+}
+
+// ClosePorts is specified in the Instance interface.
+func (inst *pluginInstance) ClosePorts(machineId string, ports []network.PortRange) error {
+	return inst.env.call("InstanceClosePorts", PortsParams{
+		InstanceId: inst.id,
+		MachineId:  machineId,
+		Ports:      ports,
+	}, nil)
+}
+
+// Ports is specified in the Instance interface.
+func (inst *pluginInstance) Ports(machineId string) ([]network.PortRange, error) {
+	var result PortsResult
+	err := inst.env.call("InstancePorts", PortsParams{
+		InstanceId: inst.id,
+		MachineId:  machineId,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Ports, nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	"github.com/juju/utils/proxy"
+
+	"github.com/juju/juju/api"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/tools"
+)
+
+// The types in this file are the parameters and results of the
+// calls made to a plugin. They are encoded as JSON.
+
+// InfoResult holds the result of the Plugin.Info call.
+type InfoResult struct {
+	ProtocolVersion int
+	ProviderType    string
+}
+
+// ConfigParams holds the attributes of an environment configuration.
+type ConfigParams struct {
+	Attrs map[string]interface{}
+}
+
+// ValidateParams holds the parameters of the Provider.Validate call.
+// OldAttrs is nil when there is no previous configuration.
+type ValidateParams struct {
+	Attrs    map[string]interface{}
+	OldAttrs map[string]interface{}
+}
+
+// OpenResult holds the result of the Provider.Prepare and
+// Provider.Open calls.
+type OpenResult struct {
+	EnvironId string
+	Attrs     map[string]interface{}
+}
+
+// SecretAttrsResult holds the result of the Provider.SecretAttrs call.
+type SecretAttrsResult struct {
+	Attrs map[string]string
+}
+
+// StringResult holds a single string result.
+type StringResult struct {
+	Result string
+}
+
+// StringsResult holds a list of strings.
+type StringsResult struct {
+	Result []string
+}
+
+// BoolResult holds a single boolean result.
+type BoolResult struct {
+	Result bool
+}
+
+// BootstrapParams holds the parameters of the Environ.Bootstrap call.
+type BootstrapParams struct {
+	Constraints    constraints.Value
+	Placement      string
+	AvailableTools tools.List
+}
+
+// BootstrapResult holds the result of the Environ.Bootstrap call.
+type BootstrapResult struct {
+	Arch   string
+	Series string
+}
+
+// FinalizeBootstrapParams holds the parameters of the
+// Environ.FinalizeBootstrap call.
+type FinalizeBootstrapParams struct {
+	MachineConfig MachineConfig
+}
+
+// StartInstanceParams holds the parameters of the
+// Environ.StartInstance call. DistributionGroup is evaluated
+// by the caller before the call is made.
+type StartInstanceParams struct {
+	Constraints       constraints.Value
+	Tools             tools.List
+	MachineConfig     MachineConfig
+	Placement         string
+	DistributionGroup []instance.Id
+}
+
+// InstanceInfo describes an instance.
+type InstanceInfo struct {
+	Id     instance.Id
+	Status string
+}
+
+// StartInstanceResult holds the result of the Environ.StartInstance call.
+type StartInstanceResult struct {
+	Instance InstanceInfo
+	Hardware *instance.HardwareCharacteristics
+	Networks []network.Info
+}
+
+// InstanceIds holds a list of instance ids.
+type InstanceIds struct {
+	Ids []instance.Id
+}
+
+// InstancesResult holds the result of the Environ.Instances and
+// Environ.AllInstances calls. An entry is nil when the instance
+// with the corresponding id was not found.
+type InstancesResult struct {
+	Instances []*InstanceInfo
+}
+
+// InstanceId identifies a single instance.
+type InstanceId struct {
+	Id instance.Id
+}
+
+// AllocateAddressParams holds the parameters of the
+// Environ.AllocateAddress call.
+type AllocateAddressParams struct {
+	InstanceId instance.Id
+	NetworkId  network.Id
+}
+
+// ReleaseAddressParams holds the parameters of the
+// Environ.ReleaseAddress call.
+type ReleaseAddressParams struct {
+	InstanceId instance.Id
+	NetworkId  network.Id
+	Address    network.Address
+}
+
+// AddressResult holds a single address.
+type AddressResult struct {
+	Address network.Address
+}
+
+// AddressesResult holds a list of addresses.
+type AddressesResult struct {
+	Addresses []network.Address
+}
+
+// NetworksResult holds the result of the Environ.ListNetworks call.
+type NetworksResult struct {
+	Networks []network.BasicInfo
+}
+
+// PortsParams holds a list of port ranges to open or close. InstanceId
+// and MachineId are set only when the ports belong to an instance.
+type PortsParams struct {
+	InstanceId instance.Id
+	MachineId  string
+	Ports      []network.PortRange
+}
+
+// PortsResult holds a list of opened port ranges.
+type PortsResult struct {
+	Ports []network.PortRange
+}
+
+// PrecheckParams holds the parameters of the
+// Environ.PrecheckInstance call.
+type PrecheckParams struct {
+	Series      string
+	Constraints constraints.Value
+	Placement   string
+}
+
+// ValidatorRules holds the rules registered with a constraints
+// validator by the caller, which are applied to the plugin's own
+// validator before it is used.
+type ValidatorRules struct {
+	Conflicts    []ConflictRule
+	Unsupported  []string
+	Vocabularies map[string][]interface{}
+}
+
+// ConflictRule holds a pair of conflicting constraint attributes.
+type ConflictRule struct {
+	Reds  []string
+	Blues []string
+}
+
+// ConstraintsParams holds the parameters of the
+// Environ.ValidateConstraints and Environ.MergeConstraints calls.
+type ConstraintsParams struct {
+	Rules       ValidatorRules
+	Constraints constraints.Value
+	Fallback    constraints.Value
+}
+
+// ConstraintsResult holds a constraints value.
+type ConstraintsResult struct {
+	Constraints constraints.Value
+}
+
+// StorageName holds the name of a file in environment storage, or
+// a prefix when listing.
+type StorageName struct {
+	Name string
+}
+
+// StorageData holds the contents of a file in environment storage.
+type StorageData struct {
+	Data []byte
+}
+
+// StoragePutParams holds the parameters of the Environ.StoragePut call.
+type StoragePutParams struct {
+	Name string
+	Data []byte
+}
+
+// ConsistencyResult holds the default consistency strategy of
+// environment storage.
+type ConsistencyResult struct {
+	Total time.Duration
+	Delay time.Duration
+	Min   int
+}
+
+// MongoInfo is the wire form of mongo.MongoInfo.
+type MongoInfo struct {
+	Addrs    []string
+	CACert   string
+	Tag      string
+	Password string
+}
+
+// APIInfo is the wire form of api.Info.
+type APIInfo struct {
+	Addrs      []string
+	CACert     string
+	Tag        string
+	Password   string
+	Nonce      string
+	EnvironTag string
+}
+
+// MachineConfig is the wire form of cloudinit.MachineConfig.
+type MachineConfig struct {
+	Bootstrap                      bool
+	StateServingInfo               *state.StateServingInfo
+	MongoInfo                      *MongoInfo
+	APIInfo                        *APIInfo
+	InstanceId                     instance.Id
+	HardwareCharacteristics        *instance.HardwareCharacteristics
+	MachineNonce                   string
+	Tools                          *tools.Tools
+	DataDir                        string
+	LogDir                         string
+	Jobs                           []params.MachineJob
+	CloudInitOutputLog             string
+	MachineId                      string
+	MachineContainerType           instance.ContainerType
+	Networks                       []string
+	AuthorizedKeys                 string
+	AgentEnvironment               map[string]string
+	Config                         map[string]interface{}
+	Constraints                    constraints.Value
+	DisableSSLHostnameVerification bool
+	Series                         string
+	MachineAgentServiceName        string
+	ProxySettings                  proxy.Settings
+	AptProxySettings               proxy.Settings
+	PreferIPv6                     bool
+	ImageStream                    string
+	EnableOSRefreshUpdate          bool
+	EnableOSUpgrade                bool
+}
+
+// toWireMachineConfig returns the wire form of mcfg.
+func toWireMachineConfig(mcfg *cloudinit.MachineConfig) MachineConfig {
+	if mcfg == nil {
+		return MachineConfig{}
+	}
+	wire := MachineConfig{
+		Bootstrap:                      mcfg.Bootstrap,
+		StateServingInfo:               mcfg.StateServingInfo,
+		InstanceId:                     mcfg.InstanceId,
+		HardwareCharacteristics:        mcfg.HardwareCharacteristics,
+		MachineNonce:                   mcfg.MachineNonce,
+		Tools:                          mcfg.Tools,
+		DataDir:                        mcfg.DataDir,
+		LogDir:                         mcfg.LogDir,
+		Jobs:                           mcfg.Jobs,
+		CloudInitOutputLog:             mcfg.CloudInitOutputLog,
+		MachineId:                      mcfg.MachineId,
+		MachineContainerType:           mcfg.MachineContainerType,
+		Networks:                       mcfg.Networks,
+		AuthorizedKeys:                 mcfg.AuthorizedKeys,
+		AgentEnvironment:               mcfg.AgentEnvironment,
+		Constraints:                    mcfg.Constraints,
+		DisableSSLHostnameVerification: mcfg.DisableSSLHostnameVerification,
+		Series:                         mcfg.Series,
+		MachineAgentServiceName:        mcfg.MachineAgentServiceName,
+		ProxySettings:                  mcfg.ProxySettings,
+		AptProxySettings:               mcfg.AptProxySettings,
+		PreferIPv6:                     mcfg.PreferIPv6,
+		ImageStream:                    mcfg.ImageStream,
+		EnableOSRefreshUpdate:          mcfg.EnableOSRefreshUpdate,
+		EnableOSUpgrade:                mcfg.EnableOSUpgrade,
+	}
+	if info := mcfg.MongoInfo; info != nil {
+		wire.MongoInfo = &MongoInfo{
+			Addrs:    info.Addrs,
+			CACert:   info.CACert,
+			Tag:      tagString(info.Tag),
+			Password: info.Password,
+		}
+	}
+	if info := mcfg.APIInfo; info != nil {
+		wire.APIInfo = &APIInfo{
+			Addrs:      info.Addrs,
+			CACert:     info.CACert,
+			Tag:        tagString(info.Tag),
+			Password:   info.Password,
+			Nonce:      info.Nonce,
+			EnvironTag: tagString(info.EnvironTag),
+		}
+	}
+	if mcfg.Config != nil {
+		wire.Config = mcfg.Config.AllAttrs()
+	}
+	return wire
+}
+
+// fromWireMachineConfig returns the machine configuration
+// held in wire.
+func fromWireMachineConfig(wire MachineConfig) (*cloudinit.MachineConfig, error) {
+	mcfg := &cloudinit.MachineConfig{
+		Bootstrap:                      wire.Bootstrap,
+		StateServingInfo:               wire.StateServingInfo,
+		InstanceId:                     wire.InstanceId,
+		HardwareCharacteristics:        wire.HardwareCharacteristics,
+		MachineNonce:                   wire.MachineNonce,
+		Tools:                          wire.Tools,
+		DataDir:                        wire.DataDir,
+		LogDir:                         wire.LogDir,
+		Jobs:                           wire.Jobs,
+		CloudInitOutputLog:             wire.CloudInitOutputLog,
+		MachineId:                      wire.MachineId,
+		MachineContainerType:           wire.MachineContainerType,
+		Networks:                       wire.Networks,
+		AuthorizedKeys:                 wire.AuthorizedKeys,
+		AgentEnvironment:               wire.AgentEnvironment,
+		Constraints:                    wire.Constraints,
+		DisableSSLHostnameVerification: wire.DisableSSLHostnameVerification,
+		Series:                         wire.Series,
+		MachineAgentServiceName:        wire.MachineAgentServiceName,
+		ProxySettings:                  wire.ProxySettings,
+		AptProxySettings:               wire.AptProxySettings,
+		PreferIPv6:                     wire.PreferIPv6,
+		ImageStream:                    wire.ImageStream,
+		EnableOSRefreshUpdate:          wire.EnableOSRefreshUpdate,
+		EnableOSUpgrade:                wire.EnableOSUpgrade,
+	}
+	if info := wire.MongoInfo; info != nil {
+		tag, err := parseTag(info.Tag)
+		if err != nil {
+			return nil, err
+		}
+		mcfg.MongoInfo = &mongo.MongoInfo{
+			Info: mongo.Info{
+				Addrs:  info.Addrs,
+				CACert: info.CACert,
+			},
+			Tag:      tag,
+			Password: info.Password,
+		}
+	}
+	if info := wire.APIInfo; info != nil {
+		tag, err := parseTag(info.Tag)
+		if err != nil {
+			return nil, err
+		}
+		envTag, err := parseTag(info.EnvironTag)
+		if err != nil {
+			return nil, err
+		}
+		mcfg.APIInfo = &api.Info{
+			Addrs:      info.Addrs,
+			CACert:     info.CACert,
+			Tag:        tag,
+			Password:   info.Password,
+			Nonce:      info.Nonce,
+			EnvironTag: envTag,
+		}
+	}
+	if wire.Config != nil {
+		cfg, err := config.New(config.NoDefaults, wire.Config)
+		if err != nil {
+			return nil, err
+		}
+		mcfg.Config = cfg
+	}
+	return mcfg, nil
+}
+
+func tagString(tag names.Tag) string {
+	if tag == nil {
+		return ""
+	}
+	return tag.String()
+}
+
+func parseTag(s string) (names.Tag, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return names.ParseTag(s)
+}
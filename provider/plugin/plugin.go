@@ -0,0 +1,199 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package plugin implements a provider that runs another provider
+// out of process. An environment of type "plugin" names the plugin
+// with its "plugin" attribute; juju runs the executable
+// juju-provider-<plugin> found on $PATH and talks to it over the
+// executable's standard input and output.
+//
+// Plugins are not distributed with the juju tools. The plugin
+// executable must be installed on $PATH wherever the environment is
+// used: on the client that bootstraps and manages it, and on every
+// state server, where the provisioner and other environment workers
+// run.
+//
+// Plugins are written by calling Main with a provider, which serves
+// the provider's EnvironProvider and Environ methods over the juju
+// RPC protocol. Calls are made with version ProtocolVersion, and a
+// plugin that does not implement that version is rejected.
+package plugin
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/jsoncodec"
+)
+
+var logger = loggo.GetLogger("juju.provider.plugin")
+
+// ProtocolVersion is the version of the plugin protocol implemented
+// by this package.
+const ProtocolVersion = 1
+
+// ProviderType is the environment type of plugin environments.
+const ProviderType = "plugin"
+
+// CommandPrefix is prepended to the name of a plugin to make the
+// name of the executable that implements it.
+const CommandPrefix = "juju-provider-"
+
+func init() {
+	environs.RegisterProvider(ProviderType, environProvider{})
+}
+
+// Launcher starts the named plugin and returns a connection to it.
+type Launcher func(name string) (io.ReadWriteCloser, error)
+
+// Launch is used to start plugins. It may be replaced to run plugins
+// some other way, for example in the same process when testing.
+var Launch Launcher = ExecLauncher
+
+// ExecLauncher runs the executable that implements the named plugin,
+// communicating with it over its standard input and output. The
+// plugin's standard error is passed through to that of the current
+// process.
+func ExecLauncher(name string) (io.ReadWriteCloser, error) {
+	if !validPluginName(name) {
+		return nil, errors.NotValidf("plugin name %q", name)
+	}
+	path, err := exec.LookPath(CommandPrefix + name)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot find plugin %q (%s%s must be installed on $PATH)", name, CommandPrefix, name)
+	}
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Annotatef(err, "cannot start plugin %q", name)
+	}
+	return &processConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+// processConn is a connection to a plugin process.
+type processConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (c *processConn) Read(buf []byte) (int, error) {
+	return c.stdout.Read(buf)
+}
+
+func (c *processConn) Write(buf []byte) (int, error) {
+	return c.stdin.Write(buf)
+}
+
+// Close closes the plugin's standard input, which tells
+// it to exit, and waits for it to do so.
+func (c *processConn) Close() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+var (
+	clientsMutex sync.Mutex
+	clients      = make(map[string]*client)
+)
+
+// getClient returns a client connected to the named plugin,
+// starting the plugin if it is not already running. A single
+// plugin process serves all environments that use it.
+func getClient(name string) (*client, error) {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	if c := clients[name]; c != nil && !c.isDead() {
+		return c, nil
+	}
+	conn, err := Launch(name)
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(name, conn)
+	var info InfoResult
+	if err := c.call("Plugin", "", "Info", nil, &info); err != nil {
+		c.Close()
+		if isNotImplemented(err) {
+			return nil, errors.Errorf("plugin %q does not support protocol version %d", name, ProtocolVersion)
+		}
+		return nil, errors.Annotatef(err, "cannot contact plugin %q", name)
+	}
+	logger.Debugf("started plugin %q for provider type %q", name, info.ProviderType)
+	clients[name] = c
+	return c, nil
+}
+
+// CloseAll closes the connections to all running plugins,
+// which causes them to exit.
+func CloseAll() {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	for name, c := range clients {
+		if err := c.Close(); err != nil {
+			logger.Warningf("error closing plugin %q: %v", name, err)
+		}
+		delete(clients, name)
+	}
+}
+
+// client makes calls to a plugin.
+type client struct {
+	name string
+	conn *rpc.Conn
+}
+
+func newClient(name string, conn io.ReadWriteCloser) *client {
+	rpcConn := rpc.NewConn(jsoncodec.NewStream(conn), nil)
+	rpcConn.Start()
+	// Closing the connection waits for the plugin to exit, so close
+	// it as soon as the plugin goes away; otherwise a plugin that
+	// exits unexpectedly is never reaped.
+	go func() {
+		<-rpcConn.Dead()
+		rpcConn.Close()
+	}()
+	return &client{name: name, conn: rpcConn}
+}
+
+// call calls the given method of the given plugin facade. Errors
+// returned by the plugin are translated back into the errors the
+// provider returned, where those are significant to juju.
+func (c *client) call(facade, id, method string, args, result interface{}) error {
+	err := c.conn.Call(rpc.Request{
+		Type:    facade,
+		Version: ProtocolVersion,
+		Id:      id,
+		Action:  method,
+	}, args, result)
+	return clientError(err)
+}
+
+func (c *client) isDead() bool {
+	select {
+	case <-c.conn.Dead():
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the connection to the plugin.
+func (c *client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,290 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin_test
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/juju/errors"
+	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/environs/jujutest"
+	envtesting "github.com/juju/juju/environs/testing"
+	"github.com/juju/juju/instance"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/provider/plugin"
+	"github.com/juju/juju/provider/plugin/plugintest"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/jsoncodec"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+)
+
+// conformanceSuite runs the provider conformance tests
+// against the dummy provider served as a plugin.
+type conformanceSuite struct {
+	testing.BaseSuite
+	gitjujutesting.MgoSuite
+	plugintest.Tests
+}
+
+var _ = gc.Suite(&conformanceSuite{
+	Tests: plugintest.Tests{
+		Tests: jujutest.Tests{
+			TestConfig: plugintest.Config("dummy", dummy.SampleConfig()),
+		},
+		Launch: plugintest.InProcess("dummy"),
+	},
+})
+
+func (s *conformanceSuite) SetUpSuite(c *gc.C) {
+	s.BaseSuite.SetUpSuite(c)
+	s.MgoSuite.SetUpSuite(c)
+}
+
+func (s *conformanceSuite) TearDownSuite(c *gc.C) {
+	s.MgoSuite.TearDownSuite(c)
+	s.BaseSuite.TearDownSuite(c)
+}
+
+func (s *conformanceSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.MgoSuite.SetUpTest(c)
+	s.Tests.SetUpTest(c)
+}
+
+func (s *conformanceSuite) TearDownTest(c *gc.C) {
+	s.Tests.TearDownTest(c)
+	s.MgoSuite.TearDownTest(c)
+	dummy.Reset()
+	s.BaseSuite.TearDownTest(c)
+}
+
+type pluginSuite struct {
+	testing.BaseSuite
+	gitjujutesting.MgoSuite
+	envtesting.ToolsFixture
+}
+
+var _ = gc.Suite(&pluginSuite{})
+
+func (s *pluginSuite) SetUpSuite(c *gc.C) {
+	s.BaseSuite.SetUpSuite(c)
+	s.MgoSuite.SetUpSuite(c)
+}
+
+func (s *pluginSuite) TearDownSuite(c *gc.C) {
+	s.MgoSuite.TearDownSuite(c)
+	s.BaseSuite.TearDownSuite(c)
+}
+
+func (s *pluginSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.MgoSuite.SetUpTest(c)
+	s.ToolsFixture.SetUpTest(c)
+	s.PatchValue(&plugin.Launch, plugintest.InProcess("dummy"))
+}
+
+func (s *pluginSuite) TearDownTest(c *gc.C) {
+	plugin.CloseAll()
+	s.ToolsFixture.TearDownTest(c)
+	s.MgoSuite.TearDownTest(c)
+	dummy.Reset()
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *pluginSuite) prepare(c *gc.C, attrs testing.Attrs) environs.Environ {
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	env, err := environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+func (s *pluginSuite) TestPrepare(c *gc.C) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	cfg := env.Config()
+	c.Assert(cfg.Type(), gc.Equals, plugin.ProviderType)
+	attrs := cfg.UnknownAttrs()
+	c.Assert(attrs["plugin"], gc.Equals, "dummy")
+	c.Assert(attrs["secret"], gc.Equals, "pork")
+	// The dummy provider records its state id when preparing.
+	c.Assert(attrs["state-id"], gc.NotNil)
+}
+
+func (s *pluginSuite) TestSecretAttrs(c *gc.C) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	attrs, err := env.Provider().SecretAttrs(env.Config())
+	c.Assert(err, gc.IsNil)
+	c.Assert(attrs, gc.DeepEquals, map[string]string{"secret": "pork"})
+}
+
+func (s *pluginSuite) TestNoPlugin(c *gc.C) {
+	attrs := plugintest.Config("dummy", dummy.SampleConfig()).Delete("plugin")
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	_, err = environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.ErrorMatches, "plugin environment has no plugin specified")
+}
+
+func (s *pluginSuite) TestProtocolVersionMismatch(c *gc.C) {
+	// Simulate a plugin that implements no version of the protocol
+	// known to this juju.
+	s.PatchValue(&plugin.Launch, plugin.Launcher(func(name string) (io.ReadWriteCloser, error) {
+		client, server := net.Pipe()
+		conn := rpc.NewConn(jsoncodec.NewNet(server), nil)
+		conn.Serve(struct{}{}, nil)
+		conn.Start()
+		return client, nil
+	}))
+	cfg, err := config.New(config.NoDefaults, plugintest.Config("old", dummy.SampleConfig()))
+	c.Assert(err, gc.IsNil)
+	_, err = environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.ErrorMatches, `plugin "old" does not support protocol version 1`)
+}
+
+func (s *pluginSuite) TestExecLauncherNotFound(c *gc.C) {
+	s.PatchEnvironment("PATH", c.MkDir())
+	_, err := plugin.ExecLauncher("nonexistent")
+	c.Assert(err, gc.ErrorMatches, `cannot find plugin "nonexistent" \(juju-provider-nonexistent must be installed on \$PATH\): .*`)
+}
+
+func (s *pluginSuite) TestExecLauncherInvalidName(c *gc.C) {
+	_, err := plugin.ExecLauncher("../bin/evil")
+	c.Assert(err, gc.ErrorMatches, `plugin name "../bin/evil" not valid`)
+}
+
+func (s *pluginSuite) TestInvalidPluginName(c *gc.C) {
+	cfg, err := config.New(config.NoDefaults, plugintest.Config("sub/dir", dummy.SampleConfig()))
+	c.Assert(err, gc.IsNil)
+	_, err = environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.ErrorMatches, `plugin name "sub/dir" not valid`)
+}
+
+// closeRecorder records when a connection to a plugin is closed.
+type closeRecorder struct {
+	io.ReadWriteCloser
+	closed chan struct{}
+}
+
+func (c *closeRecorder) Close() error {
+	close(c.closed)
+	return c.ReadWriteCloser.Close()
+}
+
+func (s *pluginSuite) TestExitedPluginIsClosed(c *gc.C) {
+	var conn *closeRecorder
+	s.PatchValue(&plugin.Launch, plugin.Launcher(func(name string) (io.ReadWriteCloser, error) {
+		rwc, err := plugintest.InProcess("dummy")(name)
+		if err != nil {
+			return nil, err
+		}
+		conn = &closeRecorder{rwc, make(chan struct{})}
+		return conn, nil
+	}))
+	s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+
+	// Break the connection, as happens when the plugin exits; the
+	// connection must be closed so that the plugin is waited for.
+	conn.ReadWriteCloser.Close()
+	select {
+	case <-conn.closed:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("connection to exited plugin was not closed")
+	}
+}
+
+func (s *pluginSuite) startInstance(c *gc.C) (environs.Environ, instance.Instance) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	s.UploadFakeTools(c, env.Storage())
+	cfg, err := env.Config().Apply(map[string]interface{}{
+		"agent-version": version.Current.Number.String(),
+	})
+	c.Assert(err, gc.IsNil)
+	err = env.SetConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	inst, _ := jujutesting.AssertStartInstance(c, env, "0")
+	return env, inst
+}
+
+func (s *pluginSuite) TestInstance(c *gc.C) {
+	_, inst := s.startInstance(c)
+
+	err := inst.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inst.Status(), gc.Equals, "")
+
+	addrs, err := inst.Addresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, gc.Not(gc.HasLen), 0)
+
+	ports := []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}}
+	err = inst.OpenPorts("0", ports)
+	c.Assert(err, gc.IsNil)
+	opened, err := inst.Ports("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, gc.DeepEquals, ports)
+	err = inst.ClosePorts("0", ports)
+	c.Assert(err, gc.IsNil)
+	opened, err = inst.Ports("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(opened, gc.HasLen, 0)
+}
+
+func (s *pluginSuite) TestInstancesNotFound(c *gc.C) {
+	env, inst := s.startInstance(c)
+	insts, err := env.Instances([]instance.Id{"unknown"})
+	c.Assert(err, gc.Equals, environs.ErrNoInstances)
+	c.Assert(insts, gc.HasLen, 0)
+
+	insts, err = env.Instances([]instance.Id{"unknown", inst.Id()})
+	c.Assert(err, gc.Equals, environs.ErrPartialInstances)
+	c.Assert(insts[0], gc.IsNil)
+	c.Assert(insts[1].Id(), gc.Equals, inst.Id())
+}
+
+func (s *pluginSuite) TestStateServerInstancesNotBootstrapped(c *gc.C) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	_, err := env.StateServerInstances()
+	c.Assert(err, gc.Equals, environs.ErrNotBootstrapped)
+}
+
+func (s *pluginSuite) TestStorageNotFound(c *gc.C) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	_, err := env.Storage().Get("nonexistent")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *pluginSuite) TestConstraintsValidator(c *gc.C) {
+	env := s.prepare(c, plugintest.Config("dummy", dummy.SampleConfig()))
+	validator, err := env.ConstraintsValidator()
+	c.Assert(err, gc.IsNil)
+
+	// Rules registered by the plugin's provider apply.
+	unsupported, err := validator.Validate(constraints.MustParse("cpu-power=10 mem=1G"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.DeepEquals, []string{"cpu-power"})
+	_, err = validator.Validate(constraints.MustParse("instance-type=small mem=1G"))
+	c.Assert(err, gc.ErrorMatches, `ambiguous constraints: "instance-type" overlaps with "mem"`)
+
+	// So do rules registered by the caller.
+	validator.RegisterUnsupported([]string{"tags"})
+	unsupported, err = validator.Validate(constraints.MustParse("tags=foo"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.DeepEquals, []string{"tags"})
+
+	merged, err := validator.Merge(constraints.MustParse("mem=1G"), constraints.MustParse("instance-type=small"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(merged, gc.DeepEquals, constraints.MustParse("instance-type=small"))
+}
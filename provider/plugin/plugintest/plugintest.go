@@ -0,0 +1,81 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package plugintest provides a conformance test suite for provider
+// plugins, and a way of running plugins in the test process.
+package plugintest
+
+import (
+	"bytes"
+	"io"
+	"net"
+
+	"github.com/juju/loggo"
+	"github.com/juju/testing"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/jujutest"
+	"github.com/juju/juju/provider/plugin"
+	coretesting "github.com/juju/juju/testing"
+)
+
+var logger = loggo.GetLogger("juju.provider.plugin.plugintest")
+
+// InProcess returns a launcher that serves the provider registered
+// with the given type from the current process, rather than running
+// a plugin executable.
+func InProcess(providerType string) plugin.Launcher {
+	return func(name string) (io.ReadWriteCloser, error) {
+		provider, err := environs.Provider(providerType)
+		if err != nil {
+			return nil, err
+		}
+		client, server := net.Pipe()
+		go func() {
+			if err := plugin.Serve(server, providerType, provider, logWriter{}); err != nil {
+				logger.Errorf("plugin %q failed: %v", name, err)
+			}
+		}()
+		return client, nil
+	}
+}
+
+// logWriter logs the output of an in-process plugin.
+type logWriter struct{}
+
+func (logWriter) Write(data []byte) (int, error) {
+	logger.Infof("%s", bytes.TrimRight(data, "\n"))
+	return len(data), nil
+}
+
+// Config returns the attributes of a plugin environment using the named
+// plugin, given the attributes of an environment of the plugin's own
+// provider type.
+func Config(name string, attrs coretesting.Attrs) coretesting.Attrs {
+	return attrs.Merge(coretesting.Attrs{
+		"type":   plugin.ProviderType,
+		"plugin": name,
+	})
+}
+
+// Tests runs the jujutest.Tests provider conformance tests against a
+// plugin. TestConfig must hold the attributes of a plugin environment,
+// as returned by Config, and Launch is used to start the plugin.
+type Tests struct {
+	jujutest.Tests
+	Launch plugin.Launcher
+
+	restoreLaunch func()
+}
+
+func (t *Tests) SetUpTest(c *gc.C) {
+	t.restoreLaunch = testing.PatchValue(&plugin.Launch, t.Launch)
+	t.Tests.SetUpTest(c)
+}
+
+func (t *Tests) TearDownTest(c *gc.C) {
+	t.Tests.TearDownTest(c)
+	plugin.CloseAll()
+	t.restoreLaunch()
+}
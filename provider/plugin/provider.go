@@ -0,0 +1,126 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+)
+
+// environProvider is the provider for plugin environments. It
+// forwards each call to the plugin named by the configuration.
+type environProvider struct{}
+
+var _ environs.EnvironProvider = environProvider{}
+
+// pluginName returns the name of the plugin used by
+// the environment with the given configuration.
+func pluginName(cfg *config.Config) (string, error) {
+	name, _ := cfg.UnknownAttrs()[pluginAttr].(string)
+	if name == "" {
+		return "", errors.New("plugin environment has no plugin specified")
+	}
+	if !validPluginName(name) {
+		return "", errors.NotValidf("plugin name %q", name)
+	}
+	return name, nil
+}
+
+// validPluginName reports whether name may be used to find a plugin
+// on $PATH. A name containing a path separator would make it be looked
+// up relative to the current directory instead.
+func validPluginName(name string) bool {
+	return !strings.ContainsAny(name, `/\`)
+}
+
+// clientFor returns a client connected to the plugin used
+// by the environment with the given configuration.
+func clientFor(cfg *config.Config) (*client, error) {
+	name, err := pluginName(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return getClient(name)
+}
+
+// Prepare is specified in the EnvironProvider interface.
+func (p environProvider) Prepare(ctx environs.BootstrapContext, cfg *config.Config) (environs.Environ, error) {
+	return p.open("Prepare", cfg)
+}
+
+// Open is specified in the EnvironProvider interface.
+func (p environProvider) Open(cfg *config.Config) (environs.Environ, error) {
+	return p.open("Open", cfg)
+}
+
+func (p environProvider) open(method string, cfg *config.Config) (environs.Environ, error) {
+	c, err := clientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var result OpenResult
+	if err := c.call("Provider", "", method, ConfigParams{cfg.AllAttrs()}, &result); err != nil {
+		return nil, err
+	}
+	envCfg, err := config.New(config.NoDefaults, result.Attrs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid configuration from plugin %q", c.name)
+	}
+	return newEnviron(c, result.EnvironId, envCfg), nil
+}
+
+// Validate is specified in the EnvironProvider interface.
+func (p environProvider) Validate(cfg, old *config.Config) (*config.Config, error) {
+	c, err := clientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	args := ValidateParams{Attrs: cfg.AllAttrs()}
+	if old != nil {
+		oldName, _ := pluginName(old)
+		if oldName != c.name {
+			return nil, errors.Errorf("cannot change plugin from %q to %q", oldName, c.name)
+		}
+		args.OldAttrs = old.AllAttrs()
+	}
+	var result ConfigParams
+	if err := c.call("Provider", "", "Validate", args, &result); err != nil {
+		return nil, err
+	}
+	return config.New(config.NoDefaults, result.Attrs)
+}
+
+// SecretAttrs is specified in the EnvironProvider interface.
+func (p environProvider) SecretAttrs(cfg *config.Config) (map[string]string, error) {
+	c, err := clientFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var result SecretAttrsResult
+	if err := c.call("Provider", "", "SecretAttrs", ConfigParams{cfg.AllAttrs()}, &result); err != nil {
+		return nil, err
+	}
+	return result.Attrs, nil
+}
+
+// BoilerplateConfig is specified in the EnvironProvider interface.
+func (p environProvider) BoilerplateConfig() string {
+	return `
+plugin:
+    type: plugin
+    # plugin names the provider plugin to use. Juju runs the
+    # executable juju-provider-<plugin>, which must be on $PATH
+    # here and on every state server; it is not installed with
+    # the juju tools.
+    plugin: myprovider
+
+    # Any other attributes are passed to the plugin, which
+    # defines what they mean.
+
+`[1:]
+}
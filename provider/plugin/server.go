@@ -0,0 +1,281 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/jsoncodec"
+	"github.com/juju/juju/rpc/rpcreflect"
+)
+
+// pluginAttr names the environment attribute that holds the
+// name of the plugin.
+const pluginAttr = "plugin"
+
+// Main runs a plugin serving the provider registered with the given
+// type on its standard input and output, and exits when its standard
+// input is closed. It is intended to be called from the main function
+// of a plugin executable.
+func Main(providerType string) {
+	provider, err := environs.Provider(providerType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(2)
+	}
+	conn := &stdioConn{os.Stdin, os.Stdout}
+	if err := Serve(conn, providerType, provider, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// stdioConn joins standard input and output into a single connection.
+type stdioConn struct {
+	io.ReadCloser
+	io.Writer
+}
+
+// Serve serves the given provider on conn until the connection is
+// closed. The provider is presented to the client as the given type.
+// Output written by the provider while bootstrapping is sent to log.
+func Serve(conn io.ReadWriteCloser, providerType string, provider environs.EnvironProvider, log io.Writer) error {
+	srv := &server{
+		providerType: providerType,
+		provider:     provider,
+		ctx:          &bootstrapContext{log},
+		environs:     make(map[string]*environState),
+	}
+	rpcConn := rpc.NewConn(jsoncodec.NewStream(conn), nil)
+	rpcConn.ServeFinder(versionedFinder{rpcreflect.ValueOf(reflect.ValueOf(root{srv}))}, serverError)
+	rpcConn.Start()
+	<-rpcConn.Dead()
+	return rpcConn.Close()
+}
+
+// versionedFinder finds methods on the plugin root, rejecting
+// calls made with any version other than ProtocolVersion.
+type versionedFinder struct {
+	root rpcreflect.Value
+}
+
+// FindMethod implements rpc.MethodFinder.
+func (f versionedFinder) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	if version != ProtocolVersion {
+		return nil, &rpcreflect.CallNotImplementedError{
+			RootMethod: rootName,
+			Version:    version,
+		}
+	}
+	return f.root.FindMethod(rootName, 0, methodName)
+}
+
+// server holds the state of a plugin.
+type server struct {
+	providerType string
+	provider     environs.EnvironProvider
+	ctx          environs.BootstrapContext
+
+	mu       sync.Mutex
+	lastId   int
+	environs map[string]*environState
+}
+
+// addEnviron records env, opened by the named plugin,
+// and returns the id of the new environment.
+func (srv *server) addEnviron(name string, env environs.Environ) string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.lastId++
+	id := strconv.Itoa(srv.lastId)
+	srv.environs[id] = newEnvironState(srv, name, env)
+	return id
+}
+
+// providerConfig returns the configuration of the plugin's
+// provider corresponding to the given plugin configuration
+// attributes, and the name of the plugin.
+func (srv *server) providerConfig(attrs map[string]interface{}) (*config.Config, string, error) {
+	providerAttrs := make(map[string]interface{})
+	for attr, value := range attrs {
+		providerAttrs[attr] = value
+	}
+	name, _ := providerAttrs[pluginAttr].(string)
+	delete(providerAttrs, pluginAttr)
+	providerAttrs["type"] = srv.providerType
+	cfg, err := config.New(config.NoDefaults, providerAttrs)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, name, nil
+}
+
+// pluginAttrs returns the plugin configuration attributes
+// corresponding to the given configuration of the plugin's
+// provider.
+func pluginAttrs(cfg *config.Config, name string) map[string]interface{} {
+	attrs := cfg.AllAttrs()
+	attrs["type"] = ProviderType
+	attrs[pluginAttr] = name
+	return attrs
+}
+
+// root is the root of the methods served by a plugin.
+type root struct {
+	srv *server
+}
+
+// Plugin returns the facade describing the plugin.
+func (r root) Plugin(id string) (*pluginAPI, error) {
+	if id != "" {
+		return nil, errors.NotFoundf("plugin %q", id)
+	}
+	return &pluginAPI{r.srv}, nil
+}
+
+// Provider returns the facade for the plugin's EnvironProvider.
+func (r root) Provider(id string) (*providerAPI, error) {
+	if id != "" {
+		return nil, errors.NotFoundf("provider %q", id)
+	}
+	return &providerAPI{r.srv}, nil
+}
+
+// Environ returns the facade for the environment with the given id,
+// as returned by Provider.Prepare or Provider.Open.
+func (r root) Environ(id string) (*environAPI, error) {
+	r.srv.mu.Lock()
+	defer r.srv.mu.Unlock()
+	st := r.srv.environs[id]
+	if st == nil {
+		return nil, errors.NotFoundf("environment %q", id)
+	}
+	return &environAPI{st}, nil
+}
+
+type pluginAPI struct {
+	srv *server
+}
+
+// Info returns the version of the protocol implemented by the
+// plugin and the type of the provider it serves.
+func (api *pluginAPI) Info() InfoResult {
+	return InfoResult{
+		ProtocolVersion: ProtocolVersion,
+		ProviderType:    api.srv.providerType,
+	}
+}
+
+type providerAPI struct {
+	srv *server
+}
+
+// Prepare implements environs.EnvironProvider.Prepare.
+func (api *providerAPI) Prepare(args ConfigParams) (OpenResult, error) {
+	cfg, name, err := api.srv.providerConfig(args.Attrs)
+	if err != nil {
+		return OpenResult{}, err
+	}
+	env, err := api.srv.provider.Prepare(api.srv.ctx, cfg)
+	if err != nil {
+		return OpenResult{}, err
+	}
+	return OpenResult{
+		EnvironId: api.srv.addEnviron(name, env),
+		Attrs:     pluginAttrs(env.Config(), name),
+	}, nil
+}
+
+// Open implements environs.EnvironProvider.Open.
+func (api *providerAPI) Open(args ConfigParams) (OpenResult, error) {
+	cfg, name, err := api.srv.providerConfig(args.Attrs)
+	if err != nil {
+		return OpenResult{}, err
+	}
+	env, err := api.srv.provider.Open(cfg)
+	if err != nil {
+		return OpenResult{}, err
+	}
+	return OpenResult{
+		EnvironId: api.srv.addEnviron(name, env),
+		Attrs:     pluginAttrs(env.Config(), name),
+	}, nil
+}
+
+// Validate implements environs.EnvironProvider.Validate.
+func (api *providerAPI) Validate(args ValidateParams) (ConfigParams, error) {
+	cfg, name, err := api.srv.providerConfig(args.Attrs)
+	if err != nil {
+		return ConfigParams{}, err
+	}
+	var old *config.Config
+	if args.OldAttrs != nil {
+		if old, _, err = api.srv.providerConfig(args.OldAttrs); err != nil {
+			return ConfigParams{}, err
+		}
+	}
+	valid, err := api.srv.provider.Validate(cfg, old)
+	if err != nil {
+		return ConfigParams{}, err
+	}
+	return ConfigParams{pluginAttrs(valid, name)}, nil
+}
+
+// SecretAttrs implements environs.EnvironProvider.SecretAttrs.
+func (api *providerAPI) SecretAttrs(args ConfigParams) (SecretAttrsResult, error) {
+	cfg, _, err := api.srv.providerConfig(args.Attrs)
+	if err != nil {
+		return SecretAttrsResult{}, err
+	}
+	attrs, err := api.srv.provider.SecretAttrs(cfg)
+	if err != nil {
+		return SecretAttrsResult{}, err
+	}
+	return SecretAttrsResult{attrs}, nil
+}
+
+// bootstrapContext is the environs.BootstrapContext used by
+// a plugin. A plugin's standard output is used to talk to juju,
+// so all output goes to the plugin's log.
+type bootstrapContext struct {
+	log io.Writer
+}
+
+func (ctx *bootstrapContext) GetStdin() io.Reader {
+	return strings.NewReader("")
+}
+
+func (ctx *bootstrapContext) GetStdout() io.Writer {
+	return ctx.log
+}
+
+func (ctx *bootstrapContext) GetStderr() io.Writer {
+	return ctx.log
+}
+
+func (ctx *bootstrapContext) Infof(format string, params ...interface{}) {
+	fmt.Fprintf(ctx.log, format+"\n", params...)
+}
+
+func (ctx *bootstrapContext) Verbosef(format string, params ...interface{}) {
+	fmt.Fprintf(ctx.log, format+"\n", params...)
+}
+
+// InterruptNotify does nothing; a plugin's calls
+// are never interrupted.
+func (ctx *bootstrapContext) InterruptNotify(sig chan<- os.Signal) {}
+
+func (ctx *bootstrapContext) StopInterruptNotify(chan<- os.Signal) {}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package plugin
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs/storage"
+)
+
+// environStorage is the storage of a plugin environment.
+// File contents are sent to and from the plugin in full.
+type environStorage struct {
+	env *environ
+}
+
+var _ storage.Storage = (*environStorage)(nil)
+
+// Get implements storage.StorageReader.Get.
+func (s *environStorage) Get(name string) (io.ReadCloser, error) {
+	var result StorageData
+	if err := s.env.call("StorageGet", StorageName{name}, &result); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(result.Data)), nil
+}
+
+// List implements storage.StorageReader.List.
+func (s *environStorage) List(prefix string) ([]string, error) {
+	var result StringsResult
+	if err := s.env.call("StorageList", StorageName{prefix}, &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// URL implements storage.StorageReader.URL.
+func (s *environStorage) URL(name string) (string, error) {
+	var result StringResult
+	if err := s.env.call("StorageURL", StorageName{name}, &result); err != nil {
+		return "", err
+	}
+	return result.Result, nil
+}
+
+// DefaultConsistencyStrategy implements
+// storage.StorageReader.DefaultConsistencyStrategy.
+func (s *environStorage) DefaultConsistencyStrategy() utils.AttemptStrategy {
+	var result ConsistencyResult
+	if err := s.env.call("StorageConsistency", nil, &result); err != nil {
+		logger.Warningf("cannot get storage consistency strategy: %v", err)
+		return utils.AttemptStrategy{}
+	}
+	return utils.AttemptStrategy{
+		Total: result.Total,
+		Delay: result.Delay,
+		Min:   result.Min,
+	}
+}
+
+// ShouldRetry implements storage.StorageReader.ShouldRetry.
+// It returns true for errors that the plugin's storage
+// reported should be retried.
+func (s *environStorage) ShouldRetry(err error) bool {
+	_, ok := errors.Cause(err).(*retryableError)
+	return ok
+}
+
+// Put implements storage.StorageWriter.Put.
+func (s *environStorage) Put(name string, r io.Reader, length int64) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r, length))
+	if err != nil {
+		return errors.Annotatef(err, "cannot read %q", name)
+	}
+	if int64(len(data)) != length {
+		return errors.Errorf("cannot read %q: expected %d bytes, got %d", name, length, len(data))
+	}
+	return s.env.call("StoragePut", StoragePutParams{name, data}, nil)
+}
+
+// Remove implements storage.StorageWriter.Remove.
+func (s *environStorage) Remove(name string) error {
+	return s.env.call("StorageRemove", StorageName{name}, nil)
+}
+
+// RemoveAll implements storage.StorageWriter.RemoveAll.
+func (s *environStorage) RemoveAll() error {
+	return s.env.call("StorageRemoveAll", nil, nil)
+}
@@ -315,6 +315,10 @@ func MasterHostPort(session *mgo.Session) (string, error) {
 	return results.PrimaryAddress, nil
 }
 
+// JujuMachineKey is the key for the member tag that holds the id of
+// the juju machine running the member.
+const JujuMachineKey = "juju-machine-id"
+
 // CurrentMembers returns the current members of the replica set.
 func CurrentMembers(session *mgo.Session) ([]Member, error) {
 	cfg, err := CurrentConfig(session)
@@ -399,6 +403,15 @@ type MemberStatus struct {
 	// between the remote member and the local instance.  It is zero for the
 	// member that the session is connected to.
 	Ping time.Duration `bson:"pingMS"`
+
+	// OpTime holds the time of the last operation applied by the
+	// member. The difference between a secondary's OpTime and the
+	// primary's describes how far the secondary lags behind.
+	OpTime time.Time `bson:"optimeDate"`
+
+	// LastHeartbeat holds the time the member was last heard from.
+	// It is zero for the member that the session is connected to.
+	LastHeartbeat time.Time `bson:"lastHeartbeat"`
 }
 
 // MemberState represents the state of a replica set member.
@@ -417,8 +417,14 @@ func (s *MongoSuite) TestCurrentStatus(c *gc.C) {
 		// ping is always going to be zero since we're on localhost
 		// so we can't really test it right now
 
-		// now overwrite Uptime so it won't throw off DeepEquals
+		// non-empty optime
+		c.Check(res.Members[x].OpTime.IsZero(), jc.IsFalse)
+
+		// now overwrite Uptime, OpTime and LastHeartbeat so they
+		// won't throw off DeepEquals
 		res.Members[x].Uptime = 0
+		res.Members[x].OpTime = time.Time{}
+		res.Members[x].LastHeartbeat = time.Time{}
 	}
 	c.Check(res, jc.DeepEquals, expected)
 }
@@ -0,0 +1,121 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The resource package describes the binary resources, such as
+// application tarballs, that a charm may declare in its metadata.
+// Resources are uploaded to the environment and attached to services
+// so that units never need to fetch them from the internet.
+package resource
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/juju/charm.v3"
+	goyaml "gopkg.in/yaml.v1"
+)
+
+// Meta describes a resource declared by a charm.
+type Meta struct {
+	// Name identifies the resource within the charm.
+	Name string
+
+	// Filename is the name of the file that units see when
+	// they fetch the resource.
+	Filename string
+
+	// Description describes the resource's contents.
+	Description string
+}
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// IsValidName returns whether name is a valid resource name.
+func IsValidName(name string) bool {
+	return validName.MatchString(name)
+}
+
+// metaFile is the part of a charm's metadata.yaml that
+// declares its resources.
+type metaFile struct {
+	Resources map[string]struct {
+		Filename    string `yaml:"filename"`
+		Description string `yaml:"description"`
+	} `yaml:"resources"`
+}
+
+// ParseMeta reads the resources declared in the "resources" section
+// of a charm's metadata.yaml, keyed by name. A resource's filename
+// defaults to its name.
+func ParseMeta(r io.Reader) (map[string]Meta, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var file metaFile
+	if err := goyaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse resources: %v", err)
+	}
+	if len(file.Resources) == 0 {
+		return nil, nil
+	}
+	resources := make(map[string]Meta)
+	for name, res := range file.Resources {
+		if !IsValidName(name) {
+			return nil, fmt.Errorf("invalid resource name %q", name)
+		}
+		filename := res.Filename
+		if filename == "" {
+			filename = name
+		}
+		if filename == "." || filename == ".." || strings.ContainsAny(filename, `/\`) {
+			return nil, fmt.Errorf("invalid filename %q for resource %q", filename, name)
+		}
+		resources[name] = Meta{
+			Name:        name,
+			Filename:    filename,
+			Description: res.Description,
+		}
+	}
+	return resources, nil
+}
+
+// ReadCharmMeta returns the resources declared by the given charm,
+// which must be a charm directory or archive for its resources to
+// be found; other charms are reported as declaring none.
+func ReadCharmMeta(ch charm.Charm) (map[string]Meta, error) {
+	switch ch := ch.(type) {
+	case *charm.CharmDir:
+		f, err := os.Open(filepath.Join(ch.Path, "metadata.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseMeta(f)
+	case *charm.CharmArchive:
+		zipReader, err := zip.OpenReader(ch.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer zipReader.Close()
+		for _, file := range zipReader.File {
+			if filepath.Clean(file.Name) != "metadata.yaml" {
+				continue
+			}
+			f, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return ParseMeta(f)
+		}
+		return nil, fmt.Errorf("charm archive %q has no metadata.yaml", ch.Path)
+	}
+	return nil, nil
+}
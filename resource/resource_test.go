@@ -0,0 +1,125 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package resource_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/juju/charm.v3"
+	charmtesting "gopkg.in/juju/charm.v3/testing"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/resource"
+	"github.com/juju/juju/testing"
+)
+
+type ResourceSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&ResourceSuite{})
+
+const resourcesMeta = `
+name: app
+summary: an application
+description: an application with resources
+resources:
+  app:
+    filename: app.tar.gz
+    description: The application tarball.
+  data-set:
+    description: Some data.
+`
+
+func (s *ResourceSuite) TestParseMeta(c *gc.C) {
+	resources, err := resource.ParseMeta(strings.NewReader(resourcesMeta))
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, jc.DeepEquals, map[string]resource.Meta{
+		"app": {
+			Name:        "app",
+			Filename:    "app.tar.gz",
+			Description: "The application tarball.",
+		},
+		"data-set": {
+			Name:        "data-set",
+			Filename:    "data-set",
+			Description: "Some data.",
+		},
+	})
+}
+
+func (s *ResourceSuite) TestParseMetaNoResources(c *gc.C) {
+	resources, err := resource.ParseMeta(strings.NewReader("name: app\n"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 0)
+}
+
+var parseMetaErrorTests = []struct {
+	meta string
+	err  string
+}{{
+	meta: "resources:\n  App:\n    filename: app\n",
+	err:  `invalid resource name "App"`,
+}, {
+	meta: "resources:\n  app-:\n    filename: app\n",
+	err:  `invalid resource name "app-"`,
+}, {
+	meta: "resources:\n  app:\n    filename: ../app\n",
+	err:  `invalid filename "../app" for resource "app"`,
+}, {
+	meta: "resources:\n  app:\n    filename: ..\n",
+	err:  `invalid filename ".." for resource "app"`,
+}}
+
+func (s *ResourceSuite) TestParseMetaErrors(c *gc.C) {
+	for i, test := range parseMetaErrorTests {
+		c.Logf("test %d: %q", i, test.meta)
+		_, err := resource.ParseMeta(strings.NewReader(test.meta))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *ResourceSuite) TestValidNames(c *gc.C) {
+	for _, name := range []string{"app", "app2", "app-data", "a-b-c"} {
+		c.Check(resource.IsValidName(name), jc.IsTrue, gc.Commentf("%q", name))
+	}
+	for _, name := range []string{"", "2app", "App", "app_data", "app-", "-app", "app--data"} {
+		c.Check(resource.IsValidName(name), jc.IsFalse, gc.Commentf("%q", name))
+	}
+}
+
+func (s *ResourceSuite) TestReadCharmMeta(c *gc.C) {
+	path := charmtesting.Charms.ClonedDirPath(c.MkDir(), "dummy")
+	err := ioutil.WriteFile(filepath.Join(path, "metadata.yaml"), []byte(resourcesMeta), 0644)
+	c.Assert(err, gc.IsNil)
+	dir, err := charm.ReadCharmDir(path)
+	c.Assert(err, gc.IsNil)
+	resources, err := resource.ReadCharmMeta(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 2)
+	c.Assert(resources["app"].Filename, gc.Equals, "app.tar.gz")
+
+	archivePath := filepath.Join(c.MkDir(), "app.charm")
+	f, err := os.Create(archivePath)
+	c.Assert(err, gc.IsNil)
+	err = dir.ArchiveTo(f)
+	f.Close()
+	c.Assert(err, gc.IsNil)
+	archive, err := charm.ReadCharmArchive(archivePath)
+	c.Assert(err, gc.IsNil)
+	fromArchive, err := resource.ReadCharmMeta(archive)
+	c.Assert(err, gc.IsNil)
+	c.Assert(fromArchive, jc.DeepEquals, resources)
+}
+
+func (s *ResourceSuite) TestReadCharmMetaNoResources(c *gc.C) {
+	dir := charmtesting.Charms.CharmDir("dummy")
+	resources, err := resource.ReadCharmMeta(dir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(resources, gc.HasLen, 0)
+}
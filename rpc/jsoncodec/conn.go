@@ -2,6 +2,7 @@ package jsoncodec
 
 import (
 	"encoding/json"
+	"io"
 	"net"
 
 	"code.google.com/p/go.net/websocket"
@@ -32,6 +33,12 @@ func (conn wsJSONConn) Close() error {
 // NewNet returns an rpc codec that uses the given net
 // connection to send and receive messages.
 func NewNet(conn net.Conn) *Codec {
+	return NewStream(conn)
+}
+
+// NewStream returns an rpc codec that uses the given
+// stream, such as a pipe, to send and receive messages.
+func NewStream(conn io.ReadWriteCloser) *Codec {
 	return New(&netConn{
 		enc:  json.NewEncoder(conn),
 		dec:  json.NewDecoder(conn),
@@ -42,7 +49,7 @@ func NewNet(conn net.Conn) *Codec {
 type netConn struct {
 	enc  *json.Encoder
 	dec  *json.Decoder
-	conn net.Conn
+	conn io.ReadWriteCloser
 }
 
 func (conn *netConn) Send(msg interface{}) error {
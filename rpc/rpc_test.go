@@ -1078,6 +1078,56 @@ func (*rpcSuite) TestRootIsKilled(c *gc.C) {
 	c.Assert(root.killed, gc.Equals, true)
 }
 
+type AdmitterRoot struct {
+	Root
+	admitted []rpc.Request
+	released int
+	deny     error
+}
+
+func (r *AdmitterRoot) Admit(req rpc.Request) (func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deny != nil {
+		return nil, r.deny
+	}
+	r.admitted = append(r.admitted, req)
+	return func() {
+		r.mu.Lock()
+		r.released++
+		r.mu.Unlock()
+	}, nil
+}
+
+func (*rpcSuite) TestRootAdmitsRequests(c *gc.C) {
+	root := &AdmitterRoot{}
+	root.simple = map[string]*SimpleMethods{
+		"a0": {root: &root.Root, id: "a0"},
+	}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+
+	var r stringVal
+	err := client.Call(rpc.Request{"SimpleMethods", 0, "a0", "Call0r1"}, nil, &r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(r, gc.Equals, stringVal{"Call0r1 ret"})
+
+	root.mu.Lock()
+	c.Assert(root.admitted, gc.DeepEquals, []rpc.Request{{"SimpleMethods", 0, "a0", "Call0r1"}})
+	c.Assert(root.released, gc.Equals, 1)
+	root.deny = &codedError{"slow down", "rate limit exceeded"}
+	root.mu.Unlock()
+
+	err = client.Call(rpc.Request{"SimpleMethods", 0, "a0", "Call0r1"}, nil, &r)
+	c.Assert(err, gc.ErrorMatches, `request error: slow down \(rate limit exceeded\)`)
+	c.Assert(err.(rpc.ErrorCoder).ErrorCode(), gc.Equals, "rate limit exceeded")
+
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	c.Assert(root.admitted, gc.HasLen, 1)
+	c.Assert(root.calls, gc.HasLen, 1)
+}
+
 func (*rpcSuite) TestBidirectional(c *gc.C) {
 	srvRoot := &Root{}
 	client, srvDone, _, _ := newRPCClientServer(c, srvRoot, nil, true)
@@ -119,6 +119,11 @@ type Conn struct {
 	// interface isn't implemented, killer is nil
 	killer Killer
 
+	// admitter is the current object that we are serving if it
+	// implements the Admitter interface. If we are not serving an
+	// object, or if the interface isn't implemented, admitter is nil.
+	admitter Admitter
+
 	// transformErrors is used to transform returned errors.
 	transformErrors func(error) error
 
@@ -266,22 +271,31 @@ func (conn *Conn) Serve(root interface{}, transformErrors func(error) error) {
 //
 // root can optionally implement the Killer method. If implemented, when the
 // connection is closed, root.Kill() will be called.
+//
+// root can also optionally implement the Admitter interface. If
+// implemented, every request is checked with root.Admit before it is
+// run.
 func (conn *Conn) ServeFinder(finder MethodFinder, transformErrors func(error) error) {
 	conn.serve(finder, finder, transformErrors)
 }
 
-func (conn *Conn) serve(methodFinder MethodFinder, maybeKiller interface{}, transformErrors func(error) error) {
+func (conn *Conn) serve(methodFinder MethodFinder, root interface{}, transformErrors func(error) error) {
 	if transformErrors == nil {
 		transformErrors = noopTransform
 	}
 	conn.mutex.Lock()
 	defer conn.mutex.Unlock()
 	conn.methodFinder = methodFinder
-	if killer, ok := maybeKiller.(Killer); ok {
+	if killer, ok := root.(Killer); ok {
 		conn.killer = killer
 	} else {
 		conn.killer = nil
 	}
+	if admitter, ok := root.(Admitter); ok {
+		conn.admitter = admitter
+	} else {
+		conn.admitter = nil
+	}
 	conn.transformErrors = transformErrors
 }
 
@@ -356,6 +370,17 @@ type Killer interface {
 	Kill()
 }
 
+// Admitter represents a type that decides whether server requests
+// may run, for example to enforce rate limits. Admit is called
+// before the method implementing the request is invoked. If it
+// returns an error, the method is not called and the error (after
+// transformation) is returned to the caller. Otherwise the returned
+// release function is called when the method has returned.
+// Admit may be called concurrently.
+type Admitter interface {
+	Admit(req Request) (release func(), err error)
+}
+
 // input reads messages from the connection and handles them
 // appropriately.
 func (conn *Conn) input() {
@@ -492,6 +517,7 @@ func (conn *Conn) writeErrorResponse(reqHdr *Header, err error, startTime time.T
 type boundRequest struct {
 	rpcreflect.MethodCaller
 	transformErrors func(error) error
+	admitter        Admitter
 	hdr             Header
 }
 
@@ -502,6 +528,7 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 	conn.mutex.Lock()
 	methodFinder := conn.methodFinder
 	transformErrors := conn.transformErrors
+	admitter := conn.admitter
 	conn.mutex.Unlock()
 
 	if methodFinder == nil {
@@ -523,6 +550,7 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 	return boundRequest{
 		MethodCaller:    caller,
 		transformErrors: transformErrors,
+		admitter:        admitter,
 		hdr:             *hdr,
 	}, nil
 }
@@ -530,6 +558,17 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 // runRequest runs the given request and sends the reply.
 func (conn *Conn) runRequest(req boundRequest, arg reflect.Value, startTime time.Time) {
 	defer conn.srvPending.Done()
+	if req.admitter != nil {
+		release, err := req.admitter.Admit(req.hdr.Request)
+		if err != nil {
+			err = conn.writeErrorResponse(&req.hdr, req.transformErrors(err), startTime)
+			if err != nil {
+				logger.Errorf("error writing response: %v", err)
+			}
+			return
+		}
+		defer release()
+	}
 	rv, err := req.Call(req.hdr.Request.Id, arg)
 	if err != nil {
 		err = conn.writeErrorResponse(&req.hdr, req.transformErrors(err), startTime)
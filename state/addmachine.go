@@ -254,14 +254,15 @@ func (st *State) addMachineOps(template MachineTemplate) (*machineDoc, []txn.Op,
 			Id:     mdoc.Id,
 			Assert: txn.DocMissing,
 			Insert: &instanceData{
-				Id:         mdoc.Id,
-				InstanceId: template.InstanceId,
-				Arch:       template.HardwareCharacteristics.Arch,
-				Mem:        template.HardwareCharacteristics.Mem,
-				RootDisk:   template.HardwareCharacteristics.RootDisk,
-				CpuCores:   template.HardwareCharacteristics.CpuCores,
-				CpuPower:   template.HardwareCharacteristics.CpuPower,
-				Tags:       template.HardwareCharacteristics.Tags,
+				Id:               mdoc.Id,
+				InstanceId:       template.InstanceId,
+				Arch:             template.HardwareCharacteristics.Arch,
+				Mem:              template.HardwareCharacteristics.Mem,
+				RootDisk:         template.HardwareCharacteristics.RootDisk,
+				CpuCores:         template.HardwareCharacteristics.CpuCores,
+				CpuPower:         template.HardwareCharacteristics.CpuPower,
+				Tags:             template.HardwareCharacteristics.Tags,
+				AvailabilityZone: template.HardwareCharacteristics.AvailabilityZone,
 			},
 		})
 	}
@@ -648,6 +649,13 @@ func (st *State) ensureAvailabilityIntentions(info *StateServerInfo) (*ensureAva
 		if err != nil {
 			return nil, err
 		}
+		if m.IsRemovingStateServer() {
+			// The machine is being removed by RemoveStateServer;
+			// the worker that maintains the replicaset will remove
+			// its JobManageEnviron job once its vote has gone.
+			logger.Infof("machine %q is being removed as a state server", m)
+			continue
+		}
 		available, err := stateServerAvailable(m)
 		if err != nil {
 			return nil, err
@@ -714,7 +722,7 @@ func removeStateServerOps(m *Machine) []txn.Op {
 		Assert: bson.D{{"novote", true}, {"hasvote", false}},
 		Update: bson.D{
 			{"$pull", bson.D{{"jobs", JobManageEnviron}}},
-			{"$set", bson.D{{"novote", false}}},
+			{"$set", bson.D{{"novote", false}, {"removingstateserver", false}}},
 		},
 	}, {
 		C:      stateServersC,
@@ -722,3 +730,87 @@ func removeStateServerOps(m *Machine) []txn.Op {
 		Update: bson.D{{"$pull", bson.D{{"machineids", m.doc.Id}}}},
 	}}
 }
+
+// RemoveStateServer starts removing the state server running on the
+// machine with the given id, without destroying the machine. A
+// machine that has no vote in the replicaset has its JobManageEnviron
+// job removed immediately. Otherwise the machine is demoted, and the
+// worker that maintains the replicaset removes the job by calling
+// CompleteStateServerRemoval once the machine's vote has been taken
+// away. The last voting state server cannot be removed.
+func (st *State) RemoveStateServer(id string) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		m, err := st.Machine(id)
+		if err != nil {
+			return nil, err
+		}
+		if !m.IsManager() {
+			return nil, errors.Errorf("machine is not a state server")
+		}
+		if m.IsRemovingStateServer() {
+			return nil, jujutxn.ErrNoOperations
+		}
+		if !m.WantsVote() && !m.HasVote() {
+			return removeStateServerOps(m), nil
+		}
+		if !m.WantsVote() {
+			// The machine is already being demoted, so just
+			// mark it for removal.
+			return []txn.Op{{
+				C:      machinesC,
+				Id:     m.doc.Id,
+				Assert: bson.D{{"jobs", JobManageEnviron}, {"novote", true}},
+				Update: bson.D{{"$set", bson.D{{"removingstateserver", true}}}},
+			}}, nil
+		}
+		info, err := st.StateServerInfo()
+		if err != nil {
+			return nil, err
+		}
+		if len(info.VotingMachineIds) <= 1 {
+			return nil, errors.Errorf("cannot remove the last voting state server")
+		}
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: bson.D{{"jobs", JobManageEnviron}, {"novote", false}},
+			Update: bson.D{{"$set", bson.D{{"novote", true}, {"removingstateserver", true}}}},
+		}, {
+			C:      stateServersC,
+			Id:     environGlobalKey,
+			Assert: bson.D{{"votingmachineids", bson.D{{"$size", len(info.VotingMachineIds)}}}},
+			Update: bson.D{{"$pull", bson.D{{"votingmachineids", m.doc.Id}}}},
+		}}, nil
+	}
+	if err := st.run(buildTxn); err != nil {
+		return errors.Annotatef(err, "cannot remove state server %s", id)
+	}
+	return nil
+}
+
+// CompleteStateServerRemoval removes the JobManageEnviron job from a
+// machine passed to RemoveStateServer, once the machine no longer has
+// a vote in the replicaset. It should only be called from the worker
+// that maintains the replicaset.
+func (st *State) CompleteStateServerRemoval(id string) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		m, err := st.Machine(id)
+		if err != nil {
+			return nil, err
+		}
+		if !m.IsManager() {
+			return nil, jujutxn.ErrNoOperations
+		}
+		if !m.IsRemovingStateServer() {
+			return nil, errors.Errorf("removal not requested")
+		}
+		if m.HasVote() {
+			return nil, errors.Errorf("machine still has a vote")
+		}
+		return removeStateServerOps(m), nil
+	}
+	if err := st.run(buildTxn); err != nil {
+		return errors.Annotatef(err, "cannot complete removal of state server %s", id)
+	}
+	return nil
+}
@@ -911,6 +911,18 @@ var assignUsingConstraintsTests = []struct {
 		unitConstraints:         "arch=amd64 mem=4G cpu-cores=2 root-disk=8192",
 		hardwareCharacteristics: "arch=amd64 mem=8G cpu-cores=1 root-disk=4096 cpu-power=50",
 		assignOk:                false,
+	}, {
+		unitConstraints:         "zones=zone1,zone2",
+		hardwareCharacteristics: "availability-zone=zone2",
+		assignOk:                true,
+	}, {
+		unitConstraints:         "zones=zone1",
+		hardwareCharacteristics: "availability-zone=zone2",
+		assignOk:                false,
+	}, {
+		unitConstraints:         "zones=zone1",
+		hardwareCharacteristics: "none",
+		assignOk:                false,
 	},
 }
 
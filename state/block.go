@@ -0,0 +1,148 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// BlockType identifies the kind of operations an environment block
+// prevents.
+type BlockType string
+
+const (
+	// DestroyBlock prevents the environment from being destroyed.
+	DestroyBlock BlockType = "destroy-environment"
+
+	// RemoveBlock prevents machines, services, units and relations
+	// from being removed, as well as the environment from being
+	// destroyed.
+	RemoveBlock BlockType = "remove-object"
+
+	// ChangeBlock prevents all operations that change the
+	// environment.
+	ChangeBlock BlockType = "all-changes"
+)
+
+// AllBlockTypes returns all the kinds of block, from the least to the
+// most restrictive.
+func AllBlockTypes() []BlockType {
+	return []BlockType{DestroyBlock, RemoveBlock, ChangeBlock}
+}
+
+// ParseBlockType returns the block type with the given name.
+func ParseBlockType(name string) (BlockType, error) {
+	for _, t := range AllBlockTypes() {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", errors.NotValidf("block type %q", name)
+}
+
+// Block represents a block switched on by an administrator to guard
+// the environment against some kinds of operation.
+type Block struct {
+	doc blockDoc
+}
+
+type blockDoc struct {
+	Type    BlockType `bson:"_id"`
+	Message string
+}
+
+// Type returns the kind of block.
+func (b *Block) Type() BlockType {
+	return b.doc.Type
+}
+
+// Message returns the reason given when the block was switched on.
+func (b *Block) Message() string {
+	return b.doc.Message
+}
+
+// SwitchBlockOn switches on the block of the given type, recording
+// msg as the reason. If the block is already on, its reason is
+// replaced.
+func (st *State) SwitchBlockOn(t BlockType, msg string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot switch on %q block", t)
+	if _, err := ParseBlockType(string(t)); err != nil {
+		return err
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		_, found, err := st.GetBlockForType(t)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return []txn.Op{{
+				C:      blocksC,
+				Id:     t,
+				Assert: txn.DocExists,
+				Update: bson.D{{"$set", bson.D{{"message", msg}}}},
+			}}, nil
+		}
+		return []txn.Op{{
+			C:      blocksC,
+			Id:     t,
+			Assert: txn.DocMissing,
+			Insert: &blockDoc{Type: t, Message: msg},
+		}}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// SwitchBlockOff switches off the block of the given type. It returns
+// an error satisfying errors.IsNotFound if the block is not on.
+func (st *State) SwitchBlockOff(t BlockType) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot switch off %q block", t)
+	ops := []txn.Op{{
+		C:      blocksC,
+		Id:     t,
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("%q block", t)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBlockForType returns the block of the given type, and whether
+// it is switched on.
+func (st *State) GetBlockForType(t BlockType) (*Block, bool, error) {
+	blocks, closer := st.getCollection(blocksC)
+	defer closer()
+
+	doc := blockDoc{}
+	err := blocks.FindId(t).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Annotatef(err, "cannot get %q block", t)
+	}
+	return &Block{doc}, true, nil
+}
+
+// AllBlocks returns all the blocks that are switched on, from the
+// least to the most restrictive.
+func (st *State) AllBlocks() ([]*Block, error) {
+	var blocks []*Block
+	for _, t := range AllBlockTypes() {
+		block, found, err := st.GetBlockForType(t)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
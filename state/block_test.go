@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type BlockSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&BlockSuite{})
+
+func (s *BlockSuite) assertBlocks(c *gc.C, expect map[state.BlockType]string) {
+	blocks, err := s.State.AllBlocks()
+	c.Assert(err, gc.IsNil)
+	got := make(map[state.BlockType]string)
+	for _, block := range blocks {
+		got[block.Type()] = block.Message()
+	}
+	c.Assert(got, jc.DeepEquals, expect)
+}
+
+func (s *BlockSuite) TestSwitchBlockOnAndOff(c *gc.C) {
+	s.assertBlocks(c, map[state.BlockType]string{})
+	_, found, err := s.State.GetBlockForType(state.DestroyBlock)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.IsFalse)
+
+	err = s.State.SwitchBlockOn(state.DestroyBlock, "production")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.ChangeBlock, "")
+	c.Assert(err, gc.IsNil)
+	block, found, err := s.State.GetBlockForType(state.DestroyBlock)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.IsTrue)
+	c.Assert(block.Type(), gc.Equals, state.DestroyBlock)
+	c.Assert(block.Message(), gc.Equals, "production")
+
+	// Switching a block on again replaces its message.
+	err = s.State.SwitchBlockOn(state.DestroyBlock, "still production")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{
+		state.DestroyBlock: "still production",
+		state.ChangeBlock:  "",
+	})
+
+	err = s.State.SwitchBlockOff(state.ChangeBlock)
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, map[state.BlockType]string{
+		state.DestroyBlock: "still production",
+	})
+	err = s.State.SwitchBlockOff(state.ChangeBlock)
+	c.Assert(err, gc.ErrorMatches, `cannot switch off "all-changes" block: "all-changes" block not found`)
+	c.Assert(errors.Cause(err), jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *BlockSuite) TestSwitchBlockOnInvalidType(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.BlockType("everything"), "")
+	c.Assert(err, gc.ErrorMatches, `cannot switch on "everything" block: block type "everything" not valid`)
+}
+
+func (s *BlockSuite) TestParseBlockType(c *gc.C) {
+	for _, t := range state.AllBlockTypes() {
+		parsed, err := state.ParseBlockType(string(t))
+		c.Assert(err, gc.IsNil)
+		c.Assert(parsed, gc.Equals, t)
+	}
+	_, err := state.ParseBlockType("everything")
+	c.Assert(err, jc.Satisfies, errors.IsNotValid)
+}
@@ -0,0 +1,296 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/environs/config"
+)
+
+const caRotationKey = "caRotation"
+
+// caRotationDoc holds the CA that replaces the environment's CA
+// while the CA is being rotated.
+type caRotationDoc struct {
+	Id           string `bson:"_id"`
+	CACert       string
+	CAPrivateKey string
+
+	// Finishing records that the new CA signs the state server
+	// certificates. The old CA is still trusted until all the state
+	// servers use certificates signed by the new one.
+	Finishing bool
+
+	// Updated holds the ids of the state server machines known to
+	// use a certificate signed by the new CA.
+	Updated []string
+
+	TxnRevno int64 `bson:"txn-revno"`
+}
+
+// TrustedCACert returns the CA certificates, in PEM format, that the
+// agents and clients of the environment trust to have signed the
+// state server certificates. While the CA is being rotated, it holds
+// both the current and the new CA certificate. The first certificate
+// is always the one that signs the state server certificates.
+func (st *State) TrustedCACert() (string, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if caCert, ok := cfg.CACert(); ok {
+		return caCert, nil
+	}
+	return st.CACert(), nil
+}
+
+// WatchTrustedCACert returns a NotifyWatcher that notifies when the
+// CA certificates trusted by the environment may have changed.
+func (st *State) WatchTrustedCACert() NotifyWatcher {
+	return newEntityWatcher(st, settingsC, environGlobalKey)
+}
+
+// StartCARotation starts replacing the environment's CA with the one
+// with the given certificate and private key. The new CA certificate
+// is trusted along with the current one, so that agents and clients
+// can learn about it, but state server certificates are still signed
+// by the current CA until FinishCARotation is called.
+func (st *State) StartCARotation(caCert, caKey string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot start CA rotation")
+	xcert, _, err := cert.ParseCertAndKey(caCert, caKey)
+	if err != nil {
+		return errors.Annotate(err, "invalid CA certificate or key")
+	}
+	if !xcert.BasicConstraintsValid || !xcert.IsCA {
+		return errors.New("CA certificate is not a valid CA")
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if inProgress, err := st.CARotationInProgress(); err != nil {
+			return nil, err
+		} else if inProgress {
+			return nil, errors.New("CA rotation already in progress")
+		}
+		settingsOp, err := st.updateTrustedCACertOp(func(trusted string) string {
+			return trusted + caCert
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      stateServersC,
+			Id:     caRotationKey,
+			Assert: txn.DocMissing,
+			Insert: &caRotationDoc{
+				Id:           caRotationKey,
+				CACert:       caCert,
+				CAPrivateKey: caKey,
+			},
+		}, settingsOp}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// CARotationInProgress reports whether the environment's CA is being
+// rotated.
+func (st *State) CARotationInProgress() (bool, error) {
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	n, err := stateServers.FindId(caRotationKey).Count()
+	if err != nil {
+		return false, errors.Annotate(err, "cannot read CA rotation")
+	}
+	return n > 0, nil
+}
+
+// FinishCARotation starts completing the replacement of the
+// environment's CA started by StartCARotation: state server
+// certificates are signed by the new CA from now on, but the old CA
+// certificate stays trusted until RecordStateServerCertificate has
+// been told that every state server uses a certificate signed by the
+// new CA.
+func (st *State) FinishCARotation() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot finish CA rotation")
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		var doc caRotationDoc
+		if err := stateServers.FindId(caRotationKey).One(&doc); err == mgo.ErrNotFound {
+			return nil, errors.New("no CA rotation in progress")
+		} else if err != nil {
+			return nil, err
+		} else if doc.Finishing {
+			return nil, errors.New("CA rotation already finishing")
+		}
+		// State servers need the new CA's key to sign their
+		// certificates, so it must be possible to record it.
+		if _, err := st.StateServingInfo(); err != nil {
+			return nil, err
+		}
+		// The first trusted CA signs state server certificates.
+		settingsOp, err := st.updateTrustedCACertOp(func(trusted string) string {
+			return doc.CACert + strings.Replace(trusted, doc.CACert, "", 1)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      stateServersC,
+			Id:     caRotationKey,
+			Assert: bson.D{{"txn-revno", doc.TxnRevno}},
+			Update: bson.D{{"$set", bson.D{{"finishing", true}}}},
+		}, {
+			C:      stateServersC,
+			Id:     stateServingInfoKey,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"caprivatekey", doc.CAPrivateKey}}}},
+		}, settingsOp}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// RecordStateServerCertificate records that the state server on the
+// machine with the given id uses the given certificate. While a CA
+// rotation is finishing, once every state server uses a certificate
+// signed by the new CA, the old CA certificate is not trusted anymore
+// and the rotation is over. Nothing is done otherwise.
+func (st *State) RecordStateServerCertificate(machineId, srvCert string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot record certificate of state server %q", machineId)
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		var doc caRotationDoc
+		if err := stateServers.FindId(caRotationKey).One(&doc); err == mgo.ErrNotFound {
+			return nil, jujutxn.ErrNoOperations
+		} else if err != nil {
+			return nil, err
+		}
+		if !doc.Finishing || cert.Verify(srvCert, doc.CACert, time.Now()) != nil {
+			return nil, jujutxn.ErrNoOperations
+		}
+		info, err := st.StateServerInfo()
+		if err != nil {
+			return nil, err
+		}
+		updated := make(map[string]bool)
+		for _, id := range doc.Updated {
+			updated[id] = true
+		}
+		recorded := updated[machineId]
+		updated[machineId] = true
+		for _, id := range info.MachineIds {
+			if updated[id] {
+				continue
+			}
+			if recorded {
+				return nil, jujutxn.ErrNoOperations
+			}
+			return []txn.Op{{
+				C:      stateServersC,
+				Id:     caRotationKey,
+				Assert: bson.D{{"txn-revno", doc.TxnRevno}},
+				Update: bson.D{{"$addToSet", bson.D{{"updated", machineId}}}},
+			}}, nil
+		}
+		// All the state servers use the new CA, so the old one can
+		// be dropped.
+		settingsOp, err := st.updateTrustedCACertOp(func(string) string {
+			return doc.CACert
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      stateServersC,
+			Id:     caRotationKey,
+			Assert: bson.D{{"txn-revno", doc.TxnRevno}},
+			Remove: true,
+		}, settingsOp}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// SetServerCertificate makes the state servers use the given
+// externally signed certificate and private key. The certificate may
+// be followed by the intermediate certificates that chain it to the
+// given CA certificate, which replaces the CA certificates trusted by
+// the environment. As the CA private key is not known, state servers
+// stop issuing certificates themselves; rotating the CA lets them
+// issue certificates again.
+func (st *State) SetServerCertificate(caCert, srvCert, srvKey string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set server certificate")
+	if _, _, err := cert.ParseCertAndKey(srvCert, srvKey); err != nil {
+		return errors.Annotate(err, "invalid certificate or key")
+	}
+	if err := cert.Verify(srvCert, caCert, time.Now()); err != nil {
+		return errors.Annotate(err, "invalid certificate")
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if inProgress, err := st.CARotationInProgress(); err != nil {
+			return nil, err
+		} else if inProgress {
+			return nil, errors.New("CA rotation in progress")
+		}
+		if _, err := st.StateServingInfo(); err != nil {
+			return nil, err
+		}
+		settingsOp, err := st.updateTrustedCACertOp(func(string) string {
+			return caCert
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      stateServersC,
+			Id:     caRotationKey,
+			Assert: txn.DocMissing,
+		}, {
+			C:      stateServersC,
+			Id:     stateServingInfoKey,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{
+				{"cert", srvCert},
+				{"privatekey", srvKey},
+				{"caprivatekey", ""},
+			}}},
+		}, settingsOp}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// updateTrustedCACertOp returns an operation that replaces the CA
+// certificates trusted by the environment with the result of calling
+// update with the current ones. The operation fails if the
+// environment settings have changed since they were read, so that
+// the change is made in the same transaction as the CA change it
+// belongs to.
+func (st *State) updateTrustedCACertOp(update func(trusted string) string) (txn.Op, error) {
+	settings, err := readSettings(st, environGlobalKey)
+	if err != nil {
+		return txn.Op{}, err
+	}
+	cfg, err := config.New(config.NoDefaults, settings.Map())
+	if err != nil {
+		return txn.Op{}, err
+	}
+	trusted, ok := cfg.CACert()
+	if !ok {
+		trusted = st.CACert()
+	}
+	caCert := update(trusted)
+	if _, err := cfg.Apply(map[string]interface{}{"ca-cert": caCert}); err != nil {
+		return txn.Op{}, err
+	}
+	op := settings.assertUnchangedOp()
+	op.Update = bson.D{{"$set", bson.D{{"ca-cert", caCert}}}}
+	return op, nil
+}
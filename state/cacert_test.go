@@ -0,0 +1,253 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/testing"
+)
+
+type CACertSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&CACertSuite{})
+
+func (s *CACertSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(state.StateServingInfo{
+		APIPort:      1234,
+		StatePort:    2345,
+		Cert:         testing.ServerCert,
+		PrivateKey:   testing.ServerKey,
+		CAPrivateKey: testing.CAKey,
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *CACertSuite) newCA(c *gc.C) (string, string) {
+	caCert, caKey, err := cert.NewCA("rotated", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	return caCert, caKey
+}
+
+func (s *CACertSuite) assertCAPrivateKey(c *gc.C, caKey string) {
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.CAPrivateKey, gc.Equals, caKey)
+}
+
+func (s *CACertSuite) TestTrustedCACert(c *gc.C) {
+	caCert, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, testing.CACert)
+}
+
+func (s *CACertSuite) TestRotateCA(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	newCACert, newCAKey := s.newCA(c)
+	err = s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+
+	// Both CAs are trusted, but the old one still signs.
+	inProgress, err := s.State.CARotationInProgress()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inProgress, jc.IsTrue)
+	caCert, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, testing.CACert+newCACert)
+	s.assertCAPrivateKey(c, testing.CAKey)
+
+	// Certificates signed by the new CA are not taken into account
+	// before the rotation is finishing.
+	srvCert, _, err := cert.NewServer(newCACert, newCAKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.RecordStateServerCertificate(m0.Id(), srvCert)
+	c.Assert(err, gc.IsNil)
+
+	// Once finishing, the new CA signs, but both are still trusted.
+	err = s.State.FinishCARotation()
+	c.Assert(err, gc.IsNil)
+	inProgress, err = s.State.CARotationInProgress()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inProgress, jc.IsTrue)
+	caCert, err = s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, newCACert+testing.CACert)
+	s.assertCAPrivateKey(c, newCAKey)
+
+	// The old CA is dropped once all the state servers use a
+	// certificate signed by the new one.
+	err = s.State.RecordStateServerCertificate(m0.Id(), srvCert)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RecordStateServerCertificate(m1.Id(), testing.ServerCert)
+	c.Assert(err, gc.IsNil)
+	caCert, err = s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, newCACert+testing.CACert)
+
+	err = s.State.RecordStateServerCertificate(m1.Id(), srvCert)
+	c.Assert(err, gc.IsNil)
+	inProgress, err = s.State.CARotationInProgress()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inProgress, jc.IsFalse)
+	caCert, err = s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, newCACert)
+	s.assertCAPrivateKey(c, newCAKey)
+}
+
+func (s *CACertSuite) TestFinishCARotationTwice(c *gc.C) {
+	newCACert, newCAKey := s.newCA(c)
+	err := s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+	err = s.State.FinishCARotation()
+	c.Assert(err, gc.IsNil)
+	err = s.State.FinishCARotation()
+	c.Assert(err, gc.ErrorMatches, "cannot finish CA rotation: CA rotation already finishing")
+}
+
+func (s *CACertSuite) TestRecordStateServerCertificateWithoutRotation(c *gc.C) {
+	err := s.State.RecordStateServerCertificate("0", testing.ServerCert)
+	c.Assert(err, gc.IsNil)
+	caCert, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, testing.CACert)
+}
+
+func (s *CACertSuite) TestStartCARotationConcurrentConfigChange(c *gc.C) {
+	newCACert, newCAKey := s.newCA(c)
+	otherCACert, _ := s.newCA(c)
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := s.State.UpdateEnvironConfig(map[string]interface{}{
+			"ca-cert": testing.CACert + otherCACert,
+		}, nil, nil)
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	err := s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+	caCert, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, testing.CACert+otherCACert+newCACert)
+}
+
+func (s *CACertSuite) TestStartCARotationTwice(c *gc.C) {
+	newCACert, newCAKey := s.newCA(c)
+	err := s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+	otherCACert, otherCAKey := s.newCA(c)
+	err = s.State.StartCARotation(otherCACert, otherCAKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: CA rotation already in progress")
+
+	caCert, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(caCert, gc.Equals, testing.CACert+newCACert)
+}
+
+func (s *CACertSuite) TestStartCARotationWithMismatchedKey(c *gc.C) {
+	newCACert, _ := s.newCA(c)
+	err := s.State.StartCARotation(newCACert, testing.CAKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: invalid CA certificate or key: .*")
+
+	inProgress, err := s.State.CARotationInProgress()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inProgress, jc.IsFalse)
+}
+
+func (s *CACertSuite) TestStartCARotationWithNonCA(c *gc.C) {
+	err := s.State.StartCARotation(testing.ServerCert, testing.ServerKey)
+	c.Assert(err, gc.ErrorMatches, "cannot start CA rotation: CA certificate is not a valid CA")
+}
+
+func (s *CACertSuite) TestFinishCARotationNotStarted(c *gc.C) {
+	err := s.State.FinishCARotation()
+	c.Assert(err, gc.ErrorMatches, "cannot finish CA rotation: no CA rotation in progress")
+	s.assertCAPrivateKey(c, testing.CAKey)
+}
+
+func (s *CACertSuite) TestWatchTrustedCACert(c *gc.C) {
+	w := s.State.WatchTrustedCACert()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	newCACert, newCAKey := s.newCA(c)
+	err := s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	err = s.State.FinishCARotation()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	srvCert, _, err := cert.NewServer(newCACert, newCAKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.RecordStateServerCertificate("0", srvCert)
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
+
+// newExternalCert returns a CA certificate, and a server certificate
+// and key signed by that CA.
+func (s *CACertSuite) newExternalCert(c *gc.C) (string, string, string) {
+	caCert, caKey := s.newCA(c)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	return caCert, srvCert, srvKey
+}
+
+func (s *CACertSuite) TestSetServerCertificate(c *gc.C) {
+	caCert, srvCert, srvKey := s.newExternalCert(c)
+	err := s.State.SetServerCertificate(caCert, srvCert, srvKey)
+	c.Assert(err, gc.IsNil)
+
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, srvCert)
+	c.Assert(info.PrivateKey, gc.Equals, srvKey)
+	c.Assert(info.CAPrivateKey, gc.Equals, "")
+	c.Assert(info.APIPort, gc.Equals, 1234)
+	trusted, err := s.State.TrustedCACert()
+	c.Assert(err, gc.IsNil)
+	c.Assert(trusted, gc.Equals, caCert)
+}
+
+func (s *CACertSuite) TestSetServerCertificateNotSignedByCA(c *gc.C) {
+	_, srvCert, srvKey := s.newExternalCert(c)
+	err := s.State.SetServerCertificate(testing.CACert, srvCert, srvKey)
+	c.Assert(err, gc.ErrorMatches, "cannot set server certificate: invalid certificate: x509: certificate signed by unknown authority.*")
+
+	info, err := s.State.StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Cert, gc.Equals, testing.ServerCert)
+	s.assertCAPrivateKey(c, testing.CAKey)
+}
+
+func (s *CACertSuite) TestSetServerCertificateMismatchedKey(c *gc.C) {
+	caCert, srvCert, _ := s.newExternalCert(c)
+	err := s.State.SetServerCertificate(caCert, srvCert, testing.ServerKey)
+	c.Assert(err, gc.ErrorMatches, "cannot set server certificate: invalid certificate or key: .*")
+}
+
+func (s *CACertSuite) TestSetServerCertificateDuringCARotation(c *gc.C) {
+	newCACert, newCAKey := s.newCA(c)
+	err := s.State.StartCARotation(newCACert, newCAKey)
+	c.Assert(err, gc.IsNil)
+
+	caCert, srvCert, srvKey := s.newExternalCert(c)
+	err = s.State.SetServerCertificate(caCert, srvCert, srvKey)
+	c.Assert(err, gc.ErrorMatches, "cannot set server certificate: CA rotation in progress")
+	s.assertCAPrivateKey(c, testing.CAKey)
+}
@@ -5,8 +5,11 @@ package state
 
 import (
 	"net/url"
+	"time"
 
 	"gopkg.in/juju/charm.v3"
+
+	"github.com/juju/juju/resource"
 )
 
 // charmDoc represents the internal state of a charm in MongoDB.
@@ -15,10 +18,23 @@ type charmDoc struct {
 	Meta          *charm.Meta
 	Config        *charm.Config
 	Actions       *charm.Actions
+	Resources     map[string]resource.Meta
 	BundleURL     *url.URL
 	BundleSha256  string
+	BundleSize    int64 `bson:",omitempty"`
 	PendingUpload bool
 	Placeholder   bool
+
+	// RefCount holds the number of service settings documents
+	// that refer to the charm. It is kept in step with the
+	// settingsrefs collection so that a charm cannot be removed
+	// while a service or unit is starting to use it.
+	RefCount int `bson:"refcount"`
+
+	// Added records when the charm was added to state, so that
+	// unused charms are not collected while a deployment using
+	// them may still be in progress.
+	Added time.Time `bson:",omitempty"`
 }
 
 // Charm represents the state of a charm in the environment.
@@ -62,6 +78,12 @@ func (c *Charm) Actions() *charm.Actions {
 	return c.doc.Actions
 }
 
+// Resources returns the resources declared by the charm,
+// keyed by name.
+func (c *Charm) Resources() map[string]resource.Meta {
+	return c.doc.Resources
+}
+
 // BundleURL returns the url to the charm bundle in
 // the provider storage.
 func (c *Charm) BundleURL() *url.URL {
@@ -73,6 +95,12 @@ func (c *Charm) BundleSha256() string {
 	return c.doc.BundleSha256
 }
 
+// BundleSize returns the size in bytes of the charm bundle, or zero
+// if it is not known.
+func (c *Charm) BundleSize() int64 {
+	return c.doc.BundleSize
+}
+
 // IsUploaded returns whether the charm has been uploaded to the
 // provider storage.
 func (c *Charm) IsUploaded() bool {
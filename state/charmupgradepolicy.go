@@ -0,0 +1,235 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// CharmUpgradePolicy describes whether a service's charm is upgraded
+// automatically when a new revision is published in the charm store.
+type CharmUpgradePolicy string
+
+const (
+	// UpgradeNever means that the charm is only upgraded explicitly.
+	// This is the policy of services that have not set one.
+	UpgradeNever CharmUpgradePolicy = "never"
+
+	// UpgradeNotify means that new revisions are reported, but the
+	// charm is only upgraded explicitly.
+	UpgradeNotify CharmUpgradePolicy = "notify"
+
+	// UpgradePatchRevisions means that the charm is upgraded to new
+	// revisions that do not change its relations or its
+	// configuration options; other revisions are only reported.
+	UpgradePatchRevisions CharmUpgradePolicy = "patch-revisions"
+
+	// UpgradeAlways means that the charm is upgraded to every new
+	// revision.
+	UpgradeAlways CharmUpgradePolicy = "always"
+)
+
+// ParseCharmUpgradePolicy returns the upgrade policy with the given
+// name.
+func ParseCharmUpgradePolicy(s string) (CharmUpgradePolicy, error) {
+	switch policy := CharmUpgradePolicy(s); policy {
+	case UpgradeNever, UpgradeNotify, UpgradePatchRevisions, UpgradeAlways:
+		return policy, nil
+	}
+	return "", errors.NotValidf("charm upgrade policy %q", s)
+}
+
+// AutoUpgrades reports whether the policy allows charms to be
+// upgraded without an explicit request.
+func (p CharmUpgradePolicy) AutoUpgrades() bool {
+	return p == UpgradePatchRevisions || p == UpgradeAlways
+}
+
+// MaintenanceWindow is a daily period of time during which automatic
+// charm upgrades may start.
+type MaintenanceWindow struct {
+	// Start and End hold the times of day, in UTC, at which the
+	// window opens and closes. If End is before Start, the window
+	// spans midnight.
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseMaintenanceWindow parses a maintenance window written as
+// "HH:MM-HH:MM", in UTC.
+func ParseMaintenanceWindow(s string) (*MaintenanceWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, errors.NotValidf("maintenance window %q (expected HH:MM-HH:MM)", s)
+	}
+	var times [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", part)
+		if err != nil {
+			return nil, errors.NotValidf("maintenance window %q (expected HH:MM-HH:MM)", s)
+		}
+		times[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if times[0] == times[1] {
+		return nil, errors.NotValidf("empty maintenance window %q", s)
+	}
+	return &MaintenanceWindow{Start: times[0], End: times[1]}, nil
+}
+
+// String returns the window in the format accepted by
+// ParseMaintenanceWindow.
+func (w *MaintenanceWindow) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return format(w.Start) + "-" + format(w.End)
+}
+
+// Contains reports whether the given time falls within the window.
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := t.Sub(midnight)
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// UpgradePolicy returns the service's charm upgrade policy, and the
+// maintenance window automatic upgrades are restricted to, if any.
+func (s *Service) UpgradePolicy() (CharmUpgradePolicy, *MaintenanceWindow) {
+	policy := s.doc.UpgradePolicy
+	if policy == "" {
+		policy = UpgradeNever
+	}
+	if s.doc.UpgradeWindow == nil {
+		return policy, nil
+	}
+	window := *s.doc.UpgradeWindow
+	return policy, &window
+}
+
+// SetUpgradePolicy sets the service's charm upgrade policy. If window
+// is not nil, automatic upgrades only start within it.
+func (s *Service) SetUpgradePolicy(policy CharmUpgradePolicy, window *MaintenanceWindow) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set upgrade policy of service %q", s)
+	if _, err := ParseCharmUpgradePolicy(string(policy)); err != nil {
+		return err
+	}
+	var update bson.D
+	if window == nil {
+		update = bson.D{
+			{"$set", bson.D{{"upgradepolicy", policy}}},
+			{"$unset", bson.D{{"upgradewindow", nil}}},
+		}
+	} else {
+		update = bson.D{{"$set", bson.D{
+			{"upgradepolicy", policy},
+			{"upgradewindow", window},
+		}}}
+	}
+	ops := []txn.Op{{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Assert: isAliveDoc,
+		Update: update,
+	}}
+	if err := s.st.runTransaction(ops); err != nil {
+		return onAbort(err, errNotAlive)
+	}
+	s.doc.UpgradePolicy = policy
+	s.doc.UpgradeWindow = nil
+	if window != nil {
+		w := *window
+		s.doc.UpgradeWindow = &w
+	}
+	return nil
+}
+
+// AutoCharmUpgrade records a charm upgrade made automatically because
+// of a service's upgrade policy.
+type AutoCharmUpgrade struct {
+	ServiceName string
+	From        *charm.URL
+	To          *charm.URL
+	Policy      CharmUpgradePolicy
+	Time        time.Time
+}
+
+type autoCharmUpgradeDoc struct {
+	Id          string `bson:"_id"`
+	Seq         int
+	ServiceName string
+	From        *charm.URL
+	To          *charm.URL
+	Policy      CharmUpgradePolicy
+	Time        time.Time
+}
+
+// SetCharmAutomatically changes the charm for the service like
+// SetCharm, on behalf of the service's upgrade policy, and records
+// the upgrade in the same transaction, so that it is reported by
+// AutoCharmUpgrades.
+func (s *Service) SetCharmAutomatically(ch *Charm) error {
+	policy, _ := s.UpgradePolicy()
+	if !policy.AutoUpgrades() {
+		return fmt.Errorf("cannot upgrade charm of service %q automatically: upgrade policy is %q", s, policy)
+	}
+	seq, err := s.st.sequence("autocharmupgrade")
+	if err != nil {
+		return errors.Annotate(err, "cannot record charm upgrade")
+	}
+	id := strconv.Itoa(seq)
+	recordOp := txn.Op{
+		C:      autoCharmUpgradesC,
+		Id:     id,
+		Assert: txn.DocMissing,
+		Insert: &autoCharmUpgradeDoc{
+			Id:          id,
+			Seq:         seq,
+			ServiceName: s.doc.Name,
+			From:        s.doc.CharmURL,
+			To:          ch.URL(),
+			Policy:      policy,
+			Time:        nowToTheSecond(),
+		},
+	}
+	return s.setCharm(ch, false, 0, recordOp)
+}
+
+// AutoCharmUpgrades returns the automatic charm upgrades of the named
+// service, or of all services if serviceName is empty, oldest first.
+func (st *State) AutoCharmUpgrades(serviceName string) ([]AutoCharmUpgrade, error) {
+	upgrades, closer := st.getCollection(autoCharmUpgradesC)
+	defer closer()
+
+	var query bson.D
+	if serviceName != "" {
+		query = bson.D{{"servicename", serviceName}}
+	}
+	var docs []autoCharmUpgradeDoc
+	if err := upgrades.Find(query).Sort("seq").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get automatic charm upgrades")
+	}
+	result := make([]AutoCharmUpgrade, len(docs))
+	for i, doc := range docs {
+		result[i] = AutoCharmUpgrade{
+			ServiceName: doc.ServiceName,
+			From:        doc.From,
+			To:          doc.To,
+			Policy:      doc.Policy,
+			Time:        doc.Time,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type CharmUpgradePolicySuite struct {
+	ConnSuite
+	oldCharm *state.Charm
+	newCharm *state.Charm
+	service  *state.Service
+}
+
+var _ = gc.Suite(&CharmUpgradePolicySuite{})
+
+func (s *CharmUpgradePolicySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.oldCharm = s.AddConfigCharm(c, "wordpress", emptyConfig, 1)
+	s.newCharm = s.AddConfigCharm(c, "wordpress", emptyConfig, 2)
+	s.service = s.AddTestingService(c, "wordpress", s.oldCharm)
+}
+
+func (s *CharmUpgradePolicySuite) TestParseCharmUpgradePolicy(c *gc.C) {
+	for _, name := range []string{"never", "notify", "patch-revisions", "always"} {
+		policy, err := state.ParseCharmUpgradePolicy(name)
+		c.Check(err, gc.IsNil)
+		c.Check(policy, gc.Equals, state.CharmUpgradePolicy(name))
+	}
+	_, err := state.ParseCharmUpgradePolicy("sometimes")
+	c.Assert(err, gc.ErrorMatches, `charm upgrade policy "sometimes" not valid`)
+}
+
+var maintenanceWindowTests = []struct {
+	window   string
+	err      string
+	contains []string
+	excludes []string
+}{{
+	window:   "02:00-04:30",
+	contains: []string{"02:00", "03:15", "04:29"},
+	excludes: []string{"01:59", "04:30", "12:00"},
+}, {
+	window:   "22:00-02:00",
+	contains: []string{"22:00", "23:59", "00:00", "01:59"},
+	excludes: []string{"02:00", "12:00", "21:59"},
+}, {
+	window: "02:00",
+	err:    `maintenance window "02:00" \(expected HH:MM-HH:MM\) not valid`,
+}, {
+	window: "02:00-25:00",
+	err:    `maintenance window "02:00-25:00" \(expected HH:MM-HH:MM\) not valid`,
+}, {
+	window: "02:00-02:00",
+	err:    `empty maintenance window "02:00-02:00" not valid`,
+}}
+
+func (s *CharmUpgradePolicySuite) TestMaintenanceWindow(c *gc.C) {
+	day := time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(clock string) time.Time {
+		t, err := time.Parse("15:04", clock)
+		c.Assert(err, gc.IsNil)
+		return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	for i, t := range maintenanceWindowTests {
+		c.Logf("test %d: %s", i, t.window)
+		w, err := state.ParseMaintenanceWindow(t.window)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(w.String(), gc.Equals, t.window)
+		for _, clock := range t.contains {
+			c.Check(w.Contains(at(clock)), jc.IsTrue, gc.Commentf("%s", clock))
+		}
+		for _, clock := range t.excludes {
+			c.Check(w.Contains(at(clock)), jc.IsFalse, gc.Commentf("%s", clock))
+		}
+	}
+}
+
+func (s *CharmUpgradePolicySuite) TestSetUpgradePolicy(c *gc.C) {
+	policy, window := s.service.UpgradePolicy()
+	c.Assert(policy, gc.Equals, state.UpgradeNever)
+	c.Assert(window, gc.IsNil)
+
+	w, err := state.ParseMaintenanceWindow("02:00-04:00")
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetUpgradePolicy(state.UpgradeAlways, w)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	policy, window = s.service.UpgradePolicy()
+	c.Assert(policy, gc.Equals, state.UpgradeAlways)
+	c.Assert(window, gc.DeepEquals, w)
+
+	err = s.service.SetUpgradePolicy(state.UpgradeNotify, nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	policy, window = s.service.UpgradePolicy()
+	c.Assert(policy, gc.Equals, state.UpgradeNotify)
+	c.Assert(window, gc.IsNil)
+
+	err = s.service.SetUpgradePolicy("sometimes", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set upgrade policy of service "wordpress": charm upgrade policy "sometimes" not valid`)
+}
+
+func (s *CharmUpgradePolicySuite) TestSetUpgradePolicyServiceNotAlive(c *gc.C) {
+	err := s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetUpgradePolicy(state.UpgradeAlways, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set upgrade policy of service "wordpress": not found or not alive`)
+}
+
+func (s *CharmUpgradePolicySuite) TestSetCharmAutomatically(c *gc.C) {
+	err := s.service.SetCharmAutomatically(s.newCharm)
+	c.Assert(err, gc.ErrorMatches, `cannot upgrade charm of service "wordpress" automatically: upgrade policy is "never"`)
+
+	err = s.service.SetUpgradePolicy(state.UpgradePatchRevisions, nil)
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetCharmAutomatically(s.newCharm)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	curl, _ := s.service.CharmURL()
+	c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	upgrades, err := s.State.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 1)
+	c.Assert(upgrades[0].Time.IsZero(), jc.IsFalse)
+	upgrades[0].Time = time.Time{}
+	c.Assert(upgrades[0], jc.DeepEquals, state.AutoCharmUpgrade{
+		ServiceName: "wordpress",
+		From:        s.oldCharm.URL(),
+		To:          s.newCharm.URL(),
+		Policy:      state.UpgradePatchRevisions,
+	})
+	upgrades, err = s.State.AutoCharmUpgrades(mysql.Name())
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 0)
+}
+
+func (s *CharmUpgradePolicySuite) TestSetCharmAutomaticallyFailureNotRecorded(c *gc.C) {
+	err := s.service.SetUpgradePolicy(state.UpgradeAlways, nil)
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	err = s.service.SetCharmAutomatically(s.newCharm)
+	c.Assert(err, gc.ErrorMatches, `service "wordpress" is not alive`)
+	upgrades, err := s.State.AutoCharmUpgrades("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 0)
+}
@@ -135,8 +135,9 @@ func (s *compatSuite) TestShowUnitPorts(c *gc.C) {
 	err = unit.Refresh()
 	c.Assert(err, gc.IsNil)
 
-	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{{"tcp", 80}})
+	ports, err := unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{80, 80, "tcp"}})
 }
 
 // Check if opening ports on a unit with ports stored in the unit doc works.
@@ -172,8 +173,9 @@ func (s *compatSuite) TestMigratePortsOnOpen(c *gc.C) {
 	err = unit.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
 
-	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	ports, err := unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 }
 
 // Check if closing ports on a unit with ports stored in the unit doc works.
@@ -209,6 +211,41 @@ func (s *compatSuite) TestMigratePortsOnClose(c *gc.C) {
 	err = unit.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{})
+	ports, err := unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{})
+}
+
+// Check that port ranges are kept as ranges in the unit doc.
+func (s *compatSuite) TestUnitDocPortRanges(c *gc.C) {
+	_, err := s.state.AddAdminUser("pass")
+	c.Assert(err, gc.IsNil)
+	charm := addCharm(c, s.state, "quantal", charmtesting.Charms.CharmDir("mysql"))
+	service, err := s.state.AddService("mysql", "user-admin", charm, nil)
+	c.Assert(err, gc.IsNil)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.state.AddMachine("quantal", JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.AssignToMachine(machine), gc.IsNil)
+
+	err = unit.OpenPorts("tcp", 1, 65535)
+	c.Assert(err, gc.IsNil)
+	err = unit.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+	err = unit.OpenPort("udp", 53)
+	c.Assert(err, gc.IsNil)
+	err = unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.doc.Ports, gc.DeepEquals, []network.Port{{"udp", 53}})
+	c.Assert(unit.doc.PortRanges, gc.DeepEquals, []network.PortRange{
+		{1, 65535, "tcp"},
+		{-1, -1, "icmp"},
+	})
+
+	err = unit.ClosePorts("tcp", 1, 65535)
+	c.Assert(err, gc.IsNil)
+	err = unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.doc.PortRanges, gc.DeepEquals, []network.PortRange{{-1, -1, "icmp"}})
 }
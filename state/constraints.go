@@ -26,6 +26,8 @@ type constraintsDoc struct {
 	Container    *instance.ContainerType
 	Tags         *[]string `bson:",omitempty"`
 	Networks     *[]string `bson:",omitempty"`
+	Zones        *[]string `bson:",omitempty"`
+	Spaces       *[]string `bson:",omitempty"`
 }
 
 func (doc constraintsDoc) value() constraints.Value {
@@ -39,6 +41,8 @@ func (doc constraintsDoc) value() constraints.Value {
 		Container:    doc.Container,
 		Tags:         doc.Tags,
 		Networks:     doc.Networks,
+		Zones:        doc.Zones,
+		Spaces:       doc.Spaces,
 	}
 }
 
@@ -53,6 +57,8 @@ func newConstraintsDoc(cons constraints.Value) constraintsDoc {
 		Container:    cons.Container,
 		Tags:         cons.Tags,
 		Networks:     cons.Networks,
+		Zones:        cons.Zones,
+		Spaces:       cons.Spaces,
 	}
 }
 
@@ -29,6 +29,7 @@ var (
 	AddBackupMetadataID   = addBackupMetadataID
 	SetBackupStored       = setBackupStored
 	ToolstorageNewStorage = &toolstorageNewStorage
+	MaxRunOutputSize      = &maxRunOutputSize
 )
 
 func SetTestHooks(c *gc.C, st *State, hooks ...jujutxn.TestHook) txntesting.TransactionChecker {
@@ -88,6 +89,17 @@ func ServiceSettingsRefCount(st *State, serviceName string, curl *charm.URL) (in
 	return 0, mgo.ErrNotFound
 }
 
+func CharmRefCount(st *State, curl *charm.URL) (int, error) {
+	charms, closer := st.getCollection(charmsC)
+	defer closer()
+
+	var doc charmDoc
+	if err := charms.FindId(curl.String()).One(&doc); err != nil {
+		return 0, err
+	}
+	return doc.RefCount, nil
+}
+
 func AddTestingCharm(c *gc.C, st *State, name string) *Charm {
 	return addCharm(c, st, "quantal", charmtesting.Charms.CharmDir(name))
 }
@@ -124,7 +136,7 @@ func addCharm(c *gc.C, st *State, series string, ch charm.Charm) *Charm {
 	curl := charm.MustParseURL("local:" + series + "/" + ident)
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/" + ident)
 	c.Assert(err, gc.IsNil)
-	sch, err := st.AddCharm(ch, curl, bundleURL, ident+"-sha256")
+	sch, err := st.AddCharm(ch, curl, bundleURL, ident+"-sha256", 0)
 	c.Assert(err, gc.IsNil)
 	return sch
 }
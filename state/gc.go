@@ -0,0 +1,140 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/state/toolstorage"
+	"github.com/juju/juju/tools"
+)
+
+// UnusedCharms returns the charms that are used by no service or
+// unit, and that were added to state before the given time.
+// Placeholders and charms pending upload are never returned.
+func (st *State) UnusedCharms(addedBefore time.Time) ([]*Charm, error) {
+	charms, closer := st.getCollection(charmsC)
+	defer closer()
+
+	var docs []charmDoc
+	sel := bson.D{
+		{"placeholder", bson.D{{"$ne", true}}},
+		{"pendingupload", bson.D{{"$ne", true}}},
+		{"refcount", bson.D{{"$not", bson.D{{"$gt", 0}}}}},
+		{"added", bson.D{{"$lt", addedBefore}}},
+	}
+	if err := charms.Find(sel).All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get charms")
+	}
+	unused := make([]*Charm, len(docs))
+	for i := range docs {
+		ch, err := newCharm(st, &docs[i])
+		if err != nil {
+			return nil, err
+		}
+		unused[i] = ch
+	}
+	return unused, nil
+}
+
+// RemoveCharm removes the charm with the given URL from state.
+// It fails if the charm is still used by a service or unit.
+func (st *State) RemoveCharm(curl *charm.URL) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot remove charm %q", curl)
+	charms, closer := st.getCollection(charmsC)
+	defer closer()
+
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		var doc charmDoc
+		sel := bson.D{
+			{"_id", curl.String()},
+			{"placeholder", bson.D{{"$ne", true}}},
+			{"pendingupload", bson.D{{"$ne", true}}},
+		}
+		if err := charms.Find(sel).One(&doc); err == mgo.ErrNotFound {
+			return nil, errors.NotFoundf("charm %q", curl)
+		} else if err != nil {
+			return nil, err
+		}
+		if doc.RefCount > 0 {
+			return nil, errors.Errorf("charm is used by %d services", doc.RefCount)
+		}
+		return []txn.Op{{
+			C:  charmsC,
+			Id: curl.String(),
+			Assert: bson.D{
+				{"placeholder", bson.D{{"$ne", true}}},
+				{"pendingupload", bson.D{{"$ne", true}}},
+				{"refcount", bson.D{{"$not", bson.D{{"$gt", 0}}}}},
+			},
+			Remove: true,
+		}}, nil
+	}
+	return st.run(buildTxn)
+}
+
+// UnusedTools returns the metadata of the tools in tools storage
+// that are older than the environment's agent version, are not
+// running on any machine or unit, and were added to storage before
+// the given time. Tools at or above the agent version are kept, as
+// they may be about to be used by an upgrade.
+func (st *State) UnusedTools(addedBefore time.Time) ([]toolstorage.Metadata, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	agentVersion, _ := cfg.AgentVersion()
+
+	inUse := make(map[string]bool)
+	for _, name := range []string{machinesC, unitsC} {
+		coll, closer := st.getCollection(name)
+		var doc struct {
+			Tools *tools.Tools `bson:",omitempty"`
+		}
+		iter := coll.Find(nil).Select(bson.D{{"tools", 1}}).Iter()
+		for iter.Next(&doc) {
+			if doc.Tools != nil {
+				inUse[doc.Tools.Version.String()] = true
+			}
+			doc.Tools = nil
+		}
+		err := iter.Close()
+		closer()
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot get tools used in %s", name)
+		}
+	}
+
+	storage, err := st.ToolsStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer storage.Close()
+	all, err := storage.AllMetadata()
+	if err != nil {
+		return nil, err
+	}
+	var unused []toolstorage.Metadata
+	for _, metadata := range all {
+		if metadata.Version.Number.Compare(agentVersion) >= 0 {
+			continue
+		}
+		if inUse[metadata.Version.String()] {
+			continue
+		}
+		// Tools stored before the added time was recorded are
+		// left alone until an upgrade step records it.
+		if metadata.Added.IsZero() || !metadata.Added.Before(addedBefore) {
+			continue
+		}
+		unused = append(unused, metadata)
+	}
+	return unused, nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/juju/charm.v3"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/toolstorage"
+	"github.com/juju/juju/version"
+)
+
+type GCSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&GCSuite{})
+
+func charmURLs(charms []*state.Charm) []string {
+	var urls []string
+	for _, ch := range charms {
+		urls = append(urls, ch.URL().String())
+	}
+	return urls
+}
+
+func (s *GCSuite) TestUnusedCharms(c *gc.C) {
+	wordpress := s.AddTestingCharm(c, "wordpress")
+	mysql := s.AddTestingCharm(c, "mysql")
+	s.AddTestingService(c, "wordpress", wordpress)
+
+	unused, err := s.State.UnusedCharms(time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(charmURLs(unused), jc.DeepEquals, []string{mysql.URL().String()})
+
+	// Charms added within the grace period are kept.
+	unused, err = s.State.UnusedCharms(time.Now().Add(-time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 0)
+}
+
+func (s *GCSuite) TestUnusedCharmsCountsUnits(c *gc.C) {
+	dummy := s.AddTestingCharm(c, "dummy")
+	service := s.AddTestingService(c, "dummy", dummy)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(dummy.URL())
+	c.Assert(err, gc.IsNil)
+
+	// Upgrade the service; the unit still runs the old charm.
+	upgraded := s.AddConfigCharm(c, "dummy", "options: {}", 2)
+	err = service.SetCharm(upgraded, false)
+	c.Assert(err, gc.IsNil)
+	unused, err := s.State.UnusedCharms(time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 0)
+
+	err = unit.SetCharmURL(upgraded.URL())
+	c.Assert(err, gc.IsNil)
+	unused, err = s.State.UnusedCharms(time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(charmURLs(unused), jc.DeepEquals, []string{dummy.URL().String()})
+}
+
+func (s *GCSuite) TestUnusedCharmsIgnoresPlaceholders(c *gc.C) {
+	_, err := s.State.PrepareLocalCharmUpload(charm.MustParseURL("local:quantal/dummy-1"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.PrepareStoreCharmUpload(charm.MustParseURL("cs:quantal/mysql-1"))
+	c.Assert(err, gc.IsNil)
+	unused, err := s.State.UnusedCharms(time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 0)
+}
+
+func (s *GCSuite) TestRemoveCharm(c *gc.C) {
+	dummy := s.AddTestingCharm(c, "dummy")
+	err := s.State.RemoveCharm(dummy.URL())
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Charm(dummy.URL())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.State.RemoveCharm(dummy.URL())
+	c.Assert(err, gc.ErrorMatches, `cannot remove charm "local:quantal/quantal-dummy-1": charm "local:quantal/quantal-dummy-1" not found`)
+}
+
+func (s *GCSuite) TestRemoveCharmInUse(c *gc.C) {
+	dummy := s.AddTestingCharm(c, "dummy")
+	s.AddTestingService(c, "dummy", dummy)
+	err := s.State.RemoveCharm(dummy.URL())
+	c.Assert(err, gc.ErrorMatches, `cannot remove charm "local:quantal/quantal-dummy-1": charm is used by 1 services`)
+}
+
+func (s *GCSuite) TestRemoveCharmUsedConcurrently(c *gc.C) {
+	dummy := s.AddTestingCharm(c, "dummy")
+	defer state.SetBeforeHooks(c, s.State, func() {
+		s.AddTestingService(c, "dummy", dummy)
+	}).Check()
+	err := s.State.RemoveCharm(dummy.URL())
+	c.Assert(err, gc.ErrorMatches, `cannot remove charm "local:quantal/quantal-dummy-1": charm is used by 1 services`)
+	_, err = s.State.Charm(dummy.URL())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *GCSuite) TestCharmRefCounts(c *gc.C) {
+	dummy := s.AddTestingCharm(c, "dummy")
+	service := s.AddTestingService(c, "dummy", dummy)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(dummy.URL())
+	c.Assert(err, gc.IsNil)
+	upgraded := s.AddConfigCharm(c, "dummy", "options: {}", 2)
+	err = service.SetCharm(upgraded, false)
+	c.Assert(err, gc.IsNil)
+	assertRefCount(c, s.State, dummy, 1)
+	assertRefCount(c, s.State, upgraded, 1)
+
+	err = unit.SetCharmURL(upgraded.URL())
+	c.Assert(err, gc.IsNil)
+	assertRefCount(c, s.State, dummy, 0)
+	assertRefCount(c, s.State, upgraded, 1)
+
+	err = unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = service.Destroy()
+	c.Assert(err, gc.IsNil)
+	assertRefCount(c, s.State, upgraded, 0)
+}
+
+func assertRefCount(c *gc.C, st *state.State, ch *state.Charm, expect int) {
+	refCount, err := state.CharmRefCount(st, ch.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(refCount, gc.Equals, expect)
+}
+
+func (s *GCSuite) addTools(c *gc.C, v version.Binary) {
+	storage, err := s.State.ToolsStorage()
+	c.Assert(err, gc.IsNil)
+	defer storage.Close()
+	err = storage.AddTools(strings.NewReader("tools"), toolstorage.Metadata{Version: v, Size: 5})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *GCSuite) TestUnusedTools(c *gc.C) {
+	current := version.Current
+	older := current
+	older.Minor--
+	oldest := older
+	oldest.Minor--
+	newer := current
+	newer.Minor++
+	for _, v := range []version.Binary{oldest, older, current, newer} {
+		s.addTools(c, v)
+	}
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m.SetAgentVersion(older)
+	c.Assert(err, gc.IsNil)
+
+	unused, err := s.State.UnusedTools(time.Now().Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 1)
+	c.Assert(unused[0].Version, gc.Equals, oldest)
+
+	// Tools added within the grace period are kept.
+	unused, err = s.State.UnusedTools(time.Now().Add(-time.Minute))
+	c.Assert(err, gc.IsNil)
+	c.Assert(unused, gc.HasLen, 0)
+}
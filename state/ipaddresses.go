@@ -0,0 +1,165 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+// IPAddress represents an address allocated by the provider for a
+// container, on the network of the container's host instance. The
+// address is recorded in state until it is released, so that it can
+// be given back to the provider even if the container goes away.
+type IPAddress struct {
+	st  *State
+	doc ipaddressDoc
+}
+
+type ipaddressDoc struct {
+	Value          string `bson:"_id"`
+	Type           network.AddressType
+	NetworkName    string
+	Scope          network.Scope
+	MachineId      string
+	HostInstanceId instance.Id
+	NetworkId      network.Id
+}
+
+// GoString implements fmt.GoStringer.
+func (a *IPAddress) GoString() string {
+	return fmt.Sprintf(
+		"&state.IPAddress{value: %q, machineId: %q, hostInstanceId: %q, networkId: %q}",
+		a.doc.Value, a.doc.MachineId, a.doc.HostInstanceId, a.doc.NetworkId)
+}
+
+// Value returns the IP address.
+func (a *IPAddress) Value() string {
+	return a.doc.Value
+}
+
+// Address returns the IP address as a network.Address.
+func (a *IPAddress) Address() network.Address {
+	return network.Address{
+		Value:       a.doc.Value,
+		Type:        a.doc.Type,
+		NetworkName: a.doc.NetworkName,
+		Scope:       a.doc.Scope,
+	}
+}
+
+// MachineId returns the id of the machine (a container) the address
+// is allocated to.
+func (a *IPAddress) MachineId() string {
+	return a.doc.MachineId
+}
+
+// HostInstanceId returns the id of the instance the provider
+// allocated the address on.
+func (a *IPAddress) HostInstanceId() instance.Id {
+	return a.doc.HostInstanceId
+}
+
+// NetworkId returns the provider-specific id of the network the
+// address was allocated on.
+func (a *IPAddress) NetworkId() network.Id {
+	return a.doc.NetworkId
+}
+
+// Remove removes the address record. It should be called once the
+// address has been released to the provider.
+func (a *IPAddress) Remove() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot remove IP address %q", a.doc.Value)
+	ops := []txn.Op{{
+		C:      ipaddressesC,
+		Id:     a.doc.Value,
+		Remove: true,
+	}}
+	return a.st.runTransaction(ops)
+}
+
+// AddIPAddress records that the given address was allocated to the
+// machine with the given id, on the provider network netId of the
+// instance hostInstId. If the address is already recorded, an error
+// satisfying errors.IsAlreadyExists is returned.
+func (st *State) AddIPAddress(addr network.Address, machineId string, hostInstId instance.Id, netId network.Id) (ipaddr *IPAddress, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add IP address %q", addr.Value)
+	if addr.Value == "" {
+		return nil, errors.Errorf("empty address")
+	}
+	doc := &ipaddressDoc{
+		Value:          addr.Value,
+		Type:           addr.Type,
+		NetworkName:    addr.NetworkName,
+		Scope:          addr.Scope,
+		MachineId:      machineId,
+		HostInstanceId: hostInstId,
+		NetworkId:      netId,
+	}
+	ops := []txn.Op{{
+		C:      ipaddressesC,
+		Id:     addr.Value,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return nil, errors.AlreadyExistsf("IP address %q", addr.Value)
+	} else if err != nil {
+		return nil, err
+	}
+	return &IPAddress{st, *doc}, nil
+}
+
+// IPAddress returns the recorded address with the given value.
+func (st *State) IPAddress(value string) (*IPAddress, error) {
+	ipaddresses, closer := st.getCollection(ipaddressesC)
+	defer closer()
+
+	doc := &ipaddressDoc{}
+	err := ipaddresses.FindId(value).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("IP address %q", value)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get IP address %q", value)
+	}
+	return &IPAddress{st, *doc}, nil
+}
+
+// AllocatedIPAddresses returns the addresses recorded as allocated to
+// the machine with the given id. The machine need not exist, so that
+// the addresses of a removed container can still be released.
+func (st *State) AllocatedIPAddresses(machineId string) (_ []*IPAddress, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot get IP addresses of machine %q", machineId)
+	return st.ipAddresses(bson.D{{"machineid", machineId}})
+}
+
+// HostIPAddresses returns the addresses recorded as allocated on the
+// host instance with the given id, to any of the host's containers.
+func (st *State) HostIPAddresses(hostInstId instance.Id) (_ []*IPAddress, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot get IP addresses on instance %q", hostInstId)
+	return st.ipAddresses(bson.D{{"hostinstanceid", hostInstId}})
+}
+
+func (st *State) ipAddresses(sel bson.D) ([]*IPAddress, error) {
+	ipaddresses, closer := st.getCollection(ipaddressesC)
+	defer closer()
+
+	docs := []ipaddressDoc{}
+	if err := ipaddresses.Find(sel).Sort("_id").All(&docs); err != nil {
+		return nil, err
+	}
+	addresses := make([]*IPAddress, len(docs))
+	for i, doc := range docs {
+		addresses[i] = &IPAddress{st, doc}
+	}
+	return addresses, nil
+}
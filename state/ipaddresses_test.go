@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+)
+
+type IPAddressSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&IPAddressSuite{})
+
+func (s *IPAddressSuite) TestAddIPAddress(c *gc.C) {
+	addr := network.NewAddress("10.0.0.5", network.ScopeCloudLocal)
+	ipaddr, err := s.State.AddIPAddress(addr, "0/lxc/0", "i-host", "net-1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddr.Value(), gc.Equals, "10.0.0.5")
+	c.Assert(ipaddr.Address(), jc.DeepEquals, addr)
+	c.Assert(ipaddr.MachineId(), gc.Equals, "0/lxc/0")
+	c.Assert(ipaddr.HostInstanceId(), gc.Equals, instance.Id("i-host"))
+	c.Assert(ipaddr.NetworkId(), gc.Equals, network.Id("net-1"))
+
+	ipaddr, err = s.State.IPAddress("10.0.0.5")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddr.Address(), jc.DeepEquals, addr)
+
+	_, err = s.State.AddIPAddress(addr, "0/lxc/1", "i-host", "net-1")
+	c.Assert(err, gc.ErrorMatches, `cannot add IP address "10.0.0.5": IP address "10.0.0.5" already exists`)
+	c.Assert(errors.Cause(err), jc.Satisfies, errors.IsAlreadyExists)
+
+	_, err = s.State.AddIPAddress(network.Address{}, "0/lxc/1", "i-host", "net-1")
+	c.Assert(err, gc.ErrorMatches, `cannot add IP address "": empty address`)
+}
+
+func (s *IPAddressSuite) TestAllocatedIPAddressesAndRemove(c *gc.C) {
+	for _, value := range []string{"10.0.0.7", "10.0.0.6"} {
+		addr := network.NewAddress(value, network.ScopeCloudLocal)
+		_, err := s.State.AddIPAddress(addr, "0/lxc/0", "i-host", "net-1")
+		c.Assert(err, gc.IsNil)
+	}
+	addr := network.NewAddress("10.0.0.8", network.ScopeCloudLocal)
+	_, err := s.State.AddIPAddress(addr, "0/lxc/1", "i-host", "net-1")
+	c.Assert(err, gc.IsNil)
+
+	ipaddrs, err := s.State.AllocatedIPAddresses("0/lxc/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 2)
+	c.Assert(ipaddrs[0].Value(), gc.Equals, "10.0.0.6")
+	c.Assert(ipaddrs[1].Value(), gc.Equals, "10.0.0.7")
+
+	err = ipaddrs[0].Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.IPAddress("10.0.0.6")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	ipaddrs, err = s.State.AllocatedIPAddresses("0/lxc/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 1)
+
+	ipaddrs, err = s.State.AllocatedIPAddresses("42")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 0)
+}
+
+func (s *IPAddressSuite) TestHostIPAddresses(c *gc.C) {
+	for _, a := range []struct {
+		value, machineId string
+		host             instance.Id
+	}{
+		{"10.0.0.7", "0/lxc/0", "i-host"},
+		{"10.0.0.6", "0/lxc/1", "i-host"},
+		{"10.0.0.8", "1/lxc/0", "i-other"},
+	} {
+		addr := network.NewAddress(a.value, network.ScopeCloudLocal)
+		_, err := s.State.AddIPAddress(addr, a.machineId, a.host, "net-1")
+		c.Assert(err, gc.IsNil)
+	}
+
+	ipaddrs, err := s.State.HostIPAddresses("i-host")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 2)
+	c.Assert(ipaddrs[0].Value(), gc.Equals, "10.0.0.6")
+	c.Assert(ipaddrs[0].MachineId(), gc.Equals, "0/lxc/1")
+	c.Assert(ipaddrs[1].Value(), gc.Equals, "10.0.0.7")
+
+	ipaddrs, err = s.State.HostIPAddresses("i-unknown")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ipaddrs, gc.HasLen, 0)
+}
@@ -108,6 +108,9 @@ type machineDoc struct {
 	HasVote       bool
 	PasswordHash  string
 	Clean         bool
+	// RemovingStateServer is set when the machine's state server
+	// job is to be removed once it no longer has a vote.
+	RemovingStateServer bool
 	// We store 2 different sets of addresses for the machine, obtained
 	// from different sources.
 	// Addresses is the set of addresses obtained by asking the provider.
@@ -177,16 +180,19 @@ type instanceData struct {
 	CpuCores   *uint64     `bson:"cpucores,omitempty"`
 	CpuPower   *uint64     `bson:"cpupower,omitempty"`
 	Tags       *[]string   `bson:"tags,omitempty"`
+
+	AvailabilityZone *string `bson:"availabilityzone,omitempty"`
 }
 
 func hardwareCharacteristics(instData instanceData) *instance.HardwareCharacteristics {
 	return &instance.HardwareCharacteristics{
-		Arch:     instData.Arch,
-		Mem:      instData.Mem,
-		RootDisk: instData.RootDisk,
-		CpuCores: instData.CpuCores,
-		CpuPower: instData.CpuPower,
-		Tags:     instData.Tags,
+		Arch:             instData.Arch,
+		Mem:              instData.Mem,
+		RootDisk:         instData.RootDisk,
+		CpuCores:         instData.CpuCores,
+		CpuPower:         instData.CpuPower,
+		Tags:             instData.Tags,
+		AvailabilityZone: instData.AvailabilityZone,
 	}
 }
 
@@ -244,6 +250,12 @@ func (m *Machine) HasVote() bool {
 	return m.doc.HasVote
 }
 
+// IsRemovingStateServer reports whether the machine's state server
+// is being removed by RemoveStateServer.
+func (m *Machine) IsRemovingStateServer() bool {
+	return m.doc.RemovingStateServer
+}
+
 // SetHasVote sets whether the machine is currently a voting
 // member of the replica set. It should only be called
 // from the worker that maintains the replica set.
@@ -602,10 +614,21 @@ func (m *Machine) removeNetworkInterfacesOps() ([]txn.Op, error) {
 		Id:     m.doc.Id,
 		Assert: isDeadDoc,
 	}}
-	sel := bson.D{{"machineid", m.doc.Id}}
-	networkInterfaces, closer := m.st.getCollection(networkInterfacesC)
+	ifacesOps, err := removeMachineNetworkInterfacesOps(m.st, m.doc.Id)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, ifacesOps...), nil
+}
+
+// removeMachineNetworkInterfacesOps returns the operations needed
+// to remove the network interfaces of the machine with the given id.
+func removeMachineNetworkInterfacesOps(st *State, machineId string) ([]txn.Op, error) {
+	sel := bson.D{{"machineid", machineId}}
+	networkInterfaces, closer := st.getCollection(networkInterfacesC)
 	defer closer()
 
+	var ops []txn.Op
 	iter := networkInterfaces.Find(sel).Select(bson.D{{"_id", 1}}).Iter()
 	var doc networkInterfaceDoc
 	for iter.Next(&doc) {
@@ -839,14 +862,15 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 		characteristics = &instance.HardwareCharacteristics{}
 	}
 	instData := &instanceData{
-		Id:         m.doc.Id,
-		InstanceId: id,
-		Arch:       characteristics.Arch,
-		Mem:        characteristics.Mem,
-		RootDisk:   characteristics.RootDisk,
-		CpuCores:   characteristics.CpuCores,
-		CpuPower:   characteristics.CpuPower,
-		Tags:       characteristics.Tags,
+		Id:               m.doc.Id,
+		InstanceId:       id,
+		Arch:             characteristics.Arch,
+		Mem:              characteristics.Mem,
+		RootDisk:         characteristics.RootDisk,
+		CpuCores:         characteristics.CpuCores,
+		CpuPower:         characteristics.CpuPower,
+		Tags:             characteristics.Tags,
+		AvailabilityZone: characteristics.AvailabilityZone,
 	}
 	// SCHEMACHANGE
 	// TODO(wallyworld) - do not check instanceId on machineDoc after schema is upgraded
@@ -141,6 +141,8 @@ var indexes = []struct {
 	{networkInterfacesC, []string{"macaddress", "networkname"}, true},
 	{networkInterfacesC, []string{"networkname"}, false},
 	{networkInterfacesC, []string{"machineid"}, false},
+	{runTasksC, []string{"jobid"}, false},
+	{autoCharmUpgradesC, []string{"servicename"}, false},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -104,6 +104,20 @@ func (st *State) precheckInstance(series string, cons constraints.Value, placeme
 }
 
 func (st *State) constraintsValidator() (constraints.Validator, error) {
+	validator, err := st.policyConstraintsValidator()
+	if err != nil {
+		return nil, err
+	}
+	// Spaces are defined in state rather than by the provider.
+	spaceNames, err := st.spaceNames()
+	if err != nil {
+		return nil, err
+	}
+	validator.RegisterVocabulary(constraints.Spaces, spaceNames)
+	return validator, nil
+}
+
+func (st *State) policyConstraintsValidator() (constraints.Validator, error) {
 	// Default behaviour is to simply use a standard validator with
 	// no environment specific behaviour built in.
 	defaultValidator := constraints.NewValidator()
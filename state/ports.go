@@ -58,34 +58,25 @@ func NewPortRange(unitName string, fromPort, toPort int, protocol string) (PortR
 
 // IsValid checks if the port range is valid.
 func (p PortRange) Validate() error {
-	proto := strings.ToLower(p.Protocol)
-	if proto != "tcp" && proto != "udp" {
-		return errors.Errorf("invalid protocol %q", proto)
-	}
 	if !names.IsValidUnit(p.UnitName) {
 		return errors.Errorf("invalid unit %q", p.UnitName)
 	}
-	if p.FromPort > p.ToPort {
-		return errors.Errorf("invalid port range %d-%d", p.FromPort, p.ToPort)
+	return p.NetworkPortRange().Validate()
+}
+
+// NetworkPortRange returns the ports in the range, without
+// the unit that opened them.
+func (p PortRange) NetworkPortRange() network.PortRange {
+	return network.PortRange{
+		FromPort: p.FromPort,
+		ToPort:   p.ToPort,
+		Protocol: p.Protocol,
 	}
-	return nil
 }
 
 // ConflictsWith determines if the two port ranges conflict.
 func (a PortRange) ConflictsWith(b PortRange) bool {
-	if a.Protocol != b.Protocol {
-		return false
-	}
-	switch {
-	case a.FromPort >= b.FromPort && a.FromPort <= b.ToPort:
-		return true
-	case a.ToPort >= b.FromPort && a.ToPort <= b.ToPort:
-		return true
-	case a.FromPort <= b.FromPort && a.ToPort >= b.ToPort:
-		return true
-	}
-
-	return false
+	return a.NetworkPortRange().ConflictsWith(b.NetworkPortRange())
 }
 
 func (p PortRange) String() string {
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package presence_test
+
+import (
+	"strconv"
+
+	"gopkg.in/mgo.v2"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/presence"
+)
+
+// benchEntities is the number of entities the benchmarks
+// are run against.
+const benchEntities = 10000
+
+type BenchmarkSuite struct {
+}
+
+var _ = gc.Suite(&BenchmarkSuite{})
+
+// setUpBenchmark sets up a PresenceSuite and starts a pinger for
+// each of benchEntities keys. The returned function stops the
+// pingers and tears the suite down. The suite is set up by hand
+// because gocheck does not call fixture methods for benchmarks.
+func setUpBenchmark(c *gc.C) (base *mgo.Collection, keys []string, tearDown func()) {
+	var s PresenceSuite
+	s.SetUpSuite(c)
+	s.SetUpTest(c)
+	var pingers []*presence.Pinger
+	for i := 0; i < benchEntities; i++ {
+		key := strconv.Itoa(i)
+		p := presence.NewPinger(s.presence, key)
+		c.Assert(p.Start(), gc.IsNil)
+		pingers = append(pingers, p)
+		keys = append(keys, key)
+	}
+	return s.presence, keys, func() {
+		for _, p := range pingers {
+			p.Stop()
+		}
+		s.TearDownTest(c)
+		s.TearDownSuite(c)
+	}
+}
+
+func (*BenchmarkSuite) BenchmarkSync(c *gc.C) {
+	base, _, tearDown := setUpBenchmark(c)
+	defer tearDown()
+	w := presence.NewWatcher(base)
+	defer w.Stop()
+	w.Sync()
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		w.Sync()
+	}
+}
+
+func (*BenchmarkSuite) BenchmarkWatchAll(c *gc.C) {
+	base, keys, tearDown := setUpBenchmark(c)
+	defer tearDown()
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		w := presence.NewWatcher(base)
+		w.Sync()
+		ch := make(chan presence.Change, len(keys))
+		for _, key := range keys {
+			w.Watch(key, ch)
+		}
+		for _ = range keys {
+			change := <-ch
+			c.Assert(change.Alive, gc.Equals, true)
+		}
+		for _, key := range keys {
+			w.Unwatch(key, ch)
+		}
+		c.Assert(w.Stop(), gc.IsNil)
+	}
+}
+
+func (*BenchmarkSuite) BenchmarkAlive(c *gc.C) {
+	base, keys, tearDown := setUpBenchmark(c)
+	defer tearDown()
+	w := presence.NewWatcher(base)
+	defer w.Stop()
+	w.Sync()
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		alive, err := w.Alive(keys[i%len(keys)])
+		c.Assert(err, gc.IsNil)
+		c.Assert(alive, gc.Equals, true)
+	}
+}
@@ -9,6 +9,7 @@ package presence
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -67,10 +68,18 @@ type Watcher struct {
 	delta time.Duration
 
 	// beingKey and beingSeq are the pinger seq <=> key mappings.
-	// Entries in these maps are considered alive.
+	// Entries in these maps are considered alive. They are only
+	// modified by the watcher goroutine, with mu held, so that
+	// Alive can consult beingSeq without a round trip through
+	// the loop.
+	mu       sync.RWMutex
 	beingKey map[int64]string
 	beingSeq map[string]int64
 
+	// synced records whether the watcher has completed a sync.
+	// Until it has, all known beings are loaded in one query.
+	synced bool
+
 	// watches has the per-key observer channels from Watch/Unwatch.
 	watches map[string][]chan<- Change
 
@@ -163,11 +172,6 @@ type reqSync struct {
 	done chan bool
 }
 
-type reqAlive struct {
-	key    string
-	result chan bool
-}
-
 func (w *Watcher) sendReq(req interface{}) {
 	select {
 	case w.request <- req:
@@ -206,16 +210,18 @@ func (w *Watcher) Sync() {
 }
 
 // Alive returns whether the key is currently considered alive by w,
-// or an error in case the watcher is dying.
+// or an error in case the watcher is dying. The answer comes from
+// what w learned in its last sync, so it is cheap to call and never
+// waits for the watcher goroutine.
 func (w *Watcher) Alive(key string) (bool, error) {
-	result := make(chan bool, 1)
-	w.sendReq(reqAlive{key, result})
-	var alive bool
 	select {
-	case alive = <-result:
 	case <-w.tomb.Dying():
 		return false, errors.Errorf("cannot check liveness: watcher is dying")
+	default:
 	}
+	w.mu.RLock()
+	_, alive := w.beingSeq[key]
+	w.mu.RUnlock()
 	return alive, nil
 }
 
@@ -225,6 +231,11 @@ func (w *Watcher) Alive(key string) (bool, error) {
 // identifier is an int64 in seconds.
 var period int64 = 30
 
+// maxBatch is the maximum number of requests handled before
+// pending events are flushed, and the maximum number of beings
+// looked up in a single query.
+const maxBatch = 1000
+
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
 	var err error
@@ -249,11 +260,27 @@ func (w *Watcher) loop() error {
 			}
 		case req := <-w.request:
 			w.handle(req)
+			w.handleQueued()
 			w.flush()
 		}
 	}
 }
 
+// handleQueued handles any further requests that are already
+// waiting, so that a burst of Watch and Unwatch calls, as made when
+// thousands of entities are watched at once, results in a single
+// flush of the events queued for all of them.
+func (w *Watcher) handleQueued() {
+	for i := 0; i < maxBatch; i++ {
+		select {
+		case req := <-w.request:
+			w.handle(req)
+		default:
+			return
+		}
+	}
+}
+
 // flush sends all pending events to their respective channels.
 func (w *Watcher) flush() {
 	// w.pending may get new requests as we handle other requests.
@@ -308,9 +335,6 @@ func (w *Watcher) handle(req interface{}) {
 				e.ch = nil
 			}
 		}
-	case reqAlive:
-		_, alive := w.beingSeq[r.key]
-		r.result <- alive
 	default:
 		panic(fmt.Errorf("unknown request: %T", req))
 	}
@@ -347,16 +371,11 @@ func (w *Watcher) findAllBeings() (map[int64]beingInfo, error) {
 // sync updates the watcher knowledge from the database, and
 // queues events to observing channels. It fetches the last two time
 // slots and compares the union of both to the in-memory state.
+//
+// Only the sequences not already known to be alive are resolved to
+// keys, and those are looked up together, so the cost of a sync
+// does not grow with the number of beings ever seen.
 func (w *Watcher) sync() error {
-	var allBeings map[int64]beingInfo
-	if len(w.beingKey) == 0 {
-		// The very first time we sync, we grab all ever-known beings,
-		// so we don't have to look them up one-by-one
-		var err error
-		if allBeings, err = w.findAllBeings(); err != nil {
-			return errors.Trace(err)
-		}
-	}
 	slot := timeSlot(time.Now(), w.delta)
 	session := w.pings.Database.Session.Copy()
 	defer session.Close()
@@ -367,83 +386,52 @@ func (w *Watcher) sync() error {
 		return errors.Trace(err)
 	}
 
-	// Learn about all enforced deaths.
+	// Learn about all enforced deaths, and all the pingers
+	// that reported.
 	dead := make(map[int64]bool)
+	alive := make(map[int64]bool)
 	for i := range ping {
-		for key, value := range ping[i].Dead {
-			k, err := strconv.ParseInt(key, 16, 64)
-			if err != nil {
-				err = errors.Annotatef(err, "presence cannot parse dead key: %q", key)
-				panic(err)
-			}
-			k *= 63
-			for i := int64(0); i < 63 && value > 0; i++ {
-				on := value&1 == 1
-				value >>= 1
-				if !on {
-					continue
-				}
-				seq := k + i
-				dead[seq] = true
-				logger.Tracef("found seq=%d dead", seq)
-			}
+		decodeSeqs(ping[i].Dead, "dead", dead)
+		decodeSeqs(ping[i].Alive, "alive", alive)
+	}
+	var unknown []int64
+	for seq := range alive {
+		if _, ok := w.beingKey[seq]; !ok {
+			unknown = append(unknown, seq)
 		}
 	}
+	sort.Sort(int64Slice(unknown))
+	beings, err := w.lookupBeings(session, unknown)
+	if err != nil {
+		return errors.Trace(err)
+	}
 
-	// Learn about all the pingers that reported and queue
-	// events for those that weren't known to be alive and
-	// are not reportedly dead either.
-	beingsC := w.beings.With(session)
-	alive := make(map[int64]bool)
-	being := beingInfo{}
-	for i := range ping {
-		for key, value := range ping[i].Alive {
-			k, err := strconv.ParseInt(key, 16, 64)
-			if err != nil {
-				err = errors.Annotatef(err, "presence cannot parse alive key: %q", key)
-				panic(err)
-			}
-			k *= 63
-			for i := int64(0); i < 63 && value > 0; i++ {
-				on := value&1 == 1
-				value >>= 1
-				if !on {
-					continue
-				}
-				seq := k + i
-				alive[seq] = true
-				if _, ok := w.beingKey[seq]; ok {
-					continue
-				}
-				// Check if the being exists in the 'all' map,
-				// otherwise do a single lookup in mongo
-				var ok bool
-				if being, ok = allBeings[seq]; !ok {
-					err := beingsC.Find(bson.D{{"_id", seq}}).One(&being)
-					if err == mgo.ErrNotFound {
-						logger.Tracef("found seq=%d unowned", seq)
-						continue
-					} else if err != nil {
-						return errors.Trace(err)
-					}
-				}
-				cur := w.beingSeq[being.Key]
-				if cur < seq {
-					delete(w.beingKey, cur)
-				} else {
-					// Current sequence is more recent.
-					continue
-				}
-				w.beingKey[seq] = being.Key
-				w.beingSeq[being.Key] = seq
-				if cur > 0 || dead[seq] {
-					continue
-				}
-				logger.Tracef("found seq=%d alive with key %q", seq, being.Key)
-				for _, ch := range w.watches[being.Key] {
-					w.pending = append(w.pending, event{ch, being.Key, true})
-				}
-			}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Queue events for the pingers that weren't known to be
+	// alive and are not reportedly dead either.
+	for _, seq := range unknown {
+		being, ok := beings[seq]
+		if !ok {
+			logger.Tracef("found seq=%d unowned", seq)
+			continue
+		}
+		cur := w.beingSeq[being.Key]
+		if cur < seq {
+			delete(w.beingKey, cur)
+		} else {
+			// Current sequence is more recent.
+			continue
+		}
+		w.beingKey[seq] = being.Key
+		w.beingSeq[being.Key] = seq
+		if cur > 0 || dead[seq] {
+			continue
+		}
+		logger.Tracef("found seq=%d alive with key %q", seq, being.Key)
+		for _, ch := range w.watches[being.Key] {
+			w.pending = append(w.pending, event{ch, being.Key, true})
 		}
 	}
 
@@ -459,9 +447,69 @@ func (w *Watcher) sync() error {
 			}
 		}
 	}
+	w.synced = true
 	return nil
 }
 
+// decodeSeqs adds to found the pinger sequences recorded in
+// the given alive or dead field of a time slot document.
+func decodeSeqs(field map[string]int64, kind string, found map[int64]bool) {
+	for key, value := range field {
+		k, err := strconv.ParseInt(key, 16, 64)
+		if err != nil {
+			err = errors.Annotatef(err, "presence cannot parse %s key: %q", kind, key)
+			panic(err)
+		}
+		k *= 63
+		for i := int64(0); i < 63 && value > 0; i++ {
+			on := value&1 == 1
+			value >>= 1
+			if !on {
+				continue
+			}
+			seq := k + i
+			found[seq] = true
+			logger.Tracef("found seq=%d %s", seq, kind)
+		}
+	}
+}
+
+// lookupBeings returns the beings with the given sequences. The
+// first time the watcher syncs, all ever-known beings are fetched
+// at once; after that, the sequences are looked up in batches.
+func (w *Watcher) lookupBeings(session *mgo.Session, seqs []int64) (map[int64]beingInfo, error) {
+	if len(seqs) == 0 {
+		return nil, nil
+	}
+	if !w.synced {
+		return w.findAllBeings()
+	}
+	beingsC := w.beings.With(session)
+	beings := make(map[int64]beingInfo, len(seqs))
+	for len(seqs) > 0 {
+		batch := seqs
+		if len(batch) > maxBatch {
+			batch = batch[:maxBatch]
+		}
+		seqs = seqs[len(batch):]
+		iter := beingsC.Find(bson.D{{"_id", bson.D{{"$in", batch}}}}).Iter()
+		var being beingInfo
+		for iter.Next(&being) {
+			beings[being.Seq] = being
+		}
+		if err := iter.Close(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return beings, nil
+}
+
+type int64Slice []int64
+
+func (s int64Slice) Len() int           { return len(s) }
+func (s int64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s int64Slice) Less(i, j int) bool { return s[i] < s[j] }
+
 // Pinger periodically reports that a specific key is alive, so that
 // watchers interested on that fact can react appropriately.
 type Pinger struct {
@@ -227,6 +227,37 @@ func (s *PresenceSuite) TestScale(c *gc.C) {
 	}
 }
 
+func (s *PresenceSuite) TestScaleAfterSync(c *gc.C) {
+	const N = 1500
+	w := presence.NewWatcher(s.presence)
+	defer w.Stop()
+	w.Sync()
+
+	var ps []*presence.Pinger
+	defer func() {
+		for _, p := range ps {
+			p.Stop()
+		}
+	}()
+	c.Logf("Starting %d pingers...", N)
+	for i := 0; i < N; i++ {
+		p := presence.NewPinger(s.presence, strconv.Itoa(i))
+		c.Assert(p.Start(), gc.IsNil)
+		ps = append(ps, p)
+	}
+
+	c.Logf("Checking everyone is alive...")
+	w.Sync()
+	for i := 0; i < N; i++ {
+		alive, err := w.Alive(strconv.Itoa(i))
+		c.Assert(err, gc.IsNil)
+		c.Assert(alive, gc.Equals, true)
+	}
+	alive, err := w.Alive("unknown")
+	c.Assert(err, gc.IsNil)
+	c.Assert(alive, gc.Equals, false)
+}
+
 func (s *PresenceSuite) TestExpiry(c *gc.C) {
 	w := presence.NewWatcher(s.presence)
 	p := presence.NewPinger(s.presence, "a")
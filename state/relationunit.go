@@ -39,8 +39,10 @@ func (ru *RelationUnit) Endpoint() Endpoint {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// If the relation's endpoint is bound to a space, the address is chosen from
+// those in that space.
 func (ru *RelationUnit) PrivateAddress() (string, bool) {
-	return ru.unit.PrivateAddress()
+	return ru.unit.privateAddressForEndpoint(ru.endpoint.Name)
 }
 
 // ErrCannotEnterScope indicates that a relation unit failed to enter its scope
@@ -0,0 +1,151 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// Reprovision prepares the machine to be provisioned again on a new
+// instance, because its instance has vanished or its agent has been
+// down for too long. The machine's instance id, nonce and addresses
+// are cleared, and its status is set to a transient error holding
+// the given reason, so that the provisioner starts a replacement
+// instance with the same constraints and placement. The units
+// assigned to the machine stay assigned, and are deployed again by
+// the machine's new agent. Containers hosted by the machine are reset
+// in the same way and become pending, so they are provisioned again
+// once the new instance is running.
+//
+// State servers, containers and manually provisioned machines cannot
+// be reprovisioned.
+func (m *Machine) Reprovision(reason string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot reprovision machine %v", m)
+	if reason == "" {
+		return errors.New("no reason given")
+	}
+	if m.IsManager() {
+		return errors.New("machine is a state server")
+	}
+	if _, ok := m.ParentId(); ok {
+		return errors.New("machine is a container")
+	}
+	if manual, err := m.IsManual(); err != nil {
+		return err
+	} else if manual {
+		return errors.New("machine was manually provisioned")
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		if _, err := m.InstanceId(); err != nil {
+			return nil, err
+		}
+		ops, err := resetInstanceOps(m.st, m.doc, isAliveDoc)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, updateStatusOp(m.st, m.globalKey(), statusDoc{
+			Status:     params.StatusError,
+			StatusInfo: reason,
+			StatusData: map[string]interface{}{"transient": true},
+		}))
+		containerOps, err := resetContainersOps(m.st, m.doc.Id)
+		if err != nil {
+			return nil, err
+		}
+		return append(ops, containerOps...), nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	m.doc.Nonce = ""
+	m.doc.InstanceId = ""
+	m.doc.Addresses = nil
+	m.doc.MachineAddresses = nil
+	return nil
+}
+
+// resetInstanceOps returns the operations needed to forget the
+// instance of the machine with the given document, so that it can be
+// provisioned again. The operations assert that the machine has not
+// been provisioned again in the meantime, as well as the given
+// assertions on the machine document.
+func resetInstanceOps(st *State, doc machineDoc, assert bson.D) ([]txn.Op, error) {
+	unchanged := bson.D{{"nonce", doc.Nonce}, {"instanceid", doc.InstanceId}}
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     doc.Id,
+		Assert: append(unchanged, assert...),
+		Update: bson.D{
+			{"$set", bson.D{{"nonce", ""}, {"instanceid", ""}}},
+			{"$unset", bson.D{{"addresses", nil}, {"machineaddresses", nil}}},
+		},
+	}, {
+		C:      instanceDataC,
+		Id:     doc.Id,
+		Remove: true,
+	}}
+	ifacesOps, err := removeMachineNetworkInterfacesOps(st, doc.Id)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, ifacesOps...), nil
+}
+
+// resetContainersOps returns the operations needed to forget the
+// instances of all the provisioned containers hosted, directly or
+// not, by the machine with the given id, and to mark those containers
+// as pending, so that they are provisioned again.
+func resetContainersOps(st *State, machineId string) ([]txn.Op, error) {
+	machines, closer := st.getCollection(machinesC)
+	defer closer()
+
+	var ops []txn.Op
+	var mc machineContainers
+	containerRefs, closer := st.getCollection(containerRefsC)
+	defer closer()
+	if err := containerRefs.FindId(machineId).One(&mc); err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+	for _, id := range mc.Children {
+		var doc machineDoc
+		if err := machines.FindId(id).One(&doc); err == mgo.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		container := newMachine(st, &doc)
+		if _, err := container.InstanceId(); IsNotProvisionedError(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		resetOps, err := resetInstanceOps(st, doc, nil)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, resetOps...)
+		ops = append(ops, updateStatusOp(st, container.globalKey(), statusDoc{
+			Status: params.StatusPending,
+		}))
+		childOps, err := resetContainersOps(st, id)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, childOps...)
+	}
+	return ops, nil
+}
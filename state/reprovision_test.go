@@ -0,0 +1,117 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type ReprovisionSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ReprovisionSuite{})
+
+func (s *ReprovisionSuite) TestReprovision(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned("i-old", "fake-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAddresses(network.NewAddress("10.0.0.1", network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	err = container.SetProvisioned("i-container", "container-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = container.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	unit, err := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress")).AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+
+	err = machine.Reprovision("instance vanished")
+	c.Assert(err, gc.IsNil)
+	for _, m := range []*state.Machine{machine, container} {
+		err = m.Refresh()
+		c.Assert(err, gc.IsNil)
+		_, err = m.InstanceId()
+		c.Assert(err, jc.Satisfies, state.IsNotProvisionedError)
+		c.Assert(m.CheckProvisioned("fake-nonce"), jc.IsFalse)
+		c.Assert(m.Addresses(), gc.HasLen, 0)
+	}
+	status, info, data, err := machine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusError)
+	c.Assert(info, gc.Equals, "instance vanished")
+	c.Assert(data, gc.DeepEquals, map[string]interface{}{"transient": true})
+	status, _, _, err = container.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusPending)
+
+	// The units stay assigned to the machine.
+	err = unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	id, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, machine.Id())
+
+	// The machine can be provisioned again.
+	err = machine.SetProvisioned("i-new", "new-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	instId, err := machine.InstanceId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instId, gc.Equals, instance.Id("i-new"))
+}
+
+func (s *ReprovisionSuite) TestReprovisionErrors(c *gc.C) {
+	manager, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = manager.SetProvisioned("i-manager", "fake-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = manager.Reprovision("instance vanished")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 0: machine is a state server")
+
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.Reprovision("instance vanished")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 1: machine 1 is not provisioned")
+	err = machine.SetProvisioned("i-old", "fake-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = machine.Reprovision("")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 1: no reason given")
+
+	manual, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = manual.SetProvisioned("manual:10.0.0.2", "manual:10.0.0.2", nil)
+	c.Assert(err, gc.IsNil)
+	err = manual.Reprovision("instance vanished")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 2: machine was manually provisioned")
+
+	container, err := s.State.AddMachineInsideMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}, machine.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	err = container.Reprovision("instance vanished")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 1/lxc/0: machine is a container")
+
+	dying, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = dying.SetProvisioned("i-dying", "fake-nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = dying.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = dying.Reprovision("instance vanished")
+	c.Assert(err, gc.ErrorMatches, "cannot reprovision machine 3: not found or not alive")
+}
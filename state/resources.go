@@ -0,0 +1,409 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/juju/blobstore"
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// resourcesDoc holds all the resources attached to a service, keyed
+// by the names declared by the service's charm. Keeping them in a
+// single document allows units to watch a service's resources with
+// one entity watcher.
+type resourcesDoc struct {
+	Service   string `bson:"_id"`
+	Resources map[string]resourceDoc
+}
+
+// resourceDoc holds the details of one revision of a resource.
+// The resource's content is held in the environment's blob store
+// under Path.
+type resourceDoc struct {
+	Revision int
+	Size     int64
+	SHA256   string
+	Path     string
+	Uploaded time.Time
+}
+
+// retiredResourceDoc records the content of a replaced revision of
+// a resource. The content is kept for a while, so that downloads of
+// the old revision that are in progress can finish. The records are
+// kept apart from the resources documents, so that removing them
+// does not look like a change to the service's resources.
+type retiredResourceDoc struct {
+	Path    string `bson:"_id"`
+	Service string
+	Name    string
+	Size    int64
+	Retired time.Time
+}
+
+// Resource represents the current revision of a binary blob
+// attached to a service.
+type Resource struct {
+	service string
+	name    string
+	doc     resourceDoc
+}
+
+// Service returns the name of the service the resource is attached to.
+func (r *Resource) Service() string {
+	return r.service
+}
+
+// Name returns the name of the resource, as declared by the
+// service's charm.
+func (r *Resource) Name() string {
+	return r.name
+}
+
+// Revision returns the resource's revision, which starts at 1 and is
+// incremented every time the resource is set.
+func (r *Resource) Revision() int {
+	return r.doc.Revision
+}
+
+// Size returns the size of the resource's content in bytes.
+func (r *Resource) Size() int64 {
+	return r.doc.Size
+}
+
+// SHA256 returns the hex-encoded SHA256 hash of the resource's content.
+func (r *Resource) SHA256() string {
+	return r.doc.SHA256
+}
+
+// Uploaded returns the time at which the resource's current
+// revision was uploaded.
+func (r *Resource) Uploaded() time.Time {
+	return r.doc.Uploaded
+}
+
+// resourceStorage returns the blob store holding resources, and a
+// function that must be called when the caller is finished with it.
+func (st *State) resourceStorage() (blobstore.ManagedStorage, string, func(), error) {
+	env, err := st.Environment()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	uuid := env.UUID()
+	session := st.db.Session.Copy()
+	return st.getManagedStorage(uuid, session), uuid, session.Close, nil
+}
+
+// resourcesDoc returns the document holding the resources attached to
+// the service, or nil if no resources have been attached yet.
+func (s *Service) resourcesDoc() (*resourcesDoc, error) {
+	resources, closer := s.st.getCollection(resourcesC)
+	defer closer()
+
+	var doc resourcesDoc
+	if err := resources.FindId(s.doc.Name).One(&doc); err == mgo.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &doc, nil
+}
+
+// resources returns the resources attached to the service, keyed by name.
+func (s *Service) resources() (map[string]resourceDoc, error) {
+	doc, err := s.resourcesDoc()
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return doc.Resources, nil
+}
+
+// Resource returns the named resource attached to the service.
+func (s *Service) Resource(name string) (*Resource, error) {
+	docs, err := s.resources()
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := docs[name]
+	if !ok {
+		return nil, errors.NotFoundf("resource %q for service %q", name, s.doc.Name)
+	}
+	return &Resource{service: s.doc.Name, name: name, doc: doc}, nil
+}
+
+// Resources returns all the resources attached to the service,
+// sorted by name.
+func (s *Service) Resources() ([]*Resource, error) {
+	docs, err := s.resources()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*Resource, len(names))
+	for i, name := range names {
+		result[i] = &Resource{service: s.doc.Name, name: name, doc: docs[name]}
+	}
+	return result, nil
+}
+
+// SetResource stores the size bytes read from r as a new revision
+// of the named resource, which must be declared by the service's
+// charm. The hex-encoded SHA256 hash of the content must be given.
+// The content of any previous revision is kept until it is removed
+// by RemoveRetiredResource, so that downloads in progress can finish.
+func (s *Service) SetResource(name string, r io.Reader, size int64, sha256 string) (_ *Resource, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set resource %q for service %q", name, s.doc.Name)
+	ch, _, err := s.Charm()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ch.Resources()[name]; !ok {
+		return nil, errors.NotFoundf("resource %q in charm %q", name, ch)
+	}
+	storage, uuid, closer, err := s.st.resourceStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+	id, err := utils.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf("resources/%s/%s/%s", s.doc.Name, name, id)
+	if err := storage.PutForEnvironment(uuid, path, r, size); err != nil {
+		return nil, errors.Annotate(err, "cannot store resource")
+	}
+
+	doc := resourceDoc{
+		Size:     size,
+		SHA256:   sha256,
+		Path:     path,
+		Uploaded: time.Now(),
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := s.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if s.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		existing, err := s.resourcesDoc()
+		if err != nil {
+			return nil, err
+		}
+		ops := []txn.Op{{
+			C:      servicesC,
+			Id:     s.doc.Name,
+			Assert: isAliveDoc,
+			Update: bson.D{{"$set", bson.D{{"hasresources", true}}}},
+		}}
+		field := "resources." + name
+		var current resourceDoc
+		var ok bool
+		if existing != nil {
+			current, ok = existing.Resources[name]
+		}
+		switch {
+		case existing == nil:
+			doc.Revision = 1
+			ops = append(ops, txn.Op{
+				C:      resourcesC,
+				Id:     s.doc.Name,
+				Assert: txn.DocMissing,
+				Insert: &resourcesDoc{
+					Service:   s.doc.Name,
+					Resources: map[string]resourceDoc{name: doc},
+				},
+			})
+		case !ok:
+			doc.Revision = 1
+			ops = append(ops, txn.Op{
+				C:      resourcesC,
+				Id:     s.doc.Name,
+				Assert: bson.D{{field, bson.D{{"$exists", false}}}},
+				Update: bson.D{{"$set", bson.D{{field, doc}}}},
+			})
+		default:
+			doc.Revision = current.Revision + 1
+			ops = append(ops, txn.Op{
+				C:      resourcesC,
+				Id:     s.doc.Name,
+				Assert: bson.D{{field + ".revision", current.Revision}},
+				Update: bson.D{{"$set", bson.D{{field, doc}}}},
+			}, txn.Op{
+				C:      retiredResourcesC,
+				Id:     current.Path,
+				Assert: txn.DocMissing,
+				Insert: &retiredResourceDoc{
+					Path:    current.Path,
+					Service: s.doc.Name,
+					Name:    name,
+					Size:    current.Size,
+					Retired: doc.Uploaded,
+				},
+			})
+		}
+		return ops, nil
+	}
+	if err := s.st.run(buildTxn); err != nil {
+		if err := storage.RemoveForEnvironment(uuid, path); err != nil {
+			logger.Warningf("cannot remove unused resource %q: %v", path, err)
+		}
+		return nil, err
+	}
+	return &Resource{service: s.doc.Name, name: name, doc: doc}, nil
+}
+
+// OpenResource returns the named resource attached to the service,
+// and a reader for its content that must be closed after use.
+func (s *Service) OpenResource(name string) (*Resource, io.ReadCloser, error) {
+	res, err := s.Resource(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	storage, uuid, closer, err := s.st.resourceStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	r, _, err := storage.GetForEnvironment(uuid, res.doc.Path)
+	if err != nil {
+		closer()
+		return nil, nil, errors.Annotatef(err, "cannot read resource %q for service %q", name, s.doc.Name)
+	}
+	return res, &resourceReader{r, closer}, nil
+}
+
+// resourceReader closes the blob store session used to read
+// a resource's content when the reader is closed.
+type resourceReader struct {
+	io.ReadCloser
+	closeSession func()
+}
+
+func (r *resourceReader) Close() error {
+	defer r.closeSession()
+	return r.ReadCloser.Close()
+}
+
+// RetiredResource describes the content of a replaced revision of
+// a resource.
+type RetiredResource struct {
+	// Service and Name identify the resource.
+	Service string
+	Name    string
+
+	// Size is the size of the content in bytes.
+	Size int64
+
+	// Retired holds the time at which the revision was replaced.
+	Retired time.Time
+
+	path string
+}
+
+// RetiredResources returns the content of the resource revisions
+// that were replaced before the given time.
+func (st *State) RetiredResources(retiredBefore time.Time) ([]RetiredResource, error) {
+	retired, closer := st.getCollection(retiredResourcesC)
+	defer closer()
+
+	var docs []retiredResourceDoc
+	sel := bson.D{{"retired", bson.D{{"$lt", retiredBefore}}}}
+	if err := retired.Find(sel).Sort("service", "name", "retired").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get retired resources")
+	}
+	result := make([]RetiredResource, len(docs))
+	for i, doc := range docs {
+		result[i] = RetiredResource{
+			Service: doc.Service,
+			Name:    doc.Name,
+			Size:    doc.Size,
+			Retired: doc.Retired,
+			path:    doc.Path,
+		}
+	}
+	return result, nil
+}
+
+// RemoveRetiredResource removes the content of a replaced resource
+// revision.
+func (st *State) RemoveRetiredResource(r RetiredResource) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot remove old content of resource %q for service %q", r.Name, r.Service)
+	storage, uuid, closer, err := st.resourceStorage()
+	if err != nil {
+		return err
+	}
+	defer closer()
+	if err := storage.RemoveForEnvironment(uuid, r.path); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	ops := []txn.Op{{
+		C:      retiredResourcesC,
+		Id:     r.path,
+		Remove: true,
+	}}
+	return st.runTransaction(ops)
+}
+
+// cleanupServiceResources removes the resources attached to a
+// removed service, along with their content.
+func (st *State) cleanupServiceResources(serviceName string) error {
+	resources, closer := st.getCollection(resourcesC)
+	defer closer()
+
+	var doc resourcesDoc
+	if err := resources.FindId(serviceName).One(&doc); err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	storage, uuid, closeStorage, err := st.resourceStorage()
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+	for name, res := range doc.Resources {
+		err := storage.RemoveForEnvironment(uuid, res.Path)
+		if err != nil && !errors.IsNotFound(err) {
+			return errors.Annotatef(err, "cannot remove resource %q for service %q", name, serviceName)
+		}
+	}
+	ops := []txn.Op{{
+		C:      resourcesC,
+		Id:     serviceName,
+		Remove: true,
+	}}
+	retired, closeRetired := st.getCollection(retiredResourcesC)
+	defer closeRetired()
+	var retiredDocs []retiredResourceDoc
+	if err := retired.Find(bson.D{{"service", serviceName}}).All(&retiredDocs); err != nil {
+		return errors.Annotatef(err, "cannot get retired resources for service %q", serviceName)
+	}
+	for _, res := range retiredDocs {
+		err := storage.RemoveForEnvironment(uuid, res.Path)
+		if err != nil && !errors.IsNotFound(err) {
+			return errors.Annotatef(err, "cannot remove resource %q for service %q", res.Name, serviceName)
+		}
+		ops = append(ops, txn.Op{
+			C:      retiredResourcesC,
+			Id:     res.Path,
+			Remove: true,
+		})
+	}
+	return st.runTransaction(ops)
+}
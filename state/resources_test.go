@@ -0,0 +1,204 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/resource"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/testing"
+)
+
+type ResourcesSuite struct {
+	ConnSuite
+	charm   *state.Charm
+	service *state.Service
+}
+
+var _ = gc.Suite(&ResourcesSuite{})
+
+var metaResources = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+    description: The application tarball.
+  data:
+    description: Some data.
+`
+
+func (s *ResourcesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charm = s.AddMetaCharm(c, "dummy", metaResources, 1)
+	s.service = s.AddTestingService(c, "app", s.charm)
+}
+
+func (s *ResourcesSuite) setResource(c *gc.C, name, content string) *state.Resource {
+	res, err := s.service.SetResource(name, strings.NewReader(content), int64(len(content)), "sha256-"+content)
+	c.Assert(err, gc.IsNil)
+	return res
+}
+
+func (s *ResourcesSuite) assertContent(c *gc.C, name, content string) {
+	res, r, err := s.service.OpenResource(name)
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, content)
+	c.Assert(res.Size(), gc.Equals, int64(len(content)))
+}
+
+func (s *ResourcesSuite) TestCharmResources(c *gc.C) {
+	c.Assert(s.charm.Resources(), jc.DeepEquals, map[string]resource.Meta{
+		"app": {
+			Name:        "app",
+			Filename:    "app.tar.gz",
+			Description: "The application tarball.",
+		},
+		"data": {
+			Name:        "data",
+			Filename:    "data",
+			Description: "Some data.",
+		},
+	})
+	ch := s.AddTestingCharm(c, "wordpress")
+	c.Assert(ch.Resources(), gc.HasLen, 0)
+}
+
+func (s *ResourcesSuite) TestSetResource(c *gc.C) {
+	res := s.setResource(c, "app", "first")
+	c.Assert(res.Service(), gc.Equals, "app")
+	c.Assert(res.Name(), gc.Equals, "app")
+	c.Assert(res.Revision(), gc.Equals, 1)
+	c.Assert(res.SHA256(), gc.Equals, "sha256-first")
+	s.assertContent(c, "app", "first")
+
+	res = s.setResource(c, "app", "second")
+	c.Assert(res.Revision(), gc.Equals, 2)
+	s.assertContent(c, "app", "second")
+
+	res = s.setResource(c, "data", "data")
+	c.Assert(res.Revision(), gc.Equals, 1)
+
+	res, err := s.service.Resource("app")
+	c.Assert(err, gc.IsNil)
+	c.Assert(res.Revision(), gc.Equals, 2)
+	c.Assert(res.SHA256(), gc.Equals, "sha256-second")
+
+	all, err := s.service.Resources()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 2)
+	c.Assert(all[0].Name(), gc.Equals, "app")
+	c.Assert(all[1].Name(), gc.Equals, "data")
+}
+
+func (s *ResourcesSuite) TestRetiredResources(c *gc.C) {
+	s.setResource(c, "app", "first")
+	s.setResource(c, "data", "data")
+	retired, err := s.State.RetiredResources(time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(retired, gc.HasLen, 0)
+
+	// Replacing a resource keeps its old content.
+	s.setResource(c, "app", "second")
+	before := time.Now().Add(-time.Hour)
+	retired, err = s.State.RetiredResources(before)
+	c.Assert(err, gc.IsNil)
+	c.Assert(retired, gc.HasLen, 0)
+	retired, err = s.State.RetiredResources(time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(retired, gc.HasLen, 1)
+	c.Assert(retired[0].Service, gc.Equals, "app")
+	c.Assert(retired[0].Name, gc.Equals, "app")
+	c.Assert(retired[0].Size, gc.Equals, int64(5))
+	c.Assert(retired[0].Retired.After(before), jc.IsTrue)
+
+	err = s.State.RemoveRetiredResource(retired[0])
+	c.Assert(err, gc.IsNil)
+	retired, err = s.State.RetiredResources(time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(retired, gc.HasLen, 0)
+	s.assertContent(c, "app", "second")
+}
+
+func (s *ResourcesSuite) TestSetResourceUndeclared(c *gc.C) {
+	_, err := s.service.SetResource("other", bytes.NewReader(nil), 0, "")
+	c.Assert(err, gc.ErrorMatches, `cannot set resource "other" for service "app": resource "other" in charm "local:quantal/quantal-dummy-1" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ResourcesSuite) TestSetResourceNotAlive(c *gc.C) {
+	_, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.SetResource("app", strings.NewReader("app"), 3, "sha256-app")
+	c.Assert(err, gc.ErrorMatches, `cannot set resource "app" for service "app": not found or not alive`)
+}
+
+func (s *ResourcesSuite) TestResourceNotFound(c *gc.C) {
+	_, err := s.service.Resource("app")
+	c.Assert(err, gc.ErrorMatches, `resource "app" for service "app" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, _, err = s.service.OpenResource("app")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	all, err := s.service.Resources()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+}
+
+func (s *ResourcesSuite) TestRemoveServiceCleansUpResources(c *gc.C) {
+	s.setResource(c, "app", "old")
+	s.setResource(c, "app", "app")
+	err := s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	dirty, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dirty, jc.IsTrue)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+
+	service := s.AddTestingService(c, "app", s.charm)
+	all, err := service.Resources()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 0)
+	retired, err := s.State.RetiredResources(time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(retired, gc.HasLen, 0)
+}
+
+func (s *ResourcesSuite) TestWatchResources(c *gc.C) {
+	w := s.service.WatchResources()
+	defer testing.AssertStop(c, w)
+
+	// Initial event.
+	wc := testing.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	s.setResource(c, "app", "first")
+	wc.AssertOneChange()
+
+	s.setResource(c, "data", "data")
+	wc.AssertOneChange()
+
+	// Resources of other services are not reported.
+	other := s.AddTestingService(c, "other", s.charm)
+	_, err := other.SetResource("app", strings.NewReader("other"), 5, "sha256-other")
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	testing.AssertStop(c, w)
+	wc.AssertClosed()
+}
@@ -0,0 +1,457 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/errors"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/apiserver/params"
+)
+
+// RollingUpgradeStatus describes the progress of a rolling charm
+// upgrade.
+type RollingUpgradeStatus string
+
+const (
+	// RollingUpgradeRunning means that batches of units are being
+	// admitted to upgrade as earlier batches become healthy.
+	RollingUpgradeRunning RollingUpgradeStatus = "running"
+
+	// RollingUpgradePaused means that no further units will be
+	// admitted until the upgrade is resumed.
+	RollingUpgradePaused RollingUpgradeStatus = "paused"
+
+	// RollingUpgradeComplete means that all units have been
+	// upgraded.
+	RollingUpgradeComplete RollingUpgradeStatus = "complete"
+)
+
+// RollingUpgrade represents an upgrade of a service's charm that
+// is applied to a limited number of units at a time. Units that
+// have not been admitted to the upgrade keep their current charm,
+// and each batch must become healthy before the next is admitted.
+type RollingUpgrade struct {
+	st  *State
+	doc rollingUpgradeDoc
+}
+
+type rollingUpgradeDoc struct {
+	ServiceName string `bson:"_id"`
+	CharmURL    *charm.URL
+	BatchSize   int
+	Status      RollingUpgradeStatus
+	Message     string
+	// Batch holds the names of the units in the current batch.
+	Batch []string
+	// StatusRevnos holds the txn-revno of the status of each unit
+	// in the current batch at the time it was admitted, so that a
+	// status set before the unit upgraded is not taken as a sign
+	// of its health.
+	StatusRevnos map[string]int64
+	// Admitted holds the names of all units that have been allowed
+	// to upgrade.
+	Admitted []string
+	TxnRevno int64 `bson:"txn-revno"`
+}
+
+// ServiceName returns the name of the service being upgraded.
+func (r *RollingUpgrade) ServiceName() string {
+	return r.doc.ServiceName
+}
+
+// CharmURL returns the URL of the charm units are upgraded to.
+func (r *RollingUpgrade) CharmURL() *charm.URL {
+	return r.doc.CharmURL
+}
+
+// BatchSize returns the maximum number of units upgraded at once.
+func (r *RollingUpgrade) BatchSize() int {
+	return r.doc.BatchSize
+}
+
+// Status returns the status of the upgrade.
+func (r *RollingUpgrade) Status() RollingUpgradeStatus {
+	return r.doc.Status
+}
+
+// Message returns the reason the upgrade was paused automatically,
+// if it was.
+func (r *RollingUpgrade) Message() string {
+	return r.doc.Message
+}
+
+// Batch returns the names of the units in the current batch.
+func (r *RollingUpgrade) Batch() []string {
+	return append([]string(nil), r.doc.Batch...)
+}
+
+// Admitted returns the names of all units that have been allowed
+// to upgrade, including those in the current batch.
+func (r *RollingUpgrade) Admitted() []string {
+	return append([]string(nil), r.doc.Admitted...)
+}
+
+// Refresh refreshes the contents of the RollingUpgrade from the
+// underlying state.
+func (r *RollingUpgrade) Refresh() error {
+	upgrade, err := getRollingUpgrade(r.st, r.doc.ServiceName)
+	if err != nil {
+		return err
+	}
+	r.doc = upgrade.doc
+	return nil
+}
+
+// Pending returns the names of the units that have yet to be
+// admitted to the upgrade, in the order they will be admitted.
+// Units that have not deployed a charm yet are not included, as
+// they will be deployed with the new charm directly.
+func (r *RollingUpgrade) Pending() ([]string, error) {
+	units, err := r.pendingUnits()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(units))
+	for i, unit := range units {
+		names[i] = unit.Name()
+	}
+	return names, nil
+}
+
+func (r *RollingUpgrade) pendingUnits() ([]*Unit, error) {
+	service, err := r.st.Service(r.doc.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	units, err := service.AllUnits()
+	if err != nil {
+		return nil, err
+	}
+	var pending []*Unit
+	for _, unit := range units {
+		if unit.Life() == Dead || r.admitted(unit.Name()) {
+			continue
+		}
+		if curl, ok := unit.CharmURL(); !ok || *curl == *r.doc.CharmURL {
+			continue
+		}
+		pending = append(pending, unit)
+	}
+	sort.Sort(UnitsByNumber(pending))
+	return pending, nil
+}
+
+func (r *RollingUpgrade) admitted(unitName string) bool {
+	for _, name := range r.doc.Admitted {
+		if name == unitName {
+			return true
+		}
+	}
+	return false
+}
+
+// holds returns whether the unit must keep its current charm until
+// it is admitted to the upgrade.
+func (r *RollingUpgrade) holds(unit *Unit) bool {
+	if r.doc.Status == RollingUpgradeComplete {
+		return false
+	}
+	if _, ok := unit.CharmURL(); !ok {
+		return false
+	}
+	return !r.admitted(unit.Name())
+}
+
+// Pause stops further units from being admitted to the upgrade.
+// Units in the current batch will still complete their upgrade.
+func (r *RollingUpgrade) Pause() error {
+	return r.setStatus(RollingUpgradePaused, "")
+}
+
+// Resume resumes a paused upgrade. If the upgrade was paused because
+// a unit failed, the unit's error must have been resolved first, or
+// the upgrade will pause again.
+func (r *RollingUpgrade) Resume() error {
+	return r.setStatus(RollingUpgradeRunning, "")
+}
+
+func (r *RollingUpgrade) setStatus(status RollingUpgradeStatus, message string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set status of rolling upgrade of service %q", r.doc.ServiceName)
+	notComplete := bson.D{{"status", bson.D{{"$ne", RollingUpgradeComplete}}}}
+	ops := []txn.Op{{
+		C:      rollingUpgradesC,
+		Id:     r.doc.ServiceName,
+		Assert: notComplete,
+		Update: bson.D{{"$set", bson.D{{"status", status}, {"message", message}}}},
+	}}
+	if err := r.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.New("upgrade already complete")
+	} else if err != nil {
+		return err
+	}
+	r.doc.Status = status
+	r.doc.Message = message
+	return nil
+}
+
+// Advance checks the units in the current batch. If any of them has
+// failed, the upgrade is paused; if all of them have upgraded
+// successfully, the next batch of units is admitted, or the upgrade
+// is marked complete if there are none left. Advance does nothing
+// unless the upgrade is running.
+func (r *RollingUpgrade) Advance() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot advance rolling upgrade of service %q", r.doc.ServiceName)
+	if err := r.Refresh(); err != nil {
+		return err
+	}
+	if r.doc.Status != RollingUpgradeRunning {
+		return nil
+	}
+	for _, name := range r.doc.Batch {
+		upgraded, failure, err := r.unitUpgraded(name)
+		if err != nil {
+			return err
+		}
+		if failure != "" {
+			return r.update(RollingUpgradePaused, failure, nil)
+		}
+		if !upgraded {
+			return nil
+		}
+	}
+	pending, err := r.pendingUnits()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return r.update(RollingUpgradeComplete, "", nil)
+	}
+	if len(pending) > r.doc.BatchSize {
+		pending = pending[:r.doc.BatchSize]
+	}
+	return r.update(RollingUpgradeRunning, "", pending)
+}
+
+// unitUpgraded returns whether the named unit has upgraded to the new
+// charm and is healthy. If the unit has failed, a description of the
+// failure is returned instead.
+func (r *RollingUpgrade) unitUpgraded(name string) (upgraded bool, failure string, err error) {
+	unit, err := r.st.Unit(name)
+	if errors.IsNotFound(err) {
+		return true, "", nil
+	} else if err != nil {
+		return false, "", err
+	}
+	if unit.Life() == Dead {
+		return true, "", nil
+	}
+	status, info, _, err := unit.Status()
+	if err != nil {
+		return false, "", err
+	}
+	if status == params.StatusError {
+		return false, fmt.Sprintf("unit %q failed: %s", name, info), nil
+	}
+	if curl, ok := unit.CharmURL(); !ok || *curl != *r.doc.CharmURL {
+		return false, "", nil
+	}
+	if status != params.StatusStarted {
+		return false, "", nil
+	}
+	// The unit agent sets the started status again once the
+	// upgrade-charm and config-changed hooks have run.
+	revno, err := statusRevno(r.st, unit.globalKey())
+	if err != nil {
+		return false, "", err
+	}
+	return revno > r.doc.StatusRevnos[name], "", nil
+}
+
+// update records the new status of the upgrade and, when batch is
+// not nil, admits its units to the upgrade.
+func (r *RollingUpgrade) update(status RollingUpgradeStatus, message string, batch []*Unit) error {
+	update := bson.D{{"$set", bson.D{{"status", status}, {"message", message}}}}
+	var ops []txn.Op
+	if batch != nil {
+		names := make([]string, len(batch))
+		revnos := make(map[string]int64)
+		for i, unit := range batch {
+			revno, err := statusRevno(r.st, unit.globalKey())
+			if err != nil {
+				return err
+			}
+			names[i] = unit.Name()
+			revnos[unit.Name()] = revno
+		}
+		update = bson.D{
+			{"$set", bson.D{
+				{"status", status},
+				{"message", message},
+				{"batch", names},
+				{"statusrevnos", revnos},
+			}},
+			{"$pushAll", bson.D{{"admitted", names}}},
+		}
+		// Touch the service so that its watchers notice that the
+		// admitted units may now upgrade.
+		ops = append(ops, txn.Op{
+			C:      servicesC,
+			Id:     r.doc.ServiceName,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$inc", bson.D{{"rollingupgradeseq", 1}}}},
+		})
+	}
+	ops = append(ops, txn.Op{
+		C:      rollingUpgradesC,
+		Id:     r.doc.ServiceName,
+		Assert: bson.D{{"txn-revno", r.doc.TxnRevno}},
+		Update: update,
+	})
+	if err := r.st.runTransaction(ops); err == txn.ErrAborted {
+		// The upgrade changed underneath us; it will be checked
+		// again next time.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return r.Refresh()
+}
+
+// statusRevno returns the txn-revno of the status document with the
+// given global key, which changes whenever the status is set.
+func statusRevno(st *State, globalKey string) (int64, error) {
+	statuses, closer := st.getCollection(statusesC)
+	defer closer()
+
+	var doc struct {
+		TxnRevno int64 `bson:"txn-revno"`
+	}
+	err := statuses.FindId(globalKey).Select(bson.D{{"txn-revno", 1}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return 0, errors.NotFoundf("status")
+	}
+	if err != nil {
+		return 0, errors.Annotatef(err, "cannot get status %q", globalKey)
+	}
+	return doc.TxnRevno, nil
+}
+
+// RollingUpgrade returns the rolling upgrade of the service's charm.
+// It returns an error satisfying errors.IsNotFound if the charm was
+// not upgraded in batches.
+func (s *Service) RollingUpgrade() (*RollingUpgrade, error) {
+	return getRollingUpgrade(s.st, s.doc.Name)
+}
+
+func getRollingUpgrade(st *State, serviceName string) (*RollingUpgrade, error) {
+	rollingUpgrades, closer := st.getCollection(rollingUpgradesC)
+	defer closer()
+
+	doc := rollingUpgradeDoc{}
+	err := rollingUpgrades.FindId(serviceName).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("rolling upgrade of service %q", serviceName)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get rolling upgrade of service %q", serviceName)
+	}
+	return &RollingUpgrade{st, doc}, nil
+}
+
+// AllRollingUpgrades returns the rolling upgrades of all services.
+func (st *State) AllRollingUpgrades() ([]*RollingUpgrade, error) {
+	rollingUpgrades, closer := st.getCollection(rollingUpgradesC)
+	defer closer()
+
+	docs := []rollingUpgradeDoc{}
+	err := rollingUpgrades.Find(nil).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get rolling upgrades")
+	}
+	upgrades := make([]*RollingUpgrade, len(docs))
+	for i, doc := range docs {
+		upgrades[i] = &RollingUpgrade{st, doc}
+	}
+	return upgrades, nil
+}
+
+// SetCharmRolling changes the charm for the service like SetCharm,
+// but existing units are upgraded batchSize units at a time: each
+// batch must upgrade successfully before the next one is admitted.
+// See RollingUpgrade.
+func (s *Service) SetCharmRolling(ch *Charm, force bool, batchSize int) error {
+	if batchSize < 1 {
+		return fmt.Errorf("cannot upgrade service %q in batches of %d units", s.doc.Name, batchSize)
+	}
+	return s.setCharm(ch, force, batchSize)
+}
+
+// CharmURLForUnit returns the charm URL the given unit should be
+// running, and whether it should upgrade to that charm even if it is
+// in an error state. This is the service's charm URL, unless a rolling
+// upgrade has not yet admitted the unit.
+func (s *Service) CharmURLForUnit(unit *Unit) (curl *charm.URL, force bool, err error) {
+	upgrade, err := s.RollingUpgrade()
+	if errors.IsNotFound(err) {
+		return s.doc.CharmURL, s.doc.ForceCharm, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	if upgrade.holds(unit) {
+		curl, _ := unit.CharmURL()
+		return curl, false, nil
+	}
+	return s.doc.CharmURL, s.doc.ForceCharm, nil
+}
+
+// rollingUpgradeOps returns the operations required to record that the
+// service's units are to be upgraded to curl in batches of batchSize
+// units. A batchSize of zero removes any existing rolling upgrade, so
+// that all units upgrade at once.
+func (s *Service) rollingUpgradeOps(curl *charm.URL, batchSize int) ([]txn.Op, error) {
+	if batchSize == 0 {
+		return []txn.Op{{
+			C:      rollingUpgradesC,
+			Id:     s.doc.Name,
+			Remove: true,
+		}}, nil
+	}
+	doc := rollingUpgradeDoc{
+		ServiceName: s.doc.Name,
+		CharmURL:    curl,
+		BatchSize:   batchSize,
+		Status:      RollingUpgradeRunning,
+	}
+	if _, err := s.RollingUpgrade(); errors.IsNotFound(err) {
+		return []txn.Op{{
+			C:      rollingUpgradesC,
+			Id:     s.doc.Name,
+			Assert: txn.DocMissing,
+			Insert: &doc,
+		}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return []txn.Op{{
+		C:      rollingUpgradesC,
+		Id:     s.doc.Name,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{
+			{"charmurl", doc.CharmURL},
+			{"batchsize", doc.BatchSize},
+			{"status", doc.Status},
+			{"message", ""},
+			{"batch", []string{}},
+			{"statusrevnos", map[string]int64{}},
+			{"admitted", []string{}},
+		}}},
+	}}, nil
+}
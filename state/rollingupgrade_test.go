@@ -0,0 +1,197 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/state"
+)
+
+type RollingUpgradeSuite struct {
+	ConnSuite
+	oldCharm *state.Charm
+	newCharm *state.Charm
+	service  *state.Service
+	units    []*state.Unit
+}
+
+var _ = gc.Suite(&RollingUpgradeSuite{})
+
+func (s *RollingUpgradeSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.oldCharm = s.AddConfigCharm(c, "wordpress", emptyConfig, 1)
+	s.newCharm = s.AddConfigCharm(c, "wordpress", emptyConfig, 2)
+	s.service = s.AddTestingService(c, "wordpress", s.oldCharm)
+	s.units = nil
+	for i := 0; i < 3; i++ {
+		unit, err := s.service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(s.oldCharm.URL())
+		c.Assert(err, gc.IsNil)
+		err = unit.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+		s.units = append(s.units, unit)
+	}
+}
+
+// upgradeUnit simulates the unit agent upgrading the unit's charm
+// and running its hooks successfully.
+func (s *RollingUpgradeSuite) upgradeUnit(c *gc.C, unit *state.Unit) {
+	err := unit.SetCharmURL(s.newCharm.URL())
+	c.Assert(err, gc.IsNil)
+	err = unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *RollingUpgradeSuite) assertCharmURLForUnit(c *gc.C, unit *state.Unit, expect *state.Charm) {
+	err := unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	curl, force, err := s.service.CharmURLForUnit(unit)
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl, gc.DeepEquals, expect.URL())
+	c.Assert(force, jc.IsFalse)
+}
+
+func (s *RollingUpgradeSuite) TestSetCharmRolling(c *gc.C) {
+	err := s.service.SetCharmRolling(s.newCharm, false, 0)
+	c.Assert(err, gc.ErrorMatches, `cannot upgrade service "wordpress" in batches of 0 units`)
+
+	err = s.service.SetCharmRolling(s.newCharm, false, 2)
+	c.Assert(err, gc.IsNil)
+	curl, _ := s.service.CharmURL()
+	c.Assert(curl, gc.DeepEquals, s.newCharm.URL())
+
+	upgrade, err := s.service.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.ServiceName(), gc.Equals, "wordpress")
+	c.Assert(upgrade.CharmURL(), gc.DeepEquals, s.newCharm.URL())
+	c.Assert(upgrade.BatchSize(), gc.Equals, 2)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradeRunning)
+	c.Assert(upgrade.Batch(), gc.HasLen, 0)
+	pending, err := upgrade.Pending()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, jc.DeepEquals, []string{"wordpress/0", "wordpress/1", "wordpress/2"})
+
+	// Units are held at their current charm until admitted, but
+	// new units get the new charm.
+	s.assertCharmURLForUnit(c, s.units[0], s.oldCharm)
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.assertCharmURLForUnit(c, unit, s.newCharm)
+
+	upgrades, err := s.State.AllRollingUpgrades()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 1)
+	c.Assert(upgrades[0].ServiceName(), gc.Equals, "wordpress")
+}
+
+func (s *RollingUpgradeSuite) TestAdvance(c *gc.C) {
+	err := s.service.SetCharmRolling(s.newCharm, false, 2)
+	c.Assert(err, gc.IsNil)
+	upgrade, err := s.service.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/0", "wordpress/1"})
+	s.assertCharmURLForUnit(c, s.units[0], s.newCharm)
+	s.assertCharmURLForUnit(c, s.units[1], s.newCharm)
+	s.assertCharmURLForUnit(c, s.units[2], s.oldCharm)
+
+	// The batch must complete before the next is admitted.
+	s.upgradeUnit(c, s.units[0])
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/0", "wordpress/1"})
+
+	// A unit that has the new charm but has not run its hooks yet is
+	// not considered upgraded.
+	err = s.units[1].SetCharmURL(s.newCharm.URL())
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/0", "wordpress/1"})
+
+	s.upgradeUnit(c, s.units[1])
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/2"})
+	c.Assert(upgrade.Admitted(), jc.DeepEquals, []string{"wordpress/0", "wordpress/1", "wordpress/2"})
+	s.assertCharmURLForUnit(c, s.units[2], s.newCharm)
+
+	s.upgradeUnit(c, s.units[2])
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradeComplete)
+
+	err = upgrade.Pause()
+	c.Assert(err, gc.ErrorMatches, `cannot set status of rolling upgrade of service "wordpress": upgrade already complete`)
+}
+
+func (s *RollingUpgradeSuite) TestAdvancePausesOnFailure(c *gc.C) {
+	err := s.service.SetCharmRolling(s.newCharm, false, 1)
+	c.Assert(err, gc.IsNil)
+	upgrade, err := s.service.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+
+	err = s.units[0].SetCharmURL(s.newCharm.URL())
+	c.Assert(err, gc.IsNil)
+	err = s.units[0].SetStatus(params.StatusError, `hook failed: "upgrade-charm"`, nil)
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradePaused)
+	c.Assert(upgrade.Message(), gc.Equals, `unit "wordpress/0" failed: hook failed: "upgrade-charm"`)
+	s.assertCharmURLForUnit(c, s.units[1], s.oldCharm)
+
+	// Nothing happens while paused.
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/0"})
+
+	// Once the error is resolved, the upgrade can be resumed.
+	err = s.units[0].SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = upgrade.Resume()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Message(), gc.Equals, "")
+	err = upgrade.Advance()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrade.Status(), gc.Equals, state.RollingUpgradeRunning)
+	c.Assert(upgrade.Batch(), jc.DeepEquals, []string{"wordpress/1"})
+}
+
+func (s *RollingUpgradeSuite) TestSetCharmAbandonsRollingUpgrade(c *gc.C) {
+	err := s.service.SetCharmRolling(s.newCharm, false, 1)
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetCharm(s.oldCharm, false)
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.RollingUpgrade()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	s.assertCharmURLForUnit(c, s.units[0], s.oldCharm)
+}
+
+func (s *RollingUpgradeSuite) TestServiceRemovalRemovesRollingUpgrade(c *gc.C) {
+	err := s.service.SetCharmRolling(s.newCharm, false, 1)
+	c.Assert(err, gc.IsNil)
+	for _, unit := range s.units {
+		err = unit.EnsureDead()
+		c.Assert(err, gc.IsNil)
+		err = unit.Remove()
+		c.Assert(err, gc.IsNil)
+	}
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	upgrades, err := s.State.AllRollingUpgrades()
+	c.Assert(err, gc.IsNil)
+	c.Assert(upgrades, gc.HasLen, 0)
+}
@@ -0,0 +1,703 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// RunTaskStatus describes the progress of a run job on one of its
+// targets.
+type RunTaskStatus string
+
+const (
+	// RunTaskQueued means the task is waiting for one of the job's
+	// other tasks to finish, so the job's parallelism is respected.
+	RunTaskQueued RunTaskStatus = "queued"
+
+	// RunTaskPending means the task is waiting to be picked up by
+	// the agent of the machine hosting its target.
+	RunTaskPending RunTaskStatus = "pending"
+
+	// RunTaskRunning means the agent is executing the commands.
+	RunTaskRunning RunTaskStatus = "running"
+
+	// RunTaskCompleted means the commands were executed; the exit
+	// code and output are recorded.
+	RunTaskCompleted RunTaskStatus = "completed"
+
+	// RunTaskFailed means the agent could not execute the commands.
+	RunTaskFailed RunTaskStatus = "failed"
+)
+
+// Done returns whether a task with the status has finished.
+func (s RunTaskStatus) Done() bool {
+	return s == RunTaskCompleted || s == RunTaskFailed
+}
+
+// RunJob represents a request to run commands on a number of
+// machines and units. Each target is represented by a RunTask,
+// which is executed by the agent of the machine hosting the target.
+type RunJob struct {
+	st  *State
+	doc runJobDoc
+}
+
+type runJobDoc struct {
+	Id          string `bson:"_id"`
+	Commands    string
+	Timeout     time.Duration
+	Parallelism int
+	Created     time.Time
+
+	// Tasks holds the ids of the job's tasks, in the order they are
+	// released to the agents.
+	Tasks []string
+
+	// Released holds the number of tasks that have been released.
+	Released int
+}
+
+// Id returns the job id.
+func (j *RunJob) Id() string {
+	return j.doc.Id
+}
+
+// Commands returns the commands to run.
+func (j *RunJob) Commands() string {
+	return j.doc.Commands
+}
+
+// Timeout returns how long the commands may run on each target
+// before they are considered to have failed.
+func (j *RunJob) Timeout() time.Duration {
+	return j.doc.Timeout
+}
+
+// Parallelism returns the maximum number of targets the commands
+// run on at the same time, or 0 if there is no limit.
+func (j *RunJob) Parallelism() int {
+	return j.doc.Parallelism
+}
+
+// Created returns the time the job was added.
+func (j *RunJob) Created() time.Time {
+	return j.doc.Created
+}
+
+// Refresh refreshes the contents of the job from the underlying
+// state.
+func (j *RunJob) Refresh() error {
+	doc, err := j.st.runJobDoc(j.doc.Id)
+	if err != nil {
+		return err
+	}
+	j.doc = *doc
+	return nil
+}
+
+// Tasks returns the job's tasks, in the order they are released to
+// the agents.
+func (j *RunJob) Tasks() ([]*RunTask, error) {
+	runTasks, closer := j.st.getCollection(runTasksC)
+	defer closer()
+
+	var docs []runTaskDoc
+	err := runTasks.Find(bson.D{{"jobid", j.doc.Id}}).All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get tasks of run job %q", j.doc.Id)
+	}
+	byId := make(map[string]*RunTask)
+	for _, doc := range docs {
+		byId[doc.Id] = &RunTask{st: j.st, doc: doc}
+	}
+	tasks := make([]*RunTask, 0, len(docs))
+	for _, id := range j.doc.Tasks {
+		if task, ok := byId[id]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// RunTask represents the execution of a run job on one machine or
+// unit.
+type RunTask struct {
+	st  *State
+	doc runTaskDoc
+}
+
+type runTaskDoc struct {
+	Id        string `bson:"_id"`
+	JobId     string
+	MachineId string
+	Target    string
+	Status    RunTaskStatus
+	Code      int
+	Stdout    []byte
+	Stderr    []byte
+	Error     string
+	Started   time.Time
+	Completed time.Time
+
+	// Output holds the output written by the commands so far, in
+	// the order it was written, while the task is running. It is
+	// removed when the task finishes, as the result holds it all.
+	Output []RunOutput
+
+	// OutputSize holds the total size of Output.
+	OutputSize int
+}
+
+// maxRunOutputSize holds the maximum number of bytes of a task's
+// output that are recorded while it runs, and of each of its standard
+// output and standard error when it finishes. Further output is
+// dropped.
+var maxRunOutputSize = 1 << 20
+
+// truncateOutput returns data truncated to at most n bytes.
+func truncateOutput(data []byte, n int) []byte {
+	if n < 0 {
+		n = 0
+	}
+	if len(data) > n {
+		return data[:n]
+	}
+	return data
+}
+
+// RunOutput holds output written by the commands of a running task.
+type RunOutput struct {
+	Stderr bool
+	Data   []byte
+}
+
+// RunTaskResult holds the outcome of running a task's commands.
+type RunTaskResult struct {
+	Code   int
+	Stdout []byte
+	Stderr []byte
+
+	// Error holds the reason the commands could not be run, if any.
+	Error string
+}
+
+// Id returns the task id.
+func (t *RunTask) Id() string {
+	return t.doc.Id
+}
+
+// JobId returns the id of the job the task belongs to.
+func (t *RunTask) JobId() string {
+	return t.doc.JobId
+}
+
+// MachineId returns the id of the machine whose agent runs the task.
+func (t *RunTask) MachineId() string {
+	return t.doc.MachineId
+}
+
+// Target returns the tag of the machine or unit the commands run on.
+func (t *RunTask) Target() string {
+	return t.doc.Target
+}
+
+// Status returns the progress of the task.
+func (t *RunTask) Status() RunTaskStatus {
+	return t.doc.Status
+}
+
+// Result returns the outcome of the task. It is only meaningful
+// once the task is done.
+func (t *RunTask) Result() RunTaskResult {
+	return RunTaskResult{
+		Code:   t.doc.Code,
+		Stdout: t.doc.Stdout,
+		Stderr: t.doc.Stderr,
+		Error:  t.doc.Error,
+	}
+}
+
+// Output returns the output written by the commands so far, in the
+// order it was written. Once the task is done, its output is only
+// held in its result.
+func (t *RunTask) Output() []RunOutput {
+	return t.doc.Output
+}
+
+// Started returns the time the agent started running the task.
+func (t *RunTask) Started() time.Time {
+	return t.doc.Started
+}
+
+// Completed returns the time the task finished.
+func (t *RunTask) Completed() time.Time {
+	return t.doc.Completed
+}
+
+// Job returns the job the task belongs to.
+func (t *RunTask) Job() (*RunJob, error) {
+	return t.st.RunJob(t.doc.JobId)
+}
+
+// Refresh refreshes the contents of the task from the underlying
+// state.
+func (t *RunTask) Refresh() error {
+	runTasks, closer := t.st.getCollection(runTasksC)
+	defer closer()
+
+	var doc runTaskDoc
+	err := runTasks.FindId(t.doc.Id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("run task %q", t.doc.Id)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot refresh run task %q", t.doc.Id)
+	}
+	t.doc = doc
+	return nil
+}
+
+// RefreshOutput refreshes the task from the underlying state like
+// Refresh, but fetches only the output written since the task was
+// last read, appending it to Output, and fetches nothing when the
+// task is unchanged. The result is only fetched once the task is
+// done. It is meant for following a running task without reloading
+// all of its output every time.
+func (t *RunTask) RefreshOutput() error {
+	runTasks, closer := t.st.getCollection(runTasksC)
+	defer closer()
+
+	var doc runTaskDoc
+	err := runTasks.Find(bson.D{
+		{"_id", t.doc.Id},
+		{"$or", []bson.D{
+			{{"status", bson.D{{"$ne", t.doc.Status}}}},
+			{{"outputsize", bson.D{{"$exists", true}, {"$ne", t.doc.OutputSize}}}},
+		}},
+	}).Select(bson.D{
+		{"stdout", 0},
+		{"stderr", 0},
+		{"output", bson.D{{"$slice", []int{len(t.doc.Output), maxRunOutputSize}}}},
+	}).One(&doc)
+	if err == mgo.ErrNotFound {
+		// The task is either unchanged or gone.
+		n, err := runTasks.FindId(t.doc.Id).Count()
+		if err != nil {
+			return errors.Annotatef(err, "cannot refresh run task %q", t.doc.Id)
+		} else if n == 0 {
+			return errors.NotFoundf("run task %q", t.doc.Id)
+		}
+		return nil
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot refresh run task %q", t.doc.Id)
+	}
+	if doc.Status.Done() {
+		return t.Refresh()
+	}
+	t.doc.Status = doc.Status
+	t.doc.Started = doc.Started
+	t.doc.Output = append(t.doc.Output, doc.Output...)
+	t.doc.OutputSize = doc.OutputSize
+	return nil
+}
+
+// Start records that the agent has started running the task. It
+// fails if the task is not pending.
+func (t *RunTask) Start() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot start run task %q", t.doc.Id)
+	started := nowToTheSecond()
+	ops := []txn.Op{{
+		C:      runTasksC,
+		Id:     t.doc.Id,
+		Assert: bson.D{{"status", RunTaskPending}},
+		Update: bson.D{{"$set", bson.D{
+			{"status", RunTaskRunning},
+			{"started", started},
+		}}},
+	}}
+	if err := t.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := t.Refresh(); err != nil {
+			return err
+		}
+		return errors.Errorf("task is %s", t.doc.Status)
+	} else if err != nil {
+		return err
+	}
+	t.doc.Status = RunTaskRunning
+	t.doc.Started = started
+	return nil
+}
+
+// AppendOutput records output written by the commands of the running
+// task, so it can be streamed to clients while the commands run.
+// Output beyond maxRunOutputSize is dropped.
+func (t *RunTask) AppendOutput(stdout, stderr []byte) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot append output of run task %q", t.doc.Id)
+	var output []RunOutput
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := t.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if t.doc.Status != RunTaskRunning {
+			return nil, errors.Errorf("task is %s", t.doc.Status)
+		}
+		output = nil
+		size := t.doc.OutputSize
+		for _, chunk := range []RunOutput{{Data: stdout}, {Stderr: true, Data: stderr}} {
+			chunk.Data = truncateOutput(chunk.Data, maxRunOutputSize-size)
+			if len(chunk.Data) > 0 {
+				output = append(output, chunk)
+				size += len(chunk.Data)
+			}
+		}
+		if len(output) == 0 {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return []txn.Op{{
+			C:  runTasksC,
+			Id: t.doc.Id,
+			Assert: bson.D{
+				{"status", RunTaskRunning},
+				{"outputsize", t.doc.OutputSize},
+			},
+			Update: bson.D{
+				{"$pushAll", bson.D{{"output", output}}},
+				{"$set", bson.D{{"outputsize", size}}},
+			},
+		}}, nil
+	}
+	if err := t.st.run(buildTxn); err != nil {
+		return err
+	}
+	for _, chunk := range output {
+		t.doc.Output = append(t.doc.Output, chunk)
+		t.doc.OutputSize += len(chunk.Data)
+	}
+	return nil
+}
+
+// Finish records the outcome of the running task, and releases the
+// job's next queued task, if any. The output recorded while the task
+// ran is removed, and the standard output and standard error of the
+// result are each truncated to maxRunOutputSize.
+func (t *RunTask) Finish(result RunTaskResult) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot finish run task %q", t.doc.Id)
+	result.Stdout = truncateOutput(result.Stdout, maxRunOutputSize)
+	result.Stderr = truncateOutput(result.Stderr, maxRunOutputSize)
+	status := RunTaskCompleted
+	if result.Error != "" {
+		status = RunTaskFailed
+	}
+	completed := nowToTheSecond()
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := t.Refresh(); err != nil {
+				return nil, err
+			}
+			if t.doc.Status != RunTaskRunning {
+				return nil, errors.Errorf("task is %s", t.doc.Status)
+			}
+		}
+		job, err := t.Job()
+		if err != nil {
+			return nil, err
+		}
+		ops := []txn.Op{{
+			C:      runTasksC,
+			Id:     t.doc.Id,
+			Assert: bson.D{{"status", RunTaskRunning}},
+			Update: bson.D{
+				{"$set", bson.D{
+					{"status", status},
+					{"code", result.Code},
+					{"stdout", result.Stdout},
+					{"stderr", result.Stderr},
+					{"error", result.Error},
+					{"completed", completed},
+				}},
+				{"$unset", bson.D{{"output", nil}, {"outputsize", nil}}},
+			},
+		}}
+		return append(ops, job.releaseOps(1)...), nil
+	}
+	if err := t.st.run(buildTxn); err != nil {
+		return err
+	}
+	t.doc.Status = status
+	t.doc.Code = result.Code
+	t.doc.Stdout = result.Stdout
+	t.doc.Stderr = result.Stderr
+	t.doc.Error = result.Error
+	t.doc.Completed = completed
+	t.doc.Output = nil
+	t.doc.OutputSize = 0
+	return nil
+}
+
+// FailRunningTasks records that the tasks the machine's agent was
+// running have failed for the given reason. The agent calls it when
+// it starts, as tasks it was running when it stopped are never
+// finished otherwise.
+func (m *Machine) FailRunningTasks(reason string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot fail running tasks of machine %s", m.doc.Id)
+	runTasks, closer := m.st.getCollection(runTasksC)
+	defer closer()
+
+	var docs []runTaskDoc
+	err = runTasks.Find(bson.D{
+		{"machineid", m.doc.Id},
+		{"status", RunTaskRunning},
+	}).All(&docs)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		task := &RunTask{st: m.st, doc: doc}
+		if err := task.Finish(RunTaskResult{Error: reason}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove removes the job and all its tasks, whether they have
+// finished or not.
+func (j *RunJob) Remove() (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot remove run job %q", j.doc.Id)
+	ops := []txn.Op{{
+		C:      runJobsC,
+		Id:     j.doc.Id,
+		Remove: true,
+	}}
+	for _, id := range j.doc.Tasks {
+		ops = append(ops, txn.Op{
+			C:      runTasksC,
+			Id:     id,
+			Remove: true,
+		})
+	}
+	return j.st.runTransaction(ops)
+}
+
+// CleanupOldRunJobs removes the run jobs added before the given time,
+// with their tasks. Tasks that have not finished by then are
+// abandoned.
+func (st *State) CleanupOldRunJobs(addedBefore time.Time) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot cleanup old run jobs")
+	runJobs, closer := st.getCollection(runJobsC)
+	defer closer()
+
+	iter := runJobs.Find(bson.D{{"created", bson.D{{"$lt", addedBefore}}}}).Iter()
+	var doc runJobDoc
+	for iter.Next(&doc) {
+		job := &RunJob{st: st, doc: doc}
+		if err := job.Remove(); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
+// releaseOps returns the operations that release up to n of the job's
+// queued tasks to the agents.
+func (j *RunJob) releaseOps(n int) []txn.Op {
+	released := j.doc.Released
+	end := released + n
+	if end > len(j.doc.Tasks) {
+		end = len(j.doc.Tasks)
+	}
+	if end == released {
+		return nil
+	}
+	ops := []txn.Op{{
+		C:      runJobsC,
+		Id:     j.doc.Id,
+		Assert: bson.D{{"released", released}},
+		Update: bson.D{{"$set", bson.D{{"released", end}}}},
+	}}
+	for _, id := range j.doc.Tasks[released:end] {
+		ops = append(ops, txn.Op{
+			C:      runTasksC,
+			Id:     id,
+			Assert: bson.D{{"status", RunTaskQueued}},
+			Update: bson.D{{"$set", bson.D{{"status", RunTaskPending}}}},
+		})
+	}
+	return ops
+}
+
+// runTaskPrefix returns the prefix of the ids of the tasks run by the
+// agent of the given machine.
+func runTaskPrefix(machineId string) string {
+	return names.NewMachineTag(machineId).String() + "#"
+}
+
+// runTaskId returns the id of the task running a job on a target.
+// Task ids are prefixed by the machine that runs them, so machine
+// agents can watch their tasks efficiently.
+func runTaskId(machineId, jobId string, target names.Tag) string {
+	return fmt.Sprintf("%s%s#%s", runTaskPrefix(machineId), jobId, target)
+}
+
+// AddRunJob adds a job running commands on the given machines and
+// units. If parallelism is positive, the commands run on at most that
+// many targets at a time.
+func (st *State) AddRunJob(commands string, timeout time.Duration, parallelism int, targets []names.Tag) (job *RunJob, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add run job")
+	if commands == "" {
+		return nil, errors.New("no commands specified")
+	}
+	if parallelism < 0 {
+		return nil, errors.New("parallelism must not be negative")
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets specified")
+	}
+	seq, err := st.sequence("runjob")
+	if err != nil {
+		return nil, err
+	}
+	jobId := strconv.Itoa(seq)
+	var ops []txn.Op
+	var taskIds []string
+	seen := make(map[names.Tag]bool)
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		machineId, op, err := st.runTargetMachine(target)
+		if err != nil {
+			return nil, err
+		}
+		// Tasks beyond the job's parallelism are queued until
+		// earlier ones finish.
+		status := RunTaskPending
+		if parallelism > 0 && len(taskIds) >= parallelism {
+			status = RunTaskQueued
+		}
+		taskId := runTaskId(machineId, jobId, target)
+		taskIds = append(taskIds, taskId)
+		ops = append(ops, op, txn.Op{
+			C:      runTasksC,
+			Id:     taskId,
+			Assert: txn.DocMissing,
+			Insert: &runTaskDoc{
+				Id:        taskId,
+				JobId:     jobId,
+				MachineId: machineId,
+				Target:    target.String(),
+				Status:    status,
+			},
+		})
+	}
+	released := len(taskIds)
+	if parallelism > 0 && parallelism < released {
+		released = parallelism
+	}
+	doc := runJobDoc{
+		Id:          jobId,
+		Commands:    commands,
+		Timeout:     timeout,
+		Parallelism: parallelism,
+		Created:     nowToTheSecond(),
+		Tasks:       taskIds,
+		Released:    released,
+	}
+	ops = append(ops, txn.Op{
+		C:      runJobsC,
+		Id:     jobId,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	})
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return nil, errors.New("a target machine or unit was removed")
+	} else if err != nil {
+		return nil, err
+	}
+	return &RunJob{st: st, doc: doc}, nil
+}
+
+// runTargetMachine returns the id of the machine that runs the
+// commands for the given target, and an operation asserting the
+// target is still alive.
+func (st *State) runTargetMachine(target names.Tag) (string, txn.Op, error) {
+	switch target := target.(type) {
+	case names.MachineTag:
+		machine, err := st.Machine(target.Id())
+		if err != nil {
+			return "", txn.Op{}, err
+		}
+		if machine.Life() != Alive {
+			return "", txn.Op{}, errors.Errorf("machine %s is not alive", target.Id())
+		}
+		return machine.Id(), txn.Op{
+			C:      machinesC,
+			Id:     machine.doc.Id,
+			Assert: isAliveDoc,
+		}, nil
+	case names.UnitTag:
+		unit, err := st.Unit(target.Id())
+		if err != nil {
+			return "", txn.Op{}, err
+		}
+		if unit.Life() != Alive {
+			return "", txn.Op{}, errors.Errorf("unit %q is not alive", target.Id())
+		}
+		machineId, err := unit.AssignedMachineId()
+		if err != nil {
+			return "", txn.Op{}, err
+		}
+		return machineId, txn.Op{
+			C:      unitsC,
+			Id:     unit.doc.Name,
+			Assert: isAliveDoc,
+		}, nil
+	}
+	return "", txn.Op{}, errors.NotValidf("run target %q", target)
+}
+
+func (st *State) runJobDoc(id string) (*runJobDoc, error) {
+	runJobs, closer := st.getCollection(runJobsC)
+	defer closer()
+
+	var doc runJobDoc
+	err := runJobs.FindId(id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("run job %q", id)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get run job %q", id)
+	}
+	return &doc, nil
+}
+
+// RunJob returns the run job with the given id.
+func (st *State) RunJob(id string) (*RunJob, error) {
+	doc, err := st.runJobDoc(id)
+	if err != nil {
+		return nil, err
+	}
+	return &RunJob{st: st, doc: *doc}, nil
+}
+
+// RunTask returns the run task with the given id.
+func (st *State) RunTask(id string) (*RunTask, error) {
+	task := &RunTask{st: st, doc: runTaskDoc{Id: id}}
+	if err := task.Refresh(); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
@@ -0,0 +1,305 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/testing"
+)
+
+type RunJobSuite struct {
+	ConnSuite
+	machine0 *state.Machine
+	machine1 *state.Machine
+	unit     *state.Unit
+}
+
+var _ = gc.Suite(&RunJobSuite{})
+
+func (s *RunJobSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine0, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.machine1, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(s.machine1)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *RunJobSuite) targets() []names.Tag {
+	return []names.Tag{s.machine0.Tag(), s.machine1.Tag(), s.unit.Tag()}
+}
+
+func (s *RunJobSuite) assertStatuses(c *gc.C, job *state.RunJob, expect ...state.RunTaskStatus) []*state.RunTask {
+	tasks, err := job.Tasks()
+	c.Assert(err, gc.IsNil)
+	var statuses []state.RunTaskStatus
+	for _, task := range tasks {
+		statuses = append(statuses, task.Status())
+	}
+	c.Assert(statuses, jc.DeepEquals, expect)
+	return tasks
+}
+
+func (s *RunJobSuite) TestAddRunJob(c *gc.C) {
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, s.targets())
+	c.Assert(err, gc.IsNil)
+	c.Assert(job.Commands(), gc.Equals, "hostname")
+	c.Assert(job.Timeout(), gc.Equals, time.Minute)
+	c.Assert(job.Parallelism(), gc.Equals, 0)
+
+	tasks := s.assertStatuses(c, job, state.RunTaskPending, state.RunTaskPending, state.RunTaskPending)
+	c.Assert(tasks[0].MachineId(), gc.Equals, "0")
+	c.Assert(tasks[0].Target(), gc.Equals, "machine-0")
+	c.Assert(tasks[1].MachineId(), gc.Equals, "1")
+	c.Assert(tasks[1].Target(), gc.Equals, "machine-1")
+	c.Assert(tasks[2].MachineId(), gc.Equals, "1")
+	c.Assert(tasks[2].Target(), gc.Equals, "unit-wordpress-0")
+	c.Assert(tasks[2].JobId(), gc.Equals, job.Id())
+
+	again, err := s.State.RunJob(job.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(again.Commands(), gc.Equals, "hostname")
+	_, err = s.State.RunJob("42")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *RunJobSuite) TestAddRunJobInvalid(c *gc.C) {
+	_, err := s.State.AddRunJob("", time.Minute, 0, s.targets())
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: no commands specified")
+	_, err = s.State.AddRunJob("hostname", time.Minute, -1, s.targets())
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: parallelism must not be negative")
+	_, err = s.State.AddRunJob("hostname", time.Minute, 0, nil)
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: no targets specified")
+	_, err = s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{names.NewMachineTag("42")})
+	c.Assert(err, gc.ErrorMatches, "cannot add run job: machine 42 not found")
+}
+
+func (s *RunJobSuite) TestParallelism(c *gc.C) {
+	job, err := s.State.AddRunJob("hostname", time.Minute, 1, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks := s.assertStatuses(c, job, state.RunTaskPending, state.RunTaskQueued, state.RunTaskQueued)
+
+	// A queued task cannot be started.
+	err = tasks[1].Start()
+	c.Assert(err, gc.ErrorMatches, `cannot start run task ".*": task is queued`)
+
+	err = tasks[0].Start()
+	c.Assert(err, gc.IsNil)
+	s.assertStatuses(c, job, state.RunTaskRunning, state.RunTaskQueued, state.RunTaskQueued)
+
+	// Finishing a task releases the next one.
+	err = tasks[0].Finish(state.RunTaskResult{Code: 1, Stdout: []byte("out"), Stderr: []byte("err")})
+	c.Assert(err, gc.IsNil)
+	err = job.Refresh()
+	c.Assert(err, gc.IsNil)
+	tasks = s.assertStatuses(c, job, state.RunTaskCompleted, state.RunTaskPending, state.RunTaskQueued)
+	c.Assert(tasks[0].Result(), jc.DeepEquals, state.RunTaskResult{
+		Code:   1,
+		Stdout: []byte("out"),
+		Stderr: []byte("err"),
+	})
+
+	err = tasks[1].Start()
+	c.Assert(err, gc.IsNil)
+	err = tasks[1].Finish(state.RunTaskResult{Error: "boom"})
+	c.Assert(err, gc.IsNil)
+	tasks = s.assertStatuses(c, job, state.RunTaskCompleted, state.RunTaskFailed, state.RunTaskPending)
+	c.Assert(tasks[1].Result().Error, gc.Equals, "boom")
+	c.Assert(tasks[1].Status().Done(), jc.IsTrue)
+
+	// A task can only be finished once.
+	err = tasks[0].Finish(state.RunTaskResult{})
+	c.Assert(err, gc.ErrorMatches, `cannot finish run task ".*": task is completed`)
+}
+
+func (s *RunJobSuite) TestWatchRunTasks(c *gc.C) {
+	w := s.machine1.WatchRunTasks()
+	defer testing.AssertStop(c, w)
+	wc := testing.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange()
+	wc.AssertNoChange()
+
+	// Only pending tasks for the machine and its units are reported.
+	job, err := s.State.AddRunJob("hostname", time.Minute, 2, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks := s.assertStatuses(c, job, state.RunTaskPending, state.RunTaskPending, state.RunTaskQueued)
+	wc.AssertChange(tasks[1].Id())
+	wc.AssertNoChange()
+
+	// Running and finishing the machine's task releases the unit's.
+	err = tasks[1].Start()
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+	err = tasks[1].Finish(state.RunTaskResult{})
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(tasks[2].Id())
+	wc.AssertNoChange()
+}
+
+func (s *RunJobSuite) TestAppendOutput(c *gc.C) {
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks, err := job.Tasks()
+	c.Assert(err, gc.IsNil)
+	task := tasks[0]
+
+	err = task.AppendOutput([]byte("out"), nil)
+	c.Assert(err, gc.ErrorMatches, `cannot append output of run task ".*": task is pending`)
+
+	err = task.Start()
+	c.Assert(err, gc.IsNil)
+	err = task.AppendOutput([]byte("one\ntw"), nil)
+	c.Assert(err, gc.IsNil)
+	err = task.AppendOutput(nil, nil)
+	c.Assert(err, gc.IsNil)
+	err = task.AppendOutput([]byte("o\n"), []byte("oops\n"))
+	c.Assert(err, gc.IsNil)
+
+	expect := []state.RunOutput{
+		{Data: []byte("one\ntw")},
+		{Data: []byte("o\n")},
+		{Stderr: true, Data: []byte("oops\n")},
+	}
+	c.Assert(task.Output(), jc.DeepEquals, expect)
+	again, err := s.State.RunTask(task.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(again.Output(), jc.DeepEquals, expect)
+}
+
+func (s *RunJobSuite) TestRefreshOutput(c *gc.C) {
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks, err := job.Tasks()
+	c.Assert(err, gc.IsNil)
+	follower := tasks[0]
+	task, err := s.State.RunTask(follower.Id())
+	c.Assert(err, gc.IsNil)
+
+	err = follower.RefreshOutput()
+	c.Assert(err, gc.IsNil)
+	c.Assert(follower.Status(), gc.Equals, state.RunTaskPending)
+
+	err = task.Start()
+	c.Assert(err, gc.IsNil)
+	err = task.AppendOutput([]byte("one\n"), nil)
+	c.Assert(err, gc.IsNil)
+	err = follower.RefreshOutput()
+	c.Assert(err, gc.IsNil)
+	c.Assert(follower.Status(), gc.Equals, state.RunTaskRunning)
+	c.Assert(follower.Output(), jc.DeepEquals, []state.RunOutput{
+		{Data: []byte("one\n")},
+	})
+
+	err = task.AppendOutput([]byte("two\n"), []byte("oops\n"))
+	c.Assert(err, gc.IsNil)
+	err = follower.RefreshOutput()
+	c.Assert(err, gc.IsNil)
+	c.Assert(follower.Output(), jc.DeepEquals, []state.RunOutput{
+		{Data: []byte("one\n")},
+		{Data: []byte("two\n")},
+		{Stderr: true, Data: []byte("oops\n")},
+	})
+
+	err = task.Finish(state.RunTaskResult{Stdout: []byte("one\ntwo\n")})
+	c.Assert(err, gc.IsNil)
+	err = follower.RefreshOutput()
+	c.Assert(err, gc.IsNil)
+	c.Assert(follower.Status(), gc.Equals, state.RunTaskCompleted)
+	c.Assert(follower.Output(), gc.HasLen, 0)
+	c.Assert(follower.Result().Stdout, gc.DeepEquals, []byte("one\ntwo\n"))
+
+	err = job.Remove()
+	c.Assert(err, gc.IsNil)
+	err = follower.RefreshOutput()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *RunJobSuite) TestFailRunningTasks(c *gc.C) {
+	job, err := s.State.AddRunJob("hostname", time.Minute, 2, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks := s.assertStatuses(c, job, state.RunTaskPending, state.RunTaskPending, state.RunTaskQueued)
+	err = tasks[0].Start()
+	c.Assert(err, gc.IsNil)
+	err = tasks[1].Start()
+	c.Assert(err, gc.IsNil)
+
+	// Only the tasks run by the machine's agent are failed, and
+	// finishing them releases the queued task.
+	err = s.machine1.FailRunningTasks("agent restarted")
+	c.Assert(err, gc.IsNil)
+	tasks = s.assertStatuses(c, job, state.RunTaskRunning, state.RunTaskFailed, state.RunTaskPending)
+	c.Assert(tasks[1].Result().Error, gc.Equals, "agent restarted")
+}
+
+func (s *RunJobSuite) TestCleanupOldRunJobs(c *gc.C) {
+	oldJob, err := s.State.AddRunJob("hostname", time.Minute, 0, s.targets())
+	c.Assert(err, gc.IsNil)
+	oldTasks, err := oldJob.Tasks()
+	c.Assert(err, gc.IsNil)
+	cutoff := time.Now().Add(time.Second)
+
+	err = s.State.CleanupOldRunJobs(oldJob.Created())
+	c.Assert(err, gc.IsNil)
+	err = oldJob.Refresh()
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.CleanupOldRunJobs(cutoff)
+	c.Assert(err, gc.IsNil)
+	err = oldJob.Refresh()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	for _, task := range oldTasks {
+		_, err := s.State.RunTask(task.Id())
+		c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	}
+}
+
+func (s *RunJobSuite) TestOutputIsCappedAndStoredOnce(c *gc.C) {
+	s.PatchValue(state.MaxRunOutputSize, 8)
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, s.targets())
+	c.Assert(err, gc.IsNil)
+	tasks, err := job.Tasks()
+	c.Assert(err, gc.IsNil)
+	task := tasks[0]
+	err = task.Start()
+	c.Assert(err, gc.IsNil)
+
+	// Output beyond the cap is dropped.
+	err = task.AppendOutput([]byte("12345"), []byte("678"))
+	c.Assert(err, gc.IsNil)
+	err = task.AppendOutput([]byte("9"), nil)
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Output(), jc.DeepEquals, []state.RunOutput{
+		{Data: []byte("12345")},
+		{Stderr: true, Data: []byte("678")},
+	})
+
+	// Once the task finishes, the output is only held in the result,
+	// truncated in the same way.
+	err = task.Finish(state.RunTaskResult{
+		Stdout: []byte("123456789"),
+		Stderr: []byte("678"),
+	})
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Output(), gc.HasLen, 0)
+	c.Assert(task.Result(), jc.DeepEquals, state.RunTaskResult{
+		Stdout: []byte("12345678"),
+		Stderr: []byte("678"),
+	})
+}
@@ -0,0 +1,507 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+)
+
+// UnitsPerMachineMetric is the name of a metric that may be used in
+// scaling rules in place of a charm metric. Its value is the number of
+// alive units of the service per machine hosting them.
+const UnitsPerMachineMetric = "juju-units-per-machine"
+
+// scalingMetricAge is how recently a metric must have been reported
+// by a unit to be taken into account by a scaling rule.
+const scalingMetricAge = 10 * time.Minute
+
+// ScalingRule describes when a service is scaled according to the
+// value of a metric, averaged over the service's alive units.
+type ScalingRule struct {
+	// Metric holds the key of the charm metric the rule applies to,
+	// or UnitsPerMachineMetric.
+	Metric string
+
+	// Above, if not nil, holds the value above which units are
+	// added.
+	Above *float64 `bson:",omitempty"`
+
+	// Below, if not nil, holds the value below which units are
+	// removed.
+	Below *float64 `bson:",omitempty"`
+
+	// Step holds the number of units added or removed at once. It
+	// defaults to 1.
+	Step int `bson:",omitempty"`
+}
+
+func (r ScalingRule) step() int {
+	if r.Step == 0 {
+		return 1
+	}
+	return r.Step
+}
+
+// ScalingPolicy describes how a service is scaled automatically.
+type ScalingPolicy struct {
+	// Min and Max hold the bounds of the number of alive units of
+	// the service. Min is kept as the service's minimum number of
+	// units; see Service.SetMinUnits.
+	Min int
+	Max int
+
+	// Cooldown holds the time after the service was scaled during
+	// which it is not scaled again.
+	Cooldown time.Duration
+
+	// Placement, if not empty, holds the placement directive used
+	// when adding units, as accepted by "juju add-unit --to". New
+	// machines are used otherwise.
+	Placement string `bson:",omitempty"`
+
+	// Rules holds the rules deciding when units are added or
+	// removed. Units are added if any rule asks for more units, and
+	// removed if every rule able to remove units asks for fewer.
+	Rules []ScalingRule
+}
+
+// Validate returns an error if the policy is not valid.
+func (p ScalingPolicy) Validate() error {
+	if p.Min < 0 {
+		return errors.NotValidf("minimum of %d units", p.Min)
+	}
+	if p.Max < 1 || p.Max < p.Min {
+		return errors.NotValidf("maximum of %d units with minimum of %d units", p.Max, p.Min)
+	}
+	if p.Cooldown < 0 {
+		return errors.NotValidf("negative cooldown")
+	}
+	for _, rule := range p.Rules {
+		if rule.Metric == "" {
+			return errors.NotValidf("scaling rule without metric")
+		}
+		if rule.Above == nil && rule.Below == nil {
+			return errors.NotValidf("scaling rule for %q without thresholds", rule.Metric)
+		}
+		if rule.Above != nil && rule.Below != nil && *rule.Below >= *rule.Above {
+			return errors.NotValidf("scaling rule for %q with lower threshold not below upper threshold", rule.Metric)
+		}
+		if rule.Step < 0 {
+			return errors.NotValidf("scaling rule for %q with negative step", rule.Metric)
+		}
+	}
+	return nil
+}
+
+// Scaling holds a service's scaling policy and the record of the last
+// time the service was scaled because of it.
+type Scaling struct {
+	st  *State
+	doc scalingDoc
+}
+
+type scalingDoc struct {
+	ServiceName string `bson:"_id"`
+	Policy      ScalingPolicy
+	LastScaled  time.Time
+	LastAction  string
+
+	// ReleasingMachines holds the ids of the machines that hosted
+	// units removed by scaling, and that are to be destroyed once
+	// they host no units.
+	ReleasingMachines []string `bson:",omitempty"`
+}
+
+// ServiceName returns the name of the scaled service.
+func (sc *Scaling) ServiceName() string {
+	return sc.doc.ServiceName
+}
+
+// Policy returns the service's scaling policy.
+func (sc *Scaling) Policy() ScalingPolicy {
+	policy := sc.doc.Policy
+	policy.Rules = append([]ScalingRule(nil), policy.Rules...)
+	return policy
+}
+
+// LastScaled returns when the service was last scaled because of its
+// policy, or the zero time if it never was.
+func (sc *Scaling) LastScaled() time.Time {
+	return sc.doc.LastScaled
+}
+
+// LastAction describes how and why the service was last scaled.
+func (sc *Scaling) LastAction() string {
+	return sc.doc.LastAction
+}
+
+// Record records that the service has just been scaled as described
+// by action, which starts the policy's cooldown.
+func (sc *Scaling) Record(action string) error {
+	now := nowToTheSecond()
+	ops := []txn.Op{{
+		C:      scalingC,
+		Id:     sc.doc.ServiceName,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{
+			{"lastscaled", now},
+			{"lastaction", action},
+		}}},
+	}}
+	if err := sc.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("scaling policy of service %q", sc.doc.ServiceName)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot record scaling of service %q", sc.doc.ServiceName)
+	}
+	sc.doc.LastScaled = now
+	sc.doc.LastAction = action
+	return nil
+}
+
+// ReleaseMachines records that the given machines, which host units
+// being removed by scaling, are to be destroyed once they host no
+// units.
+func (sc *Scaling) ReleaseMachines(machineIds []string) error {
+	return sc.updateReleasingMachines(bson.D{{"$addToSet", bson.D{
+		{"releasingmachines", bson.D{{"$each", machineIds}}},
+	}}})
+}
+
+// MachineReleased records that the given machine no longer needs to
+// be destroyed by scaling.
+func (sc *Scaling) MachineReleased(machineId string) error {
+	return sc.updateReleasingMachines(bson.D{{"$pull", bson.D{
+		{"releasingmachines", machineId},
+	}}})
+}
+
+// ReleasingMachines returns the ids of the machines that are to be
+// destroyed once they host no units.
+func (sc *Scaling) ReleasingMachines() []string {
+	return append([]string(nil), sc.doc.ReleasingMachines...)
+}
+
+func (sc *Scaling) updateReleasingMachines(update bson.D) error {
+	ops := []txn.Op{{
+		C:      scalingC,
+		Id:     sc.doc.ServiceName,
+		Assert: txn.DocExists,
+		Update: update,
+	}}
+	if err := sc.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("scaling policy of service %q", sc.doc.ServiceName)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot update machines released by scaling of service %q", sc.doc.ServiceName)
+	}
+	scaling, err := getScaling(sc.st, sc.doc.ServiceName)
+	if err != nil {
+		return err
+	}
+	sc.doc.ReleasingMachines = scaling.doc.ReleasingMachines
+	return nil
+}
+
+// ScalingRuleValue holds the current value of a scaling rule's
+// metric.
+type ScalingRuleValue struct {
+	Rule ScalingRule
+	// Known is false if no recent value of the metric is available,
+	// in which case the rule is ignored.
+	Known bool
+	Value float64
+}
+
+// ScalingEvaluation holds the outcome of evaluating a scaling policy.
+type ScalingEvaluation struct {
+	// Units holds the number of alive units of the service.
+	Units int
+	// Desired holds the number of units the service should have.
+	Desired int
+	// Reason describes why Desired differs from Units, if it does.
+	Reason string
+	// Cooldown is true if the service was scaled too recently to be
+	// scaled again.
+	Cooldown bool
+	Values   []ScalingRuleValue
+}
+
+// Evaluate returns the number of units the service should have at the
+// given time according to its scaling policy. The number of units is
+// never changed by more than the largest step of the rules that apply,
+// and is kept within the policy's bounds; units missing to reach the
+// minimum are left to be added by the minimum units worker.
+func (sc *Scaling) Evaluate(now time.Time) (*ScalingEvaluation, error) {
+	service, err := sc.st.Service(sc.doc.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	units, err := service.AllUnits()
+	if err != nil {
+		return nil, err
+	}
+	var alive []string
+	machines := make(map[string]bool)
+	for _, unit := range units {
+		if unit.Life() == Alive {
+			alive = append(alive, unit.Name())
+			if unit.doc.MachineId != "" {
+				machines[unit.doc.MachineId] = true
+			}
+		}
+	}
+	policy := sc.doc.Policy
+	eval := &ScalingEvaluation{
+		Units:   len(alive),
+		Desired: len(alive),
+	}
+	eval.Values, err = sc.ruleValues(alive, len(machines), now)
+	if err != nil {
+		return nil, err
+	}
+	if eval.Units > policy.Max {
+		eval.Desired = policy.Max
+		eval.Reason = fmt.Sprintf("above maximum of %d units", policy.Max)
+		return eval, nil
+	}
+	if !sc.doc.LastScaled.IsZero() && now.Before(sc.doc.LastScaled.Add(policy.Cooldown)) {
+		eval.Cooldown = true
+		return eval, nil
+	}
+	up, down := 0, 0
+	var upReason, downReason string
+	voteDown := true
+	for _, v := range eval.Values {
+		if !v.Known {
+			continue
+		}
+		rule := v.Rule
+		if rule.Above != nil && v.Value > *rule.Above && rule.step() > up {
+			up = rule.step()
+			upReason = fmt.Sprintf("%s is %g, above %g", rule.Metric, v.Value, *rule.Above)
+		}
+		if rule.Below == nil {
+			continue
+		}
+		if v.Value >= *rule.Below {
+			voteDown = false
+		} else if rule.step() > down {
+			down = rule.step()
+			downReason = fmt.Sprintf("%s is %g, below %g", rule.Metric, v.Value, *rule.Below)
+		}
+	}
+	switch {
+	case up > 0:
+		eval.Desired = eval.Units + up
+		if eval.Desired > policy.Max {
+			eval.Desired = policy.Max
+		}
+		eval.Reason = upReason
+	case down > 0 && voteDown:
+		eval.Desired = eval.Units - down
+		if eval.Desired < policy.Min {
+			eval.Desired = policy.Min
+		}
+		if eval.Desired > eval.Units {
+			// Missing units are added by the minimum units worker.
+			eval.Desired = eval.Units
+		}
+		eval.Reason = downReason
+	}
+	if eval.Desired == eval.Units {
+		eval.Reason = ""
+	}
+	return eval, nil
+}
+
+// ruleValues returns the current values of the policy's rules for the
+// given alive units, which are hosted on the given number of machines.
+func (sc *Scaling) ruleValues(unitNames []string, machines int, now time.Time) ([]ScalingRuleValue, error) {
+	metrics, err := sc.st.latestUnitMetrics(unitNames, now.Add(-scalingMetricAge))
+	if err != nil {
+		return nil, err
+	}
+	values := make([]ScalingRuleValue, len(sc.doc.Policy.Rules))
+	for i, rule := range sc.doc.Policy.Rules {
+		values[i].Rule = rule
+		if rule.Metric == UnitsPerMachineMetric {
+			if machines > 0 {
+				values[i].Known = true
+				values[i].Value = float64(len(unitNames)) / float64(machines)
+			}
+			continue
+		}
+		var total float64
+		var count int
+		for _, unitMetrics := range metrics {
+			if value, ok := unitMetrics[rule.Metric]; ok {
+				total += value
+				count++
+			}
+		}
+		if count > 0 {
+			values[i].Known = true
+			values[i].Value = total / float64(count)
+		}
+	}
+	return values, nil
+}
+
+// latestUnitMetrics returns, for each of the named units, the latest
+// value of each numeric metric it reported since the given time.
+func (st *State) latestUnitMetrics(unitNames []string, since time.Time) (map[string]map[string]float64, error) {
+	metrics, closer := st.getCollection(metricsC)
+	defer closer()
+
+	query := bson.D{
+		{"unit", bson.D{{"$in", unitNames}}},
+		{"created", bson.D{{"$gte", since}}},
+	}
+	var docs []metricBatchDoc
+	if err := metrics.Find(query).Sort("created").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get metrics")
+	}
+	result := make(map[string]map[string]float64)
+	latest := make(map[string]map[string]time.Time)
+	for _, doc := range docs {
+		if result[doc.Unit] == nil {
+			result[doc.Unit] = make(map[string]float64)
+			latest[doc.Unit] = make(map[string]time.Time)
+		}
+		for _, metric := range doc.Metrics {
+			value, err := strconv.ParseFloat(metric.Value, 64)
+			if err != nil || metric.Time.Before(since) {
+				continue
+			}
+			if t, ok := latest[doc.Unit][metric.Key]; ok && metric.Time.Before(t) {
+				continue
+			}
+			result[doc.Unit][metric.Key] = value
+			latest[doc.Unit][metric.Key] = metric.Time
+		}
+	}
+	return result, nil
+}
+
+// Scaling returns the service's scaling policy. It returns an error
+// satisfying errors.IsNotFound if the service is not scaled
+// automatically.
+func (s *Service) Scaling() (*Scaling, error) {
+	return getScaling(s.st, s.doc.Name)
+}
+
+func getScaling(st *State, serviceName string) (*Scaling, error) {
+	scaling, closer := st.getCollection(scalingC)
+	defer closer()
+
+	doc := scalingDoc{}
+	err := scaling.FindId(serviceName).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("scaling policy of service %q", serviceName)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get scaling policy of service %q", serviceName)
+	}
+	return &Scaling{st, doc}, nil
+}
+
+// AllScalings returns the scaling policies of all services.
+func (st *State) AllScalings() ([]*Scaling, error) {
+	scaling, closer := st.getCollection(scalingC)
+	defer closer()
+
+	docs := []scalingDoc{}
+	if err := scaling.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get scaling policies")
+	}
+	result := make([]*Scaling, len(docs))
+	for i, doc := range docs {
+		result[i] = &Scaling{st, doc}
+	}
+	return result, nil
+}
+
+// SetScalingPolicy sets the policy by which the service is scaled
+// automatically. The service's minimum number of units is set to the
+// policy's minimum.
+func (s *Service) SetScalingPolicy(policy ScalingPolicy) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set scaling policy of service %q", s)
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if s.doc.Subordinate {
+		return errors.New("service is subordinate")
+	}
+	service := &Service{st: s.st, doc: s.doc}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := service.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if service.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		var ops []txn.Op
+		if policy.Min != service.doc.MinUnits {
+			ops = setMinUnitsOps(service, policy.Min)
+		} else {
+			ops = []txn.Op{{
+				C:      servicesC,
+				Id:     service.doc.Name,
+				Assert: isAliveDoc,
+			}}
+		}
+		if _, err := service.Scaling(); errors.IsNotFound(err) {
+			ops = append(ops, txn.Op{
+				C:      scalingC,
+				Id:     service.doc.Name,
+				Assert: txn.DocMissing,
+				Insert: &scalingDoc{
+					ServiceName: service.doc.Name,
+					Policy:      policy,
+				},
+			})
+		} else if err != nil {
+			return nil, err
+		} else {
+			ops = append(ops, txn.Op{
+				C:      scalingC,
+				Id:     service.doc.Name,
+				Assert: txn.DocExists,
+				Update: bson.D{{"$set", bson.D{{"policy", policy}}}},
+			})
+		}
+		return ops, nil
+	}
+	if err := s.st.run(buildTxn); err != nil {
+		return err
+	}
+	s.doc.MinUnits = policy.Min
+	return nil
+}
+
+// RemoveScalingPolicy stops the service from being scaled
+// automatically. The service's minimum number of units is left
+// unchanged.
+func (s *Service) RemoveScalingPolicy() error {
+	ops := []txn.Op{{
+		C:      scalingC,
+		Id:     s.doc.Name,
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	if err := s.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.NotFoundf("scaling policy of service %q", s.doc.Name)
+	} else if err != nil {
+		return errors.Annotatef(err, "cannot remove scaling policy of service %q", s.doc.Name)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type ScalingSuite struct {
+	ConnSuite
+	charm   *state.Charm
+	service *state.Service
+	units   []*state.Unit
+}
+
+var _ = gc.Suite(&ScalingSuite{})
+
+func (s *ScalingSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	s.service = s.AddTestingService(c, "wordpress", s.charm)
+	s.units = nil
+	for i := 0; i < 2; i++ {
+		s.addUnit(c)
+	}
+}
+
+func (s *ScalingSuite) addUnit(c *gc.C) *state.Unit {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetCharmURL(s.charm.URL())
+	c.Assert(err, gc.IsNil)
+	s.units = append(s.units, unit)
+	return unit
+}
+
+func (s *ScalingSuite) addMetric(c *gc.C, unit *state.Unit, key, value string) {
+	now := time.Now()
+	_, err := unit.AddMetrics(now, []state.Metric{{Key: key, Value: value, Time: now}})
+	c.Assert(err, gc.IsNil)
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+var loadPolicy = state.ScalingPolicy{
+	Min:      1,
+	Max:      4,
+	Cooldown: time.Minute,
+	Rules: []state.ScalingRule{{
+		Metric: "load",
+		Above:  float(0.8),
+		Below:  float(0.2),
+		Step:   2,
+	}},
+}
+
+func (s *ScalingSuite) TestSetScalingPolicy(c *gc.C) {
+	_, err := s.service.Scaling()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	err = s.service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.MinUnits(), gc.Equals, 1)
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.ServiceName(), gc.Equals, "wordpress")
+	c.Assert(scaling.Policy(), jc.DeepEquals, loadPolicy)
+	c.Assert(scaling.LastScaled().IsZero(), jc.IsTrue)
+
+	policy := loadPolicy
+	policy.Min, policy.Max, policy.Placement = 2, 3, "lxc:0"
+	err = s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.MinUnits(), gc.Equals, 2)
+	scalings, err := s.State.AllScalings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scalings, gc.HasLen, 1)
+	c.Assert(scalings[0].Policy(), jc.DeepEquals, policy)
+
+	err = s.service.RemoveScalingPolicy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.Scaling()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = s.service.RemoveScalingPolicy()
+	c.Assert(err, gc.ErrorMatches, `scaling policy of service "wordpress" not found`)
+	c.Assert(s.service.MinUnits(), gc.Equals, 2)
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyInvalid(c *gc.C) {
+	for i, t := range []struct {
+		policy state.ScalingPolicy
+		err    string
+	}{{
+		policy: state.ScalingPolicy{Min: -1, Max: 1},
+		err:    "minimum of -1 units not valid",
+	}, {
+		policy: state.ScalingPolicy{Min: 3, Max: 2},
+		err:    "maximum of 2 units with minimum of 3 units not valid",
+	}, {
+		policy: state.ScalingPolicy{Max: 2, Cooldown: -time.Second},
+		err:    "negative cooldown not valid",
+	}, {
+		policy: state.ScalingPolicy{Max: 2, Rules: []state.ScalingRule{{Above: float(1)}}},
+		err:    "scaling rule without metric not valid",
+	}, {
+		policy: state.ScalingPolicy{Max: 2, Rules: []state.ScalingRule{{Metric: "load"}}},
+		err:    `scaling rule for "load" without thresholds not valid`,
+	}, {
+		policy: state.ScalingPolicy{Max: 2, Rules: []state.ScalingRule{{Metric: "load", Above: float(1), Below: float(1)}}},
+		err:    `scaling rule for "load" with lower threshold not below upper threshold not valid`,
+	}} {
+		c.Logf("test %d", i)
+		err := s.service.SetScalingPolicy(t.policy)
+		c.Check(err, gc.ErrorMatches, `cannot set scaling policy of service "wordpress": `+t.err)
+	}
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyServiceNotAlive(c *gc.C) {
+	err := s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy of service "wordpress": not found or not alive`)
+}
+
+func (s *ScalingSuite) TestScalingRemovedWithService(c *gc.C) {
+	service := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	err := service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.IsNil)
+	err = service.Destroy()
+	c.Assert(err, gc.IsNil)
+	scalings, err := s.State.AllScalings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scalings, gc.HasLen, 0)
+}
+
+func (s *ScalingSuite) evaluate(c *gc.C, t time.Time) *state.ScalingEvaluation {
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	eval, err := scaling.Evaluate(t)
+	c.Assert(err, gc.IsNil)
+	return eval
+}
+
+func (s *ScalingSuite) TestEvaluateMetrics(c *gc.C) {
+	err := s.service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.IsNil)
+
+	// Without metrics, nothing changes.
+	eval := s.evaluate(c, time.Now())
+	c.Assert(eval.Units, gc.Equals, 2)
+	c.Assert(eval.Desired, gc.Equals, 2)
+	c.Assert(eval.Values, gc.HasLen, 1)
+	c.Assert(eval.Values[0].Known, jc.IsFalse)
+
+	// Only the latest value reported by each unit counts.
+	s.addMetric(c, s.units[0], "load", "0.1")
+	s.addMetric(c, s.units[0], "load", "0.9")
+	s.addMetric(c, s.units[1], "load", "1.0")
+	s.addMetric(c, s.units[1], "other", "12")
+	eval = s.evaluate(c, time.Now())
+	c.Assert(eval.Values[0].Known, jc.IsTrue)
+	c.Assert(eval.Values[0].Value, gc.Equals, 0.95)
+	c.Assert(eval.Desired, gc.Equals, 4)
+	c.Assert(eval.Reason, gc.Equals, "load is 0.95, above 0.8")
+
+	// Metrics reported too long ago are ignored.
+	eval = s.evaluate(c, time.Now().Add(time.Hour))
+	c.Assert(eval.Values[0].Known, jc.IsFalse)
+	c.Assert(eval.Desired, gc.Equals, 2)
+
+	s.addMetric(c, s.units[0], "load", "0.1")
+	s.addMetric(c, s.units[1], "load", "0.1")
+	eval = s.evaluate(c, time.Now())
+	c.Assert(eval.Desired, gc.Equals, 1)
+	c.Assert(eval.Reason, gc.Equals, "load is 0.1, below 0.2")
+}
+
+func (s *ScalingSuite) TestEvaluateCooldown(c *gc.C) {
+	err := s.service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.IsNil)
+	s.addMetric(c, s.units[0], "load", "2")
+
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	err = scaling.Record("added 2 units")
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.LastAction(), gc.Equals, "added 2 units")
+	c.Assert(scaling.LastScaled().IsZero(), jc.IsFalse)
+
+	eval := s.evaluate(c, time.Now())
+	c.Assert(eval.Cooldown, jc.IsTrue)
+	c.Assert(eval.Desired, gc.Equals, 2)
+
+	eval = s.evaluate(c, time.Now().Add(2*time.Minute))
+	c.Assert(eval.Cooldown, jc.IsFalse)
+	c.Assert(eval.Desired, gc.Equals, 4)
+}
+
+func (s *ScalingSuite) TestEvaluateBounds(c *gc.C) {
+	policy := loadPolicy
+	policy.Max = 3
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.IsNil)
+	s.addMetric(c, s.units[0], "load", "2")
+	eval := s.evaluate(c, time.Now())
+	c.Assert(eval.Desired, gc.Equals, 3)
+
+	for i := 0; i < 3; i++ {
+		s.addUnit(c)
+	}
+	eval = s.evaluate(c, time.Now())
+	c.Assert(eval.Units, gc.Equals, 5)
+	c.Assert(eval.Desired, gc.Equals, 3)
+	c.Assert(eval.Reason, gc.Equals, "above maximum of 3 units")
+}
+
+func (s *ScalingSuite) TestEvaluateUnitsPerMachine(c *gc.C) {
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Max: 10,
+		Rules: []state.ScalingRule{{
+			Metric: state.UnitsPerMachineMetric,
+			Above:  float(1),
+		}},
+	})
+	c.Assert(err, gc.IsNil)
+	eval := s.evaluate(c, time.Now())
+	c.Assert(eval.Values[0].Known, jc.IsFalse)
+
+	// Only the machines hosting the service's units are counted.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	for _, unit := range s.units {
+		err = unit.AssignToMachine(machine)
+		c.Assert(err, gc.IsNil)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+	}
+	eval = s.evaluate(c, time.Now())
+	c.Assert(eval.Values[0].Value, gc.Equals, 2.0)
+	c.Assert(eval.Desired, gc.Equals, 3)
+	c.Assert(eval.Reason, gc.Equals, fmt.Sprintf("%s is 2, above 1", state.UnitsPerMachineMetric))
+}
+
+func (s *ScalingSuite) TestReleaseMachines(c *gc.C) {
+	err := s.service.SetScalingPolicy(loadPolicy)
+	c.Assert(err, gc.IsNil)
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.ReleasingMachines(), gc.HasLen, 0)
+
+	err = scaling.ReleaseMachines([]string{"1", "2"})
+	c.Assert(err, gc.IsNil)
+	err = scaling.ReleaseMachines([]string{"2", "3"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.ReleasingMachines(), jc.SameContents, []string{"1", "2", "3"})
+
+	err = scaling.MachineReleased("2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.ReleasingMachines(), jc.SameContents, []string{"1", "3"})
+
+	scaling, err = s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.ReleasingMachines(), jc.SameContents, []string{"1", "3"})
+}
@@ -44,7 +44,20 @@ type serviceDoc struct {
 	Exposed       bool
 	MinUnits      int
 	OwnerTag      string
-	TxnRevno      int64 `bson:"txn-revno"`
+	HasResources  bool
+	// EndpointBindings maps the names of the charm's endpoints to
+	// the spaces they are bound to. The empty name holds the
+	// default space for endpoints that are not bound explicitly.
+	EndpointBindings map[string]string `bson:",omitempty"`
+	// RollingUpgradeSeq is incremented whenever a rolling charm
+	// upgrade admits a batch of units, so that watchers of the
+	// service notice that those units may now upgrade.
+	RollingUpgradeSeq int `bson:",omitempty"`
+	// UpgradePolicy and UpgradeWindow describe when the charm is
+	// upgraded automatically; see SetUpgradePolicy.
+	UpgradePolicy CharmUpgradePolicy `bson:",omitempty"`
+	UpgradeWindow *MaintenanceWindow `bson:",omitempty"`
+	TxnRevno      int64              `bson:"txn-revno"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -212,10 +225,16 @@ func (s *Service) destroyOps() ([]txn.Op, error) {
 // removeOps returns the operations required to remove the service. Supplied
 // asserts will be included in the operation on the service document.
 func (s *Service) removeOps(asserts bson.D) []txn.Op {
+	// Resources are only cleaned up when some have been attached, so
+	// the removal must fail if one is attached behind our backs.
+	hasResources := bson.D{{"hasresources", bson.D{{"$ne", true}}}}
+	if s.doc.HasResources {
+		hasResources = bson.D{{"hasresources", true}}
+	}
 	ops := []txn.Op{{
 		C:      servicesC,
 		Id:     s.doc.Name,
-		Assert: asserts,
+		Assert: append(hasResources, asserts...),
 		Remove: true,
 	}, {
 		C:      settingsrefsC,
@@ -225,9 +244,21 @@ func (s *Service) removeOps(asserts bson.D) []txn.Op {
 		C:      settingsC,
 		Id:     s.settingsKey(),
 		Remove: true,
-	}}
+	}, charmDecRefOp(s.doc.CharmURL)}
 	ops = append(ops, removeRequestedNetworksOp(s.st, s.globalKey()))
 	ops = append(ops, removeConstraintsOp(s.st, s.globalKey()))
+	ops = append(ops, txn.Op{
+		C:      rollingUpgradesC,
+		Id:     s.doc.Name,
+		Remove: true,
+	}, txn.Op{
+		C:      scalingC,
+		Id:     s.doc.Name,
+		Remove: true,
+	})
+	if s.doc.HasResources {
+		ops = append(ops, s.st.newCleanupOp(cleanupServiceResources, s.doc.Name))
+	}
 	return append(ops, annotationRemoveOp(s.st, s.globalKey()))
 }
 
@@ -401,7 +432,7 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 	}
 
 	// Add or create a reference to the new settings doc.
-	incOp, err := settingsIncRefOp(s.st, s.doc.Name, ch.URL(), true)
+	incOps, err := settingsIncRefOps(s.st, s.doc.Name, ch.URL(), true)
 	if err != nil {
 		return nil, err
 	}
@@ -418,16 +449,16 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 		oldSettings.assertUnchangedOp(),
 		// Create/replace with new settings.
 		settingsOp,
-		// Increment the ref count.
-		incOp,
-		// Update the charm URL and force flag (if relevant).
-		{
-			C:      servicesC,
-			Id:     s.doc.Name,
-			Assert: append(isAliveDoc, differentCharm...),
-			Update: bson.D{{"$set", bson.D{{"charmurl", ch.URL()}, {"forcecharm", force}}}},
-		},
 	}
+	// Increment the ref count.
+	ops = append(ops, incOps...)
+	// Update the charm URL and force flag (if relevant).
+	ops = append(ops, txn.Op{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Assert: append(isAliveDoc, differentCharm...),
+		Update: bson.D{{"$set", bson.D{{"charmurl", ch.URL()}, {"forcecharm", force}}}},
+	})
 	// Add any extra peer relations that need creation.
 	newPeers := s.extraPeerRelations(ch.Meta())
 	peerOps, err := s.st.addPeerRelationsOps(s.doc.Name, newPeers)
@@ -464,8 +495,16 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 
 // SetCharm changes the charm for the service. New units will be started with
 // this charm, and existing units will be upgraded to use it. If force is true,
-// units will be upgraded even if they are in an error state.
+// units will be upgraded even if they are in an error state. Any rolling
+// upgrade of the service is abandoned, so all units upgrade at once.
 func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
+	return s.setCharm(ch, force, 0)
+}
+
+// setCharm changes the charm for the service. Unless batchSize is zero,
+// existing units are upgraded in batches of that size. The given extra
+// operations are run in the same transaction.
+func (s *Service) setCharm(ch *Charm, force bool, batchSize int, extraOps ...txn.Op) (err error) {
 	services, closer := s.st.getCollection(servicesC)
 	defer closer()
 	settings := services.Database.C(settingsC)
@@ -507,7 +546,12 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 				return nil, err
 			}
 		}
-		return ops, nil
+		rollingOps, err := s.rollingUpgradeOps(ch.URL(), batchSize)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, rollingOps...)
+		return append(ops, extraOps...), nil
 	}
 	if err = s.st.run(buildTxn); err == nil {
 		s.doc.CharmURL = ch.URL()
@@ -853,40 +897,107 @@ func (s *Service) Networks() ([]string, error) {
 	return readRequestedNetworks(s.st, s.globalKey())
 }
 
-// settingsIncRefOp returns an operation that increments the ref count
-// of the service settings identified by serviceName and curl. If
+// EndpointBindings returns a map from the names of the service's
+// charm endpoints to the spaces they are bound to. The empty name, if
+// present, maps to the space used for any endpoint not listed.
+func (s *Service) EndpointBindings() map[string]string {
+	bindings := make(map[string]string)
+	for endpoint, space := range s.doc.EndpointBindings {
+		bindings[endpoint] = space
+	}
+	return bindings
+}
+
+// SetEndpointBindings binds the service's charm endpoints to spaces,
+// replacing any existing bindings. Each key must be the name of one
+// of the service's endpoints, or the empty string to bind all other
+// endpoints, and each value must be the name of an existing space.
+func (s *Service) SetEndpointBindings(bindings map[string]string) (err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot set endpoint bindings for service %q", s)
+	eps, err := s.Endpoints()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, ep := range eps {
+		known[ep.Name] = true
+	}
+	ops := []txn.Op{{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Assert: bson.D{{"life", Alive}, {"charmurl", s.doc.CharmURL}},
+		Update: bson.D{{"$set", bson.D{{"endpointbindings", bindings}}}},
+	}}
+	for endpoint, space := range bindings {
+		if endpoint != "" && !known[endpoint] {
+			return errors.Errorf("charm has no endpoint %q", endpoint)
+		}
+		if _, err := s.st.Space(space); err != nil {
+			return err
+		}
+		ops = append(ops, txn.Op{
+			C:      spacesC,
+			Id:     space,
+			Assert: txn.DocExists,
+		})
+	}
+	if err := s.st.runTransaction(ops); err == txn.ErrAborted {
+		return errors.Errorf("service or spaces changed")
+	} else if err != nil {
+		return err
+	}
+	s.doc.EndpointBindings = make(map[string]string)
+	for endpoint, space := range bindings {
+		s.doc.EndpointBindings[endpoint] = space
+	}
+	return nil
+}
+
+// boundSpace returns the name of the space the named endpoint is
+// bound to, or the empty string if it is not bound.
+func (s *Service) boundSpace(endpoint string) string {
+	if space, ok := s.doc.EndpointBindings[endpoint]; ok {
+		return space
+	}
+	return s.doc.EndpointBindings[""]
+}
+
+// settingsIncRefOps returns the operations that increment the ref
+// count of the service settings identified by serviceName and curl. If
 // canCreate is false, a missing document will be treated as an error;
-// otherwise, it will be created with a ref count of 1.
-func settingsIncRefOp(st *State, serviceName string, curl *charm.URL, canCreate bool) (txn.Op, error) {
+// otherwise, it will be created with a ref count of 1, and the ref
+// count of the charm itself will be incremented.
+func settingsIncRefOps(st *State, serviceName string, curl *charm.URL, canCreate bool) ([]txn.Op, error) {
 	settingsrefs, closer := st.getCollection(settingsrefsC)
 	defer closer()
 
 	key := serviceSettingsKey(serviceName, curl)
 	if count, err := settingsrefs.FindId(key).Count(); err != nil {
-		return txn.Op{}, err
+		return nil, err
 	} else if count == 0 {
 		if !canCreate {
-			return txn.Op{}, errors.NotFoundf("service settings")
+			return nil, errors.NotFoundf("service settings")
 		}
-		return txn.Op{
+		return []txn.Op{{
 			C:      settingsrefsC,
 			Id:     key,
 			Assert: txn.DocMissing,
 			Insert: settingsRefsDoc{1},
-		}, nil
+		}, charmIncRefOp(curl)}, nil
 	}
-	return txn.Op{
+	return []txn.Op{{
 		C:      settingsrefsC,
 		Id:     key,
 		Assert: txn.DocExists,
 		Update: bson.D{{"$inc", bson.D{{"refcount", 1}}}},
-	}, nil
+	}}, nil
 }
 
 // settingsDecRefOps returns a list of operations that decrement the
 // ref count of the service settings identified by serviceName and
 // curl. If the ref count is set to zero, the appropriate setting and
-// ref count documents will both be deleted.
+// ref count documents will both be deleted, and the ref count of the
+// charm itself will be decremented.
 func settingsDecRefOps(st *State, serviceName string, curl *charm.URL) ([]txn.Op, error) {
 	settingsrefs, closer := st.getCollection(settingsrefsC)
 	defer closer()
@@ -908,7 +1019,7 @@ func settingsDecRefOps(st *State, serviceName string, curl *charm.URL) ([]txn.Op
 			C:      settingsC,
 			Id:     key,
 			Remove: true,
-		}}, nil
+		}, charmDecRefOp(curl)}, nil
 	}
 	return []txn.Op{{
 		C:      settingsrefsC,
@@ -918,6 +1029,27 @@ func settingsDecRefOps(st *State, serviceName string, curl *charm.URL) ([]txn.Op
 	}}, nil
 }
 
+// charmIncRefOp returns an operation that increments the ref count
+// of the charm with the given URL, which must exist.
+func charmIncRefOp(curl *charm.URL) txn.Op {
+	return txn.Op{
+		C:      charmsC,
+		Id:     curl.String(),
+		Assert: txn.DocExists,
+		Update: bson.D{{"$inc", bson.D{{"refcount", 1}}}},
+	}
+}
+
+// charmDecRefOp returns an operation that decrements the ref count
+// of the charm with the given URL.
+func charmDecRefOp(curl *charm.URL) txn.Op {
+	return txn.Op{
+		C:      charmsC,
+		Id:     curl.String(),
+		Update: bson.D{{"$inc", bson.D{{"refcount", -1}}}},
+	}
+}
+
 // settingsRefsDoc holds the number of units and services using the
 // settings document identified by the document's id. Every time a
 // service upgrades its charm the settings doc ref count for the new
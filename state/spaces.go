@@ -0,0 +1,189 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
+)
+
+// Space represents a network space: a named set of subnets, such as
+// all the subnets of a DMZ, to which charm endpoints can be bound.
+type Space struct {
+	st  *State
+	doc spaceDoc
+}
+
+type spaceDoc struct {
+	Name string `bson:"_id"`
+}
+
+var validSpaceName = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+
+// IsValidSpaceName returns whether name is a valid space name.
+func IsValidSpaceName(name string) bool {
+	return validSpaceName.MatchString(name)
+}
+
+// Name returns the name of the space.
+func (s *Space) Name() string {
+	return s.doc.Name
+}
+
+// Subnets returns the subnets in the space, ordered by CIDR.
+func (s *Space) Subnets() ([]*Subnet, error) {
+	return s.st.subnets(bson.D{{"spacename", s.doc.Name}})
+}
+
+// Addresses returns those of the given addresses that are within
+// one of the space's subnets.
+func (s *Space) Addresses(addresses []network.Address) ([]network.Address, error) {
+	subnets, err := s.Subnets()
+	if err != nil {
+		return nil, err
+	}
+	var result []network.Address
+	for _, addr := range addresses {
+		for _, subnet := range subnets {
+			if subnet.Contains(addr.Value) {
+				result = append(result, addr)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// AddSpace creates a new space with the given name, containing the
+// subnets with the given CIDRs. The subnets must already exist and
+// must not belong to another space. If a space with the same name
+// already exists, an error satisfying errors.IsAlreadyExists is
+// returned.
+func (st *State) AddSpace(name string, subnets []string) (space *Space, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add space %q", name)
+	if !IsValidSpaceName(name) {
+		return nil, errors.Errorf("invalid name")
+	}
+	doc := &spaceDoc{Name: name}
+	ops := []txn.Op{{
+		C:      spacesC,
+		Id:     name,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	for _, cidr := range subnets {
+		subnet, err := st.Subnet(cidr)
+		if err != nil {
+			return nil, err
+		}
+		if subnet.SpaceName() != "" {
+			return nil, errors.Errorf("subnet %q is already in space %q", subnet.CIDR(), subnet.SpaceName())
+		}
+		ops = append(ops, txn.Op{
+			C:      subnetsC,
+			Id:     subnet.CIDR(),
+			Assert: bson.D{{"spacename", ""}},
+			Update: bson.D{{"$set", bson.D{{"spacename", name}}}},
+		})
+	}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if _, err := st.Space(name); err == nil {
+			return nil, errors.AlreadyExistsf("space %q", name)
+		}
+		return nil, errors.Errorf("subnets changed while adding space")
+	} else if err != nil {
+		return nil, err
+	}
+	return &Space{st, *doc}, nil
+}
+
+// Space returns the space with the given name.
+func (st *State) Space(name string) (*Space, error) {
+	spaces, closer := st.getCollection(spacesC)
+	defer closer()
+
+	doc := &spaceDoc{}
+	err := spaces.FindId(name).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("space %q", name)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get space %q", name)
+	}
+	return &Space{st, *doc}, nil
+}
+
+// AllSpaces returns all spaces in the environment, ordered by name.
+func (st *State) AllSpaces() ([]*Space, error) {
+	spacesCollection, closer := st.getCollection(spacesC)
+	defer closer()
+
+	docs := []spaceDoc{}
+	err := spacesCollection.Find(nil).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get all spaces")
+	}
+	spaces := make([]*Space, len(docs))
+	for i, doc := range docs {
+		spaces[i] = &Space{st, doc}
+	}
+	return spaces, nil
+}
+
+// spaceNames returns the names of all spaces in the environment.
+func (st *State) spaceNames() ([]string, error) {
+	spaces, err := st.AllSpaces()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(spaces))
+	for i, space := range spaces {
+		names[i] = space.Name()
+	}
+	return names, nil
+}
+
+// ResolveSpaceConstraints returns cons with any spaces constraint
+// translated into a zones constraint, so that providers which place
+// instances by availability zone start them where the subnets of the
+// requested spaces are. The zones are those of all the subnets in
+// the requested spaces; cons is returned unchanged if it already has
+// a zones constraint. An error is returned if none of the subnets has
+// a known zone, as the spaces constraint could not then be honoured.
+func (st *State) ResolveSpaceConstraints(cons constraints.Value) (constraints.Value, error) {
+	if !cons.HaveSpaces() || cons.HaveZones() {
+		return cons, nil
+	}
+	subnets, err := st.subnets(bson.D{{"spacename", bson.D{{"$in", *cons.Spaces}}}})
+	if err != nil {
+		return cons, err
+	}
+	seen := make(map[string]bool)
+	var zones []string
+	for _, subnet := range subnets {
+		zone := subnet.AvailabilityZone()
+		if zone != "" && !seen[zone] {
+			seen[zone] = true
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		return cons, errors.Errorf(
+			"cannot resolve spaces constraint: no subnet in spaces %q has a known availability zone",
+			strings.Join(*cons.Spaces, ","),
+		)
+	}
+	sort.Strings(zones)
+	cons.Zones = &zones
+	return cons, nil
+}
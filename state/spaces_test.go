@@ -0,0 +1,172 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type SpaceSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&SpaceSuite{})
+
+func (s *SpaceSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	for _, info := range []state.SubnetInfo{
+		{CIDR: "10.0.1.0/24", AvailabilityZone: "zone2"},
+		{CIDR: "10.0.2.0/24", AvailabilityZone: "zone1"},
+		{CIDR: "10.0.3.0/24", AvailabilityZone: "zone2"},
+		{CIDR: "192.168.0.0/24"},
+	} {
+		_, err := s.State.AddSubnet(info)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *SpaceSuite) TestAddSpace(c *gc.C) {
+	space, err := s.State.AddSpace("dmz", []string{"10.0.1.0/24", "10.0.2.0/24"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(space.Name(), gc.Equals, "dmz")
+
+	space, err = s.State.Space("dmz")
+	c.Assert(err, gc.IsNil)
+	subnets, err := space.Subnets()
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnets, gc.HasLen, 2)
+	c.Assert(subnets[0].CIDR(), gc.Equals, "10.0.1.0/24")
+	c.Assert(subnets[0].SpaceName(), gc.Equals, "dmz")
+	c.Assert(subnets[1].CIDR(), gc.Equals, "10.0.2.0/24")
+
+	_, err = s.State.AddSpace("empty", nil)
+	c.Assert(err, gc.IsNil)
+	spaces, err := s.State.AllSpaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(spaces, gc.HasLen, 2)
+	c.Assert(spaces[0].Name(), gc.Equals, "dmz")
+	c.Assert(spaces[1].Name(), gc.Equals, "empty")
+}
+
+func (s *SpaceSuite) TestAddSpaceErrors(c *gc.C) {
+	_, err := s.State.AddSpace("Bad_Name", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "Bad_Name": invalid name`)
+
+	_, err = s.State.AddSpace("dmz", []string{"10.9.9.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "dmz": subnet "10.9.9.0/24" not found`)
+
+	_, err = s.State.AddSpace("dmz", []string{"10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddSpace("dmz", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add space "dmz": space "dmz" already exists`)
+	c.Assert(errors.Cause(err), jc.Satisfies, errors.IsAlreadyExists)
+
+	_, err = s.State.AddSpace("other", []string{"10.0.1.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add space "other": subnet "10.0.1.0/24" is already in space "dmz"`)
+}
+
+func (s *SpaceSuite) TestSpaceNotFound(c *gc.C) {
+	_, err := s.State.Space("dmz")
+	c.Assert(err, gc.ErrorMatches, `space "dmz" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *SpaceSuite) TestAddresses(c *gc.C) {
+	space, err := s.State.AddSpace("dmz", []string{"10.0.1.0/24", "10.0.2.0/24"})
+	c.Assert(err, gc.IsNil)
+	addresses, err := space.Addresses(network.NewAddresses("10.0.2.5", "192.168.0.5", "10.0.1.5"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, jc.DeepEquals, network.NewAddresses("10.0.2.5", "10.0.1.5"))
+}
+
+func (s *SpaceSuite) TestResolveSpaceConstraints(c *gc.C) {
+	_, err := s.State.AddSpace("dmz", []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddSpace("internal", []string{"192.168.0.0/24"})
+	c.Assert(err, gc.IsNil)
+
+	for i, t := range []struct {
+		cons     string
+		expected string
+	}{
+		{"mem=4G", "mem=4G"},
+		{"spaces=dmz", "spaces=dmz zones=zone1,zone2"},
+		{"spaces=dmz zones=zone3", "spaces=dmz zones=zone3"},
+	} {
+		c.Logf("test %d: %s", i, t.cons)
+		cons, err := s.State.ResolveSpaceConstraints(constraints.MustParse(t.cons))
+		c.Check(err, gc.IsNil)
+		c.Check(cons, jc.DeepEquals, constraints.MustParse(t.expected))
+	}
+
+	// The subnets of the internal space have no known zone.
+	_, err = s.State.ResolveSpaceConstraints(constraints.MustParse("spaces=internal"))
+	c.Assert(err, gc.ErrorMatches, `cannot resolve spaces constraint: no subnet in spaces "internal" has a known availability zone`)
+}
+
+func (s *SpaceSuite) TestSpacesConstraintValidation(c *gc.C) {
+	_, err := s.State.AddSpace("dmz", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConstraints(constraints.MustParse("spaces=dmz"))
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConstraints(constraints.MustParse("spaces=nowhere"))
+	c.Assert(err, gc.ErrorMatches, `invalid constraint value: spaces=nowhere\nvalid values are:.*`)
+}
+
+func (s *SpaceSuite) TestEndpointBindings(c *gc.C) {
+	_, err := s.State.AddSpace("dmz", []string{"10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddSpace("internal", []string{"192.168.0.0/24"})
+	c.Assert(err, gc.IsNil)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(service.EndpointBindings(), gc.HasLen, 0)
+
+	err = service.SetEndpointBindings(map[string]string{"kvm": "dmz"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": charm has no endpoint "kvm"`)
+	err = service.SetEndpointBindings(map[string]string{"db": "nowhere"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": space "nowhere" not found`)
+
+	bindings := map[string]string{"db": "dmz", "": "internal"}
+	err = service.SetEndpointBindings(bindings)
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, bindings)
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.EndpointBindings(), jc.DeepEquals, bindings)
+
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAddresses(
+		network.NewAddress("10.0.1.5", network.ScopeCloudLocal),
+		network.NewAddress("192.168.0.5", network.ScopeCloudLocal),
+	)
+	c.Assert(err, gc.IsNil)
+
+	// The unit's private address is in the default space.
+	address, ok := unit.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "192.168.0.5")
+
+	// A relation unit's private address is in its endpoint's space.
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+	address, ok = ru.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "10.0.1.5")
+}
@@ -29,14 +29,21 @@ import (
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/resource"
 	"github.com/juju/juju/state/multiwatcher"
 	"github.com/juju/juju/state/presence"
 	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/stats"
 	"github.com/juju/juju/version"
 )
 
 var logger = loggo.GetLogger("juju.state")
 
+var txnRetries = stats.NewCounter(
+	"juju_state_txn_retries_total",
+	"Number of times state transactions have been retried after their assertions failed.",
+)
+
 const (
 	// The following define the mongo collections used to record the Juju environment state.
 	environmentsC      = "environments"
@@ -68,6 +75,17 @@ const (
 	metricsC           = "metrics"
 	upgradeInfoC       = "upgradeInfo"
 	toolsmetadataC     = "toolsmetadata"
+	resourcesC         = "resources"
+	retiredResourcesC  = "retiredresources"
+	subnetsC           = "subnets"
+	spacesC            = "spaces"
+	ipaddressesC       = "ipaddresses"
+	rollingUpgradesC   = "rollingupgrades"
+	blocksC            = "blocks"
+	runJobsC           = "runjobs"
+	runTasksC          = "runtasks"
+	autoCharmUpgradesC = "autocharmupgrades"
+	scalingC           = "scaling"
 
 	// This collection is used just for storing metadata.
 	backupsMetaC = "backupsmetadata"
@@ -113,6 +131,9 @@ type StateServingInfo struct {
 	// this will be passed as the KeyFile argument to MongoDB
 	SharedSecret   string
 	SystemIdentity string
+	// CAPrivateKey holds the private key of the CA that signs
+	// state server certificates.
+	CAPrivateKey string
 }
 
 // EnvironTag() returns the environment tag for the environment controlled by
@@ -187,7 +208,12 @@ func (st *State) runTransaction(ops []txn.Op) error {
 func (st *State) run(transactions jujutxn.TransactionSource) error {
 	session := st.db.Session.Copy()
 	defer session.Close()
-	return st.txnRunner(session).Run(transactions)
+	return st.txnRunner(session).Run(func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			txnRetries.Inc()
+		}
+		return transactions(attempt)
+	})
 }
 
 // ResumeTransactions resumes all pending transactions.
@@ -602,9 +628,10 @@ func (st *State) parseTag(tag names.Tag) (string, string, error) {
 }
 
 // AddCharm adds the ch charm with curl to the state. bundleURL must
-// be set to a URL where the bundle for ch may be downloaded from. On
-// success the newly added charm state is returned.
-func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string) (stch *Charm, err error) {
+// be set to a URL where the bundle for ch may be downloaded from, and
+// bundleSize to the size of that bundle. On success the newly added
+// charm state is returned.
+func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string, bundleSize int64) (stch *Charm, err error) {
 	// The charm may already exist in state as a placeholder, so we
 	// check for that situation and update the existing charm record
 	// if necessary, otherwise add a new record.
@@ -614,13 +641,20 @@ func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, b
 
 	err = charms.Find(bson.D{{"_id", curl.String()}, {"placeholder", true}}).One(&existing)
 	if err == mgo.ErrNotFound {
+		resources, err := resource.ReadCharmMeta(ch)
+		if err != nil {
+			return nil, errors.Annotatef(err, "cannot read resources of charm %q", curl)
+		}
 		cdoc := &charmDoc{
 			URL:          curl,
 			Meta:         ch.Meta(),
 			Config:       ch.Config(),
 			Actions:      ch.Actions(),
+			Resources:    resources,
 			BundleURL:    bundleURL,
 			BundleSha256: bundleSha256,
+			BundleSize:   bundleSize,
+			Added:        nowToTheSecond(),
 		}
 		err = charms.Insert(cdoc)
 		if err != nil {
@@ -630,7 +664,7 @@ func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, b
 	} else if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return st.updateCharmDoc(ch, curl, bundleURL, bundleSha256, stillPlaceholder)
+	return st.updateCharmDoc(ch, curl, bundleURL, bundleSha256, bundleSize, stillPlaceholder)
 }
 
 // Charm returns the charm with the given URL. Charms pending upload
@@ -944,7 +978,7 @@ var ErrCharmRevisionAlreadyModified = fmt.Errorf("charm revision already modifie
 
 // UpdateUploadedCharm marks the given charm URL as uploaded and
 // updates the rest of its data, returning it as *state.Charm.
-func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string) (*Charm, error) {
+func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string, bundleSize int64) (*Charm, error) {
 	charms, closer := st.getCollection(charmsC)
 	defer closer()
 
@@ -960,7 +994,7 @@ func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL
 		return nil, errors.Trace(&ErrCharmAlreadyUploaded{curl})
 	}
 
-	return st.updateCharmDoc(ch, curl, bundleURL, bundleSha256, stillPending)
+	return st.updateCharmDoc(ch, curl, bundleURL, bundleSha256, bundleSize, stillPending)
 }
 
 // updateCharmDoc updates the charm with specified URL with the given
@@ -968,16 +1002,23 @@ func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL
 // charm is no longer a placeholder or pending (depending on preReq),
 // it returns ErrCharmRevisionAlreadyModified.
 func (st *State) updateCharmDoc(
-	ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string, preReq interface{}) (*Charm, error) {
+	ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string, bundleSize int64, preReq interface{}) (*Charm, error) {
 
+	resources, err := resource.ReadCharmMeta(ch)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot read resources of charm %q", curl)
+	}
 	updateFields := bson.D{{"$set", bson.D{
 		{"meta", ch.Meta()},
 		{"config", ch.Config()},
 		{"actions", ch.Actions()},
+		{"resources", resources},
 		{"bundleurl", bundleURL},
 		{"bundlesha256", bundleSha256},
+		{"bundlesize", bundleSize},
 		{"pendingupload", false},
 		{"placeholder", false},
+		{"added", nowToTheSecond()},
 	}}}
 	ops := []txn.Op{{
 		C:      charmsC,
@@ -1086,6 +1127,7 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 			Assert: txn.DocMissing,
 			Insert: settingsRefsDoc{1},
 		},
+		charmIncRefOp(ch.URL()),
 		{
 			C:      servicesC,
 			Id:     name,
@@ -191,9 +191,10 @@ func (s *StateSuite) dummyCharm(c *gc.C, curlOverride string) (ch charm.Charm, c
 func (s *StateSuite) TestAddCharm(c *gc.C) {
 	// Check that adding charms from scratch works correctly.
 	ch, curl, bundleURL, bundleSHA256 := s.dummyCharm(c, "")
-	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 1234)
 	c.Assert(err, gc.IsNil)
 	c.Assert(dummy.URL().String(), gc.Equals, curl.String())
+	c.Assert(dummy.BundleSize(), gc.Equals, int64(1234))
 
 	doc := state.CharmDoc{}
 	err = s.charms.FindId(curl).One(&doc)
@@ -216,7 +217,7 @@ func (s *StateSuite) TestAddCharmUpdatesPlaceholder(c *gc.C) {
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
 	c.Assert(err, gc.IsNil)
 	bundleSHA256 := "dummy-1-sha256"
-	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.IsNil)
 	c.Assert(dummy.URL().String(), gc.Equals, curl.String())
 
@@ -312,7 +313,7 @@ func (s *StateSuite) TestPrepareStoreCharmUpload(c *gc.C) {
 	// Now add a charm and try again - we should get the same result
 	// as with AddCharm.
 	ch, curl, bundleURL, bundleSHA256 := s.dummyCharm(c, "cs:precise/dummy-2")
-	sch, err = s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	sch, err = s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.IsNil)
 	schCopy, err = s.State.PrepareStoreCharmUpload(curl)
 	c.Assert(err, gc.IsNil)
@@ -352,24 +353,25 @@ func (s *StateSuite) TestPrepareStoreCharmUpload(c *gc.C) {
 
 func (s *StateSuite) TestUpdateUploadedCharm(c *gc.C) {
 	ch, curl, bundleURL, bundleSHA256 := s.dummyCharm(c, "")
-	_, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	_, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.IsNil)
 
 	// Test with already uploaded and a missing charms.
-	sch, err := s.State.UpdateUploadedCharm(ch, curl, bundleURL, bundleSHA256)
+	sch, err := s.State.UpdateUploadedCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.ErrorMatches, fmt.Sprintf("charm %q already uploaded", curl))
 	c.Assert(sch, gc.IsNil)
 	missingCurl := charm.MustParseURL("local:quantal/missing-1")
-	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, bundleURL, "missing")
+	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, bundleURL, "missing", 0)
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 	c.Assert(sch, gc.IsNil)
 
 	// Test with with an uploaded local charm.
 	_, err = s.State.PrepareLocalCharmUpload(missingCurl)
 	c.Assert(err, gc.IsNil)
-	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, bundleURL, "missing")
+	sch, err = s.State.UpdateUploadedCharm(ch, missingCurl, bundleURL, "missing", 1234)
 	c.Assert(err, gc.IsNil)
 	c.Assert(sch.URL(), gc.DeepEquals, missingCurl)
+	c.Assert(sch.BundleSize(), gc.Equals, int64(1234))
 	c.Assert(sch.Revision(), gc.Equals, missingCurl.Revision)
 	c.Assert(sch.IsUploaded(), jc.IsTrue)
 	c.Assert(sch.IsPlaceholder(), jc.IsFalse)
@@ -401,7 +403,7 @@ func (s *StateSuite) assertPlaceholderCharmExists(c *gc.C, curl *charm.URL) {
 func (s *StateSuite) TestLatestPlaceholderCharm(c *gc.C) {
 	// Add a deployed charm
 	ch, curl, bundleURL, bundleSHA256 := s.dummyCharm(c, "cs:quantal/dummy-1")
-	_, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	_, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.IsNil)
 
 	// Deployed charm not found.
@@ -454,7 +456,7 @@ func (s *StateSuite) TestAddStoreCharmPlaceholder(c *gc.C) {
 func (s *StateSuite) assertAddStoreCharmPlaceholder(c *gc.C) (*charm.URL, *charm.URL, *state.Charm) {
 	// Add a deployed charm
 	ch, curl, bundleURL, bundleSHA256 := s.dummyCharm(c, "cs:quantal/dummy-1")
-	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	dummy, err := s.State.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 	c.Assert(err, gc.IsNil)
 
 	// Add a charm placeholder
@@ -3121,6 +3123,86 @@ func (s *StateSuite) TestEnsureAvailabilityConcurrentMore(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
+func (s *StateSuite) TestRemoveStateServerDemotesVotingMachine(c *gc.C) {
+	s.PatchValue(state.StateServerAvailable, func(m *state.Machine) (bool, error) {
+		return true, nil
+	})
+	_, err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	m0, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	err = m0.SetHasVote(true)
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.RemoveStateServer("0")
+	c.Assert(err, gc.IsNil)
+	s.assertStateServerInfo(c, []string{"0", "1", "2"}, []string{"1", "2"})
+	err = m0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m0.WantsVote(), jc.IsFalse)
+	c.Assert(m0.IsRemovingStateServer(), jc.IsTrue)
+	c.Assert(m0.IsManager(), jc.IsTrue) // job intact until the vote has gone
+
+	// The removal cannot complete while the machine has a vote.
+	err = s.State.CompleteStateServerRemoval("0")
+	c.Assert(err, gc.ErrorMatches, "cannot complete removal of state server 0: machine still has a vote")
+
+	// EnsureAvailability does not promote the machine again.
+	changes, err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	c.Assert(changes.Promoted, gc.HasLen, 0)
+	c.Assert(changes.Added, gc.DeepEquals, []string{"3"})
+
+	err = m0.SetHasVote(false)
+	c.Assert(err, gc.IsNil)
+	err = s.State.CompleteStateServerRemoval("0")
+	c.Assert(err, gc.IsNil)
+	s.assertStateServerInfo(c, []string{"1", "2", "3"}, []string{"1", "2", "3"})
+	err = m0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m0.IsManager(), jc.IsFalse)
+	c.Assert(m0.IsRemovingStateServer(), jc.IsFalse)
+	c.Assert(m0.Life(), gc.Equals, state.Alive)
+}
+
+func (s *StateSuite) TestRemoveStateServerRemovesNonVotingMachine(c *gc.C) {
+	_, err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(state.StateServerAvailable, func(m *state.Machine) (bool, error) {
+		return m.Id() != "0", nil
+	})
+	_, err = s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	s.assertStateServerInfo(c, []string{"0", "1", "2", "3"}, []string{"1", "2", "3"})
+
+	// Machine 0 has neither a vote nor wants one, so its job is
+	// removed immediately.
+	err = s.State.RemoveStateServer("0")
+	c.Assert(err, gc.IsNil)
+	s.assertStateServerInfo(c, []string{"1", "2", "3"}, []string{"1", "2", "3"})
+	m0, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m0.IsManager(), jc.IsFalse)
+}
+
+func (s *StateSuite) TestRemoveStateServerLastVoter(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits, state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RemoveStateServer("0")
+	c.Assert(err, gc.ErrorMatches, "cannot remove state server 0: cannot remove the last voting state server")
+	s.assertStateServerInfo(c, []string{"0"}, []string{"0"})
+}
+
+func (s *StateSuite) TestRemoveStateServerNotStateServer(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RemoveStateServer("0")
+	c.Assert(err, gc.ErrorMatches, "cannot remove state server 0: machine is not a state server")
+	err = s.State.RemoveStateServer("42")
+	c.Assert(err, gc.ErrorMatches, `cannot remove state server 42: machine 42 not found`)
+	c.Assert(errors.Cause(err), jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *StateSuite) TestStateServingInfo(c *gc.C) {
 	info, err := s.State.StateServingInfo()
 	c.Assert(err, gc.ErrorMatches, "state serving info not found")
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package storagegc finds and removes charm archives, tools tarballs
+// and old resource content that are no longer used by anything in
+// the environment.
+package storagegc
+
+import (
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/state"
+)
+
+var logger = loggo.GetLogger("juju.state.storagegc")
+
+// DefaultGracePeriod is how long a charm or tools tarball must have
+// been in state before it may be collected, so that charms and tools
+// added for a deployment or machine that is still being started are
+// not removed from under it. It is also how long the content of a
+// replaced resource revision is kept, so that downloads of it that
+// are in progress can finish.
+const DefaultGracePeriod = 24 * time.Hour
+
+const (
+	// KindCharm identifies an unused charm archive.
+	KindCharm = "charm"
+
+	// KindTools identifies an unused tools tarball.
+	KindTools = "tools"
+
+	// KindResource identifies the content of a replaced
+	// revision of a service's resource.
+	KindResource = "resource"
+)
+
+// Item describes an unused charm archive, tools tarball or
+// resource content.
+type Item struct {
+	// Kind is KindCharm, KindTools or KindResource.
+	Kind string
+
+	// Name is the charm URL, the tools version, or the service
+	// and resource names separated by a slash.
+	Name string
+
+	// Size is the number of bytes the item occupies in storage.
+	Size int64
+}
+
+// Report lists the items found by Collect.
+type Report struct {
+	Items []Item
+}
+
+// Size returns the total size of the reported items.
+func (r *Report) Size() int64 {
+	var total int64
+	for _, item := range r.Items {
+		total += item.Size
+	}
+	return total
+}
+
+// Params holds the arguments for Collect.
+type Params struct {
+	// Storage is the provider storage holding charm archives.
+	Storage storage.Storage
+
+	// GracePeriod is how long a charm or tools tarball must have
+	// been in state, or the content of a resource must have been
+	// replaced, before it is considered for collection.
+	GracePeriod time.Duration
+
+	// DryRun causes Collect to only report what it would remove.
+	DryRun bool
+}
+
+// Collect finds the charms, tools and resource content that are no
+// longer used in the environment and, unless p.DryRun is set, removes
+// them from state and storage. It returns a report of the items found.
+func Collect(st *state.State, p Params) (*Report, error) {
+	report := &Report{}
+	cutoff := time.Now().Add(-p.GracePeriod)
+	charms, err := st.UnusedCharms(cutoff)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot find unused charms")
+	}
+	for _, ch := range charms {
+		name := archiveName(ch.BundleURL())
+		report.Items = append(report.Items, Item{KindCharm, ch.URL().String(), ch.BundleSize()})
+		if p.DryRun {
+			continue
+		}
+		// Remove the charm from state first, so that nothing can
+		// start using it after its archive has been removed.
+		if err := st.RemoveCharm(ch.URL()); err != nil {
+			return nil, err
+		}
+		if name != "" {
+			if err := p.Storage.Remove(name); err != nil {
+				return nil, errors.Annotatef(err, "cannot remove archive of charm %q", ch.URL())
+			}
+		}
+		logger.Infof("removed unused charm %q", ch.URL())
+	}
+
+	resources, err := st.RetiredResources(cutoff)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot find old resource content")
+	}
+	for _, res := range resources {
+		name := res.Service + "/" + res.Name
+		report.Items = append(report.Items, Item{KindResource, name, res.Size})
+		if p.DryRun {
+			continue
+		}
+		if err := st.RemoveRetiredResource(res); err != nil {
+			return nil, err
+		}
+		logger.Infof("removed old content of resource %q", name)
+	}
+
+	tools, err := st.UnusedTools(cutoff)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot find unused tools")
+	}
+	if len(tools) == 0 {
+		return report, nil
+	}
+	toolsStorage, err := st.ToolsStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer toolsStorage.Close()
+	for _, metadata := range tools {
+		report.Items = append(report.Items, Item{KindTools, metadata.Version.String(), metadata.Size})
+		if p.DryRun {
+			continue
+		}
+		if err := toolsStorage.RemoveTools(metadata.Version); err != nil {
+			return nil, errors.Annotatef(err, "cannot remove tools %v", metadata.Version)
+		}
+		logger.Infof("removed unused tools %v", metadata.Version)
+	}
+	return report, nil
+}
+
+// archiveName returns the name in provider storage of the charm
+// archive at the given URL. Charm archives are stored at the top
+// level of the storage, so the name is the last element of the URL.
+func archiveName(bundleURL *url.URL) string {
+	if bundleURL == nil {
+		return ""
+	}
+	name := path.Base(bundleURL.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
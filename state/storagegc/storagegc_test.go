@@ -0,0 +1,146 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storagegc_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/juju/charm.v3"
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/storagegc"
+	"github.com/juju/juju/state/toolstorage"
+	"github.com/juju/juju/version"
+)
+
+type storageGCSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&storageGCSuite{})
+
+func (s *storageGCSuite) params(dryRun bool) storagegc.Params {
+	return storagegc.Params{
+		Storage: s.Environ.Storage(),
+		DryRun:  dryRun,
+	}
+}
+
+func (s *storageGCSuite) addOldTools(c *gc.C) version.Binary {
+	v := version.Current
+	v.Minor--
+	storage, err := s.State.ToolsStorage()
+	c.Assert(err, gc.IsNil)
+	defer storage.Close()
+	err = storage.AddTools(strings.NewReader("tools"), toolstorage.Metadata{Version: v, Size: 5})
+	c.Assert(err, gc.IsNil)
+	return v
+}
+
+func (s *storageGCSuite) TestDryRun(c *gc.C) {
+	used := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", used)
+	unused := s.AddTestingCharm(c, "mysql")
+	tools := s.addOldTools(c)
+
+	report, err := storagegc.Collect(s.State, s.params(true))
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 2)
+	c.Assert(report.Items[0].Kind, gc.Equals, storagegc.KindCharm)
+	c.Assert(report.Items[0].Name, gc.Equals, unused.URL().String())
+	c.Assert(report.Items[0].Size > 0, jc.IsTrue)
+	c.Assert(report.Items[1], gc.Equals, storagegc.Item{storagegc.KindTools, tools.String(), 5})
+	c.Assert(report.Size(), gc.Equals, report.Items[0].Size+5)
+
+	// Nothing was removed.
+	_, err = s.State.Charm(unused.URL())
+	c.Assert(err, gc.IsNil)
+	r, err := s.Environ.Storage().Get(charm.Quote(unused.URL().String()))
+	c.Assert(err, gc.IsNil)
+	r.Close()
+}
+
+func (s *storageGCSuite) TestCollect(c *gc.C) {
+	used := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", used)
+	unused := s.AddTestingCharm(c, "mysql")
+	tools := s.addOldTools(c)
+
+	report, err := storagegc.Collect(s.State, s.params(false))
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 2)
+
+	_, err = s.State.Charm(unused.URL())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = s.Environ.Storage().Get(charm.Quote(unused.URL().String()))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	storage, err := s.State.ToolsStorage()
+	c.Assert(err, gc.IsNil)
+	defer storage.Close()
+	_, err = storage.Metadata(tools)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// The used charm is untouched, and nothing is left to collect.
+	_, err = s.State.Charm(used.URL())
+	c.Assert(err, gc.IsNil)
+	report, err = storagegc.Collect(s.State, s.params(false))
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 0)
+}
+
+func (s *storageGCSuite) TestGracePeriod(c *gc.C) {
+	s.AddTestingCharm(c, "mysql")
+	s.addOldTools(c)
+	p := s.params(false)
+	p.GracePeriod = storagegc.DefaultGracePeriod
+	report, err := storagegc.Collect(s.State, p)
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 0)
+}
+
+var resourcesMeta = `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+    description: The application tarball.
+`
+
+func (s *storageGCSuite) TestCollectRetiredResources(c *gc.C) {
+	ch := s.AddMetaCharm(c, "dummy", resourcesMeta, 1)
+	service := s.AddTestingService(c, "app", ch)
+	for _, content := range []string{"first", "second"} {
+		_, err := service.SetResource("app", strings.NewReader(content), int64(len(content)), "sha256-"+content)
+		c.Assert(err, gc.IsNil)
+	}
+
+	// The old content is kept for the grace period.
+	p := s.params(false)
+	p.GracePeriod = storagegc.DefaultGracePeriod
+	report, err := storagegc.Collect(s.State, p)
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 0)
+
+	report, err = storagegc.Collect(s.State, s.params(false))
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, jc.DeepEquals, []storagegc.Item{{storagegc.KindResource, "app/app", 5}})
+
+	// The current content is untouched.
+	_, r, err := service.OpenResource("app")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "second")
+
+	report, err = storagegc.Collect(s.State, s.params(false))
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Items, gc.HasLen, 0)
+}
@@ -0,0 +1,185 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/juju/errors"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/mgo.v2/txn"
+
+	"github.com/juju/juju/network"
+)
+
+// Subnet represents the state of a subnet.
+type Subnet struct {
+	st  *State
+	doc subnetDoc
+}
+
+// SubnetInfo describes a single subnet.
+type SubnetInfo struct {
+	// CIDR of the subnet, in 123.45.67.0/24 format.
+	CIDR string
+
+	// ProviderId is a provider-specific subnet id, if known.
+	ProviderId network.Id
+
+	// VLANTag needs to be between 1 and 4094 for VLANs and 0 for
+	// normal networks. It's defined by IEEE 802.1Q standard.
+	VLANTag int
+
+	// AvailabilityZone is the availability zone the subnet is in,
+	// if the provider has availability zones.
+	AvailabilityZone string
+}
+
+// subnetDoc represents a subnet known to juju. A subnet belongs to
+// at most one space.
+type subnetDoc struct {
+	CIDR             string `bson:"_id"`
+	ProviderId       network.Id
+	VLANTag          int
+	AvailabilityZone string
+	SpaceName        string
+}
+
+func newSubnet(st *State, doc *subnetDoc) *Subnet {
+	return &Subnet{st, *doc}
+}
+
+// GoString implements fmt.GoStringer.
+func (s *Subnet) GoString() string {
+	return fmt.Sprintf(
+		"&state.Subnet{cidr: %q, providerId: %q, vlanTag: %v, zone: %q, space: %q}",
+		s.CIDR(), s.ProviderId(), s.VLANTag(), s.AvailabilityZone(), s.SpaceName())
+}
+
+// CIDR returns the subnet CIDR (e.g. 192.168.50.0/24).
+func (s *Subnet) CIDR() string {
+	return s.doc.CIDR
+}
+
+// ProviderId returns the provider-specific id of the subnet.
+func (s *Subnet) ProviderId() network.Id {
+	return s.doc.ProviderId
+}
+
+// VLANTag returns the subnet VLAN tag. It's a number between 1 and
+// 4094 for VLANs and 0 if the subnet is not a VLAN.
+func (s *Subnet) VLANTag() int {
+	return s.doc.VLANTag
+}
+
+// AvailabilityZone returns the availability zone of the subnet, or
+// the empty string if it is not known.
+func (s *Subnet) AvailabilityZone() string {
+	return s.doc.AvailabilityZone
+}
+
+// SpaceName returns the name of the space the subnet belongs to, or
+// the empty string if it belongs to none.
+func (s *Subnet) SpaceName() string {
+	return s.doc.SpaceName
+}
+
+// Contains returns whether the given IP address is within the subnet.
+func (s *Subnet) Contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	_, ipNet, err := net.ParseCIDR(s.doc.CIDR)
+	if err != nil {
+		return false
+	}
+	return ipNet.Contains(ip)
+}
+
+// normalizeCIDR returns the canonical form of the given CIDR, so
+// that "10.0.0.1/24" and "10.0.0.0/24" name the same subnet.
+func normalizeCIDR(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	return ipNet.String(), nil
+}
+
+// AddSubnet creates a new subnet with the given params. If a subnet
+// with the same CIDR already exists in state, an error satisfying
+// errors.IsAlreadyExists is returned.
+func (st *State) AddSubnet(args SubnetInfo) (s *Subnet, err error) {
+	defer errors.DeferredAnnotatef(&err, "cannot add subnet %q", args.CIDR)
+	cidr, err := normalizeCIDR(args.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	if args.VLANTag < 0 || args.VLANTag > 4094 {
+		return nil, errors.Errorf("invalid VLAN tag %d: must be between 0 and 4094", args.VLANTag)
+	}
+	doc := &subnetDoc{
+		CIDR:             cidr,
+		ProviderId:       args.ProviderId,
+		VLANTag:          args.VLANTag,
+		AvailabilityZone: args.AvailabilityZone,
+	}
+	ops := []txn.Op{{
+		C:      subnetsC,
+		Id:     cidr,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		return nil, errors.AlreadyExistsf("subnet %q", cidr)
+	} else if err != nil {
+		return nil, err
+	}
+	return newSubnet(st, doc), nil
+}
+
+// Subnet returns the subnet with the given CIDR.
+func (st *State) Subnet(cidr string) (*Subnet, error) {
+	normalized, err := normalizeCIDR(cidr)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get subnet %q", cidr)
+	}
+	subnets, closer := st.getCollection(subnetsC)
+	defer closer()
+
+	doc := &subnetDoc{}
+	err = subnets.FindId(normalized).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("subnet %q", cidr)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get subnet %q", cidr)
+	}
+	return newSubnet(st, doc), nil
+}
+
+// AllSubnets returns all known subnets in the environment, ordered
+// by CIDR.
+func (st *State) AllSubnets() ([]*Subnet, error) {
+	return st.subnets(nil)
+}
+
+func (st *State) subnets(sel bson.D) ([]*Subnet, error) {
+	subnetsCollection, closer := st.getCollection(subnetsC)
+	defer closer()
+
+	docs := []subnetDoc{}
+	err := subnetsCollection.Find(sel).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot get subnets")
+	}
+	subnets := make([]*Subnet, len(docs))
+	for i := range docs {
+		subnets[i] = newSubnet(st, &docs[i])
+	}
+	return subnets, nil
+}
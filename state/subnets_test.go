@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+)
+
+type SubnetSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&SubnetSuite{})
+
+func (s *SubnetSuite) TestAddSubnet(c *gc.C) {
+	subnet, err := s.State.AddSubnet(state.SubnetInfo{
+		CIDR:             "10.0.1.7/24",
+		ProviderId:       "subnet-1",
+		VLANTag:          42,
+		AvailabilityZone: "zone1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.CIDR(), gc.Equals, "10.0.1.0/24")
+	c.Assert(subnet.ProviderId(), gc.Equals, network.Id("subnet-1"))
+	c.Assert(subnet.VLANTag(), gc.Equals, 42)
+	c.Assert(subnet.AvailabilityZone(), gc.Equals, "zone1")
+	c.Assert(subnet.SpaceName(), gc.Equals, "")
+
+	subnet, err = s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.ProviderId(), gc.Equals, network.Id("subnet-1"))
+}
+
+func (s *SubnetSuite) TestAddSubnetErrors(c *gc.C) {
+	_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0"})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.1.0": invalid CIDR address: 10.0.1.0`)
+
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24", VLANTag: 4095})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.1.0/24": invalid VLAN tag 4095: must be between 0 and 4094`)
+
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.ErrorMatches, `cannot add subnet "10.0.1.0/24": subnet "10.0.1.0/24" already exists`)
+	c.Assert(errors.Cause(err), jc.Satisfies, errors.IsAlreadyExists)
+}
+
+func (s *SubnetSuite) TestSubnetNotFound(c *gc.C) {
+	_, err := s.State.Subnet("10.0.1.0/24")
+	c.Assert(err, gc.ErrorMatches, `subnet "10.0.1.0/24" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *SubnetSuite) TestAllSubnets(c *gc.C) {
+	for _, cidr := range []string{"10.0.2.0/24", "10.0.1.0/24"} {
+		_, err := s.State.AddSubnet(state.SubnetInfo{CIDR: cidr})
+		c.Assert(err, gc.IsNil)
+	}
+	subnets, err := s.State.AllSubnets()
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnets, gc.HasLen, 2)
+	c.Assert(subnets[0].CIDR(), gc.Equals, "10.0.1.0/24")
+	c.Assert(subnets[1].CIDR(), gc.Equals, "10.0.2.0/24")
+}
+
+func (s *SubnetSuite) TestContains(c *gc.C) {
+	subnet, err := s.State.AddSubnet(state.SubnetInfo{CIDR: "10.0.1.0/24"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(subnet.Contains("10.0.1.5"), jc.IsTrue)
+	c.Assert(subnet.Contains("10.0.2.5"), jc.IsFalse)
+	c.Assert(subnet.Contains("example.com"), jc.IsFalse)
+}
@@ -5,6 +5,7 @@ package toolstorage
 
 import (
 	"io"
+	"time"
 
 	"github.com/juju/juju/version"
 )
@@ -14,6 +15,11 @@ type Metadata struct {
 	Version version.Binary
 	Size    int64
 	SHA256  string
+
+	// Added records when the tools were added to storage. It is
+	// set by AddTools and AddToolsAlias, and ignored when passed
+	// to them.
+	Added time.Time
 }
 
 // Storage provides methods for storing and retrieving tools by version.
@@ -39,6 +45,12 @@ type Storage interface {
 	// Metadata returns the Metadata for the specified version
 	// if it exists, else an error satisfying errors.IsNotFound.
 	Metadata(v version.Binary) (Metadata, error)
+
+	// RemoveTools removes the metadata for the specified version,
+	// and the tools tarball if no other metadata refers to it. It
+	// returns an error satisfying errors.IsNotFound if there is no
+	// metadata for the version.
+	RemoveTools(v version.Binary) error
 }
 
 // StorageCloser extends the Storage interface with a Close method.
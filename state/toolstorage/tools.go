@@ -6,6 +6,7 @@ package toolstorage
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/juju/blobstore"
 	"github.com/juju/errors"
@@ -57,6 +58,7 @@ func (s *toolsStorage) AddTools(r io.Reader, metadata Metadata) error {
 		Size:    metadata.Size,
 		SHA256:  metadata.SHA256,
 		Path:    path,
+		Added:   time.Now(),
 	}
 	ops := []txn.Op{{
 		C:      s.metadataCollection.Name,
@@ -70,6 +72,7 @@ func (s *toolsStorage) AddTools(r io.Reader, metadata Metadata) error {
 				{"size", metadata.Size},
 				{"sha256", metadata.SHA256},
 				{"path", path},
+				{"added", doc.Added},
 			},
 		}},
 	}}
@@ -93,6 +96,7 @@ func (s *toolsStorage) AddToolsAlias(alias, version version.Binary) error {
 		Size:    existingDoc.Size,
 		SHA256:  existingDoc.SHA256,
 		Path:    existingDoc.Path,
+		Added:   time.Now(),
 	}
 	ops := []txn.Op{{
 		C:      s.metadataCollection.Name,
@@ -120,6 +124,7 @@ func (s *toolsStorage) Tools(v version.Binary) (Metadata, io.ReadCloser, error)
 		Version: metadataDoc.Version,
 		Size:    metadataDoc.Size,
 		SHA256:  metadataDoc.SHA256,
+		Added:   metadataDoc.Added,
 	}
 	return metadata, tools, nil
 }
@@ -133,6 +138,7 @@ func (s *toolsStorage) Metadata(v version.Binary) (Metadata, error) {
 		Version: metadataDoc.Version,
 		Size:    metadataDoc.Size,
 		SHA256:  metadataDoc.SHA256,
+		Added:   metadataDoc.Added,
 	}
 	return metadata, nil
 }
@@ -148,18 +154,51 @@ func (s *toolsStorage) AllMetadata() ([]Metadata, error) {
 			Version: doc.Version,
 			Size:    doc.Size,
 			SHA256:  doc.SHA256,
+			Added:   doc.Added,
 		}
 		list[i] = metadata
 	}
 	return list, nil
 }
 
+func (s *toolsStorage) RemoveTools(v version.Binary) error {
+	doc, err := s.toolsMetadata(v)
+	if err != nil {
+		return err
+	}
+	ops := []txn.Op{{
+		C:      s.metadataCollection.Name,
+		Id:     doc.Id,
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	err = s.txnRunner.RunTransaction(ops)
+	if err == txn.ErrAborted {
+		return errors.NotFoundf("%v tools metadata", v)
+	} else if err != nil {
+		return errors.Annotate(err, "cannot remove tools metadata")
+	}
+	// Aliases share the tarball of the tools they alias, so
+	// only remove it once nothing refers to it.
+	refs, err := s.metadataCollection.Find(bson.D{{"path", doc.Path}}).Count()
+	if err != nil {
+		return errors.Annotate(err, "cannot count tools metadata")
+	}
+	if refs == 0 {
+		if err := s.managedStorage.RemoveForEnvironment(s.envUUID, doc.Path); err != nil {
+			return errors.Annotate(err, "cannot remove tools tarball")
+		}
+	}
+	return nil
+}
+
 type toolsMetadataDoc struct {
 	Id      string         `bson:"_id"`
 	Version version.Binary `bson:"version"`
 	Size    int64          `bson:"size"`
 	SHA256  string         `bson:"sha256,omitempty"`
 	Path    string         `bson:"path"`
+	Added   time.Time      `bson:"added,omitempty"`
 }
 
 func (s *toolsStorage) toolsMetadata(v version.Binary) (toolsMetadataDoc, error) {
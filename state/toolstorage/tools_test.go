@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"strings"
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/blobstore"
 	"github.com/juju/errors"
@@ -83,6 +84,8 @@ func (s *ToolsSuite) testAddTools(c *gc.C, content string) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(r, gc.NotNil)
 	defer rc.Close()
+	c.Assert(metadata.Added.IsZero(), jc.IsFalse)
+	addedMetadata.Added = metadata.Added
 	c.Assert(metadata, gc.Equals, addedMetadata)
 
 	data, err := ioutil.ReadAll(rc)
@@ -161,6 +164,14 @@ func (s *ToolsSuite) TestAllMetadata(c *gc.C) {
 	metadata, err = s.storage.AllMetadata()
 	c.Assert(err, gc.IsNil)
 	c.Assert(metadata, gc.HasLen, 2)
+	for i := range metadata {
+		// The alias records when it was added; the original
+		// metadata doc was inserted directly, so it does not.
+		if metadata[i].Version == alias {
+			c.Assert(metadata[i].Added.IsZero(), jc.IsFalse)
+			metadata[i].Added = time.Time{}
+		}
+	}
 	expected = append(expected, toolstorage.Metadata{
 		Version: alias,
 		Size:    3,
@@ -227,3 +238,34 @@ func (s *ToolsSuite) addMetadataDoc(c *gc.C, v version.Binary, size int64, hash,
 	err := s.metadataCollection.Insert(&doc)
 	c.Assert(err, gc.IsNil)
 }
+
+func (s *ToolsSuite) TestRemoveTools(c *gc.C) {
+	s.testAddTools(c, "abc")
+	err := s.storage.RemoveTools(version.Current)
+	c.Assert(err, gc.IsNil)
+	_, _, err = s.storage.Tools(version.Current)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, _, err = s.managedStorage.GetForEnvironment("my-uuid", "tools/"+version.Current.String()+"-hash(abc)")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ToolsSuite) TestRemoveToolsKeepsAliasedTarball(c *gc.C) {
+	s.testAddTools(c, "abc")
+	alias := bumpVersion(version.Current)
+	err := s.storage.AddToolsAlias(alias, version.Current)
+	c.Assert(err, gc.IsNil)
+
+	err = s.storage.RemoveTools(version.Current)
+	c.Assert(err, gc.IsNil)
+	_, r, err := s.storage.Tools(alias)
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "abc")
+}
+
+func (s *ToolsSuite) TestRemoveToolsNotExist(c *gc.C) {
+	err := s.storage.RemoveTools(version.Current)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
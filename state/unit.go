@@ -6,6 +6,8 @@ package state
 import (
 	stderrors "errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -76,6 +78,7 @@ type unitDoc struct {
 	Resolved     ResolvedMode
 	Tools        *tools.Tools `bson:",omitempty"`
 	Ports        []network.Port
+	PortRanges   []network.PortRange `bson:",omitempty"`
 	Life         Life
 	TxnRevno     int64 `bson:"txn-revno"`
 	PasswordHash string
@@ -666,15 +669,62 @@ func (u *Unit) PublicAddress() (string, bool) {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// If the service's endpoints are bound to a space by default, the address
+// is chosen from those in that space.
 func (u *Unit) PrivateAddress() (string, bool) {
-	var privateAddress string
+	return u.privateAddressForEndpoint("")
+}
+
+// privateAddressForEndpoint returns the private address of the unit
+// to be used for the named endpoint, and whether it is valid. If the
+// endpoint is bound to a space, only addresses in the space's subnets
+// are considered.
+func (u *Unit) privateAddressForEndpoint(endpoint string) (string, bool) {
 	addresses := u.addressesOfMachine()
-	if len(addresses) > 0 {
-		privateAddress = network.SelectInternalAddress(addresses, false)
+	if len(addresses) == 0 {
+		return "", false
+	}
+	spaceName, err := u.endpointSpace(endpoint)
+	if err != nil {
+		unitLogger.Errorf("unit %v cannot get endpoint bindings: %v", u, err)
+		return "", false
+	}
+	if spaceName != "" {
+		space, err := u.st.Space(spaceName)
+		if err == nil {
+			addresses, err = space.Addresses(addresses)
+		}
+		if err != nil {
+			unitLogger.Errorf("unit %v cannot get addresses in space %q: %v", u, spaceName, err)
+			return "", false
+		}
 	}
+	privateAddress := network.SelectInternalAddress(addresses, false)
 	return privateAddress, privateAddress != ""
 }
 
+// endpointSpace returns the name of the space the named endpoint of
+// the unit's service is bound to, or the empty string if it is not
+// bound. Only the service's bindings are fetched, and only if it has
+// any, so unbound services cost no more than a lookup by id.
+func (u *Unit) endpointSpace(endpoint string) (string, error) {
+	services, closer := u.st.getCollection(servicesC)
+	defer closer()
+
+	var doc serviceDoc
+	sel := bson.D{
+		{"_id", u.doc.Service},
+		{"endpointbindings", bson.D{{"$exists", true}}},
+	}
+	err := services.Find(sel).Select(bson.D{{"endpointbindings", 1}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return newService(u.st, &doc).boundSpace(endpoint), nil
+}
+
 // Refresh refreshes the contents of the Unit from the underlying
 // state. It an error that satisfies errors.IsNotFound if the unit has
 // been removed.
@@ -730,19 +780,71 @@ func (u *Unit) SetStatus(status params.Status, info string, data map[string]inte
 }
 
 // OpenPort sets the policy of the port with protocol and number to be opened.
-func (u *Unit) OpenPort(protocol string, number int) (err error) {
-	ports, err := NewPortRange(u.Name(), number, number, protocol)
+func (u *Unit) OpenPort(protocol string, number int) error {
+	return u.OpenPorts(protocol, number, number)
+}
+
+// ClosePort sets the policy of the port with protocol and number to be closed.
+func (u *Unit) ClosePort(protocol string, number int) error {
+	return u.ClosePorts(protocol, number, number)
+}
+
+// OpenPorts sets the policy of the ports in the given range to be
+// opened. It fails if any of the ports are already open for another
+// unit on the same machine, or if they overlap a different range
+// opened by this unit.
+func (u *Unit) OpenPorts(protocol string, fromPort, toPort int) (err error) {
+	ports, err := NewPortRange(u.Name(), fromPort, toPort, protocol)
 	if err != nil {
 		return err
 	}
 	defer errors.Maskf(&err, "cannot open ports %v for unit %q", ports, u)
 
-	machineId, err := u.AssignedMachineId()
+	machinePorts, err := u.machinePorts()
 	if err != nil {
 		return err
 	}
+	err = machinePorts.OpenPorts(ports)
+	if err != nil {
+		return err
+	}
+	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
+	return u.openUnitPorts(ports)
+}
 
+// ClosePorts sets the policy of the ports in the given range to be
+// closed. The range must match one previously opened by the unit.
+func (u *Unit) ClosePorts(protocol string, fromPort, toPort int) (err error) {
+	ports, err := NewPortRange(u.Name(), fromPort, toPort, protocol)
+	if err != nil {
+		return err
+	}
+	defer errors.Maskf(&err, "cannot close ports %v for unit %q", ports, u)
+
+	machinePorts, err := u.machinePorts()
+	if err != nil {
+		return err
+	}
+	err = machinePorts.ClosePorts(ports)
+	if err != nil {
+		return err
+	}
+	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
+	return u.closeUnitPorts(ports)
+}
+
+// machinePorts returns the ports document for the unit's assigned
+// machine, first migrating any ports still stored in the unit's own
+// document.
+func (u *Unit) machinePorts() (*Ports, error) {
+	machineId, err := u.AssignedMachineId()
+	if err != nil {
+		return nil, err
+	}
 	machinePorts, err := getOrCreatePorts(u.st, machineId)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if this unit is still storing ports in its own document,
 	// if so - attempt a migration.
@@ -754,143 +856,119 @@ func (u *Unit) OpenPort(protocol string, number int) (err error) {
 		err = machinePorts.migratePorts(u)
 		if err != nil {
 			unitLogger.Errorf("could not migrate ports collection for unit %v: %v", u, err)
-			return err
+			return nil, err
 		}
 		err = machinePorts.Refresh()
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-
-	err = machinePorts.OpenPorts(ports)
-	if err != nil {
-		return err
-	}
-	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
-	return u.openUnitPort(protocol, number)
+	return machinePorts, nil
 }
 
-// openUnitPort is the old implementation of OpenPort that amends the list of ports on the unit document.
+// openUnitPorts is the old implementation of OpenPorts that amends the list of ports on the unit document.
+// The old list holds single ports only, so other port ranges are
+// recorded separately, as ranges.
 // TODO(domas) 2014-07-04 bug #1337813
 // This is kept in place until the firewaller is updated to watch the OpenedPorts collection.
-func (u *Unit) openUnitPort(protocol string, number int) (err error) {
-	port := network.Port{Protocol: protocol, Number: number}
-	defer errors.Maskf(&err, "cannot open port %v for unit %q", port, u)
+func (u *Unit) openUnitPorts(portRange PortRange) error {
+	field, value := unitPortsField(portRange.NetworkPortRange())
 	ops := []txn.Op{{
 		C:      unitsC,
 		Id:     u.doc.Name,
 		Assert: notDeadDoc,
-		Update: bson.D{{"$addToSet", bson.D{{"ports", port}}}},
+		Update: bson.D{{"$addToSet", bson.D{{field, value}}}},
 	}}
-	err = u.st.runTransaction(ops)
-	if err != nil {
+	if err := u.st.runTransaction(ops); err != nil {
 		return onAbort(err, ErrDead)
 	}
-	found := false
-	for _, p := range u.doc.Ports {
-		if p == port {
-			found = true
-			break
+	switch value := value.(type) {
+	case network.Port:
+		for _, p := range u.doc.Ports {
+			if p == value {
+				return nil
+			}
 		}
-	}
-	if !found {
-		u.doc.Ports = append(u.doc.Ports, port)
+		u.doc.Ports = append(u.doc.Ports, value)
+	case network.PortRange:
+		for _, p := range u.doc.PortRanges {
+			if p == value {
+				return nil
+			}
+		}
+		u.doc.PortRanges = append(u.doc.PortRanges, value)
 	}
 	return nil
 }
 
-// closeUnitPort is the old implementation of ClosePort that alters the list of ports on the unit document.
+// closeUnitPorts is the old implementation of ClosePorts that alters the list of ports on the unit document.
 // TODO(domas) 2014-07-04 bug #1337813
 // This is kept in place until the firewaller is updated to watch the OpenedPorts collection.
-func (u *Unit) closeUnitPort(protocol string, number int) (err error) {
-	port := network.Port{Protocol: protocol, Number: number}
-	defer errors.Maskf(&err, "cannot close port %v for unit %q", port, u)
+func (u *Unit) closeUnitPorts(portRange PortRange) error {
+	field, value := unitPortsField(portRange.NetworkPortRange())
 	ops := []txn.Op{{
 		C:      unitsC,
 		Id:     u.doc.Name,
 		Assert: notDeadDoc,
-		Update: bson.D{{"$pull", bson.D{{"ports", port}}}},
+		Update: bson.D{{"$pull", bson.D{{field, value}}}},
 	}}
-	err = u.st.runTransaction(ops)
-	if err != nil {
+	if err := u.st.runTransaction(ops); err != nil {
 		return onAbort(err, ErrDead)
 	}
-	newPorts := make([]network.Port, 0, len(u.doc.Ports))
-	for _, p := range u.doc.Ports {
-		if p != port {
-			newPorts = append(newPorts, p)
+	switch value := value.(type) {
+	case network.Port:
+		newPorts := make([]network.Port, 0, len(u.doc.Ports))
+		for _, p := range u.doc.Ports {
+			if p != value {
+				newPorts = append(newPorts, p)
+			}
+		}
+		u.doc.Ports = newPorts
+	case network.PortRange:
+		newRanges := make([]network.PortRange, 0, len(u.doc.PortRanges))
+		for _, p := range u.doc.PortRanges {
+			if p != value {
+				newRanges = append(newRanges, p)
+			}
 		}
+		u.doc.PortRanges = newRanges
 	}
-	u.doc.Ports = newPorts
 	return nil
 }
 
-// ClosePort sets the policy of the port with protocol and number to be closed.
-func (u *Unit) ClosePort(protocol string, number int) (err error) {
-	ports, err := NewPortRange(u.Name(), number, number, protocol)
-	if err != nil {
-		return err
+// unitPortsField returns the unit document field that records the
+// given port range, and the value recorded there: single TCP and UDP
+// ports go in the old list of ports, everything else is kept as a range.
+func unitPortsField(portRange network.PortRange) (string, interface{}) {
+	if portRange.FromPort == portRange.ToPort && strings.ToLower(portRange.Protocol) != "icmp" {
+		return "ports", network.Port{Protocol: portRange.Protocol, Number: portRange.FromPort}
 	}
-	defer errors.Maskf(&err, "cannot close ports %v for unit %q", ports, u)
-
-	machineId, err := u.AssignedMachineId()
-	if err != nil {
-		return err
-	}
-
-	machinePorts, err := getOrCreatePorts(u.st, machineId)
-	if err != nil {
-		return err
-	}
-
-	// Check if this unit is still storing ports in its own document,
-	// if so - attempt a migration.
-	// TODO(domas) 2014-07-04 bug #1337817: remove second condition
-	if len(u.doc.Ports) != 0 && len(machinePorts.PortsForUnit(u.Name())) == 0 {
-		err = machinePorts.migratePorts(u)
-		if err != nil {
-			unitLogger.Errorf("could not migrate ports collection for unit %v: %v", u, err)
-			return err
-		}
-		err = machinePorts.Refresh()
-		if err != nil {
-			return err
-		}
-	}
-
-	err = machinePorts.ClosePorts(ports)
-	if err != nil {
-		return err
-	}
-	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
-	return u.closeUnitPort(protocol, number)
+	return "portranges", portRange
 }
 
-// OpenedPorts returns a slice containing the open ports of the unit.
-// TODO(domas) 2014-07-04 but #1337817: update this function to return port ranges.
-func (u *Unit) OpenedPorts() []network.Port {
+// OpenedPorts returns the port ranges opened by the unit,
+// sorted by protocol and port.
+func (u *Unit) OpenedPorts() ([]network.PortRange, error) {
 	machineId, err := u.AssignedMachineId()
 	if err != nil {
-		unitLogger.Errorf("Cannot retrieve opened ports list for unit %v: %v", u, err)
-		return nil
+		return nil, err
 	}
-
+	result := []network.PortRange{}
 	machinePorts, err := getPorts(u.st, machineId)
-	result := []network.Port{}
-	if err == nil {
-		ports := machinePorts.PortsForUnit(u.Name())
-		for _, port := range ports {
-			result = append(result, network.Port{
-				Protocol: port.Protocol,
-				Number:   port.FromPort})
-		}
-	} else {
+	if errors.IsNotFound(err) {
 		// Read the port list in the unit document if the ports
 		// document does not exist.
-		result = append([]network.Port{}, u.doc.Ports...)
+		result = append(result, network.PortsToPortRanges(u.doc.Ports)...)
+		result = append(result, u.doc.PortRanges...)
+	} else if err != nil {
+		return nil, err
+	} else {
+		for _, port := range machinePorts.PortsForUnit(u.Name()) {
+			result = append(result, port.NetworkPortRange())
+		}
 	}
-	network.SortPorts(result)
-	return result
+	network.SortPortRanges(result)
+	return result, nil
 }
 
 // CharmURL returns the charm URL this unit is currently using.
@@ -938,21 +1016,19 @@ func (u *Unit) SetCharmURL(curl *charm.URL) (err error) {
 		}
 
 		// Add a reference to the service settings for the new charm.
-		incOp, err := settingsIncRefOp(u.st, u.doc.Service, curl, false)
+		incOps, err := settingsIncRefOps(u.st, u.doc.Service, curl, false)
 		if err != nil {
 			return nil, err
 		}
 
 		// Set the new charm URL.
 		differentCharm := bson.D{{"charmurl", bson.D{{"$ne", curl}}}}
-		ops := []txn.Op{
-			incOp,
-			{
-				C:      unitsC,
-				Id:     u.doc.Name,
-				Assert: append(notDeadDoc, differentCharm...),
-				Update: bson.D{{"$set", bson.D{{"charmurl", curl}}}},
-			}}
+		ops := append(incOps, txn.Op{
+			C:      unitsC,
+			Id:     u.doc.Name,
+			Assert: append(notDeadDoc, differentCharm...),
+			Update: bson.D{{"$set", bson.D{{"charmurl", curl}}}},
+		})
 		if u.doc.CharmURL != nil {
 			// Drop the reference to the old charm.
 			decOps, err := settingsDecRefOps(u.st, u.doc.Service, u.doc.CharmURL)
@@ -1451,6 +1527,15 @@ func (u *Unit) findCleanMachineQuery(requireEmpty bool, cons *constraints.Value)
 	// to err on the side of caution and exclude such machines.
 	var suitableInstanceData []instanceData
 	var suitableTerms bson.D
+	if cons.HaveSpaces() {
+		// Machines are matched to spaces through the availability
+		// zones of the spaces' subnets.
+		resolved, err := u.st.ResolveSpaceConstraints(*cons)
+		if err != nil {
+			return nil, closer, err
+		}
+		cons = &resolved
+	}
 	if cons.Arch != nil && *cons.Arch != "" {
 		suitableTerms = append(suitableTerms, bson.DocElem{"arch", *cons.Arch})
 	}
@@ -1469,6 +1554,9 @@ func (u *Unit) findCleanMachineQuery(requireEmpty bool, cons *constraints.Value)
 	if cons.Tags != nil && len(*cons.Tags) > 0 {
 		suitableTerms = append(suitableTerms, bson.DocElem{"tags", bson.D{{"$all", *cons.Tags}}})
 	}
+	if cons.HaveZones() {
+		suitableTerms = append(suitableTerms, bson.DocElem{"availabilityzone", bson.D{{"$in", *cons.Zones}}})
+	}
 	if len(suitableTerms) > 0 {
 		instanceData := db.C(instanceDataC)
 		err := instanceData.Find(suitableTerms).Select(bson.M{"_id": 1}).All(&suitableInstanceData)
@@ -1743,3 +1831,19 @@ func (u *Unit) AddMetrics(created time.Time, metrics []Metric) (*MetricBatch, er
 	}
 	return u.st.addMetrics(u.UnitTag(), charmUrl, created, metrics)
 }
+
+// UnitsByNumber sorts units by the number in their names, which is
+// the order in which they were added to their service.
+type UnitsByNumber []*Unit
+
+func (u UnitsByNumber) Len() int      { return len(u) }
+func (u UnitsByNumber) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u UnitsByNumber) Less(i, j int) bool {
+	return unitNumber(u[i].Name()) < unitNumber(u[j].Name())
+}
+
+// unitNumber returns the number part of a unit name.
+func unitNumber(name string) int {
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
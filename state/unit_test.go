@@ -984,62 +984,136 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	// Verify no open ports before activity.
-	c.Assert(s.unit.OpenedPorts(), gc.HasLen, 0)
+	open, err := s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.HasLen, 0)
 
 	// Now open and close port.
 	err = s.unit.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	open := s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 80},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{80, 80, "tcp"},
 	})
 
 	err = s.unit.OpenPort("udp", 53)
 	c.Assert(err, gc.IsNil)
-	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 80},
-		{"udp", 53},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{80, 80, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.OpenPort("tcp", 53)
 	c.Assert(err, gc.IsNil)
-	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 80},
-		{"udp", 53},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{80, 80, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.OpenPort("tcp", 443)
 	c.Assert(err, gc.IsNil)
-	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 80},
-		{"tcp", 443},
-		{"udp", 53},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{80, 80, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 443},
-		{"udp", 53},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 443},
-		{"udp", 53},
+	open, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 }
 
+func (s *UnitSuite) TestOpenedPortsRanges(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.OpenPorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPort("udp", 53)
+	c.Assert(err, gc.IsNil)
+
+	ranges, err := s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{
+		{-1, -1, "icmp"},
+		{8000, 8002, "tcp"},
+		{53, 53, "udp"},
+	})
+
+	// Another unit on the same machine cannot open
+	// overlapping ports, but may open ICMP.
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = other.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = other.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+	err = other.OpenPorts("tcp", 7990, 8000)
+	c.Assert(err, gc.ErrorMatches, `cannot open ports 7990-8000/tcp for unit "wordpress/1": cannot open ports 7990-8000/tcp on machine 0 due to conflict`)
+	err = other.OpenPorts("tcp", 7990, 7999)
+	c.Assert(err, gc.IsNil)
+
+	// Ranges must be closed as they were opened.
+	err = s.unit.ClosePort("tcp", 8001)
+	c.Assert(err, gc.ErrorMatches, `cannot close ports 8001-8001/tcp for unit "wordpress/0": mismatched port ranges 8000-8002/tcp and 8001-8001/tcp`)
+	err = s.unit.ClosePorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.ClosePorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+	ranges, err = s.unit.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{{53, 53, "udp"}})
+	ranges, err = other.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ranges, gc.DeepEquals, []network.PortRange{
+		{-1, -1, "icmp"},
+		{7990, 7999, "tcp"},
+	})
+}
+
+func (s *UnitSuite) TestOpenPortsInvalid(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.OpenPorts("tcp", 90, 80)
+	c.Assert(err, gc.ErrorMatches, "invalid port range 90-80")
+	err = s.unit.OpenPorts("icmp", 80, 80)
+	c.Assert(err, gc.ErrorMatches, "invalid ICMP port range 80-80")
+	err = s.unit.OpenPorts("sctp", 80, 80)
+	c.Assert(err, gc.ErrorMatches, `invalid protocol "sctp"`)
+}
+
 func (s *UnitSuite) TestOpenClosePortWhenDying(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
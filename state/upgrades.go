@@ -4,6 +4,7 @@
 package state
 
 import (
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
@@ -117,3 +118,84 @@ func AddEnvironmentUUIDToStateServerDoc(st *State) error {
 
 	return st.runTransaction(ops)
 }
+
+// AddCharmRefCounts sets the ref count of each charm to the number of
+// service settings documents that refer to it.
+func AddCharmRefCounts(st *State) error {
+	err := st.ResumeTransactions()
+	if err != nil {
+		return err
+	}
+
+	settingsrefs, closer := st.getCollection(settingsrefsC)
+	defer closer()
+	refs := make(map[string]int)
+	var refDoc struct {
+		Id string `bson:"_id"`
+	}
+	iter := settingsrefs.Find(nil).Select(bson.D{{"_id", 1}}).Iter()
+	for iter.Next(&refDoc) {
+		// Service settings keys have the form "s#<service>#<charm url>".
+		parts := strings.SplitN(refDoc.Id, "#", 3)
+		if len(parts) == 3 {
+			refs[parts[2]]++
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return errors.Annotate(err, "cannot read service settings refs")
+	}
+
+	charms, closer := st.getCollection(charmsC)
+	defer closer()
+	var charmDocs []struct {
+		Id       string `bson:"_id"`
+		RefCount int    `bson:"refcount"`
+	}
+	if err := charms.Find(nil).Select(bson.D{{"refcount", 1}}).All(&charmDocs); err != nil {
+		return errors.Annotate(err, "cannot read charms")
+	}
+	var ops []txn.Op
+	for _, doc := range charmDocs {
+		upgradesLogger.Debugf("setting ref count of charm %q to %d", doc.Id, refs[doc.Id])
+		ops = append(ops, txn.Op{
+			C:      charmsC,
+			Id:     doc.Id,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$set", bson.D{{"refcount", refs[doc.Id]}}}},
+		})
+	}
+	return st.runTransaction(ops)
+}
+
+// AddCharmAndToolsAddedTimes records the current time as the time
+// each charm and tools tarball was added, where that is not known, so
+// that they are kept for the full grace period before being collected.
+func AddCharmAndToolsAddedTimes(st *State) error {
+	err := st.ResumeTransactions()
+	if err != nil {
+		return err
+	}
+
+	now := nowToTheSecond()
+	var ops []txn.Op
+	for _, name := range []string{charmsC, toolsmetadataC} {
+		coll, closer := st.getCollection(name)
+		var docs []struct {
+			Id string `bson:"_id"`
+		}
+		err := coll.Find(bson.D{{"added", bson.D{{"$exists", false}}}}).Select(bson.D{{"_id", 1}}).All(&docs)
+		closer()
+		if err != nil {
+			return errors.Annotatef(err, "cannot read %s", name)
+		}
+		for _, doc := range docs {
+			ops = append(ops, txn.Op{
+				C:      name,
+				Id:     doc.Id,
+				Assert: txn.DocExists,
+				Update: bson.D{{"$set", bson.D{{"added", now}}}},
+			})
+		}
+	}
+	return st.runTransaction(ops)
+}
@@ -4,15 +4,19 @@
 package state
 
 import (
+	"strings"
 	"time"
 
 	gitjujutesting "github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
+	charmtesting "gopkg.in/juju/charm.v3/testing"
 	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/txn"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/state/toolstorage"
 	"github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 )
 
 type upgradesSuite struct {
@@ -179,3 +183,67 @@ func (s *upgradesSuite) TestAddEnvironmentUUIDToStateServerDocIdempotent(c *gc.C
 	c.Assert(err, gc.IsNil)
 	c.Assert(info.EnvironmentTag, gc.Equals, tag)
 }
+
+func (s *upgradesSuite) TestAddCharmRefCounts(c *gc.C) {
+	_, err := s.state.AddAdminUser("pass")
+	c.Assert(err, gc.IsNil)
+	mysql := addCharm(c, s.state, "quantal", charmtesting.Charms.CharmDir("mysql"))
+	dummy := addCharm(c, s.state, "quantal", charmtesting.Charms.CharmDir("dummy"))
+	for _, name := range []string{"mysql1", "mysql2"} {
+		_, err := s.state.AddService(name, "user-admin", mysql, nil)
+		c.Assert(err, gc.IsNil)
+	}
+
+	// Clear the ref counts, as they were before they were introduced.
+	charms, closer := s.state.getCollection(charmsC)
+	defer closer()
+	_, err = charms.UpdateAll(nil, bson.D{{"$unset", bson.D{{"refcount", nil}}}})
+	c.Assert(err, gc.IsNil)
+
+	for i := 0; i < 2; i++ {
+		err = AddCharmRefCounts(s.state)
+		c.Assert(err, gc.IsNil)
+		var doc charmDoc
+		err = charms.FindId(mysql.URL().String()).One(&doc)
+		c.Assert(err, gc.IsNil)
+		c.Assert(doc.RefCount, gc.Equals, 2)
+		err = charms.FindId(dummy.URL().String()).One(&doc)
+		c.Assert(err, gc.IsNil)
+		c.Assert(doc.RefCount, gc.Equals, 0)
+	}
+}
+
+func (s *upgradesSuite) TestAddCharmAndToolsAddedTimes(c *gc.C) {
+	dummy := addCharm(c, s.state, "quantal", charmtesting.Charms.CharmDir("dummy"))
+	storage, err := s.state.ToolsStorage()
+	c.Assert(err, gc.IsNil)
+	defer storage.Close()
+	err = storage.AddTools(strings.NewReader("tools"), toolstorage.Metadata{Version: version.Current, Size: 5})
+	c.Assert(err, gc.IsNil)
+
+	// Clear the added times, as they were before they were recorded.
+	for _, name := range []string{charmsC, toolsmetadataC} {
+		coll, closer := s.state.getCollection(name)
+		_, err = coll.UpdateAll(nil, bson.D{{"$unset", bson.D{{"added", nil}}}})
+		closer()
+		c.Assert(err, gc.IsNil)
+	}
+
+	before := time.Now().Add(-time.Second)
+	err = AddCharmAndToolsAddedTimes(s.state)
+	c.Assert(err, gc.IsNil)
+	ch, err := s.state.Charm(dummy.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.doc.Added.After(before), jc.IsTrue)
+	metadata, err := storage.Metadata(version.Current)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata.Added.After(before), jc.IsTrue)
+
+	// Times that are already known are left alone.
+	added := ch.doc.Added
+	err = AddCharmAndToolsAddedTimes(s.state)
+	c.Assert(err, gc.IsNil)
+	ch, err = s.state.Charm(dummy.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.doc.Added.Equal(added), jc.IsTrue)
+}
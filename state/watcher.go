@@ -1187,6 +1187,12 @@ func (s *Service) Watch() NotifyWatcher {
 	return newEntityWatcher(s.st, servicesC, s.doc.Name)
 }
 
+// WatchResources returns a watcher for observing changes to the
+// resources attached to a service.
+func (s *Service) WatchResources() NotifyWatcher {
+	return newEntityWatcher(s.st, resourcesC, s.doc.Name)
+}
+
 // Watch returns a watcher for observing changes to a unit.
 func (u *Unit) Watch() NotifyWatcher {
 	return newEntityWatcher(u.st, unitsC, u.doc.Name)
@@ -1897,3 +1903,104 @@ func (w *machineInterfacesWatcher) loop() error {
 		}
 	}
 }
+
+// runTasksWatcher notifies of the ids of run tasks that are ready to
+// be executed by a machine's agent.
+type runTasksWatcher struct {
+	commonWatcher
+	prefix string
+	out    chan []string
+}
+
+var _ StringsWatcher = (*runTasksWatcher)(nil)
+
+// WatchRunTasks returns a StringsWatcher that notifies of the ids of
+// run tasks that are waiting to be executed by the machine's agent,
+// either on the machine itself or on the units assigned to it.
+func (m *Machine) WatchRunTasks() StringsWatcher {
+	w := &runTasksWatcher{
+		commonWatcher: commonWatcher{st: m.st},
+		prefix:        runTaskPrefix(m.doc.Id),
+		out:           make(chan []string),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Changes returns the event channel for w.
+func (w *runTasksWatcher) Changes() <-chan []string {
+	return w.out
+}
+
+// pending returns the ids of the pending tasks matching the query.
+func (w *runTasksWatcher) pending(query bson.D) (set.Strings, error) {
+	runTasks, closer := w.st.getCollection(runTasksC)
+	defer closer()
+
+	ids := set.NewStrings()
+	var doc struct {
+		Id string `bson:"_id"`
+	}
+	query = append(query, bson.DocElem{Name: "status", Value: RunTaskPending})
+	iter := runTasks.Find(query).Select(bson.D{{"_id", 1}}).Iter()
+	for iter.Next(&doc) {
+		ids.Add(doc.Id)
+	}
+	return ids, iter.Close()
+}
+
+func (w *runTasksWatcher) loop() error {
+	in := make(chan watcher.Change)
+	filter := func(id interface{}) bool {
+		k, ok := id.(string)
+		return ok && strings.HasPrefix(k, w.prefix)
+	}
+	w.st.watcher.WatchCollectionWithFilter(runTasksC, in, filter)
+	defer w.st.watcher.UnwatchCollection(runTasksC, in)
+
+	prefixQuery := bson.D{{"$regex", "^" + regexp.QuoteMeta(w.prefix)}}
+	changes, err := w.pending(bson.D{{"_id", prefixQuery}})
+	if err != nil {
+		return err
+	}
+	out := w.out
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case ch := <-in:
+			updates, ok := collect(ch, in, w.tomb.Dying())
+			if !ok {
+				return tomb.ErrDying
+			}
+			var ids []string
+			for id, exists := range updates {
+				if exists {
+					ids = append(ids, id.(string))
+				}
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			pending, err := w.pending(bson.D{{"_id", bson.D{{"$in", ids}}}})
+			if err != nil {
+				return err
+			}
+			for _, id := range pending.Values() {
+				changes.Add(id)
+			}
+			if !changes.IsEmpty() {
+				out = w.out
+			}
+		case out <- changes.SortedValues():
+			changes = set.NewStrings()
+			out = nil
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package stats implements the counters, gauges and histograms that
+// juju agents keep about their own operation, and writes them out in
+// the Prometheus text exposition format.
+//
+// Each metric has a fixed set of label names given when it is
+// registered. Values are recorded against a set of label values,
+// which must match the label names in number.
+package stats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry used by the package level functions. It
+// holds the metrics exposed by the API server.
+var Default = NewRegistry()
+
+// DefaultBuckets holds histogram bucket boundaries, in seconds,
+// suitable for measuring request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// NewCounter registers a new counter with the default registry.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return Default.NewCounter(name, help, labelNames...)
+}
+
+// NewGauge registers a new gauge with the default registry.
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return Default.NewGauge(name, help, labelNames...)
+}
+
+// NewHistogram registers a new histogram with the default registry.
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labelNames...)
+}
+
+// metric is implemented by all the kinds of metric held in a
+// Registry.
+type metric interface {
+	// describe returns the metric's description.
+	describe() *metricDesc
+
+	// writeSamples writes the metric's samples in text format.
+	writeSamples(w io.Writer) error
+}
+
+// Registry holds a set of named metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := m.describe().name
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Sprintf("metric %q registered twice", name))
+	}
+	r.metrics[name] = m
+}
+
+// NewCounter registers and returns a new counter.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{values: newValueSet(name, help, "counter", labelNames)}
+	r.register(c)
+	return c
+}
+
+// NewGauge registers and returns a new gauge.
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{values: newValueSet(name, help, "gauge", labelNames)}
+	r.register(g)
+	return g
+}
+
+// NewHistogram registers and returns a new histogram counting
+// observations into the given buckets, which must be sorted in
+// increasing order. If buckets is nil, DefaultBuckets is used.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("buckets for histogram %q are not sorted", name))
+	}
+	h := &Histogram{
+		metricDesc: metricDesc{name, help, "histogram", labelNames},
+		buckets:    buckets,
+		samples:    make(map[string]*histogramSample),
+	}
+	r.register(h)
+	return h
+}
+
+// WriteText writes all the metrics in the registry to w in the
+// Prometheus text exposition format, ordered by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]metric, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		d := m.describe()
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind); err != nil {
+			return err
+		}
+		if err := m.writeSamples(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricDesc describes a metric.
+type metricDesc struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+func (d *metricDesc) describe() *metricDesc {
+	return d
+}
+
+// key returns a map key for the given label values, checking that
+// there is one for each label name.
+func (d *metricDesc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %q has %d labels, got %d values", d.name, len(d.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+// labels formats the label pairs for the given key, with any extra
+// label appended.
+func (d *metricDesc) labels(key string, extra ...string) string {
+	var pairs []string
+	if len(d.labelNames) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, name := range d.labelNames {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// valueSet holds a single float value for each set of label values.
+type valueSet struct {
+	metricDesc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newValueSet(name, help, kind string, labelNames []string) valueSet {
+	return valueSet{
+		metricDesc: metricDesc{name, help, kind, labelNames},
+		values:     make(map[string]float64),
+	}
+}
+
+func (s *valueSet) update(f func(float64) float64, labelValues []string) {
+	key := s.key(labelValues)
+	s.mu.Lock()
+	s.values[key] = f(s.values[key])
+	s.mu.Unlock()
+}
+
+func (s *valueSet) get(labelValues []string) float64 {
+	key := s.key(labelValues)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+func (s *valueSet) writeSamples(w io.Writer) error {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = fmt.Sprintf("%s%s %s\n", s.name, s.labels(key), formatFloat(s.values[key]))
+	}
+	s.mu.Unlock()
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a metric whose values only ever increase.
+type Counter struct {
+	values valueSet
+}
+
+func (c *Counter) describe() *metricDesc {
+	return &c.values.metricDesc
+}
+
+func (c *Counter) writeSamples(w io.Writer) error {
+	return c.values.writeSamples(w)
+}
+
+// Inc increments the counter for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter for the
+// given label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %q cannot decrease", c.values.name))
+	}
+	c.values.update(func(old float64) float64 { return old + v }, labelValues)
+}
+
+// Value returns the current value of the counter for the given
+// label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.values.get(labelValues)
+}
+
+// Gauge is a metric whose values may go up and down.
+type Gauge struct {
+	values valueSet
+}
+
+func (g *Gauge) describe() *metricDesc {
+	return &g.values.metricDesc
+}
+
+func (g *Gauge) writeSamples(w io.Writer) error {
+	return g.values.writeSamples(w)
+}
+
+// Set sets the gauge for the given label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.values.update(func(float64) float64 { return v }, labelValues)
+}
+
+// Add adds v to the gauge for the given label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.values.update(func(old float64) float64 { return old + v }, labelValues)
+}
+
+// Inc increments the gauge for the given label values.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge for the given label values.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value returns the current value of the gauge for the given label
+// values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.values.get(labelValues)
+}
+
+// Histogram is a metric that counts observations into buckets.
+type Histogram struct {
+	metricDesc
+	buckets []float64
+
+	mu      sync.Mutex
+	samples map[string]*histogramSample
+}
+
+type histogramSample struct {
+	// counts holds the number of observations that fell into each
+	// bucket; the final element counts observations larger than
+	// the last bucket.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records the observation v for the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.samples[key]
+	if !ok {
+		s = &histogramSample{counts: make([]uint64, len(h.buckets)+1)}
+		h.samples[key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.count++
+	s.sum += v
+}
+
+// Count returns the number of observations recorded for the given
+// label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.samples[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) writeSamples(w io.Writer) error {
+	h.mu.Lock()
+	keys := make([]string, 0, len(h.samples))
+	for key := range h.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, key := range keys {
+		s := h.samples[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			lines = append(lines, fmt.Sprintf("%s_bucket%s %d\n", h.name, h.labels(key, "le", formatFloat(bound)), cumulative))
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s_bucket%s %d\n", h.name, h.labels(key, "le", "+Inf"), s.count),
+			fmt.Sprintf("%s_sum%s %s\n", h.name, h.labels(key), formatFloat(s.sum)),
+			fmt.Sprintf("%s_count%s %d\n", h.name, h.labels(key), s.count),
+		)
+	}
+	h.mu.Unlock()
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package stats_test
+
+import (
+	"bytes"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/stats"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type statsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&statsSuite{})
+
+func (*statsSuite) TestCounter(c *gc.C) {
+	r := stats.NewRegistry()
+	counter := r.NewCounter("requests_total", "Requests served.", "method")
+	counter.Inc("Get")
+	counter.Inc("Get")
+	counter.Add(3, "Put")
+	c.Assert(counter.Value("Get"), gc.Equals, 2.0)
+	c.Assert(counter.Value("Put"), gc.Equals, 3.0)
+	c.Assert(counter.Value("Delete"), gc.Equals, 0.0)
+	c.Assert(func() { counter.Add(-1, "Get") }, gc.PanicMatches, `counter "requests_total" cannot decrease`)
+	c.Assert(func() { counter.Inc() }, gc.PanicMatches, `metric "requests_total" has 1 labels, got 0 values`)
+
+	var buf bytes.Buffer
+	err := r.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(buf.String(), gc.Equals, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{method="Get"} 2
+requests_total{method="Put"} 3
+`)
+}
+
+func (*statsSuite) TestGauge(c *gc.C) {
+	r := stats.NewRegistry()
+	gauge := r.NewGauge("connections", "Open connections.")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+	c.Assert(gauge.Value(), gc.Equals, 1.0)
+	gauge.Set(0.5)
+
+	var buf bytes.Buffer
+	err := r.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(buf.String(), gc.Equals, `# HELP connections Open connections.
+# TYPE connections gauge
+connections 0.5
+`)
+}
+
+func (*statsSuite) TestHistogram(c *gc.C) {
+	r := stats.NewRegistry()
+	h := r.NewHistogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "facade")
+	h.Observe(0.05, "Client")
+	h.Observe(0.1, "Client")
+	h.Observe(0.5, "Client")
+	h.Observe(2, "Client")
+	c.Assert(h.Count("Client"), gc.Equals, uint64(4))
+	c.Assert(h.Count("Uniter"), gc.Equals, uint64(0))
+
+	var buf bytes.Buffer
+	err := r.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(buf.String(), gc.Equals, `# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{facade="Client",le="0.1"} 2
+latency_seconds_bucket{facade="Client",le="1"} 3
+latency_seconds_bucket{facade="Client",le="+Inf"} 4
+latency_seconds_sum{facade="Client"} 2.65
+latency_seconds_count{facade="Client"} 4
+`)
+}
+
+func (*statsSuite) TestHistogramBucketsMustBeSorted(c *gc.C) {
+	r := stats.NewRegistry()
+	c.Assert(func() {
+		r.NewHistogram("h", "", []float64{1, 0.1})
+	}, gc.PanicMatches, `buckets for histogram "h" are not sorted`)
+}
+
+func (*statsSuite) TestRegisterTwice(c *gc.C) {
+	r := stats.NewRegistry()
+	r.NewCounter("x", "")
+	c.Assert(func() { r.NewGauge("x", "") }, gc.PanicMatches, `metric "x" registered twice`)
+}
+
+func (*statsSuite) TestEscaping(c *gc.C) {
+	r := stats.NewRegistry()
+	counter := r.NewCounter("b", "Help with \\ and\nnewline.", "label")
+	counter.Inc("a \"quoted\"\\value\n")
+	r.NewCounter("a", "Sorted first.")
+
+	var buf bytes.Buffer
+	err := r.WriteText(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(buf.String(), gc.Equals, `# HELP a Sorted first.
+# TYPE a counter
+# HELP b Help with \\ and\nnewline.
+# TYPE b counter
+b{label="a \"quoted\"\\value\n"} 1
+`)
+}
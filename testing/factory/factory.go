@@ -231,7 +231,7 @@ func (factory *Factory) MakeCharm(c *gc.C, params *CharmParams) *state.Charm {
 	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
 	bundleSHA256 := factory.UniqueString("bundlesha")
 	c.Assert(err, gc.IsNil)
-	charm, err := factory.st.AddCharm(ch, curl, bundleURL, bundleSHA256)
+	charm, err := factory.st.AddCharm(ch, curl, bundleURL, bundleSHA256, 0)
 
 	c.Assert(err, gc.IsNil)
 	return charm
@@ -29,5 +29,19 @@ func stepsFor121() []Step {
 				return state.AddStateUsersAsEnvironUsers(context.State())
 			},
 		},
+		&upgradeStep{
+			description: "add ref counts to charms",
+			targets:     []Target{DatabaseMaster},
+			run: func(context Context) error {
+				return state.AddCharmRefCounts(context.State())
+			},
+		},
+		&upgradeStep{
+			description: "record when charms and tools were added",
+			targets:     []Target{DatabaseMaster},
+			run: func(context Context) error {
+				return state.AddCharmAndToolsAddedTimes(context.State())
+			},
+		},
 	}
 }
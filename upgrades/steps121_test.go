@@ -21,6 +21,8 @@ func (s *steps121Suite) TestUpgradeOperationsContent(c *gc.C) {
 		"rename the user LastConnection field to LastLogin",
 		"add environment uuid to state server doc",
 		"add all users in state as environment users",
+		"add ref counts to charms",
+		"record when charms and tools were added",
 	}
 
 	upgradeSteps := upgrades.StepsFor121()
@@ -0,0 +1,220 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package autoscaler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/juju"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.autoscaler")
+
+// interval sets how often scaling policies are evaluated.
+var interval = 30 * time.Second
+
+// NewAutoscaler returns a worker that periodically evaluates the
+// scaling policies of all services, and adds or removes units as
+// they require. Units are added with the policy's placement, or on
+// new machines chosen according to the service's constraints; the
+// most recently added units are removed first, and the machines they
+// leave empty are destroyed.
+func NewAutoscaler(st *state.State) worker.Worker {
+	f := func(stop <-chan struct{}) error {
+		scalings, err := st.AllScalings()
+		if err != nil {
+			return err
+		}
+		for _, scaling := range scalings {
+			if err := scale(st, scaling); err != nil {
+				logger.Errorf("cannot scale service %q: %v", scaling.ServiceName(), err)
+			}
+		}
+		return nil
+	}
+	return worker.NewPeriodicWorker(f, interval)
+}
+
+// scale adds or removes units of the scaling's service as its policy
+// requires.
+func scale(st *state.State, scaling *state.Scaling) error {
+	if err := releaseMachines(st, scaling); err != nil {
+		return err
+	}
+	service, err := st.Service(scaling.ServiceName())
+	if err != nil {
+		return err
+	}
+	if service.Life() != state.Alive {
+		return nil
+	}
+	eval, err := scaling.Evaluate(time.Now())
+	if err != nil {
+		return err
+	}
+	checker := common.NewBlockChecker(st)
+	var action string
+	switch {
+	case eval.Desired > eval.Units:
+		if err := checker.ChangeAllowed(); err != nil {
+			logger.Infof("not scaling service %q: %v", service.Name(), err)
+			return nil
+		}
+		n := eval.Desired - eval.Units
+		if err := addUnits(st, service, n, scaling.Policy().Placement); err != nil {
+			return err
+		}
+		action = fmt.Sprintf("added %d %s: %s", n, unitsNoun(n), eval.Reason)
+	case eval.Desired < eval.Units:
+		if err := checker.RemoveAllowed(); err != nil {
+			logger.Infof("not scaling service %q: %v", service.Name(), err)
+			return nil
+		}
+		n := eval.Units - eval.Desired
+		if err := removeUnits(scaling, service, n); err != nil {
+			return err
+		}
+		action = fmt.Sprintf("removed %d %s: %s", n, unitsNoun(n), eval.Reason)
+	default:
+		return nil
+	}
+	logger.Infof("service %q %s", service.Name(), action)
+	return scaling.Record(action)
+}
+
+func unitsNoun(n int) string {
+	if n == 1 {
+		return "unit"
+	}
+	return "units"
+}
+
+// addUnits adds n units to the service, placed as directed by
+// placement if it is not empty.
+func addUnits(st *state.State, service *state.Service, n int, placement string) error {
+	if placement == "" {
+		_, err := juju.AddUnits(st, service, n, "")
+		return err
+	}
+	// A placement directive places a single unit.
+	for i := 0; i < n; i++ {
+		if _, err := juju.AddUnits(st, service, 1, placement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeUnits destroys the n most recently added alive units of the
+// service, and records that the machines hosting them are to be
+// destroyed once they are empty. Machines chosen by the policy's
+// placement directive are kept.
+func removeUnits(scaling *state.Scaling, service *state.Service, n int) error {
+	units, err := service.AllUnits()
+	if err != nil {
+		return err
+	}
+	var alive []*state.Unit
+	for _, unit := range units {
+		if unit.Life() == state.Alive {
+			alive = append(alive, unit)
+		}
+	}
+	sort.Sort(sort.Reverse(state.UnitsByNumber(alive)))
+	if n > len(alive) {
+		n = len(alive)
+	}
+	var machineIds []string
+	for _, unit := range alive[:n] {
+		machineId, err := unit.AssignedMachineId()
+		if state.IsNotAssigned(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if machineId != scaling.Policy().Placement {
+			machineIds = append(machineIds, machineId)
+		}
+	}
+	if len(machineIds) > 0 {
+		if err := scaling.ReleaseMachines(machineIds); err != nil {
+			return err
+		}
+	}
+	for _, unit := range alive[:n] {
+		if err := unit.Destroy(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseMachines destroys the machines released by the scaling that
+// no longer host units.
+func releaseMachines(st *state.State, scaling *state.Scaling) error {
+	machineIds := scaling.ReleasingMachines()
+	if len(machineIds) == 0 {
+		return nil
+	}
+	if err := common.NewBlockChecker(st).RemoveAllowed(); err != nil {
+		logger.Infof("not releasing machines of service %q: %v", scaling.ServiceName(), err)
+		return nil
+	}
+	for _, machineId := range machineIds {
+		released, err := releaseMachine(st, machineId)
+		if err != nil {
+			return errors.Annotatef(err, "cannot release machine %s", machineId)
+		}
+		if released {
+			if err := scaling.MachineReleased(machineId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// releaseMachine destroys the machine if it hosts no units, and
+// reports whether it no longer needs to be released. A machine that
+// hosts alive units again is kept.
+func releaseMachine(st *state.State, machineId string) (bool, error) {
+	machine, err := st.Machine(machineId)
+	if errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if machine.Life() != state.Alive || machine.IsManager() {
+		return true, nil
+	}
+	units, err := machine.Units()
+	if err != nil {
+		return false, err
+	}
+	for _, unit := range units {
+		if unit.Life() == state.Alive {
+			return true, nil
+		}
+	}
+	if len(units) > 0 {
+		// Wait for the dying units to be removed.
+		return false, nil
+	}
+	err = machine.Destroy()
+	if _, ok := err.(*state.HasContainersError); ok {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	logger.Infof("destroyed machine %s released by scaling", machineId)
+	return true, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package autoscaler_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/autoscaler"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type AutoscalerSuite struct {
+	testing.JujuConnSuite
+	charm   *state.Charm
+	service *state.Service
+	units   []*state.Unit
+}
+
+var _ = gc.Suite(&AutoscalerSuite{})
+
+func (s *AutoscalerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(autoscaler.Interval, 10*time.Millisecond)
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	s.service = s.AddTestingService(c, "wordpress", s.charm)
+	s.units = nil
+	for i := 0; i < 2; i++ {
+		unit, err := s.service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(s.charm.URL())
+		c.Assert(err, gc.IsNil)
+		s.units = append(s.units, unit)
+	}
+}
+
+func (s *AutoscalerSuite) reportLoad(c *gc.C, load string) {
+	now := time.Now()
+	for _, unit := range s.units {
+		_, err := unit.AddMetrics(now, []state.Metric{{Key: "load", Value: load, Time: now}})
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *AutoscalerSuite) startAutoscaler(c *gc.C) {
+	w := autoscaler.NewAutoscaler(s.State)
+	s.AddCleanup(func(c *gc.C) {
+		c.Assert(worker.Stop(w), gc.IsNil)
+	})
+}
+
+func (s *AutoscalerSuite) waitForAliveUnits(c *gc.C, expected ...string) {
+	var alive []string
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		units, err := s.service.AllUnits()
+		c.Assert(err, gc.IsNil)
+		alive = nil
+		for _, unit := range units {
+			if unit.Life() == state.Alive {
+				alive = append(alive, unit.Name())
+			}
+		}
+		if len(alive) == len(expected) {
+			c.Assert(alive, jc.SameContents, expected)
+			return
+		}
+	}
+	c.Fatalf("timed out waiting for units %v; got %v", expected, alive)
+}
+
+func above(f float64) state.ScalingRule {
+	return state.ScalingRule{Metric: "load", Above: &f}
+}
+
+func below(f float64) state.ScalingRule {
+	return state.ScalingRule{Metric: "load", Below: &f}
+}
+
+func (s *AutoscalerSuite) TestScaleUp(c *gc.C) {
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Min:      1,
+		Max:      3,
+		Cooldown: time.Hour,
+		Rules:    []state.ScalingRule{above(0.8)},
+	})
+	c.Assert(err, gc.IsNil)
+	s.reportLoad(c, "0.9")
+	s.startAutoscaler(c)
+	s.waitForAliveUnits(c, "wordpress/0", "wordpress/1", "wordpress/2")
+
+	unit, err := s.State.Unit("wordpress/2")
+	c.Assert(err, gc.IsNil)
+	_, err = unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scaling.LastAction(), gc.Equals, "added 1 unit: load is 0.9, above 0.8")
+}
+
+func (s *AutoscalerSuite) TestScaleDown(c *gc.C) {
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Min:   1,
+		Max:   3,
+		Rules: []state.ScalingRule{below(0.2)},
+	})
+	c.Assert(err, gc.IsNil)
+	s.reportLoad(c, "0.1")
+	s.startAutoscaler(c)
+	s.waitForAliveUnits(c, "wordpress/0")
+}
+
+func (s *AutoscalerSuite) TestScaleDownDestroysEmptyMachines(c *gc.C) {
+	var machines []*state.Machine
+	for _, unit := range s.units {
+		machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		err = unit.AssignToMachine(machine)
+		c.Assert(err, gc.IsNil)
+		machines = append(machines, machine)
+	}
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Min:   1,
+		Max:   3,
+		Rules: []state.ScalingRule{below(0.2)},
+	})
+	c.Assert(err, gc.IsNil)
+	s.reportLoad(c, "0.1")
+	s.startAutoscaler(c)
+	s.waitForAliveUnits(c, "wordpress/0")
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err := machines[1].Refresh()
+		c.Assert(err, gc.IsNil)
+		if machines[1].Life() != state.Alive {
+			break
+		}
+	}
+	c.Assert(machines[1].Life(), gc.Equals, state.Dying)
+	err = machines[0].Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines[0].Life(), gc.Equals, state.Alive)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		scaling, err := s.service.Scaling()
+		c.Assert(err, gc.IsNil)
+		if len(scaling.ReleasingMachines()) == 0 {
+			return
+		}
+	}
+	c.Fatalf("timed out waiting for machine %s to be released", machines[1].Id())
+}
+
+func (s *AutoscalerSuite) TestCooldown(c *gc.C) {
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Max:      3,
+		Cooldown: time.Hour,
+		Rules:    []state.ScalingRule{below(0.2)},
+	})
+	c.Assert(err, gc.IsNil)
+	scaling, err := s.service.Scaling()
+	c.Assert(err, gc.IsNil)
+	err = scaling.Record("added 2 units")
+	c.Assert(err, gc.IsNil)
+	s.reportLoad(c, "0.1")
+	s.startAutoscaler(c)
+	time.Sleep(coretesting.ShortWait)
+	s.waitForAliveUnits(c, "wordpress/0", "wordpress/1")
+
+	// Without a cooldown, the service is scaled down.
+	err = s.service.SetScalingPolicy(state.ScalingPolicy{
+		Min:   1,
+		Max:   3,
+		Rules: []state.ScalingRule{below(0.2)},
+	})
+	c.Assert(err, gc.IsNil)
+	s.waitForAliveUnits(c, "wordpress/0")
+}
+
+func (s *AutoscalerSuite) TestBlocked(c *gc.C) {
+	err := s.service.SetScalingPolicy(state.ScalingPolicy{
+		Max:   3,
+		Rules: []state.ScalingRule{below(0.2)},
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.RemoveBlock, "keep everything")
+	c.Assert(err, gc.IsNil)
+	s.reportLoad(c, "0.1")
+	s.startAutoscaler(c)
+	time.Sleep(coretesting.ShortWait)
+	s.waitForAliveUnits(c, "wordpress/0", "wordpress/1")
+}
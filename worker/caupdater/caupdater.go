@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package caupdater
+
+import (
+	"fmt"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.caupdater")
+
+// CACertUpdater is responsible for propagating the certificates of
+// the CAs trusted by the environment.
+//
+// In practice, CACertUpdater is used by agents to watch the trusted
+// CA certificates in state and write the changes to the agent's
+// config file, so that the agents keep trusting the state servers
+// while the environment's CA is rotated.
+type CACertUpdater struct {
+	getter CACertGetter
+	setter CACertSetter
+}
+
+// CACertGetter is an interface that is provided to NewCACertUpdater
+// which can be used to watch for changes to the trusted CA
+// certificates.
+type CACertGetter interface {
+	CACert() (string, error)
+	WatchCACert() (watcher.NotifyWatcher, error)
+}
+
+// CACertSetter is an interface that is provided to NewCACertUpdater
+// whose SetCACert method will be invoked whenever the trusted CA
+// certificates change.
+type CACertSetter interface {
+	SetCACert(caCert string) error
+}
+
+// NewCACertUpdater returns a worker.Worker that watches for changes to
+// the trusted CA certificates and then sets them on the CACertSetter.
+func NewCACertUpdater(getter CACertGetter, setter CACertSetter) worker.Worker {
+	return worker.NewNotifyWorker(&CACertUpdater{
+		getter: getter,
+		setter: setter,
+	})
+}
+
+func (c *CACertUpdater) SetUp() (watcher.NotifyWatcher, error) {
+	return c.getter.WatchCACert()
+}
+
+func (c *CACertUpdater) Handle() error {
+	caCert, err := c.getter.CACert()
+	if err != nil {
+		return fmt.Errorf("error getting CA certificate: %v", err)
+	}
+	if err := c.setter.SetCACert(caCert); err != nil {
+		return fmt.Errorf("error setting CA certificate: %v", err)
+	}
+	logger.Infof("CA certificate updated")
+	return nil
+}
+
+func (c *CACertUpdater) TearDown() error {
+	return nil
+}
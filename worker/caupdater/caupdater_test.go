@@ -0,0 +1,75 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package caupdater_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cert"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/caupdater"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type CACertUpdaterSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&CACertUpdaterSuite{})
+
+type caCertSetter struct {
+	caCerts chan string
+	err     error
+}
+
+func (s *caCertSetter) SetCACert(caCert string) error {
+	s.caCerts <- caCert
+	return s.err
+}
+
+func (s *CACertUpdaterSuite) TestStartStop(c *gc.C) {
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	worker := caupdater.NewCACertUpdater(st.Machiner(), &caCertSetter{})
+	worker.Kill()
+	c.Assert(worker.Wait(), gc.IsNil)
+}
+
+func (s *CACertUpdaterSuite) TestCACertChange(c *gc.C) {
+	setter := &caCertSetter{caCerts: make(chan string, 1)}
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobHostUnits)
+	worker := caupdater.NewCACertUpdater(st.Machiner(), setter)
+	defer func() { c.Assert(worker.Wait(), gc.IsNil) }()
+	defer worker.Kill()
+	s.BackingState.StartSync()
+
+	// SetCACert should be called with the initial value,
+	// and then the updated value.
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetCACert to be called first")
+	case caCert := <-setter.caCerts:
+		c.Assert(caCert, gc.Equals, coretesting.CACert)
+	}
+	newCACert, _, err := cert.NewCA("new", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"ca-cert": coretesting.CACert + newCACert,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	select {
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for SetCACert to be called second")
+	case caCert := <-setter.caCerts:
+		c.Assert(caCert, gc.Equals, coretesting.CACert+newCACert)
+	}
+}
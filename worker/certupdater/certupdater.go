@@ -0,0 +1,243 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certupdater
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.certupdater")
+
+var (
+	// checkInterval holds how often the certificate is checked,
+	// regardless of changes to the state server addresses or CA.
+	checkInterval = 6 * time.Hour
+
+	// renewBefore holds how long before it expires the certificate
+	// is replaced.
+	renewBefore = 30 * 24 * time.Hour
+
+	// validity holds how long new certificates are valid for.
+	validity = 10 * 365 * 24 * time.Hour
+)
+
+// fixedHostnames holds the names new certificates are always valid
+// for, in addition to the state server addresses. Clients accept a
+// certificate valid for "*" whatever host name they dialled.
+var fixedHostnames = []string{"*", "localhost", "juju-apiserver", "juju-mongodb"}
+
+// State holds the state methods used by the certificate updater.
+type State interface {
+	StateServingInfo() (state.StateServingInfo, error)
+	TrustedCACert() (string, error)
+	APIHostPorts() ([][]network.HostPort, error)
+	WatchAPIHostPorts() state.NotifyWatcher
+	WatchTrustedCACert() state.NotifyWatcher
+}
+
+// StateServingInfoGetter returns the state serving information the
+// state server is currently using, and whether there is any.
+type StateServingInfoGetter func() (state.StateServingInfo, bool)
+
+// StateServingInfoSetter makes the state server use the given state
+// serving information, which holds a new certificate and key.
+type StateServingInfoSetter func(info state.StateServingInfo) error
+
+type certificateUpdater struct {
+	tomb    tomb.Tomb
+	updater *updater
+}
+
+// NewCertificateUpdater returns a worker that replaces the state
+// server's certificate with a new one signed by the environment's CA
+// when the certificate is about to expire, when it is not valid for
+// all the state server addresses, or when it has not been signed by
+// the CA that currently signs state server certificates. When the CA
+// private key is not known, the worker instead makes the state server
+// use the externally signed certificate recorded in state.
+func NewCertificateUpdater(st State, getter StateServingInfoGetter, setter StateServingInfoSetter) worker.Worker {
+	w := &certificateUpdater{
+		updater: newUpdater(st, getter, setter),
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *certificateUpdater) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *certificateUpdater) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *certificateUpdater) loop() error {
+	st := w.updater.st
+	addressWatcher := st.WatchAPIHostPorts()
+	defer watcher.Stop(addressWatcher, &w.tomb)
+	caWatcher := st.WatchTrustedCACert()
+	defer watcher.Stop(caWatcher, &w.tomb)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-addressWatcher.Changes():
+			if !ok {
+				return watcher.MustErr(addressWatcher)
+			}
+		case _, ok := <-caWatcher.Changes():
+			if !ok {
+				return watcher.MustErr(caWatcher)
+			}
+		case <-time.After(checkInterval):
+		}
+		if err := w.updater.update(time.Now()); err != nil {
+			// Don't stop the worker; the next check may succeed.
+			logger.Errorf("cannot update state server certificate: %v", err)
+		}
+	}
+}
+
+// updater checks the state server certificate and replaces it when
+// necessary.
+type updater struct {
+	st     State
+	getter StateServingInfoGetter
+	setter StateServingInfoSetter
+}
+
+func newUpdater(st State, getter StateServingInfoGetter, setter StateServingInfoSetter) *updater {
+	return &updater{
+		st:     st,
+		getter: getter,
+		setter: setter,
+	}
+}
+
+// update replaces the state server certificate if it needs to be at
+// the given time.
+func (u *updater) update(now time.Time) error {
+	info, ok := u.getter()
+	if !ok {
+		return errors.New("no state serving information available")
+	}
+	stateInfo, err := u.st.StateServingInfo()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if stateInfo.CAPrivateKey == "" {
+		// The state server certificate has been signed externally,
+		// so all state servers use the one recorded in state.
+		if stateInfo.Cert == "" || stateInfo.Cert == info.Cert {
+			return nil
+		}
+		logger.Infof("using state server certificate recorded in state")
+		info.Cert = stateInfo.Cert
+		info.PrivateKey = stateInfo.PrivateKey
+		info.CAPrivateKey = ""
+		return u.setter(info)
+	}
+	trusted, err := u.st.TrustedCACert()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The first trusted CA signs state server certificates.
+	caCerts, err := cert.SplitCerts(trusted)
+	if err != nil {
+		return errors.Annotate(err, "cannot parse CA certificate")
+	}
+	caCert := caCerts[0]
+	hostPorts, err := u.st.APIHostPorts()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hostnames := serverHostnames(hostPorts)
+	reason := renewReason(info.Cert, caCert, hostnames, now)
+	if reason == "" {
+		return nil
+	}
+	logger.Infof("generating new state server certificate: %s", reason)
+	newCert, newKey, err := cert.NewServer(caCert, stateInfo.CAPrivateKey, now.Add(validity), hostnames)
+	if err != nil {
+		return errors.Annotate(err, "cannot generate certificate")
+	}
+	info.Cert = newCert
+	info.PrivateKey = newKey
+	info.CAPrivateKey = stateInfo.CAPrivateKey
+	return u.setter(info)
+}
+
+// serverHostnames returns the names a state server certificate must
+// be valid for.
+func serverHostnames(hostPorts [][]network.HostPort) []string {
+	hostnames := append([]string{}, fixedHostnames...)
+	seen := make(map[string]bool)
+	for _, name := range hostnames {
+		seen[name] = true
+	}
+	for _, serverHostPorts := range hostPorts {
+		for _, hp := range serverHostPorts {
+			if !seen[hp.Value] {
+				seen[hp.Value] = true
+				hostnames = append(hostnames, hp.Value)
+			}
+		}
+	}
+	return hostnames
+}
+
+// renewReason returns why the given certificate must be replaced at
+// the given time, or "" if it need not be.
+func renewReason(certPEM, caCertPEM string, hostnames []string, now time.Time) string {
+	xcert, err := cert.ParseCert(certPEM)
+	if err != nil {
+		return fmt.Sprintf("cannot parse certificate: %v", err)
+	}
+	if !now.Add(renewBefore).Before(xcert.NotAfter) {
+		return fmt.Sprintf("certificate expires at %v", xcert.NotAfter)
+	}
+	if err := cert.Verify(certPEM, caCertPEM, now); err != nil {
+		return fmt.Sprintf("certificate not signed by current CA: %v", err)
+	}
+	for _, hostname := range hostnames {
+		if !certIncludes(xcert.DNSNames, xcert.IPAddresses, hostname) {
+			return fmt.Sprintf("certificate not valid for %q", hostname)
+		}
+	}
+	return ""
+}
+
+// certIncludes reports whether the given certificate names and
+// addresses include the given hostname.
+func certIncludes(dnsNames []string, ipAddresses []net.IP, hostname string) bool {
+	if ip := net.ParseIP(hostname); ip != nil {
+		for _, addr := range ipAddresses {
+			if addr.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, name := range dnsNames {
+		if name == hostname {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certupdater_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cert"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/certupdater"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+// fakeState implements certupdater.State, without the watchers.
+type fakeState struct {
+	certupdater.State
+	info      state.StateServingInfo
+	caCert    string
+	hostPorts [][]network.HostPort
+}
+
+func (st *fakeState) StateServingInfo() (state.StateServingInfo, error) {
+	return st.info, nil
+}
+
+func (st *fakeState) TrustedCACert() (string, error) {
+	return st.caCert, nil
+}
+
+func (st *fakeState) APIHostPorts() ([][]network.HostPort, error) {
+	return st.hostPorts, nil
+}
+
+type updaterSuite struct {
+	coretesting.BaseSuite
+	st      *fakeState
+	current state.StateServingInfo
+	set     []state.StateServingInfo
+	updater *certupdater.Updater
+}
+
+var _ = gc.Suite(&updaterSuite{})
+
+var testHostnames = []string{"*", "localhost", "juju-apiserver", "juju-mongodb", "10.0.0.1"}
+
+func (s *updaterSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.st = &fakeState{
+		info:      state.StateServingInfo{CAPrivateKey: coretesting.CAKey},
+		caCert:    coretesting.CACert,
+		hostPorts: [][]network.HostPort{network.AddressesWithPort(network.NewAddresses("10.0.0.1"), 17070)},
+	}
+	s.current = s.newServingInfo(c, coretesting.CACert, coretesting.CAKey, time.Now().AddDate(1, 0, 0), testHostnames)
+	s.set = nil
+	getter := func() (state.StateServingInfo, bool) {
+		return s.current, true
+	}
+	setter := func(info state.StateServingInfo) error {
+		s.set = append(s.set, info)
+		return nil
+	}
+	s.updater = certupdater.NewUpdater(s.st, getter, setter)
+}
+
+func (s *updaterSuite) newServingInfo(c *gc.C, caCert, caKey string, expiry time.Time, hostnames []string) state.StateServingInfo {
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, expiry, hostnames)
+	c.Assert(err, gc.IsNil)
+	return state.StateServingInfo{
+		APIPort:      17070,
+		StatePort:    37017,
+		Cert:         srvCert,
+		PrivateKey:   srvKey,
+		SharedSecret: "secret",
+		CAPrivateKey: caKey,
+	}
+}
+
+// assertUpdated checks that a single new certificate, signed by the
+// given CA and valid for the given address, has been set.
+func (s *updaterSuite) assertUpdated(c *gc.C, caCert, caKey, addr string) {
+	c.Assert(s.set, gc.HasLen, 1)
+	info := s.set[0]
+	c.Assert(info.Cert, gc.Not(gc.Equals), s.current.Cert)
+	c.Assert(info.APIPort, gc.Equals, s.current.APIPort)
+	c.Assert(info.SharedSecret, gc.Equals, s.current.SharedSecret)
+	c.Assert(info.CAPrivateKey, gc.Equals, caKey)
+	_, _, err := cert.ParseCertAndKey(info.Cert, info.PrivateKey)
+	c.Assert(err, gc.IsNil)
+	err = cert.Verify(info.Cert, caCert, time.Now().AddDate(9, 0, 0))
+	c.Assert(err, gc.IsNil)
+	xcert, err := cert.ParseCert(info.Cert)
+	c.Assert(err, gc.IsNil)
+	c.Assert(xcert.DNSNames, jc.SameContents, []string{"*", "localhost", "juju-apiserver", "juju-mongodb"})
+	c.Assert(xcert.IPAddresses, gc.HasLen, 1)
+	c.Assert(xcert.IPAddresses[0].String(), gc.Equals, addr)
+}
+
+func (s *updaterSuite) TestUnchanged(c *gc.C) {
+	err := s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.set, gc.HasLen, 0)
+}
+
+func (s *updaterSuite) TestAddressesChanged(c *gc.C) {
+	s.st.hostPorts = [][]network.HostPort{network.AddressesWithPort(network.NewAddresses("10.0.0.2"), 17070)}
+	err := s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	s.assertUpdated(c, coretesting.CACert, coretesting.CAKey, "10.0.0.2")
+}
+
+func (s *updaterSuite) TestExpiring(c *gc.C) {
+	s.current = s.newServingInfo(c, coretesting.CACert, coretesting.CAKey, time.Now().AddDate(0, 0, 10), testHostnames)
+	err := s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	s.assertUpdated(c, coretesting.CACert, coretesting.CAKey, "10.0.0.1")
+}
+
+func (s *updaterSuite) TestCAChanged(c *gc.C) {
+	newCACert, newCAKey, err := cert.NewCA("new", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+
+	// While the new CA is only trusted, the certificate is kept.
+	s.st.caCert = coretesting.CACert + newCACert
+	err = s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.set, gc.HasLen, 0)
+
+	// Once it signs certificates, a new certificate is signed by it.
+	s.st.caCert = newCACert
+	s.st.info.CAPrivateKey = newCAKey
+	err = s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	s.assertUpdated(c, newCACert, newCAKey, "10.0.0.1")
+}
+
+func (s *updaterSuite) TestNoCAPrivateKey(c *gc.C) {
+	s.st.info = s.current
+	s.st.info.CAPrivateKey = ""
+	s.st.hostPorts = [][]network.HostPort{network.AddressesWithPort(network.NewAddresses("10.0.0.2"), 17070)}
+	err := s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.set, gc.HasLen, 0)
+}
+
+func (s *updaterSuite) TestExternalCertificate(c *gc.C) {
+	caCert, caKey, err := cert.NewCA("external", time.Now().AddDate(10, 0, 0))
+	c.Assert(err, gc.IsNil)
+	srvCert, srvKey, err := cert.NewServer(caCert, caKey, time.Now().AddDate(1, 0, 0), []string{"*"})
+	c.Assert(err, gc.IsNil)
+	s.st.caCert = caCert
+	s.st.info = state.StateServingInfo{
+		Cert:       srvCert,
+		PrivateKey: srvKey,
+	}
+
+	// The externally signed certificate is used as is, even
+	// though it is not valid for the state server addresses.
+	err = s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.set, gc.HasLen, 1)
+	info := s.set[0]
+	c.Assert(info.Cert, gc.Equals, srvCert)
+	c.Assert(info.PrivateKey, gc.Equals, srvKey)
+	c.Assert(info.CAPrivateKey, gc.Equals, "")
+	c.Assert(info.APIPort, gc.Equals, s.current.APIPort)
+	c.Assert(info.SharedSecret, gc.Equals, s.current.SharedSecret)
+
+	s.current = info
+	s.set = nil
+	err = s.updater.Update(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.set, gc.HasLen, 0)
+}
+
+type workerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&workerSuite{})
+
+func (s *workerSuite) TestWorker(c *gc.C) {
+	current := state.StateServingInfo{
+		APIPort:      1234,
+		StatePort:    2345,
+		Cert:         coretesting.ServerCert,
+		PrivateKey:   coretesting.ServerKey,
+		CAPrivateKey: coretesting.CAKey,
+	}
+	err := s.State.SetStateServingInfo(current)
+	c.Assert(err, gc.IsNil)
+	getter := func() (state.StateServingInfo, bool) {
+		return current, true
+	}
+	setc := make(chan state.StateServingInfo, 1)
+	setter := func(info state.StateServingInfo) error {
+		select {
+		case setc <- info:
+		default:
+		}
+		return nil
+	}
+	w := certupdater.NewCertificateUpdater(s.State, getter, setter)
+	defer func() {
+		c.Assert(worker.Stop(w), gc.IsNil)
+	}()
+
+	// The test server certificate is not valid for any
+	// hostname, so it is replaced.
+	select {
+	case info := <-setc:
+		err := cert.Verify(info.Cert, coretesting.CACert, time.Now())
+		c.Assert(err, gc.IsNil)
+		c.Assert(info.Cert, gc.Not(gc.Equals), current.Cert)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("certificate not replaced")
+	}
+}
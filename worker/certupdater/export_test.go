@@ -0,0 +1,18 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package certupdater
+
+import (
+	"time"
+)
+
+type Updater updater
+
+func NewUpdater(st State, getter StateServingInfoGetter, setter StateServingInfoSetter) *Updater {
+	return (*Updater)(newUpdater(st, getter, setter))
+}
+
+func (u *Updater) Update(now time.Time) error {
+	return (*updater)(u).update(now)
+}
@@ -11,6 +11,7 @@ import (
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/api/charmrevisionupdater"
+	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/worker"
 )
 
@@ -19,10 +20,16 @@ var logger = loggo.GetLogger("juju.worker.charmrevisionworker")
 // interval sets how often the resuming is called.
 var interval = 24 * time.Hour
 
+// upgradeInterval sets how often charms are upgraded automatically to
+// the latest revisions found, so that upgrades can start soon after
+// a service's maintenance window opens.
+var upgradeInterval = 10 * time.Minute
+
 var _ worker.Worker = (*RevisionUpdateWorker)(nil)
 
 // RevisionUpdateWorker is responsible for a periodical retrieval of charm versions
 // from the charm store, and recording the revision status for deployed charms.
+// It also upgrades the charms of services whose upgrade policy allows it.
 type RevisionUpdateWorker struct {
 	st   *charmrevisionupdater.State
 	tomb tomb.Tomb
@@ -60,12 +67,20 @@ func (ruw *RevisionUpdateWorker) Wait() error {
 
 func (ruw *RevisionUpdateWorker) loop() error {
 	ruw.updateVersions()
+	ruw.upgradeCharms()
+	updates := time.NewTicker(interval)
+	defer updates.Stop()
+	upgrades := time.NewTicker(upgradeInterval)
+	defer upgrades.Stop()
 	for {
 		select {
 		case <-ruw.tomb.Dying():
 			return tomb.ErrDying
-		case <-time.After(interval):
+		case <-updates.C:
 			ruw.updateVersions()
+			ruw.upgradeCharms()
+		case <-upgrades.C:
+			ruw.upgradeCharms()
 		}
 	}
 }
@@ -75,3 +90,11 @@ func (ruw *RevisionUpdateWorker) updateVersions() {
 		logger.Errorf("cannot process charms: %v", err)
 	}
 }
+
+func (ruw *RevisionUpdateWorker) upgradeCharms() {
+	if err := ruw.st.UpgradeCharms(); params.IsCodeNotImplemented(err) {
+		logger.Debugf("not upgrading charms: %v", err)
+	} else if err != nil {
+		logger.Errorf("cannot upgrade charms: %v", err)
+	}
+}
@@ -36,6 +36,7 @@ var _ = gc.Suite(&RevisionUpdateSuite{})
 
 func (s *RevisionUpdateSuite) SetUpSuite(c *gc.C) {
 	c.Assert(*charmrevisionworker.Interval, gc.Equals, 24*time.Hour)
+	c.Assert(*charmrevisionworker.UpgradeInterval, gc.Equals, 10*time.Minute)
 	s.JujuConnSuite.SetUpSuite(c)
 	s.CharmSuite.SetUpSuite(c, &s.JujuConnSuite)
 }
@@ -110,3 +111,22 @@ func (s *RevisionUpdateSuite) TestVersionUpdateRunsPeriodically(c *gc.C) {
 	// Check the results of the latest changes.
 	c.Assert(s.checkCharmRevision(c, 24), jc.IsTrue)
 }
+
+func (s *RevisionUpdateSuite) TestUpgradeCharms(c *gc.C) {
+	s.SetupScenario(c)
+	svc, err := s.State.Service("mysql")
+	c.Assert(err, gc.IsNil)
+	err = svc.SetUpgradePolicy(state.UpgradeAlways, nil)
+	c.Assert(err, gc.IsNil)
+
+	s.runUpdater(c, time.Hour)
+	for attempt := coretesting.LongAttempt.Start(); attempt.Next(); {
+		err := svc.Refresh()
+		c.Assert(err, gc.IsNil)
+		curl, _ := svc.CharmURL()
+		if curl.String() == "cs:quantal/mysql-23" {
+			return
+		}
+	}
+	c.Fatalf("mysql was not upgraded")
+}
@@ -0,0 +1,366 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package instancepoller
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/stats"
+)
+
+// ShortPoll, ShortPollBackoff, MaxShortPoll and LongPoll hold the
+// polling intervals for the instance updater.
+//
+// A pending machine, which is not provisioned yet, has no address or
+// instance status, or whose agent has not started, is first polled
+// after ShortPoll, and then at intervals growing by a factor of
+// ShortPollBackoff up to MaxShortPoll, so that it is polled often
+// until it settles down.
+//
+// A stable machine is polled at intervals starting at MaxShortPoll and
+// growing by a factor of ShortPollBackoff each time its instance has
+// not changed, up to LongPoll. When the addresses or status of a
+// machine's instance change, it is polled again after ShortPoll.
+var (
+	ShortPoll        = 1 * time.Second
+	ShortPollBackoff = 2.0
+	MaxShortPoll     = 30 * time.Second
+	LongPoll         = 15 * time.Minute
+)
+
+// RateLimitBackoff holds how long polling is suspended when the
+// provider first reports that its request rate limit has been
+// exceeded. It doubles each time the limit is exceeded again, up to
+// LongPoll, and is reset when the provider answers.
+var RateLimitBackoff = 5 * time.Second
+
+// gatherTime holds how early machines may be polled, so that they are
+// polled along with other machines and the provider is asked about
+// more instances at once.
+var gatherTime = 3 * time.Second
+
+// defaultMaxInstancesPerCall holds the maximum number of instances
+// asked for in a single call to Instances, when the provider does not
+// specify one.
+const defaultMaxInstancesPerCall = 100
+
+// unimplementedPoll holds the polling interval of machines for which
+// the provider does not report instance information. It will not
+// until we are upgraded, so don't bother asking any more.
+const unimplementedPoll = 365 * 24 * time.Hour
+
+var (
+	pollLatency = stats.NewHistogram(
+		"juju_instancepoller_poll_duration_seconds",
+		"Time taken by the provider to report the instances of polled machines.",
+		nil,
+	)
+	polledInstances = stats.NewCounter(
+		"juju_instancepoller_polled_instances_total",
+		"Number of instances asked for by the instance poller.",
+	)
+	rateLimitedPolls = stats.NewCounter(
+		"juju_instancepoller_rate_limited_polls_total",
+		"Number of instance polls refused because the provider's rate limit was exceeded.",
+	)
+	polledMachines = stats.NewGauge(
+		"juju_instancepoller_machines",
+		"Number of machines whose instance is polled.",
+	)
+)
+
+type instanceGetter interface {
+	Instances(ids []instance.Id) ([]instance.Instance, error)
+}
+
+// polledMachine holds the polling schedule of a machine.
+type polledMachine struct {
+	machine  machine
+	interval time.Duration
+	next     time.Time
+	// index holds the index of the machine in the poll queue.
+	index int
+}
+
+// pollQueue holds the polled machines ordered by the time they are
+// next polled. It implements heap.Interface.
+type pollQueue []*polledMachine
+
+func (q pollQueue) Len() int           { return len(q) }
+func (q pollQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q pollQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *pollQueue) Push(x interface{}) {
+	p := x.(*polledMachine)
+	p.index = len(*q)
+	*q = append(*q, p)
+}
+
+func (q *pollQueue) Pop() interface{} {
+	old := *q
+	p := old[len(old)-1]
+	*q = old[:len(old)-1]
+	p.index = -1
+	return p
+}
+
+// scheduler polls the instances of all the machines it knows about,
+// each according to its own schedule, asking the provider about as
+// many instances as possible at once.
+type scheduler struct {
+	context  updaterContext
+	machines map[string]*polledMachine
+	queue    pollQueue
+
+	// suspendedUntil holds when polling may resume after the
+	// provider's rate limit has been exceeded, and rateLimitWait
+	// how long polling was last suspended for.
+	suspendedUntil time.Time
+	rateLimitWait  time.Duration
+}
+
+func newScheduler(context updaterContext) *scheduler {
+	return &scheduler{
+		context:  context,
+		machines: make(map[string]*polledMachine),
+	}
+}
+
+// nextPoll returns when the next machine is due to be polled, if any
+// machine is.
+func (s *scheduler) nextPoll() (time.Time, bool) {
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	next := s.queue[0].next
+	if next.Before(s.suspendedUntil) {
+		next = s.suspendedUntil
+	}
+	return next, true
+}
+
+// schedule arranges for the machine to be polled again after the
+// given interval.
+func (s *scheduler) schedule(p *polledMachine, now time.Time, interval time.Duration) {
+	p.interval = interval
+	s.scheduleAt(p, now.Add(interval))
+}
+
+// scheduleAt arranges for the machine to be polled at the given time,
+// without changing its polling interval.
+func (s *scheduler) scheduleAt(p *polledMachine, t time.Time) {
+	p.next = t
+	if p.index < 0 {
+		heap.Push(&s.queue, p)
+	} else {
+		heap.Fix(&s.queue, p.index)
+	}
+}
+
+// remove stops polling the machine with the given id.
+func (s *scheduler) remove(id string) {
+	p := s.machines[id]
+	if p.index >= 0 {
+		heap.Remove(&s.queue, p.index)
+	}
+	delete(s.machines, id)
+	polledMachines.Set(float64(len(s.machines)))
+}
+
+// machinesChanged starts polling the machines with the given ids if
+// they are new, and polls them as soon as possible otherwise, as
+// they may just have been provisioned. Dead machines stop being
+// polled.
+func (s *scheduler) machinesChanged(ids []string, now time.Time) error {
+	for _, id := range ids {
+		p := s.machines[id]
+		if p == nil {
+			m, err := s.context.getMachine(id)
+			if errors.IsNotFound(err) {
+				logger.Warningf("watcher gave notification of non-existent machine %q", id)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			// We don't poll manual machines.
+			isManual, err := m.IsManual()
+			if err != nil {
+				return err
+			}
+			if isManual {
+				continue
+			}
+			p = &polledMachine{machine: m, index: -1}
+			s.machines[id] = p
+			polledMachines.Set(float64(len(s.machines)))
+		} else {
+			if err := p.machine.Refresh(); err != nil {
+				return err
+			}
+			if p.machine.Life() == state.Dead {
+				s.remove(id)
+				continue
+			}
+		}
+		s.schedule(p, now, 0)
+	}
+	return nil
+}
+
+// poll polls the instances of the machines that are due to be polled
+// at the given time, or shortly after.
+func (s *scheduler) poll(now time.Time) error {
+	if now.Before(s.suspendedUntil) {
+		return nil
+	}
+	var popped []*polledMachine
+	for len(s.queue) > 0 && !s.queue[0].next.After(now.Add(gatherTime)) {
+		popped = append(popped, heap.Pop(&s.queue).(*polledMachine))
+	}
+	var due []*polledMachine
+	var ids []instance.Id
+	for _, p := range popped {
+		instId, err := p.machine.InstanceId()
+		if state.IsNotProvisionedError(err) {
+			// We can't ask for the machine's instance
+			// if it isn't provisioned yet.
+			s.schedule(p, now, nextInterval(p.interval, false, false))
+			continue
+		}
+		if err != nil {
+			return errors.Annotate(err, "cannot get machine's instance id")
+		}
+		due = append(due, p)
+		ids = append(ids, instId)
+	}
+	limit := defaultMaxInstancesPerCall
+	env := s.context.instanceGetter()
+	if limiter, ok := env.(environs.InstancesLimiter); ok && limiter.MaxInstancesPerCall() > 0 {
+		limit = limiter.MaxInstancesPerCall()
+	}
+	for len(due) > 0 {
+		n := len(due)
+		if n > limit {
+			n = limit
+		}
+		insts, err := s.instances(env, ids[:n])
+		if errors.Cause(err) == environs.ErrRateLimited {
+			s.rateLimited(now, err)
+			// Poll all the remaining machines when polling resumes.
+			for _, p := range due {
+				s.scheduleAt(p, s.suspendedUntil)
+			}
+			return nil
+		}
+		s.rateLimitWait = 0
+		for i, p := range due[:n] {
+			var inst instance.Instance
+			if err == nil {
+				inst = insts[i]
+			}
+			if err := s.update(p, now, ids[i], inst, err); err != nil {
+				return err
+			}
+		}
+		due, ids = due[n:], ids[n:]
+	}
+	return nil
+}
+
+// instances asks the provider about the instances with the given ids.
+func (s *scheduler) instances(env instanceGetter, ids []instance.Id) ([]instance.Instance, error) {
+	start := time.Now()
+	insts, err := env.Instances(ids)
+	elapsed := time.Since(start)
+	pollLatency.Observe(elapsed.Seconds())
+	polledInstances.Add(float64(len(ids)))
+	logger.Debugf("polled %d instances in %v", len(ids), elapsed)
+	switch err {
+	case environs.ErrNoInstances:
+		return make([]instance.Instance, len(ids)), nil
+	case environs.ErrPartialInstances:
+		return insts, nil
+	}
+	return insts, err
+}
+
+// rateLimited suspends polling because the provider's rate limit
+// has been exceeded.
+func (s *scheduler) rateLimited(now time.Time, err error) {
+	rateLimitedPolls.Inc()
+	switch {
+	case s.rateLimitWait == 0:
+		s.rateLimitWait = RateLimitBackoff
+	case s.rateLimitWait < LongPoll:
+		s.rateLimitWait *= 2
+		if s.rateLimitWait > LongPoll {
+			s.rateLimitWait = LongPoll
+		}
+	}
+	s.suspendedUntil = now.Add(s.rateLimitWait)
+	logger.Warningf("cannot poll instances: %v - suspending polling for %v", err, s.rateLimitWait)
+}
+
+// update updates the machine from its instance, as returned by the
+// provider with the given error, and schedules its next poll.
+func (s *scheduler) update(p *polledMachine, now time.Time, instId instance.Id, inst instance.Instance, err error) error {
+	var info instanceInfo
+	if err == nil {
+		info, err = instInfo(instId, inst)
+	}
+	if errors.IsNotImplemented(err) {
+		s.schedule(p, now, unimplementedPoll)
+		return nil
+	}
+	if err != nil {
+		logger.Warningf("cannot get instance info for instance %q: %v", instId, err)
+		s.schedule(p, now, nextInterval(p.interval, false, false))
+		return nil
+	}
+	changed, err := setInstanceInfo(p.machine, info)
+	if err != nil {
+		return err
+	}
+	machineStatus, _, _, err := p.machine.Status()
+	if err != nil {
+		logger.Warningf("cannot get current machine status for machine %v: %v", p.machine.Id(), err)
+	}
+	stable := len(info.addresses) > 0 && info.status != "" && machineStatus == params.StatusStarted
+	s.schedule(p, now, nextInterval(p.interval, stable, changed))
+	return nil
+}
+
+// nextInterval returns the interval after which a machine last polled
+// at the given interval should be polled again.
+func nextInterval(interval time.Duration, stable, changed bool) time.Duration {
+	if changed || interval < ShortPoll {
+		return ShortPoll
+	}
+	next := time.Duration(float64(interval) * ShortPollBackoff)
+	if !stable {
+		if next > MaxShortPoll {
+			next = MaxShortPoll
+		}
+		return next
+	}
+	if next < MaxShortPoll {
+		next = MaxShortPoll
+	}
+	if next > LongPoll {
+		next = LongPoll
+	}
+	return next
+}
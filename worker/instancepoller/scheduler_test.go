@@ -0,0 +1,360 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package instancepoller
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type schedulerSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&schedulerSuite{})
+
+type testInstance struct {
+	instance.Instance
+	id        instance.Id
+	addresses []network.Address
+	status    string
+	err       error
+}
+
+var _ instance.Instance = (*testInstance)(nil)
+
+func (t *testInstance) Id() instance.Id {
+	return t.id
+}
+
+func (t *testInstance) Addresses() ([]network.Address, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.addresses, nil
+}
+
+func (t *testInstance) Status() string {
+	return t.status
+}
+
+type testInstanceGetter struct {
+	// calls holds the ids passed to each call of the Instances method.
+	calls   [][]instance.Id
+	results map[instance.Id]instance.Instance
+	err     error
+	counter int32
+}
+
+func (tig *testInstanceGetter) Instances(ids []instance.Id) (result []instance.Instance, err error) {
+	tig.calls = append(tig.calls, ids)
+	atomic.AddInt32(&tig.counter, 1)
+	results := make([]instance.Instance, len(ids))
+	for i, id := range ids {
+		// We don't check 'ok' here, because we want the Instance{nil}
+		// response for those
+		results[i] = tig.results[id]
+	}
+	return results, tig.err
+}
+
+func (tig *testInstanceGetter) newTestInstance(id instance.Id, status string, addresses []string) *testInstance {
+	if tig.results == nil {
+		tig.results = make(map[instance.Id]instance.Instance)
+	}
+	thisInstance := &testInstance{
+		id:        id,
+		status:    status,
+		addresses: network.NewAddresses(addresses...),
+	}
+	tig.results[thisInstance.Id()] = thisInstance
+	return thisInstance
+}
+
+// limitedInstanceGetter is a testInstanceGetter that implements
+// environs.InstancesLimiter.
+type limitedInstanceGetter struct {
+	testInstanceGetter
+	limit int
+}
+
+func (g *limitedInstanceGetter) MaxInstancesPerCall() int {
+	return g.limit
+}
+
+// newTestScheduler returns a scheduler polling the given machines
+// with the given instance getter.
+func newTestScheduler(c *gc.C, getter instanceGetter, machines ...*testMachine) *scheduler {
+	byId := make(map[string]*testMachine)
+	var ids []string
+	for _, m := range machines {
+		byId[m.id] = m
+		ids = append(ids, m.id)
+	}
+	context := &testUpdaterContext{
+		getter: getter,
+		getMachineFunc: func(id string) (machine, error) {
+			m, ok := byId[id]
+			if !ok {
+				return nil, errors.NotFoundf("machine %v", id)
+			}
+			return m, nil
+		},
+		dyingc: make(chan struct{}),
+	}
+	s := newScheduler(context)
+	err := s.machinesChanged(ids, time.Now())
+	c.Assert(err, gc.IsNil)
+	return s
+}
+
+func newTestMachine(i int, status params.Status) *testMachine {
+	return &testMachine{
+		id:         fmt.Sprint(i),
+		instanceId: instance.Id(fmt.Sprintf("i%d", i)),
+		refresh:    func() error { return nil },
+		life:       state.Alive,
+		status:     status,
+	}
+}
+
+// pollNext polls the scheduler at the time the next machine is due.
+func pollNext(c *gc.C, s *scheduler) time.Time {
+	next, ok := s.nextPoll()
+	c.Assert(ok, jc.IsTrue)
+	err := s.poll(next)
+	c.Assert(err, gc.IsNil)
+	return next
+}
+
+func (*schedulerSuite) TestPollSetsInstanceInfo(c *gc.C) {
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i0", "running", []string{"127.0.0.1"})
+	m := newTestMachine(0, params.StatusStarted)
+	s := newTestScheduler(c, getter, m)
+	pollNext(c, s)
+
+	c.Assert(getter.calls, jc.DeepEquals, [][]instance.Id{{"i0"}})
+	c.Assert(m.addresses, gc.DeepEquals, testAddrs)
+	c.Assert(m.setAddressCount, gc.Equals, 1)
+	c.Assert(m.instStatus, gc.Equals, "running")
+}
+
+func (*schedulerSuite) TestBatchesByProviderLimit(c *gc.C) {
+	getter := &limitedInstanceGetter{limit: 4}
+	var machines []*testMachine
+	for i := 0; i < 10; i++ {
+		machines = append(machines, newTestMachine(i, params.StatusStarted))
+	}
+	s := newTestScheduler(c, getter, machines...)
+	pollNext(c, s)
+
+	c.Assert(getter.calls, gc.HasLen, 3)
+	c.Assert(getter.calls[0], gc.HasLen, 4)
+	c.Assert(getter.calls[1], gc.HasLen, 4)
+	c.Assert(getter.calls[2], gc.HasLen, 2)
+}
+
+func (*schedulerSuite) TestBatchesByDefaultLimit(c *gc.C) {
+	getter := new(testInstanceGetter)
+	var machines []*testMachine
+	for i := 0; i < defaultMaxInstancesPerCall+1; i++ {
+		machines = append(machines, newTestMachine(i, params.StatusStarted))
+	}
+	s := newTestScheduler(c, getter, machines...)
+	pollNext(c, s)
+
+	c.Assert(getter.calls, gc.HasLen, 2)
+	c.Assert(getter.calls[0], gc.HasLen, defaultMaxInstancesPerCall)
+	c.Assert(getter.calls[1], gc.HasLen, 1)
+}
+
+func (*schedulerSuite) TestGathersMachinesDueSoon(c *gc.C) {
+	getter := new(testInstanceGetter)
+	m0 := newTestMachine(0, params.StatusStarted)
+	m1 := newTestMachine(1, params.StatusStarted)
+	m2 := newTestMachine(2, params.StatusStarted)
+	s := newTestScheduler(c, getter, m0, m1, m2)
+	now := time.Now()
+	s.scheduleAt(s.machines["0"], now)
+	s.scheduleAt(s.machines["1"], now.Add(gatherTime))
+	s.scheduleAt(s.machines["2"], now.Add(gatherTime+time.Second))
+	err := s.poll(now)
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(getter.calls, jc.DeepEquals, [][]instance.Id{{"i0", "i1"}})
+}
+
+func (*schedulerSuite) TestPendingMachinePolledQuickly(c *gc.C) {
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i0", "pending", nil)
+	m := newTestMachine(0, params.StatusPending)
+	sched := newTestScheduler(c, getter, m)
+	var intervals []time.Duration
+	last := pollNext(c, sched)
+	for i := 0; i < 8; i++ {
+		next := pollNext(c, sched)
+		intervals = append(intervals, next.Sub(last))
+		last = next
+	}
+	c.Assert(intervals, jc.DeepEquals, []time.Duration{
+		ShortPoll,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		MaxShortPoll,
+		MaxShortPoll,
+		MaxShortPoll,
+	})
+	c.Assert(getter.calls, gc.HasLen, 9)
+}
+
+func (*schedulerSuite) TestStableMachineSlowsDown(c *gc.C) {
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i0", "running", []string{"127.0.0.1"})
+	m := newTestMachine(0, params.StatusStarted)
+	sched := newTestScheduler(c, getter, m)
+	var intervals []time.Duration
+	last := pollNext(c, sched)
+	for i := 0; i < 8; i++ {
+		next := pollNext(c, sched)
+		intervals = append(intervals, next.Sub(last))
+		last = next
+	}
+	// The first poll sets the instance info, so the machine
+	// is polled again quickly.
+	c.Assert(intervals, jc.DeepEquals, []time.Duration{
+		ShortPoll,
+		MaxShortPoll,
+		2 * MaxShortPoll,
+		4 * MaxShortPoll,
+		8 * MaxShortPoll,
+		16 * MaxShortPoll,
+		LongPoll,
+		LongPoll,
+	})
+
+	// A change in the instance makes the machine polled quickly again.
+	getter.newTestInstance("i0", "running", []string{"127.0.0.2"})
+	pollNext(c, sched)
+	next, ok := sched.nextPoll()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(next.Sub(last), gc.Equals, LongPoll+ShortPoll)
+}
+
+func (*schedulerSuite) TestNotProvisionedMachineNotPolled(c *gc.C) {
+	getter := new(testInstanceGetter)
+	m := newTestMachine(0, params.StatusPending)
+	m.instanceId = ""
+	sched := newTestScheduler(c, getter, m)
+	for i := 0; i < 3; i++ {
+		pollNext(c, sched)
+	}
+	c.Assert(getter.calls, gc.HasLen, 0)
+
+	// Once provisioned, the machine is polled as soon as it changes.
+	m.instanceId = "i0"
+	now := time.Now()
+	err := sched.machinesChanged([]string{"0"}, now)
+	c.Assert(err, gc.IsNil)
+	next, ok := sched.nextPoll()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(next, gc.Equals, now)
+	pollNext(c, sched)
+	c.Assert(getter.calls, jc.DeepEquals, [][]instance.Id{{"i0"}})
+}
+
+func (*schedulerSuite) TestRateLimitBackoff(c *gc.C) {
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i0", "running", []string{"127.0.0.1"})
+	getter.err = errors.Annotate(environs.ErrRateLimited, "too many requests")
+	m := newTestMachine(0, params.StatusStarted)
+	sched := newTestScheduler(c, getter, m)
+
+	last := pollNext(c, sched)
+	for _, wait := range []time.Duration{RateLimitBackoff, 2 * RateLimitBackoff, 4 * RateLimitBackoff} {
+		next := pollNext(c, sched)
+		c.Assert(next.Sub(last), gc.Equals, wait)
+		last = next
+	}
+	c.Assert(m.addresses, gc.HasLen, 0)
+	c.Assert(getter.calls, gc.HasLen, 4)
+
+	// Once the provider answers, polling resumes normally.
+	getter.err = nil
+	next := pollNext(c, sched)
+	c.Assert(next.Sub(last), gc.Equals, 8*RateLimitBackoff)
+	c.Assert(m.addresses, gc.DeepEquals, testAddrs)
+	c.Assert(sched.rateLimitWait, gc.Equals, time.Duration(0))
+}
+
+func (*schedulerSuite) TestInstanceNotFound(c *gc.C) {
+	getter := new(testInstanceGetter)
+	getter.err = environs.ErrNoInstances
+	m := newTestMachine(0, params.StatusStarted)
+	sched := newTestScheduler(c, getter, m)
+	last := pollNext(c, sched)
+	next := pollNext(c, sched)
+	c.Assert(next.Sub(last), gc.Equals, ShortPoll)
+	c.Assert(m.addresses, gc.HasLen, 0)
+}
+
+func (*schedulerSuite) TestInstanceInfoUnimplemented(c *gc.C) {
+	getter := new(testInstanceGetter)
+	inst := getter.newTestInstance("i0", "running", nil)
+	inst.err = errors.NotImplementedf("instance address")
+	m := newTestMachine(0, params.StatusStarted)
+	sched := newTestScheduler(c, getter, m)
+	last := pollNext(c, sched)
+	next := pollNext(c, sched)
+	c.Assert(next.Sub(last), gc.Equals, unimplementedPoll)
+}
+
+func (*schedulerSuite) TestDeadMachineNotPolled(c *gc.C) {
+	getter := new(testInstanceGetter)
+	m0 := newTestMachine(0, params.StatusStarted)
+	m1 := newTestMachine(1, params.StatusStarted)
+	sched := newTestScheduler(c, getter, m0, m1)
+	m0.setLife(state.Dead)
+	err := sched.machinesChanged([]string{"0"}, time.Now())
+	c.Assert(err, gc.IsNil)
+	pollNext(c, sched)
+	c.Assert(getter.calls, jc.DeepEquals, [][]instance.Id{{"i1"}})
+	c.Assert(sched.machines, gc.HasLen, 1)
+}
+
+func (*schedulerSuite) TestNextInterval(c *gc.C) {
+	for i, test := range []struct {
+		interval time.Duration
+		stable   bool
+		changed  bool
+		expect   time.Duration
+	}{
+		{0, false, false, ShortPoll},
+		{ShortPoll, false, false, 2 * ShortPoll},
+		{MaxShortPoll, false, false, MaxShortPoll},
+		{LongPoll, false, false, MaxShortPoll},
+		{ShortPoll, true, false, MaxShortPoll},
+		{MaxShortPoll, true, false, 2 * MaxShortPoll},
+		{LongPoll, true, false, LongPoll},
+		{LongPoll, true, true, ShortPoll},
+		{MaxShortPoll, false, true, ShortPoll},
+	} {
+		c.Logf("test %d: %v stable %v changed %v", i, test.interval, test.stable, test.changed)
+		c.Check(nextInterval(test.interval, test.stable, test.changed), gc.Equals, test.expect)
+	}
+}
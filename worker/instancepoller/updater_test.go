@@ -5,14 +5,13 @@
 package instancepoller
 
 import (
-	"errors"
+	stderrors "errors"
 	stdtesting "testing"
 	"time"
 
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
-	"github.com/juju/juju/apiserver/params"
 	"github.com/juju/juju/state"
 	coretesting "github.com/juju/juju/testing"
 )
@@ -31,7 +30,7 @@ func (*updaterSuite) TestStopsWatcher(c *gc.C) {
 	context := &testUpdaterContext{
 		dyingc: make(chan struct{}),
 	}
-	expectErr := errors.New("some error")
+	expectErr := stderrors.New("some error")
 	watcher := &testMachinesWatcher{
 		changes: make(chan []string),
 		err:     expectErr,
@@ -50,33 +49,18 @@ func (*updaterSuite) TestStopsWatcher(c *gc.C) {
 	c.Assert(watcher.stopped, jc.IsTrue)
 }
 
-func (*updaterSuite) TestWatchMachinesWaitsForMachinePollers(c *gc.C) {
-	// We can't see that the machine pollers are still alive directly,
-	// but we can make the machine's Refresh method block,
-	// and test that watchMachinesLoop only terminates
-	// when it unblocks.
-	waitRefresh := make(chan struct{})
+func (*updaterSuite) TestPollsChangedMachines(c *gc.C) {
 	m := &testMachine{
 		id:         "99",
 		instanceId: "i1234",
+		refresh:    func() error { return nil },
 		life:       state.Alive,
-		refresh: func() error {
-			// Signal that we're in Refresh.
-			waitRefresh <- struct{}{}
-			// Wait to be unblocked.
-			<-waitRefresh
-			return nil
-		},
 	}
-	dyingc := make(chan struct{})
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i1234", "running", []string{"127.0.0.1"})
 	context := &testUpdaterContext{
-		dyingc: dyingc,
-		newMachineContextFunc: func() machineContext {
-			return &testMachineContext{
-				getInstanceInfo: instanceInfoGetter(c, "i1234", testAddrs, "running", nil),
-				dyingc:          dyingc,
-			}
-		},
+		dyingc: make(chan struct{}),
+		getter: getter,
 		getMachineFunc: func(id string) (machine, error) {
 			c.Check(id, gc.Equals, m.id)
 			return m, nil
@@ -89,27 +73,21 @@ func (*updaterSuite) TestWatchMachinesWaitsForMachinePollers(c *gc.C) {
 	go func() {
 		done <- watchMachinesLoop(context, watcher)
 	}()
-	// Send two changes; the first one should start the machineLoop;
-	// the second should call Refresh.
 	watcher.changes <- []string{"99"}
-	watcher.changes <- []string{"99"}
-	// Wait for the machineLoop to call Refresh
-	select {
-	case <-waitRefresh:
-		c.Logf("poller called Refresh")
-	case <-time.After(coretesting.LongWait):
-		c.Fatalf("timed out waiting for machine to be refreshed")
-	}
-	close(context.dyingc)
-	// Wait a little while to be sure that watchMachinesLoop is
-	// actually waiting for its machine poller to finish.
-	select {
-	case err := <-done:
-		c.Fatalf("watchMachinesLoop terminated prematurely: %v", err)
-	case <-time.After(coretesting.ShortWait):
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		if len(m.Addresses()) > 0 {
+			break
+		}
+		if !a.HasNext() {
+			c.Fatalf("timed out waiting for machine to be polled")
+		}
 	}
+	c.Assert(m.Addresses(), gc.DeepEquals, testAddrs)
+	instStatus, err := m.InstanceStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instStatus, gc.Equals, "running")
 
-	waitRefresh <- struct{}{}
+	close(context.dyingc)
 	select {
 	case err := <-done:
 		c.Assert(err, gc.IsNil)
@@ -120,64 +98,108 @@ func (*updaterSuite) TestWatchMachinesWaitsForMachinePollers(c *gc.C) {
 }
 
 func (s *updaterSuite) TestManualMachinesIgnored(c *gc.C) {
-	waitStatus := make(chan struct{})
-	s.PatchValue(&MachineStatus, func(m *testMachine) (status params.Status, info string, data map[string]interface{}, err error) {
-		// Signal that we're in Status.
-		waitStatus <- struct{}{}
-		return params.StatusPending, "", map[string]interface{}{}, nil
-	})
 	m := &testMachine{
 		id:         "99",
 		instanceId: "manual:1234",
 		life:       state.Alive,
 	}
-	dyingc := make(chan struct{})
+	getter := new(testInstanceGetter)
 	context := &testUpdaterContext{
-		dyingc: dyingc,
-		newMachineContextFunc: func() machineContext {
-			return &testMachineContext{
-				getInstanceInfo: instanceInfoGetter(c, "manual:1234", testAddrs, "running", nil),
-				dyingc:          dyingc,
-			}
-		},
+		dyingc: make(chan struct{}),
+		getter: getter,
 		getMachineFunc: func(id string) (machine, error) {
 			c.Check(id, gc.Equals, m.id)
 			return m, nil
 		},
 	}
+	sched := newScheduler(context)
+	now := time.Now()
+	err := sched.machinesChanged([]string{"99"}, now)
+	c.Assert(err, gc.IsNil)
+	_, ok := sched.nextPoll()
+	c.Assert(ok, jc.IsFalse)
+	err = sched.poll(now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(getter.counter, gc.Equals, int32(0))
+}
+
+var terminatingErrorsTests = []struct {
+	about  string
+	mutate func(m *testMachine, err error)
+}{{
+	about: "set addresses",
+	mutate: func(m *testMachine, err error) {
+		m.setAddressesErr = err
+	},
+}, {
+	about: "refresh",
+	mutate: func(m *testMachine, err error) {
+		m.refresh = func() error {
+			return err
+		}
+	},
+}, {
+	about: "instance id",
+	mutate: func(m *testMachine, err error) {
+		m.instanceIdErr = err
+	},
+}}
+
+func (*updaterSuite) TestTerminatingErrors(c *gc.C) {
+	for i, test := range terminatingErrorsTests {
+		c.Logf("test %d: %s", i, test.about)
+		testTerminatingErrors(c, test.mutate)
+	}
+}
+
+// testTerminatingErrors checks that when a testMachine is changed
+// with the given mutate function, watchMachinesLoop terminates with
+// the given error. The machine is reported twice by the watcher, so
+// that it goes through all possible places that can return an error.
+func testTerminatingErrors(c *gc.C, mutate func(m *testMachine, err error)) {
+	getter := new(testInstanceGetter)
+	getter.newTestInstance("i1234", "running", []string{"127.0.0.1"})
+	m := &testMachine{
+		id:         "99",
+		instanceId: "i1234",
+		refresh:    func() error { return nil },
+		life:       state.Alive,
+	}
+	context := &testUpdaterContext{
+		dyingc: make(chan struct{}),
+		getter: getter,
+		getMachineFunc: func(id string) (machine, error) {
+			return m, nil
+		},
+	}
+	expectErr := stderrors.New("a very unusual error")
+	mutate(m, expectErr)
 	watcher := &testMachinesWatcher{
-		changes: make(chan []string),
+		changes: make(chan []string, 2),
 	}
+	watcher.changes <- []string{"99"}
+	watcher.changes <- []string{"99"}
 	done := make(chan error)
 	go func() {
 		done <- watchMachinesLoop(context, watcher)
 	}()
-	// Send a change to start the machineLoop;
-	watcher.changes <- []string{"99"}
-	select {
-	case <-waitStatus:
-		c.Fatalf("poller called Status")
-	case <-time.After(coretesting.ShortWait):
-		c.Logf("status not called")
-	}
-	close(context.dyingc)
 	select {
 	case err := <-done:
-		c.Assert(err, gc.IsNil)
+		c.Assert(err, gc.ErrorMatches, ".*"+expectErr.Error())
 	case <-time.After(coretesting.LongWait):
+		close(context.dyingc)
 		c.Fatalf("timed out waiting for watchMachinesLoop to terminate")
 	}
-	c.Assert(watcher.stopped, jc.IsTrue)
 }
 
 type testUpdaterContext struct {
-	newMachineContextFunc func() machineContext
-	getMachineFunc        func(id string) (machine, error)
-	dyingc                chan struct{}
+	getMachineFunc func(id string) (machine, error)
+	getter         instanceGetter
+	dyingc         chan struct{}
 }
 
-func (context *testUpdaterContext) newMachineContext() machineContext {
-	return context.newMachineContextFunc()
+func (context *testUpdaterContext) instanceGetter() instanceGetter {
+	return context.getter
 }
 
 func (context *testUpdaterContext) getMachine(id string) (machine, error) {
@@ -13,7 +13,6 @@ import (
 type updaterWorker struct {
 	st   *state.State
 	tomb tomb.Tomb
-	*aggregator
 
 	observer *worker.EnvironObserver
 }
@@ -21,6 +20,9 @@ type updaterWorker struct {
 // NewWorker returns a worker that keeps track of
 // the machines in the state and polls their instance
 // addresses and status periodically to keep them up to date.
+// The instances of all machines are polled by a single scheduler,
+// which asks the provider about as many instances at once as it
+// allows.
 func NewWorker(st *state.State) worker.Worker {
 	u := &updaterWorker{
 		st: st,
@@ -46,7 +48,6 @@ func (u *updaterWorker) loop() (err error) {
 	if err != nil {
 		return err
 	}
-	u.aggregator = newAggregator(u.observer.Environ())
 	logger.Infof("instance poller received inital environment configuration")
 	defer func() {
 		obsErr := worker.Stop(u.observer)
@@ -57,18 +58,14 @@ func (u *updaterWorker) loop() (err error) {
 	return watchMachinesLoop(u, u.st.WatchEnvironMachines())
 }
 
-func (u *updaterWorker) newMachineContext() machineContext {
-	return u
-}
-
 func (u *updaterWorker) getMachine(id string) (machine, error) {
 	return u.st.Machine(id)
 }
 
-func (u *updaterWorker) dying() <-chan struct{} {
-	return u.tomb.Dying()
+func (u *updaterWorker) instanceGetter() instanceGetter {
+	return u.observer.Environ()
 }
 
-func (u *updaterWorker) killAll(err error) {
-	u.tomb.Kill(err)
+func (u *updaterWorker) dying() <-chan struct{} {
+	return u.tomb.Dying()
 }
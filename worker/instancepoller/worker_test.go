@@ -41,6 +41,7 @@ func (s *workerSuite) TestWorker(c *gc.C) {
 	// correctly.
 	s.PatchValue(&ShortPoll, 10*time.Millisecond)
 	s.PatchValue(&LongPoll, 10*time.Millisecond)
+	s.PatchValue(&MaxShortPoll, 10*time.Millisecond)
 	s.PatchValue(&gatherTime, 10*time.Millisecond)
 	machines, insts := s.setupScenario(c)
 	s.State.StartSync()
@@ -0,0 +1,207 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package introspection implements a worker that answers questions
+// about a running agent on a local socket: which workers it runs and
+// how they are faring, what its goroutines are doing, and how its
+// logging is configured.
+package introspection
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/rpc"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/juju/sockets"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.introspection")
+
+// SocketFile is the name of the introspection socket, created in
+// the agent's directory.
+const SocketFile = "introspection.socket"
+
+// The queries that may be made of an agent.
+const (
+	QueryWorkers    = "workers"
+	QueryGoroutines = "goroutines"
+	QueryLogging    = "logging"
+)
+
+// Queries holds all the supported queries.
+var Queries = []string{QueryWorkers, QueryGoroutines, QueryLogging}
+
+// SocketPath returns the path of the introspection socket for the
+// agent with the given tag, whose files are kept in agentDir.
+func SocketPath(agentDir string, tag names.Tag) string {
+	if version.Current.OS == version.Windows {
+		return fmt.Sprintf(`\\.\pipe\%s-introspection`, tag)
+	}
+	return filepath.Join(agentDir, SocketFile)
+}
+
+// WorkersResult holds the result of a workers query.
+type WorkersResult struct {
+	Workers []worker.WorkerReport
+}
+
+// Introspector holds the methods called over the rpc connection.
+type Introspector struct {
+	reporter worker.Reporter
+}
+
+// Workers returns reports on the workers run by the agent.
+func (i *Introspector) Workers(_ struct{}, result *WorkersResult) error {
+	result.Workers = i.reporter.Report()
+	return nil
+}
+
+// Goroutines returns the stacks of all the agent's goroutines.
+func (i *Introspector) Goroutines(_ struct{}, result *string) error {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return errors.Trace(err)
+	}
+	*result = buf.String()
+	return nil
+}
+
+// Logging returns the agent's logging configuration.
+func (i *Introspector) Logging(_ struct{}, result *string) error {
+	*result = loggo.LoggerInfo()
+	return nil
+}
+
+type introspectionWorker struct {
+	tomb     tomb.Tomb
+	listener net.Listener
+	server   *rpc.Server
+}
+
+// NewWorker returns a worker that answers introspection queries
+// about the workers reported on by reporter on the given socket.
+func NewWorker(reporter worker.Reporter, socketPath string) (worker.Worker, error) {
+	server := rpc.NewServer()
+	if err := server.Register(&Introspector{reporter}); err != nil {
+		return nil, errors.Trace(err)
+	}
+	listener, err := sockets.Listen(socketPath)
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot listen for introspection queries")
+	}
+	w := &introspectionWorker{
+		listener: listener,
+		server:   server,
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	logger.Debugf("introspection listener running on %s", socketPath)
+	return w, nil
+}
+
+// Kill implements worker.Worker.
+func (w *introspectionWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.
+func (w *introspectionWorker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *introspectionWorker) loop() error {
+	go func() {
+		<-w.tomb.Dying()
+		w.listener.Close()
+	}()
+	for {
+		conn, err := w.listener.Accept()
+		if err != nil {
+			select {
+			case <-w.tomb.Dying():
+				// The error is a result of the listener
+				// being closed.
+				return tomb.ErrDying
+			default:
+			}
+			return errors.Trace(err)
+		}
+		go w.server.ServeConn(conn)
+	}
+}
+
+// Query asks the agent listening on the given socket the given
+// query, and returns the answer formatted for display.
+func Query(socketPath, query string) (string, error) {
+	client, err := sockets.Dial(socketPath)
+	if err != nil {
+		return "", errors.Annotate(err, "cannot connect to agent")
+	}
+	defer client.Close()
+	switch query {
+	case QueryWorkers:
+		var result WorkersResult
+		if err := client.Call("Introspector.Workers", struct{}{}, &result); err != nil {
+			return "", errors.Trace(err)
+		}
+		return FormatWorkers(result.Workers), nil
+	case QueryGoroutines:
+		var result string
+		err := client.Call("Introspector.Goroutines", struct{}{}, &result)
+		return result, errors.Trace(err)
+	case QueryLogging:
+		var result string
+		err := client.Call("Introspector.Logging", struct{}{}, &result)
+		return result + "\n", errors.Trace(err)
+	}
+	return "", errors.NotValidf("query %q (expected one of %s)", query, strings.Join(Queries, ", "))
+}
+
+// FormatWorkers returns a table describing the given workers, with
+// nested workers named by their path from the top level.
+func FormatWorkers(reports []worker.WorkerReport) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKER\tSTATE\tRESTARTS\tLAST ERROR")
+	formatWorkers(tw, "", reports)
+	tw.Flush()
+	return buf.String()
+}
+
+func formatWorkers(tw *tabwriter.Writer, prefix string, reports []worker.WorkerReport) {
+	sort.Sort(byId(reports))
+	for _, r := range reports {
+		state := "stopped"
+		if r.Running {
+			state = "running"
+		}
+		lastError := ""
+		if r.LastError != "" {
+			lastError = fmt.Sprintf("%s: %s", r.LastErrorTime.UTC().Format(time.RFC3339), r.LastError)
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%d\t%s\n", prefix, r.Id, state, r.Restarts, lastError)
+		formatWorkers(tw, prefix+r.Id+"/", r.Workers)
+	}
+}
+
+type byId []worker.WorkerReport
+
+func (b byId) Len() int           { return len(b) }
+func (b byId) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byId) Less(i, j int) bool { return b[i].Id < b[j].Id }
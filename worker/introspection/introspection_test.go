@@ -0,0 +1,100 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package introspection_test
+
+import (
+	"path/filepath"
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/introspection"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type introspectionSuite struct {
+	coretesting.BaseSuite
+	socketPath string
+	reporter   *fakeReporter
+}
+
+var _ = gc.Suite(&introspectionSuite{})
+
+type fakeReporter struct {
+	reports []worker.WorkerReport
+}
+
+func (r *fakeReporter) Report() []worker.WorkerReport {
+	return r.reports
+}
+
+func (s *introspectionSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.socketPath = filepath.Join(c.MkDir(), introspection.SocketFile)
+	s.reporter = &fakeReporter{}
+	w, err := introspection.NewWorker(s.reporter, s.socketPath)
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(c *gc.C) {
+		c.Assert(worker.Stop(w), gc.IsNil)
+	})
+}
+
+func (s *introspectionSuite) TestWorkers(c *gc.C) {
+	errorTime := time.Date(2014, 10, 1, 12, 30, 0, 0, time.UTC)
+	s.reporter.reports = []worker.WorkerReport{{
+		Id:      "api",
+		Running: true,
+		Workers: []worker.WorkerReport{{
+			Id:            "uniter",
+			Running:       true,
+			Restarts:      3,
+			LastError:     "hook failed",
+			LastErrorTime: errorTime,
+		}, {
+			Id: "logger",
+		}},
+	}, {
+		Id:      "termination",
+		Running: true,
+	}}
+	out, err := introspection.Query(s.socketPath, introspection.QueryWorkers)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, ""+
+		"WORKER       STATE    RESTARTS  LAST ERROR\n"+
+		"api          running  0         \n"+
+		"api/logger   stopped  0         \n"+
+		"api/uniter   running  3         2014-10-01T12:30:00Z: hook failed\n"+
+		"termination  running  0         \n",
+	)
+}
+
+func (s *introspectionSuite) TestGoroutines(c *gc.C) {
+	out, err := introspection.Query(s.socketPath, introspection.QueryGoroutines)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, jc.Contains, "goroutine ")
+	c.Assert(out, jc.Contains, "introspection")
+}
+
+func (s *introspectionSuite) TestLogging(c *gc.C) {
+	out, err := introspection.Query(s.socketPath, introspection.QueryLogging)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Matches, "<root>=[A-Z]+.*\n")
+}
+
+func (s *introspectionSuite) TestUnknownQuery(c *gc.C) {
+	_, err := introspection.Query(s.socketPath, "secrets")
+	c.Assert(err, gc.ErrorMatches, `query "secrets" \(expected one of workers, goroutines, logging\) not valid`)
+}
+
+func (s *introspectionSuite) TestNoAgent(c *gc.C) {
+	_, err := introspection.Query(filepath.Join(c.MkDir(), "nothing"), introspection.QueryWorkers)
+	c.Assert(err, gc.ErrorMatches, "cannot connect to agent: .*")
+}
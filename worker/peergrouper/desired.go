@@ -13,7 +13,7 @@ import (
 )
 
 // jujuMachineTag is the key for the tag where we save the member's juju machine id.
-const jujuMachineTag = "juju-machine-id"
+const jujuMachineTag = replicaset.JujuMachineKey
 
 var logger = loggo.GetLogger("juju.worker.peergrouper")
 
@@ -226,6 +226,33 @@ func (st *fakeState) StateServerInfo() (*state.StateServerInfo, error) {
 	return deepCopy(st.stateServers.Get()).(*state.StateServerInfo), nil
 }
 
+// CompleteStateServerRemoval implements stateInterface.CompleteStateServerRemoval.
+func (st *fakeState) CompleteStateServerRemoval(id string) error {
+	if err := errorFor("State.CompleteStateServerRemoval", id); err != nil {
+		return err
+	}
+	m := st.machine(id)
+	if m == nil {
+		return errors.NotFoundf("machine %s", id)
+	}
+	if m.HasVote() {
+		return fmt.Errorf("machine %s still has a vote", id)
+	}
+	m.mutate(func(doc *machineDoc) {
+		doc.removing = false
+		doc.wantsVote = false
+	})
+	info := st.stateServers.Get().(*state.StateServerInfo)
+	var ids []string
+	for _, mid := range info.MachineIds {
+		if mid != id {
+			ids = append(ids, mid)
+		}
+	}
+	st.setStateServers(ids...)
+	return nil
+}
+
 func (st *fakeState) WatchStateServerInfo() state.NotifyWatcher {
 	return WatchValue(&st.stateServers)
 }
@@ -241,6 +268,7 @@ type machineDoc struct {
 	id             string
 	wantsVote      bool
 	hasVote        bool
+	removing       bool
 	instanceId     instance.Id
 	mongoHostPorts []network.HostPort
 	apiHostPorts   []network.HostPort
@@ -368,6 +396,22 @@ func (m *fakeMachine) SetHasVote(hasVote bool) error {
 	return nil
 }
 
+// IsRemovingStateServer implements stateMachine.IsRemovingStateServer.
+func (m *fakeMachine) IsRemovingStateServer() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.doc.removing
+}
+
+// setRemoving marks the machine as being removed as a state server,
+// as State.RemoveStateServer does.
+func (m *fakeMachine) setRemoving() {
+	m.mutate(func(doc *machineDoc) {
+		doc.removing = true
+		doc.wantsVote = false
+	})
+}
+
 func (m *fakeMachine) setWantsVote(wantsVote bool) {
 	m.mutate(func(doc *machineDoc) {
 		doc.wantsVote = wantsVote
@@ -23,6 +23,7 @@ type stateInterface interface {
 	Machine(id string) (stateMachine, error)
 	WatchStateServerInfo() state.NotifyWatcher
 	StateServerInfo() (*state.StateServerInfo, error)
+	CompleteStateServerRemoval(id string) error
 	MongoSession() mongoSession
 }
 
@@ -34,6 +35,7 @@ type stateMachine interface {
 	WantsVote() bool
 	HasVote() bool
 	SetHasVote(hasVote bool) error
+	IsRemovingStateServer() bool
 	APIHostPorts() []network.HostPort
 	MongoHostPorts() []network.HostPort
 }
@@ -332,6 +334,18 @@ func (w *pgWorker) updateReplicaset() error {
 	if err := setHasVote(removed, false); err != nil {
 		return err
 	}
+	// Machines being removed as state servers can have their
+	// JobManageEnviron job removed now that they have no vote.
+	// They will then drop out of the peer group.
+	for m, hasVote := range voting {
+		if hasVote || !m.stm.IsRemovingStateServer() {
+			continue
+		}
+		logger.Infof("completing removal of state server machine %v", m)
+		if err := w.st.CompleteStateServerRemoval(m.id); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
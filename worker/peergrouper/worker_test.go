@@ -15,6 +15,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
+	"github.com/juju/juju/replicaset"
 	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/worker"
@@ -230,6 +231,64 @@ func (s *workerSuite) TestSetsAndUpdatesMembers(c *gc.C) {
 	})
 }
 
+func (s *workerSuite) TestCompletesStateServerRemoval(c *gc.C) {
+	testForIPv4AndIPv6(func(ipVersion testIPVersion) {
+		s.PatchValue(&pollInterval, 5*time.Millisecond)
+
+		st := newFakeState()
+		initState(c, st, 4, ipVersion)
+		st.machine("13").setWantsVote(false)
+		st.session.setStatus(mkStatuses("0p 1s 2s 3s", ipVersion))
+
+		memberWatcher := st.session.members.Watch()
+		mustNext(c, memberWatcher)
+
+		w := newWorker(st, noPublisher{})
+		defer func() {
+			c.Check(worker.Stop(w), gc.IsNil)
+		}()
+		for {
+			val := mustNext(c, memberWatcher)
+			if votingCount(val.([]replicaset.Member)) == 3 {
+				break
+			}
+		}
+		assertMembers(c, memberWatcher.Value(), mkMembers("0v 1v 2v 3", ipVersion))
+
+		// Ask for machine 10 to be removed, and give its
+		// vote to machine 13.
+		c.Logf("removing state server machine 10")
+		st.machine("10").setRemoving()
+		st.machine("13").setWantsVote(true)
+
+		// Machine 10 loses its vote and then, once its state
+		// server status has been removed, its membership.
+		c.Logf("waiting for removal")
+		for {
+			val := mustNext(c, memberWatcher)
+			if len(val.([]replicaset.Member)) == 3 {
+				break
+			}
+		}
+		assertMembers(c, memberWatcher.Value(), mkMembers("1v 2v 3v", ipVersion))
+		info, err := st.StateServerInfo()
+		c.Assert(err, gc.IsNil)
+		c.Assert(info.MachineIds, jc.SameContents, []string{"11", "12", "13"})
+		c.Assert(st.machine("10").HasVote(), jc.IsFalse)
+		c.Assert(st.machine("10").IsRemovingStateServer(), jc.IsFalse)
+	})
+}
+
+func votingCount(members []replicaset.Member) int {
+	n := 0
+	for i := range members {
+		if isVotingMember(&members[i]) {
+			n++
+		}
+	}
+	return n
+}
+
 func (s *workerSuite) TestHasVoteMaintainedEvenWhenReplicaSetFails(c *gc.C) {
 	testForIPv4AndIPv6(func(ipVersion testIPVersion) {
 		st := newFakeState()
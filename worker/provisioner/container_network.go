@@ -0,0 +1,136 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/container"
+	"github.com/juju/juju/instance"
+)
+
+// prepareContainerNetwork returns the network config to start the
+// container for the given machine with. It asks for an address on
+// the host's network to be allocated for the container, so that the
+// container is reachable from other hosts; if the environment cannot
+// allocate addresses, the container gets a private address from the
+// bridge as before.
+func prepareContainerNetwork(api APICalls, logger loggo.Logger, machineId, bridgeDevice string) (*container.NetworkConfig, error) {
+	network := container.BridgeNetworkConfig(bridgeDevice)
+	tag := names.NewMachineTag(machineId)
+	result, err := api.AllocateContainerAddress(tag)
+	if params.IsCodeNotSupported(err) || params.IsCodeNotImplemented(err) {
+		logger.Infof("not allocating an address for container %q: %v", machineId, err)
+		return network, nil
+	} else if err != nil {
+		return nil, err
+	}
+	address := result.Address.Value
+	if err := addContainerRoute(address, bridgeDevice); err != nil {
+		releaseContainerAddresses(api, logger, tag, bridgeDevice)
+		return nil, err
+	}
+	logger.Infof("allocated address %q for container %q", address, machineId)
+	network.StaticAddress = &container.StaticAddress{
+		Address: address,
+		CIDR:    result.CIDR,
+		Gateway: result.GatewayAddress,
+	}
+	return network, nil
+}
+
+// releaseContainerAddresses releases the addresses allocated for
+// the container with the given tag and removes the host routes to
+// them, logging any failure: a container that is going away must not
+// be kept because of it. Addresses that cannot be released stay
+// recorded in state, and are released when an address is next
+// allocated for a container on the same host; their routes are
+// removed anyway, so that the host does not keep answering for
+// addresses the provider may give to another host.
+func releaseContainerAddresses(api APICalls, logger loggo.Logger, tag names.MachineTag, bridgeDevice string) {
+	addresses, err := api.ReleaseContainerAddresses(tag)
+	if err != nil && !params.IsCodeNotImplemented(err) {
+		logger.Errorf("cannot release addresses of container %q: %v", tag.Id(), err)
+	}
+	for _, address := range addresses {
+		if err := removeContainerRoute(address, bridgeDevice); err != nil {
+			logger.Errorf("cannot remove route to address %q of container %q: %v", address, tag.Id(), err)
+		}
+	}
+}
+
+// containerMachineTag returns the tag of the machine a container was
+// started for, given the container's instance id. Containers are
+// named after the tags of their machines, prefixed by the namespace
+// of their container manager.
+func containerMachineTag(namespace string, id instance.Id) (names.MachineTag, error) {
+	name := string(id)
+	if namespace != "" {
+		name = strings.TrimPrefix(name, namespace+"-")
+	}
+	return names.ParseMachineTag(name)
+}
+
+// releaseStoppedContainerAddresses releases the addresses allocated
+// for the destroyed container with the given instance id, and removes
+// the host routes to them.
+func releaseStoppedContainerAddresses(api APICalls, logger loggo.Logger, namespace string, id instance.Id, bridgeDevice string) {
+	tag, err := containerMachineTag(namespace, id)
+	if err != nil {
+		logger.Warningf("cannot release addresses of container %q: %v", id, err)
+		return
+	}
+	releaseContainerAddresses(api, logger, tag, bridgeDevice)
+}
+
+// addContainerRoute sets up the host to route traffic for the given
+// container address to the container over the bridge, and to answer
+// ARP requests for it on the host's primary network interface.
+var addContainerRoute = func(address, bridgeDevice string) error {
+	if _, err := utils.RunCommand("ip", "route", "replace", address, "dev", bridgeDevice); err != nil {
+		return err
+	}
+	device, err := primaryDevice()
+	if err != nil {
+		return err
+	}
+	for _, sysctl := range []string{
+		"net.ipv4.ip_forward=1",
+		fmt.Sprintf("net.ipv4.conf.%s.proxy_arp=1", device),
+	} {
+		if _, err := utils.RunCommand("sysctl", "-w", sysctl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeContainerRoute removes the host route to the given container
+// address.
+var removeContainerRoute = func(address, bridgeDevice string) error {
+	_, err := utils.RunCommand("ip", "route", "del", address, "dev", bridgeDevice)
+	return err
+}
+
+// primaryDevice returns the host's primary network interface, the one
+// its default route goes through.
+func primaryDevice() (string, error) {
+	out, err := utils.RunCommand("ip", "route", "show", "default")
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("cannot find the host's default route")
+}
@@ -22,4 +22,6 @@ func GetRetryWatcher(p Provisioner) (watcher.NotifyWatcher, error) {
 var (
 	ContainerManagerConfig = containerManagerConfig
 	GetToolsFinder         = &getToolsFinder
+	AddContainerRoute      = &addContainerRoute
+	RemoveContainerRoute   = &removeContainerRoute
 )
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/juju/loggo"
+	"github.com/juju/names"
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/container"
@@ -25,12 +26,15 @@ func NewKvmBroker(
 	agentConfig agent.Config,
 	managerConfig container.ManagerConfig,
 ) (environs.InstanceBroker, error) {
+	// The manager pops the namespace from the config.
+	namespace := managerConfig[container.ConfigName]
 	manager, err := kvm.NewContainerManager(managerConfig)
 	if err != nil {
 		return nil, err
 	}
 	return &kvmBroker{
 		manager:     manager,
+		namespace:   namespace,
 		api:         api,
 		agentConfig: agentConfig,
 	}, nil
@@ -38,6 +42,7 @@ func NewKvmBroker(
 
 type kvmBroker struct {
 	manager     container.Manager
+	namespace   string
 	api         APICalls
 	agentConfig agent.Config
 }
@@ -54,11 +59,7 @@ func (broker *kvmBroker) StartInstance(args environs.StartInstanceParams) (insta
 	// TODO: Default to using the host network until we can configure.  Yes,
 	// this is using the LxcBridge value, we should put it in the api call for
 	// container config.
-	bridgeDevice := broker.agentConfig.Value(agent.LxcBridge)
-	if bridgeDevice == "" {
-		bridgeDevice = kvm.DefaultKvmBridge
-	}
-	network := container.BridgeNetworkConfig(bridgeDevice)
+	bridgeDevice := broker.bridgeDevice()
 
 	series := args.Tools.OneSeries()
 	args.MachineConfig.MachineContainerType = instance.KVM
@@ -85,15 +86,31 @@ func (broker *kvmBroker) StartInstance(args environs.StartInstanceParams) (insta
 		return nil, nil, nil, err
 	}
 
+	network, err := prepareContainerNetwork(broker.api, kvmLogger, machineId, bridgeDevice)
+	if err != nil {
+		kvmLogger.Errorf("failed to prepare container network: %v", err)
+		return nil, nil, nil, err
+	}
 	inst, hardware, err := broker.manager.CreateContainer(args.MachineConfig, series, network)
 	if err != nil {
 		kvmLogger.Errorf("failed to start container: %v", err)
+		if network.StaticAddress != nil {
+			releaseContainerAddresses(broker.api, kvmLogger, names.NewMachineTag(machineId), bridgeDevice)
+		}
 		return nil, nil, nil, err
 	}
 	kvmLogger.Infof("started kvm container for machineId: %s, %s, %s", machineId, inst.Id(), hardware.String())
 	return inst, hardware, nil, nil
 }
 
+// bridgeDevice returns the network bridge containers are attached to.
+func (broker *kvmBroker) bridgeDevice() string {
+	if bridgeDevice := broker.agentConfig.Value(agent.LxcBridge); bridgeDevice != "" {
+		return bridgeDevice
+	}
+	return kvm.DefaultKvmBridge
+}
+
 // StopInstances shuts down the given instances.
 func (broker *kvmBroker) StopInstances(ids ...instance.Id) error {
 	// TODO: potentially parallelise.
@@ -103,6 +120,7 @@ func (broker *kvmBroker) StopInstances(ids ...instance.Id) error {
 			kvmLogger.Errorf("container did not stop: %v", err)
 			return err
 		}
+		releaseStoppedContainerAddresses(broker.api, kvmLogger, broker.namespace, id, broker.bridgeDevice())
 	}
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/juju/loggo"
+	"github.com/juju/names"
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/apiserver/params"
@@ -23,15 +24,20 @@ var _ environs.InstanceBroker = (*lxcBroker)(nil)
 
 type APICalls interface {
 	ContainerConfig() (params.ContainerConfig, error)
+	AllocateContainerAddress(tag names.MachineTag) (params.ContainerAddressResult, error)
+	ReleaseContainerAddresses(tag names.MachineTag) ([]string, error)
 }
 
 func NewLxcBroker(api APICalls, agentConfig agent.Config, managerConfig container.ManagerConfig) (environs.InstanceBroker, error) {
+	// The manager pops the namespace from the config.
+	namespace := managerConfig[container.ConfigName]
 	manager, err := lxc.NewContainerManager(managerConfig)
 	if err != nil {
 		return nil, err
 	}
 	return &lxcBroker{
 		manager:     manager,
+		namespace:   namespace,
 		api:         api,
 		agentConfig: agentConfig,
 	}, nil
@@ -39,6 +45,7 @@ func NewLxcBroker(api APICalls, agentConfig agent.Config, managerConfig containe
 
 type lxcBroker struct {
 	manager     container.Manager
+	namespace   string
 	api         APICalls
 	agentConfig agent.Config
 }
@@ -53,11 +60,7 @@ func (broker *lxcBroker) StartInstance(args environs.StartInstanceParams) (insta
 	lxcLogger.Infof("starting lxc container for machineId: %s", machineId)
 
 	// Default to using the host network until we can configure.
-	bridgeDevice := broker.agentConfig.Value(agent.LxcBridge)
-	if bridgeDevice == "" {
-		bridgeDevice = lxc.DefaultLxcBridge
-	}
-	network := container.BridgeNetworkConfig(bridgeDevice)
+	bridgeDevice := broker.bridgeDevice()
 
 	series := args.Tools.OneSeries()
 	args.MachineConfig.MachineContainerType = instance.LXC
@@ -83,15 +86,31 @@ func (broker *lxcBroker) StartInstance(args environs.StartInstanceParams) (insta
 		return nil, nil, nil, err
 	}
 
+	network, err := prepareContainerNetwork(broker.api, lxcLogger, machineId, bridgeDevice)
+	if err != nil {
+		lxcLogger.Errorf("failed to prepare container network: %v", err)
+		return nil, nil, nil, err
+	}
 	inst, hardware, err := broker.manager.CreateContainer(args.MachineConfig, series, network)
 	if err != nil {
 		lxcLogger.Errorf("failed to start container: %v", err)
+		if network.StaticAddress != nil {
+			releaseContainerAddresses(broker.api, lxcLogger, names.NewMachineTag(machineId), bridgeDevice)
+		}
 		return nil, nil, nil, err
 	}
 	lxcLogger.Infof("started lxc container for machineId: %s, %s, %s", machineId, inst.Id(), hardware.String())
 	return inst, hardware, nil, nil
 }
 
+// bridgeDevice returns the network bridge containers are attached to.
+func (broker *lxcBroker) bridgeDevice() string {
+	if bridgeDevice := broker.agentConfig.Value(agent.LxcBridge); bridgeDevice != "" {
+		return bridgeDevice
+	}
+	return lxc.DefaultLxcBridge
+}
+
 // StopInstances shuts down the given instances.
 func (broker *lxcBroker) StopInstances(ids ...instance.Id) error {
 	// TODO: potentially parallelise.
@@ -101,6 +120,7 @@ func (broker *lxcBroker) StopInstances(ids ...instance.Id) error {
 			lxcLogger.Errorf("container did not stop: %v", err)
 			return err
 		}
+		releaseStoppedContainerAddresses(broker.api, lxcLogger, broker.namespace, id, broker.bridgeDevice())
 	}
 	return nil
 }
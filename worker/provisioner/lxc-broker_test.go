@@ -42,6 +42,7 @@ type lxcBrokerSuite struct {
 	lxcSuite
 	broker      environs.InstanceBroker
 	agentConfig agent.ConfigSetterWriter
+	api         *fakeAPI
 }
 
 var _ = gc.Suite(&lxcBrokerSuite{})
@@ -80,7 +81,8 @@ func (s *lxcBrokerSuite) SetUpTest(c *gc.C) {
 		})
 	c.Assert(err, gc.IsNil)
 	managerConfig := container.ManagerConfig{container.ConfigName: "juju", "use-clone": "false"}
-	s.broker, err = provisioner.NewLxcBroker(&fakeAPI{}, s.agentConfig, managerConfig)
+	s.api = &fakeAPI{}
+	s.broker, err = provisioner.NewLxcBroker(s.api, s.agentConfig, managerConfig)
 	c.Assert(err, gc.IsNil)
 }
 
@@ -131,6 +133,48 @@ func (s *lxcBrokerSuite) TestStartInstanceWithBridgeEnviron(c *gc.C) {
 	c.Assert(string(lxcConfContents), jc.Contains, "lxc.network.link = br0")
 }
 
+func (s *lxcBrokerSuite) TestStartInstanceWithAllocatedAddress(c *gc.C) {
+	var routes []string
+	s.PatchValue(provisioner.AddContainerRoute, func(address, bridgeDevice string) error {
+		routes = append(routes, address+" dev "+bridgeDevice)
+		return nil
+	})
+	s.PatchValue(provisioner.RemoveContainerRoute, func(address, bridgeDevice string) error {
+		routes = append(routes, "del "+address+" dev "+bridgeDevice)
+		return nil
+	})
+	s.api.address = &params.ContainerAddressResult{
+		Address:        network.NewAddress("0.10.0.5", network.ScopeCloudLocal),
+		CIDR:           "0.10.0.0/24",
+		NetworkId:      "dummy-private",
+		GatewayAddress: "0.10.0.2",
+	}
+	lxc := s.startInstance(c, "1/lxc/0")
+	c.Assert(s.api.allocated, jc.DeepEquals, []string{"machine-1-lxc-0"})
+	c.Assert(routes, jc.DeepEquals, []string{"0.10.0.5 dev lxcbr0"})
+	userData, err := ioutil.ReadFile(filepath.Join(s.ContainerDir, string(lxc.Id()), "cloud-init"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(userData), jc.Contains, "address 0.10.0.5")
+	c.Assert(string(userData), jc.Contains, "gateway 0.10.0.2")
+
+	err = s.broker.StopInstances(lxc.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.api.released, jc.DeepEquals, []string{"machine-1-lxc-0"})
+	c.Assert(routes, jc.DeepEquals, []string{"0.10.0.5 dev lxcbr0", "del 0.10.0.5 dev lxcbr0"})
+}
+
+func (s *lxcBrokerSuite) TestStartInstanceWithoutAddressAllocation(c *gc.C) {
+	s.PatchValue(provisioner.AddContainerRoute, func(address, bridgeDevice string) error {
+		c.Fatalf("unexpected route for %q", address)
+		return nil
+	})
+	lxc := s.startInstance(c, "1/lxc/0")
+	c.Assert(s.api.allocated, jc.DeepEquals, []string{"machine-1-lxc-0"})
+	userData, err := ioutil.ReadFile(filepath.Join(s.ContainerDir, string(lxc.Id()), "cloud-init"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(userData), gc.Not(jc.Contains), "inet static")
+}
+
 func (s *lxcBrokerSuite) TestStopInstance(c *gc.C) {
 	lxc0 := s.startInstance(c, "1/lxc/0")
 	lxc1 := s.startInstance(c, "1/lxc/1")
@@ -298,7 +342,13 @@ func (s *lxcProvisionerSuite) TestContainerStartedAndStopped(c *gc.C) {
 	s.waitRemoved(c, container)
 }
 
-type fakeAPI struct{}
+// fakeAPI implements provisioner.APICalls. Unless an address is set,
+// address allocation is not supported.
+type fakeAPI struct {
+	address   *params.ContainerAddressResult
+	allocated []string
+	released  []string
+}
 
 func (*fakeAPI) ContainerConfig() (params.ContainerConfig, error) {
 	return params.ContainerConfig{
@@ -307,3 +357,22 @@ func (*fakeAPI) ContainerConfig() (params.ContainerConfig, error) {
 		AuthorizedKeys:          coretesting.FakeAuthKeys,
 		SSLHostnameVerification: true}, nil
 }
+
+func (f *fakeAPI) AllocateContainerAddress(tag names.MachineTag) (params.ContainerAddressResult, error) {
+	f.allocated = append(f.allocated, tag.String())
+	if f.address == nil {
+		return params.ContainerAddressResult{}, &params.Error{
+			Message: "address allocation not supported",
+			Code:    params.CodeNotSupported,
+		}
+	}
+	return *f.address, nil
+}
+
+func (f *fakeAPI) ReleaseContainerAddresses(tag names.MachineTag) ([]string, error) {
+	f.released = append(f.released, tag.String())
+	if f.address == nil {
+		return nil, nil
+	}
+	return []string{f.address.Address.Value}, nil
+}
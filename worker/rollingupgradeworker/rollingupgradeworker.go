@@ -0,0 +1,50 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package rollingupgradeworker
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.rollingupgradeworker")
+
+// interval sets how often running rolling upgrades are checked.
+var interval = 10 * time.Second
+
+// NewRollingUpgradeWorker returns a worker that periodically advances
+// the running rolling charm upgrades of all services: it admits the
+// next batch of units once the current one has upgraded, and pauses
+// an upgrade when one of its units fails. As all progress is recorded
+// in state, upgrades carry on where they left off when the worker is
+// restarted.
+func NewRollingUpgradeWorker(st *state.State) worker.Worker {
+	f := func(stop <-chan struct{}) error {
+		upgrades, err := st.AllRollingUpgrades()
+		if err != nil {
+			return err
+		}
+		for _, upgrade := range upgrades {
+			if upgrade.Status() != state.RollingUpgradeRunning {
+				continue
+			}
+			if err := upgrade.Advance(); err != nil {
+				logger.Errorf("%v", err)
+				continue
+			}
+			switch upgrade.Status() {
+			case state.RollingUpgradePaused:
+				logger.Warningf("paused rolling upgrade of service %q: %s", upgrade.ServiceName(), upgrade.Message())
+			case state.RollingUpgradeComplete:
+				logger.Infof("completed rolling upgrade of service %q to %q", upgrade.ServiceName(), upgrade.CharmURL())
+			}
+		}
+		return nil
+	}
+	return worker.NewPeriodicWorker(f, interval)
+}
@@ -0,0 +1,88 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package rollingupgradeworker_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/rollingupgradeworker"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type RollingUpgradeSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&RollingUpgradeSuite{})
+
+var dummyMeta = `
+name: dummy
+summary: "That's a dummy charm."
+description: "This is a dummy charm."
+`
+
+func (s *RollingUpgradeSuite) waitForStatus(c *gc.C, upgrade *state.RollingUpgrade, status state.RollingUpgradeStatus, batch ...string) {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err := upgrade.Refresh()
+		c.Assert(err, gc.IsNil)
+		if upgrade.Status() == status && len(upgrade.Batch()) == len(batch) {
+			c.Assert(upgrade.Batch(), jc.DeepEquals, batch)
+			return
+		}
+	}
+	c.Fatalf("timed out waiting for %s upgrade with batch %v; got %s with %v",
+		status, batch, upgrade.Status(), upgrade.Batch())
+}
+
+func (s *RollingUpgradeSuite) TestAdvancesRollingUpgrades(c *gc.C) {
+	s.PatchValue(rollingupgradeworker.Interval, 10*time.Millisecond)
+	oldCharm := s.AddMetaCharm(c, "dummy", dummyMeta, 1)
+	newCharm := s.AddMetaCharm(c, "dummy", dummyMeta, 2)
+	service := s.AddTestingService(c, "dummy", oldCharm)
+	var units []*state.Unit
+	for i := 0; i < 3; i++ {
+		unit, err := service.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.SetCharmURL(oldCharm.URL())
+		c.Assert(err, gc.IsNil)
+		err = unit.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+		units = append(units, unit)
+	}
+	err := service.SetCharmRolling(newCharm, false, 2)
+	c.Assert(err, gc.IsNil)
+	upgrade, err := service.RollingUpgrade()
+	c.Assert(err, gc.IsNil)
+
+	w := rollingupgradeworker.NewRollingUpgradeWorker(s.State)
+	defer func() {
+		w.Kill()
+		c.Assert(w.Wait(), gc.IsNil)
+	}()
+	s.waitForStatus(c, upgrade, state.RollingUpgradeRunning, "dummy/0", "dummy/1")
+
+	for _, unit := range units[:2] {
+		err = unit.SetCharmURL(newCharm.URL())
+		c.Assert(err, gc.IsNil)
+		err = unit.SetStatus(params.StatusStarted, "", nil)
+		c.Assert(err, gc.IsNil)
+	}
+	s.waitForStatus(c, upgrade, state.RollingUpgradeRunning, "dummy/2")
+
+	err = units[2].SetStatus(params.StatusError, `hook failed: "upgrade-charm"`, nil)
+	c.Assert(err, gc.IsNil)
+	s.waitForStatus(c, upgrade, state.RollingUpgradePaused, "dummy/2")
+	c.Assert(upgrade.Message(), gc.Equals, `unit "dummy/2" failed: hook failed: "upgrade-charm"`)
+}
@@ -0,0 +1,35 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runjobcleaner
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.runjobcleaner")
+
+// interval sets how often old run jobs are removed.
+var interval = time.Hour
+
+// DefaultMaxAge holds how long run jobs are kept by default, so their
+// results can still be retrieved.
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// NewRunJobCleaner returns a worker that periodically removes the
+// asynchronous run jobs added longer than maxAge ago, with their
+// tasks and results.
+func NewRunJobCleaner(st *state.State, maxAge time.Duration) worker.Worker {
+	f := func(stop <-chan struct{}) error {
+		if err := st.CleanupOldRunJobs(time.Now().Add(-maxAge)); err != nil {
+			logger.Errorf("%v", err)
+		}
+		return nil
+	}
+	return worker.NewPeriodicWorker(f, interval)
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runjobcleaner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/runjobcleaner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type RunJobCleanerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&RunJobCleanerSuite{})
+
+func (s *RunJobCleanerSuite) TestRemovesOldRunJobs(c *gc.C) {
+	s.PatchValue(runjobcleaner.Interval, 10*time.Millisecond)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{machine.Tag()})
+	c.Assert(err, gc.IsNil)
+
+	// A job is kept until it is older than the maximum age.
+	w := runjobcleaner.NewRunJobCleaner(s.State, time.Hour)
+	time.Sleep(coretesting.ShortWait)
+	c.Assert(worker.Stop(w), gc.IsNil)
+	err = job.Refresh()
+	c.Assert(err, gc.IsNil)
+
+	w = runjobcleaner.NewRunJobCleaner(s.State, -time.Minute)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err = job.Refresh()
+		if errors.IsNotFound(err) {
+			return
+		}
+		c.Assert(err, gc.IsNil)
+	}
+	c.Fatalf("timed out waiting for run job %q to be removed", job.Id())
+}
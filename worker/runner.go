@@ -5,15 +5,24 @@ package worker
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"launchpad.net/tomb"
+
+	"github.com/juju/juju/stats"
 )
 
 // RestartDelay holds the length of time that a worker
 // will wait between exiting and restarting.
 var RestartDelay = 3 * time.Second
 
+var workerRestarts = stats.NewCounter(
+	"juju_worker_restarts_total",
+	"Number of times workers have been restarted after exiting, by worker id.",
+	"worker",
+)
+
 // Worker is implemented by a running worker.
 type Worker interface {
 	// Kill asks the worker to stop without necessarily
@@ -31,6 +40,36 @@ type Runner interface {
 	StopWorker(id string) error
 }
 
+// WorkerReport describes a worker started by a Runner.
+type WorkerReport struct {
+	// Id holds the id the worker was started with.
+	Id string
+
+	// Running holds whether the worker is currently running.
+	Running bool
+
+	// Restarts holds the number of times the worker has been
+	// restarted after exiting.
+	Restarts int
+
+	// LastError holds the most recent error returned by the
+	// worker, and LastErrorTime the time it was returned. Both
+	// are empty if the worker has never returned an error.
+	LastError     string
+	LastErrorTime time.Time
+
+	// Workers holds reports on the workers run by this worker,
+	// if it implements Reporter.
+	Workers []WorkerReport
+}
+
+// Reporter is implemented by workers that can describe the
+// workers they run.
+type Reporter interface {
+	// Report returns a report for each worker, ordered by id.
+	Report() []WorkerReport
+}
+
 // runner runs a set of workers, restarting them as necessary
 // when they fail.
 type runner struct {
@@ -39,11 +78,15 @@ type runner struct {
 	stopc         chan string
 	donec         chan doneInfo
 	startedc      chan startInfo
+	reportc       chan chan []workerSnapshot
 	isFatal       func(error) bool
 	moreImportant func(err0, err1 error) bool
 }
 
-var _ Runner = (*runner)(nil)
+var (
+	_ Runner   = (*runner)(nil)
+	_ Reporter = (*runner)(nil)
+)
 
 type startReq struct {
 	id    string
@@ -60,6 +103,13 @@ type doneInfo struct {
 	err error
 }
 
+// workerSnapshot holds the state of a worker as seen
+// by the runner's main loop.
+type workerSnapshot struct {
+	report WorkerReport
+	worker Worker
+}
+
 // NewRunner creates a new Runner.  When a worker finishes, if its error
 // is deemed fatal (determined by calling isFatal), all the other workers
 // will be stopped and the runner itself will finish.  Of all the fatal errors
@@ -76,6 +126,7 @@ func NewRunner(isFatal func(error) bool, moreImportant func(err0, err1 error) bo
 		stopc:         make(chan string),
 		donec:         make(chan doneInfo),
 		startedc:      make(chan startInfo),
+		reportc:       make(chan chan []workerSnapshot),
 		isFatal:       isFatal,
 		moreImportant: moreImportant,
 	}
@@ -122,6 +173,29 @@ func (runner *runner) Wait() error {
 	return runner.tomb.Wait()
 }
 
+// Report implements Reporter. It returns nil if the
+// runner is not running.
+func (runner *runner) Report() []WorkerReport {
+	replyc := make(chan []workerSnapshot, 1)
+	select {
+	case runner.reportc <- replyc:
+	case <-runner.tomb.Dead():
+		return nil
+	}
+	snapshots := <-replyc
+	reports := make([]WorkerReport, len(snapshots))
+	for i, snapshot := range snapshots {
+		reports[i] = snapshot.report
+		// Ask any nested runners for their reports outside
+		// the main loop, so that a slow worker cannot hold
+		// it up.
+		if reporter, ok := snapshot.worker.(Reporter); ok {
+			reports[i].Workers = reporter.Report()
+		}
+	}
+	return reports
+}
+
 func (runner *runner) Kill() {
 	logger.Debugf("killing runner %p", runner)
 	runner.tomb.Kill(nil)
@@ -138,6 +212,10 @@ type workerInfo struct {
 	worker       Worker
 	restartDelay time.Duration
 	stopping     bool
+
+	restarts      int
+	lastErr       error
+	lastErrorTime time.Time
 }
 
 func (runner *runner) run() error {
@@ -191,6 +269,8 @@ func (runner *runner) run() error {
 			if info := workers[id]; info != nil {
 				killWorker(id, info)
 			}
+		case replyc := <-runner.reportc:
+			replyc <- snapshotWorkers(workers)
 		case info := <-runner.startedc:
 			workerInfo := workers[info.id]
 			workerInfo.worker = info.worker
@@ -199,11 +279,14 @@ func (runner *runner) run() error {
 			}
 		case info := <-runner.donec:
 			workerInfo := workers[info.id]
+			workerInfo.worker = nil
 			if !workerInfo.stopping && info.err == nil {
 				delete(workers, info.id)
 				break
 			}
 			if info.err != nil {
+				workerInfo.lastErr = info.err
+				workerInfo.lastErrorTime = time.Now()
 				if runner.isFatal(info.err) {
 					logger.Errorf("fatal %q: %v", info.id, info.err)
 					if finalError == nil || runner.moreImportant(info.err, finalError) {
@@ -225,12 +308,42 @@ func (runner *runner) run() error {
 				delete(workers, info.id)
 				break
 			}
+			workerRestarts.Inc(info.id)
+			workerInfo.restarts++
 			go runner.runWorker(workerInfo.restartDelay, info.id, workerInfo.start)
 			workerInfo.restartDelay = RestartDelay
 		}
 	}
 }
 
+// snapshotWorkers returns the current state of the given
+// workers, ordered by id.
+func snapshotWorkers(workers map[string]*workerInfo) []workerSnapshot {
+	ids := make([]string, 0, len(workers))
+	for id := range workers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	snapshots := make([]workerSnapshot, len(ids))
+	for i, id := range ids {
+		info := workers[id]
+		report := WorkerReport{
+			Id:       id,
+			Running:  info.worker != nil,
+			Restarts: info.restarts,
+		}
+		if info.lastErr != nil {
+			report.LastError = info.lastErr.Error()
+			report.LastErrorTime = info.lastErrorTime
+		}
+		snapshots[i] = workerSnapshot{
+			report: report,
+			worker: info.worker,
+		}
+	}
+	return snapshots
+}
+
 func killAll(workers map[string]*workerInfo) {
 	for id, info := range workers {
 		killWorker(id, info)
@@ -82,6 +82,71 @@ func (*runnerSuite) TestOneWorkerRestart(c *gc.C) {
 	starter.assertStarted(c, false)
 }
 
+// waitReport polls the runner until the report for the worker
+// with the given id satisfies the given condition.
+func waitReport(c *gc.C, runner worker.Runner, id string, cond func(worker.WorkerReport) bool) worker.WorkerReport {
+	reporter, ok := runner.(worker.Reporter)
+	c.Assert(ok, gc.Equals, true)
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		for _, report := range reporter.Report() {
+			if report.Id == id && cond(report) {
+				return report
+			}
+		}
+	}
+	c.Fatalf("timed out waiting for report on %q", id)
+	panic("unreachable")
+}
+
+func (*runnerSuite) TestReport(c *gc.C) {
+	runner := worker.NewRunner(noneFatal, noImportance)
+	starter := newTestWorkerStarter()
+	err := runner.StartWorker("id", testWorkerStart(starter))
+	c.Assert(err, gc.IsNil)
+	starter.assertStarted(c, true)
+	report := waitReport(c, runner, "id", func(r worker.WorkerReport) bool {
+		return r.Running
+	})
+	c.Assert(report, gc.DeepEquals, worker.WorkerReport{Id: "id", Running: true})
+
+	starter.die <- fmt.Errorf("an error")
+	starter.assertStarted(c, false)
+	starter.assertStarted(c, true)
+	report = waitReport(c, runner, "id", func(r worker.WorkerReport) bool {
+		return r.Running && r.Restarts == 1
+	})
+	c.Assert(report.LastError, gc.Equals, "an error")
+	c.Assert(report.LastErrorTime.IsZero(), gc.Equals, false)
+
+	c.Assert(worker.Stop(runner), gc.IsNil)
+	starter.assertStarted(c, false)
+	c.Assert(runner.(worker.Reporter).Report(), gc.IsNil)
+}
+
+func (*runnerSuite) TestReportNested(c *gc.C) {
+	runner := worker.NewRunner(noneFatal, noImportance)
+	inner := worker.NewRunner(noneFatal, noImportance)
+	err := runner.StartWorker("inner", func() (worker.Worker, error) {
+		return inner, nil
+	})
+	c.Assert(err, gc.IsNil)
+	starter := newTestWorkerStarter()
+	err = inner.StartWorker("leaf", testWorkerStart(starter))
+	c.Assert(err, gc.IsNil)
+	starter.assertStarted(c, true)
+
+	report := waitReport(c, runner, "inner", func(r worker.WorkerReport) bool {
+		return len(r.Workers) == 1 && r.Workers[0].Running
+	})
+	c.Assert(report, gc.DeepEquals, worker.WorkerReport{
+		Id:      "inner",
+		Running: true,
+		Workers: []worker.WorkerReport{{Id: "leaf", Running: true}},
+	})
+	c.Assert(worker.Stop(runner), gc.IsNil)
+	starter.assertStarted(c, false)
+}
+
 func (*runnerSuite) TestOneWorkerStartFatalError(c *gc.C) {
 	runner := worker.NewRunner(allFatal, noImportance)
 	starter := newTestWorkerStarter()
@@ -0,0 +1,260 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runtaskworker
+
+import (
+	"bytes"
+	"io"
+	osexec "os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"github.com/juju/utils/exec"
+
+	"github.com/juju/juju/api/runtasks"
+	"github.com/juju/juju/api/watcher"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/juju/paths"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.runtaskworker")
+
+// runTaskHandler runs the tasks of asynchronous run jobs targeting a
+// machine or the units assigned to it.
+type runTaskHandler struct {
+	st  *runtasks.State
+	tag names.MachineTag
+
+	// abort is closed when the worker is killed, so running commands
+	// are killed too.
+	abort chan struct{}
+}
+
+// runTaskWorker wraps the strings worker running the handler, so
+// killing it aborts the commands being run.
+type runTaskWorker struct {
+	worker.Worker
+	abort     chan struct{}
+	abortOnce sync.Once
+}
+
+// NewRunTaskWorker returns a worker that runs the tasks of
+// asynchronous run jobs targeting the machine with the given tag, or
+// the units assigned to it, and records their outcome.
+func NewRunTaskWorker(st *runtasks.State, tag names.MachineTag) worker.Worker {
+	h := &runTaskHandler{st: st, tag: tag, abort: make(chan struct{})}
+	return &runTaskWorker{
+		Worker: worker.NewStringsWorker(h),
+		abort:  h.abort,
+	}
+}
+
+// Kill is part of the worker.Worker interface.
+func (w *runTaskWorker) Kill() {
+	w.abortOnce.Do(func() { close(w.abort) })
+	w.Worker.Kill()
+}
+
+// SetUp is part of the worker.StringsWatchHandler interface. Tasks
+// left running by a previous incarnation of the agent are recorded
+// as failed, as nothing would ever finish them.
+func (h *runTaskHandler) SetUp() (watcher.StringsWatcher, error) {
+	if err := h.st.FailRunningTasks(h.tag); err != nil {
+		return nil, err
+	}
+	return h.st.WatchRunTasks(h.tag)
+}
+
+// TearDown is part of the worker.StringsWatchHandler interface.
+func (h *runTaskHandler) TearDown() error {
+	return nil
+}
+
+// Handle is part of the worker.StringsWatchHandler interface. The
+// tasks are run one at a time; commands run on the same machine are
+// serialised by the hook execution lock in any case.
+func (h *runTaskHandler) Handle(ids []string) error {
+	results, err := h.st.RunTasks(ids)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		select {
+		case <-h.abort:
+			// Leave the remaining tasks pending.
+			return nil
+		default:
+		}
+		if result.Error != nil {
+			logger.Warningf("cannot get run task: %v", result.Error)
+			continue
+		}
+		task := result.Result
+		if err := h.st.StartRunTask(task.Id); err != nil {
+			// The task may have been started by a previous
+			// incarnation of the agent.
+			logger.Warningf("%v", err)
+			continue
+		}
+		logger.Debugf("running task %q on %s", task.Id, task.Target)
+		if err := h.st.FinishRunTask(h.runTask(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTask runs the task's commands, sending their output to the API
+// server as they run, and returns their outcome.
+func (h *runTaskHandler) runTask(task params.RunTask) params.RunTaskOutcome {
+	output := &taskOutput{st: h.st, id: task.Id}
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go output.loop(stop, stopped)
+	response, err := runCommands(task, output.writer(false), output.writer(true), h.abort)
+	close(stop)
+	<-stopped
+	if err := output.flush(); err != nil {
+		logger.Warningf("%v", err)
+	}
+	outcome := params.RunTaskOutcome{Id: task.Id}
+	if err != nil {
+		outcome.Error = err.Error()
+	} else {
+		outcome.ExecResponse = *response
+	}
+	return outcome
+}
+
+// outputFlushInterval holds how often the output of running commands
+// is sent to the API server.
+var outputFlushInterval = time.Second
+
+// taskOutput collects the output of a task's commands as they run,
+// and sends it to the API server so it can be streamed to clients.
+type taskOutput struct {
+	st     *runtasks.State
+	id     string
+	mu     sync.Mutex
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+// writer returns a writer collecting the commands' standard output,
+// or their standard error if stderr is true.
+func (o *taskOutput) writer(stderr bool) io.Writer {
+	return &outputWriter{o, stderr}
+}
+
+// loop sends the collected output every outputFlushInterval until
+// stop is closed.
+func (o *taskOutput) loop(stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(outputFlushInterval):
+			if err := o.flush(); err != nil {
+				// The output is recorded when the task
+				// finishes in any case.
+				logger.Warningf("%v", err)
+			}
+		}
+	}
+}
+
+// flush sends the output collected since the last flush.
+func (o *taskOutput) flush() error {
+	o.mu.Lock()
+	output := params.RunTaskOutput{
+		Id:     o.id,
+		Stdout: append([]byte(nil), o.stdout.Bytes()...),
+		Stderr: append([]byte(nil), o.stderr.Bytes()...),
+	}
+	o.stdout.Reset()
+	o.stderr.Reset()
+	o.mu.Unlock()
+	if len(output.Stdout) == 0 && len(output.Stderr) == 0 {
+		return nil
+	}
+	return o.st.AppendRunTaskOutput(output)
+}
+
+type outputWriter struct {
+	output *taskOutput
+	stderr bool
+}
+
+// Write implements io.Writer.
+func (w *outputWriter) Write(data []byte) (int, error) {
+	w.output.mu.Lock()
+	defer w.output.mu.Unlock()
+	if w.stderr {
+		return w.output.stderr.Write(data)
+	}
+	return w.output.stdout.Write(data)
+}
+
+// runCommands runs the task's commands with juju-run, in the hook
+// context of the target unit, or without a context if the target is
+// the machine itself. The output is copied to stdout and stderr as
+// the commands run. The commands are killed if they take longer than
+// the task's timeout, or when abort is closed.
+var runCommands = func(task params.RunTask, stdout, stderr io.Writer, abort <-chan struct{}) (*exec.ExecResponse, error) {
+	jujuRun, err := paths.JujuRun(version.Current.Series)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--no-context", task.Commands}
+	if tag, err := names.ParseUnitTag(task.Target); err == nil {
+		args = []string{tag.Id(), task.Commands}
+	}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd := osexec.Command(jujuRun, args...)
+	cmd.Stdout = io.MultiWriter(&stdoutBuf, stdout)
+	cmd.Stderr = io.MultiWriter(&stderrBuf, stderr)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var timeout <-chan time.Time
+	if task.Timeout > 0 {
+		timeout = time.After(task.Timeout)
+	}
+	select {
+	case err = <-done:
+	case <-timeout:
+		cmd.Process.Kill()
+		<-done
+		return nil, errors.Errorf("command timed out after %v", task.Timeout)
+	case <-abort:
+		cmd.Process.Kill()
+		<-done
+		return nil, errors.New("command aborted: agent is stopping")
+	}
+	response := &exec.ExecResponse{
+		Stdout: stdoutBuf.Bytes(),
+		Stderr: stderrBuf.Bytes(),
+	}
+	if exitErr, ok := err.(*osexec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			response.Code = status.ExitStatus()
+			return response, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
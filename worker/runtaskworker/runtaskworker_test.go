@@ -0,0 +1,155 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package runtaskworker_test
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/exec"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/runtaskworker"
+)
+
+type RunTaskWorkerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&RunTaskWorkerSuite{})
+
+func (s *RunTaskWorkerSuite) waitDone(c *gc.C, job *state.RunJob) []*state.RunTask {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		tasks, err := job.Tasks()
+		c.Assert(err, gc.IsNil)
+		done := true
+		for _, task := range tasks {
+			done = done && task.Status().Done()
+		}
+		if done {
+			return tasks
+		}
+		select {
+		case <-timeout:
+			c.Fatalf("timed out waiting for run job %q", job.Id())
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+}
+
+func (s *RunTaskWorkerSuite) TestRunsTasks(c *gc.C) {
+	apiState, machine := s.OpenAPIAsNewMachine(c)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	other, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	s.PatchValue(runtaskworker.OutputFlushInterval, 10*time.Millisecond)
+	var ran []params.RunTask
+	var streamed []state.RunOutput
+	s.PatchValue(runtaskworker.RunCommands, func(task params.RunTask, stdout, stderr io.Writer, abort <-chan struct{}) (*exec.ExecResponse, error) {
+		ran = append(ran, task)
+		if task.Target == machine.Tag().String() {
+			return nil, fmt.Errorf("no juju-run")
+		}
+		fmt.Fprint(stdout, "ok")
+		fmt.Fprint(stderr, "warning")
+		// The output is recorded while the commands run.
+		for a := coretesting.LongAttempt.Start(); a.Next(); {
+			running, err := s.State.RunTask(task.Id)
+			c.Check(err, gc.IsNil)
+			if streamed = running.Output(); len(streamed) == 2 {
+				break
+			}
+		}
+		return &exec.ExecResponse{Code: 2, Stdout: []byte("ok")}, nil
+	})
+
+	job, err := s.State.AddRunJob("hostname", time.Minute, 1, []names.Tag{machine.Tag(), unit.Tag()})
+	c.Assert(err, gc.IsNil)
+	otherJob, err := s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{other.Tag()})
+	c.Assert(err, gc.IsNil)
+
+	w := runtaskworker.NewRunTaskWorker(apiState.RunTasks(), machine.Tag().(names.MachineTag))
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	tasks := s.waitDone(c, job)
+	c.Assert(tasks[0].Status(), gc.Equals, state.RunTaskFailed)
+	c.Assert(tasks[0].Result().Error, gc.Equals, "no juju-run")
+	c.Assert(tasks[1].Status(), gc.Equals, state.RunTaskCompleted)
+	c.Assert(tasks[1].Result(), jc.DeepEquals, state.RunTaskResult{Code: 2, Stdout: []byte("ok")})
+	c.Assert(streamed, jc.DeepEquals, []state.RunOutput{
+		{Data: []byte("ok")},
+		{Stderr: true, Data: []byte("warning")},
+	})
+	c.Assert(ran, jc.DeepEquals, []params.RunTask{{
+		Id:       tasks[0].Id(),
+		Target:   machine.Tag().String(),
+		Commands: "hostname",
+		Timeout:  time.Minute,
+	}, {
+		Id:       tasks[1].Id(),
+		Target:   "unit-wordpress-0",
+		Commands: "hostname",
+		Timeout:  time.Minute,
+	}})
+
+	// The other machine's task is left alone.
+	otherTasks, err := otherJob.Tasks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(otherTasks[0].Status(), gc.Equals, state.RunTaskPending)
+}
+
+func (s *RunTaskWorkerSuite) TestFailsTasksLeftRunning(c *gc.C) {
+	apiState, machine := s.OpenAPIAsNewMachine(c)
+	job, err := s.State.AddRunJob("hostname", time.Minute, 0, []names.Tag{machine.Tag()})
+	c.Assert(err, gc.IsNil)
+	tasks, err := job.Tasks()
+	c.Assert(err, gc.IsNil)
+	err = tasks[0].Start()
+	c.Assert(err, gc.IsNil)
+
+	w := runtaskworker.NewRunTaskWorker(apiState.RunTasks(), machine.Tag().(names.MachineTag))
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	tasks = s.waitDone(c, job)
+	c.Assert(tasks[0].Status(), gc.Equals, state.RunTaskFailed)
+	c.Assert(tasks[0].Result().Error, gc.Equals, "agent restarted while running the commands")
+}
+
+func (s *RunTaskWorkerSuite) TestKillAbortsCommands(c *gc.C) {
+	apiState, machine := s.OpenAPIAsNewMachine(c)
+	started := make(chan struct{})
+	s.PatchValue(runtaskworker.RunCommands, func(task params.RunTask, stdout, stderr io.Writer, abort <-chan struct{}) (*exec.ExecResponse, error) {
+		close(started)
+		<-abort
+		return nil, fmt.Errorf("aborted")
+	})
+	job, err := s.State.AddRunJob("sleep 1000", 0, 0, []names.Tag{machine.Tag()})
+	c.Assert(err, gc.IsNil)
+
+	w := runtaskworker.NewRunTaskWorker(apiState.RunTasks(), machine.Tag().(names.MachineTag))
+	select {
+	case <-started:
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for commands to run")
+	}
+	c.Assert(worker.Stop(w), gc.IsNil)
+
+	tasks := s.waitDone(c, job)
+	c.Assert(tasks[0].Status(), gc.Equals, state.RunTaskFailed)
+	c.Assert(tasks[0].Result().Error, gc.Equals, "aborted")
+}
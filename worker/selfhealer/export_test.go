@@ -0,0 +1,23 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package selfhealer
+
+import (
+	"time"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/state"
+)
+
+var Interval = &interval
+
+type Healer healer
+
+func NewHealer(st *state.State) *Healer {
+	return (*Healer)(newHealer(st))
+}
+
+func (h *Healer) Heal(env environs.Environ, now time.Time) error {
+	return (*healer)(h).heal(env, now)
+}
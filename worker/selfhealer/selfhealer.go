@@ -0,0 +1,240 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package selfhealer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/apiserver/common"
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.selfhealer")
+
+// interval sets how often machines are checked.
+var interval = time.Minute
+
+type selfHealer struct {
+	st   *state.State
+	tomb tomb.Tomb
+}
+
+// NewSelfHealer returns a worker that, when the "self-healing"
+// environment setting is enabled, periodically looks for machines
+// whose instance has vanished, or whose agent has been down for
+// longer than "self-healing-agent-timeout", and reprovisions them:
+// the provisioner starts a replacement instance with the same
+// constraints and placement, and the units assigned to the machine
+// are deployed again there. The instance of a machine whose agent is
+// down is stopped before the machine is reprovisioned. State
+// servers, containers and manually provisioned machines are left
+// alone.
+func NewSelfHealer(st *state.State) worker.Worker {
+	w := &selfHealer{st: st}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *selfHealer) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *selfHealer) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *selfHealer) loop() (err error) {
+	observer, err := worker.NewEnvironObserver(w.st)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		obsErr := worker.Stop(observer)
+		if err == nil {
+			err = obsErr
+		}
+	}()
+	h := newHealer(w.st)
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(interval):
+			if err := h.heal(observer.Environ(), time.Now()); err != nil {
+				logger.Errorf("cannot check machines: %v", err)
+			}
+		}
+	}
+}
+
+// healer reprovisions the machines that need it, remembering between
+// checks which machines have been found in trouble.
+type healer struct {
+	st *state.State
+
+	// missing holds the ids of the machines whose instance was not
+	// found by the last check. A machine is only reprovisioned when
+	// its instance is missing in two consecutive checks, so that a
+	// provider failing to report an instance once does no harm.
+	missing map[string]bool
+
+	// down holds when the agent of each machine was first found down,
+	// for the machines whose agent was down at the last check.
+	down map[string]time.Time
+}
+
+func newHealer(st *state.State) *healer {
+	return &healer{
+		st:      st,
+		missing: make(map[string]bool),
+		down:    make(map[string]time.Time),
+	}
+}
+
+// heal checks the machines of the environment at the given time, and
+// reprovisions those that need it. If the check fails, what was found
+// by the previous check is kept.
+func (h *healer) heal(env environs.Environ, now time.Time) error {
+	cfg, err := h.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.SelfHealing() {
+		h.missing = make(map[string]bool)
+		h.down = make(map[string]time.Time)
+		return nil
+	}
+	if err := common.NewBlockChecker(h.st).ChangeAllowed(); err != nil {
+		logger.Debugf("not checking machines: %v", err)
+		return nil
+	}
+	machines, ids, err := h.candidates()
+	if err != nil {
+		return err
+	}
+	missing := make(map[string]bool)
+	down := make(map[string]time.Time)
+	if len(machines) == 0 {
+		h.missing, h.down = missing, down
+		return nil
+	}
+	insts, err := env.Instances(ids)
+	switch err {
+	case nil, environs.ErrPartialInstances:
+	case environs.ErrNoInstances:
+		insts = make([]instance.Instance, len(ids))
+	default:
+		return err
+	}
+	timeout := cfg.SelfHealingAgentTimeout()
+	for i, m := range machines {
+		var reason string
+		if insts[i] == nil {
+			missing[m.Id()] = true
+			if !h.missing[m.Id()] {
+				logger.Infof("instance %q of machine %v not found", ids[i], m)
+				continue
+			}
+			reason = fmt.Sprintf("instance %q vanished", ids[i])
+		} else {
+			isDown, err := agentDown(m)
+			if err != nil {
+				return err
+			}
+			if !isDown {
+				continue
+			}
+			since, ok := h.down[m.Id()]
+			if !ok {
+				since = now
+			}
+			down[m.Id()] = since
+			if now.Sub(since) < timeout {
+				continue
+			}
+			reason = fmt.Sprintf("agent down for more than %v", timeout)
+		}
+		// The old instance must be gone before the machine is
+		// reprovisioned, so that its agent never runs alongside the
+		// replacement. A machine that cannot be stopped or
+		// reprovisioned is still tracked, so it is tried again at
+		// the next check.
+		if insts[i] != nil {
+			if err := env.StopInstances(ids[i]); err != nil {
+				logger.Errorf("cannot stop instance %q of machine %v: %v", ids[i], m, err)
+				continue
+			}
+		}
+		logger.Infof("reprovisioning machine %v: %s", m, reason)
+		if err := m.Reprovision(reason); err != nil {
+			logger.Errorf("%v", err)
+			continue
+		}
+		delete(missing, m.Id())
+		delete(down, m.Id())
+	}
+	h.missing, h.down = missing, down
+	return nil
+}
+
+// candidates returns the machines that may be reprovisioned, along
+// with their instance ids.
+func (h *healer) candidates() ([]*state.Machine, []instance.Id, error) {
+	all, err := h.st.AllMachines()
+	if err != nil {
+		return nil, nil, err
+	}
+	var machines []*state.Machine
+	var ids []instance.Id
+	for _, m := range all {
+		if m.Life() != state.Alive || m.IsManager() {
+			continue
+		}
+		if _, ok := m.ParentId(); ok {
+			continue
+		}
+		if manual, err := m.IsManual(); err != nil {
+			return nil, nil, err
+		} else if manual {
+			continue
+		}
+		id, err := m.InstanceId()
+		if state.IsNotProvisionedError(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		machines = append(machines, m)
+		ids = append(ids, id)
+	}
+	return machines, ids, nil
+}
+
+// agentDown reports whether the agent of the machine has started but
+// is not running anymore.
+func agentDown(m *state.Machine) (bool, error) {
+	status, _, _, err := m.Status()
+	if err != nil {
+		return false, err
+	}
+	if status != params.StatusStarted {
+		return false, nil
+	}
+	alive, err := m.AgentPresence()
+	if err != nil {
+		return false, err
+	}
+	return !alive, nil
+}
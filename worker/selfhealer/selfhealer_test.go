@@ -0,0 +1,225 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package selfhealer_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/selfhealer"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type SelfHealerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&SelfHealerSuite{})
+
+func (s *SelfHealerSuite) enableSelfHealing(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"self-healing":               true,
+		"self-healing-agent-timeout": 600,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+// addMachine adds a machine provisioned as the given instance.
+func (s *SelfHealerSuite) addMachine(c *gc.C, instId instance.Id) *state.Machine {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned(instId, "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	return machine
+}
+
+func assertProvisioned(c *gc.C, machine *state.Machine, provisioned bool) {
+	err := machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, err = machine.InstanceId()
+	if provisioned {
+		c.Assert(err, gc.IsNil)
+	} else {
+		c.Assert(err, jc.Satisfies, state.IsNotProvisionedError)
+	}
+}
+
+func (s *SelfHealerSuite) TestVanishedInstance(c *gc.C) {
+	s.enableSelfHealing(c)
+	machine := s.addMachine(c, "i-vanished")
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+
+	// The instance must be missing in two consecutive checks.
+	err := h.Heal(s.Environ, now)
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, true)
+	err = h.Heal(s.Environ, now.Add(time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, false)
+
+	status, info, data, err := machine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusError)
+	c.Assert(info, gc.Equals, `instance "i-vanished" vanished`)
+	c.Assert(data, gc.DeepEquals, map[string]interface{}{"transient": true})
+}
+
+func (s *SelfHealerSuite) TestFailedCheckKeepsFindings(c *gc.C) {
+	s.enableSelfHealing(c)
+	machine := s.addMachine(c, "i-vanished")
+	cfg, err := s.Environ.Config().Apply(map[string]interface{}{"broken": "Instances"})
+	c.Assert(err, gc.IsNil)
+	brokenEnv, err := environs.New(cfg)
+	c.Assert(err, gc.IsNil)
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+
+	err = h.Heal(s.Environ, now)
+	c.Assert(err, gc.IsNil)
+	err = h.Heal(brokenEnv, now.Add(time.Minute))
+	c.Assert(err, gc.ErrorMatches, "dummy.Instances is broken")
+	assertProvisioned(c, machine, true)
+
+	// The instance was already found missing before the failed check.
+	err = h.Heal(s.Environ, now.Add(2*time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, false)
+}
+
+func (s *SelfHealerSuite) TestAgentDown(c *gc.C) {
+	s.enableSelfHealing(c)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	inst, hc := testing.AssertStartInstance(c, s.Environ, machine.Id())
+	err = machine.SetProvisioned(inst.Id(), "fake_nonce", hc)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+
+	err = h.Heal(s.Environ, now)
+	c.Assert(err, gc.IsNil)
+	err = h.Heal(s.Environ, now.Add(5*time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, true)
+	err = h.Heal(s.Environ, now.Add(10*time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, false)
+
+	_, info, _, err := machine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, "agent down for more than 10m0s")
+	// The old instance has been stopped.
+	_, err = s.Environ.Instances([]instance.Id{inst.Id()})
+	c.Assert(err, gc.Equals, environs.ErrNoInstances)
+}
+
+func (s *SelfHealerSuite) TestAgentDownStopFails(c *gc.C) {
+	s.enableSelfHealing(c)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	inst, hc := testing.AssertStartInstance(c, s.Environ, machine.Id())
+	err = machine.SetProvisioned(inst.Id(), "fake_nonce", hc)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	cfg, err := s.Environ.Config().Apply(map[string]interface{}{"broken": "StopInstance"})
+	c.Assert(err, gc.IsNil)
+	brokenEnv, err := environs.New(cfg)
+	c.Assert(err, gc.IsNil)
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+
+	// The machine is not reprovisioned while its instance is running.
+	err = h.Heal(brokenEnv, now)
+	c.Assert(err, gc.IsNil)
+	err = h.Heal(brokenEnv, now.Add(10*time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, true)
+
+	// It is tried again at the next check.
+	err = h.Heal(s.Environ, now.Add(11*time.Minute))
+	c.Assert(err, gc.IsNil)
+	assertProvisioned(c, machine, false)
+	_, err = s.Environ.Instances([]instance.Id{inst.Id()})
+	c.Assert(err, gc.Equals, environs.ErrNoInstances)
+}
+
+func (s *SelfHealerSuite) TestDisabled(c *gc.C) {
+	machine := s.addMachine(c, "i-vanished")
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		err := h.Heal(s.Environ, now.Add(time.Duration(i)*time.Minute))
+		c.Assert(err, gc.IsNil)
+	}
+	assertProvisioned(c, machine, true)
+}
+
+func (s *SelfHealerSuite) TestIgnoredMachines(c *gc.C) {
+	s.enableSelfHealing(c)
+	manager, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = manager.SetProvisioned("i-manager", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	manual, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = manual.SetProvisioned("manual:10.0.0.1", "manual:10.0.0.1", nil)
+	c.Assert(err, gc.IsNil)
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		err := h.Heal(s.Environ, now.Add(time.Duration(i)*time.Minute))
+		c.Assert(err, gc.IsNil)
+	}
+	assertProvisioned(c, manager, true)
+	assertProvisioned(c, manual, true)
+}
+
+func (s *SelfHealerSuite) TestBlocked(c *gc.C) {
+	s.enableSelfHealing(c)
+	machine := s.addMachine(c, "i-vanished")
+	err := s.State.SwitchBlockOn("all-changes", "frozen")
+	c.Assert(err, gc.IsNil)
+	h := selfhealer.NewHealer(s.State)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		err := h.Heal(s.Environ, now.Add(time.Duration(i)*time.Minute))
+		c.Assert(err, gc.IsNil)
+	}
+	assertProvisioned(c, machine, true)
+}
+
+func (s *SelfHealerSuite) TestWorker(c *gc.C) {
+	s.PatchValue(selfhealer.Interval, 10*time.Millisecond)
+	s.enableSelfHealing(c)
+	machine := s.addMachine(c, "i-vanished")
+	w := selfhealer.NewSelfHealer(s.State)
+	defer func() {
+		c.Assert(worker.Stop(w), gc.IsNil)
+	}()
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err := machine.Refresh()
+		c.Assert(err, gc.IsNil)
+		if _, err := machine.InstanceId(); state.IsNotProvisionedError(err) {
+			return
+		}
+	}
+	c.Fatalf("machine %v not reprovisioned", machine)
+}
@@ -0,0 +1,47 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storagegcworker
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/storagegc"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.storagegcworker")
+
+// interval sets how often unused storage is collected.
+var interval = 6 * time.Hour
+
+// NewStorageGC returns a worker that periodically removes charm
+// archives, tools tarballs and old resource content that are no longer
+// used in the environment, once they are older than the given grace
+// period.
+func NewStorageGC(st *state.State, gracePeriod time.Duration) worker.Worker {
+	f := func(stop <-chan struct{}) error {
+		stor, err := environs.GetStorage(st)
+		if err != nil {
+			logger.Errorf("cannot access provider storage: %v", err)
+			return nil
+		}
+		report, err := storagegc.Collect(st, storagegc.Params{
+			Storage:     stor,
+			GracePeriod: gracePeriod,
+		})
+		if err != nil {
+			logger.Errorf("cannot collect unused storage: %v", err)
+			return nil
+		}
+		if len(report.Items) > 0 {
+			logger.Infof("removed %d unused items, reclaiming %d bytes", len(report.Items), report.Size())
+		}
+		return nil
+	}
+	return worker.NewPeriodicWorker(f, interval)
+}
@@ -0,0 +1,60 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storagegcworker_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/errors"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/storagegcworker"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type StorageGCSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&StorageGCSuite{})
+
+func (s *StorageGCSuite) TestRemovesUnusedCharms(c *gc.C) {
+	used := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", used)
+	unused := s.AddTestingCharm(c, "mysql")
+
+	w := storagegcworker.NewStorageGC(s.State, 0)
+	defer func() {
+		w.Kill()
+		c.Assert(w.Wait(), gc.IsNil)
+	}()
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		_, err := s.State.Charm(unused.URL())
+		if errors.IsNotFound(err) {
+			break
+		}
+		c.Assert(err, gc.IsNil)
+		if !a.HasNext() {
+			c.Fatalf("unused charm was not removed")
+		}
+	}
+	_, err := s.State.Charm(used.URL())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StorageGCSuite) TestKeepsCharmsInGracePeriod(c *gc.C) {
+	ch := s.AddTestingCharm(c, "mysql")
+	w := storagegcworker.NewStorageGC(s.State, time.Hour)
+	time.Sleep(coretesting.ShortWait)
+	w.Kill()
+	c.Assert(w.Wait(), gc.IsNil)
+	_, err := s.State.Charm(ch.URL())
+	c.Assert(err, gc.IsNil)
+}
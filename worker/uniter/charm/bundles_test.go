@@ -83,7 +83,7 @@ func (s *BundlesDirSuite) AddCharm(c *gc.C) (*uniter.Charm, *state.Charm, []byte
 	bun, err := corecharm.ReadCharmArchive(bunpath)
 	c.Assert(err, gc.IsNil)
 	bundata, hash := readHash(c, bunpath)
-	sch, err := s.State.AddCharm(bun, curl, surl, hash)
+	sch, err := s.State.AddCharm(bun, curl, surl, hash, int64(len(bundata)))
 	c.Assert(err, gc.IsNil)
 	apiCharm, err := s.uniter.Charm(sch.URL())
 	c.Assert(err, gc.IsNil)
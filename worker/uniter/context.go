@@ -5,8 +5,11 @@ package uniter
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -22,6 +25,8 @@ import (
 
 	"github.com/juju/juju/api/uniter"
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/resource"
 	"github.com/juju/juju/version"
 	unitdebug "github.com/juju/juju/worker/uniter/debug"
 	"github.com/juju/juju/worker/uniter/jujuc"
@@ -96,6 +101,13 @@ type HookContext struct {
 	// serviceOwner contains the owner of the service
 	serviceOwner string
 
+	// charmDir is the directory holding the deployed charm.
+	charmDir string
+
+	// resourcesDir is the directory holding the unit's local
+	// copies of the service's resources.
+	resourcesDir string
+
 	// proxySettings are the current proxy settings that the uniter knows about
 	proxySettings proxy.Settings
 }
@@ -110,6 +122,8 @@ func NewHookContext(
 	relations map[int]*ContextRelation,
 	apiAddrs []string,
 	serviceOwner string,
+	charmDir string,
+	resourcesDir string,
 	proxySettings proxy.Settings,
 	actionParams map[string]interface{},
 ) (*HookContext, error) {
@@ -123,6 +137,8 @@ func NewHookContext(
 		relations:      relations,
 		apiAddrs:       apiAddrs,
 		serviceOwner:   serviceOwner,
+		charmDir:       charmDir,
+		resourcesDir:   resourcesDir,
 		proxySettings:  proxySettings,
 		actionParams:   actionParams,
 	}
@@ -151,18 +167,95 @@ func (ctx *HookContext) PrivateAddress() (string, bool) {
 	return ctx.privateAddress, ctx.privateAddress != ""
 }
 
-func (ctx *HookContext) OpenPort(protocol string, port int) error {
-	return ctx.unit.OpenPort(protocol, port)
+func (ctx *HookContext) OpenPorts(protocol string, fromPort, toPort int) error {
+	return ctx.unit.OpenPorts(protocol, fromPort, toPort)
 }
 
-func (ctx *HookContext) ClosePort(protocol string, port int) error {
-	return ctx.unit.ClosePort(protocol, port)
+func (ctx *HookContext) ClosePorts(protocol string, fromPort, toPort int) error {
+	return ctx.unit.ClosePorts(protocol, fromPort, toPort)
+}
+
+func (ctx *HookContext) OpenedPorts() ([]network.PortRange, error) {
+	return ctx.unit.OpenedPorts()
 }
 
 func (ctx *HookContext) OwnerTag() string {
 	return ctx.serviceOwner
 }
 
+// ResourcePath returns the path of the local copy of the current
+// revision of the named resource, downloading it from the state
+// server first if necessary. Copies are kept in
+// <resourcesDir>/<name>/<revision>/<filename>.
+func (ctx *HookContext) ResourcePath(name string) (string, error) {
+	ch, err := charm.ReadCharmDir(ctx.charmDir)
+	if err != nil {
+		return "", err
+	}
+	metas, err := resource.ReadCharmMeta(ch)
+	if err != nil {
+		return "", err
+	}
+	meta, ok := metas[name]
+	if !ok {
+		return "", fmt.Errorf("charm does not declare resource %q", name)
+	}
+	service, err := ctx.unit.Service()
+	if err != nil {
+		return "", err
+	}
+	infos, err := service.Resources()
+	if err != nil {
+		return "", err
+	}
+	var info *params.ResourceInfo
+	for i := range infos {
+		if infos[i].Name == name {
+			info = &infos[i]
+			break
+		}
+	}
+	if info == nil {
+		return "", fmt.Errorf("resource %q has not been attached", name)
+	}
+	dir := filepath.Join(ctx.resourcesDir, name, fmt.Sprint(info.Revision))
+	path := filepath.Join(dir, meta.Filename)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	r, err := service.OpenResource(name)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	// Download to a temporary file in the same directory, and only
+	// rename it into place once the content has been verified, so
+	// a partial download is never mistaken for a complete one.
+	f, err := ioutil.TempFile(dir, ".download")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot download resource %q: %v", name, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != info.SHA256 {
+		return "", fmt.Errorf("cannot download resource %q: sha256 mismatch", name)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
 func (ctx *HookContext) ConfigSettings() (charm.Settings, error) {
 	if ctx.configSettings == nil {
 		var err error
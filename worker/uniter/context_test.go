@@ -753,7 +753,7 @@ func (s *HookContextSuite) getHookContext(c *gc.C, uuid string, relid int,
 	}
 	context, err := uniter.NewHookContext(s.apiUnit, "TestCtx", uuid,
 		"test-env-name", relid, remote, s.relctxs, apiAddrs, "test-owner",
-		proxies, map[string]interface{}(nil))
+		c.MkDir(), c.MkDir(), proxies, map[string]interface{}(nil))
 	c.Assert(err, gc.IsNil)
 	return context
 }
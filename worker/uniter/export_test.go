@@ -17,6 +17,10 @@ func (u *Uniter) GetProxyValues() proxy.Settings {
 	return u.proxy
 }
 
+func (u *Uniter) RecordingHooks() bool {
+	return u.recordingHooks()
+}
+
 var MergeEnvironment = mergeEnvironment
 
 var SearchHook = searchHook
@@ -46,6 +46,8 @@ type filter struct {
 	outResolvedOn  chan params.ResolvedMode
 	outRelations   chan []int
 	outRelationsOn chan []int
+	outResources   chan struct{}
+	outResourcesOn chan struct{}
 
 	// The want* chans are used to indicate that the filter should send
 	// events if it has them available.
@@ -110,6 +112,8 @@ func newFilter(st *uniter.State, unitTag string) (*filter, error) {
 		outResolvedOn:     make(chan params.ResolvedMode),
 		outRelations:      make(chan []int),
 		outRelationsOn:    make(chan []int),
+		outResources:      make(chan struct{}),
+		outResourcesOn:    make(chan struct{}),
 		wantForcedUpgrade: make(chan bool),
 		wantResolved:      make(chan struct{}),
 		discardConfig:     make(chan struct{}),
@@ -178,6 +182,12 @@ func (f *filter) RelationsEvents() <-chan []int {
 	return f.outRelationsOn
 }
 
+// ResourceEvents returns a channel that will receive a signal whenever
+// a new revision of one of the service's resources is attached.
+func (f *filter) ResourceEvents() <-chan struct{} {
+	return f.outResourcesOn
+}
+
 // WantUpgradeEvent controls whether the filter will generate upgrade
 // events for unforced service charm changes.
 func (f *filter) WantUpgradeEvent(mustForce bool) {
@@ -330,6 +340,16 @@ func (f *filter) loop(unitTag string) (err error) {
 			watcher.Stop(relationsw, &f.tomb)
 		}
 	}()
+	resourcesw, err := f.service.WatchResources()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop(resourcesw, &f.tomb)
+	// The initial resources event reflects resources the unit will
+	// fetch as it needs them, so only later changes are interesting.
+	if _, ok := <-resourcesw.Changes(); !ok {
+		return watcher.MustErr(resourcesw)
+	}
 	var addressChanges <-chan struct{}
 	addressesw, err := f.unit.WatchAddresses()
 	if err != nil {
@@ -415,6 +435,12 @@ func (f *filter) loop(unitTag string) (err error) {
 				}
 			}
 			f.relationsChanged(ids)
+		case _, ok = <-resourcesw.Changes():
+			filterLogger.Debugf("got resources change")
+			if !ok {
+				return watcher.MustErr(resourcesw)
+			}
+			f.outResources = f.outResourcesOn
 
 		// Send events on active out chans.
 		case f.outUpgrade <- f.upgrade:
@@ -433,6 +459,9 @@ func (f *filter) loop(unitTag string) (err error) {
 			filterLogger.Debugf("sent relations event")
 			f.outRelations = nil
 			f.relations = nil
+		case f.outResources <- nothing:
+			filterLogger.Debugf("sent resources event")
+			f.outResources = nil
 
 		// Handle explicit requests.
 		case curl := <-f.setCharm:
@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	jc "github.com/juju/testing/checkers"
@@ -591,6 +592,45 @@ func (s *FilterSuite) TestCharmErrorEvents(c *gc.C) {
 	s.assertFilterDies(c, f)
 }
 
+func (s *FilterSuite) TestResourceEvents(c *gc.C) {
+	meta := `
+name: app
+summary: "An application"
+description: "An application with a tarball"
+resources:
+  app:
+    filename: app.tar.gz
+`
+	app := s.AddTestingService(c, "app", s.AddMetaCharm(c, "dummy", meta, 1))
+	unit, err := app.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+	s.APILogin(c, unit)
+
+	f, err := newFilter(s.uniter, unit.Tag().String())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, f)
+	asserter := coretesting.NotifyAsserterC{
+		Precond: func() { s.BackingState.StartSync() },
+		C:       c,
+		Chan:    f.ResourceEvents(),
+	}
+
+	// No event until a resource is attached.
+	asserter.AssertNoReceive()
+
+	// Attach a resource; event received.
+	_, err = app.SetResource("app", strings.NewReader("content"), 7, "sha256-content")
+	c.Assert(err, gc.IsNil)
+	asserter.AssertOneReceive()
+
+	// Attach a new revision; event received.
+	_, err = app.SetResource("app", strings.NewReader("more content"), 12, "sha256-more")
+	c.Assert(err, gc.IsNil)
+	asserter.AssertOneReceive()
+}
+
 func (s *FilterSuite) TestRelationsEvents(c *gc.C) {
 	f, err := newFilter(s.uniter, s.unit.Tag().String())
 	c.Assert(err, gc.IsNil)
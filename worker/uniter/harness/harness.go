@@ -0,0 +1,431 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The harness package runs a charm's hooks outside of an environment,
+// in a scratch directory and against the fake hook context used to
+// replay hooks, so that charms can be tested without deploying them.
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/juju/cmd"
+	"github.com/juju/utils"
+	utilexec "github.com/juju/utils/exec"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/juju/charm.v3/hooks"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/worker/uniter/jujuc"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+// Params holds the parameters for New.
+type Params struct {
+	// CharmDir holds the directory of the charm to test. The charm is
+	// copied into a scratch directory, so that the hooks cannot change
+	// the original.
+	CharmDir string
+
+	// UnitName holds the name of the unit running the hooks. It
+	// defaults to the name of the charm's directory followed by "/0".
+	UnitName string
+
+	// HookTool holds the path of an executable that, when run under
+	// the name of a hook tool, forwards the call to the hook tool
+	// server, as juju and jujud do. It defaults to the juju executable
+	// found in $PATH.
+	HookTool string
+}
+
+// Harness runs the hooks of a charm against a replay.Context, which
+// tests change between hooks to set up the unit's state.
+type Harness struct {
+	ctx        *replay.Context
+	dir        string
+	charmDir   string
+	toolsDir   string
+	socketPath string
+	envUUID    string
+	server     *jujuc.Server
+
+	mu        sync.Mutex
+	contextId string
+	calls     []replay.Call
+	hookCount int
+}
+
+// New returns a Harness that runs the hooks of the charm in
+// p.CharmDir. The Harness must be closed when it is no longer needed.
+func New(p Params) (_ *Harness, err error) {
+	if p.UnitName == "" {
+		p.UnitName = filepath.Base(p.CharmDir) + "/0"
+	}
+	if p.HookTool == "" {
+		if p.HookTool, err = exec.LookPath("juju"); err != nil {
+			return nil, fmt.Errorf("cannot find hook tool executable: %v", err)
+		}
+	}
+	dir, err := ioutil.TempDir("", "juju-harness")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+	envUUID, err := utils.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	h := &Harness{
+		ctx: replay.NewContext(&replay.Recording{
+			UnitName:   p.UnitName,
+			RelationId: -1,
+		}),
+		dir:        dir,
+		charmDir:   filepath.Join(dir, "charm"),
+		toolsDir:   filepath.Join(dir, "tools"),
+		socketPath: filepath.Join(dir, "agent.socket"),
+		envUUID:    envUUID.String(),
+	}
+	if err := copyDir(h.charmDir, p.CharmDir); err != nil {
+		return nil, fmt.Errorf("cannot copy charm: %v", err)
+	}
+	if err := os.Mkdir(h.toolsDir, 0755); err != nil {
+		return nil, err
+	}
+	for _, name := range jujuc.CommandNames() {
+		if err := os.Symlink(p.HookTool, filepath.Join(h.toolsDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	if h.server, err = jujuc.NewServer(h.getCmd, h.socketPath); err != nil {
+		return nil, err
+	}
+	h.server.SetObserver(h.observe)
+	go h.server.Run()
+	return h, nil
+}
+
+// Close stops the hook tool server and removes the scratch directory.
+func (h *Harness) Close() error {
+	h.server.Close()
+	return os.RemoveAll(h.dir)
+}
+
+// Context returns the context the hooks are run against.
+func (h *Harness) Context() *replay.Context {
+	return h.ctx
+}
+
+// CharmDir returns the scratch directory the charm's hooks are run
+// from.
+func (h *Harness) CharmDir() string {
+	return h.charmDir
+}
+
+func (h *Harness) getCmd(contextId, cmdName string) (cmd.Command, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.contextId == "" || contextId != h.contextId {
+		return nil, fmt.Errorf("expected context id %q, got %q", h.contextId, contextId)
+	}
+	return jujuc.NewCommand(h.ctx, cmdName)
+}
+
+func (h *Harness) observe(req jujuc.Request, resp utilexec.ExecResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, replay.Call{
+		Command: req.CommandName,
+		Args:    req.Args,
+		Code:    resp.Code,
+		Stdout:  string(resp.Stdout),
+		Stderr:  string(resp.Stderr),
+	})
+}
+
+// Hook describes a hook to run.
+type Hook struct {
+	Kind hooks.Kind
+
+	// RelationId identifies the relation associated with the hook. It
+	// is only used when Kind indicates a relation hook.
+	RelationId int
+
+	// RemoteUnit is the name of the unit that triggered the hook. It
+	// is only used when Kind indicates a relation hook other than
+	// relation-broken.
+	RemoteUnit string
+
+	// ActionName and ActionParams hold the name and parameters of the
+	// action to run. They are only used when Kind is
+	// hooks.ActionRequested.
+	ActionName   string
+	ActionParams map[string]interface{}
+}
+
+// Result holds the outcome of running a hook.
+type Result struct {
+	// HookName holds the name of the hook that was run.
+	HookName string
+
+	// Missing holds whether the charm does not implement the hook, in
+	// which case it was skipped, as the uniter does.
+	Missing bool
+
+	// Calls holds the hook tool calls made by the hook, in order.
+	Calls []replay.Call
+
+	// Output holds the combined standard output and standard error of
+	// the hook.
+	Output string
+
+	// Err holds the error the hook failed with, if any.
+	Err error
+}
+
+// Run runs the given hook. Changes made to relation settings are only
+// kept if the hook succeeds. If the hook fails, the returned error
+// describes the failure, and the result is returned too.
+func (h *Harness) Run(hi Hook) (*Result, error) {
+	hookName, location := string(hi.Kind), "hooks"
+	relationId, remoteUnit := -1, ""
+	var actionParams map[string]interface{}
+	if hi.Kind.IsRelation() {
+		r, found := h.ctx.Relation(hi.RelationId)
+		if !found {
+			return nil, fmt.Errorf("relation %d not found", hi.RelationId)
+		}
+		if hi.Kind != hooks.RelationBroken && hi.RemoteUnit == "" {
+			return nil, fmt.Errorf("%q hook requires a remote unit", hi.Kind)
+		}
+		hookName = fmt.Sprintf("%s-%s", r.Name(), hi.Kind)
+		relationId, remoteUnit = r.Id(), hi.RemoteUnit
+	} else if hi.Kind == hooks.ActionRequested {
+		if hi.ActionName == "" {
+			return nil, fmt.Errorf("action hook requires an action name")
+		}
+		hookName, location = hi.ActionName, "actions"
+		actionParams = hi.ActionParams
+	}
+	if err := h.ctx.StartHook(relationId, remoteUnit, actionParams); err != nil {
+		return nil, err
+	}
+	result := &Result{HookName: hookName}
+	hook := filepath.Join(h.charmDir, location, hookName)
+	if _, err := os.Stat(hook); os.IsNotExist(err) {
+		h.ctx.FinishHook(true)
+		result.Missing = true
+		return result, nil
+	}
+
+	h.mu.Lock()
+	h.hookCount++
+	h.contextId = fmt.Sprintf("%s:%s:%d", h.ctx.UnitName(), hookName, h.hookCount)
+	h.calls = nil
+	env := h.hookVars()
+	h.mu.Unlock()
+
+	var output bytes.Buffer
+	ps := exec.Command(hook)
+	ps.Env = env
+	ps.Dir = h.charmDir
+	ps.Stdout = &output
+	ps.Stderr = &output
+	result.Err = ps.Run()
+
+	h.mu.Lock()
+	h.contextId = ""
+	result.Calls = h.calls
+	h.mu.Unlock()
+	result.Output = output.String()
+	h.ctx.FinishHook(result.Err == nil)
+	if result.Err != nil {
+		return result, fmt.Errorf("hook %q failed: %v", hookName, result.Err)
+	}
+	return result, nil
+}
+
+// hookVars returns the environment to run a hook with, as the uniter
+// does. It must be called with h.mu held.
+func (h *Harness) hookVars() []string {
+	vars := []string{
+		"CHARM_DIR=" + h.charmDir,
+		"JUJU_CONTEXT_ID=" + h.contextId,
+		"JUJU_AGENT_SOCKET=" + h.socketPath,
+		"JUJU_UNIT_NAME=" + h.ctx.UnitName(),
+		"JUJU_ENV_UUID=" + h.envUUID,
+		"JUJU_ENV_NAME=harness",
+		"APT_LISTCHANGES_FRONTEND=none",
+		"DEBIAN_FRONTEND=noninteractive",
+		"PATH=" + h.toolsDir + string(filepath.ListSeparator) + os.Getenv("PATH"),
+	}
+	if r, found := h.ctx.HookRelation(); found {
+		vars = append(vars, "JUJU_RELATION="+r.Name())
+		vars = append(vars, "JUJU_RELATION_ID="+r.FakeId())
+		name, _ := h.ctx.RemoteUnitName()
+		vars = append(vars, "JUJU_REMOTE_UNIT="+name)
+	}
+	return vars
+}
+
+// Simulate runs the given hooks in order, stopping at the first one
+// that fails. It returns the results of the hooks that were run.
+func (h *Harness) Simulate(his ...Hook) ([]*Result, error) {
+	var results []*Result
+	for _, hi := range his {
+		result, err := h.Run(hi)
+		if result != nil {
+			results = append(results, result)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// Deploy runs the hooks the uniter runs when a unit is deployed:
+// install, config-changed and start.
+func (h *Harness) Deploy() ([]*Result, error) {
+	return h.Simulate(
+		Hook{Kind: hooks.Install},
+		Hook{Kind: hooks.ConfigChanged},
+		Hook{Kind: hooks.Start},
+	)
+}
+
+// SetConfig changes the service configuration, as UpdateConfig does,
+// and runs the config-changed hook.
+func (h *Harness) SetConfig(settings charm.Settings) (*Result, error) {
+	h.ctx.UpdateConfig(settings)
+	return h.Run(Hook{Kind: hooks.ConfigChanged})
+}
+
+// JoinUnit adds the named remote unit, with the given settings, to
+// the relation with the given id, and runs the relation-joined and
+// relation-changed hooks.
+func (h *Harness) JoinUnit(relationId int, unitName string, settings params.RelationSettings) ([]*Result, error) {
+	if err := h.ctx.SetRemoteUnit(relationId, unitName, settings); err != nil {
+		return nil, err
+	}
+	return h.Simulate(
+		Hook{Kind: hooks.RelationJoined, RelationId: relationId, RemoteUnit: unitName},
+		Hook{Kind: hooks.RelationChanged, RelationId: relationId, RemoteUnit: unitName},
+	)
+}
+
+// ChangeUnit changes the settings of the named remote unit in the
+// relation with the given id, and runs the relation-changed hook.
+func (h *Harness) ChangeUnit(relationId int, unitName string, settings params.RelationSettings) (*Result, error) {
+	r, found := h.ctx.Relation(relationId)
+	if !found {
+		return nil, fmt.Errorf("relation %d not found", relationId)
+	}
+	if _, err := r.ReadSettings(unitName); err != nil {
+		return nil, err
+	}
+	if err := h.ctx.SetRemoteUnit(relationId, unitName, settings); err != nil {
+		return nil, err
+	}
+	return h.Run(Hook{Kind: hooks.RelationChanged, RelationId: relationId, RemoteUnit: unitName})
+}
+
+// DepartUnit removes the named remote unit from the relation with the
+// given id, and runs the relation-departed hook.
+func (h *Harness) DepartUnit(relationId int, unitName string) (*Result, error) {
+	if err := h.ctx.RemoveRemoteUnit(relationId, unitName); err != nil {
+		return nil, err
+	}
+	return h.Run(Hook{Kind: hooks.RelationDeparted, RelationId: relationId, RemoteUnit: unitName})
+}
+
+// BreakRelation departs all the remote units of the relation with the
+// given id, runs the relation-broken hook, and removes the relation.
+func (h *Harness) BreakRelation(relationId int) ([]*Result, error) {
+	r, found := h.ctx.Relation(relationId)
+	if !found {
+		return nil, fmt.Errorf("relation %d not found", relationId)
+	}
+	var results []*Result
+	for _, unitName := range r.UnitNames() {
+		result, err := h.DepartUnit(relationId, unitName)
+		if result != nil {
+			results = append(results, result)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+	result, err := h.Run(Hook{Kind: hooks.RelationBroken, RelationId: relationId})
+	if result != nil {
+		results = append(results, result)
+	}
+	if err != nil {
+		return results, err
+	}
+	return results, h.ctx.RemoveRelation(relationId)
+}
+
+// RunAction runs the named action with the given parameters.
+func (h *Harness) RunAction(name string, actionParams map[string]interface{}) (*Result, error) {
+	return h.Run(Hook{Kind: hooks.ActionRequested, ActionName: name, ActionParams: actionParams})
+}
+
+// copyDir copies the contents of the directory src to dst, leaving
+// out version control data.
+func copyDir(dst, src string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch mode := info.Mode(); {
+		case info.IsDir():
+			if info.Name() == ".git" || info.Name() == ".bzr" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, mode.Perm()|0700)
+		case mode&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case mode.IsRegular():
+			return copyFile(target, path, mode.Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(dst, src string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,185 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package harness_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/juju/charm.v3"
+	"gopkg.in/juju/charm.v3/hooks"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/harness"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+type HarnessSuite struct {
+	testing.BaseSuite
+	hookTool string
+	charmDir string
+	h        *harness.Harness
+}
+
+var _ = gc.Suite(&HarnessSuite{})
+
+func (s *HarnessSuite) SetUpSuite(c *gc.C) {
+	s.BaseSuite.SetUpSuite(c)
+	dir := c.MkDir()
+	cmd := exec.Command("go", "build", "github.com/juju/juju/cmd/jujud")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	c.Logf(string(out))
+	c.Assert(err, gc.IsNil)
+	s.hookTool = filepath.Join(dir, "jujud")
+}
+
+var testHooks = map[string]string{
+	"hooks/install":              "echo installing\ntouch installed\n",
+	"hooks/config-changed":       "config-get blog-title\n",
+	"hooks/start":                "open-port 80/tcp\n",
+	"hooks/db-relation-joined":   "relation-set user=wordpress\n",
+	"hooks/db-relation-changed":  "relation-get host\n",
+	"hooks/db-relation-departed": "relation-set departed=$JUJU_REMOTE_UNIT\necho oops >&2\nexit 1\n",
+	"hooks/db-relation-broken":   "relation-ids db\n",
+	"actions/backup":             "action-get target\n",
+}
+
+func (s *HarnessSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.charmDir = filepath.Join(c.MkDir(), "wordpress")
+	for name, content := range testHooks {
+		path := filepath.Join(s.charmDir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		c.Assert(err, gc.IsNil)
+		err = ioutil.WriteFile(path, []byte("#!/bin/bash\n"+content), 0755)
+		c.Assert(err, gc.IsNil)
+	}
+	h, err := harness.New(harness.Params{
+		CharmDir: s.charmDir,
+		HookTool: s.hookTool,
+	})
+	c.Assert(err, gc.IsNil)
+	s.h = h
+	s.AddCleanup(func(c *gc.C) {
+		c.Check(h.Close(), gc.IsNil)
+	})
+}
+
+func (s *HarnessSuite) TestDeploy(c *gc.C) {
+	s.h.Context().UpdateConfig(charm.Settings{"blog-title": "My Title"})
+	results, err := s.h.Deploy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 3)
+
+	c.Check(results[0].HookName, gc.Equals, "install")
+	c.Check(results[0].Output, gc.Equals, "installing\n")
+	c.Check(results[0].Calls, gc.HasLen, 0)
+	_, err = os.Stat(filepath.Join(s.h.CharmDir(), "installed"))
+	c.Check(err, gc.IsNil)
+	_, err = os.Stat(filepath.Join(s.charmDir, "installed"))
+	c.Check(err, jc.Satisfies, os.IsNotExist)
+
+	c.Check(results[1].HookName, gc.Equals, "config-changed")
+	c.Check(results[1].Calls, jc.DeepEquals, []replay.Call{{
+		Command: "config-get",
+		Args:    []string{"blog-title"},
+		Stdout:  "My Title\n",
+	}})
+	c.Check(results[1].Output, gc.Equals, "My Title\n")
+
+	c.Check(results[2].HookName, gc.Equals, "start")
+	ports, err := s.h.Context().OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Check(ports, jc.DeepEquals, []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}})
+}
+
+func (s *HarnessSuite) TestSetConfig(c *gc.C) {
+	result, err := s.h.SetConfig(charm.Settings{"blog-title": "Other Title"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Output, gc.Equals, "Other Title\n")
+}
+
+func (s *HarnessSuite) TestMissingHook(c *gc.C) {
+	result, err := s.h.Run(harness.Hook{Kind: hooks.Stop})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, &harness.Result{HookName: "stop", Missing: true})
+}
+
+func (s *HarnessSuite) TestRelationLifecycle(c *gc.C) {
+	ctx := s.h.Context()
+	relId := ctx.AddRelation("db")
+	results, err := s.h.JoinUnit(relId, "mysql/0", params.RelationSettings{"host": "10.0.0.2"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 2)
+	c.Check(results[0].HookName, gc.Equals, "db-relation-joined")
+	c.Check(results[1].HookName, gc.Equals, "db-relation-changed")
+	c.Check(results[1].Output, gc.Equals, "10.0.0.2\n")
+	settings, err := ctx.LocalSettings(relId)
+	c.Assert(err, gc.IsNil)
+	c.Check(settings, jc.DeepEquals, params.RelationSettings{"user": "wordpress"})
+
+	result, err := s.h.ChangeUnit(relId, "mysql/0", params.RelationSettings{"host": "10.0.0.3"})
+	c.Assert(err, gc.IsNil)
+	c.Check(result.Output, gc.Equals, "10.0.0.3\n")
+	_, err = s.h.ChangeUnit(relId, "mysql/1", nil)
+	c.Check(err, gc.ErrorMatches, `unit "mysql/1" is not in relation 0`)
+
+	// Settings changed by a failed hook are discarded.
+	result, err = s.h.DepartUnit(relId, "mysql/0")
+	c.Assert(err, gc.ErrorMatches, `hook "db-relation-departed" failed: exit status 1`)
+	c.Check(result.Output, gc.Equals, "oops\n")
+	c.Check(result.Calls, jc.DeepEquals, []replay.Call{{
+		Command: "relation-set",
+		Args:    []string{"departed=mysql/0"},
+	}})
+	settings, err = ctx.LocalSettings(relId)
+	c.Assert(err, gc.IsNil)
+	c.Check(settings, jc.DeepEquals, params.RelationSettings{"user": "wordpress"})
+
+	results, err = s.h.BreakRelation(relId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Check(results[0].HookName, gc.Equals, "db-relation-broken")
+	c.Check(results[0].Output, gc.Equals, "db:0\n")
+	c.Check(ctx.RelationIds(), gc.HasLen, 0)
+}
+
+func (s *HarnessSuite) TestRunRelationHookErrors(c *gc.C) {
+	_, err := s.h.Run(harness.Hook{Kind: hooks.RelationJoined, RelationId: 7, RemoteUnit: "mysql/0"})
+	c.Assert(err, gc.ErrorMatches, "relation 7 not found")
+	relId := s.h.Context().AddRelation("db")
+	_, err = s.h.Run(harness.Hook{Kind: hooks.RelationJoined, RelationId: relId})
+	c.Assert(err, gc.ErrorMatches, `"relation-joined" hook requires a remote unit`)
+}
+
+func (s *HarnessSuite) TestRunAction(c *gc.C) {
+	result, err := s.h.RunAction("backup", map[string]interface{}{"target": "/srv"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.HookName, gc.Equals, "backup")
+	c.Assert(result.Output, gc.Equals, "/srv\n")
+}
+
+func (s *HarnessSuite) TestSimulateStopsAtFailure(c *gc.C) {
+	relId := s.h.Context().AddRelation("db")
+	err := s.h.Context().SetRemoteUnit(relId, "mysql/0", nil)
+	c.Assert(err, gc.IsNil)
+	results, err := s.h.Simulate(
+		harness.Hook{Kind: hooks.Install},
+		harness.Hook{Kind: hooks.RelationDeparted, RelationId: relId, RemoteUnit: "mysql/0"},
+		harness.Hook{Kind: hooks.Start},
+	)
+	c.Assert(err, gc.ErrorMatches, `hook "db-relation-departed" failed: exit status 1`)
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[1].Err, gc.ErrorMatches, "exit status 1")
+	ports, err := s.h.Context().OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.HasLen, 0)
+}
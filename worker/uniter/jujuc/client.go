@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/utils/exec"
+
+	"github.com/juju/juju/juju/sockets"
+)
+
+func getenv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", fmt.Errorf("%s not set", name)
+	}
+	return value, nil
+}
+
+func getwd() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// ClientMain uses JUJU_CONTEXT_ID and JUJU_AGENT_SOCKET to ask the
+// Server listening on the socket to execute the named Command with
+// the given arguments on our behalf, and writes the Command's output
+// to stdout and stderr. It returns the Command's exit code.
+func ClientMain(commandName string, args []string, stdout, stderr io.Writer) (code int, err error) {
+	code = 1
+	contextId, err := getenv("JUJU_CONTEXT_ID")
+	if err != nil {
+		return
+	}
+	dir, err := getwd()
+	if err != nil {
+		return
+	}
+	req := Request{
+		ContextId:   contextId,
+		Dir:         dir,
+		CommandName: commandName,
+		Args:        args,
+	}
+	socketPath, err := getenv("JUJU_AGENT_SOCKET")
+	if err != nil {
+		return
+	}
+	client, err := sockets.Dial(socketPath)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	var resp exec.ExecResponse
+	err = client.Call("Jujuc.Main", req, &resp)
+	if err != nil {
+		return
+	}
+	stdout.Write(resp.Stdout)
+	stderr.Write(resp.Stderr)
+	return resp.Code, nil
+}
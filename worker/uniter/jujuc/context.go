@@ -11,6 +11,7 @@ import (
 	"gopkg.in/juju/charm.v3"
 
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
 )
 
 // Context is the interface that all hook helper commands
@@ -26,14 +27,18 @@ type Context interface {
 	// PrivateAddress returns the executing unit's private address.
 	PrivateAddress() (string, bool)
 
-	// OpenPort marks the supplied port for opening when the executing unit's
-	// service is exposed.
-	OpenPort(protocol string, port int) error
+	// OpenPorts marks the supplied port range for opening when the
+	// executing unit's service is exposed. For ICMP, fromPort and
+	// toPort are both -1.
+	OpenPorts(protocol string, fromPort, toPort int) error
 
-	// ClosePort ensures the supplied port is closed even when the executing
-	// unit's service is exposed (unless it is opened separately by a co-
-	// located unit).
-	ClosePort(protocol string, port int) error
+	// ClosePorts ensures the supplied port range is closed even when
+	// the executing unit's service is exposed (unless it is opened
+	// separately by a co-located unit).
+	ClosePorts(protocol string, fromPort, toPort int) error
+
+	// OpenedPorts returns the port ranges opened by the executing unit.
+	OpenedPorts() ([]network.PortRange, error)
 
 	// Config returns the current service configuration of the executing unit.
 	ConfigSettings() (charm.Settings, error)
@@ -59,6 +64,10 @@ type Context interface {
 
 	// OwnerTag returns the owner of the service the executing units belongs to
 	OwnerTag() string
+
+	// ResourcePath returns the local path of the current revision of
+	// the named resource, fetching it from the state server if needed.
+	ResourcePath(name string) (string, error)
 }
 
 // ContextRelation expresses the capabilities of a hook with respect to a relation.
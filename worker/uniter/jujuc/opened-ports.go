@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+)
+
+// OpenedPortsCommand implements the opened-ports command.
+type OpenedPortsCommand struct {
+	cmd.CommandBase
+	ctx Context
+	out cmd.Output
+}
+
+func NewOpenedPortsCommand(ctx Context) cmd.Command {
+	return &OpenedPortsCommand{ctx: ctx}
+}
+
+func (c *OpenedPortsCommand) Info() *cmd.Info {
+	doc := `
+Each port or range is listed in the form accepted by open-port and
+close-port, such as 80/tcp, 8000-8100/tcp or icmp.
+`
+	return &cmd.Info{
+		Name:    "opened-ports",
+		Purpose: "list all ports or ranges opened by the unit",
+		Doc:     doc,
+	}
+}
+
+func (c *OpenedPortsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *OpenedPortsCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *OpenedPortsCommand) Run(ctx *cmd.Context) error {
+	ranges, err := c.ctx.OpenedPorts()
+	if err != nil {
+		return err
+	}
+	result := []string{}
+	for _, portRange := range ranges {
+		result = append(result, portRange.DisplayString())
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+type OpenedPortsSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&OpenedPortsSuite{})
+
+func (s *OpenedPortsSuite) TestOutputFormat(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		out  string
+	}{{
+		nil,
+		"80/tcp\n8000-8100/tcp\n53/udp\nicmp\n",
+	}, {
+		[]string{"--format", "json"},
+		`["80/tcp","8000-8100/tcp","53/udp","icmp"]` + "\n",
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		hctx.openedPorts = []network.PortRange{
+			{80, 80, "tcp"},
+			{8000, 8100, "tcp"},
+			{53, 53, "udp"},
+			network.ICMPPortRange(),
+		}
+		com, err := jujuc.NewCommand(hctx, "opened-ports")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Assert(code, gc.Equals, 0)
+		c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Assert(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *OpenedPortsSuite) TestNoPorts(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "opened-ports")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, nil)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+}
+
+func (s *OpenedPortsSuite) TestBadArgs(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "opened-ports")
+	c.Assert(err, gc.IsNil)
+	err = testing.InitCommand(com, []string{"foo"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["foo"\]`)
+}
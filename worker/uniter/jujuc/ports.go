@@ -13,7 +13,7 @@ import (
 	"launchpad.net/gnuflag"
 )
 
-const portFormat = "<port>[/<protocol>]"
+const portFormat = "<port>[/<protocol>] or <from>-<to>[/<protocol>] or icmp"
 
 // portCommand implements the open-port and close-port commands.
 type portCommand struct {
@@ -21,7 +21,8 @@ type portCommand struct {
 	info       *cmd.Info
 	action     func(*portCommand) error
 	Protocol   string
-	Port       int
+	FromPort   int
+	ToPort     int
 	formatFlag string // deprecated
 }
 
@@ -41,16 +42,31 @@ func (c *portCommand) Init(args []string) error {
 	if args == nil {
 		return errors.New("no port specified")
 	}
+	if strings.ToLower(args[0]) == "icmp" {
+		c.Protocol = "icmp"
+		c.FromPort, c.ToPort = -1, -1
+		return cmd.CheckEmpty(args[1:])
+	}
 	parts := strings.Split(args[0], "/")
 	if len(parts) > 2 {
 		return fmt.Errorf("expected %s; got %q", portFormat, args[0])
 	}
-	port, err := strconv.Atoi(parts[0])
+	ports := strings.Split(parts[0], "-")
+	if len(ports) > 2 {
+		return fmt.Errorf("expected %s; got %q", portFormat, args[0])
+	}
+	fromPort, err := parsePort(ports[0])
 	if err != nil {
-		return badPort(parts[0])
+		return err
 	}
-	if port < 1 || port > 65535 {
-		return badPort(port)
+	toPort := fromPort
+	if len(ports) == 2 {
+		if toPort, err = parsePort(ports[1]); err != nil {
+			return err
+		}
+		if fromPort > toPort {
+			return fmt.Errorf("invalid port range %d-%d", fromPort, toPort)
+		}
 	}
 	protocol := "tcp"
 	if len(parts) == 2 {
@@ -59,11 +75,23 @@ func (c *portCommand) Init(args []string) error {
 			return fmt.Errorf(`protocol must be "tcp" or "udp"; got %q`, protocol)
 		}
 	}
-	c.Port = port
+	c.FromPort = fromPort
+	c.ToPort = toPort
 	c.Protocol = protocol
 	return cmd.CheckEmpty(args[1:])
 }
 
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, badPort(value)
+	}
+	if port < 1 || port > 65535 {
+		return 0, badPort(port)
+	}
+	return port, nil
+}
+
 func (c *portCommand) Run(ctx *cmd.Context) error {
 	if c.formatFlag != "" {
 		fmt.Fprintf(ctx.Stderr, "--format flag deprecated for command %q", c.Info().Name)
@@ -74,15 +102,20 @@ func (c *portCommand) Run(ctx *cmd.Context) error {
 var openPortInfo = &cmd.Info{
 	Name:    "open-port",
 	Args:    portFormat,
-	Purpose: "register a port to open",
-	Doc:     "The port will only be open while the service is exposed.",
+	Purpose: "register a port or range to open",
+	Doc: `
+The port will only be open while the service is exposed. A range of
+ports, such as 8000-8100/tcp, may be opened at once, and must later be
+closed with close-port using the same range. Ports cannot be opened if
+they are already open for another unit on the same machine.
+`,
 }
 
 func NewOpenPortCommand(ctx Context) cmd.Command {
 	return &portCommand{
 		info: openPortInfo,
 		action: func(c *portCommand) error {
-			return ctx.OpenPort(c.Protocol, c.Port)
+			return ctx.OpenPorts(c.Protocol, c.FromPort, c.ToPort)
 		},
 	}
 }
@@ -90,14 +123,14 @@ func NewOpenPortCommand(ctx Context) cmd.Command {
 var closePortInfo = &cmd.Info{
 	Name:    "close-port",
 	Args:    portFormat,
-	Purpose: "ensure a port is always closed",
+	Purpose: "ensure a port or range is always closed",
 }
 
 func NewClosePortCommand(ctx Context) cmd.Command {
 	return &portCommand{
 		info: closePortInfo,
 		action: func(c *portCommand) error {
-			return ctx.ClosePort(c.Protocol, c.Port)
+			return ctx.ClosePorts(c.Protocol, c.FromPort, c.ToPort)
 		},
 	}
 }
@@ -27,6 +27,10 @@ var portsTests = []struct {
 	{[]string{"close-port", "80/TCP"}, set.NewStrings("99/tcp")},
 	{[]string{"open-port", "123/udp"}, set.NewStrings("99/tcp", "123/udp")},
 	{[]string{"close-port", "9999/UDP"}, set.NewStrings("99/tcp", "123/udp")},
+	{[]string{"open-port", "8000-8100/tcp"}, set.NewStrings("99/tcp", "123/udp", "8000-8100/tcp")},
+	{[]string{"open-port", "ICMP"}, set.NewStrings("99/tcp", "123/udp", "8000-8100/tcp", "icmp")},
+	{[]string{"close-port", "8000-8100"}, set.NewStrings("99/tcp", "123/udp", "icmp")},
+	{[]string{"close-port", "icmp"}, set.NewStrings("99/tcp", "123/udp")},
 }
 
 func (s *PortsSuite) TestOpenClose(c *gc.C) {
@@ -52,7 +56,13 @@ var badPortsTests = []struct {
 	{[]string{"65536"}, `port must be in the range \[1, 65535\]; got "65536"`},
 	{[]string{"two"}, `port must be in the range \[1, 65535\]; got "two"`},
 	{[]string{"80/http"}, `protocol must be "tcp" or "udp"; got "http"`},
-	{[]string{"blah/blah/blah"}, `expected <port>\[/<protocol>\]; got "blah/blah/blah"`},
+	{[]string{"blah/blah/blah"}, `expected <port>\[/<protocol>\] or <from>-<to>\[/<protocol>\] or icmp; got "blah/blah/blah"`},
+	{[]string{"1-2-3"}, `expected <port>\[/<protocol>\] or <from>-<to>\[/<protocol>\] or icmp; got "1-2-3"`},
+	{[]string{"80-"}, `port must be in the range \[1, 65535\]; got ""`},
+	{[]string{"80-70000"}, `port must be in the range \[1, 65535\]; got "70000"`},
+	{[]string{"100-80/tcp"}, `invalid port range 100-80`},
+	{[]string{"80-100/icmp"}, `protocol must be "tcp" or "udp"; got "icmp"`},
+	{[]string{"icmp", "haha"}, `unrecognized args: \["haha"\]`},
 	{[]string{"123", "haha"}, `unrecognized args: \["haha"\]`},
 }
 
@@ -74,17 +84,20 @@ func (s *PortsSuite) TestHelp(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	flags := testing.NewFlagSet()
 	c.Assert(string(open.Info().Help(flags)), gc.Equals, `
-usage: open-port <port>[/<protocol>]
-purpose: register a port to open
+usage: open-port <port>[/<protocol>] or <from>-<to>[/<protocol>] or icmp
+purpose: register a port or range to open
 
-The port will only be open while the service is exposed.
+The port will only be open while the service is exposed. A range of
+ports, such as 8000-8100/tcp, may be opened at once, and must later be
+closed with close-port using the same range. Ports cannot be opened if
+they are already open for another unit on the same machine.
 `[1:])
 
 	close, err := jujuc.NewCommand(hctx, "close-port")
 	c.Assert(err, gc.IsNil)
 	c.Assert(string(close.Info().Help(flags)), gc.Equals, `
-usage: close-port <port>[/<protocol>]
-purpose: ensure a port is always closed
+usage: close-port <port>[/<protocol>] or <from>-<to>[/<protocol>] or icmp
+purpose: ensure a port or range is always closed
 `[1:])
 }
 
@@ -0,0 +1,53 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+
+	"github.com/juju/cmd"
+)
+
+// ResourceGetCommand implements the resource-get command.
+type ResourceGetCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	Name string
+}
+
+func NewResourceGetCommand(ctx Context) cmd.Command {
+	return &ResourceGetCommand{ctx: ctx}
+}
+
+func (c *ResourceGetCommand) Info() *cmd.Info {
+	doc := `
+resource-get fetches the current revision of the named resource from the
+state server, if it has not already been fetched, and prints the path of
+the local copy. It fails if no revision of the resource has been attached
+to the service.
+`
+	return &cmd.Info{
+		Name:    "resource-get",
+		Args:    "<name>",
+		Purpose: "print the local path of a resource",
+		Doc:     doc,
+	}
+}
+
+func (c *ResourceGetCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no resource name specified")
+	}
+	c.Name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *ResourceGetCommand) Run(ctx *cmd.Context) error {
+	path, err := c.ctx.ResourcePath(c.Name)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Stdout.Write([]byte(path + "\n"))
+	return err
+}
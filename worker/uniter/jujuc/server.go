@@ -30,11 +30,13 @@ var newCommands = map[string]func(Context) cmd.Command{
 	"config-get" + cmdSuffix:    NewConfigGetCommand,
 	"juju-log" + cmdSuffix:      NewJujuLogCommand,
 	"open-port" + cmdSuffix:     NewOpenPortCommand,
+	"opened-ports" + cmdSuffix:  NewOpenedPortsCommand,
 	"relation-get" + cmdSuffix:  NewRelationGetCommand,
 	"action-get" + cmdSuffix:    NewActionGetCommand,
 	"relation-ids" + cmdSuffix:  NewRelationIdsCommand,
 	"relation-list" + cmdSuffix: NewRelationListCommand,
 	"relation-set" + cmdSuffix:  NewRelationSetCommand,
+	"resource-get" + cmdSuffix:  NewResourceGetCommand,
 	"unit-get" + cmdSuffix:      NewUnitGetCommand,
 	"owner-get" + cmdSuffix:     NewOwnerGetCommand,
 }
@@ -69,10 +71,15 @@ type Request struct {
 // CmdGetter looks up a Command implementation connected to a particular Context.
 type CmdGetter func(contextId, cmdName string) (cmd.Command, error)
 
+// CallObserver is notified of every Command run by a Server, with the
+// Request and the resulting response.
+type CallObserver func(req Request, resp exec.ExecResponse)
+
 // Jujuc implements the jujuc command in the form required by net/rpc.
 type Jujuc struct {
-	mu     sync.Mutex
-	getCmd CmdGetter
+	mu       sync.Mutex
+	getCmd   CmdGetter
+	observer CallObserver
 }
 
 // badReqErrorf returns an error indicating a bad Request.
@@ -107,6 +114,9 @@ func (j *Jujuc) Main(req Request, resp *exec.ExecResponse) error {
 	resp.Code = cmd.Main(c, ctx, req.Args)
 	resp.Stdout = stdout.Bytes()
 	resp.Stderr = stderr.Bytes()
+	if j.observer != nil {
+		j.observer(req, *resp)
+	}
 	return nil
 }
 
@@ -116,6 +126,7 @@ type Server struct {
 	socketPath string
 	listener   net.Listener
 	server     *rpc.Server
+	jujuc      *Jujuc
 	closed     chan bool
 	closing    chan bool
 	wg         sync.WaitGroup
@@ -126,7 +137,8 @@ type Server struct {
 // actually do so until Run is called.
 func NewServer(getCmd CmdGetter, socketPath string) (*Server, error) {
 	server := rpc.NewServer()
-	if err := server.Register(&Jujuc{getCmd: getCmd}); err != nil {
+	jujuc := &Jujuc{getCmd: getCmd}
+	if err := server.Register(jujuc); err != nil {
 		return nil, err
 	}
 	listener, err := sockets.Listen(socketPath)
@@ -137,12 +149,21 @@ func NewServer(getCmd CmdGetter, socketPath string) (*Server, error) {
 		socketPath: socketPath,
 		listener:   listener,
 		server:     server,
+		jujuc:      jujuc,
 		closed:     make(chan bool),
 		closing:    make(chan bool),
 	}
 	return s, nil
 }
 
+// SetObserver arranges for observer to be notified of every Command
+// run by the server from now on.
+func (s *Server) SetObserver(observer CallObserver) {
+	s.jujuc.mu.Lock()
+	defer s.jujuc.mu.Unlock()
+	s.jujuc.observer = observer
+}
+
 // Run accepts new connections until it encounters an error, or until Close is
 // called, and then blocks until all existing connections have been closed.
 func (s *Server) Run() (err error) {
@@ -119,6 +119,31 @@ func (s *ServerSuite) TestHappyPath(c *gc.C) {
 	c.Assert(string(content), gc.Equals, "something")
 }
 
+func (s *ServerSuite) TestObserver(c *gc.C) {
+	var calls []jujuc.Request
+	var resps []exec.ExecResponse
+	s.server.SetObserver(func(req jujuc.Request, resp exec.ExecResponse) {
+		calls = append(calls, req)
+		resps = append(resps, resp)
+	})
+	dir := c.MkDir()
+	req := jujuc.Request{
+		ContextId:   "validCtx",
+		Dir:         dir,
+		CommandName: "remote",
+		Args:        []string{"--value", "something"},
+	}
+	resp, err := s.Call(c, req)
+	c.Assert(err, gc.IsNil)
+	c.Assert(calls, jc.DeepEquals, []jujuc.Request{req})
+	c.Assert(resps, jc.DeepEquals, []exec.ExecResponse{resp})
+
+	// Bad requests are not observed.
+	_, err = s.Call(c, jujuc.Request{ContextId: "whatever", Dir: dir, CommandName: "remote"})
+	c.Assert(err, gc.NotNil)
+	c.Assert(calls, gc.HasLen, 1)
+}
+
 func (s *ServerSuite) TestLocks(c *gc.C) {
 	var wg sync.WaitGroup
 	t0 := time.Now()
@@ -199,6 +224,7 @@ var newCommandTests = []struct {
 	{"relation-ids", ""},
 	{"relation-list", ""},
 	{"relation-set", ""},
+	{"resource-get", ""},
 	{"unit-get", ""},
 	{"random", "unknown command: random"},
 }
@@ -15,6 +15,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/testing"
 	"github.com/juju/juju/worker/uniter/jujuc"
@@ -81,6 +82,7 @@ func setSettings(c *gc.C, ru *state.RelationUnit, settings map[string]interface{
 type Context struct {
 	actionParams map[string]interface{}
 	ports        set.Strings
+	openedPorts  []network.PortRange
 	relid        int
 	remote       string
 	rels         map[int]*ContextRelation
@@ -98,16 +100,20 @@ func (c *Context) PrivateAddress() (string, bool) {
 	return "192.168.0.99", true
 }
 
-func (c *Context) OpenPort(protocol string, port int) error {
-	c.ports.Add(fmt.Sprintf("%d/%s", port, protocol))
+func (c *Context) OpenPorts(protocol string, fromPort, toPort int) error {
+	c.ports.Add(network.PortRange{fromPort, toPort, protocol}.DisplayString())
 	return nil
 }
 
-func (c *Context) ClosePort(protocol string, port int) error {
-	c.ports.Remove(fmt.Sprintf("%d/%s", port, protocol))
+func (c *Context) ClosePorts(protocol string, fromPort, toPort int) error {
+	c.ports.Remove(network.PortRange{fromPort, toPort, protocol}.DisplayString())
 	return nil
 }
 
+func (c *Context) OpenedPorts() ([]network.PortRange, error) {
+	return c.openedPorts, nil
+}
+
 func (c *Context) ConfigSettings() (charm.Settings, error) {
 	return charm.Settings{
 		"empty":               nil,
@@ -147,6 +153,13 @@ func (c *Context) OwnerTag() string {
 	return "test-owner"
 }
 
+func (c *Context) ResourcePath(name string) (string, error) {
+	if name != "app" {
+		return "", fmt.Errorf("resource %q not found", name)
+	}
+	return "/var/lib/juju/agents/unit-u-0/resources/app/1/app.tar.gz", nil
+}
+
 type ContextRelation struct {
 	id    int
 	name  string
@@ -262,12 +262,19 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 			continue
 		case curl := <-u.f.UpgradeEvents():
 			return ModeUpgrading(curl), nil
+		case <-u.f.ResourceEvents():
+			// A new resource revision is treated like a charm upgrade:
+			// run upgrade-charm, and config-changed after it.
+			hi = hook.Info{Kind: hooks.UpgradeCharm}
 		}
 		if err := u.runHook(hi); err == errHookFailed {
 			return ModeHookError, nil
 		} else if err != nil {
 			return nil, err
 		}
+		if hi.Kind == hooks.UpgradeCharm {
+			return ModeContinue, nil
+		}
 	}
 }
 
@@ -0,0 +1,152 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/utils/exec"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/worker/uniter/jujuc"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+// maxHookRecordings holds the number of hook recordings kept by a
+// unit; older recordings are removed.
+var maxHookRecordings = 20
+
+// hookRecorder records the execution context of a hook, and the hook
+// tool calls it makes.
+type hookRecorder struct {
+	mu  sync.Mutex
+	rec *replay.Recording
+}
+
+// observe records a hook tool call.
+func (r *hookRecorder) observe(req jujuc.Request, resp exec.ExecResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Calls = append(r.rec.Calls, replay.Call{
+		Command: req.CommandName,
+		Args:    req.Args,
+		Code:    resp.Code,
+		Stdout:  string(resp.Stdout),
+		Stderr:  string(resp.Stderr),
+	})
+}
+
+// newHookRecorder returns a hookRecorder holding the execution context
+// the hook will see when it is run from location in the charm.
+func (ctx *HookContext) newHookRecorder(hookName, location, charmDir, toolsDir, socketPath string) (*hookRecorder, error) {
+	config, err := ctx.ConfigSettings()
+	if err != nil {
+		return nil, err
+	}
+	ports, err := ctx.OpenedPorts()
+	if err != nil {
+		return nil, err
+	}
+	rec := &replay.Recording{
+		HookName:       hookName,
+		Location:       location,
+		UnitName:       ctx.UnitName(),
+		Time:           time.Now().UTC(),
+		Env:            ctx.hookVars(charmDir, toolsDir, socketPath),
+		PublicAddress:  ctx.publicAddress,
+		PrivateAddress: ctx.privateAddress,
+		OwnerTag:       ctx.serviceOwner,
+		Config:         config,
+		ActionParams:   ctx.actionParams,
+		OpenedPorts:    ports,
+		RelationId:     ctx.relationId,
+		RemoteUnitName: ctx.remoteUnitName,
+	}
+	ids := ctx.RelationIds()
+	sort.Ints(ids)
+	for _, id := range ids {
+		rctx := ctx.relations[id]
+		settings, err := rctx.Settings()
+		if err != nil {
+			return nil, err
+		}
+		relation := replay.Relation{
+			Id:       id,
+			Name:     rctx.Name(),
+			Settings: settings.Map(),
+			Members:  make(map[string]params.RelationSettings),
+		}
+		for _, unit := range rctx.UnitNames() {
+			if relation.Members[unit], err = rctx.ReadSettings(unit); err != nil {
+				return nil, err
+			}
+		}
+		rec.Relations = append(rec.Relations, relation)
+	}
+	return &hookRecorder{rec: rec}, nil
+}
+
+// recordingHooks reports whether hooks should be recorded.
+func (u *Uniter) recordingHooks() bool {
+	u.recordHooksMutex.Lock()
+	defer u.recordHooksMutex.Unlock()
+	return u.recordHooks
+}
+
+// saveHookRecording writes the recording held by recorder, together
+// with the charm, to an archive in the unit's recordings directory.
+// Only the most recent maxHookRecordings archives are kept.
+func (u *Uniter) saveHookRecording(recorder *hookRecorder, hookErr error) error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	rec := recorder.rec
+	if hookErr != nil {
+		rec.Error = hookErr.Error()
+	}
+	dir := filepath.Join(u.baseDir, "recordings")
+	// Recordings hold hook settings and environment, which may be
+	// secret, so only the agent may read them.
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.tar.gz", rec.Time.Format("20060102-150405"), rec.HookName)
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = replay.WriteArchive(f, rec, u.charmPath)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	logger.Infof("recorded %q hook in %s", rec.HookName, f.Name())
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var archives []string
+	for _, info := range infos {
+		if strings.HasSuffix(info.Name(), ".tar.gz") {
+			archives = append(archives, info.Name())
+		}
+	}
+	// ReadDir sorts by name, and names start with the time.
+	for len(archives) > maxHookRecordings {
+		if err := os.Remove(filepath.Join(dir, archives[0])); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package replay
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/juju/charm.v3"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+// Context is an implementation of jujuc.Context that serves the
+// execution context held in a Recording. Changes made by hook tools,
+// such as relation settings and opened ports, are kept in memory.
+// The context can also be changed between hooks with its methods, so
+// that it can be used to run a sequence of hooks; it must not be
+// changed while a hook is running.
+type Context struct {
+	rec            Recording
+	ports          []network.PortRange
+	relations      map[int]*ContextRelation
+	nextRelationId int
+}
+
+var _ jujuc.Context = (*Context)(nil)
+
+// NewContext returns a Context serving the given recording. The
+// recording itself is not changed by the context.
+func NewContext(rec *Recording) *Context {
+	ctx := &Context{
+		rec:       *rec,
+		ports:     append([]network.PortRange(nil), rec.OpenedPorts...),
+		relations: make(map[int]*ContextRelation),
+	}
+	for _, r := range rec.Relations {
+		members := make(map[string]params.RelationSettings)
+		for unit, settings := range r.Members {
+			members[unit] = copySettings(settings)
+		}
+		ctx.relations[r.Id] = &ContextRelation{
+			id:       r.Id,
+			name:     r.Name,
+			settings: copySettings(r.Settings),
+			members:  members,
+		}
+		if r.Id >= ctx.nextRelationId {
+			ctx.nextRelationId = r.Id + 1
+		}
+	}
+	return ctx
+}
+
+// SetAddresses sets the addresses of the unit.
+func (ctx *Context) SetAddresses(public, private string) {
+	ctx.rec.PublicAddress = public
+	ctx.rec.PrivateAddress = private
+}
+
+// SetOwnerTag sets the tag of the owner of the unit's service.
+func (ctx *Context) SetOwnerTag(tag string) {
+	ctx.rec.OwnerTag = tag
+}
+
+// UpdateConfig sets the given service configuration values. Values
+// that are nil are unset.
+func (ctx *Context) UpdateConfig(settings charm.Settings) {
+	config := charm.Settings{}
+	for name, value := range ctx.rec.Config {
+		config[name] = value
+	}
+	for name, value := range settings {
+		if value == nil {
+			delete(config, name)
+		} else {
+			config[name] = value
+		}
+	}
+	ctx.rec.Config = config
+}
+
+// AddRelation adds a relation with the given name, with no remote
+// units, and returns its id.
+func (ctx *Context) AddRelation(name string) int {
+	id := ctx.nextRelationId
+	ctx.nextRelationId++
+	ctx.relations[id] = &ContextRelation{
+		id:       id,
+		name:     name,
+		settings: make(params.RelationSettings),
+		members:  make(map[string]params.RelationSettings),
+	}
+	return id
+}
+
+// RemoveRelation removes the relation with the given id.
+func (ctx *Context) RemoveRelation(id int) error {
+	if _, err := ctx.relation(id); err != nil {
+		return err
+	}
+	delete(ctx.relations, id)
+	return nil
+}
+
+// RelationId returns the id of the first relation with the given
+// name.
+func (ctx *Context) RelationId(name string) (int, error) {
+	for _, id := range ctx.RelationIds() {
+		if ctx.relations[id].name == name {
+			return id, nil
+		}
+	}
+	return -1, fmt.Errorf("relation %q not found", name)
+}
+
+// SetRemoteUnit adds the named remote unit to the relation with the
+// given id, or changes its settings if it is already a member.
+func (ctx *Context) SetRemoteUnit(relationId int, unitName string, settings params.RelationSettings) error {
+	r, err := ctx.relation(relationId)
+	if err != nil {
+		return err
+	}
+	r.members[unitName] = copySettings(settings)
+	return nil
+}
+
+// RemoveRemoteUnit removes the named remote unit from the relation
+// with the given id.
+func (ctx *Context) RemoveRemoteUnit(relationId int, unitName string) error {
+	r, err := ctx.relation(relationId)
+	if err != nil {
+		return err
+	}
+	if _, found := r.members[unitName]; !found {
+		return fmt.Errorf("unit %q is not in relation %d", unitName, relationId)
+	}
+	delete(r.members, unitName)
+	return nil
+}
+
+// LocalSettings returns the unit's own settings in the relation with
+// the given id, as written by the hooks that have finished
+// successfully.
+func (ctx *Context) LocalSettings(relationId int) (params.RelationSettings, error) {
+	r, err := ctx.relation(relationId)
+	if err != nil {
+		return nil, err
+	}
+	return copySettings(r.settings), nil
+}
+
+// StartHook sets up the context for running a hook for the relation
+// with the given id, or -1 for a hook that is not a relation hook,
+// and the named remote unit, if any. The action parameters are only
+// used by action hooks.
+func (ctx *Context) StartHook(relationId int, remoteUnitName string, actionParams map[string]interface{}) error {
+	if relationId != -1 {
+		if _, err := ctx.relation(relationId); err != nil {
+			return err
+		}
+	}
+	ctx.rec.RelationId = relationId
+	ctx.rec.RemoteUnitName = remoteUnitName
+	ctx.rec.ActionParams = actionParams
+	return nil
+}
+
+// FinishHook ends the hook being run. As in the uniter, changes made
+// to relation settings are only kept if the hook succeeded.
+func (ctx *Context) FinishHook(succeeded bool) {
+	for _, r := range ctx.relations {
+		if succeeded && r.pending != nil {
+			r.settings = params.RelationSettings(r.pending)
+		}
+		r.pending = nil
+	}
+	ctx.rec.RelationId = -1
+	ctx.rec.RemoteUnitName = ""
+	ctx.rec.ActionParams = nil
+}
+
+func (ctx *Context) relation(id int) (*ContextRelation, error) {
+	r, found := ctx.relations[id]
+	if !found {
+		return nil, fmt.Errorf("relation %d not found", id)
+	}
+	return r, nil
+}
+
+func (ctx *Context) UnitName() string {
+	return ctx.rec.UnitName
+}
+
+func (ctx *Context) PublicAddress() (string, bool) {
+	return ctx.rec.PublicAddress, ctx.rec.PublicAddress != ""
+}
+
+func (ctx *Context) PrivateAddress() (string, bool) {
+	return ctx.rec.PrivateAddress, ctx.rec.PrivateAddress != ""
+}
+
+func (ctx *Context) OpenPorts(protocol string, fromPort, toPort int) error {
+	ports := network.PortRange{FromPort: fromPort, ToPort: toPort, Protocol: protocol}
+	for _, p := range ctx.ports {
+		if p == ports {
+			return nil
+		}
+	}
+	ctx.ports = append(ctx.ports, ports)
+	return nil
+}
+
+func (ctx *Context) ClosePorts(protocol string, fromPort, toPort int) error {
+	ports := network.PortRange{FromPort: fromPort, ToPort: toPort, Protocol: protocol}
+	for i, p := range ctx.ports {
+		if p == ports {
+			ctx.ports = append(ctx.ports[:i], ctx.ports[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (ctx *Context) OpenedPorts() ([]network.PortRange, error) {
+	return append([]network.PortRange(nil), ctx.ports...), nil
+}
+
+func (ctx *Context) ConfigSettings() (charm.Settings, error) {
+	result := charm.Settings{}
+	for name, value := range ctx.rec.Config {
+		result[name] = value
+	}
+	return result, nil
+}
+
+func (ctx *Context) ActionParams() map[string]interface{} {
+	return ctx.rec.ActionParams
+}
+
+func (ctx *Context) HookRelation() (jujuc.ContextRelation, bool) {
+	return ctx.Relation(ctx.rec.RelationId)
+}
+
+func (ctx *Context) RemoteUnitName() (string, bool) {
+	return ctx.rec.RemoteUnitName, ctx.rec.RemoteUnitName != ""
+}
+
+func (ctx *Context) Relation(id int) (jujuc.ContextRelation, bool) {
+	r, found := ctx.relations[id]
+	if !found {
+		return nil, false
+	}
+	return r, true
+}
+
+func (ctx *Context) RelationIds() []int {
+	ids := []int{}
+	for id := range ctx.relations {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (ctx *Context) OwnerTag() string {
+	return ctx.rec.OwnerTag
+}
+
+func (ctx *Context) ResourcePath(name string) (string, error) {
+	return "", fmt.Errorf("resource %q is not available outside an environment", name)
+}
+
+// ContextRelation is the implementation of jujuc.ContextRelation
+// used by Context.
+type ContextRelation struct {
+	id       int
+	name     string
+	settings params.RelationSettings
+	members  map[string]params.RelationSettings
+
+	// pending holds the settings being changed by the running hook,
+	// if it has read them.
+	pending settings
+}
+
+func (r *ContextRelation) Id() int {
+	return r.id
+}
+
+func (r *ContextRelation) Name() string {
+	return r.name
+}
+
+func (r *ContextRelation) FakeId() string {
+	return fmt.Sprintf("%s:%d", r.name, r.id)
+}
+
+func (r *ContextRelation) Settings() (jujuc.Settings, error) {
+	if r.pending == nil {
+		r.pending = settings(copySettings(r.settings))
+	}
+	return r.pending, nil
+}
+
+func (r *ContextRelation) UnitNames() (units []string) {
+	for unit := range r.members {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	return units
+}
+
+func (r *ContextRelation) ReadSettings(unit string) (params.RelationSettings, error) {
+	s, found := r.members[unit]
+	if !found {
+		return nil, fmt.Errorf("unit %q is not in relation %d", unit, r.id)
+	}
+	return copySettings(s), nil
+}
+
+// settings implements jujuc.Settings in memory.
+type settings params.RelationSettings
+
+func (s settings) Map() params.RelationSettings {
+	return copySettings(params.RelationSettings(s))
+}
+
+func (s settings) Set(key, value string) {
+	s[key] = value
+}
+
+func (s settings) Delete(key string) {
+	delete(s, key)
+}
+
+func copySettings(s params.RelationSettings) params.RelationSettings {
+	result := make(params.RelationSettings)
+	for k, v := range s {
+		result[k] = v
+	}
+	return result
+}
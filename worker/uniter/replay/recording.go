@@ -0,0 +1,231 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The replay package records the execution context of hooks run by the
+// uniter, so that failing hooks can be replayed outside the
+// environment against a fake hook tool server.
+package replay
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/juju/charm.v3"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+)
+
+// Recording holds everything a hook could observe about its execution
+// context, as it was when the hook was run.
+type Recording struct {
+	// HookName holds the name of the hook that was run.
+	HookName string
+
+	// Location holds the directory within the charm that holds the
+	// hook, either "hooks" or "actions".
+	Location string
+
+	// UnitName holds the name of the unit that ran the hook.
+	UnitName string
+
+	// Time holds when the hook was run.
+	Time time.Time
+
+	// Env holds the environment variables the hook was run with.
+	Env []string
+
+	PublicAddress  string
+	PrivateAddress string
+	OwnerTag       string
+	Config         charm.Settings
+	ActionParams   map[string]interface{}
+	OpenedPorts    []network.PortRange
+
+	// RelationId holds the id of the relation the hook was run for,
+	// or -1 if it was not a relation hook.
+	RelationId int
+
+	// RemoteUnitName holds the name of the remote unit the hook was
+	// run for, if any.
+	RemoteUnitName string
+
+	// Relations holds the relations the unit was participating in.
+	Relations []Relation
+
+	// Calls holds the hook tool calls made by the hook, in order.
+	Calls []Call
+
+	// Error holds the error the hook failed with, if any.
+	Error string
+}
+
+// Relation holds the state of a relation as seen by a hook.
+type Relation struct {
+	Id   int
+	Name string
+
+	// Settings holds the unit's own settings in the relation.
+	Settings params.RelationSettings
+
+	// Members holds the settings of the remote units in the relation.
+	Members map[string]params.RelationSettings
+}
+
+// Call holds a hook tool call made by a hook, and its outcome.
+type Call struct {
+	Command string
+	Args    []string
+	Code    int
+	Stdout  string
+	Stderr  string
+}
+
+const (
+	recordingFile = "recording.json"
+	charmPrefix   = "charm/"
+)
+
+// WriteArchive writes a gzipped tar archive holding the recording and
+// the contents of charmDir to w.
+func WriteArchive(w io.Writer, rec *Recording, charmDir string) (err error) {
+	gzw := gzip.NewWriter(w)
+	defer closeErrorCheck(&err, gzw)
+	tarw := tar.NewWriter(gzw)
+	defer closeErrorCheck(&err, tarw)
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = tarw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     recordingFile,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  rec.Time,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tarw.Write(data); err != nil {
+		return err
+	}
+	return filepath.Walk(charmDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(charmDir, file)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if rel == "." || !(info.IsDir() || info.Mode().IsRegular()) {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = charmPrefix + filepath.ToSlash(rel)
+		if err := tarw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tarw, f)
+		return err
+	})
+}
+
+// ReadArchive reads an archive written by WriteArchive from r,
+// extracting the charm into charmDir, and returns the recording.
+func ReadArchive(r io.Reader, charmDir string) (*Recording, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read hook recording: %v", err)
+	}
+	defer gzr.Close()
+	tarr := tar.NewReader(gzr)
+	var rec *Recording
+	for {
+		header, err := tarr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read hook recording: %v", err)
+		}
+		if header.Name == recordingFile {
+			data, err := ioutil.ReadAll(tarr)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read hook recording: %v", err)
+			}
+			rec = new(Recording)
+			if err := json.Unmarshal(data, rec); err != nil {
+				return nil, fmt.Errorf("cannot read hook recording: %v", err)
+			}
+			continue
+		}
+		if err := extract(tarr, header, charmDir); err != nil {
+			return nil, fmt.Errorf("cannot extract charm: %v", err)
+		}
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("cannot read hook recording: %s not found", recordingFile)
+	}
+	return rec, nil
+}
+
+// extract writes the tar entry described by header to its place
+// within charmDir.
+func extract(r io.Reader, header *tar.Header, charmDir string) error {
+	name := path.Clean(header.Name)
+	if !strings.HasPrefix(name, charmPrefix) {
+		return fmt.Errorf("unexpected file %q", header.Name)
+	}
+	target := filepath.Join(charmDir, filepath.FromSlash(strings.TrimPrefix(name, charmPrefix)))
+	if !strings.HasPrefix(target, filepath.Clean(charmDir)+string(filepath.Separator)) {
+		return fmt.Errorf("unexpected file %q", header.Name)
+	}
+	mode := os.FileMode(header.Mode).Perm()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, mode|0700)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, r)
+		return err
+	}
+	return fmt.Errorf("unexpected file %q", header.Name)
+}
+
+func closeErrorCheck(errp *error, c io.Closer) {
+	if err := c.Close(); err != nil && *errp == nil {
+		*errp = err
+	}
+}
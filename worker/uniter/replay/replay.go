@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package replay
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+// Params holds the parameters for Replay.
+type Params struct {
+	// Recording holds the recording of the hook to replay.
+	Recording *Recording
+
+	// CharmDir holds the directory of the charm to run the hook from.
+	CharmDir string
+
+	// ToolsDir holds a directory containing the hook tools, as
+	// executables that call jujuc.ClientMain.
+	ToolsDir string
+
+	// SocketPath holds the path of the socket the hook tool server
+	// listens on.
+	SocketPath string
+
+	// Stdout and Stderr receive the output of the hook.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// contextId identifies the context served to replayed hooks.
+const contextId = "replay"
+
+// replacedVars holds the recorded environment variables that refer to
+// the machine the hook was recorded on, and are replaced when the hook
+// is replayed.
+var replacedVars = []string{
+	"CHARM_DIR",
+	"JUJU_CONTEXT_ID",
+	"JUJU_AGENT_SOCKET",
+	"PATH",
+	"Path",
+	"PSModulePath",
+}
+
+// Replay runs the recorded hook from the charm in p.CharmDir, with the
+// recorded environment, while a hook tool server serves the recorded
+// context. It returns the context, so that the changes made by the
+// hook can be inspected, and the error the hook failed with, if any.
+func Replay(p Params) (*Context, error) {
+	rec := p.Recording
+	hook := filepath.Join(p.CharmDir, rec.Location, rec.HookName)
+	if _, err := os.Stat(hook); err != nil {
+		return nil, fmt.Errorf("cannot find hook: %v", err)
+	}
+	ctx := NewContext(rec)
+	getCmd := func(ctxId, cmdName string) (cmd.Command, error) {
+		if ctxId != contextId {
+			return nil, fmt.Errorf("expected context id %q, got %q", contextId, ctxId)
+		}
+		return jujuc.NewCommand(ctx, cmdName)
+	}
+	srv, err := jujuc.NewServer(getCmd, p.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	go srv.Run()
+	defer srv.Close()
+
+	ps := exec.Command(hook)
+	ps.Env = replayEnv(rec.Env, p)
+	ps.Dir = p.CharmDir
+	ps.Stdout = p.Stdout
+	ps.Stderr = p.Stderr
+	return ctx, ps.Run()
+}
+
+// replayEnv returns the environment to replay a hook with, based on
+// the recorded environment env.
+func replayEnv(env []string, p Params) []string {
+	var result []string
+	for _, v := range env {
+		replaced := false
+		for _, name := range replacedVars {
+			if strings.HasPrefix(v, name+"=") {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, v)
+		}
+	}
+	return append(result,
+		"CHARM_DIR="+p.CharmDir,
+		"JUJU_CONTEXT_ID="+contextId,
+		"JUJU_AGENT_SOCKET="+p.SocketPath,
+		"PATH="+p.ToolsDir+string(filepath.ListSeparator)+os.Getenv("PATH"),
+	)
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package replay_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd"
+	jc "github.com/juju/testing/checkers"
+	"gopkg.in/juju/charm.v3"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/apiserver/params"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/jujuc"
+	"github.com/juju/juju/worker/uniter/replay"
+)
+
+type ReplaySuite struct {
+	testing.BaseSuite
+	rec      *replay.Recording
+	charmDir string
+}
+
+var _ = gc.Suite(&ReplaySuite{})
+
+func (s *ReplaySuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.rec = &replay.Recording{
+		HookName: "db-relation-changed",
+		Location: "hooks",
+		UnitName: "wordpress/0",
+		Time:     time.Date(2014, 10, 1, 12, 0, 0, 0, time.UTC),
+		Env: []string{
+			"CHARM_DIR=/var/lib/juju/agents/unit-wordpress-0/charm",
+			"JUJU_CONTEXT_ID=wordpress/0:db-relation-changed:42",
+			"JUJU_AGENT_SOCKET=@/var/lib/juju/agents/unit-wordpress-0/agent.socket",
+			"JUJU_UNIT_NAME=wordpress/0",
+			"JUJU_REMOTE_UNIT=mysql/0",
+			"PATH=/var/lib/juju/tools/unit-wordpress-0:/usr/bin",
+		},
+		PrivateAddress: "10.0.0.1",
+		Config:         charm.Settings{"blog-title": "My Title"},
+		OpenedPorts:    []network.PortRange{{FromPort: 80, ToPort: 80, Protocol: "tcp"}},
+		RelationId:     1,
+		RemoteUnitName: "mysql/0",
+		Relations: []replay.Relation{{
+			Id:       1,
+			Name:     "db",
+			Settings: params.RelationSettings{"private-address": "10.0.0.1"},
+			Members: map[string]params.RelationSettings{
+				"mysql/0": {"user": "admin"},
+			},
+		}},
+		Calls: []replay.Call{{
+			Command: "relation-get",
+			Args:    []string{"user"},
+			Stdout:  "admin\n",
+		}},
+		Error: "exit status 1",
+	}
+	s.charmDir = c.MkDir()
+	err := os.MkdirAll(filepath.Join(s.charmDir, "hooks"), 0755)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(s.charmDir, "metadata.yaml"), []byte("name: wordpress\n"), 0644)
+	c.Assert(err, gc.IsNil)
+	s.writeHook(c, "#!/bin/sh\necho $JUJU_UNIT_NAME $JUJU_REMOTE_UNIT $JUJU_CONTEXT_ID\necho oops >&2\nexit 3\n")
+}
+
+func (s *ReplaySuite) writeHook(c *gc.C, content string) {
+	path := filepath.Join(s.charmDir, "hooks", s.rec.HookName)
+	err := ioutil.WriteFile(path, []byte(content), 0755)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ReplaySuite) TestArchive(c *gc.C) {
+	var buf bytes.Buffer
+	err := replay.WriteArchive(&buf, s.rec, s.charmDir)
+	c.Assert(err, gc.IsNil)
+
+	charmDir := c.MkDir()
+	rec, err := replay.ReadArchive(&buf, charmDir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(rec, jc.DeepEquals, s.rec)
+
+	data, err := ioutil.ReadFile(filepath.Join(charmDir, "metadata.yaml"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "name: wordpress\n")
+	info, err := os.Stat(filepath.Join(charmDir, "hooks", s.rec.HookName))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0755))
+}
+
+func (s *ReplaySuite) TestReadArchiveInvalid(c *gc.C) {
+	_, err := replay.ReadArchive(bytes.NewBufferString("junk"), c.MkDir())
+	c.Assert(err, gc.ErrorMatches, "cannot read hook recording: .*")
+}
+
+func (s *ReplaySuite) runCommand(c *gc.C, ctx jujuc.Context, name string, args ...string) string {
+	com, err := jujuc.NewCommand(ctx, name)
+	c.Assert(err, gc.IsNil)
+	cmdCtx := testing.Context(c)
+	code := cmd.Main(com, cmdCtx, args)
+	c.Assert(code, gc.Equals, 0, gc.Commentf("stderr: %s", testing.Stderr(cmdCtx)))
+	return testing.Stdout(cmdCtx)
+}
+
+func (s *ReplaySuite) TestContext(c *gc.C) {
+	ctx := replay.NewContext(s.rec)
+	c.Assert(s.runCommand(c, ctx, "config-get", "blog-title"), gc.Equals, "My Title\n")
+	c.Assert(s.runCommand(c, ctx, "unit-get", "private-address"), gc.Equals, "10.0.0.1\n")
+	c.Assert(s.runCommand(c, ctx, "relation-list"), gc.Equals, "mysql/0\n")
+	c.Assert(s.runCommand(c, ctx, "relation-get", "user", "mysql/0"), gc.Equals, "admin\n")
+
+	// Changes are kept in the context, but not in the recording.
+	s.runCommand(c, ctx, "relation-set", "foo=bar")
+	c.Assert(s.runCommand(c, ctx, "relation-get", "foo", "wordpress/0"), gc.Equals, "bar\n")
+	c.Assert(s.rec.Relations[0].Settings, jc.DeepEquals, params.RelationSettings{"private-address": "10.0.0.1"})
+	s.runCommand(c, ctx, "open-port", "8080")
+	s.runCommand(c, ctx, "close-port", "80")
+	ports, err := ctx.OpenedPorts()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, jc.DeepEquals, []network.PortRange{{FromPort: 8080, ToPort: 8080, Protocol: "tcp"}})
+
+	r, found := ctx.Relation(1)
+	c.Assert(found, jc.IsTrue)
+	_, err = r.ReadSettings("mysql/1")
+	c.Assert(err, gc.ErrorMatches, `unit "mysql/1" is not in relation 1`)
+	_, err = ctx.ResourcePath("data")
+	c.Assert(err, gc.ErrorMatches, `resource "data" is not available outside an environment`)
+}
+
+func (s *ReplaySuite) replay(c *gc.C) (*replay.Context, string, string, error) {
+	var stdout, stderr bytes.Buffer
+	ctx, err := replay.Replay(replay.Params{
+		Recording:  s.rec,
+		CharmDir:   s.charmDir,
+		ToolsDir:   c.MkDir(),
+		SocketPath: filepath.Join(c.MkDir(), "agent.socket"),
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+	})
+	return ctx, stdout.String(), stderr.String(), err
+}
+
+func (s *ReplaySuite) TestReplay(c *gc.C) {
+	ctx, stdout, stderr, err := s.replay(c)
+	c.Assert(err, gc.ErrorMatches, "exit status 3")
+	c.Assert(ctx, gc.NotNil)
+	c.Assert(stdout, gc.Equals, "wordpress/0 mysql/0 replay\n")
+	c.Assert(stderr, gc.Equals, "oops\n")
+}
+
+func (s *ReplaySuite) TestReplayMissingHook(c *gc.C) {
+	s.rec.HookName = "install"
+	_, _, _, err := s.replay(c)
+	c.Assert(err, gc.ErrorMatches, "cannot find hook: .*")
+}
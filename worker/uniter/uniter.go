@@ -85,6 +85,12 @@ type Uniter struct {
 	proxy      proxyutils.Settings
 	proxyMutex sync.Mutex
 
+	// recordHooks holds whether the execution context of hooks
+	// should be recorded for replaying. It is updated from the
+	// environment configuration.
+	recordHooks      bool
+	recordHooksMutex sync.Mutex
+
 	ranConfigChanged bool
 	// The execution observer is only used in tests at this stage. Should this
 	// need to be extended, perhaps a list of observers would be needed.
@@ -375,8 +381,8 @@ func (u *Uniter) getHookContext(hctxId string, relationId int, remoteUnitName st
 	// Make a copy of the proxy settings.
 	proxySettings := u.proxy
 	return NewHookContext(u.unit, hctxId, u.uuid, u.envName, relationId,
-		remoteUnitName, ctxRelations, apiAddrs, ownerTag, proxySettings,
-		actionParams)
+		remoteUnitName, ctxRelations, apiAddrs, ownerTag, u.charmPath,
+		filepath.Join(u.baseDir, "resources"), proxySettings, actionParams)
 }
 
 func (u *Uniter) acquireHookLock(message string) (err error) {
@@ -536,6 +542,22 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 	}
 	defer srv.Close()
 
+	var recorder *hookRecorder
+	if u.recordingHooks() && actionParamsErr == nil {
+		location := "hooks"
+		if hi.Kind == hooks.ActionRequested {
+			location = "actions"
+		}
+		r, err := hctx.newHookRecorder(hookName, location, u.charmPath, u.toolsDir, socketPath)
+		if err != nil {
+			// Failing to record a hook must not stop it running.
+			logger.Errorf("cannot record %q hook: %v", hookName, err)
+		} else {
+			recorder = r
+			srv.SetObserver(recorder.observe)
+		}
+	}
+
 	// Run the hook.
 	if err := u.writeState(RunHook, Pending, &hi, nil); err != nil {
 		return err
@@ -557,6 +579,11 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 	} else {
 		err = hctx.RunHook(hookName, u.charmPath, u.toolsDir, socketPath)
 	}
+	if recorder != nil && !IsMissingHookError(err) {
+		if err := u.saveHookRecording(recorder, err); err != nil {
+			logger.Errorf("cannot save recording of %q hook: %v", hookName, err)
+		}
+	}
 
 	// Since the Action validation error was separated, regular error pathways
 	// will still occur correctly.
@@ -827,6 +854,18 @@ func (u *Uniter) updatePackageProxy(cfg *config.Config) {
 	}
 }
 
+// updateRecordHooks updates whether hooks are recorded from the
+// environment.
+func (u *Uniter) updateRecordHooks(cfg *config.Config) {
+	u.recordHooksMutex.Lock()
+	defer u.recordHooksMutex.Unlock()
+
+	if recordHooks := cfg.RecordHooks(); u.recordHooks != recordHooks {
+		u.recordHooks = recordHooks
+		logger.Infof("hook recording enabled: %v", recordHooks)
+	}
+}
+
 // watchForProxyChanges kicks off a go routine to listen to the watcher and
 // update the proxy settings.
 func (u *Uniter) watchForProxyChanges(environWatcher apiwatcher.NotifyWatcher) {
@@ -845,6 +884,7 @@ func (u *Uniter) watchForProxyChanges(environWatcher apiwatcher.NotifyWatcher) {
 					logger.Errorf("cannot load environment configuration: %v", err)
 				} else {
 					u.updatePackageProxy(environConfig)
+					u.updateRecordHooks(environConfig)
 				}
 			}
 		}
@@ -43,6 +43,7 @@ import (
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/uniter"
 	"github.com/juju/juju/worker/uniter/charm"
+	"github.com/juju/juju/worker/uniter/replay"
 )
 
 // worstCase is used for timeouts when timing out
@@ -538,6 +539,41 @@ func (s *UniterSuite) TestUniterConfigChangedHook(c *gc.C) {
 	s.runUniterTests(c, configChangedHookTests)
 }
 
+var recordHooksTests = []uniterTest{
+	ut(
+		"hooks are recorded when enabled",
+		createCharm{
+			customize: func(c *gc.C, ctx *context, path string) {
+				appendHook(c, path, "config-changed", "config-get blog-title")
+			},
+		},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		verifyHookRecordings{},
+		setRecordHooks(true),
+		changeConfig{"blog-title": "Goodness Gracious Me"},
+		waitHooks{"config-changed"},
+		verifyHookRecordings{{
+			HookName: "config-changed",
+			Location: "hooks",
+			UnitName: "u/0",
+			Config:   corecharm.Settings{"blog-title": "Goodness Gracious Me"},
+			Calls: []replay.Call{{
+				Command: "config-get",
+				Args:    []string{"blog-title"},
+				Stdout:  "Goodness Gracious Me\n",
+			}},
+		}},
+	)}
+
+func (s *UniterSuite) TestUniterRecordHooks(c *gc.C) {
+	s.runUniterTests(c, recordHooksTests)
+}
+
 var hookSynchronizationTests = []uniterTest{
 	ut(
 		"verify config change hook not run while lock held",
@@ -1621,7 +1657,7 @@ func (s addCharm) step(c *gc.C, ctx *context) {
 	hurl, err := url.Parse(gitjujutesting.Server.URL + key)
 	c.Assert(err, gc.IsNil)
 	ctx.charms[key] = gitjujutesting.Response{200, nil, body}
-	ctx.sch, err = ctx.st.AddCharm(s.dir, s.curl, hurl, hash)
+	ctx.sch, err = ctx.st.AddCharm(s.dir, s.curl, hurl, hash, int64(len(body)))
 	c.Assert(err, gc.IsNil)
 }
 
@@ -2477,6 +2513,70 @@ func (s setProxySettings) step(c *gc.C, ctx *context) {
 	c.Fatal("settings didn't get noticed by the uniter")
 }
 
+type setRecordHooks bool
+
+func (s setRecordHooks) step(c *gc.C, ctx *context) {
+	attrs := map[string]interface{}{"record-hooks": bool(s)}
+	err := ctx.st.UpdateEnvironConfig(attrs, nil, nil)
+	c.Assert(err, gc.IsNil)
+	for attempt := coretesting.LongAttempt.Start(); attempt.Next(); {
+		if ctx.uniter.RecordingHooks() == bool(s) {
+			return
+		}
+	}
+	c.Fatal("setting didn't get noticed by the uniter")
+}
+
+// verifyHookRecordings checks the unit's hook recordings against the
+// expected ones, ignoring the recorded time, environment, addresses,
+// owner, ports and relations, and the juju-log calls made by all the
+// test hooks.
+type verifyHookRecordings []replay.Recording
+
+func (s verifyHookRecordings) step(c *gc.C, ctx *context) {
+	infos, err := ioutil.ReadDir(filepath.Join(ctx.path, "recordings"))
+	if os.IsNotExist(err) {
+		infos = nil
+	} else {
+		c.Assert(err, gc.IsNil)
+	}
+	c.Assert(infos, gc.HasLen, len(s))
+	if len(infos) > 0 {
+		dirInfo, err := os.Stat(filepath.Join(ctx.path, "recordings"))
+		c.Assert(err, gc.IsNil)
+		c.Assert(dirInfo.Mode().Perm(), gc.Equals, os.FileMode(0700))
+	}
+	for i, info := range infos {
+		c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0600))
+		f, err := os.Open(filepath.Join(ctx.path, "recordings", info.Name()))
+		c.Assert(err, gc.IsNil)
+		charmDir := c.MkDir()
+		rec, err := replay.ReadArchive(f, charmDir)
+		f.Close()
+		c.Assert(err, gc.IsNil)
+		_, err = os.Stat(filepath.Join(charmDir, "hooks", rec.HookName))
+		c.Assert(err, gc.IsNil)
+		c.Assert(rec.Env, gc.Not(gc.HasLen), 0)
+		expect := s[i]
+		expect.Time = rec.Time
+		expect.Env = rec.Env
+		expect.PublicAddress = rec.PublicAddress
+		expect.PrivateAddress = rec.PrivateAddress
+		expect.OwnerTag = rec.OwnerTag
+		expect.OpenedPorts = rec.OpenedPorts
+		expect.RelationId = -1
+		expect.Relations = rec.Relations
+		var calls []replay.Call
+		for _, call := range rec.Calls {
+			if call.Command != "juju-log" {
+				calls = append(calls, call)
+			}
+		}
+		rec.Calls = calls
+		c.Assert(*rec, jc.DeepEquals, expect)
+	}
+}
+
 type runCommands []string
 
 func (cmds runCommands) step(c *gc.C, ctx *context) {